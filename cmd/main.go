@@ -1,6 +1,8 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"hash/fnv"
 	"image/color"
@@ -19,13 +21,16 @@ import (
 	"tesselbox/pkg/audio"
 	"tesselbox/pkg/biomes"
 	"tesselbox/pkg/blocks"
+	"tesselbox/pkg/chat"
 	"tesselbox/pkg/chest"
 	"tesselbox/pkg/combat"
 	"tesselbox/pkg/config"
 	"tesselbox/pkg/crafting"
 	"tesselbox/pkg/debug"
 	"tesselbox/pkg/dimension"
-	"tesselbox/pkg/enemies"
+	"tesselbox/pkg/dungeons"
+	"tesselbox/pkg/enchant"
+	"tesselbox/pkg/entities"
 	"tesselbox/pkg/equipment"
 	"tesselbox/pkg/gametime"
 	"tesselbox/pkg/gui"
@@ -33,12 +38,18 @@ import (
 	"tesselbox/pkg/hexagon"
 	"tesselbox/pkg/input"
 	"tesselbox/pkg/items"
+	"tesselbox/pkg/mobs"
+	"tesselbox/pkg/network"
+	"tesselbox/pkg/organisms"
 	"tesselbox/pkg/player"
 	"tesselbox/pkg/plugins"
+	"tesselbox/pkg/quests"
 	"tesselbox/pkg/save"
 	"tesselbox/pkg/skin"
+	"tesselbox/pkg/station"
 	"tesselbox/pkg/survival"
 	"tesselbox/pkg/ui"
+	"tesselbox/pkg/village"
 	"tesselbox/pkg/weather"
 	"tesselbox/pkg/world"
 
@@ -153,15 +164,25 @@ const (
 	ScreenWidth  = 1280
 	ScreenHeight = 720
 	FPS          = 60
+
+	// Darkest a lit-by-nothing block is drawn
+	minBlockBrightness = 0.12
 )
 
 // DroppedItem represents an item that has been dropped in the world
 type DroppedItem struct {
 	Type     items.ItemType
 	Quantity int
+	Meta     *items.Metadata // Custom name, enchantments and so on
 	X, Y     float64
 	VX, VY   float64 // Velocity for physics
 	Lifetime time.Time
+
+	// A plugin vetoed the last pickup; don't ask again before this
+	PickupRetry time.Time
+
+	// ID of the server's item in multiplayer; 0 for items only shown here
+	NetID uint64
 }
 
 // Game represents the game state
@@ -174,13 +195,17 @@ type Game struct {
 
 	// Systems
 	craftingSystem  *crafting.CraftingSystem
-	craftingUI      *crafting.CraftingUI
+	craftingUI      *ui.CraftingUI
 	pluginManager   *plugins.PluginManager
 	pluginUI        *plugins.PluginUI
 	pluginInstaller *plugins.PluginInstaller
 	skinEditor      *skin.SkinEditor
 	inputManager    *input.InputManager
 
+	// Script plugins and the event bus they use to hook into gameplay
+	eventBus      *entities.EventBus
+	scriptPlugins *entities.PluginManager
+
 	// Save system
 	saveManager *save.SaveManager
 	autoSaver   *save.AutoSaver
@@ -213,6 +238,9 @@ type Game struct {
 	indicesPool [][]uint16
 	poolIndex   int
 
+	// GPU copies of block textures, uploaded on first use
+	blockTextures map[blocks.BlockType]*ebiten.Image
+
 	// Camera
 	cameraX, cameraY float64
 
@@ -223,8 +251,9 @@ type Game struct {
 	rightMouseWasPressed bool
 
 	// Game state (using StateManager)
-	stateManager *ui.StateManager
-	CreativeMode bool
+	stateManager  *ui.StateManager
+	previousState ui.GameState // State in the last update, to notice UIs closing
+	CreativeMode  bool
 
 	// Command system
 	commandMode   bool
@@ -261,9 +290,17 @@ type Game struct {
 	// Chest system
 	chestManager *chest.ChestManager
 	chestUI      *ui.ChestUI
+	anvilUI      *ui.AnvilUI
+	furnaceUI    *ui.FurnaceUI
+
+	// Villages and dungeons found in generated terrain
+	villageManager *village.VillageManager
+	questManager   *quests.QuestManager
+	dungeonManager *dungeons.DungeonManager
 
 	// Combat system
-	weaponSystem *combat.WeaponSystem
+	weaponSystem     *combat.WeaponSystem
+	projectileSystem *combat.ProjectileSystem
 
 	// Damage indicators
 	damageIndicators  *ui.DamageIndicatorManager
@@ -278,7 +315,7 @@ type Game struct {
 	loadingScreen *ui.LoadingScreen
 
 	// Enemy systems
-	zombieSpawner *enemies.ZombieSpawner
+	zombieSpawner *mobs.Spawner
 
 	// Layer system (surface=0, middle=1, back=2)
 	currentLayer int
@@ -286,6 +323,27 @@ type Game struct {
 
 	// Dimension system
 	dimensionManager *dimension.Manager
+
+	// Multiplayer client state (nil client when playing locally)
+	netClient     *network.Client
+	remotePlayers map[string]network.EntityState
+	chatLog       []string
+	lastNetMove   time.Time
+
+	// The inventory, and the contents of the open chest or furnace, as the
+	// server last agreed they are. Local changes are sent as inventory syncs.
+	netInventory []items.Item
+	netOpen      *network.Container // Kind and position of the open container
+	netContents  []items.Item       // nil until the server has sent them
+	netDrops     []items.Item       // Stacks dropped since the last sync
+
+	// Newest inventory and container from the server, waiting for the
+	// player to let go of a dragged item
+	pendingInventory *network.Inventory
+	pendingContainer *network.Container
+
+	// Armor last reported to the server, which reduces hits by its defense
+	netArmor []byte
 }
 
 // NewGame creates a new game with default world
@@ -298,8 +356,23 @@ func NewGameWithWorld(worldName string, worldSeed int64) *Game {
 	// Use shared white image singleton for better resource management
 	whiteImage := getSharedWhiteImage()
 
+	// Reopen an existing world with its saved seed so unsaved chunks regenerate identically
+	var gameWorld *world.World
+	if world.WorldExists(worldName) {
+		loaded, err := world.NewWorldFromStorage(worldName)
+		if err != nil {
+			log.Printf("Warning: Failed to load world '%s' metadata: %v", worldName, err)
+		} else {
+			gameWorld = loaded
+			worldSeed = 0 // Saved seed takes precedence
+		}
+	}
+	if gameWorld == nil {
+		gameWorld = world.NewWorld(worldName) // Create world with name
+	}
+
 	g := &Game{
-		world:                  world.NewWorld(worldName), // Create world with name
+		world:                  gameWorld,
 		player:                 player.NewPlayer(400, 300),
 		inventory:              items.NewInventory(32),
 		selectedBlock:          "dirt",
@@ -323,7 +396,7 @@ func NewGameWithWorld(worldName string, worldSeed int64) *Game {
 		g.world.SetSeed(worldSeed)
 		log.Printf("World '%s' created with seed: %d", worldName, worldSeed)
 	} else {
-		log.Printf("World '%s' created with random seed: %d", worldName, g.world.GetSeed())
+		log.Printf("World '%s' using seed: %d", worldName, g.world.GetSeed())
 	}
 
 	// Initialize object pools for rendering optimization
@@ -363,9 +436,14 @@ func NewGameWithWorld(worldName string, worldSeed int64) *Game {
 	}
 	g.craftingSystem.OnItemCrafted = func(recipeID string) {
 		g.ItemsCrafted++
-		g.UnlockedRecipes[recipeID] = true
+		g.sendCraft(recipeID)
 	}
-	g.craftingUI = crafting.NewCraftingUI(g.craftingSystem, g.inventory)
+	g.craftingSystem.OnRecipeUnlocked = func(recipe *crafting.Recipe) {
+		g.UnlockedRecipes[recipe.ID] = true
+		g.addChatLine("Learned recipe: " + recipe.Name)
+	}
+	g.craftingUI = ui.NewCraftingUI(g.craftingSystem, g.inventory)
+	g.craftingUI.CreativeMode = g.CreativeMode
 
 	// Initialize input manager
 	g.inputManager = input.NewInputManager()
@@ -388,6 +466,7 @@ func NewGameWithWorld(worldName string, worldSeed int64) *Game {
 	defaultPlugin := plugins.NewDefaultPlugin()
 	g.pluginManager.RegisterPlugin(defaultPlugin)
 	g.pluginManager.EnablePlugin("default")
+	g.initScriptPlugins()
 
 	// Initialize skin editor
 	g.skinEditor = skin.NewSkinEditor()
@@ -474,10 +553,31 @@ func NewGameWithWorld(worldName string, worldSeed int64) *Game {
 	g.backpackUI.SetSelectedSlot(g.player.GetSelectedSlot())
 
 	// Create zombie spawner
-	g.zombieSpawner = enemies.NewZombieSpawner(g.dayNightCycle)
+	g.zombieSpawner = mobs.NewSpawner(g.dayNightCycle)
+	g.zombieSpawner.LightAt = func(x, y, ambientLight float64) float64 {
+		return g.world.LightLevelAt(x, y, ambientLight)
+	}
+	g.zombieSpawner.Climbable = func(minX, minY, maxX, maxY float64) bool {
+		return g.world.BoxClimbable(minX, minY, maxX, maxY)
+	}
+	g.zombieSpawner.Navigator = mobs.NavigatorFunc(func(m *mobs.Mob, goalX, goalY float64) (float64, float64, bool) {
+		return g.world.Paths.Waypoint(m, goalX, goalY)
+	})
 
 	// Set up damage callback for zombie attacks
-	g.zombieSpawner.OnPlayerDamage = func(damage float64, zombieX, zombieY float64) {
+	g.zombieSpawner.OnPlayerDamage = func(m *mobs.Mob, damage float64) {
+		zombieX, zombieY := m.X, m.Y
+
+		// Armor, and its enchantments, soak up part of the hit
+		damage *= 1 - g.equipmentSet.GetDamageReduction()
+
+		// Plugins may cancel the hit or change its damage
+		hit := entities.CreateCombatEvent(m.ID, g.playerID(), damage, m.Def.ID, false, false)
+		if !g.publishPre(entities.EventDamage, &hit) {
+			return
+		}
+		damage = hit.Damage
+
 		// Apply damage to player health system
 		if g.healthSystem != nil {
 			// Determine which body part to damage based on zombie position
@@ -508,6 +608,12 @@ func NewGameWithWorld(worldName string, worldSeed int64) *Game {
 		// Also apply damage to simple health for backward compatibility
 		if g.player != nil {
 			g.player.TakeDamage(damage)
+			hit.Killed = g.player.Health <= 0
+		}
+
+		g.publishPost(entities.EventDamage, hit)
+		if hit.Killed {
+			g.publishPost(entities.EventDeath, entities.CreateDeathEvent(hit.TargetID, "Killed by "+m.Def.Name, hit.AttackerID))
 		}
 
 		// Trigger screen flash
@@ -565,12 +671,44 @@ func NewGameWithWorld(worldName string, worldSeed int64) *Game {
 
 	// Create combat system
 	g.weaponSystem = combat.NewWeaponSystem()
+	g.projectileSystem = combat.NewProjectileSystem()
 	// Load existing chests
 	if err := g.chestManager.LoadChests(); err != nil {
 		log.Printf("Failed to load chests: %v", err)
 	}
 	g.chestUI = ui.NewChestUI(ScreenWidth, ScreenHeight, g.chestManager, g.inventory)
 
+	// Anvils enchant, combine and repair; their recipes are a key away
+	g.anvilUI = ui.NewAnvilUI(ScreenWidth, ScreenHeight, g.inventory, g.equipmentSet, g.player)
+	g.furnaceUI = ui.NewFurnaceUI(ScreenWidth, ScreenHeight, g.inventory)
+	g.furnaceUI.OnDrop = g.dropStack
+	g.anvilUI.OnWork = g.sendAnvilWork
+
+	g.anvilUI.OnOpenRecipes = func() {
+		g.anvilUI.Close()
+		g.craftingUI.SetStation(crafting.STATION_ANVIL)
+		g.stateManager.SetState(ui.StateCrafting)
+		g.craftingUI.Toggle()
+	}
+
+	// Create village and dungeon managers for generated structures
+	g.villageManager = village.NewVillageManager(config.GetWorldSaveDir(worldName))
+	if err := g.villageManager.Load(); err != nil {
+		log.Printf("Failed to load villages: %v", err)
+	}
+	g.dungeonManager = dungeons.NewDungeonManager()
+
+	// Create the quest manager; new players start on the tutorial quest
+	g.questManager = quests.NewQuestManager(config.GetWorldSaveDir(worldName))
+	if err := g.questManager.Load(); err != nil {
+		log.Printf("Failed to load quests: %v", err)
+	}
+	if _, started := g.questManager.GetQuestStatus(questPlayer, tutorialQuest); !started {
+		if _, err := g.questManager.AcceptQuest(questPlayer, tutorialQuest); err != nil {
+			log.Printf("Failed to start the tutorial quest: %v", err)
+		}
+	}
+
 	// Create damage indicators
 	g.damageIndicators = ui.NewDamageIndicatorManager(ScreenWidth, ScreenHeight)
 	g.screenFlash = ui.NewScreenFlash()
@@ -623,9 +761,31 @@ func (g *Game) Update() error {
 	// Update play time statistics
 	g.TotalPlayTime += time.Duration(deltaTime * float64(time.Second))
 
+	// Load and unload the plugins background installs are waiting on
+	if g.pluginInstaller != nil {
+		g.pluginInstaller.RunPending()
+	}
+
 	// Use StateManager for modal handling
 	state := g.stateManager.GetState()
 
+	// Closing a UI may have moved items into the inventory
+	if state == ui.StateGame && g.previousState != ui.StateGame {
+		g.learnRecipes()
+	}
+	g.previousState = state
+
+	if g.netClient != nil {
+		if g.netOpen != nil && state != ui.StateChest && state != ui.StateFurnace {
+			g.closeNetContainer()
+		}
+		// Menus don't pause a multiplayer game; the game state exchanges
+		// state with the server further down
+		if state != ui.StateGame {
+			g.updateNetwork()
+		}
+	}
+
 	// Handle crafting UI
 	if state == ui.StateCrafting {
 		if err := g.craftingUI.Update(); err != nil {
@@ -660,6 +820,40 @@ func (g *Game) Update() error {
 
 		// Handle escape to close chest
 		if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+			g.chestUI.Close()
+			g.stateManager.SetState(ui.StateGame)
+		}
+		return nil
+	}
+
+	// Handle furnace UI
+	if state == ui.StateFurnace {
+		// The open furnace keeps smelting while the player watches; in
+		// multiplayer the server runs it and sends its progress
+		if g.netClient == nil {
+			g.world.UpdateStations(deltaTime)
+		}
+		if err := g.furnaceUI.Update(); err != nil {
+			return err
+		}
+
+		// Handle escape to close furnace
+		if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+			g.furnaceUI.Close()
+			g.stateManager.SetState(ui.StateGame)
+		}
+		return nil
+	}
+
+	// Handle anvil UI
+	if state == ui.StateAnvil {
+		if err := g.anvilUI.Update(); err != nil {
+			return err
+		}
+
+		// Handle escape to close anvil
+		if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+			g.anvilUI.Close()
 			g.stateManager.SetState(ui.StateGame)
 		}
 		return nil
@@ -771,12 +965,47 @@ func (g *Game) Update() error {
 		zombieSpawnFunc := func(x, y float64) (float64, float64) {
 			return g.world.FindSpawnPosition(x, y)
 		}
-		// Only update overworld zombies when in overworld (not in Randomland)
-		if g.dimensionManager == nil || !g.dimensionManager.IsInRandomland() {
+		// Only update overworld zombies when in overworld (not in Randomland);
+		// in multiplayer the server runs them
+		if g.netClient == nil && (g.dimensionManager == nil || !g.dimensionManager.IsInRandomland()) {
 			g.zombieSpawner.Update(deltaTime, g.player, ambientLight, zombieCollisionFunc, zombieSpawnFunc)
-		}
 
-		// Update weather system
+			// Creatures spawn from the biome's tables and share the zombies'
+			// collision and damage handling
+			playerCenterX, playerCenterY := g.player.GetCenter()
+			g.world.SpawnCreatures(g.dayNightCycle, playerCenterX, playerCenterY)
+			g.world.UpdateCreatures(&mobs.Context{
+				Target:       g.player,
+				AmbientLight: ambientLight,
+				Collides:     zombieCollisionFunc,
+				Climbable:    g.world.BoxClimbable,
+				Navigator:    g.world.Paths,
+				OnAttack:     g.zombieSpawner.OnPlayerDamage,
+			}, deltaTime)
+			g.world.RemoveDeadCreatures()
+
+			// Plan the routes mobs asked for, within the per-update budget
+			g.world.Paths.Update(deltaTime)
+		}
+
+		// Flow water and lava and drop unsupported blocks; in multiplayer
+		// the server runs them
+		if g.netClient == nil {
+			g.world.UpdateBlocks(deltaTime)
+			g.dropBrokenFallingBlocks()
+			g.world.UpdateLiquids(deltaTime)
+		}
+		g.linkGeneratedStructures()
+
+		// Run furnaces and drop what was in broken ones; in multiplayer the
+		// server does both
+		if g.netClient == nil {
+			g.world.UpdateStations(deltaTime)
+		}
+		g.dropRemovedStations()
+
+		// Update weather system over the player's biome
+		g.weatherSystem.SetBiome(g.world.BiomeAt(g.player.X, g.player.Y))
 		g.weatherSystem.Update(deltaTime, ScreenWidth, ScreenHeight)
 
 		// Update audio system (clean up finished sounds)
@@ -791,6 +1020,9 @@ func (g *Game) Update() error {
 		// Update dropped items physics
 		g.updateDroppedItems(deltaTime)
 
+		// Move arrows, thrown items and spells and apply what they hit
+		g.updateProjectiles(deltaTime)
+
 		// Apply collision-aware position update using nearbyHexagons already fetched above
 		g.player.UpdateWithCollision(deltaTime, func(minX, minY, maxX, maxY float64) bool {
 			for _, hex := range nearbyHexagons {
@@ -817,6 +1049,11 @@ func (g *Game) Update() error {
 			return false
 		})
 
+		// Exchange state with the multiplayer server
+		if g.netClient != nil {
+			g.updateNetwork()
+		}
+
 		// Update dimension system (zombie updates in randomland)
 		if g.dimensionManager != nil {
 			g.dimensionManager.Update(g.player, deltaTime)
@@ -937,6 +1174,11 @@ func (g *Game) handleGameInput() {
 		g.dropItem()
 	}
 
+	// Throw item
+	if g.inputManager.IsActionJustPressed("throw") {
+		g.throwSelectedItem()
+	}
+
 	// Load game (F9)
 	if inpututil.IsKeyJustPressed(ebiten.KeyF9) {
 		if err := g.LoadGame(); err != nil {
@@ -999,9 +1241,14 @@ func (g *Game) handleGameInput() {
 
 	// Track previous state to detect "just pressed"
 	if !g.leftMouseWasPressed && staticLeftPressed {
-		// Left mouse just pressed - start mining and weapon attack
-		g.startMining()
-		g.performWeaponAttack()
+		// Left mouse just pressed - bows and wands fire, anything else
+		// mines and swings
+		if g.selectedWeaponFires() {
+			g.fireSelectedWeapon()
+		} else {
+			g.startMining()
+			g.performWeaponAttack()
+		}
 	}
 	if g.leftMouseWasPressed && !staticLeftPressed {
 		// Left mouse just released - stop mining
@@ -1179,7 +1426,19 @@ func (g *Game) executeCommand(command string) {
 
 	switch cmd {
 	case "help":
-		log.Printf("Available commands: help, give, creative, survival, tp, plugin list, plugin load, plugin unload, plugin reload")
+		log.Printf("Available commands: help, give, creative, survival, tp, say, plugin list, plugin load, plugin unload, plugin reload")
+	case "say":
+		if g.netClient == nil {
+			log.Printf("Chat is only available in multiplayer")
+			return
+		}
+		if len(args) == 0 {
+			log.Printf("Usage: /say <message>")
+			return
+		}
+		if err := g.netClient.SendChat(strings.Join(args, " ")); err != nil {
+			log.Printf("Failed to send chat: %v", err)
+		}
 	case "give":
 		if len(args) < 2 {
 			log.Printf("Usage: /give <item_type> <quantity>")
@@ -1218,11 +1477,17 @@ func (g *Game) executeCommand(command string) {
 		}
 	case "creative":
 		g.CreativeMode = true
+		g.craftingUI.CreativeMode = true
 		log.Printf("Switched to creative mode")
 	case "survival":
 		g.CreativeMode = false
+		g.craftingUI.CreativeMode = false
 		log.Printf("Switched to survival mode")
 	case "tp":
+		if g.netClient != nil {
+			log.Printf("Teleporting is not allowed on a multiplayer server")
+			return
+		}
 		if len(args) < 2 {
 			log.Printf("Usage: /tp <x> <y>")
 			return
@@ -1292,9 +1557,17 @@ func (g *Game) dropItem() {
 	if selectedItem == nil || selectedItem.Type == items.NONE {
 		return // Nothing to drop
 	}
+	dropped := *selectedItem // The slot is cleared when its last item goes
 
 	// Remove one item from the selected slot
 	if g.inventory.RemoveItem(1) {
+		// A multiplayer server drops it where everyone sees it
+		if g.netClient != nil {
+			dropped.Quantity = 1
+			g.netDrops = append(g.netDrops, dropped)
+			return
+		}
+
 		// Get player position to drop item in front of player
 		playerX, playerY := g.player.GetCenter()
 
@@ -1308,8 +1581,9 @@ func (g *Game) dropItem() {
 
 		// Create dropped item entity
 		droppedItem := &DroppedItem{
-			Type:     selectedItem.Type,
+			Type:     dropped.Type,
 			Quantity: 1,
+			Meta:     dropped.Meta,
 			X:        dropX,
 			Y:        dropY,
 			VX:       vx,
@@ -1343,11 +1617,17 @@ func (g *Game) interactWithStation() {
 			station = crafting.STATION_WORKBENCH
 			g.CurrentCraftingStation = "workbench"
 		case "furnace":
-			station = crafting.STATION_FURNACE
-			g.CurrentCraftingStation = "furnace"
+			// Furnaces smelt on their own; open the one found
+			if g.openStation(hex.X, hex.Y) {
+				return
+			}
+			continue
 		case "anvil":
-			station = crafting.STATION_ANVIL
+			// Anvils open for enchanting first
 			g.CurrentCraftingStation = "anvil"
+			g.anvilUI.OpenAnvil()
+			g.stateManager.SetState(ui.StateAnvil)
+			return
 		default:
 			continue
 		}
@@ -1444,27 +1724,50 @@ func (g *Game) updateMining(deltaTime float64) {
 func (g *Game) completeMining(targetHex *world.Hexagon) {
 	// Get the block type before removing
 	blockType := targetHex.BlockType
+	x, y := targetHex.X, targetHex.Y
+
+	// Plugins may cancel the break or change what it drops
+	breakEvent := entities.CreateBlockEvent(blocks.BlockID(blockType), x, y, float64(g.currentLayer), "player", g.selectedItemID())
+	if minedItemType := items.BlockDrop(blockType); minedItemType != items.NONE {
+		breakEvent.Drop = items.ItemID(minedItemType)
+		breakEvent.DropQuantity = 1
+	}
+	if !g.publishPre(entities.EventBlockBroken, &breakEvent) {
+		targetHex.Health = targetHex.MaxHealth
+		return
+	}
 
 	// Play mining complete sound
 	g.playBlockSound("break", blockType)
 
 	// Use the exact hexagon coordinates for removal
-	x, y := targetHex.X, targetHex.Y
 	g.world.RemoveHexagonAt(x, y)
+	g.sendBlockChange(x, y, blocks.AIR)
 
 	// Use item durability
-	g.inventory.UseItem()
+	// Use item durability; a multiplayer server wears the tool in its own copy
+	if g.netClient == nil {
+		g.inventory.UseItem()
+	}
 
 	// Drop mined item as floating item (like Minecraft) instead of adding directly to inventory
-	minedItemType := g.getItemFromBlockType(blockType)
-	if minedItemType != items.NONE {
+	minedItemType, dropQuantity := items.NONE, breakEvent.DropQuantity
+	if breakEvent.Drop != "" && dropQuantity > 0 {
+		if itemType, ok := items.ItemTypeByID(breakEvent.Drop); ok {
+			minedItemType = itemType
+		} else {
+			log.Printf("Unknown drop %q for broken block", breakEvent.Drop)
+		}
+	}
+	// A multiplayer server puts the drop straight into the inventory it syncs
+	if minedItemType != items.NONE && g.netClient == nil {
 		// Spawn floating item at the mined block position with slight random velocity
 		vx := float64(rand.Intn(60)-30) / 10.0   // Random horizontal velocity: -3.0 to 3.0
 		vy := -3.0 - float64(rand.Intn(20))/10.0 // Upward velocity with variation: -3.0 to -5.0
 
 		droppedItem := &DroppedItem{
 			Type:     minedItemType,
-			Quantity: 1,
+			Quantity: dropQuantity,
 			X:        x,
 			Y:        y - 10, // Slightly above the block center
 			VX:       vx,
@@ -1475,99 +1778,31 @@ func (g *Game) completeMining(targetHex *world.Hexagon) {
 		g.droppedItems = append(g.droppedItems, droppedItem)
 	}
 
-	// Trigger gravity fall
-	q, r := hexagon.PixelToHex(x, y, world.HexSize)
-	hex := hexagon.HexRound(q, r)
-	g.fallBlocks(hex.Q, hex.R)
+	g.publishPost(entities.EventBlockBroken, breakEvent)
+	g.advanceQuests("break", blocks.BlockID(blockType))
 }
 
-// fallBlocks makes blocks above the destroyed position fall if they have gravity
-func (g *Game) fallBlocks(q, r int) {
-	currentR := r + 1
-	for {
-		// Get pixel position for this hex
-		h := hexagon.Hexagon{Q: q, R: currentR}
-		x, y := hexagon.HexToPixel(h, world.HexSize)
-
-		// Check if there's a block here
-		block := g.world.GetHexagonAt(x, y)
-		if block == nil || block.BlockType == blocks.AIR {
-			break
-		}
-
-		// Check if it has gravity
-		props := blocks.BlockDefinitions[getBlockKeyFromType(block.BlockType)]
-		if props == nil || !props.Gravity {
-			break
-		}
-
-		// Move it down
-		newH := hexagon.Hexagon{Q: q, R: currentR - 1}
-		newX, newY := hexagon.HexToPixel(newH, world.HexSize)
-
-		g.world.RemoveHexagonAt(x, y)
-		g.world.AddHexagonAt(newX, newY, block.BlockType)
-
-		currentR++
-	}
-}
-
-// handleMining handles block mining
-func (g *Game) handleMining() {
+// handleBlockPlacement handles block placement
+func (g *Game) handleBlockPlacement() {
 	// Convert mouse position to world coordinates
 	mouseWorldX := float64(g.mouseX) + g.cameraX
 	mouseWorldY := float64(g.mouseY) + g.cameraY
 
-	// Find the hexagon at mouse position directly using world coordinates
-	targetHex := g.world.GetHexagonAt(mouseWorldX, mouseWorldY)
-	if targetHex == nil {
-		return
+	// Opening a chest or station comes before using the held item, so
+	// clicking one with food in hand doesn't eat it
+	if g.handleChestInteraction(mouseWorldX, mouseWorldY) {
+		return // Opened chest, don't place block
 	}
 
-	// Check if player can reach the block
-	if !g.player.CanReach(targetHex.X, targetHex.Y) {
+	// Check if clicking on a station block such as a furnace
+	if hex := g.world.GetHexagonAt(mouseWorldX, mouseWorldY); hex != nil && g.player.CanReach(hex.X, hex.Y) && g.openStation(hex.X, hex.Y) {
 		return
 	}
 
-	// Calculate mining damage based on tool and block hardness
-	damage := g.calculateMiningDamage(targetHex.BlockType)
-
-	// Damage the block
-	targetHex.TakeDamage(damage)
-
-	// Check if block is destroyed
-	if targetHex.Health <= 0 {
-		// Get the block type before removing
-		blockType := targetHex.BlockType
-
-		// Track statistics
-		g.BlocksDestroyed++
-
-		// Get the exact world position before removing
-		x, y := targetHex.X, targetHex.Y
-		g.world.RemoveHexagonAt(x, y)
-
-		// Use item durability
-		g.inventory.UseItem()
-
-		// Add mined item to inventory
-		minedItemType := g.getItemFromBlockType(blockType)
-		if minedItemType != items.NONE {
-			if !g.inventory.AddItem(minedItemType, 1) {
-				// Inventory full - could implement dropping item here
-				log.Printf("Inventory full, cannot pick up %v", minedItemType)
-			}
-		}
-
-		// Trigger gravity fall
-		q, r := hexagon.PixelToHex(x, y, world.HexSize)
-		hex := hexagon.HexRound(q, r)
-		g.fallBlocks(hex.Q, hex.R)
+	if g.useSelectedItem() {
+		return // Ate, drank or filled a bottle instead
 	}
-}
 
-// handleBlockPlacement handles block placement
-func (g *Game) handleBlockPlacement() {
 	var blockTypeToPlace string
 
 	if g.CreativeMode && g.selectedBlock != "" {
@@ -1596,15 +1831,6 @@ func (g *Game) handleBlockPlacement() {
 		return
 	}
 
-	// Convert mouse position to world coordinates
-	mouseWorldX := float64(g.mouseX) + g.cameraX
-	mouseWorldY := float64(g.mouseY) + g.cameraY
-
-	// Check if clicking on an existing chest block
-	if g.handleChestInteraction(mouseWorldX, mouseWorldY) {
-		return // Opened chest, don't place block
-	}
-
 	// Find which hexagon the mouse is over using the same system as world generation
 	// Convert world coordinates to local chunk coordinates
 	chunkX, chunkY := g.world.GetChunkCoords(mouseWorldX, mouseWorldY)
@@ -1644,9 +1870,24 @@ func (g *Game) handleBlockPlacement() {
 		return // Too far from player
 	}
 
-	// Place block at the calculated position
+	// Plugins may cancel the placement or swap the block being placed
 	blockType := stringToBlockType(blockTypeToPlace)
+	placeEvent := entities.CreateBlockEvent(blocks.BlockID(blockType), placeX, placeY, float64(g.currentLayer), "player", g.selectedItemID())
+	if !g.publishPre(entities.EventBlockPlaced, &placeEvent) {
+		return
+	}
+	if placeEvent.BlockType != blocks.BlockID(blockType) {
+		replacement, ok := blocks.BlockTypeByID(placeEvent.BlockType)
+		if !ok {
+			log.Printf("Unknown block %q for placement", placeEvent.BlockType)
+			return
+		}
+		blockType = replacement
+	}
+
+	// Place block at the calculated position
 	g.world.AddHexagonAt(placeX, placeY, blockType)
+	g.sendBlockChange(placeX, placeY, blockType)
 
 	// Track statistics
 	g.BlocksPlaced++
@@ -1658,6 +1899,165 @@ func (g *Game) handleBlockPlacement() {
 	if !g.CreativeMode {
 		g.inventory.RemoveItem(1)
 	}
+
+	g.publishPost(entities.EventBlockPlaced, placeEvent)
+	g.advanceQuests("place", blocks.BlockID(blockType))
+}
+
+// openStation opens the UI of the station block at a position, reporting
+// whether there was one
+func (g *Game) openStation(x, y float64) bool {
+	s := g.world.StationAt(x, y)
+	if s == nil {
+		return false
+	}
+	switch s.Kind {
+	case station.KindFurnace:
+		g.CurrentCraftingStation = "furnace"
+		g.furnaceUI.OpenFurnace(s)
+		g.stateManager.SetState(ui.StateFurnace)
+		g.openNetContainer(s.Kind, s.X, s.Y)
+		g.playUISound("open")
+		return true
+	}
+	return false
+}
+
+// dropRemovedStations drops the contents of stations whose blocks were
+// removed, closing the UI of the open one. A multiplayer server drops them
+// for everyone.
+func (g *Game) dropRemovedStations() {
+	for _, s := range g.world.TakeRemovedStations() {
+		if g.furnaceUI.Station == s {
+			g.furnaceUI.Close()
+			g.stateManager.SetState(ui.StateGame)
+		}
+		if g.netClient != nil {
+			continue
+		}
+		for _, item := range s.Contents() {
+			g.droppedItems = append(g.droppedItems, &DroppedItem{
+				Type:     item.Type,
+				Quantity: item.Quantity,
+				Meta:     item.Meta,
+				X:        s.X,
+				Y:        s.Y - 10,
+				VX:       float64(rand.Intn(60)-30) / 10.0,
+				VY:       -3.0,
+				Lifetime: time.Now().Add(5 * time.Minute), // Items disappear after 5 minutes
+			})
+		}
+	}
+}
+
+// dropBrokenFallingBlocks drops falling blocks that had nowhere to land as
+// floating items
+func (g *Game) dropBrokenFallingBlocks() {
+	for _, fb := range g.world.TakeBrokenFallingBlocks() {
+		itemType := items.BlockDrop(fb.BlockType)
+		if itemType == items.NONE {
+			continue
+		}
+		g.droppedItems = append(g.droppedItems, &DroppedItem{
+			Type:     itemType,
+			Quantity: 1,
+			X:        fb.X,
+			Y:        fb.Y - 10,
+			VX:       float64(rand.Intn(60)-30) / 10.0,
+			VY:       -3.0,
+			Lifetime: time.Now().Add(5 * time.Minute), // Items disappear after 5 minutes
+		})
+	}
+}
+
+// useSelectedItem eats or drinks the selected item, fills a bottle at the
+// water under the mouse, or drinks from it with an empty hand. Returns true
+// if the right click was used up.
+func (g *Game) useSelectedItem() bool {
+	if g.survivalManager == nil {
+		return false
+	}
+	selectedItem := g.inventory.GetSelectedItem()
+	if selectedItem == nil {
+		return false
+	}
+
+	mouseWorldX := float64(g.mouseX) + g.cameraX
+	mouseWorldY := float64(g.mouseY) + g.cameraY
+	hex := g.world.GetHexagonAt(mouseWorldX, mouseWorldY)
+	atWater := hex != nil && hex.BlockType == blocks.WATER && g.player.CanReach(hex.X, hex.Y)
+
+	switch {
+	case selectedItem.Type == items.BOTTLE && atWater:
+		if g.netClient != nil {
+			g.sendUseItem(mouseWorldX, mouseWorldY)
+			return true
+		}
+		if g.inventory.RemoveItem(1) {
+			g.giveItem(items.WATER_BOTTLE)
+		}
+		return true
+	case selectedItem.Type == items.NONE && atWater:
+		if !g.survivalManager.DrinkWater() {
+			return false
+		}
+		g.sendUseItem(mouseWorldX, mouseWorldY)
+		return true
+	case selectedItem.Type == items.NONE:
+		return false
+	}
+
+	itemType := selectedItem.Type
+	if !g.survivalManager.Consume(itemType) {
+		return false
+	}
+	// A multiplayer server takes the item and gives back what it leaves
+	if g.netClient != nil {
+		g.sendUseItem(mouseWorldX, mouseWorldY)
+		return true
+	}
+	g.inventory.UseItem()
+	if leftover, ok := items.ItemTypeByID(items.GetItemProperties(itemType).Leaves); ok {
+		g.giveItem(leftover)
+	}
+	return true
+}
+
+// learnRecipes teaches the player the recipes unlocked by the items and
+// books they hold. It runs when the inventory gains items rather than every
+// frame.
+func (g *Game) learnRecipes() {
+	if g.craftingSystem != nil {
+		g.craftingSystem.UnlockFromInventory(g.inventory)
+	}
+}
+
+// giveItem puts one item in the inventory, or drops it at the player when
+// there is no room
+func (g *Game) giveItem(itemType items.ItemType) {
+	if g.inventory.AddItem(itemType, 1) {
+		g.learnRecipes()
+		return
+	}
+	g.dropStack(items.Item{Type: itemType, Quantity: 1})
+}
+
+// dropStack drops a stack into the world at the player
+func (g *Game) dropStack(item items.Item) {
+	if g.netClient != nil {
+		g.netDrops = append(g.netDrops, item)
+		return
+	}
+	playerX, playerY := g.player.GetCenter()
+	g.droppedItems = append(g.droppedItems, &DroppedItem{
+		Type:     item.Type,
+		Quantity: item.Quantity,
+		Meta:     item.Meta,
+		X:        playerX,
+		Y:        playerY - 10,
+		VY:       -2.0,
+		Lifetime: time.Now().Add(5 * time.Minute), // Items disappear after 5 minutes
+	})
 }
 
 // handleChestInteraction checks if player clicked on a chest and opens it
@@ -1703,6 +2103,8 @@ func (g *Game) handleChestInteraction(mouseWorldX, mouseWorldY float64) bool {
 		// Open the chest UI
 		if g.chestUI != nil && g.chestManager != nil {
 			g.chestUI.OpenChest(blockX, blockY)
+			g.stateManager.SetState(ui.StateChest)
+			g.openNetContainer(network.ContainerChest, blockX, blockY)
 			return true
 		}
 	}
@@ -1831,13 +2233,41 @@ func (g *Game) updateDroppedItems(deltaTime float64) {
 		// Check for player pickup (proximity check)
 		playerX, playerY := g.player.GetCenter()
 		distance := math.Sqrt((item.X-playerX)*(item.X-playerX) + (item.Y-playerY)*(item.Y-playerY))
-		if distance < 30.0 { // Pickup range
-			// Try to add to inventory
-			if g.inventory.AddItem(item.Type, item.Quantity) {
+		if distance < 30.0 && time.Now().After(item.PickupRetry) { // Pickup range
+			// Plugins may cancel the pickup or change what is picked up
+			pickup := entities.CreateItemEvent(items.ItemID(item.Type), item.Quantity, "player", "", true)
+			if !g.publishPre(entities.EventItemPickup, &pickup) {
+				item.PickupRetry = time.Now().Add(time.Second)
+				continue
+			}
+			// A multiplayer server hands out its own items, as they are
+			if g.netClient != nil {
+				g.sendPickUp(item)
+				continue
+			}
+			pickedType, ok := items.ItemTypeByID(pickup.ItemType)
+			if !ok || pickup.Quantity <= 0 {
+				// Nothing left to pick up
+				g.droppedItems = append(g.droppedItems[:i], g.droppedItems[i+1:]...)
+				continue
+			}
+
+			// Try to add to inventory, keeping the metadata unless a plugin
+			// swapped the item for another
+			picked := items.Item{Type: pickedType, Quantity: pickup.Quantity, Durability: -1}
+			if props := items.GetItemProperties(pickedType); props != nil {
+				picked.Durability = props.Durability
+			}
+			if pickedType == item.Type {
+				picked.Meta = item.Meta
+			}
+			if g.inventory.AddStack(picked) {
 				// Play pickup sound
 				g.playItemSound("pickup")
 				// Remove picked up item
 				g.droppedItems = append(g.droppedItems[:i], g.droppedItems[i+1:]...)
+				g.publishPost(entities.EventItemPickup, pickup)
+				g.learnRecipes()
 			}
 		}
 	}
@@ -1873,6 +2303,22 @@ func (g *Game) Draw(screen *ebiten.Image) {
 		return
 	}
 
+	if state == ui.StateFurnace {
+		// Draw game in background
+		g.drawGameScene(screen)
+		// Draw furnace UI overlay
+		g.furnaceUI.Draw(screen)
+		return
+	}
+
+	if state == ui.StateAnvil {
+		// Draw game in background
+		g.drawGameScene(screen)
+		// Draw anvil UI overlay
+		g.anvilUI.Draw(screen)
+		return
+	}
+
 	if state == ui.StatePluginUI {
 		// Draw game in background
 		g.drawGameScene(screen)
@@ -1943,6 +2389,8 @@ func (g *Game) drawGameScene(screen *ebiten.Image) {
 
 	// Draw current layer (no blur)
 	g.drawLayer(screen, px, py, g.currentLayer, 0)
+	g.drawFallingBlocks(screen)
+	g.drawOrganisms(screen)
 
 	// Draw player (only on current layer)
 	g.drawPlayer(screen)
@@ -1950,14 +2398,72 @@ func (g *Game) drawGameScene(screen *ebiten.Image) {
 	// Draw weapon swing effect (only on current layer)
 	g.drawWeaponSwing(screen)
 
+	// Draw arrows, thrown items and spells
+	g.drawProjectiles(screen)
+
 	// Draw zombies (only on current layer)
-	g.drawZombies(screen)
+	g.drawMobs(screen)
+
+	// Draw other players in multiplayer
+	g.drawRemotePlayers(screen)
 
 	// Draw dropped items (only on current layer)
 	g.drawDroppedItems(screen)
 
 	// Draw weather particles
-	g.weatherSystem.Draw(screen, g.cameraX, g.cameraY)
+	g.drawWeather(screen)
+}
+
+// drawWeather renders weather particles
+func (g *Game) drawWeather(screen *ebiten.Image) {
+	active := g.weatherSystem.ActiveWeather()
+
+	switch active.Type {
+	case weather.Rain, weather.Storm:
+		for _, particle := range g.weatherSystem.RainParticles() {
+			if particle.Active {
+				screenX := particle.X - g.cameraX
+				screenY := particle.Y - g.cameraY
+
+				// Draw rain drop as a vertical line
+				length := 8.0 * active.Intensity
+				alpha := uint8(200 * active.Intensity * (particle.Life / particle.MaxLife))
+				ebitenutil.DrawLine(screen, screenX, screenY, screenX, screenY+length, color.RGBA{200, 220, 255, alpha})
+			}
+		}
+	case weather.Snow:
+		for _, particle := range g.weatherSystem.SnowParticles() {
+			if particle.Active {
+				screenX := particle.X - g.cameraX
+				screenY := particle.Y - g.cameraY
+
+				// Draw snowflake as a few small dots
+				size := 2.0 + rand.Float64()*2.0
+				alpha := uint8(180 * active.Intensity * (particle.Life / particle.MaxLife))
+				for i := 0; i < 3; i++ {
+					offsetX := (rand.Float64() - 0.5) * 4
+					offsetY := (rand.Float64() - 0.5) * 4
+					ebitenutil.DrawRect(screen, screenX+offsetX-size/2, screenY+offsetY-size/2, size, size, color.RGBA{255, 255, 255, alpha})
+				}
+			}
+		}
+	}
+}
+
+// blockTexture returns the GPU image for a block's texture, or nil if it has none
+func (g *Game) blockTexture(props *blocks.BlockProperties) *ebiten.Image {
+	if props.Texture == nil {
+		return nil
+	}
+	if texture, ok := g.blockTextures[props.ID]; ok {
+		return texture
+	}
+	if g.blockTextures == nil {
+		g.blockTextures = make(map[blocks.BlockType]*ebiten.Image)
+	}
+	texture := ebiten.NewImageFromImage(props.Texture)
+	g.blockTextures[props.ID] = texture
+	return texture
 }
 
 // drawBlocksBatched draws blocks in batches grouped by color for performance optimization
@@ -2075,7 +2581,7 @@ func (g *Game) drawBlocksBatched(screen *ebiten.Image, blockList []*world.Hexago
 		}
 
 		// Draw the batch
-		if props.Texture != nil {
+		if texture := g.blockTexture(props); texture != nil {
 			// Draw textured hexagons using triangles with texture mapping
 			for _, block := range groupBlocks {
 				screenX := block.X - g.cameraX
@@ -2083,6 +2589,7 @@ func (g *Game) drawBlocksBatched(screen *ebiten.Image, blockList []*world.Hexago
 
 				// Get hexagon corners
 				corners := hexagon.GetHexCorners(screenX, screenY, world.HexSize)
+				brightness := g.blockBrightness(block)
 
 				// Prepare vertices with texture coordinates
 				vertices := make([]ebiten.Vertex, len(corners))
@@ -2105,6 +2612,11 @@ func (g *Game) drawBlocksBatched(screen *ebiten.Image, blockList []*world.Hexago
 						b *= float32(damageRatio)
 					}
 
+					// Apply local light
+					r *= brightness
+					gc *= brightness
+					b *= brightness
+
 					vertices[i] = ebiten.Vertex{
 						DstX:   float32(corner[0]),
 						DstY:   float32(corner[1]),
@@ -2120,7 +2632,7 @@ func (g *Game) drawBlocksBatched(screen *ebiten.Image, blockList []*world.Hexago
 				// Indices for hexagon triangles
 				indices := []uint16{0, 1, 2, 0, 2, 3, 0, 3, 4, 0, 4, 5}
 
-				screen.DrawTriangles(vertices, indices, props.Texture, nil)
+				screen.DrawTriangles(vertices, indices, texture, nil)
 			}
 		} else {
 			// Draw solid colors using triangles
@@ -2153,6 +2665,12 @@ func (g *Game) drawBlocksBatched(screen *ebiten.Image, blockList []*world.Hexago
 					b *= float32(math.Min(1.0, float64(damageRatio)))
 				}
 
+				// Apply local light
+				brightness := g.blockBrightness(block)
+				r *= brightness
+				gc *= brightness
+				b *= brightness
+
 				// Add vertices for this hexagon
 				for _, corner := range corners {
 					vertices = append(vertices, ebiten.Vertex{
@@ -2182,6 +2700,25 @@ func (g *Game) drawBlocksBatched(screen *ebiten.Image, blockList []*world.Hexago
 	}
 }
 
+// blockBrightness returns how brightly a block is lit, never fully black so
+// unlit terrain stays readable
+func (g *Game) blockBrightness(block *world.Hexagon) float32 {
+	brightness := g.world.BlockBrightness(block, g.dayNightCycle.SkyLight)
+	return float32(math.Max(minBlockBrightness, brightness))
+}
+
+// drawFallingBlocks draws gravity blocks that are falling between cells
+func (g *Game) drawFallingBlocks(screen *ebiten.Image) {
+	if len(g.world.FallingBlocks) == 0 {
+		return
+	}
+	falling := make([]*world.Hexagon, 0, len(g.world.FallingBlocks))
+	for _, fb := range g.world.FallingBlocks {
+		falling = append(falling, world.NewHexagon(fb.X, fb.Y, world.HexSize, fb.BlockType))
+	}
+	g.drawBlocksBatched(screen, falling)
+}
+
 // drawLayer draws a specific layer of the world with optional blur effect
 func (g *Game) drawLayer(screen *ebiten.Image, px, py float64, layer int, blurAmount float64) {
 	// Get visible blocks for this layer
@@ -2222,11 +2759,12 @@ func (g *Game) drawBlocksBatchedWithBlur(screen *ebiten.Image, blockList []*worl
 			continue
 		}
 
-		// Get base color with darkening
+		// Get base color with darkening and local light
 		r, gr, b, a := block.ActiveColor.RGBA()
-		darkenedR := uint8(float64(r/257) * darkenFactor)
-		darkenedG := uint8(float64(gr/257) * darkenFactor)
-		darkenedB := uint8(float64(b/257) * darkenFactor)
+		shade := darkenFactor * float64(g.blockBrightness(block))
+		darkenedR := uint8(float64(r/257) * shade)
+		darkenedG := uint8(float64(gr/257) * shade)
+		darkenedB := uint8(float64(b/257) * shade)
 
 		// Apply opacity
 		finalA := uint8(float64(a/257) * float64(opacity) / 255.0)
@@ -2352,6 +2890,11 @@ func (g *Game) drawUI(screen *ebiten.Image) {
 
 	// Draw portal interaction prompt
 	g.drawPortalPrompt(screen)
+
+	// Draw recent multiplayer chat
+	for i, line := range g.chatLog {
+		ebitenutil.DebugPrintAt(screen, line, 10, ScreenHeight-220+i*16)
+	}
 }
 
 // drawPortalPrompt shows prompt when near a portal
@@ -2390,8 +2933,9 @@ func (g *Game) drawDebugInfo(screen *ebiten.Image) {
 	vx, vy := g.player.GetVelocity()
 
 	timeInfo := g.dayNightCycle.GetTimeString()
-	lightInfo := fmt.Sprintf("Ambient: %.2f, Sky: %.2f, Block: %.2f",
-		g.dayNightCycle.AmbientLight, g.dayNightCycle.SkyLight, g.dayNightCycle.BlockLight)
+	skyLevel, blockLevel := g.world.GetLightAt(px, py)
+	lightInfo := fmt.Sprintf("Ambient: %.2f, Sky: %.2f, Block: %.2f, Local: %d/%d",
+		g.dayNightCycle.AmbientLight, g.dayNightCycle.SkyLight, g.dayNightCycle.BlockLight, skyLevel, blockLevel)
 
 	_, weatherIntensity, weatherName := g.weatherSystem.GetWeatherInfo()
 	weatherInfo := fmt.Sprintf("Weather: %s (%.1f)", weatherName, weatherIntensity)
@@ -2413,9 +2957,29 @@ func (g *Game) drawDebugInfo(screen *ebiten.Image) {
 	ebitenutil.DebugPrint(screen, info)
 }
 
-// drawDroppedItems renders all dropped items in the world
-func (g *Game) drawDroppedItems(screen *ebiten.Image) {
-	for _, item := range g.droppedItems {
+// drawOrganisms draws the bushes, mushrooms and other organisms growing on
+// top of their blocks, with a health bar once they are damaged
+func (g *Game) drawOrganisms(screen *ebiten.Image) {
+	const size = 12.0
+	for _, org := range g.world.Organisms {
+		screenX := org.X - g.cameraX
+		screenY := org.Y - organismLift - g.cameraY
+		if screenX < -50 || screenX > ScreenWidth+50 || screenY < -50 || screenY > ScreenHeight+50 {
+			continue
+		}
+
+		ebitenutil.DrawRect(screen, screenX-size/2, screenY-size/2, size, size, org.Color())
+		if org.Health < org.MaxHealth && org.MaxHealth > 0 {
+			barY := screenY - size/2 - 6
+			ebitenutil.DrawRect(screen, screenX-size/2, barY, size, 3, color.RGBA{255, 0, 0, 255})
+			ebitenutil.DrawRect(screen, screenX-size/2, barY, size*org.Health/org.MaxHealth, 3, color.RGBA{0, 255, 0, 255})
+		}
+	}
+}
+
+// drawDroppedItems renders all dropped items in the world
+func (g *Game) drawDroppedItems(screen *ebiten.Image) {
+	for _, item := range g.droppedItems {
 		// Calculate screen position
 		screenX := item.X - g.cameraX
 		screenY := item.Y - g.cameraY
@@ -2641,6 +3205,9 @@ func (g *Game) drawPlayer(screen *ebiten.Image) {
 
 	// Draw floating player name above player
 	playerName := "Player" // Could be customizable
+	if g.netClient != nil {
+		playerName = g.netClient.Welcome.PlayerName
+	}
 	nameX := int(screenX + float64(g.player.Width)/2 - float64(len(playerName)*4))
 	nameY := int(screenY - 35)
 
@@ -2689,83 +3256,61 @@ func (g *Game) drawPlayer(screen *ebiten.Image) {
 	}
 }
 
-// drawZombies draws all active zombies (overworld and randomland)
-func (g *Game) drawZombies(screen *ebiten.Image) {
-	// Collect zombies from both spawners
-	var allZombies []*enemies.Zombie
-
-	// Add overworld zombies
-	if g.zombieSpawner != nil {
-		allZombies = append(allZombies, g.zombieSpawner.Zombies...)
-	}
-
-	// Add Randomland zombies if in that dimension
-	if g.dimensionManager != nil && g.dimensionManager.IsInRandomland() {
-		if g.dimensionManager.RandomlandDim != nil && g.dimensionManager.RandomlandDim.ZombieSpawner != nil {
-			allZombies = append(allZombies, g.dimensionManager.RandomlandDim.ZombieSpawner.Zombies...)
-		}
-	}
-
-	for _, zombie := range allZombies {
-		if !zombie.IsAlive {
+// drawMobs draws all active mobs: zombies (overworld and randomland) and
+// creatures
+func (g *Game) drawMobs(screen *ebiten.Image) {
+	for _, mob := range g.activeMobs() {
+		if !mob.IsAlive() {
 			continue
 		}
 
-		screenX := zombie.X - g.cameraX
-		screenY := zombie.Y - g.cameraY
+		screenX := mob.X - g.cameraX
+		screenY := mob.Y - g.cameraY
 
 		// Don't draw if off screen
 		if screenX < -100 || screenX > ScreenWidth+100 || screenY < -100 || screenY > ScreenHeight+100 {
 			continue
 		}
 
-		// Green version of player (like player but green skin)
+		// Humanoid mobs look like the player in their type's colors
 		var bodyColor, headColor, armColor, legColor color.RGBA
-		if zombie.IsBurning {
+		if mob.IsBurning {
 			// Burning - orange like fire
 			bodyColor = color.RGBA{255, 100, 50, 255}
 			headColor = color.RGBA{255, 120, 60, 255}
 			armColor = color.RGBA{255, 100, 50, 255}
 			legColor = color.RGBA{200, 80, 40, 255}
 		} else {
-			// Green version of player (like player but green skin)
-			bodyColor = color.RGBA{75, 118, 60, 255}   // Green shirt
-			headColor = color.RGBA{120, 200, 100, 255} // Green skin
-			armColor = color.RGBA{75, 118, 60, 255}    // Green arms
-			legColor = color.RGBA{50, 80, 40, 255}     // Green pants
-
-			// Different shades for zombie variants
-			switch zombie.Type {
-			case enemies.ZombieFast:
-				bodyColor = color.RGBA{100, 160, 80, 255}  // Lighter green
-				headColor = color.RGBA{150, 220, 120, 255} // Brighter green skin
-				armColor = color.RGBA{100, 160, 80, 255}
-				legColor = color.RGBA{70, 110, 50, 255}
-			case enemies.ZombieStrong:
-				bodyColor = color.RGBA{60, 100, 50, 255}  // Darker green
-				headColor = color.RGBA{100, 160, 80, 255} // Darker green skin
-				armColor = color.RGBA{60, 100, 50, 255}
-				legColor = color.RGBA{40, 70, 35, 255}
-			case enemies.ZombieTank:
-				bodyColor = color.RGBA{50, 80, 40, 255}  // Very dark green
-				headColor = color.RGBA{80, 130, 60, 255} // Dark green skin
-				armColor = color.RGBA{50, 80, 40, 255}
-				legColor = color.RGBA{30, 50, 25, 255}
-			}
+			bodyColor = mob.Def.Color.RGBA()
+			headColor = mob.Def.HeadColor.RGBA()
+			armColor = mob.Def.Color.RGBA()
+			legColor = mob.Def.LegColor.RGBA()
+		}
+
+		if mob.Def.Shape == mobs.ShapeBlob {
+			// Blobs are a single rounded body with eyes
+			radius := math.Min(mob.Width, mob.Height) / 2
+			ebitenutil.DrawRect(screen, screenX, screenY+radius/2, mob.Width, mob.Height-radius/2, bodyColor)
+			ebitenutil.DrawCircle(screen, screenX+mob.Width/2, screenY+radius, radius, bodyColor)
+			eyeY := screenY + mob.Height/3
+			ebitenutil.DrawRect(screen, screenX+mob.Width*0.3-2, eyeY, 4, 4, color.RGBA{255, 255, 255, 255})
+			ebitenutil.DrawRect(screen, screenX+mob.Width*0.7-2, eyeY, 4, 4, color.RGBA{255, 255, 255, 255})
+			g.drawMobHealthBar(screen, mob, screenX, screenY)
+			continue
 		}
 
-		// Draw zombie body (50x50) - same structure as player
-		bodyWidth := 50.0
-		bodyHeight := 50.0
+		// Draw body - same structure as player
+		bodyWidth := mob.Width
+		bodyHeight := mob.Height
 		ebitenutil.DrawRect(screen, screenX-5, screenY+10, bodyWidth+10, bodyHeight-10, bodyColor)
 
-		// Draw zombie head (25x25) - centered on top like player
+		// Draw head (25x25) - centered on top like player
 		headSize := 25.0
 		headX := screenX + (bodyWidth-headSize)/2
 		headY := screenY - 5
 		ebitenutil.DrawRect(screen, headX, headY, headSize, headSize, headColor)
 
-		// Draw zombie arms (10x30) - same as player
+		// Draw arms (10x30) - same as player
 		armWidth := 10.0
 		armHeight := 30.0
 		// Left arm
@@ -2773,7 +3318,7 @@ func (g *Game) drawZombies(screen *ebiten.Image) {
 		// Right arm
 		ebitenutil.DrawRect(screen, screenX+bodyWidth+5, screenY+20, armWidth, armHeight, armColor)
 
-		// Draw zombie legs (12x20) - same as player
+		// Draw legs (12x20) - same as player
 		legWidth := 12.0
 		legHeight := 20.0
 		// Left leg
@@ -2781,28 +3326,32 @@ func (g *Game) drawZombies(screen *ebiten.Image) {
 		// Right leg
 		ebitenutil.DrawRect(screen, screenX+bodyWidth-legWidth-8, screenY+bodyHeight-legHeight, legWidth, legHeight, legColor)
 
-		// Draw health bar above zombie
-		healthBarWidth := zombie.Width
-		healthBarHeight := 4.0
-		healthPct := zombie.Health / zombie.MaxHealth
-		healthWidth := healthBarWidth * healthPct
-
-		// Background (gray)
-		ebitenutil.DrawRect(screen, screenX, screenY-15, healthBarWidth, healthBarHeight, color.RGBA{50, 50, 50, 255})
-
-		// Health (green to red based on health)
-		var healthColor color.RGBA
-		if healthPct > 0.5 {
-			healthColor = color.RGBA{0, 255, 0, 255}
-		} else if healthPct > 0.25 {
-			healthColor = color.RGBA{255, 255, 0, 255}
-		} else {
-			healthColor = color.RGBA{255, 0, 0, 255}
-		}
-		ebitenutil.DrawRect(screen, screenX, screenY-15, healthWidth, healthBarHeight, healthColor)
+		g.drawMobHealthBar(screen, mob, screenX, screenY)
 	}
 }
 
+// drawMobHealthBar draws a mob's health bar above it
+func (g *Game) drawMobHealthBar(screen *ebiten.Image, mob *mobs.Mob, screenX, screenY float64) {
+	healthBarWidth := mob.Width
+	healthBarHeight := 4.0
+	healthPct := mob.Health / mob.MaxHealth
+	healthWidth := healthBarWidth * healthPct
+
+	// Background (gray)
+	ebitenutil.DrawRect(screen, screenX, screenY-15, healthBarWidth, healthBarHeight, color.RGBA{50, 50, 50, 255})
+
+	// Health (green to red based on health)
+	var healthColor color.RGBA
+	if healthPct > 0.5 {
+		healthColor = color.RGBA{0, 255, 0, 255}
+	} else if healthPct > 0.25 {
+		healthColor = color.RGBA{255, 255, 0, 255}
+	} else {
+		healthColor = color.RGBA{255, 0, 0, 255}
+	}
+	ebitenutil.DrawRect(screen, screenX, screenY-15, healthWidth, healthBarHeight, healthColor)
+}
+
 // drawWeaponSwing draws the weapon swing effect
 func (g *Game) drawWeaponSwing(screen *ebiten.Image) {
 	if g.weaponSystem == nil || !g.weaponSystem.IsSwinging() {
@@ -2843,9 +3392,49 @@ func (g *Game) drawWeaponSwing(screen *ebiten.Image) {
 	ebitenutil.DrawLine(screen, screenX, screenY, screenX+math.Cos(endRad)*radius*0.5, screenY+math.Sin(endRad)*radius*0.5, arcColor)
 }
 
+// drawProjectiles renders arrows, thrown items and spells in flight, and
+// the bursts where spells land
+func (g *Game) drawProjectiles(screen *ebiten.Image) {
+	if g.projectileSystem == nil {
+		return
+	}
+
+	for _, p := range g.projectileSystem.Projectiles {
+		screenX := p.X - g.cameraX
+		screenY := p.Y - g.cameraY
+		if screenX < -50 || screenX > ScreenWidth+50 || screenY < -50 || screenY > ScreenHeight+50 {
+			continue
+		}
+
+		switch p.Kind {
+		case combat.ProjectileArrow:
+			// Shaft trailing back from the tip along the flight direction
+			tailX := screenX - math.Cos(p.Angle)*16
+			tailY := screenY - math.Sin(p.Angle)*16
+			ebitenutil.DrawLine(screen, tailX, tailY, screenX, screenY, color.RGBA{139, 90, 43, 255})
+			ebitenutil.DrawRect(screen, screenX-1, screenY-1, 3, 3, color.RGBA{200, 200, 200, 255})
+		case combat.ProjectileThrown:
+			itemColor := color.RGBA{255, 255, 255, 255}
+			if itemType, ok := items.ItemTypeByID(p.Item); ok {
+				itemColor = items.ItemColorByID(itemType)
+			}
+			ebitenutil.DrawRect(screen, screenX-4, screenY-4, 8, 8, itemColor)
+		case combat.ProjectileSpell:
+			ebitenutil.DrawCircle(screen, screenX, screenY, 7, color.RGBA{255, 0, 255, 80})
+			ebitenutil.DrawCircle(screen, screenX, screenY, 4, color.RGBA{255, 180, 255, 220})
+		}
+	}
+
+	for _, burst := range g.projectileSystem.Bursts {
+		radius := 6 + burst.Age*80
+		alpha := uint8(180 * (1 - burst.Age/combat.SpellBurstDuration))
+		ebitenutil.DrawCircle(screen, burst.X-g.cameraX, burst.Y-g.cameraY, radius, color.RGBA{255, 100, 255, alpha})
+	}
+}
+
 // performWeaponAttack executes a weapon swing attack
 func (g *Game) performWeaponAttack() {
-	if g.weaponSystem == nil || g.zombieSpawner == nil {
+	if g.weaponSystem == nil {
 		return
 	}
 
@@ -2858,42 +3447,49 @@ func (g *Game) performWeaponAttack() {
 
 	// Calculate weapon damage based on equipped weapon
 	damage := 5.0 // Base unarmed damage
+	var weapon *items.Metadata
 	selectedItem := g.inventory.GetSelectedItem()
 	if selectedItem != nil && selectedItem.Type != items.NONE {
 		props := items.ItemDefinitions[selectedItem.Type]
 		if props != nil && props.IsWeapon {
 			damage = props.WeaponDamage
+			weapon = selectedItem.Meta
 		}
 	}
+	if g.survivalManager != nil {
+		damage *= g.survivalManager.Effects.GetDamageMultiplier() // Strength from food
+	}
 
 	// Perform attack
-	results := g.weaponSystem.PerformAttack(playerX, playerY, mouseWorldX, mouseWorldY, damage, g.zombieSpawner.Zombies)
+	targets := g.activeMobs()
+	results := g.weaponSystem.PerformAttack(playerX, playerY, mouseWorldX, mouseWorldY, damage, weapon, targets)
 
-	// Apply damage to hit zombies and show indicators
+	// Apply damage to hit mobs and show indicators
 	for _, result := range results {
 		if result.Hit {
-			// Find the zombie that was hit and apply damage
-			for _, zombie := range g.zombieSpawner.Zombies {
-				if zombie.IsAlive &&
-					math.Abs(zombie.X+zombie.Width/2-result.HitX) < 10 &&
-					math.Abs(zombie.Y+zombie.Height/2-result.HitY) < 10 {
+			// Find the mob that was hit and apply damage
+			for _, mob := range targets {
+				if mob.IsAlive() &&
+					math.Abs(mob.X+mob.Width/2-result.HitX) < 10 &&
+					math.Abs(mob.Y+mob.Height/2-result.HitY) < 10 {
+					// Plugins may cancel the hit or change its damage
+					hit := entities.CreateCombatEvent("player", mob.ID, result.Damage, g.selectedItemID(), result.IsCritical, false)
+					if !g.publishPre(entities.EventDamage, &hit) {
+						break
+					}
+
 					// Apply damage
-					zombie.TakeDamage(result.Damage)
+					mob.TakeDamage(hit.Damage)
+					hit.Killed = !mob.IsAlive()
+					g.publishPost(entities.EventDamage, hit)
+					if hit.Killed {
+						g.publishPost(entities.EventDeath, entities.CreateDeathEvent(mob.ID, "Killed by player", "player"))
+						g.dropMobLoot(mob)
+					}
 
 					// Show damage indicator with appropriate tier color
 					if g.damageIndicators != nil {
-						var tier ui.DamageTier
-						switch result.Tier {
-						case combat.CritTierPurple:
-							tier = ui.TierPurple // Fatal - instant death
-						case combat.CritTierRed:
-							tier = ui.TierRed // Severe damage
-						case combat.CritTierYellow:
-							tier = ui.TierYellow // Moderate damage
-						default:
-							tier = ui.TierGreen // Low damage
-						}
-						g.damageIndicators.SpawnDamageIndicator(zombie.X, zombie.Y, result.Damage, tier, result.IsCritical)
+						g.damageIndicators.SpawnDamageIndicator(mob.X, mob.Y, hit.Damage, damageTier(result.Tier), result.IsCritical)
 					}
 
 					break
@@ -2901,13 +3497,336 @@ func (g *Game) performWeaponAttack() {
 			}
 		}
 	}
+
+	// Swings also cut down bushes, mushrooms and other organisms
+	g.harvestOrganism(mouseWorldX, mouseWorldY, damage)
+}
+
+// organismLift is how far above the block it grows on an organism is drawn
+// and clicked
+var organismLift = world.HexSize
+
+// harvestOrganism damages the organism under the mouse, dropping what it
+// yields once it is destroyed
+func (g *Game) harvestOrganism(mouseWorldX, mouseWorldY, damage float64) {
+	org := g.world.GetOrganismAt(mouseWorldX, mouseWorldY+organismLift, 20)
+	if org == nil || !g.player.CanReach(org.X, org.Y-organismLift) {
+		return
+	}
+	if !org.TakeDamage(damage) {
+		return
+	}
+	g.world.RemoveOrganism(org)
+	for _, drop := range organisms.DropItems(org) {
+		g.droppedItems = append(g.droppedItems, &DroppedItem{
+			Type:     drop.Type,
+			Quantity: drop.Quantity,
+			X:        org.X,
+			Y:        org.Y - organismLift,
+			VX:       float64(rand.Intn(60)-30) / 10.0,
+			VY:       -3.0,
+			Lifetime: time.Now().Add(5 * time.Minute), // Items disappear after 5 minutes
+		})
+	}
+}
+
+// damageTier returns the damage indicator color for a critical hit tier
+func damageTier(tier combat.CritTier) ui.DamageTier {
+	switch tier {
+	case combat.CritTierPurple:
+		return ui.TierPurple // Fatal - instant death
+	case combat.CritTierRed:
+		return ui.TierRed // Severe damage
+	case combat.CritTierYellow:
+		return ui.TierYellow // Moderate damage
+	default:
+		return ui.TierGreen // Low damage
+	}
+}
+
+// selectedWeaponFires reports whether the selected item is a bow or wand,
+// which fire projectiles instead of swinging
+func (g *Game) selectedWeaponFires() bool {
+	selectedItem := g.inventory.GetSelectedItem()
+	if selectedItem == nil || selectedItem.Type == items.NONE {
+		return false
+	}
+	props := items.GetItemProperties(selectedItem.Type)
+	return props != nil && props.IsWeapon && (props.WeaponType == "ranged" || props.WeaponType == "magic")
+}
+
+// fireSelectedWeapon shoots the selected bow or wand towards the mouse. Bows
+// use up one piece of their ammo per shot and wands a point of durability.
+func (g *Game) fireSelectedWeapon() {
+	g.launchSelectedItem(false)
+}
+
+// throwSelectedItem throws one of the selected item towards the mouse, if it
+// can be thrown. It drops as an item where it lands.
+func (g *Game) throwSelectedItem() {
+	if g.launchSelectedItem(true) {
+		g.playItemSound("drop")
+	}
+}
+
+// launchSelectedItem fires or throws the selected item towards the mouse and
+// pays for the shot, reporting whether it launched. On a multiplayer server
+// the local projectile only shows the shot: the server fires its own, pays
+// for it from its copy of the inventory and applies the hits.
+func (g *Game) launchSelectedItem(throw bool) bool {
+	if g.projectileSystem == nil {
+		return false
+	}
+	selectedItem := g.inventory.GetSelectedItem()
+	if selectedItem == nil {
+		return false
+	}
+	shot, ok := combat.ShotFor(*selectedItem, throw)
+	if !ok || !g.projectileSystem.CanFire(shot.Rate) {
+		return false
+	}
+
+	switch {
+	case shot.Ammo != items.NONE:
+		if !g.inventory.RemoveItemType(shot.Ammo, 1) {
+			return false // Out of ammo
+		}
+	case shot.Kind == combat.ProjectileSpell:
+		g.inventory.WearSelectedItem()
+	case shot.Kind == combat.ProjectileThrown:
+		g.inventory.RemoveItem(1)
+	}
+
+	playerX, playerY := g.player.GetCenter()
+	mouseWorldX := float64(g.mouseX) + g.cameraX
+	mouseWorldY := float64(g.mouseY) + g.cameraY
+	projectile := g.projectileSystem.Fire(shot.Kind, g.playerID(), playerX, playerY, mouseWorldX, mouseWorldY, shot.Damage)
+	projectile.Weapon = shot.Weapon
+	projectile.CritBonus = shot.CritBonus
+	if shot.Kind == combat.ProjectileThrown {
+		projectile.Item = shot.Weapon
+	}
+
+	if g.netClient != nil {
+		if err := g.netClient.SendFire(shot.Weapon, throw, mouseWorldX, mouseWorldY); err != nil {
+			log.Printf("Failed to send shot: %v", err)
+		}
+	}
+	return true
+}
+
+// playerID is the local player's projectile target and owner ID
+func (g *Game) playerID() string {
+	name := "Player"
+	if g.netClient != nil {
+		name = g.netClient.Welcome.PlayerName
+	}
+	return combat.PlayerTargetID(name)
+}
+
+// projectileTargets gathers everything projectiles can hit: mobs, the player
+// and other players on a multiplayer server
+func (g *Game) projectileTargets() []combat.ProjectileTarget {
+	var targets []combat.ProjectileTarget
+	for _, mob := range g.activeMobs() {
+		if !mob.IsAlive() {
+			continue
+		}
+		targets = append(targets, combat.ProjectileTarget{
+			ID:     mob.ID,
+			Kind:   "mob",
+			X:      mob.X,
+			Y:      mob.Y,
+			Width:  mob.Width,
+			Height: mob.Height,
+			Health: mob.Health,
+		})
+	}
+	targets = append(targets, combat.ProjectileTarget{
+		ID:     g.playerID(),
+		Kind:   "player",
+		X:      g.player.X,
+		Y:      g.player.Y,
+		Width:  g.player.Width,
+		Height: g.player.Height,
+		Health: g.player.Health,
+	})
+	for name, remote := range g.remotePlayers {
+		targets = append(targets, combat.ProjectileTarget{
+			ID:     combat.PlayerTargetID(name),
+			Kind:   "player",
+			X:      remote.X,
+			Y:      remote.Y,
+			Width:  player.PlayerWidth,
+			Height: player.PlayerHeight,
+			Health: remote.Health,
+		})
+	}
+	return targets
+}
+
+// isSolidAt reports whether a world position is inside a solid block
+func (g *Game) isSolidAt(x, y float64) bool {
+	hex := g.world.GetHexagonAt(x, y)
+	if hex == nil {
+		return false
+	}
+	def := blocks.BlockDefinitions[getBlockKeyFromType(hex.BlockType)]
+	return def != nil && def.Solid
+}
+
+// updateProjectiles moves projectiles, drops thrown items where they land and
+// applies hits the same way melee hits are applied
+func (g *Game) updateProjectiles(deltaTime float64) {
+	if g.projectileSystem == nil {
+		return
+	}
+
+	impacts := g.projectileSystem.Update(deltaTime, g.projectileTargets(), g.isSolidAt)
+	if g.netClient != nil {
+		return // The server applies hits and keeps what lands
+	}
+	for _, impact := range impacts {
+		projectile := impact.Projectile
+		if !impact.HitTarget {
+			if projectile.Kind != combat.ProjectileThrown || projectile.Item == "" {
+				continue
+			}
+			if itemType, ok := items.ItemTypeByID(projectile.Item); ok {
+				g.droppedItems = append(g.droppedItems, &DroppedItem{
+					Type:     itemType,
+					Quantity: 1,
+					X:        impact.Result.HitX,
+					Y:        impact.Result.HitY,
+					Lifetime: time.Now().Add(5 * time.Minute), // Items disappear after 5 minutes
+				})
+			}
+			continue
+		}
+		g.applyProjectileHit(impact)
+	}
+}
+
+// applyProjectileHit damages whatever a projectile struck. Plugins may cancel
+// the hit or change its damage.
+func (g *Game) applyProjectileHit(impact combat.ProjectileImpact) {
+	target := impact.Target
+	result := impact.Result
+	hit := entities.CreateCombatEvent(impact.Projectile.Owner, target.ID, result.Damage, impact.Projectile.Weapon, result.IsCritical, false)
+	if !g.publishPre(entities.EventDamage, &hit) {
+		return
+	}
+
+	switch target.Kind {
+	case "mob":
+		for _, mob := range g.activeMobs() {
+			if mob.ID == target.ID && mob.IsAlive() {
+				mob.TakeDamage(hit.Damage)
+				hit.Killed = !mob.IsAlive()
+				if hit.Killed {
+					g.dropMobLoot(mob)
+				}
+				break
+			}
+		}
+	case "player":
+		if target.ID != g.playerID() {
+			return // Only a server can hurt other players
+		}
+		g.player.TakeDamage(hit.Damage)
+		hit.Killed = g.player.Health <= 0
+	}
+
+	g.publishPost(entities.EventDamage, hit)
+	if hit.Killed {
+		g.publishPost(entities.EventDeath, entities.CreateDeathEvent(target.ID, "Shot by "+hit.AttackerID, hit.AttackerID))
+	}
+
+	if g.damageIndicators != nil {
+		g.damageIndicators.SpawnDamageIndicator(target.X, target.Y, hit.Damage, damageTier(result.Tier), result.IsCritical)
+	}
+}
+
+// activeMobs returns the mobs around the player: the zombies of the current
+// dimension and the world's creatures
+func (g *Game) activeMobs() []*mobs.Mob {
+	var active []*mobs.Mob
+	if g.dimensionManager != nil && g.dimensionManager.IsInRandomland() {
+		if g.dimensionManager.RandomlandDim != nil && g.dimensionManager.RandomlandDim.ZombieSpawner != nil {
+			active = append(active, g.dimensionManager.RandomlandDim.ZombieSpawner.Mobs...)
+		}
+	} else if g.zombieSpawner != nil {
+		active = append(active, g.zombieSpawner.Mobs...)
+	}
+	return append(active, g.world.Creatures...)
+}
+
+// dropMobLoot drops a killed mob's loot where it died and gives the player
+// its experience
+func (g *Game) dropMobLoot(mob *mobs.Mob) {
+	g.player.AddXP(mob.Def.XP)
+	g.advanceQuests("kill", mob.Def.ID)
+	x, y := mob.GetCenter()
+	for _, drop := range mob.RollLoot() {
+		g.droppedItems = append(g.droppedItems, &DroppedItem{
+			Type:     drop.Type,
+			Quantity: drop.Quantity,
+			X:        x,
+			Y:        y,
+			VX:       float64(rand.Intn(60)-30) / 10.0,
+			VY:       -3.0,
+			Lifetime: time.Now().Add(5 * time.Minute), // Items disappear after 5 minutes
+		})
+	}
+}
+
+// Quests are kept per world for its local player
+const (
+	questPlayer   = "player"
+	tutorialQuest = "tutorial_beginner" // The quest new players start on
+)
+
+// advanceQuests counts an action towards the player's quests, handing in the
+// ones it completes: the player gets the rewards and learns the recipes the
+// quest unlocks
+func (g *Game) advanceQuests(objective, target string) {
+	if g.questManager == nil {
+		return
+	}
+	for _, playerQuest := range g.questManager.UpdateProgress(questPlayer, objective, target, 1) {
+		if playerQuest.Status != quests.QuestCompleted {
+			continue
+		}
+		reward, err := g.questManager.CompleteQuest(questPlayer, playerQuest.QuestID)
+		if err != nil {
+			log.Printf("Failed to hand in quest %s: %v", playerQuest.QuestID, err)
+			continue
+		}
+		if quest, ok := g.questManager.GetQuest(playerQuest.QuestID); ok {
+			g.addChatLine("Quest complete: " + quest.Name)
+		}
+		g.player.AddXP(reward.XP)
+		for _, item := range reward.Items {
+			for range item.Quantity {
+				g.giveItem(item.Type)
+			}
+		}
+		g.craftingSystem.UnlockForQuest(playerQuest.QuestID)
+	}
 }
 
 // respawnPlayer respawns the player at a safe location
 func (g *Game) respawnPlayer() {
-	// Reset player position (spawn at world origin or safe location)
-	g.player.X = 0
-	g.player.Y = 0
+	// Reset player position (spawn at world origin or safe location); a
+	// multiplayer server picks the spawn and sends the position back
+	if g.netClient != nil {
+		if err := g.netClient.SendRespawn(); err != nil {
+			log.Printf("Failed to request respawn: %v", err)
+		}
+	} else {
+		g.player.X = 0
+		g.player.Y = 0
+	}
 	g.player.VX = 0
 	g.player.VY = 0
 
@@ -2925,8 +3844,10 @@ func (g *Game) respawnPlayer() {
 		g.survivalManager.Hunger = g.survivalManager.MaxHunger * 0.5 // 50% hunger on respawn
 		g.survivalManager.Thirst = g.survivalManager.MaxThirst * 0.5
 		g.survivalManager.Stamina = g.survivalManager.MaxStamina
+		g.survivalManager.Saturation = 0
 		g.survivalManager.IsStarving = false
 		g.survivalManager.IsDehydrated = false
+		g.survivalManager.Effects.Clear()
 	}
 
 	// Clear death state
@@ -2942,27 +3863,108 @@ func (g *Game) checkPlayerDeath() {
 
 	// Check if player health is 0
 	if g.player.Health <= 0 || (g.healthSystem != nil && g.healthSystem.OverallHealth <= 0) {
-		g.stateManager.SetState(ui.StateDeathScreen)
-
 		// Determine cause of death
 		cause := "Unknown"
 		if g.survivalManager != nil && g.survivalManager.IsStarving {
 			cause = "Starvation"
 		} else if g.survivalManager != nil && g.survivalManager.IsDehydrated {
 			cause = "Dehydration"
+		} else if g.survivalManager != nil && g.survivalManager.Effects.HealthPerSecond() < 0 {
+			cause = "Food poisoning"
 		} else {
 			cause = "Killed by Zombie"
 		}
 
+		// Plugins may prevent the death or change its cause
+		death := entities.CreateDeathEvent("player", cause, "")
+		if !g.publishPre(entities.EventDeath, &death) {
+			g.preventPlayerDeath()
+			return
+		}
+
+		g.stateManager.SetState(ui.StateDeathScreen)
+
 		// Trigger death screen
 		if g.deathScreen != nil {
-			g.deathScreen.Trigger(cause)
+			g.deathScreen.Trigger(death.Cause)
 		}
 
-		log.Printf("Player died: %s", cause)
+		log.Printf("Player died: %s", death.Cause)
+		g.publishPost(entities.EventDeath, death)
 	}
 }
 
+// preventPlayerDeath leaves the player alive with minimal health after a
+// plugin cancelled their death
+func (g *Game) preventPlayerDeath() {
+	if g.player.Health <= 0 {
+		g.player.Health = 1
+	}
+	if g.healthSystem != nil && g.healthSystem.OverallHealth <= 0 {
+		for i := 0; i < 6; i++ {
+			g.healthSystem.HealBodyPart(health.BodyPart(i), 1)
+		}
+	}
+}
+
+// initScriptPlugins loads the script plugins from the plugins directory,
+// sandboxed with the security settings of plugins.yaml, and sets up the
+// plugin manager UI over the configured repository
+func (g *Game) initScriptPlugins() {
+	g.eventBus = entities.NewEventBus()
+	g.scriptPlugins = entities.NewPluginManager(entities.NewEntityManager(), entities.NewSystemManager(), g.eventBus)
+	g.scriptPlugins.SetPluginPath(filepath.Join(getTesselboxDir(), "plugins"))
+
+	pluginConfig := entities.NewPluginConfigManager(filepath.Join(getTesselboxDir(), "config"))
+	if err := pluginConfig.LoadGlobalConfig(); err != nil {
+		log.Printf("Failed to load plugin config, using defaults: %v", err)
+	}
+	g.scriptPlugins.SetSecurityConfig(pluginConfig.GetGlobalConfig().Security)
+
+	if err := g.scriptPlugins.LoadAllPlugins(); err != nil {
+		log.Printf("Failed to load script plugins: %v", err)
+	}
+
+	g.pluginInstaller = plugins.NewPluginInstaller(g.scriptPlugins)
+	g.pluginInstaller.SetPluginsDirectory(filepath.Join(getTesselboxDir(), "plugins"))
+	repository := pluginConfig.GetGlobalConfig().Repository
+	if repository != "" && !strings.Contains(repository, "://") && !filepath.IsAbs(repository) {
+		repository = filepath.Join(getTesselboxDir(), repository)
+	}
+	if repository != "" {
+		if err := g.pluginInstaller.OpenRepository(repository); err != nil {
+			log.Printf("Plugin repository unavailable: %v", err)
+		}
+	}
+	g.pluginUI = plugins.NewPluginUI(g.scriptPlugins, g.pluginInstaller)
+}
+
+// publishPre runs the pre phase of a gameplay event, letting plugins modify
+// data (a pointer). It returns false when a plugin cancelled the action.
+func (g *Game) publishPre(eventType entities.EventType, data interface{}) bool {
+	if g.eventBus == nil {
+		return true
+	}
+	return !g.eventBus.PublishPre(eventType, "game", data).Cancelled
+}
+
+// publishPost announces a gameplay action after it has been applied
+func (g *Game) publishPost(eventType entities.EventType, data interface{}) {
+	if g.eventBus == nil {
+		return
+	}
+	g.eventBus.PublishWithSource(eventType, "game", data)
+}
+
+// selectedItemID returns the string ID of the held item, or "" for none
+func (g *Game) selectedItemID() string {
+	selectedItem := g.inventory.GetSelectedItem()
+	if selectedItem == nil || selectedItem.Type == items.NONE {
+		return ""
+	}
+	return items.ItemID(selectedItem.Type)
+}
+
 // Layout defines the game's layout
 func (g *Game) Layout(outsideWidth, outsideHeight int) (int, int) {
 	return ScreenWidth, ScreenHeight
@@ -3021,6 +4023,9 @@ func getBlockKeyFromType(blockType blocks.BlockType) string {
 	case blocks.CACTUS:
 		return "cactus"
 	default:
+		if id := blocks.BlockID(blockType); id != "" {
+			return id
+		}
 		return "dirt"
 	}
 }
@@ -3055,6 +4060,7 @@ func (g *Game) calculateMiningDamage(blockType blocks.BlockType) float64 {
 			// Tool damage = base damage * tool power / block hardness
 			// This makes tools much more effective against harder blocks
 			baseDamage = baseDamage * itemProps.ToolPower / math.Max(0.1, blockDef.Hardness)
+			baseDamage *= 1 + enchant.Bonus(selectedItem.Meta, enchant.StatMiningSpeed)
 		}
 	}
 
@@ -3071,32 +4077,6 @@ func (g *Game) calculateMiningDamage(blockType blocks.BlockType) float64 {
 	return baseDamage
 }
 
-// getItemFromBlockType converts a block type to the corresponding item type
-func (g *Game) getItemFromBlockType(blockType blocks.BlockType) items.ItemType {
-	switch blockType {
-	case blocks.DIRT:
-		return items.DIRT_BLOCK
-	case blocks.GRASS:
-		return items.GRASS_BLOCK
-	case blocks.STONE:
-		return items.STONE_BLOCK
-	case blocks.SAND:
-		return items.SAND_BLOCK
-	case blocks.LOG:
-		return items.LOG_BLOCK
-	case blocks.COAL_ORE:
-		return items.COAL
-	case blocks.IRON_ORE:
-		return items.IRON_INGOT // Could be iron ore, but using ingot for now
-	case blocks.GOLD_ORE:
-		return items.GOLD_INGOT
-	case blocks.DIAMOND_ORE:
-		return items.DIAMOND
-	default:
-		return items.NONE
-	}
-}
-
 // createSaveState creates a save state from the current game state
 func (g *Game) createSaveState() *save.GameState {
 	// Determine current dimension
@@ -3144,6 +4124,16 @@ func (g *Game) getUnlockedRecipesAsSlice() []string {
 	return recipes
 }
 
+// linkGeneratedStructures hands villages, dungeons and their chests found in
+// newly loaded chunks to the game systems; in multiplayer the server does this
+func (g *Game) linkGeneratedStructures() {
+	structures := g.world.TakeGeneratedStructures()
+	if g.netClient != nil || len(structures) == 0 {
+		return
+	}
+	g.world.LinkStructures(structures, g.villageManager, g.dungeonManager, g.chestManager)
+}
+
 // SaveGame saves the current game state
 func (g *Game) SaveGame() error {
 	if g.saveManager == nil {
@@ -3163,6 +4153,20 @@ func (g *Game) SaveGame() error {
 		}
 	}
 
+	// Save villages
+	if g.villageManager != nil {
+		if err := g.villageManager.Save(); err != nil {
+			log.Printf("Failed to save villages: %v", err)
+		}
+	}
+
+	// Save quest progress
+	if g.questManager != nil {
+		if err := g.questManager.Save(); err != nil {
+			log.Printf("Failed to save quests: %v", err)
+		}
+	}
+
 	// Save dimension state (Randomland)
 	if g.dimensionManager != nil {
 		if err := g.dimensionManager.Save(); err != nil {
@@ -3185,7 +4189,18 @@ func (g *Game) LoadGame() error {
 		return err
 	}
 
-	return g.saveManager.ApplySaveData(saveData, g.createSaveState())
+	if err := g.saveManager.ApplySaveData(saveData, g.createSaveState()); err != nil {
+		return err
+	}
+
+	// Restore the recipes the player was taught
+	g.craftingSystem.SetUnlockedRecipes(saveData.UnlockedRecipes)
+	g.UnlockedRecipes = make(map[string]bool, len(saveData.UnlockedRecipes))
+	for _, id := range saveData.UnlockedRecipes {
+		g.UnlockedRecipes[id] = true
+	}
+	g.learnRecipes()
+	return nil
 }
 
 // StartAutoSave starts the auto-saver
@@ -3312,10 +4327,10 @@ func (g *Game) updateBackgroundMusic() {
 				if timeOfDay == gametime.Dusk || timeOfDay == gametime.Night || timeOfDay == gametime.Midnight {
 					track = string(audio.MusicNight)
 				} else {
-					track = string(audio.MusicGameplay)
+					track = g.biomeMusic()
 				}
 			} else {
-				track = string(audio.MusicGameplay)
+				track = g.biomeMusic()
 			}
 		}
 
@@ -3325,13 +4340,17 @@ func (g *Game) updateBackgroundMusic() {
 	}
 }
 
+// biomeMusic returns the daytime music of the biome the player is in
+func (g *Game) biomeMusic() string {
+	if props := biomes.Definition(g.world.BiomeAt(g.player.X, g.player.Y)); props != nil && props.Music != "" {
+		return props.Music
+	}
+	return string(audio.MusicGameplay)
+}
+
 // updateAudioContext updates the audio context based on game state
 func (g *Game) updateAudioContext() {
-	// Determine current biome (simplified)
-	biome := biomes.PLAINS // Default biome
-	if g.player.Y < -500 {
-		biome = biomes.PLAINS // Keep as plains for underground for now
-	}
+	biome := g.world.BiomeAt(g.player.X, g.player.Y)
 
 	// Determine if underground
 	isUnderground := g.player.Y < 0
@@ -4171,36 +5190,6 @@ func startGameWithGUI(worldName string, worldSeed int64) {
 	}
 }
 
-// startGameCLI starts the game in CLI mode (for testing only)
-func startGameCLI() {
-	worldName := "default"
-	if len(os.Args) > 2 {
-		worldName = os.Args[2]
-	}
-
-	fmt.Printf("Starting game in world: %s\n", worldName)
-	fmt.Println("Game running... Press Ctrl+C to exit")
-
-	// Create game instance without GUI
-	game := NewGame()
-	game.stateManager.SetState(ui.StateGame)
-
-	// Simple game loop
-	for {
-		// Update game state
-		if err := game.Update(); err != nil {
-			fmt.Printf("Error: %v\n", err)
-			break
-		}
-
-		// Simple status display
-		px, py := game.player.GetCenter()
-		fmt.Printf("\rPosition: (%.1f, %.1f)  Chunks: %d", px, py, len(game.world.Chunks))
-
-		time.Sleep(50 * time.Millisecond)
-	}
-}
-
 // listWorldsCLI lists available worlds
 func listWorldsCLI() {
 	fmt.Println("Available worlds:")
@@ -4268,6 +5257,649 @@ func deleteWorldCLI() {
 	fmt.Printf("World '%s' deleted successfully!\n", worldName)
 }
 
+// convertWorldCLI upgrades a world saved as one JSON file per chunk to region files
+func convertWorldCLI() {
+	if len(os.Args) < 3 {
+		fmt.Println("Usage: tesselbox convert-world <world name>")
+		return
+	}
+	worldName := os.Args[2]
+
+	worldDir := filepath.Join(config.GetWorldsDir(), worldName)
+	if _, err := os.Stat(worldDir); os.IsNotExist(err) {
+		fmt.Printf("World '%s' not found\n", worldName)
+		return
+	}
+
+	fmt.Printf("Converting world: %s\n", worldName)
+
+	storage := world.NewWorldStorage(worldName)
+	converted, err := storage.ConvertLegacyChunks()
+	if err != nil {
+		fmt.Printf("Error converting world after %d chunks: %v\n", converted, err)
+		return
+	}
+
+	fmt.Printf("World '%s' converted: %d chunks moved to region files\n", worldName, converted)
+}
+
+// migrateWorldCLI upgrades every save file of a world to the current format
+func migrateWorldCLI() {
+	args := os.Args[2:]
+	dryRun := false
+	var worldName string
+	for _, arg := range args {
+		if arg == "--dry-run" {
+			dryRun = true
+		} else if worldName == "" {
+			worldName = arg
+		}
+	}
+	if worldName == "" {
+		fmt.Println("Usage: tesselbox migrate-world <world name> [--dry-run]")
+		return
+	}
+
+	if !world.WorldExists(worldName) {
+		if _, err := os.Stat(config.GetWorldSaveDir(worldName)); os.IsNotExist(err) {
+			fmt.Printf("World '%s' not found\n", worldName)
+			return
+		}
+	}
+
+	if dryRun {
+		fmt.Printf("Checking world (dry run): %s\n", worldName)
+	} else {
+		fmt.Printf("Migrating world: %s\n", worldName)
+	}
+
+	results, err := save.NewMigrator(dryRun).MigrateWorld(worldName)
+	fmt.Print(save.FormatMigrationResults(results))
+	if err != nil {
+		fmt.Printf("Error migrating world: %v\n", err)
+		return
+	}
+
+	if dryRun {
+		fmt.Println("Dry run complete, no files were changed")
+		return
+	}
+	fmt.Printf("World '%s' is up to date\n", worldName)
+}
+
+// connectCLI joins a multiplayer server and renders its world
+func connectCLI() {
+	args := os.Args[2:]
+	playerName := "player"
+	var addr string
+	for i := 0; i < len(args); i++ {
+		if args[i] == "--name" && i+1 < len(args) {
+			playerName = args[i+1]
+			i++
+		} else if addr == "" {
+			addr = args[i]
+		}
+	}
+	if addr == "" {
+		fmt.Println("Usage: tesselbox connect <host[:port]> [--name <player name>]")
+		return
+	}
+
+	// Load block definitions before any world generation
+	blocks.LoadBlocks()
+	getSharedWhiteImage()
+
+	fmt.Printf("Connecting to %s as %s...\n", addr, playerName)
+	client, err := network.Dial(addr, playerName)
+	if err != nil {
+		fmt.Printf("Error connecting: %v\n", err)
+		return
+	}
+	defer client.Close()
+	fmt.Printf("Joined world '%s'\n", client.Welcome.WorldName)
+
+	ebiten.SetWindowSize(ScreenWidth, ScreenHeight)
+	ebiten.SetWindowTitle("Tesselbox v2.0 - " + client.Welcome.WorldName)
+	ebiten.SetTPS(FPS)
+	ebiten.SetCursorMode(ebiten.CursorModeVisible)
+
+	if err := ebiten.RunGame(NewNetworkGame(client)); err != nil {
+		log.Fatalf("Failed to run game: %v", err)
+	}
+}
+
+// NewNetworkGame creates a game that renders a server's world. The world is
+// regenerated locally from the server's seed and overwritten by the chunks
+// the server streams, and nothing is saved on this machine.
+func NewNetworkGame(client *network.Client) *Game {
+	welcome := client.Welcome
+	g := NewGameWithWorld(welcome.WorldName, welcome.Seed)
+
+	g.world = world.NewRemoteWorld(welcome.WorldName, welcome.Seed, welcome.Generation)
+	g.player.SetPosition(welcome.SpawnX, welcome.SpawnY)
+	g.dayNightCycle.SetTime(welcome.WorldTime)
+	g.zombieSpawner.Mobs = nil
+
+	// Saves, chests and dimensions belong to the server; chests are filled
+	// in as they are opened
+	g.saveManager = nil
+	g.dimensionManager = nil
+	g.chestManager = chest.NewChestManager(welcome.WorldName)
+	g.chestUI.ChestManager = g.chestManager
+
+	g.netClient = client
+	g.remotePlayers = make(map[string]network.EntityState)
+	return g
+}
+
+// updateNetwork applies messages from the server and reports the player's position
+func (g *Game) updateNetwork() {
+	const maxMessagesPerFrame = 64
+
+	g.syncInventory()
+	g.syncEquipment()
+	for i := 0; i < maxMessagesPerFrame; i++ {
+		select {
+		case env, ok := <-g.netClient.Messages():
+			if !ok {
+				g.handleDisconnect()
+				return
+			}
+			if err := g.applyNetworkMessage(env); err != nil {
+				log.Printf("Bad message from server: %v", err)
+			}
+			continue
+		default:
+		}
+		break
+	}
+	g.applyServerInventory()
+
+	// Position updates at the server's tick rate are plenty
+	if time.Since(g.lastNetMove) >= time.Second/time.Duration(max(g.netClient.Welcome.TickRate, 1)) {
+		g.lastNetMove = time.Now()
+		if err := g.netClient.SendMove(g.player.X, g.player.Y, g.player.VX, g.player.VY); err != nil {
+			log.Printf("Failed to send position: %v", err)
+		}
+	}
+}
+
+// applyNetworkMessage applies one server message to the local game
+func (g *Game) applyNetworkMessage(env *network.Envelope) error {
+	switch env.Type {
+	case network.MsgChunk:
+		var msg network.ChunkMessage
+		if err := env.Decode(&msg); err != nil {
+			return err
+		}
+		g.world.ImportChunk(msg.Chunk, msg.Blocks)
+
+	case network.MsgBlockChange:
+		var change network.BlockChange
+		if err := env.Decode(&change); err != nil {
+			return err
+		}
+		if change.Player == g.netClient.Welcome.PlayerName {
+			return nil // Already applied locally
+		}
+		blockType, ok := blocks.BlockTypeByID(change.Block)
+		if !ok {
+			blockType = blocks.RegisterBlockType(change.Block)
+		}
+		g.world.RemoveHexagonAt(change.X, change.Y)
+		if blockType != blocks.AIR {
+			g.world.AddHexagonAt(change.X, change.Y, blockType)
+		}
+		// Liquids come with their level and whether they are a source
+		if hex := g.world.GetHexagonAt(change.X, change.Y); hex != nil && change.Level > 0 && blocks.IsLiquidBlock(hex.BlockType) {
+			hex.LiquidLevel = change.Level
+			hex.LiquidSource = change.Source
+		}
+
+	case network.MsgPlayerPosition:
+		var msg network.PlayerMove
+		if err := env.Decode(&msg); err != nil {
+			return err
+		}
+		g.player.SetPosition(msg.X, msg.Y)
+		g.player.SetVelocity(msg.VX, msg.VY)
+
+	case network.MsgDamage:
+		var msg network.Damage
+		if err := env.Decode(&msg); err != nil {
+			return err
+		}
+		g.applyNetworkDamage(msg)
+
+	case network.MsgInventory:
+		var msg network.Inventory
+		if err := env.Decode(&msg); err != nil {
+			return err
+		}
+		// Inventories from before the server saw the latest local action
+		// would undo it
+		if g.netClient.Acknowledged(msg.Ack) {
+			g.pendingInventory = &msg
+		}
+
+	case network.MsgContainer:
+		var msg network.Container
+		if err := env.Decode(&msg); err != nil {
+			return err
+		}
+		if g.netClient.Acknowledged(msg.Ack) {
+			g.pendingContainer = &msg
+		}
+
+	case network.MsgEntities:
+		var msg network.Entities
+		if err := env.Decode(&msg); err != nil {
+			return err
+		}
+		g.applyEntities(msg)
+
+	case network.MsgChat:
+		var msg chat.ChatMessage
+		if err := env.Decode(&msg); err != nil {
+			return err
+		}
+		g.addChatLine(fmt.Sprintf("<%s> %s", msg.SenderName, msg.Content))
+	}
+	return nil
+}
+
+// applyNetworkDamage applies a projectile hit the server reports. The server
+// has already hurt its copy of the player, so plugins can only change what
+// happens locally.
+func (g *Game) applyNetworkDamage(msg network.Damage) {
+	hit := entities.CreateCombatEvent(msg.Attacker, g.playerID(), msg.Amount, msg.Weapon, msg.Critical, false)
+	if !g.publishPre(entities.EventDamage, &hit) {
+		return
+	}
+	g.player.TakeDamage(hit.Damage)
+	hit.Killed = g.player.Health <= 0
+
+	g.publishPost(entities.EventDamage, hit)
+	if hit.Killed {
+		g.publishPost(entities.EventDeath, entities.CreateDeathEvent(hit.TargetID, "Shot by "+hit.AttackerID, hit.AttackerID))
+	}
+	if g.damageIndicators != nil {
+		g.damageIndicators.SpawnDamageIndicator(g.player.X, g.player.Y, hit.Damage, ui.TierYellow, msg.Critical)
+	}
+}
+
+// applyServerInventory applies the newest inventory and container contents
+// the server sent, unless the player is dragging an item around
+func (g *Game) applyServerInventory() {
+	if g.draggingItem() {
+		return
+	}
+	if msg := g.pendingInventory; msg != nil {
+		g.pendingInventory = nil
+		g.applyInventory(*msg)
+	}
+	if msg := g.pendingContainer; msg != nil {
+		g.pendingContainer = nil
+		g.applyContainer(*msg)
+	}
+}
+
+// applyInventory replaces the local inventory with the server's
+func (g *Game) applyInventory(msg network.Inventory) {
+	for i := range g.inventory.Slots {
+		if i >= len(msg.Slots) {
+			g.inventory.Slots[i] = items.Item{Type: items.NONE, Durability: -1}
+			continue
+		}
+		g.inventory.Slots[i] = netStack(msg.Slots[i])
+	}
+	g.netInventory = cloneStacks(g.inventory.Slots)
+	g.learnRecipes()
+}
+
+// applyContainer replaces what is in the open chest or furnace with the
+// server's contents
+func (g *Game) applyContainer(msg network.Container) {
+	open := g.netOpen
+	if open == nil || open.Kind != msg.Kind || open.X != msg.X || open.Y != msg.Y {
+		return // Closed since
+	}
+	contents := make([]items.Item, len(msg.Slots))
+	for i, slot := range msg.Slots {
+		contents[i] = netStack(slot)
+	}
+
+	if msg.Kind == network.ContainerChest {
+		copy(g.chestManager.GetChest(msg.X, msg.Y).Slots, contents)
+	} else {
+		s := g.world.StationAt(msg.X, msg.Y)
+		if s == nil {
+			return
+		}
+		copy(s.Slots[:], contents)
+		s.Burn, s.BurnTotal, s.Progress = msg.Burn, msg.BurnTotal, msg.Progress
+	}
+	g.netContents = cloneStacks(contents)
+}
+
+// containerContents returns a copy of what is in the open chest or furnace
+// locally, or nil if it is gone
+func (g *Game) containerContents(open *network.Container) []items.Item {
+	if open.Kind == network.ContainerChest {
+		return cloneStacks(g.chestManager.GetChest(open.X, open.Y).Slots)
+	}
+	s := g.world.StationAt(open.X, open.Y)
+	if s == nil {
+		return nil
+	}
+	return cloneStacks(s.Slots[:])
+}
+
+// syncInventory sends the inventory, and the open chest or furnace, to the
+// server when the player has moved items around or dropped some since the
+// server last agreed on them. Crafting and other actions are sent as they
+// happen, before this, so the server has already made the same change.
+func (g *Game) syncInventory() {
+	if g.netInventory == nil || g.draggingItem() {
+		return // Nothing from the server yet, or an item is in mid-air
+	}
+
+	var contents []items.Item
+	if g.netOpen != nil {
+		if g.netContents == nil {
+			return // Wait to see what the server has in it
+		}
+		contents = g.containerContents(g.netOpen)
+	}
+	if len(g.netDrops) == 0 && sameStacks(g.inventory.Slots, g.netInventory) &&
+		(contents == nil || sameStacks(contents, g.netContents)) {
+		return
+	}
+
+	msg := network.InventorySync{
+		Slots:   network.NewInventorySlots(g.inventory.Slots),
+		Dropped: network.NewInventorySlots(g.netDrops),
+	}
+	if contents != nil {
+		msg.Container = &network.Container{
+			Kind:  g.netOpen.Kind,
+			X:     g.netOpen.X,
+			Y:     g.netOpen.Y,
+			Slots: network.NewInventorySlots(contents),
+		}
+	}
+	if err := g.netClient.SendInventorySync(msg); err != nil {
+		log.Printf("Failed to send inventory: %v", err)
+		return
+	}
+	g.netInventory = cloneStacks(g.inventory.Slots)
+	if contents != nil {
+		g.netContents = contents
+	}
+	g.netDrops = nil
+}
+
+// syncEquipment tells the multiplayer server about armor the player put on,
+// took off or enchanted
+func (g *Game) syncEquipment() {
+	msg := network.NewEquipment(g.equipmentSet)
+	armor, err := json.Marshal(msg)
+	if err != nil || bytes.Equal(armor, g.netArmor) {
+		return
+	}
+	if err := g.netClient.SendEquipment(msg); err != nil {
+		log.Printf("Failed to send equipment: %v", err)
+		return
+	}
+	g.netArmor = armor
+}
+
+// openNetContainer asks the multiplayer server for what is in the chest or
+// station the player opened
+func (g *Game) openNetContainer(kind string, x, y float64) {
+	if g.netClient == nil {
+		return
+	}
+	g.netOpen = &network.Container{Kind: kind, X: x, Y: y}
+	g.netContents = nil
+	g.pendingContainer = nil
+	if err := g.netClient.SendOpenContainer(kind, x, y); err != nil {
+		log.Printf("Failed to open %s: %v", kind, err)
+	}
+}
+
+// closeNetContainer sends what the player left in the open chest or furnace
+// and tells the server it was closed
+func (g *Game) closeNetContainer() {
+	g.syncInventory()
+	g.netOpen = nil
+	g.netContents = nil
+	g.pendingContainer = nil
+	if err := g.netClient.SendCloseContainer(); err != nil {
+		log.Printf("Failed to close container: %v", err)
+	}
+}
+
+// draggingItem reports whether the player holds an item picked up from a
+// slot, which is in neither the inventory nor a container
+func (g *Game) draggingItem() bool {
+	return g.backpackUI.DraggedItem != nil || g.chestUI.DraggedItem != nil || g.furnaceUI.DraggedItem != nil
+}
+
+// sendCraft tells the multiplayer server about a craft, so it crafts the
+// same in its copy of the inventory
+func (g *Game) sendCraft(recipeID string) {
+	if g.netClient == nil {
+		return
+	}
+	station := g.craftingUI.GetCurrentStation().Name()
+	if err := g.netClient.SendCraft(recipeID, station, g.inventory.Selected); err != nil {
+		log.Printf("Failed to send craft: %v", err)
+	}
+}
+
+// sendAnvilWork tells the multiplayer server about an item enchanted,
+// combined or repaired at an anvil
+func (g *Game) sendAnvilWork(slot, second int, enchantment string, level int) {
+	if g.netClient == nil {
+		return
+	}
+	work := network.Anvil{Slot: slot, Second: second, Enchantment: enchantment, Level: level}
+	if err := g.netClient.SendAnvil(work); err != nil {
+		log.Printf("Failed to send anvil work: %v", err)
+	}
+}
+
+// sendUseItem asks the multiplayer server to use the selected item on a
+// point, such as eating it or filling it with water
+func (g *Game) sendUseItem(targetX, targetY float64) {
+	if g.netClient == nil {
+		return
+	}
+	slot := g.inventory.Selected
+	itemID := items.ItemID(g.inventory.Slots[slot].Type)
+	if err := g.netClient.SendUseItem(slot, itemID, targetX, targetY); err != nil {
+		log.Printf("Failed to send item use: %v", err)
+	}
+}
+
+// sendPickUp asks the multiplayer server for a dropped item. It stays in
+// the world until the server's next snapshot leaves it out.
+func (g *Game) sendPickUp(item *DroppedItem) {
+	item.PickupRetry = time.Now().Add(time.Second)
+	if item.NetID == 0 {
+		return // Only shown here, so there is nothing to pick up
+	}
+	if err := g.netClient.SendPickUp(item.NetID); err != nil {
+		log.Printf("Failed to send pickup: %v", err)
+	}
+}
+
+// applyItemDrops shows the items the server has lying near the player. Ones
+// already shown keep the position local physics gave them.
+func (g *Game) applyItemDrops(drops []network.ItemDrop) {
+	shown := make(map[uint64]*DroppedItem)
+	var kept []*DroppedItem
+	for _, item := range g.droppedItems {
+		if item.NetID == 0 {
+			kept = append(kept, item)
+		} else {
+			shown[item.NetID] = item
+		}
+	}
+	for _, drop := range drops {
+		stack := netStack(drop.Item)
+		if item, ok := shown[drop.ID]; ok {
+			item.Quantity = stack.Quantity
+			kept = append(kept, item)
+			continue
+		}
+		kept = append(kept, &DroppedItem{
+			Type:     stack.Type,
+			Quantity: stack.Quantity,
+			Meta:     stack.Meta,
+			X:        drop.X,
+			Y:        drop.Y,
+			Lifetime: time.Now().Add(5 * time.Minute), // The server expires it first
+			NetID:    drop.ID,
+		})
+	}
+	g.droppedItems = kept
+}
+
+// netStack converts a slot from the server to an item stack, registering
+// item IDs this client does not know
+func netStack(slot network.InventorySlot) items.Item {
+	if _, ok := items.ItemTypeByID(slot.Item); !ok {
+		items.RegisterItemType(slot.Item)
+	}
+	stack, _ := slot.Stack()
+	return stack
+}
+
+// cloneStacks copies item stacks along with their metadata
+func cloneStacks(stacks []items.Item) []items.Item {
+	clone := make([]items.Item, len(stacks))
+	for i, stack := range stacks {
+		stack.Meta = stack.Meta.Clone()
+		clone[i] = stack
+	}
+	return clone
+}
+
+// sameStacks reports whether two lists of slots hold the same stacks
+func sameStacks(a, b []items.Item) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		emptyA := a[i].Type == items.NONE || a[i].Quantity <= 0
+		emptyB := b[i].Type == items.NONE || b[i].Quantity <= 0
+		if emptyA || emptyB {
+			if emptyA != emptyB {
+				return false
+			}
+			continue
+		}
+		if a[i].Type != b[i].Type || a[i].Quantity != b[i].Quantity ||
+			a[i].Durability != b[i].Durability || !a[i].Meta.Equal(b[i].Meta) {
+			return false
+		}
+	}
+	return true
+}
+
+// applyEntities replaces remote players, zombies and creatures with the
+// server's snapshot
+func (g *Game) applyEntities(msg network.Entities) {
+	g.dayNightCycle.SetTime(msg.WorldTime)
+
+	players := make(map[string]network.EntityState)
+	var zombies, creatures []*mobs.Mob
+	for _, entity := range msg.Entities {
+		switch entity.Kind {
+		case network.EntityPlayer:
+			if entity.ID != g.netClient.Welcome.PlayerName {
+				players[entity.ID] = entity
+			}
+		case network.EntityZombie, network.EntityCreature:
+			mobType := entity.Mob
+			if mobType == "" && entity.Kind == network.EntityZombie {
+				mobType = "zombie" // Sent by servers from before mob types
+			}
+			def, ok := mobs.GetType(mobType)
+			if !ok {
+				continue
+			}
+			mob := mobs.NewMob(def, entity.ID, entity.X, entity.Y)
+			mob.Health = entity.Health
+			mob.MaxHealth = entity.MaxHealth
+			mob.IsBurning = entity.Burning
+			if entity.Kind == network.EntityZombie {
+				zombies = append(zombies, mob)
+			} else {
+				creatures = append(creatures, mob)
+			}
+		}
+	}
+	g.remotePlayers = players
+	g.zombieSpawner.Mobs = zombies
+	g.world.Creatures = creatures
+	g.applyItemDrops(msg.Items)
+}
+
+// sendBlockChange tells the multiplayer server about a local block edit
+func (g *Game) sendBlockChange(x, y float64, blockType blocks.BlockType) {
+	if g.netClient == nil {
+		return
+	}
+	if err := g.netClient.SendBlockChange(x, y, blocks.BlockID(blockType)); err != nil {
+		log.Printf("Failed to send block change: %v", err)
+	}
+}
+
+// handleDisconnect returns to single-player state after losing the server
+func (g *Game) handleDisconnect() {
+	reason := "connection closed"
+	if err := g.netClient.Err(); err != nil {
+		reason = err.Error()
+	}
+	log.Printf("Disconnected from server: %s", reason)
+	g.addChatLine("Disconnected: " + reason)
+	g.netClient = nil
+	g.remotePlayers = nil
+	g.netOpen = nil
+}
+
+// addChatLine keeps the last few chat lines for display
+func (g *Game) addChatLine(line string) {
+	const maxChatLines = 8
+	g.chatLog = append(g.chatLog, line)
+	if len(g.chatLog) > maxChatLines {
+		g.chatLog = g.chatLog[len(g.chatLog)-maxChatLines:]
+	}
+}
+
+// drawRemotePlayers draws other players as name-tagged blocky figures
+func (g *Game) drawRemotePlayers(screen *ebiten.Image) {
+	for name, entity := range g.remotePlayers {
+		screenX := entity.X - g.cameraX
+		screenY := entity.Y - g.cameraY
+		if screenX < -100 || screenX > ScreenWidth+100 || screenY < -100 || screenY > ScreenHeight+100 {
+			continue
+		}
+
+		nameX := int(screenX + player.PlayerWidth/2 - float64(len(name)*4))
+		nameY := int(screenY - 35)
+		ebitenutil.DrawRect(screen, float64(nameX-5), float64(nameY-2), float64(len(name)*8+10), 14, color.RGBA{0, 0, 0, 150})
+		ebitenutil.DebugPrintAt(screen, name, nameX, nameY)
+
+		headSize := 25.0
+		ebitenutil.DrawRect(screen, screenX-5, screenY+10, player.PlayerWidth+10, player.PlayerHeight-10, g.colorToRGB(30, 90, 160))
+		ebitenutil.DrawRect(screen, screenX+(player.PlayerWidth-headSize)/2, screenY-5, headSize, headSize, g.colorToRGB(255, 200, 150))
+	}
+}
+
 // handlePortalTeleportation checks for and handles portal teleportation
 func (g *Game) handlePortalTeleportation() {
 	if g.dimensionManager == nil {
@@ -4370,6 +6002,26 @@ func main() {
 		fmt.Printf("⚠️ Failed to initialize storage: %v\n", err)
 	}
 
+	// Maintenance subcommands run without opening a window
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "convert-world":
+			convertWorldCLI()
+			return
+		case "migrate-world":
+			migrateWorldCLI()
+			return
+		case "connect":
+			connectCLI()
+			return
+		case "server":
+			if err := network.RunDedicated(os.Args[2:]); err != nil {
+				log.Fatalf("Server error: %v", err)
+			}
+			return
+		}
+	}
+
 	// Run pixel art GUI
 	runGUI()
 }
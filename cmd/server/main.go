@@ -0,0 +1,16 @@
+// Command tesselbox-server runs a dedicated world simulation without any
+// graphics dependencies, so it builds and runs on machines with no display.
+package main
+
+import (
+	"log"
+	"os"
+
+	"tesselbox/pkg/network"
+)
+
+func main() {
+	if err := network.RunDedicated(os.Args[1:]); err != nil {
+		log.Fatalf("Server error: %v", err)
+	}
+}
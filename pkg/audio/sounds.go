@@ -258,6 +258,8 @@ func (sl *SoundLibrary) PlayMusic(context string) {
 			track = MusicUnderground
 		} else if sl.timeOfDay == "night" {
 			track = MusicNight
+		} else if props := biomes.Definition(sl.currentBiome); props != nil && props.Music != "" {
+			track = MusicTrack(props.Music)
 		} else {
 			track = MusicGameplay
 		}
@@ -25,8 +25,10 @@ const (
 	MANGROVE
 )
 
-// BiomeProperties defines properties of a biome
+// BiomeProperties defines properties of a biome. The built-in definitions
+// below are overridden by assets/config/biomes.yaml and extended by plugins.
 type BiomeProperties struct {
+	ID           string // Key in biomes.yaml, such as "ice_fields"
 	Name         string
 	SurfaceBlock string
 	UnderBlock   string
@@ -34,11 +36,175 @@ type BiomeProperties struct {
 	OreFrequency float64
 	Temperature  float64
 	Humidity     float64
+
+	// Placement: GetBiomeAtPosition checks climate ranges from the highest
+	// priority down, so overrides such as mountains outrank the land biomes
+	Priority int
+	Climate  []ClimateRange // Empty for biomes that never generate on their own
+
+	// Height profile and layers
+	BaseHeight      float64 // World Y of the surface before terrain noise
+	HeightScale     float64 // Scales terrain noise; 0 leaves it unscaled
+	SurfaceDepth    float64 // Depth the surface block reaches; 0 uses DefaultSurfaceDepth
+	SubsurfaceDepth float64 // Depth the under block reaches; 0 uses DefaultSubsurfaceDepth
+	Flooded         bool    // Open air below sea level fills with water
+
+	// Underground generation
+	CaveDensity float64   // Scales how much rock caves carve away; 0 disables caves
+	LakeChance  float64   // Chance a stretch of cave floods into an underground lake
+	LakeLiquid  string    // Block filling shallow lakes; deep lakes are always lava
+	OreVeins    []OreVein // Ore deposits by depth band; nil uses DefaultOreVeins
+
+	// Life
+	Trees     []TreeChance     // Tree variants by weight; nil grows oaks
+	Organisms []OrganismChance // Surface organisms, checked in order
+	Creatures CreatureSpawns
+
+	// Ambience
+	Music   string          // Daytime music track; empty plays the gameplay track
+	Weather *WeatherWeights // Nil leaves weather chances unweighted
+}
+
+// Default layer depths, in pixels below the surface
+const (
+	DefaultSurfaceDepth    = 5.0
+	DefaultSubsurfaceDepth = 15.0
+)
+
+// Range is a half-open interval [Min, Max) of a normalised climate value.
+// The zero Range places no limit.
+type Range struct {
+	Min, Max float64
+}
+
+// Below returns the range of values under max
+func Below(max float64) Range {
+	return Range{Min: math.Inf(-1), Max: max}
+}
+
+// Above returns the range of values at or over min
+func Above(min float64) Range {
+	return Range{Min: min, Max: math.Inf(1)}
+}
+
+// Between returns the range of values from min up to max
+func Between(min, max float64) Range {
+	return Range{Min: min, Max: max}
+}
+
+// Contains reports whether a value lies in the range
+func (r Range) Contains(v float64) bool {
+	if r == (Range{}) {
+		return true
+	}
+	return v >= r.Min && v < r.Max
+}
+
+// ClimateRange is one region of climate a biome generates in. Values are the
+// normalised 0-1 noise fields GetBiomeAtPosition samples.
+type ClimateRange struct {
+	Temperature Range
+	Humidity    Range
+	Elevation   Range
+	Continental Range
+}
+
+// Contains reports whether a climate sample lies in every range
+func (c ClimateRange) Contains(temp, humid, elev, continental float64) bool {
+	return c.Temperature.Contains(temp) && c.Humidity.Contains(humid) &&
+		c.Elevation.Contains(elev) && c.Continental.Contains(continental)
+}
+
+// TreeChance weights one tree variant, such as "oak" or "spruce"
+type TreeChance struct {
+	Tree   string  `yaml:"tree"`
+	Weight float64 `yaml:"weight"`
+}
+
+// OrganismChance is the chance a surface cell grows an organism
+type OrganismChance struct {
+	Organism string  `yaml:"organism"`
+	Chance   float64 `yaml:"chance"`
+}
+
+// CreatureSpawns lists the creatures a biome spawns by weight. A nil Night
+// table uses DefaultNightCreatures; nothing spawns by day unless listed.
+type CreatureSpawns struct {
+	Day   []CreatureChance `yaml:"day,omitempty"`
+	Night []CreatureChance `yaml:"night,omitempty"`
 }
 
-// BiomeDefinitions holds all biome type definitions
+// CreatureChance weights one creature, such as "zombie"
+type CreatureChance struct {
+	Creature string  `yaml:"creature"`
+	Weight   float64 `yaml:"weight"`
+}
+
+// DefaultNightCreatures are the night spawns of biomes that do not list their own
+var DefaultNightCreatures = []CreatureChance{
+	{Creature: "slime", Weight: 1},
+	{Creature: "spider", Weight: 1},
+	{Creature: "zombie", Weight: 1},
+}
+
+// WeatherWeights scale the chance of each kind of weather starting
+type WeatherWeights struct {
+	Rain  float64 `yaml:"rain"`
+	Storm float64 `yaml:"storm"`
+	Snow  float64 `yaml:"snow"`
+}
+
+// OreVein describes clustered deposits of one block within a band of depth
+// below the surface. Depths are in pixels, like terrain depth.
+type OreVein struct {
+	Block    string  `yaml:"block"`     // Block name, such as "iron_ore"
+	MinDepth float64 `yaml:"min_depth"` // Shallowest depth the vein can reach
+	MaxDepth float64 `yaml:"max_depth"` // Deepest depth the vein can reach
+	Chance   float64 `yaml:"chance"`    // Chance a patch of the band holds a vein, before OreFrequency
+	Size     int     `yaml:"size"`      // Typical vein radius in grid cells
+}
+
+// DefaultOreVeins are the ore deposits of biomes that do not list their own.
+// Earlier entries win where veins overlap.
+var DefaultOreVeins = []OreVein{
+	{Block: "diamond_ore", MinDepth: 1800, MaxDepth: 3400, Chance: 0.08, Size: 1},
+	{Block: "gold_ore", MinDepth: 900, MaxDepth: 2600, Chance: 0.12, Size: 2},
+	{Block: "iron_ore", MinDepth: 200, MaxDepth: 2000, Chance: 0.25, Size: 2},
+	{Block: "coal_ore", MinDepth: 60, MaxDepth: 1400, Chance: 0.35, Size: 3},
+	{Block: "gravel", MinDepth: 60, MaxDepth: 900, Chance: 0.2, Size: 3},
+}
+
+// volcanicOreVeins adds obsidian to the default deposits
+var volcanicOreVeins = append([]OreVein{
+	{Block: "obsidian", MinDepth: 200, MaxDepth: 2600, Chance: 0.3, Size: 2},
+}, DefaultOreVeins...)
+
+// desertOreVeins swaps gravel for layers of sandstone
+var desertOreVeins = []OreVein{
+	{Block: "diamond_ore", MinDepth: 1800, MaxDepth: 3400, Chance: 0.08, Size: 1},
+	{Block: "gold_ore", MinDepth: 600, MaxDepth: 2600, Chance: 0.16, Size: 2},
+	{Block: "iron_ore", MinDepth: 200, MaxDepth: 2000, Chance: 0.2, Size: 2},
+	{Block: "coal_ore", MinDepth: 60, MaxDepth: 1400, Chance: 0.25, Size: 2},
+	{Block: "sandstone", MinDepth: 15, MaxDepth: 700, Chance: 0.4, Size: 4},
+}
+
+// Trees and organisms shared by several biomes
+var (
+	oakTrees            = []TreeChance{{Tree: "oak", Weight: 1}}
+	grasslandOrganisms  = []OrganismChance{{Organism: "tree", Chance: 0.05}, {Organism: "bush", Chance: 0.05}, {Organism: "flower", Chance: 0.05}}
+	drylandWeather      = &WeatherWeights{Rain: 0.2, Storm: 0.5, Snow: 0}
+	coldWeather         = &WeatherWeights{Rain: 0.3, Storm: 0.5, Snow: 4}
+	wetlandWeather      = &WeatherWeights{Rain: 2, Storm: 1.5, Snow: 0}
+	swampNightCreatures = []CreatureChance{{Creature: "slime", Weight: 3}, {Creature: "spider", Weight: 1}, {Creature: "zombie", Weight: 1}}
+	grazingCreatures    = CreatureSpawns{Day: []CreatureChance{{Creature: "boar", Weight: 1}}}
+)
+
+// BiomeDefinitions holds the definition in effect for every biome type. It
+// starts out with the built-in definitions; read it through Definition once
+// plugins may be registering biomes.
 var BiomeDefinitions = map[BiomeType]*BiomeProperties{
 	PLAINS: {
+		ID:           "plains",
 		Name:         "Plains",
 		SurfaceBlock: "grass",
 		UnderBlock:   "dirt",
@@ -46,8 +212,19 @@ var BiomeDefinitions = map[BiomeType]*BiomeProperties{
 		OreFrequency: 1.0,
 		Temperature:  0.5,
 		Humidity:     0.5,
+		Climate: []ClimateRange{
+			{Temperature: Between(0.3, 0.5), Humidity: Between(0.5, 0.7)},
+		},
+		BaseHeight:  400,
+		CaveDensity: 1.0,
+		LakeChance:  0.25,
+		LakeLiquid:  "water",
+		Trees:       oakTrees,
+		Organisms:   grasslandOrganisms,
+		Creatures:   grazingCreatures,
 	},
 	FOREST: {
+		ID:           "forest",
 		Name:         "Forest",
 		SurfaceBlock: "grass",
 		UnderBlock:   "dirt",
@@ -55,8 +232,19 @@ var BiomeDefinitions = map[BiomeType]*BiomeProperties{
 		OreFrequency: 1.0,
 		Temperature:  0.4,
 		Humidity:     0.7,
+		Climate: []ClimateRange{
+			{Temperature: Between(0.5, 0.7), Humidity: Between(0.5, 0.7)},
+		},
+		BaseHeight:  400,
+		CaveDensity: 1.0,
+		LakeChance:  0.3,
+		LakeLiquid:  "water",
+		Trees:       []TreeChance{{Tree: "oak", Weight: 0.7}, {Tree: "birch", Weight: 0.3}},
+		Organisms:   []OrganismChance{{Organism: "tree", Chance: 0.15}, {Organism: "bush", Chance: 0.10}, {Organism: "flower", Chance: 0.10}},
+		Creatures:   grazingCreatures,
 	},
 	DESERT: {
+		ID:           "desert",
 		Name:         "Desert",
 		SurfaceBlock: "sand",
 		UnderBlock:   "sand",
@@ -64,17 +252,39 @@ var BiomeDefinitions = map[BiomeType]*BiomeProperties{
 		OreFrequency: 0.5,
 		Temperature:  0.9,
 		Humidity:     0.1,
+		Climate: []ClimateRange{
+			{Temperature: Above(0.3), Humidity: Below(0.3)},
+		},
+		BaseHeight:  380,
+		CaveDensity: 1.2,
+		LakeChance:  0.1,
+		LakeLiquid:  "water",
+		OreVeins:    desertOreVeins,
+		Trees:       oakTrees, // Small oaks in oases
+		Organisms:   []OrganismChance{{Organism: "cactus", Chance: 0.02}, {Organism: "dead_bush", Chance: 0.03}},
+		Creatures:   CreatureSpawns{Night: []CreatureChance{{Creature: "zombie", Weight: 2}, {Creature: "spider", Weight: 1}}},
+		Weather:     drylandWeather,
 	},
 	BADLANDS: {
+		// No climate of its own yet; plugins and biomes.yaml can give it one
+		ID:           "badlands",
 		Name:         "Badlands",
-		SurfaceBlock: "red_sand",
-		UnderBlock:   "red_sand",
+		SurfaceBlock: "sand",
+		UnderBlock:   "sandstone",
 		TreeDensity:  0.0,
 		OreFrequency: 0.5,
 		Temperature:  0.9,
 		Humidity:     0.1,
+		BaseHeight:   400,
+		CaveDensity:  1.3,
+		LakeChance:   0.15,
+		LakeLiquid:   "lava",
+		OreVeins:     desertOreVeins,
+		Organisms:    grasslandOrganisms,
+		Weather:      drylandWeather,
 	},
 	MOUNTAINS: {
+		ID:           "mountains",
 		Name:         "Mountains",
 		SurfaceBlock: "stone",
 		UnderBlock:   "stone",
@@ -82,17 +292,39 @@ var BiomeDefinitions = map[BiomeType]*BiomeProperties{
 		OreFrequency: 2.0,
 		Temperature:  0.3,
 		Humidity:     0.3,
+		Priority:     50,
+		Climate: []ClimateRange{
+			{Continental: Below(0.3), Elevation: Above(0.8)},
+		},
+		BaseHeight:  320,
+		CaveDensity: 1.4,
+		LakeChance:  0.2,
+		LakeLiquid:  "water",
+		Organisms:   []OrganismChance{{Organism: "bush", Chance: 0.03}},
+		Weather:     &WeatherWeights{Rain: 1, Storm: 1, Snow: 2},
 	},
 	OCEAN: {
+		ID:           "ocean",
 		Name:         "Ocean",
-		SurfaceBlock: "water",
+		SurfaceBlock: "sand",
 		UnderBlock:   "sand",
 		TreeDensity:  0.0,
 		OreFrequency: 0.3,
 		Temperature:  0.6,
 		Humidity:     1.0,
+		Priority:     10,
+		Climate: []ClimateRange{
+			{Elevation: Below(0.35)},
+		},
+		BaseHeight:  550,
+		Flooded:     true,
+		CaveDensity: 0.3,
+		LakeChance:  0.1,
+		LakeLiquid:  "water",
+		Organisms:   grasslandOrganisms,
 	},
 	SWAMP: {
+		ID:           "swamp",
 		Name:         "Swamp",
 		SurfaceBlock: "grass",
 		UnderBlock:   "dirt",
@@ -100,8 +332,20 @@ var BiomeDefinitions = map[BiomeType]*BiomeProperties{
 		OreFrequency: 0.8,
 		Temperature:  0.6,
 		Humidity:     0.9,
+		Climate: []ClimateRange{
+			{Temperature: Between(0.3, 0.9), Humidity: Above(0.7)},
+		},
+		BaseHeight:  420,
+		CaveDensity: 0.8,
+		LakeChance:  0.5,
+		LakeLiquid:  "water",
+		Trees:       oakTrees,
+		Organisms:   []OrganismChance{{Organism: "bush", Chance: 0.08}, {Organism: "flower", Chance: 0.04}},
+		Creatures:   CreatureSpawns{Night: swampNightCreatures},
+		Weather:     wetlandWeather,
 	},
 	TAIGA: {
+		ID:           "taiga",
 		Name:         "Taiga",
 		SurfaceBlock: "grass",
 		UnderBlock:   "dirt",
@@ -109,17 +353,40 @@ var BiomeDefinitions = map[BiomeType]*BiomeProperties{
 		OreFrequency: 1.2,
 		Temperature:  0.2,
 		Humidity:     0.6,
+		Climate: []ClimateRange{
+			{Temperature: Below(0.3), Humidity: Between(0.5, 0.7)},
+		},
+		BaseHeight:  380,
+		CaveDensity: 1.0,
+		LakeChance:  0.25,
+		LakeLiquid:  "water",
+		Trees:       []TreeChance{{Tree: "spruce", Weight: 1}},
+		Organisms:   []OrganismChance{{Organism: "tree", Chance: 0.12}, {Organism: "bush", Chance: 0.06}},
+		Creatures:   grazingCreatures,
+		Weather:     &WeatherWeights{Rain: 0.7, Storm: 0.7, Snow: 2},
 	},
 	TUNDRA: {
+		ID:           "tundra",
 		Name:         "Tundra",
 		SurfaceBlock: "snow",
-		UnderBlock:   "dirt",
+		UnderBlock:   "snow",
 		TreeDensity:  0.05,
 		OreFrequency: 0.7,
 		Temperature:  0.1,
 		Humidity:     0.3,
+		Climate: []ClimateRange{
+			{Temperature: Below(0.3), Humidity: Below(0.3)},
+			{Temperature: Below(0.3), Humidity: Above(0.7)},
+		},
+		BaseHeight:  360,
+		CaveDensity: 0.9,
+		LakeChance:  0.2,
+		LakeLiquid:  "water",
+		Organisms:   []OrganismChance{{Organism: "ice_shrub", Chance: 0.01}},
+		Weather:     coldWeather,
 	},
 	JUNGLE: {
+		ID:           "jungle",
 		Name:         "Jungle",
 		SurfaceBlock: "grass",
 		UnderBlock:   "dirt",
@@ -127,8 +394,20 @@ var BiomeDefinitions = map[BiomeType]*BiomeProperties{
 		OreFrequency: 0.9,
 		Temperature:  0.8,
 		Humidity:     0.95,
+		Climate: []ClimateRange{
+			{Temperature: Between(0.7, 0.9), Humidity: Between(0.5, 0.7)},
+			{Temperature: Above(0.9), Humidity: Above(0.7)},
+		},
+		BaseHeight:  400,
+		CaveDensity: 1.1,
+		LakeChance:  0.4,
+		LakeLiquid:  "water",
+		Trees:       []TreeChance{{Tree: "jungle", Weight: 1}},
+		Organisms:   []OrganismChance{{Organism: "tree", Chance: 0.25}, {Organism: "bush", Chance: 0.10}},
+		Weather:     wetlandWeather,
 	},
 	SAVANNA: {
+		ID:           "savanna",
 		Name:         "Savanna",
 		SurfaceBlock: "grass",
 		UnderBlock:   "dirt",
@@ -136,26 +415,60 @@ var BiomeDefinitions = map[BiomeType]*BiomeProperties{
 		OreFrequency: 0.6,
 		Temperature:  0.85,
 		Humidity:     0.4,
+		Climate: []ClimateRange{
+			{Temperature: Between(0.3, 0.9), Humidity: Between(0.3, 0.5)},
+			{Temperature: Above(0.9), Humidity: Between(0.3, 0.7)},
+		},
+		BaseHeight:  390,
+		CaveDensity: 1.0,
+		LakeChance:  0.15,
+		LakeLiquid:  "water",
+		Trees:       []TreeChance{{Tree: "acacia", Weight: 1}},
+		Organisms:   []OrganismChance{{Organism: "tree", Chance: 0.08}, {Organism: "bush", Chance: 0.04}},
+		Weather:     &WeatherWeights{Rain: 0.5, Storm: 1, Snow: 0},
 	},
 	ICE_FIELDS: {
+		ID:           "ice_fields",
 		Name:         "Ice Fields",
 		SurfaceBlock: "ice",
-		UnderBlock:   "snow",
+		UnderBlock:   "ice",
 		TreeDensity:  0.0,
 		OreFrequency: 0.5,
 		Temperature:  0.0,
 		Humidity:     0.2,
+		Climate: []ClimateRange{
+			{Temperature: Below(0.3), Humidity: Between(0.3, 0.5)},
+		},
+		BaseHeight:  340,
+		CaveDensity: 0.8,
+		LakeChance:  0.2,
+		LakeLiquid:  "water",
+		Organisms:   []OrganismChance{{Organism: "ice_spike", Chance: 0.005}},
+		Weather:     coldWeather,
 	},
 	VOLCANIC: {
+		ID:           "volcanic",
 		Name:         "Volcanic",
-		SurfaceBlock: "obsidian",
+		SurfaceBlock: "stone",
 		UnderBlock:   "stone",
 		TreeDensity:  0.0,
 		OreFrequency: 3.0,
 		Temperature:  1.0,
 		Humidity:     0.1,
+		Priority:     40,
+		Climate: []ClimateRange{
+			{Continental: Above(0.7), Elevation: Above(0.6)},
+		},
+		BaseHeight:  300,
+		CaveDensity: 1.2,
+		LakeChance:  0.5,
+		LakeLiquid:  "lava",
+		OreVeins:    volcanicOreVeins,
+		Organisms:   []OrganismChance{{Organism: "lava_rock", Chance: 0.02}},
+		Weather:     &WeatherWeights{Rain: 0.5, Storm: 1, Snow: 0},
 	},
 	CORAL_REEF: {
+		ID:           "coral_reef",
 		Name:         "Coral Reef",
 		SurfaceBlock: "sand",
 		UnderBlock:   "sand",
@@ -163,8 +476,19 @@ var BiomeDefinitions = map[BiomeType]*BiomeProperties{
 		OreFrequency: 0.4,
 		Temperature:  0.7,
 		Humidity:     1.0,
+		Priority:     20,
+		Climate: []ClimateRange{
+			{Continental: Above(0.6), Elevation: Below(0.35)},
+		},
+		BaseHeight:  560,
+		Flooded:     true,
+		CaveDensity: 0.3,
+		LakeChance:  0.1,
+		LakeLiquid:  "water",
+		Organisms:   []OrganismChance{{Organism: "coral", Chance: 0.05}},
 	},
 	MANGROVE: {
+		ID:           "mangrove",
 		Name:         "Mangrove",
 		SurfaceBlock: "grass",
 		UnderBlock:   "dirt",
@@ -172,35 +496,38 @@ var BiomeDefinitions = map[BiomeType]*BiomeProperties{
 		OreFrequency: 0.6,
 		Temperature:  0.75,
 		Humidity:     0.95,
+		Priority:     30,
+		Climate: []ClimateRange{
+			{Continental: Above(0.6), Temperature: Above(0.8), Humidity: Above(0.8)},
+		},
+		BaseHeight: 545,
+		Organisms:  []OrganismChance{{Organism: "mangrove_tree", Chance: 0.10}},
+		Creatures:  CreatureSpawns{Night: swampNightCreatures},
+		Weather:    wetlandWeather,
 	},
 }
 
-// SimplexNoise is a simple noise implementation for terrain generation
-type SimplexNoise struct {
-	seed float64
-}
-
-// NewSimplexNoise creates a new simplex noise generator
-func NewSimplexNoise(seed float64) *SimplexNoise {
-	return &SimplexNoise{seed: seed}
-}
-
-// Noise2D returns 2D noise value at the given coordinates
-func (n *SimplexNoise) Noise2D(x, y float64) float64 {
-	// Simple value-based noise for demonstration
-	// In a full implementation, use a proper simplex/perlin noise library
-	return n.sineNoise(x*0.01+n.seed, y*0.01+n.seed)*0.5 +
-		n.sineNoise(x*0.05+n.seed, y*0.05+n.seed)*0.25 +
-		n.sineNoise(x*0.1+n.seed, y*0.1+n.seed)*0.25
+// GetBiomeAtPosition returns the biome type at given world coordinates,
+// placing every biome in effect including those plugins register
+func GetBiomeAtPosition(x, y float64, noise *SimplexNoise) BiomeType {
+	registryMutex.RLock()
+	rules := climateRules
+	registryMutex.RUnlock()
+	return biomeFromRules(rules, x, y, noise)
 }
 
-// sineNoise generates a simple sine-based noise
-func (n *SimplexNoise) sineNoise(x, y float64) float64 {
-	return (math.Sin(x) + math.Cos(y)) / 2.0
+// GetBuiltinBiomeAtPosition returns the biome type at given world
+// coordinates, placing only the built-in and biomes.yaml biomes. Plugins may
+// still change what those biomes are made of.
+func GetBuiltinBiomeAtPosition(x, y float64, noise *SimplexNoise) BiomeType {
+	registryMutex.RLock()
+	rules := builtinClimateRules
+	registryMutex.RUnlock()
+	return biomeFromRules(rules, x, y, noise)
 }
 
-// GetBiomeAtPosition returns the biome type at given world coordinates
-func GetBiomeAtPosition(x, y float64, noise *SimplexNoise) BiomeType {
+// biomeFromRules returns the biome the first matching climate rule picks
+func biomeFromRules(rules []climateRule, x, y float64, noise *SimplexNoise) BiomeType {
 	temp := noise.Noise2D(x*0.008, y*0.008)
 	humid := noise.Noise2D(x*0.008+1000, y*0.008+1000)
 	elev := noise.Noise2D(x*0.004, y*0.004)
@@ -214,80 +541,15 @@ func GetBiomeAtPosition(x, y float64, noise *SimplexNoise) BiomeType {
 	elev = (elev + 1) / 2.0
 	continental = (continental + 1) / 2.0
 
-	// Enhanced biome determination following BLOCKS_LIST.md patterns
-	var biomeType BiomeType
-
-	// Special biome overrides first (highest priority)
-	if continental < 0.3 && elev > 0.8 {
-		biomeType = MOUNTAINS // Mountain peaks
-	} else if continental > 0.7 && elev > 0.6 {
-		biomeType = VOLCANIC // Volcanic regions
-	} else if continental > 0.6 && temp > 0.8 && humid > 0.8 {
-		biomeType = MANGROVE // Mangrove swamps
-	} else if elev < 0.35 {
-		// Water bodies (oceans and deep water)
-		if continental > 0.6 {
-			biomeType = CORAL_REEF // Coral reefs in deep ocean
-		} else {
-			biomeType = OCEAN // Regular ocean
-		}
-	} else {
-		// Land biomes with temperature and humidity classification
-		// Temperature zones: Cold (<0.3), Cool (0.3-0.5), Temperate (0.5-0.7), Warm (0.7-0.9), Hot (>0.9)
-		// Humidity zones: Arid (<0.3), Dry (0.3-0.5), Moderate (0.5-0.7), Humid (>0.7)
-
-		if temp < 0.3 { // Cold biomes
-			if humid < 0.3 {
-				biomeType = TUNDRA // Cold & Arid
-			} else if humid < 0.5 {
-				biomeType = ICE_FIELDS // Cold & Dry
-			} else if humid < 0.7 {
-				biomeType = TAIGA // Cold & Moderate
-			} else {
-				biomeType = TUNDRA // Cold & Humid (tundra dominates)
-			}
-		} else if temp < 0.5 { // Cool biomes
-			if humid < 0.3 {
-				biomeType = DESERT // Cool & Arid (rare cold deserts)
-			} else if humid < 0.5 {
-				biomeType = SAVANNA // Cool & Dry
-			} else if humid < 0.7 {
-				biomeType = PLAINS // Cool & Moderate
-			} else {
-				biomeType = SWAMP // Cool & Humid
-			}
-		} else if temp < 0.7 { // Temperate biomes
-			if humid < 0.3 {
-				biomeType = DESERT // Temperate & Arid
-			} else if humid < 0.5 {
-				biomeType = SAVANNA // Temperate & Dry
-			} else if humid < 0.7 {
-				biomeType = FOREST // Temperate & Moderate
-			} else {
-				biomeType = SWAMP // Temperate & Humid
-			}
-		} else if temp < 0.9 { // Warm biomes
-			if humid < 0.3 {
-				biomeType = DESERT // Warm & Arid
-			} else if humid < 0.5 {
-				biomeType = SAVANNA // Warm & Dry
-			} else if humid < 0.7 {
-				biomeType = JUNGLE // Warm & Moderate
-			} else {
-				biomeType = SWAMP // Warm & Humid
-			}
-		} else { // Hot biomes (>0.9)
-			if humid < 0.3 {
-				biomeType = DESERT // Hot & Arid
-			} else if humid < 0.7 {
-				biomeType = SAVANNA // Hot & Dry/Moderate
-			} else {
-				biomeType = JUNGLE // Hot & Humid
-			}
+	// The first climate range containing the sample picks the biome, from
+	// the highest priority down; the land biomes tile temperature and
+	// humidity below the mountain, volcanic, mangrove and ocean overrides
+	for _, rule := range rules {
+		if rule.climate.Contains(temp, humid, elev, continental) {
+			return rule.biome
 		}
 	}
-
-	return biomeType
+	return PLAINS
 }
 
 // GetSurfaceHeightVariation returns the surface height variation at the given position
@@ -298,9 +560,9 @@ func GetSurfaceHeightVariation(x, y float64, noise *SimplexNoise) float64 {
 // ShouldSpawnTree returns whether a tree should spawn at the given position
 func ShouldSpawnTree(x, y float64, noise *SimplexNoise) bool {
 	biome := GetBiomeAtPosition(x, y, noise)
-	props := BiomeDefinitions[biome]
+	props := Definition(biome)
 
-	if props.TreeDensity <= 0 {
+	if props == nil || props.TreeDensity <= 0 {
 		return false
 	}
 
@@ -311,7 +573,7 @@ func ShouldSpawnTree(x, y float64, noise *SimplexNoise) bool {
 
 // GetBiomeBlock returns the surface block type for the given biome
 func GetBiomeBlock(biome BiomeType) string {
-	if props, ok := BiomeDefinitions[biome]; ok {
+	if props := Definition(biome); props != nil {
 		return props.SurfaceBlock
 	}
 	return "dirt"
@@ -319,7 +581,7 @@ func GetBiomeBlock(biome BiomeType) string {
 
 // GetUnderBlock returns the underground block type for the given biome
 func GetBiomeUnderBlock(biome BiomeType) string {
-	if props, ok := BiomeDefinitions[biome]; ok {
+	if props := Definition(biome); props != nil {
 		return props.UnderBlock
 	}
 	return "stone"
@@ -0,0 +1,338 @@
+package biomes
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"sync"
+
+	"tesselbox/assets"
+	"tesselbox/pkg/overrides"
+
+	"gopkg.in/yaml.v3"
+)
+
+// BiomeTypeMap maps biome IDs, as used in biomes.yaml, to biome types. Read
+// it through BiomeTypeByID once plugins may be registering biomes.
+var BiomeTypeMap = map[string]BiomeType{
+	"plains":     PLAINS,
+	"forest":     FOREST,
+	"desert":     DESERT,
+	"badlands":   BADLANDS,
+	"mountains":  MOUNTAINS,
+	"ocean":      OCEAN,
+	"swamp":      SWAMP,
+	"taiga":      TAIGA,
+	"tundra":     TUNDRA,
+	"jungle":     JUNGLE,
+	"savanna":    SAVANNA,
+	"ice_fields": ICE_FIELDS,
+	"volcanic":   VOLCANIC,
+	"coral_reef": CORAL_REEF,
+	"mangrove":   MANGROVE,
+}
+
+// nextBiomeType is the type given to the next biome ID not seen before
+var nextBiomeType = MANGROVE + 1
+
+// climateRule is one climate range of a biome, in the order
+// GetBiomeAtPosition checks them
+type climateRule struct {
+	biome    BiomeType
+	priority int
+	climate  ClimateRange
+}
+
+var (
+	// registryMutex guards BiomeTypeMap, BiomeDefinitions, the layers behind
+	// them and the climate rules, which plugins change while worlds generate
+	registryMutex sync.RWMutex
+
+	// biomeLayers are the definitions stacked for each biome type; the
+	// topmost is the one in BiomeDefinitions
+	biomeLayers = overrides.New[BiomeType, *BiomeProperties]()
+
+	// climateRules place every biome in effect, including plugin ones, while
+	// builtinClimateRules place only the built-in and biomes.yaml definitions
+	climateRules        []climateRule
+	builtinClimateRules []climateRule
+)
+
+// BiomeJSON represents the YAML structure for biome definitions
+type BiomeJSON struct {
+	Name        string             `yaml:"name"`
+	Priority    int                `yaml:"priority,omitempty"`
+	Climate     []ClimateRangeJSON `yaml:"climate,omitempty"`
+	Temperature float64            `yaml:"temperature"`
+	Humidity    float64            `yaml:"humidity"`
+	Height      struct {
+		Base  float64 `yaml:"base"`
+		Scale float64 `yaml:"scale,omitempty"`
+	} `yaml:"height"`
+	Layers struct {
+		Surface         string  `yaml:"surface"`
+		SurfaceDepth    float64 `yaml:"surface_depth,omitempty"`
+		Subsurface      string  `yaml:"subsurface"`
+		SubsurfaceDepth float64 `yaml:"subsurface_depth,omitempty"`
+		Flooded         bool    `yaml:"flooded,omitempty"`
+	} `yaml:"layers"`
+	Caves struct {
+		Density    float64 `yaml:"density"`
+		LakeChance float64 `yaml:"lake_chance"`
+		LakeLiquid string  `yaml:"lake_liquid,omitempty"`
+	} `yaml:"caves"`
+	Ores struct {
+		Frequency float64   `yaml:"frequency"`
+		Veins     []OreVein `yaml:"veins,omitempty"`
+	} `yaml:"ores"`
+	TreeDensity float64          `yaml:"tree_density"`
+	Trees       []TreeChance     `yaml:"trees,omitempty"`
+	Organisms   []OrganismChance `yaml:"organisms,omitempty"`
+	Creatures   CreatureSpawns   `yaml:"creatures,omitempty"`
+	Music       string           `yaml:"music,omitempty"`
+	Weather     *WeatherWeights  `yaml:"weather,omitempty"`
+}
+
+// ClimateRangeJSON is a climate range in YAML. Omitted bounds are open.
+type ClimateRangeJSON struct {
+	Temperature *RangeJSON `yaml:"temperature,omitempty"`
+	Humidity    *RangeJSON `yaml:"humidity,omitempty"`
+	Elevation   *RangeJSON `yaml:"elevation,omitempty"`
+	Continental *RangeJSON `yaml:"continental,omitempty"`
+}
+
+// RangeJSON is a range in YAML, such as {min: 0.3, max: 0.5}
+type RangeJSON struct {
+	Min *float64 `yaml:"min,omitempty"`
+	Max *float64 `yaml:"max,omitempty"`
+}
+
+// toRange converts a YAML range, leaving omitted bounds open
+func (r *RangeJSON) toRange() Range {
+	if r == nil {
+		return Range{}
+	}
+	out := Range{Min: math.Inf(-1), Max: math.Inf(1)}
+	if r.Min != nil {
+		out.Min = *r.Min
+	}
+	if r.Max != nil {
+		out.Max = *r.Max
+	}
+	return out
+}
+
+// Properties converts a YAML biome into biome properties
+func (b *BiomeJSON) Properties(id string) *BiomeProperties {
+	props := &BiomeProperties{
+		ID:              id,
+		Name:            b.Name,
+		SurfaceBlock:    b.Layers.Surface,
+		UnderBlock:      b.Layers.Subsurface,
+		TreeDensity:     b.TreeDensity,
+		OreFrequency:    b.Ores.Frequency,
+		Temperature:     b.Temperature,
+		Humidity:        b.Humidity,
+		Priority:        b.Priority,
+		BaseHeight:      b.Height.Base,
+		HeightScale:     b.Height.Scale,
+		SurfaceDepth:    b.Layers.SurfaceDepth,
+		SubsurfaceDepth: b.Layers.SubsurfaceDepth,
+		Flooded:         b.Layers.Flooded,
+		CaveDensity:     b.Caves.Density,
+		LakeChance:      b.Caves.LakeChance,
+		LakeLiquid:      b.Caves.LakeLiquid,
+		OreVeins:        b.Ores.Veins,
+		Trees:           b.Trees,
+		Organisms:       b.Organisms,
+		Creatures:       b.Creatures,
+		Music:           b.Music,
+		Weather:         b.Weather,
+	}
+	if props.Name == "" {
+		props.Name = id
+	}
+
+	for _, c := range b.Climate {
+		props.Climate = append(props.Climate, ClimateRange{
+			Temperature: c.Temperature.toRange(),
+			Humidity:    c.Humidity.toRange(),
+			Elevation:   c.Elevation.toRange(),
+			Continental: c.Continental.toRange(),
+		})
+	}
+	return props
+}
+
+// ParseBiomes parses biome definitions in the biomes.yaml format, keyed by ID
+func ParseBiomes(data []byte) (map[string]*BiomeProperties, error) {
+	var defs map[string]*BiomeJSON
+	if err := yaml.Unmarshal(data, &defs); err != nil {
+		return nil, err
+	}
+	props := make(map[string]*BiomeProperties, len(defs))
+	for id, def := range defs {
+		if def == nil {
+			return nil, fmt.Errorf("biome %s has no definition", id)
+		}
+		props[id] = def.Properties(id)
+	}
+	return props, nil
+}
+
+// RegisterBiome sets the built-in definition of a biome, adding the biome if
+// it is new, and returns its type. Plugin overrides of the biome stay on top
+// of it. Biomes should be registered before worlds generate, since they
+// change where biomes fall.
+func RegisterBiome(id string, props *BiomeProperties) BiomeType {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	biomeType := biomeTypeFor(id)
+	props.ID = id
+	biomeLayers.SetBuiltin(biomeType, props)
+	applyLayers(biomeType)
+	rebuildClimateRules()
+	return biomeType
+}
+
+// OverrideBiome stacks a plugin's definition of a biome on top of any others,
+// adding the biome if it is new, and returns its type. ReleaseBiomes removes
+// it again.
+func OverrideBiome(owner, id string, props *BiomeProperties) BiomeType {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	biomeType := biomeTypeFor(id)
+	props.ID = id
+	biomeLayers.Override(owner, biomeType, props)
+	applyLayers(biomeType)
+	rebuildClimateRules()
+	return biomeType
+}
+
+// ReleaseBiomes removes every definition an owner stacked with OverrideBiome.
+// Each biome falls back to the topmost definition left; biomes with none
+// left are removed, though their types stay reserved so registering the ID
+// again gives back the same type.
+func ReleaseBiomes(owner string) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	changed := biomeLayers.Release(owner)
+	for _, biomeType := range changed {
+		applyLayers(biomeType)
+	}
+	if len(changed) > 0 {
+		rebuildClimateRules()
+	}
+}
+
+// Definition returns the definition of a biome in effect, or nil
+func Definition(biomeType BiomeType) *BiomeProperties {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+	return BiomeDefinitions[biomeType]
+}
+
+// BiomeTypeByID returns the type of a biome ID
+func BiomeTypeByID(id string) (BiomeType, bool) {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+	biomeType, ok := BiomeTypeMap[id]
+	return biomeType, ok
+}
+
+// biomeTypeFor returns the type of a biome ID, reserving a new one for IDs
+// not seen before; callers must hold registryMutex
+func biomeTypeFor(id string) BiomeType {
+	biomeType, exists := BiomeTypeMap[id]
+	if !exists {
+		biomeType = nextBiomeType
+		nextBiomeType++
+		BiomeTypeMap[id] = biomeType
+	}
+	return biomeType
+}
+
+// applyLayers puts a biome's topmost definition in effect; callers must hold
+// registryMutex
+func applyLayers(biomeType BiomeType) {
+	if props, ok := biomeLayers.Top(biomeType); ok {
+		BiomeDefinitions[biomeType] = props
+	} else {
+		delete(BiomeDefinitions, biomeType)
+	}
+}
+
+// rebuildClimateRules orders every biome's climate ranges by priority,
+// breaking ties by biome type so placement is the same on every run. It
+// builds the rules of the definitions in effect and those of the built-in
+// definitions alone; callers must hold registryMutex.
+func rebuildClimateRules() {
+	types := biomeLayers.Keys()
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+
+	var rules, builtinRules []climateRule
+	for _, t := range types {
+		props, _ := biomeLayers.Top(t)
+		rules = appendClimateRules(rules, t, props)
+		if builtin, ok := biomeLayers.Builtin(t); ok {
+			builtinRules = appendClimateRules(builtinRules, t, builtin)
+		}
+	}
+	sort.SliceStable(rules, func(i, j int) bool { return rules[i].priority > rules[j].priority })
+	sort.SliceStable(builtinRules, func(i, j int) bool { return builtinRules[i].priority > builtinRules[j].priority })
+	climateRules = rules
+	builtinClimateRules = builtinRules
+}
+
+// appendClimateRules adds the climate ranges of one biome definition
+func appendClimateRules(rules []climateRule, biomeType BiomeType, props *BiomeProperties) []climateRule {
+	for _, c := range props.Climate {
+		rules = append(rules, climateRule{biome: biomeType, priority: props.Priority, climate: c})
+	}
+	return rules
+}
+
+// LoadBiomes loads biome definitions from YAML over the built-in ones
+func LoadBiomes() {
+	registryMutex.Lock()
+	for biomeType, props := range BiomeDefinitions {
+		if _, ok := biomeLayers.Top(biomeType); !ok {
+			biomeLayers.SetBuiltin(biomeType, props)
+		}
+	}
+	rebuildClimateRules()
+	registryMutex.Unlock()
+
+	LoadBiomesFromAssets()
+}
+
+// LoadBiomesFromAssets loads biome definitions from embedded assets
+func LoadBiomesFromAssets() {
+	data, err := assets.GetConfigFile("biomes.yaml")
+	if err != nil {
+		log.Printf("Warning: Failed to load biomes.yaml from embedded assets: %v", err)
+		return
+	}
+	defs, err := ParseBiomes(data)
+	if err != nil {
+		log.Printf("Error loading biomes configuration: %v", err)
+		return
+	}
+
+	ids := make([]string, 0, len(defs))
+	for id := range defs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids) // New biomes get the same types on every run
+	for _, id := range ids {
+		RegisterBiome(id, defs[id])
+	}
+}
+
+func init() {
+	LoadBiomes()
+}
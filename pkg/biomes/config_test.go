@@ -0,0 +1,36 @@
+package biomes
+
+import "testing"
+
+func TestReleasedBiomeKeepsType(t *testing.T) {
+	biomeType := OverrideBiome("test", "test_biome", &BiomeProperties{Name: "Test"})
+	ReleaseBiomes("test")
+	if Definition(biomeType) != nil {
+		t.Error("released biome is still defined")
+	}
+	if again := OverrideBiome("test", "test_biome", &BiomeProperties{Name: "Test"}); again != biomeType {
+		t.Errorf("registering the ID again gave type %d, want %d", again, biomeType)
+	}
+	ReleaseBiomes("test")
+}
+
+func TestBuiltinPlacementIgnoresOverrides(t *testing.T) {
+	noise := NewSimplexNoise(42)
+	// Claim every climate for a plugin biome
+	OverrideBiome("test", "everywhere", &BiomeProperties{
+		Name:     "Everywhere",
+		Priority: 1000,
+		Climate:  []ClimateRange{{}},
+	})
+	defer ReleaseBiomes("test")
+
+	everywhere, _ := BiomeTypeByID("everywhere")
+	for _, x := range []float64{0, 1234, -5678} {
+		if got := GetBiomeAtPosition(x, 0, noise); got != everywhere {
+			t.Errorf("GetBiomeAtPosition(%v) = %d, want the plugin biome %d", x, got, everywhere)
+		}
+		if got := GetBuiltinBiomeAtPosition(x, 0, noise); got == everywhere {
+			t.Errorf("GetBuiltinBiomeAtPosition(%v) placed the plugin biome", x)
+		}
+	}
+}
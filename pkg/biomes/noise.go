@@ -0,0 +1,239 @@
+package biomes
+
+import (
+	"math"
+)
+
+// SimplexNoise is seeded gradient noise shared by terrain, biomes, caves and
+// weather. The same seed always gives the same values on every platform.
+type SimplexNoise struct {
+	seed float64
+	perm [512]uint8
+
+	// sine selects the sum-of-sines noise of worlds generated before the
+	// switch to gradient noise, which still need it to generate unsaved
+	// chunks the way their saved ones were
+	sine bool
+}
+
+// NewSimplexNoise creates a simplex noise generator for a seed
+func NewSimplexNoise(seed float64) *SimplexNoise {
+	n := &SimplexNoise{seed: seed}
+
+	// Shuffle the permutation table with SplitMix64 seeded from the seed's bits
+	for i := 0; i < 256; i++ {
+		n.perm[i] = uint8(i)
+	}
+	state := math.Float64bits(seed)
+	for i := 255; i > 0; i-- {
+		state += 0x9e3779b97f4a7c15
+		z := state
+		z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+		z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+		z ^= z >> 31
+		j := int(z % uint64(i+1))
+		n.perm[i], n.perm[j] = n.perm[j], n.perm[i]
+	}
+	for i := 0; i < 256; i++ {
+		n.perm[256+i] = n.perm[i]
+	}
+	return n
+}
+
+// NewSineNoise creates the legacy sum-of-sines generator. Only worlds
+// generated before gradient noise should use it.
+func NewSineNoise(seed float64) *SimplexNoise {
+	n := NewSimplexNoise(seed)
+	n.sine = true
+	return n
+}
+
+// Noise2D returns layered noise in [-1, 1] for terrain and biome lookups.
+// Callers scale coordinates down; the layers are at 0.01, 0.05 and 0.1 of
+// the given coordinates.
+func (n *SimplexNoise) Noise2D(x, y float64) float64 {
+	if n.sine {
+		return n.sineNoise(x*0.01+n.seed, y*0.01+n.seed)*0.5 +
+			n.sineNoise(x*0.05+n.seed, y*0.05+n.seed)*0.25 +
+			n.sineNoise(x*0.1+n.seed, y*0.1+n.seed)*0.25
+	}
+	return n.Simplex2D(x*0.01, y*0.01)*0.5 +
+		n.Simplex2D(x*0.05+31.7, y*0.05-17.3)*0.25 +
+		n.Simplex2D(x*0.1-53.1, y*0.1+71.9)*0.25
+}
+
+// sineNoise generates a simple sine-based noise
+func (n *SimplexNoise) sineNoise(x, y float64) float64 {
+	return (math.Sin(x) + math.Cos(y)) / 2.0
+}
+
+// Skewing factors between the square grid and the simplex grid
+var (
+	f2 = 0.5 * (math.Sqrt(3) - 1)
+	g2 = (3 - math.Sqrt(3)) / 6
+)
+
+const (
+	f3 = 1.0 / 3.0
+	g3 = 1.0 / 6.0
+)
+
+// grad3 are the gradient directions: the midpoints of a cube's edges
+var grad3 = [12][3]float64{
+	{1, 1, 0}, {-1, 1, 0}, {1, -1, 0}, {-1, -1, 0},
+	{1, 0, 1}, {-1, 0, 1}, {1, 0, -1}, {-1, 0, -1},
+	{0, 1, 1}, {0, -1, 1}, {0, 1, -1}, {0, -1, -1},
+}
+
+// Simplex2D returns 2D simplex noise in [-1, 1] with features about one
+// unit across
+func (n *SimplexNoise) Simplex2D(x, y float64) float64 {
+	// Find the simplex cell containing the point
+	s := (x + y) * f2
+	i := math.Floor(x + s)
+	j := math.Floor(y + s)
+	t := (i + j) * g2
+	x0 := x - (i - t)
+	y0 := y - (j - t)
+
+	// Which of the cell's two triangles the point is in
+	i1, j1 := 0, 1
+	if x0 > y0 {
+		i1, j1 = 1, 0
+	}
+
+	x1 := x0 - float64(i1) + g2
+	y1 := y0 - float64(j1) + g2
+	x2 := x0 - 1 + 2*g2
+	y2 := y0 - 1 + 2*g2
+
+	ii := int(i) & 255
+	jj := int(j) & 255
+	gi0 := n.perm[ii+int(n.perm[jj])] % 12
+	gi1 := n.perm[ii+i1+int(n.perm[jj+j1])] % 12
+	gi2 := n.perm[ii+1+int(n.perm[jj+1])] % 12
+
+	total := corner2D(gi0, x0, y0) + corner2D(gi1, x1, y1) + corner2D(gi2, x2, y2)
+	return 70 * total
+}
+
+// corner2D returns one simplex corner's contribution to 2D noise
+func corner2D(gi uint8, x, y float64) float64 {
+	t := 0.5 - x*x - y*y
+	if t < 0 {
+		return 0
+	}
+	t *= t
+	g := grad3[gi]
+	return t * t * (g[0]*x + g[1]*y)
+}
+
+// Simplex3D returns 3D simplex noise in [-1, 1] with features about one
+// unit across. The third axis is useful for time, so 2D fields can evolve.
+func (n *SimplexNoise) Simplex3D(x, y, z float64) float64 {
+	s := (x + y + z) * f3
+	i := math.Floor(x + s)
+	j := math.Floor(y + s)
+	k := math.Floor(z + s)
+	t := (i + j + k) * g3
+	x0 := x - (i - t)
+	y0 := y - (j - t)
+	z0 := z - (k - t)
+
+	// Which of the cube's six tetrahedra the point is in
+	var i1, j1, k1, i2, j2, k2 int
+	if x0 >= y0 {
+		switch {
+		case y0 >= z0:
+			i1, j1, k1, i2, j2, k2 = 1, 0, 0, 1, 1, 0
+		case x0 >= z0:
+			i1, j1, k1, i2, j2, k2 = 1, 0, 0, 1, 0, 1
+		default:
+			i1, j1, k1, i2, j2, k2 = 0, 0, 1, 1, 0, 1
+		}
+	} else {
+		switch {
+		case y0 < z0:
+			i1, j1, k1, i2, j2, k2 = 0, 0, 1, 0, 1, 1
+		case x0 < z0:
+			i1, j1, k1, i2, j2, k2 = 0, 1, 0, 0, 1, 1
+		default:
+			i1, j1, k1, i2, j2, k2 = 0, 1, 0, 1, 1, 0
+		}
+	}
+
+	x1 := x0 - float64(i1) + g3
+	y1 := y0 - float64(j1) + g3
+	z1 := z0 - float64(k1) + g3
+	x2 := x0 - float64(i2) + 2*g3
+	y2 := y0 - float64(j2) + 2*g3
+	z2 := z0 - float64(k2) + 2*g3
+	x3 := x0 - 1 + 3*g3
+	y3 := y0 - 1 + 3*g3
+	z3 := z0 - 1 + 3*g3
+
+	ii := int(i) & 255
+	jj := int(j) & 255
+	kk := int(k) & 255
+	p := n.perm
+	gi0 := p[ii+int(p[jj+int(p[kk])])] % 12
+	gi1 := p[ii+i1+int(p[jj+j1+int(p[kk+k1])])] % 12
+	gi2 := p[ii+i2+int(p[jj+j2+int(p[kk+k2])])] % 12
+	gi3 := p[ii+1+int(p[jj+1+int(p[kk+1])])] % 12
+
+	total := corner3D(gi0, x0, y0, z0) + corner3D(gi1, x1, y1, z1) +
+		corner3D(gi2, x2, y2, z2) + corner3D(gi3, x3, y3, z3)
+	return 32 * total
+}
+
+// corner3D returns one simplex corner's contribution to 3D noise
+func corner3D(gi uint8, x, y, z float64) float64 {
+	t := 0.6 - x*x - y*y - z*z
+	if t < 0 {
+		return 0
+	}
+	t *= t
+	g := grad3[gi]
+	return t * t * (g[0]*x + g[1]*y + g[2]*z)
+}
+
+// FBM2D sums octaves of simplex noise (fractal Brownian motion). Each octave
+// multiplies frequency by lacunarity and amplitude by gain; the sum is
+// normalised back to [-1, 1].
+func (n *SimplexNoise) FBM2D(x, y float64, octaves int, lacunarity, gain float64) float64 {
+	sum, amplitude, total := 0.0, 1.0, 0.0
+	for o := 0; o < octaves; o++ {
+		sum += n.Simplex2D(x, y) * amplitude
+		total += amplitude
+		x, y = x*lacunarity+19.1, y*lacunarity-7.3 // Offset so octaves do not share a lattice origin
+		amplitude *= gain
+	}
+	if total == 0 {
+		return 0
+	}
+	return sum / total
+}
+
+// FBM3D is FBM2D with a third axis
+func (n *SimplexNoise) FBM3D(x, y, z float64, octaves int, lacunarity, gain float64) float64 {
+	sum, amplitude, total := 0.0, 1.0, 0.0
+	for o := 0; o < octaves; o++ {
+		sum += n.Simplex3D(x, y, z) * amplitude
+		total += amplitude
+		x, y, z = x*lacunarity+19.1, y*lacunarity-7.3, z*lacunarity+3.7
+		amplitude *= gain
+	}
+	if total == 0 {
+		return 0
+	}
+	return sum / total
+}
+
+// DomainWarp2D displaces a point by two independent noise fields, so noise
+// sampled at the result bends and swirls instead of forming round blobs.
+// Strength is the largest displacement in input units.
+func (n *SimplexNoise) DomainWarp2D(x, y, strength float64) (float64, float64) {
+	dx := n.FBM2D(x+5.2, y+1.3, 3, 2, 0.5)
+	dy := n.FBM2D(x-9.7, y+2.8, 3, 2, 0.5)
+	return x + dx*strength, y + dy*strength
+}
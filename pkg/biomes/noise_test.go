@@ -0,0 +1,71 @@
+package biomes
+
+import (
+	"math"
+	"testing"
+)
+
+// noiseTolerance allows for fused multiply-adds on some architectures; any
+// real change to the noise moves values far more than this
+const noiseTolerance = 1e-12
+
+// noiseGolden holds the noise of a seed at a point, recorded from the
+// generator. Changing any of these changes the terrain of existing worlds, so
+// a failure here needs a GeneratorVersion bump, not new values.
+var noiseGolden = []struct {
+	seed      float64
+	x, y, z   float64
+	simplex2D float64
+	simplex3D float64
+	fbm2D     float64
+	fbm3D     float64
+	warpX     float64
+	warpY     float64
+}{
+	{0, 0.5, 0.25, 0.75, 0.41422232522006863, 0.9375249999999994, 0.2041929176101768, 0.43550034151769723, -3.7360411283156783, -2.29872044890488},
+	{0, 12.3, -4.56, 7.89, -0.6018012057497666, 0.20236892033709167, -0.2795403436987642, -0.0334711856789015, 8.805070331722984, -1.9473686437099156},
+	{0, -101.7, 33.3, -0.4, -0.01102126505868397, 0.5266440092181159, -0.23057349894773294, 0.2608512902847767, -101.45660566642009, 33.159219382238874},
+	{12345, 0.5, 0.25, 0.75, -0.12051279576658058, 0.44825937499999957, 0.039152045956751556, 0.3817959582836798, 2.663140550367642, 3.051814622829756},
+	{12345, 12.3, -4.56, 7.89, -0.5984852544208961, -0.17240788593047168, -0.43228702348355513, -0.29848460946837224, 8.937741669662136, -3.44319390867373},
+	{12345, -101.7, 33.3, -0.4, 0.4403640646094876, -0.08782589774486164, 0.5044159552179825, -0.13867755188149314, -102.71259985044016, 32.688383981319},
+}
+
+func TestNoiseGolden(t *testing.T) {
+	check := func(t *testing.T, name string, got, want float64) {
+		t.Helper()
+		if math.Abs(got-want) > noiseTolerance {
+			t.Errorf("%s = %v, want %v", name, got, want)
+		}
+	}
+
+	for _, tt := range noiseGolden {
+		n := NewSimplexNoise(tt.seed)
+		check(t, "Simplex2D", n.Simplex2D(tt.x, tt.y), tt.simplex2D)
+		check(t, "Simplex3D", n.Simplex3D(tt.x, tt.y, tt.z), tt.simplex3D)
+		check(t, "FBM2D", n.FBM2D(tt.x, tt.y, 4, 2, 0.5), tt.fbm2D)
+		check(t, "FBM3D", n.FBM3D(tt.x, tt.y, tt.z, 4, 2, 0.5), tt.fbm3D)
+		warpX, warpY := n.DomainWarp2D(tt.x, tt.y, 10)
+		check(t, "DomainWarp2D x", warpX, tt.warpX)
+		check(t, "DomainWarp2D y", warpY, tt.warpY)
+		if t.Failed() {
+			t.Fatalf("noise changed for seed %v at (%v, %v, %v)", tt.seed, tt.x, tt.y, tt.z)
+		}
+	}
+}
+
+func TestNoiseRange(t *testing.T) {
+	n := NewSimplexNoise(7)
+	for i := 0; i < 1000; i++ {
+		x, y, z := float64(i)*0.37-150, float64(i)*-0.91+40, float64(i)*0.13
+		for name, v := range map[string]float64{
+			"Simplex2D": n.Simplex2D(x, y),
+			"Simplex3D": n.Simplex3D(x, y, z),
+			"FBM2D":     n.FBM2D(x, y, 5, 2, 0.5),
+			"FBM3D":     n.FBM3D(x, y, z, 5, 2, 0.5),
+		} {
+			if v < -1 || v > 1 {
+				t.Fatalf("%s(%v, %v, %v) = %v, outside [-1, 1]", name, x, y, z, v)
+			}
+		}
+	}
+}
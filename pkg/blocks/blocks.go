@@ -2,6 +2,7 @@ package blocks
 
 import (
 	"fmt"
+	"image"
 	"image/color"
 	"log"
 	"math"
@@ -9,7 +10,6 @@ import (
 
 	"tesselbox/assets"
 
-	"github.com/hajimehoshi/ebiten/v2"
 	"gopkg.in/yaml.v3"
 )
 
@@ -95,6 +95,8 @@ const (
 	STONE_BRICKS
 	CHISELED_STONE
 	RANDOMLAND_PORTAL
+	LAVA
+	SPAWNER
 )
 
 // BlockProperties defines the properties of a block type
@@ -112,9 +114,9 @@ type BlockProperties struct {
 	Flammable   bool
 	LightLevel  int
 	Gravity     bool
-	Viscosity   float64       // For liquids
-	Pattern     string        // "solid", "striped", "checkerboard", etc.
-	Texture     *ebiten.Image // Optional texture for pixel-by-pixel appearance
+	Viscosity   float64     // For liquids
+	Pattern     string      // "solid", "striped", "checkerboard", etc.
+	Texture     image.Image // Optional texture for pixel-by-pixel appearance
 
 	// Humidity-based appearance system
 	HumidityColors       []color.RGBA // Colors for different humidity levels [dry, normal, wet]
@@ -240,6 +242,9 @@ var BlockTypeMap = map[string]BlockType{
 	"mossy_cobblestone": MOSSY_COBBLESTONE,
 	"stone_bricks":      STONE_BRICKS,
 	"chiseled_stone":    CHISELED_STONE,
+	"randomland_portal": RANDOMLAND_PORTAL,
+	"lava":              LAVA,
+	"spawner":           SPAWNER,
 }
 
 // Initialize custom block definitions from block designer
@@ -1061,6 +1066,33 @@ func loadDefaultBlocks() {
 			},
 			HumidityPatterns: []string{"rough", "weathered", "mossy"},
 		},
+		"lava": {
+			ID:          LAVA,
+			Name:        "Lava",
+			Color:       color.RGBA{255, 100, 0, 220},
+			Hardness:    0,
+			Transparent: true,
+			Solid:       false,
+			Collectible: false,
+			Flammable:   false,
+			LightLevel:  15,
+			Gravity:     false,
+			Viscosity:   0.8,
+			Pattern:     "liquid",
+		},
+		"spawner": {
+			ID:          SPAWNER,
+			Name:        "Spawner",
+			Color:       color.RGBA{40, 50, 70, 255},
+			Hardness:    5.0,
+			Transparent: true,
+			Solid:       true,
+			Collectible: false,
+			Flammable:   false,
+			LightLevel:  3,
+			Gravity:     false,
+			Pattern:     "solid",
+		},
 		"randomland_portal": {
 			ID:                   RANDOMLAND_PORTAL,
 			Name:                 "Randomland Portal",
@@ -1093,7 +1125,7 @@ func loadMods() {
 
 // generateProceduralTexture creates a texture using a color palette with random pixels
 // Uses caching to avoid regenerating the same textures
-func generateProceduralTexture(colors []color.RGBA, id BlockType) *ebiten.Image {
+func generateProceduralTexture(colors []color.RGBA, id BlockType) *image.RGBA {
 	if len(colors) == 0 {
 		return nil
 	}
@@ -1107,7 +1139,7 @@ func generateProceduralTexture(colors []color.RGBA, id BlockType) *ebiten.Image
 	}
 
 	const size = 64
-	img := ebiten.NewImage(size, size)
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
 	rand.Seed(int64(id) * 1000) // Deterministic seed per block type
 
 	// Batch pixel operations using a pre-allocated slice
@@ -1125,7 +1157,7 @@ func generateProceduralTexture(colors []color.RGBA, id BlockType) *ebiten.Image
 	}
 
 	// Write pixels to image (much faster than individual Set calls)
-	img.WritePixels(pixels)
+	copy(img.Pix, pixels)
 
 	// Cache the texture
 	textureCache.Set(cacheKey, img)
@@ -1183,6 +1215,31 @@ func GetLiquidProperties(liquidType LiquidType) *LiquidProperties {
 	return LiquidDefinitions[liquidType]
 }
 
+// LiquidTypeOf returns the liquid a block type holds, if it is a liquid block
+func LiquidTypeOf(blockType BlockType) (LiquidType, bool) {
+	switch blockType {
+	case WATER:
+		return WATER_LIQUID, true
+	case LAVA:
+		return LAVA_LIQUID, true
+	}
+	return 0, false
+}
+
+// IsLiquidBlock reports whether a block type is a liquid
+func IsLiquidBlock(blockType BlockType) bool {
+	_, ok := LiquidTypeOf(blockType)
+	return ok
+}
+
+// Block returns the block type that holds this liquid in the world
+func (t LiquidType) Block() BlockType {
+	if t == LAVA_LIQUID {
+		return LAVA
+	}
+	return WATER
+}
+
 // IsValidLiquid checks if a liquid type is valid
 func IsValidLiquid(liquidType LiquidType) bool {
 	_, ok := LiquidDefinitions[liquidType]
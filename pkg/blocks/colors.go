@@ -2,17 +2,17 @@ package blocks
 
 import (
 	"fmt"
+	"image"
 	"image/color"
+	"image/draw"
 	"math"
 	"math/rand"
 	"time"
-
-	"github.com/hajimehoshi/ebiten/v2"
 )
 
-// SimpleLRUCache is a thread-safe LRU cache for ebiten images
+// SimpleLRUCache is a thread-safe LRU cache for texture images
 type SimpleLRUCache struct {
-	data    map[string]*ebiten.Image
+	data    map[string]*image.RGBA
 	keys    []string
 	size    int
 	maxSize int
@@ -21,7 +21,7 @@ type SimpleLRUCache struct {
 // NewSimpleLRUCache creates a new LRU cache with max size
 func NewSimpleLRUCache(maxSize int) *SimpleLRUCache {
 	return &SimpleLRUCache{
-		data:    make(map[string]*ebiten.Image),
+		data:    make(map[string]*image.RGBA),
 		keys:    make([]string, 0, maxSize),
 		size:    0,
 		maxSize: maxSize,
@@ -29,7 +29,7 @@ func NewSimpleLRUCache(maxSize int) *SimpleLRUCache {
 }
 
 // Get retrieves an item from the cache
-func (c *SimpleLRUCache) Get(key string) (*ebiten.Image, bool) {
+func (c *SimpleLRUCache) Get(key string) (*image.RGBA, bool) {
 	if val, ok := c.data[key]; ok {
 		// Move key to end (most recently used)
 		c.moveToEnd(key)
@@ -39,7 +39,7 @@ func (c *SimpleLRUCache) Get(key string) (*ebiten.Image, bool) {
 }
 
 // Set adds or updates an item in the cache
-func (c *SimpleLRUCache) Set(key string, value *ebiten.Image) {
+func (c *SimpleLRUCache) Set(key string, value *image.RGBA) {
 	if _, ok := c.data[key]; ok {
 		c.data[key] = value
 		c.moveToEnd(key)
@@ -485,7 +485,7 @@ func (ba *BlockAppearance) applyGradientVariation(baseColor color.RGBA, scheme *
 
 // GenerateBlockTexture generates a texture for a block with color variations
 // Uses texture caching for performance
-func (ba *BlockAppearance) GenerateBlockTexture(blockType string, x, y int, biome string, depth float64) *ebiten.Image {
+func (ba *BlockAppearance) GenerateBlockTexture(blockType string, x, y int, biome string, depth float64) *image.RGBA {
 	// Create cache key based on block properties
 	// Use a simplified key for performance - only cache unique variations
 	variation := 0
@@ -501,14 +501,14 @@ func (ba *BlockAppearance) GenerateBlockTexture(blockType string, x, y int, biom
 	}
 
 	const textureSize = 64
-	img := ebiten.NewImage(textureSize, textureSize)
+	img := image.NewRGBA(image.Rect(0, 0, textureSize, textureSize))
 
 	baseColor := ba.GetBlockColor(blockType, x, y, biome, depth)
 
 	scheme, exists := ba.ColorSchemes[blockType]
 	if !exists {
 		// Simple solid color texture
-		img.Fill(baseColor)
+		fillImage(img, baseColor)
 		textureCache.Set(cacheKey, img)
 		return img
 	}
@@ -529,8 +529,8 @@ func (ba *BlockAppearance) GenerateBlockTexture(blockType string, x, y int, biom
 }
 
 // generateSolidTexture generates a solid color texture with slight variation
-func (ba *BlockAppearance) generateSolidTexture(img *ebiten.Image, baseColor color.RGBA) {
-	img.Fill(baseColor)
+func (ba *BlockAppearance) generateSolidTexture(img *image.RGBA, baseColor color.RGBA) {
+	fillImage(img, baseColor)
 
 	// Add some noise for texture
 	const size = 64
@@ -558,11 +558,11 @@ func (ba *BlockAppearance) generateSolidTexture(img *ebiten.Image, baseColor col
 }
 
 // generatePatternTexture generates a patterned texture
-func (ba *BlockAppearance) generatePatternTexture(img *ebiten.Image, scheme *BlockColorScheme, baseColor color.RGBA, blockX, blockY int) {
+func (ba *BlockAppearance) generatePatternTexture(img *image.RGBA, scheme *BlockColorScheme, baseColor color.RGBA, blockX, blockY int) {
 	const size = 64
 
 	// Fill with base color
-	img.Fill(baseColor)
+	fillImage(img, baseColor)
 
 	// Apply pattern
 	if len(scheme.PatternColors) > 0 {
@@ -586,11 +586,11 @@ func (ba *BlockAppearance) generatePatternTexture(img *ebiten.Image, scheme *Blo
 }
 
 // generateGradientTexture generates a gradient texture
-func (ba *BlockAppearance) generateGradientTexture(img *ebiten.Image, scheme *BlockColorScheme, baseColor color.RGBA, depth float64) {
+func (ba *BlockAppearance) generateGradientTexture(img *image.RGBA, scheme *BlockColorScheme, baseColor color.RGBA, depth float64) {
 	const size = 64
 
 	if len(scheme.GradientColors) < 2 {
-		img.Fill(baseColor)
+		fillImage(img, baseColor)
 		return
 	}
 
@@ -605,6 +605,11 @@ func (ba *BlockAppearance) generateGradientTexture(img *ebiten.Image, scheme *Bl
 	}
 }
 
+// fillImage fills a texture with a single color
+func fillImage(img *image.RGBA, c color.RGBA) {
+	draw.Draw(img, img.Bounds(), &image.Uniform{C: c}, image.Point{}, draw.Src)
+}
+
 // Utility functions
 func max(a, b int) int {
 	if a > b {
@@ -1,11 +1,10 @@
 package blocks
 
 import (
+	"image"
 	"image/color"
 	"math"
 	"math/rand"
-
-	"github.com/hajimehoshi/ebiten/v2"
 )
 
 // CustomBlockRenderer handles custom block appearances
@@ -21,9 +20,9 @@ func NewCustomBlockRenderer() *CustomBlockRenderer {
 }
 
 // GenerateCustomTexture creates a custom texture for any block
-func (cbr *CustomBlockRenderer) GenerateCustomTexture(blockType string, x, y int, customParams map[string]interface{}) *ebiten.Image {
+func (cbr *CustomBlockRenderer) GenerateCustomTexture(blockType string, x, y int, customParams map[string]interface{}) *image.RGBA {
 	const textureSize = 64
-	img := ebiten.NewImage(textureSize, textureSize)
+	img := image.NewRGBA(image.Rect(0, 0, textureSize, textureSize))
 
 	switch blockType {
 	case "stone":
@@ -42,7 +41,7 @@ func (cbr *CustomBlockRenderer) GenerateCustomTexture(blockType string, x, y int
 }
 
 // generateStoneTexture creates a stone texture with custom patterns
-func (cbr *CustomBlockRenderer) generateStoneTexture(img *ebiten.Image, x, y int, params map[string]interface{}) {
+func (cbr *CustomBlockRenderer) generateStoneTexture(img *image.RGBA, x, y int, params map[string]interface{}) {
 	baseColor := color.RGBA{128, 128, 128, 255}
 
 	// Get custom parameters
@@ -80,12 +79,12 @@ func (cbr *CustomBlockRenderer) generateStoneTexture(img *ebiten.Image, x, y int
 }
 
 // generateGrassTexture creates grass with blade patterns
-func (cbr *CustomBlockRenderer) generateGrassTexture(img *ebiten.Image, x, y int, params map[string]interface{}) {
+func (cbr *CustomBlockRenderer) generateGrassTexture(img *image.RGBA, x, y int, params map[string]interface{}) {
 	baseColor := color.RGBA{124, 169, 84, 255}
 	darkColor := color.RGBA{94, 139, 54, 255}
 
 	// Fill base color
-	img.Fill(baseColor)
+	fillImage(img, baseColor)
 
 	// Add grass blades
 	bladeCount := 20 + cbr.randSource.Intn(10)
@@ -108,7 +107,7 @@ func (cbr *CustomBlockRenderer) generateGrassTexture(img *ebiten.Image, x, y int
 }
 
 // generateWaterTexture creates animated water effect
-func (cbr *CustomBlockRenderer) generateWaterTexture(img *ebiten.Image, x, y int, params map[string]interface{}) {
+func (cbr *CustomBlockRenderer) generateWaterTexture(img *image.RGBA, x, y int, params map[string]interface{}) {
 	baseColor := color.RGBA{64, 164, 223, 180}
 
 	// Create water with wave patterns
@@ -139,7 +138,7 @@ func (cbr *CustomBlockRenderer) generateWaterTexture(img *ebiten.Image, x, y int
 }
 
 // generateCrystalTexture creates a crystalline structure
-func (cbr *CustomBlockRenderer) generateCrystalTexture(img *ebiten.Image, x, y int, params map[string]interface{}) {
+func (cbr *CustomBlockRenderer) generateCrystalTexture(img *image.RGBA, x, y int, params map[string]interface{}) {
 	// Get crystal color from params or use default
 	crystalColor, ok := params["color"].(color.RGBA)
 	if !ok {
@@ -181,8 +180,8 @@ func (cbr *CustomBlockRenderer) generateCrystalTexture(img *ebiten.Image, x, y i
 }
 
 // generateSolidTexture creates a simple solid color texture
-func (cbr *CustomBlockRenderer) generateSolidTexture(img *ebiten.Image, col color.RGBA) {
-	img.Fill(col)
+func (cbr *CustomBlockRenderer) generateSolidTexture(img *image.RGBA, col color.RGBA) {
+	fillImage(img, col)
 }
 
 // Global custom renderer instance
@@ -0,0 +1,67 @@
+package blocks
+
+import (
+	"sync"
+)
+
+var (
+	registryMutex sync.RWMutex
+	// blockIDs is the reverse of BlockTypeMap
+	blockIDs map[BlockType]string
+)
+
+// buildBlockIDs rebuilds the reverse lookup; callers must hold registryMutex
+func buildBlockIDs() {
+	blockIDs = make(map[BlockType]string, len(BlockTypeMap))
+	for id, blockType := range BlockTypeMap {
+		blockIDs[blockType] = id
+	}
+}
+
+// BlockID returns the stable string ID of a block type, or "" if it has none
+func BlockID(blockType BlockType) string {
+	registryMutex.RLock()
+	if blockIDs != nil && len(blockIDs) == len(BlockTypeMap) {
+		id := blockIDs[blockType]
+		registryMutex.RUnlock()
+		return id
+	}
+	registryMutex.RUnlock()
+
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+	buildBlockIDs()
+	return blockIDs[blockType]
+}
+
+// BlockTypeByID returns the runtime block type for a stable string ID
+func BlockTypeByID(id string) (BlockType, bool) {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+	blockType, ok := BlockTypeMap[id]
+	return blockType, ok
+}
+
+// RegisterBlockType returns the runtime block type for a string ID such as
+// "plugin:foo/bar", allocating the next free runtime ID if it is not known yet.
+// Runtime IDs are only valid for the current process; saves store string IDs.
+func RegisterBlockType(id string) BlockType {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	if blockType, ok := BlockTypeMap[id]; ok {
+		return blockType
+	}
+
+	next := RANDOMLAND_PORTAL
+	for _, blockType := range BlockTypeMap {
+		if blockType > next {
+			next = blockType
+		}
+	}
+	next++
+
+	BlockTypeMap[id] = next
+	buildBlockIDs()
+	return next
+}
@@ -2,6 +2,7 @@
 package chest
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -10,10 +11,14 @@ import (
 
 	"tesselbox/pkg/config"
 	"tesselbox/pkg/items"
+	"tesselbox/pkg/palette"
 )
 
 const (
 	ChestSlots = 27 // 3x9 chest inventory
+
+	// FileVersion is the current chests file format version
+	FileVersion = "2.0"
 )
 
 // ChestInventory represents the contents of a single chest
@@ -23,6 +28,29 @@ type ChestInventory struct {
 	Slots []items.Item `json:"slots"`
 }
 
+// chestFile is the on-disk form of a world's chests. Version 1.0 files were a
+// bare JSON array of ChestInventory holding raw item types.
+type chestFile struct {
+	Version     string           `json:"version"`
+	ItemPalette *palette.Palette `json:"item_palette"`
+	Chests      []chestRecord    `json:"chests"`
+}
+
+// chestRecord is a serialized chest
+type chestRecord struct {
+	X     float64      `json:"x"`
+	Y     float64      `json:"y"`
+	Slots []slotRecord `json:"slots"`
+}
+
+// slotRecord is a serialized chest slot
+type slotRecord struct {
+	Type       int             `json:"type"` // Index into the file's item palette
+	Quantity   int             `json:"quantity"`
+	Durability int             `json:"durability"`
+	Meta       *items.Metadata `json:"meta,omitempty"`
+}
+
 // ChestManager manages all chests in the world
 type ChestManager struct {
 	chests   map[string]*ChestInventory // Key: "x,y" format
@@ -94,18 +122,29 @@ func (cm *ChestManager) ChestExists(x, y float64) bool {
 	return exists
 }
 
-// AddItemToChest adds an item to the first available slot in a chest
+// AddItemToChest adds plain items of a type to the first available slot in a chest
 func (cm *ChestManager) AddItemToChest(x, y float64, itemType items.ItemType, quantity int) bool {
+	itemProps := items.ItemDefinitions[itemType]
+	if itemProps == nil {
+		return false
+	}
+	return cm.AddStackToChest(x, y, items.Item{Type: itemType, Quantity: quantity, Durability: itemProps.Durability})
+}
+
+// AddStackToChest adds an item stack to a chest, keeping its durability and
+// metadata. It only stacks with items it StacksWith.
+func (cm *ChestManager) AddStackToChest(x, y float64, item items.Item) bool {
 	chest := cm.GetChest(x, y)
 
 	cm.mutex.Lock()
 	defer cm.mutex.Unlock()
 
 	// Try to stack with existing items first
-	itemProps := items.ItemDefinitions[itemType]
+	quantity := item.Quantity
+	itemProps := items.ItemDefinitions[item.Type]
 	if itemProps != nil && itemProps.StackSize > 1 {
 		for i := range chest.Slots {
-			if chest.Slots[i].Type == itemType && chest.Slots[i].Quantity < itemProps.StackSize {
+			if chest.Slots[i].StacksWith(item) && chest.Slots[i].Quantity < itemProps.StackSize {
 				canAdd := itemProps.StackSize - chest.Slots[i].Quantity
 				if canAdd >= quantity {
 					chest.Slots[i].Quantity += quantity
@@ -121,9 +160,10 @@ func (cm *ChestManager) AddItemToChest(x, y float64, itemType items.ItemType, qu
 	for i := range chest.Slots {
 		if chest.Slots[i].Type == items.NONE {
 			chest.Slots[i] = items.Item{
-				Type:       itemType,
+				Type:       item.Type,
 				Quantity:   quantity,
-				Durability: itemProps.Durability,
+				Durability: item.Durability,
+				Meta:       item.Meta.Clone(),
 			}
 			return true
 		}
@@ -192,13 +232,26 @@ func (cm *ChestManager) SaveChests() error {
 	}
 
 	// Convert to serializable format
-	chestList := make([]*ChestInventory, 0, len(cm.chests))
+	file := chestFile{
+		Version:     FileVersion,
+		ItemPalette: items.NewItemPalette(),
+		Chests:      make([]chestRecord, 0, len(cm.chests)),
+	}
 	for _, chest := range cm.chests {
-		chestList = append(chestList, chest)
+		record := chestRecord{X: chest.X, Y: chest.Y, Slots: make([]slotRecord, len(chest.Slots))}
+		for i, slot := range chest.Slots {
+			record.Slots[i] = slotRecord{
+				Type:       items.EncodeItemType(file.ItemPalette, slot.Type),
+				Quantity:   slot.Quantity,
+				Durability: slot.Durability,
+				Meta:       slot.Meta.Clone(),
+			}
+		}
+		file.Chests = append(file.Chests, record)
 	}
 
 	// Marshal and save
-	data, err := json.MarshalIndent(chestList, "", "  ")
+	data, err := json.MarshalIndent(file, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal chests: %w", err)
 	}
@@ -228,9 +281,9 @@ func (cm *ChestManager) LoadChests() error {
 	}
 
 	// Unmarshal
-	var chestList []*ChestInventory
-	if err := json.Unmarshal(data, &chestList); err != nil {
-		return fmt.Errorf("failed to unmarshal chests: %w", err)
+	chestList, err := decodeChestFile(data)
+	if err != nil {
+		return err
 	}
 
 	// Populate map
@@ -249,6 +302,41 @@ func (cm *ChestManager) LoadChests() error {
 	return nil
 }
 
+// decodeChestFile reads a chests file in the current or the 1.0 format
+func decodeChestFile(data []byte) ([]*ChestInventory, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		var chestList []*ChestInventory
+		if err := json.Unmarshal(data, &chestList); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal chests: %w", err)
+		}
+		return chestList, nil
+	}
+
+	file := chestFile{ItemPalette: palette.New()}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal chests: %w", err)
+	}
+	if file.Version != FileVersion {
+		return nil, fmt.Errorf("unsupported chests file version %q", file.Version)
+	}
+
+	chestList := make([]*ChestInventory, 0, len(file.Chests))
+	for _, record := range file.Chests {
+		chest := &ChestInventory{X: record.X, Y: record.Y, Slots: make([]items.Item, len(record.Slots))}
+		for i, slot := range record.Slots {
+			chest.Slots[i] = items.Item{
+				Type:       items.DecodeItemType(file.ItemPalette, slot.Type),
+				Quantity:   slot.Quantity,
+				Durability: slot.Durability,
+				Meta:       slot.Meta,
+			}
+		}
+		chestList = append(chestList, chest)
+	}
+	return chestList, nil
+}
+
 // GetAllChests returns all chest positions and contents
 func (cm *ChestManager) GetAllChests() []*ChestInventory {
 	cm.mutex.RLock()
@@ -0,0 +1,253 @@
+package combat
+
+import (
+	"math"
+	"time"
+)
+
+// ProjectileKind decides how a projectile flies and what happens where it lands
+type ProjectileKind int
+
+const (
+	ProjectileArrow  ProjectileKind = iota // Fast shallow arc; sticks in terrain
+	ProjectileThrown                       // Lobbed item; drops where it lands
+	ProjectileSpell                        // Flies straight; bursts on impact
+)
+
+// Flight tuning per kind, in pixels and seconds
+const (
+	ArrowSpeed    = 900.0
+	ArrowGravity  = 700.0
+	ThrownSpeed   = 550.0
+	ThrownGravity = 980.0
+	SpellSpeed    = 650.0
+
+	// SpellBurstDuration is how long the flash where a spell lands lasts
+	SpellBurstDuration = 0.3
+
+	projectileLifetime = 4.0  // Seconds before a projectile still in flight is dropped
+	stuckLifetime      = 10.0 // Seconds an arrow stays stuck in terrain
+	spellLifetime      = 1.5
+
+	// maxProjectileStep is the furthest a projectile moves between collision
+	// checks, so fast arrows cannot pass through a hexagon or a target
+	maxProjectileStep = 12.0
+)
+
+// Projectile is an arrow, thrown item or spell in flight
+type Projectile struct {
+	Kind      ProjectileKind
+	Owner     string // ID of the shooter, who cannot be hit by it
+	Item      string // Item ID dropped where a thrown projectile lands
+	Weapon    string // Item ID of the weapon that fired it, for combat events
+	X, Y      float64
+	VX, VY    float64
+	Gravity   float64
+	Damage    float64
+	CritBonus float64 // Added to the chance of a critical hit, from enchantments
+	Age       float64
+	Lifetime  float64
+	Stuck     bool    // Lodged in terrain; no longer flies or hits
+	Angle     float64 // Direction of flight in radians, kept once stuck
+}
+
+// ProjectileTarget is anything a projectile can hit: mobs and players. X and
+// Y are the top-left of the bounding box.
+type ProjectileTarget struct {
+	ID     string
+	Kind   string // "mob" or "player"
+	X, Y   float64
+	Width  float64
+	Height float64
+	Health float64
+}
+
+// ProjectileImpact is a projectile striking a target or terrain
+type ProjectileImpact struct {
+	Projectile *Projectile
+	HitTarget  bool // False when the projectile hit terrain
+	Target     ProjectileTarget
+	Headshot   bool
+	Result     AttackResult
+}
+
+// SpellBurst is the short-lived flash where a spell lands
+type SpellBurst struct {
+	X, Y float64
+	Age  float64
+}
+
+// ProjectileSystem moves projectiles and reports what they hit
+type ProjectileSystem struct {
+	Projectiles  []*Projectile
+	Bursts       []*SpellBurst
+	LastFireTime time.Time
+}
+
+// NewProjectileSystem creates an empty projectile system
+func NewProjectileSystem() *ProjectileSystem {
+	return &ProjectileSystem{}
+}
+
+// CanFire checks whether a weapon firing the given number of shots per
+// second is ready again
+func (ps *ProjectileSystem) CanFire(shotsPerSecond float64) bool {
+	return time.Since(ps.LastFireTime) >= FireCooldown(shotsPerSecond)
+}
+
+// FireCooldown is the time between shots of a weapon firing the given number
+// of shots per second
+func FireCooldown(shotsPerSecond float64) time.Duration {
+	if shotsPerSecond <= 0 {
+		shotsPerSecond = 1
+	}
+	return time.Duration(float64(time.Second) / shotsPerSecond)
+}
+
+// Fire launches a projectile from a position towards a target point
+func (ps *ProjectileSystem) Fire(kind ProjectileKind, owner string, x, y, targetX, targetY, damage float64) *Projectile {
+	speed, gravity, lifetime := ArrowSpeed, ArrowGravity, projectileLifetime
+	switch kind {
+	case ProjectileThrown:
+		speed, gravity = ThrownSpeed, ThrownGravity
+	case ProjectileSpell:
+		speed, gravity, lifetime = SpellSpeed, 0, spellLifetime
+	}
+
+	angle := math.Atan2(targetY-y, targetX-x)
+	p := &Projectile{
+		Kind:     kind,
+		Owner:    owner,
+		X:        x,
+		Y:        y,
+		VX:       math.Cos(angle) * speed,
+		VY:       math.Sin(angle) * speed,
+		Gravity:  gravity,
+		Damage:   damage,
+		Lifetime: lifetime,
+		Angle:    angle,
+	}
+	ps.Projectiles = append(ps.Projectiles, p)
+	ps.LastFireTime = time.Now()
+	return p
+}
+
+// Update moves every projectile and returns what each one struck this frame.
+// isSolid reports whether a world position is inside a solid hexagon.
+func (ps *ProjectileSystem) Update(deltaTime float64, targets []ProjectileTarget, isSolid func(x, y float64) bool) []ProjectileImpact {
+	var impacts []ProjectileImpact
+
+	alive := ps.Projectiles[:0]
+	for _, p := range ps.Projectiles {
+		p.Age += deltaTime
+		if p.Stuck {
+			if p.Age < stuckLifetime {
+				alive = append(alive, p)
+			}
+			continue
+		}
+		if p.Age > p.Lifetime {
+			continue
+		}
+
+		impact, done := p.step(deltaTime, targets, isSolid)
+		if impact != nil {
+			impacts = append(impacts, *impact)
+			if p.Kind == ProjectileSpell {
+				ps.Bursts = append(ps.Bursts, &SpellBurst{X: p.X, Y: p.Y})
+			}
+		}
+		if !done {
+			alive = append(alive, p)
+		}
+	}
+	for i := len(alive); i < len(ps.Projectiles); i++ {
+		ps.Projectiles[i] = nil
+	}
+	ps.Projectiles = alive
+
+	bursts := ps.Bursts[:0]
+	for _, b := range ps.Bursts {
+		b.Age += deltaTime
+		if b.Age < SpellBurstDuration {
+			bursts = append(bursts, b)
+		}
+	}
+	ps.Bursts = bursts
+
+	return impacts
+}
+
+// step advances a projectile by one frame in short substeps, stopping at the
+// first target or solid hexagon. It reports the impact, if any, and whether
+// the projectile should be removed.
+func (p *Projectile) step(deltaTime float64, targets []ProjectileTarget, isSolid func(x, y float64) bool) (*ProjectileImpact, bool) {
+	p.VY += p.Gravity * deltaTime
+	distance := math.Hypot(p.VX, p.VY) * deltaTime
+	steps := int(math.Ceil(distance / maxProjectileStep))
+	if steps < 1 {
+		steps = 1
+	}
+	dx, dy := p.VX*deltaTime/float64(steps), p.VY*deltaTime/float64(steps)
+	p.Angle = math.Atan2(p.VY, p.VX)
+
+	for i := 0; i < steps; i++ {
+		p.X += dx
+		p.Y += dy
+
+		for _, t := range targets {
+			if t.ID == p.Owner || t.Health <= 0 {
+				continue
+			}
+			if p.X < t.X || p.X > t.X+t.Width || p.Y < t.Y || p.Y > t.Y+t.Height {
+				continue
+			}
+			return p.hit(t), true
+		}
+
+		if isSolid != nil && isSolid(p.X, p.Y) {
+			// Back out of the hexagon so arrows lodge in its face and thrown
+			// items drop outside it
+			p.X -= dx
+			p.Y -= dy
+			impact := &ProjectileImpact{Projectile: p}
+			impact.Result.HitX, impact.Result.HitY = p.X, p.Y
+			if p.Kind == ProjectileArrow {
+				p.Stuck = true
+				p.Age = 0
+				return impact, false
+			}
+			return impact, true
+		}
+	}
+	return nil, false
+}
+
+// hit works out the damage a projectile deals to a target. Hits in the top
+// third of the target count as headshots, which kill mobs and deal double
+// damage to players.
+func (p *Projectile) hit(t ProjectileTarget) *ProjectileImpact {
+	isHeadshot := p.Y-t.Y < t.Height/3
+	var damage float64
+	var tier CritTier
+	var isCrit bool
+	if t.Kind == "player" {
+		damage, tier, isCrit = calculatePlayerCrit(p.Damage, isHeadshot, p.CritBonus)
+	} else {
+		damage, tier, isCrit = calculateCrit(p.Damage, t.Health, isHeadshot, p.CritBonus)
+	}
+	return &ProjectileImpact{
+		Projectile: p,
+		HitTarget:  true,
+		Target:     t,
+		Headshot:   isHeadshot,
+		Result: AttackResult{
+			Hit:        true,
+			Damage:     damage,
+			IsCritical: isCrit,
+			Tier:       tier,
+			HitX:       p.X,
+			HitY:       p.Y,
+		},
+	}
+}
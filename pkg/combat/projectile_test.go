@@ -0,0 +1,26 @@
+package combat
+
+import "testing"
+
+func TestProjectileHitPlayer(t *testing.T) {
+	player := ProjectileTarget{ID: "player:Alice", Kind: "player", Width: 40, Height: 80, Health: 100}
+	zombie := ProjectileTarget{ID: "zombie_1", Kind: "mob", Width: 40, Height: 80, Health: 100}
+
+	// A headshot kills a mob outright but only doubles the damage to a player
+	p := &Projectile{X: 20, Y: 10, Damage: 10}
+	if impact := p.hit(zombie); impact.Result.Damage != 100 || impact.Result.Tier != CritTierPurple {
+		t.Errorf("zombie headshot = %v %v, want fatal", impact.Result.Damage, impact.Result.Tier)
+	}
+	if impact := p.hit(player); impact.Result.Damage != 20 || impact.Result.Tier != CritTierRed {
+		t.Errorf("player headshot = %v %v, want 20 red", impact.Result.Damage, impact.Result.Tier)
+	}
+
+	// Body shots on players never roll the fatal tier
+	p.Y = 60
+	for range 1000 {
+		impact := p.hit(player)
+		if impact.Result.Tier == CritTierPurple || impact.Result.Damage > 20 {
+			t.Fatalf("player body shot = %v %v", impact.Result.Damage, impact.Result.Tier)
+		}
+	}
+}
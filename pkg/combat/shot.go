@@ -0,0 +1,64 @@
+package combat
+
+import (
+	"tesselbox/pkg/enchant"
+	"tesselbox/pkg/items"
+)
+
+// Shot is the projectile an item launches and what launching it costs. The
+// client and the server both work shots out here so they agree on them.
+type Shot struct {
+	Kind      ProjectileKind
+	Weapon    string // Item ID of the bow, wand or thrown item
+	Damage    float64
+	CritBonus float64
+	Rate      float64        // Shots per second
+	Ammo      items.ItemType // Used up per shot; NONE for wands and thrown items
+}
+
+// ShotFor works out the shot an item fires, or, when throw is set, the shot
+// of throwing it. Bows fire arrows and use up their ammo, wands fire spells
+// and wear down, and thrown items are used up themselves.
+func ShotFor(item items.Item, throw bool) (Shot, bool) {
+	props := items.GetItemProperties(item.Type)
+	if item.Type == items.NONE || props == nil {
+		return Shot{}, false
+	}
+	shot := Shot{Weapon: items.ItemID(item.Type)}
+
+	if throw {
+		if props.ThrowDamage <= 0 {
+			return Shot{}, false
+		}
+		shot.Kind = ProjectileThrown
+		shot.Damage = props.ThrowDamage
+		shot.Rate = 2
+		return shot, true
+	}
+
+	switch {
+	case props.IsWeapon && props.WeaponType == "ranged":
+		shot.Kind = ProjectileArrow
+		if props.AmmoType != "" {
+			ammo, ok := items.ItemTypeByID(props.AmmoType)
+			if !ok {
+				return Shot{}, false
+			}
+			shot.Ammo = ammo
+		}
+	case props.IsWeapon && props.WeaponType == "magic":
+		shot.Kind = ProjectileSpell
+	default:
+		return Shot{}, false
+	}
+	shot.Damage = props.WeaponDamage * (1 + enchant.Bonus(item.Meta, enchant.StatDamage))
+	shot.CritBonus = enchant.Bonus(item.Meta, enchant.StatCritChance)
+	shot.Rate = props.WeaponSpeed
+	return shot, true
+}
+
+// PlayerTargetID is the projectile target and owner ID of a player, kept
+// apart from mob IDs
+func PlayerTargetID(name string) string {
+	return "player:" + name
+}
@@ -1,4 +1,4 @@
-// Package combat implements weapon swing mechanics
+// Package combat implements weapon swing and projectile mechanics
 package combat
 
 import (
@@ -6,7 +6,9 @@ import (
 	"math/rand"
 	"time"
 
-	"tesselbox/pkg/enemies"
+	"tesselbox/pkg/enchant"
+	"tesselbox/pkg/items"
+	"tesselbox/pkg/mobs"
 )
 
 // WeaponSwing represents an active weapon swing attack
@@ -160,8 +162,9 @@ type AttackResult struct {
 	HitY       float64
 }
 
-// calculateCrit determines critical hit tier based on random chance and headshots
-func calculateCrit(baseDamage, zombieHealth float64, isHeadshot bool) (float64, CritTier, bool) {
+// calculateCrit determines critical hit tier based on random chance and
+// headshots; critBonus is added to the chance of each critical tier
+func calculateCrit(baseDamage, zombieHealth float64, isHeadshot bool, critBonus float64) (float64, CritTier, bool) {
 	// Headshots are always purple (instant death) for normal zombies
 	if isHeadshot {
 		return zombieHealth, CritTierPurple, true
@@ -169,7 +172,7 @@ func calculateCrit(baseDamage, zombieHealth float64, isHeadshot bool) (float64,
 
 	// Random critical chance
 	// 5% purple, 15% red, 30% yellow, 50% green
-	roll := rand.Float64()
+	roll := rand.Float64() - critBonus
 
 	switch {
 	case roll < 0.05: // 5% - Purple (Fatal)
@@ -183,9 +186,25 @@ func calculateCrit(baseDamage, zombieHealth float64, isHeadshot bool) (float64,
 	}
 }
 
-// PerformAttack executes an attack and returns results for all hit targets
-func (ws *WeaponSystem) PerformAttack(playerX, playerY, targetX, targetY, damage float64, zombies []*enemies.Zombie) []AttackResult {
+// calculatePlayerCrit is calculateCrit for hits on players, which no single
+// hit kills outright: headshots and the fatal tier deal double damage
+func calculatePlayerCrit(baseDamage float64, isHeadshot bool, critBonus float64) (float64, CritTier, bool) {
+	if isHeadshot {
+		return baseDamage * 2, CritTierRed, true
+	}
+	damage, tier, isCrit := calculateCrit(baseDamage, 0, false, critBonus)
+	if tier == CritTierPurple {
+		return baseDamage * 2, CritTierRed, true
+	}
+	return damage, tier, isCrit
+}
+
+// PerformAttack executes an attack and returns results for all hit targets.
+// The weapon's metadata, if any, adds its enchantments' damage and crit chance.
+func (ws *WeaponSystem) PerformAttack(playerX, playerY, targetX, targetY, damage float64, weapon *items.Metadata, targets []*mobs.Mob) []AttackResult {
 	results := make([]AttackResult, 0)
+	damage *= 1 + enchant.Bonus(weapon, enchant.StatDamage)
+	critBonus := enchant.Bonus(weapon, enchant.StatCritChance)
 
 	// Start the swing
 	ws.StartSwing(playerX, playerY, targetX, targetY, damage)
@@ -193,27 +212,27 @@ func (ws *WeaponSystem) PerformAttack(playerX, playerY, targetX, targetY, damage
 		return results
 	}
 
-	// Check hits against all zombies
-	for _, zombie := range zombies {
-		if !zombie.IsAlive {
+	// Check hits against all mobs
+	for _, mob := range targets {
+		if !mob.IsAlive() {
 			continue
 		}
 
-		if ws.CheckHit(zombie.X, zombie.Y, zombie.Width, zombie.Height) {
+		if ws.CheckHit(mob.X, mob.Y, mob.Width, mob.Height) {
 			// Check if headshot (aiming at upper body/head area)
-			targetCenterY := zombie.Y + zombie.Height/2
-			isHeadshot := (targetCenterY - zombie.Y) < zombie.Height/3
+			targetCenterY := mob.Y + mob.Height/2
+			isHeadshot := (targetCenterY - mob.Y) < mob.Height/3
 
 			// Calculate critical hit
-			finalDamage, tier, isCrit := calculateCrit(damage, zombie.Health, isHeadshot)
+			finalDamage, tier, isCrit := calculateCrit(damage, mob.Health, isHeadshot, critBonus)
 
 			result := AttackResult{
 				Hit:        true,
 				Damage:     finalDamage,
 				IsCritical: isCrit,
 				Tier:       tier,
-				HitX:       zombie.X + zombie.Width/2,
-				HitY:       zombie.Y + zombie.Height/2,
+				HitX:       mob.X + mob.Width/2,
+				HitY:       mob.Y + mob.Height/2,
 			}
 			results = append(results, result)
 		}
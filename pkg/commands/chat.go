@@ -146,9 +146,9 @@ func (ch *ChatHandler) handlePluginCommand(args []string) string {
 
 // loadPlugin loads a plugin from file
 func (ch *ChatHandler) loadPlugin(filename string) string {
-	// Ensure .so extension
-	if !strings.HasSuffix(filename, ".so") && !strings.HasSuffix(filename, ".dll") {
-		filename += ".so" // Default to .so for Linux
+	// Ensure script extension
+	if !entities.IsScriptPluginFile(filename) {
+		filename += entities.ScriptExtension
 	}
 
 	pluginPath := filepath.Join("plugins", filename)
@@ -254,13 +254,8 @@ func (ch *ChatHandler) reloadPlugin(name string) string {
 		return fmt.Sprintf("Plugin not found: %s", name)
 	}
 
-	// Unload
-	if err := ch.pluginManager.UnloadPlugin(name); err != nil {
-		return fmt.Sprintf("Failed to unload plugin: %v", err)
-	}
-
-	// Reload
-	if err := ch.pluginManager.LoadPlugin(name); err != nil {
+	// Reload, keeping the running version if the script has errors
+	if err := ch.pluginManager.ReloadPlugin(name); err != nil {
 		return fmt.Sprintf("Failed to reload plugin: %v", err)
 	}
 
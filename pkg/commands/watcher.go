@@ -96,7 +96,7 @@ func (pw *PluginWatcher) scanPlugins() {
 
 		name := entry.Name()
 
-		// Only watch .so and .dll files
+		// Only watch plugin scripts
 		if !isPluginFile(name) {
 			continue
 		}
@@ -146,7 +146,8 @@ func (pw *PluginWatcher) handleNewPlugin(path string) {
 	}
 }
 
-// handleModifiedPlugin handles a modified plugin
+// handleModifiedPlugin hot-reloads a modified plugin, or retries loading one
+// that failed before
 func (pw *PluginWatcher) handleModifiedPlugin(path string) {
 	log.Printf("Plugin modified: %s", path)
 
@@ -154,31 +155,17 @@ func (pw *PluginWatcher) handleModifiedPlugin(path string) {
 		return
 	}
 
-	// Get plugin name from path
-	name := filepath.Base(path)
-
-	// Check if plugin is loaded
-	plugins := pw.pluginManager.ListPlugins()
-	isLoaded := false
-	for _, p := range plugins {
-		if p == name {
-			isLoaded = true
-			break
-		}
+	name := entities.ScriptPluginName(path)
+	if !pw.pluginManager.IsLoaded(name) {
+		pw.handleNewPlugin(path)
+		return
 	}
 
-	if isLoaded {
-		// Reload the plugin
-		if err := pw.pluginManager.UnloadPlugin(name); err != nil {
-			log.Printf("Failed to unload plugin %s for reload: %v", name, err)
-			return
-		}
-
-		if err := pw.pluginManager.LoadPlugin(path); err != nil {
-			log.Printf("Failed to reload plugin %s: %v", name, err)
-		} else {
-			log.Printf("Auto-reloaded plugin: %s", name)
-		}
+	// The edited script is compiled before the running version is replaced
+	if err := pw.pluginManager.ReloadPlugin(name); err != nil {
+		log.Printf("Failed to reload plugin %s: %v", name, err)
+	} else {
+		log.Printf("Auto-reloaded plugin: %s", name)
 	}
 }
 
@@ -190,8 +177,10 @@ func (pw *PluginWatcher) handleDeletedPlugin(path string) {
 		return
 	}
 
-	// Get plugin name from path
-	name := filepath.Base(path)
+	name := entities.ScriptPluginName(path)
+	if !pw.pluginManager.IsLoaded(name) {
+		return
+	}
 
 	// Unload the plugin
 	if err := pw.pluginManager.UnloadPlugin(name); err != nil {
@@ -203,5 +192,5 @@ func (pw *PluginWatcher) handleDeletedPlugin(path string) {
 
 // isPluginFile checks if a file is a plugin file
 func isPluginFile(name string) bool {
-	return filepath.Ext(name) == ".so" || filepath.Ext(name) == ".dll"
+	return entities.IsScriptPluginFile(name)
 }
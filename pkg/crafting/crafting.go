@@ -2,7 +2,11 @@ package crafting
 
 import (
 	"fmt"
+	"log"
+	"sort"
+	"strings"
 	"tesselbox/pkg/items"
+	"tesselbox/pkg/station"
 
 	"tesselbox/assets"
 
@@ -19,6 +23,55 @@ const (
 	STATION_ANVIL
 )
 
+// stationNames are the names crafting_recipes.yaml uses for stations
+var stationNames = map[string]CraftingStation{
+	"none":      STATION_NONE,
+	"workbench": STATION_WORKBENCH,
+	"furnace":   STATION_FURNACE,
+	"anvil":     STATION_ANVIL,
+}
+
+// UnmarshalYAML accepts a station name ("furnace") or a raw station number
+func (s *CraftingStation) UnmarshalYAML(value *yaml.Node) error {
+	var name string
+	if err := value.Decode(&name); err != nil {
+		return err
+	}
+	if named, ok := stationNames[name]; ok {
+		*s = named
+		return nil
+	}
+
+	var raw int
+	if err := value.Decode(&raw); err == nil && value.Tag == "!!int" {
+		*s = CraftingStation(raw)
+		return nil
+	}
+	return fmt.Errorf("unknown crafting station %q at line %d", name, value.Line)
+}
+
+// ParseStation returns the station with a name, such as "workbench"
+func ParseStation(name string) (CraftingStation, bool) {
+	station, ok := stationNames[name]
+	return station, ok
+}
+
+// Name returns the station's name, as recipes and the network protocol use it
+func (s CraftingStation) Name() string {
+	for name, station := range stationNames {
+		if station == s {
+			return name
+		}
+	}
+	return fmt.Sprintf("%d", int(s))
+}
+
+// IsTimed reports whether a station processes its recipes over time as a
+// placed block entity rather than crafting them on the spot
+func (s CraftingStation) IsTimed() bool {
+	return s == STATION_FURNACE
+}
+
 // RecipeInput represents an input item requirement
 type RecipeInput struct {
 	ItemType items.ItemType `yaml:"item_type"`
@@ -41,18 +94,27 @@ type Recipe struct {
 	CraftingTime    float64         `yaml:"crafting_time"`    // in seconds, 0 = instant
 	RequiredTool    items.ItemType  `yaml:"required_tool"`    // NONE if no tool required
 	RequiredStation CraftingStation `yaml:"required_station"` // STATION_NONE if no station required
+
+	// Shape lays the recipe out on the crafting grid; its inputs are then
+	// the items in it. Recipes without one are shapeless.
+	Shape  []ShapeCell    `yaml:"shape"`
+	Unlock *UnlockTrigger `yaml:"unlock"` // nil if known from the start
 }
 
 // CraftingSystem manages recipes and crafting operations
 type CraftingSystem struct {
-	recipes       map[string]*Recipe
-	OnItemCrafted func(recipeID string) // Callback for when an item is crafted
+	recipes          map[string]*Recipe
+	unlocked         map[string]bool       // IDs of the recipes the player was taught
+	triggers         unlockIndex           // Locked recipes by what unlocks them
+	OnItemCrafted    func(recipeID string) // Callback for when an item is crafted
+	OnRecipeUnlocked func(recipe *Recipe)  // Callback for when the player learns a recipe
 }
 
 // NewCraftingSystem creates a new crafting system
 func NewCraftingSystem() *CraftingSystem {
 	return &CraftingSystem{
-		recipes: make(map[string]*Recipe),
+		recipes:  make(map[string]*Recipe),
+		unlocked: make(map[string]bool),
 	}
 }
 
@@ -76,10 +138,15 @@ func (cs *CraftingSystem) LoadRecipes(filePath string) error {
 	// Load recipes
 	loadedCount := 0
 	for i := range recipes {
-		if recipes[i].ID != "" {
-			cs.recipes[recipes[i].ID] = &recipes[i]
-			loadedCount++
+		if recipes[i].ID == "" {
+			continue
 		}
+		if err := recipes[i].prepareShape(); err != nil {
+			log.Printf("Warning: Skipping recipe: %v", err)
+			continue
+		}
+		cs.recipes[recipes[i].ID] = &recipes[i]
+		loadedCount++
 	}
 
 	// If no valid recipes were loaded, fall back to defaults
@@ -87,6 +154,8 @@ func (cs *CraftingSystem) LoadRecipes(filePath string) error {
 		return cs.loadDefaultRecipes()
 	}
 
+	cs.registerStationRecipes()
+	cs.indexTriggers()
 	return nil
 }
 
@@ -101,10 +170,7 @@ func (cs *CraftingSystem) loadDefaultRecipes() error {
 			ID:          "wooden_pickaxe",
 			Name:        "Wooden Pickaxe",
 			Description: "A basic pickaxe for mining stone",
-			Inputs: []RecipeInput{
-				{ItemType: items.PLANKS, Quantity: 3},
-				{ItemType: items.STICK, Quantity: 2},
-			},
+			Shape:       pickaxeShape(items.PLANKS),
 			Outputs: []RecipeOutput{
 				{ItemType: items.WOODEN_PICKAXE, Quantity: 1},
 			},
@@ -116,10 +182,7 @@ func (cs *CraftingSystem) loadDefaultRecipes() error {
 			ID:          "stone_pickaxe",
 			Name:        "Stone Pickaxe",
 			Description: "A sturdy pickaxe for mining ores",
-			Inputs: []RecipeInput{
-				{ItemType: items.STONE_BLOCK, Quantity: 3},
-				{ItemType: items.STICK, Quantity: 2},
-			},
+			Shape:       pickaxeShape(items.STONE_BLOCK),
 			Outputs: []RecipeOutput{
 				{ItemType: items.STONE_PICKAXE, Quantity: 1},
 			},
@@ -187,10 +250,7 @@ func (cs *CraftingSystem) loadDefaultRecipes() error {
 			ID:          "iron_pickaxe",
 			Name:        "Iron Pickaxe",
 			Description: "A durable pickaxe for mining tough materials",
-			Inputs: []RecipeInput{
-				{ItemType: items.IRON_INGOT, Quantity: 3},
-				{ItemType: items.STICK, Quantity: 2},
-			},
+			Shape:       pickaxeShape(items.IRON_INGOT),
 			Outputs: []RecipeOutput{
 				{ItemType: items.IRON_PICKAXE, Quantity: 1},
 			},
@@ -202,10 +262,7 @@ func (cs *CraftingSystem) loadDefaultRecipes() error {
 			ID:          "wooden_sword",
 			Name:        "Wooden Sword",
 			Description: "A basic weapon for defense",
-			Inputs: []RecipeInput{
-				{ItemType: items.PLANKS, Quantity: 2},
-				{ItemType: items.STICK, Quantity: 1},
-			},
+			Shape:       swordShape(items.PLANKS),
 			Outputs: []RecipeOutput{
 				{ItemType: items.WOODEN_SWORD, Quantity: 1},
 			},
@@ -217,10 +274,7 @@ func (cs *CraftingSystem) loadDefaultRecipes() error {
 			ID:          "stone_sword",
 			Name:        "Stone Sword",
 			Description: "A sturdy weapon for combat",
-			Inputs: []RecipeInput{
-				{ItemType: items.STONE_BLOCK, Quantity: 2},
-				{ItemType: items.STICK, Quantity: 1},
-			},
+			Shape:       swordShape(items.STONE_BLOCK),
 			Outputs: []RecipeOutput{
 				{ItemType: items.STONE_SWORD, Quantity: 1},
 			},
@@ -260,10 +314,7 @@ func (cs *CraftingSystem) loadDefaultRecipes() error {
 			ID:          "iron_sword",
 			Name:        "Iron Sword",
 			Description: "A strong weapon for serious combat",
-			Inputs: []RecipeInput{
-				{ItemType: items.IRON_INGOT, Quantity: 2},
-				{ItemType: items.STICK, Quantity: 1},
-			},
+			Shape:       swordShape(items.IRON_INGOT),
 			Outputs: []RecipeOutput{
 				{ItemType: items.IRON_SWORD, Quantity: 1},
 			},
@@ -450,10 +501,7 @@ func (cs *CraftingSystem) loadDefaultRecipes() error {
 			ID:          "diamond_sword",
 			Name:        "Diamond Sword",
 			Description: "The ultimate weapon",
-			Inputs: []RecipeInput{
-				{ItemType: items.DIAMOND, Quantity: 2},
-				{ItemType: items.STICK, Quantity: 1},
-			},
+			Shape:       swordShape(items.DIAMOND),
 			Outputs: []RecipeOutput{
 				{ItemType: items.DIAMOND_SWORD, Quantity: 1},
 			},
@@ -461,6 +509,49 @@ func (cs *CraftingSystem) loadDefaultRecipes() error {
 			RequiredTool:    items.IRON_PICKAXE,
 			RequiredStation: STATION_ANVIL,
 		},
+		// Food and drink
+		{
+			ID:          "cooked_meat",
+			Name:        "Cooked Meat",
+			Description: "Cook raw meat so it is safe and filling",
+			Inputs: []RecipeInput{
+				{ItemType: items.RAW_MEAT, Quantity: 1},
+			},
+			Outputs: []RecipeOutput{
+				{ItemType: items.COOKED_MEAT, Quantity: 1},
+			},
+			CraftingTime:    6.0,
+			RequiredTool:    items.NONE,
+			RequiredStation: STATION_FURNACE,
+		},
+		{
+			ID:          "roasted_mushroom",
+			Name:        "Roasted Mushroom",
+			Description: "Roast a wild mushroom",
+			Inputs: []RecipeInput{
+				{ItemType: items.MUSHROOM, Quantity: 1},
+			},
+			Outputs: []RecipeOutput{
+				{ItemType: items.ROASTED_MUSHROOM, Quantity: 1},
+			},
+			CraftingTime:    4.0,
+			RequiredTool:    items.NONE,
+			RequiredStation: STATION_FURNACE,
+		},
+		{
+			ID:          "bottle",
+			Name:        "Bottle",
+			Description: "Blow glass into bottles for carrying water",
+			Inputs: []RecipeInput{
+				{ItemType: items.GLASS, Quantity: 1},
+			},
+			Outputs: []RecipeOutput{
+				{ItemType: items.BOTTLE, Quantity: 3},
+			},
+			CraftingTime:    2.0,
+			RequiredTool:    items.NONE,
+			RequiredStation: STATION_WORKBENCH,
+		},
 		// Dimension portal recipe
 		{
 			ID:          "randomland_portal",
@@ -483,12 +574,67 @@ func (cs *CraftingSystem) loadDefaultRecipes() error {
 
 	// Load default recipes
 	for i := range defaultRecipes {
+		if err := defaultRecipes[i].prepareShape(); err != nil {
+			log.Printf("Warning: Skipping recipe: %v", err)
+			continue
+		}
 		cs.recipes[defaultRecipes[i].ID] = &defaultRecipes[i]
 	}
 
+	cs.registerStationRecipes()
+	cs.indexTriggers()
 	return nil
 }
 
+// pickaxeShape lays a pickaxe out as a row of head material over a two stick
+// handle
+func pickaxeShape(head items.ItemType) []ShapeCell {
+	return []ShapeCell{
+		{HexCoord: HexCoord{Q: -1, R: 0}, ItemType: head},
+		{HexCoord: HexCoord{Q: 0, R: 0}, ItemType: head},
+		{HexCoord: HexCoord{Q: 1, R: 0}, ItemType: head},
+		{HexCoord: HexCoord{Q: -1, R: 1}, ItemType: items.STICK},
+		{HexCoord: HexCoord{Q: 0, R: 1}, ItemType: items.STICK},
+	}
+}
+
+// swordShape lays a sword out as a diagonal of two blade material over a stick
+func swordShape(blade items.ItemType) []ShapeCell {
+	return []ShapeCell{
+		{HexCoord: HexCoord{Q: 1, R: -1}, ItemType: blade},
+		{HexCoord: HexCoord{Q: 0, R: 0}, ItemType: blade},
+		{HexCoord: HexCoord{Q: -1, R: 1}, ItemType: items.STICK},
+	}
+}
+
+// registerStationRecipes hands the recipes of timed stations to the station
+// package, which processes them in placed stations using their crafting
+// time. Only recipes with a single input and output can be processed there.
+func (cs *CraftingSystem) registerStationRecipes() {
+	var furnace []*station.Recipe
+	for _, recipe := range cs.recipes {
+		if recipe.RequiredStation != STATION_FURNACE {
+			continue
+		}
+		if len(recipe.Inputs) != 1 || len(recipe.Outputs) != 1 {
+			log.Printf("Warning: Furnace recipe %s needs exactly one input and one output", recipe.ID)
+			continue
+		}
+		furnace = append(furnace, &station.Recipe{
+			ID:             recipe.ID,
+			Kind:           station.KindFurnace,
+			Input:          recipe.Inputs[0].ItemType,
+			InputQuantity:  recipe.Inputs[0].Quantity,
+			Output:         recipe.Outputs[0].ItemType,
+			OutputQuantity: recipe.Outputs[0].Quantity,
+			Time:           recipe.CraftingTime,
+		})
+	}
+	if err := station.SetRecipes(station.KindFurnace, furnace); err != nil {
+		log.Printf("Warning: Failed to register furnace recipes: %v", err)
+	}
+}
+
 // LoadRecipesFromAssets loads recipes from embedded assets
 func (cs *CraftingSystem) LoadRecipesFromAssets() error {
 	return cs.LoadRecipes("crafting_recipes.yaml")
@@ -511,10 +657,17 @@ func (cs *CraftingSystem) GetAllRecipes() []*Recipe {
 
 // CanCraft checks if the player can craft a recipe at the given station
 func (cs *CraftingSystem) CanCraft(recipe *Recipe, inventory *items.Inventory, station CraftingStation) bool {
-	// Check if required station matches
+	// Check if required station matches; timed stations process their
+	// recipes themselves
 	if recipe.RequiredStation != STATION_NONE && recipe.RequiredStation != station {
 		return false
 	}
+	if recipe.RequiredStation.IsTimed() {
+		return false
+	}
+	if !cs.IsUnlocked(recipe.ID) {
+		return false
+	}
 
 	// Check if required tool is in selected slot
 	if recipe.RequiredTool != items.NONE {
@@ -567,10 +720,30 @@ func (cs *CraftingSystem) Craft(recipeID string, inventory *items.Inventory, sta
 	}
 
 	// Check if crafting is possible
+	if recipe.IsShaped() {
+		return fmt.Errorf("%s must be laid out on the crafting grid", recipe.Name)
+	}
+	if !cs.CanCraft(recipe, inventory, station) {
+		return fmt.Errorf("cannot craft %s: missing materials, tools, or station", recipe.Name)
+	}
+	return cs.craft(recipe, inventory)
+}
+
+// CraftRecipe crafts a recipe by ID, shaped or not, as if it had been laid
+// out on the grid. Servers use it to repeat crafts their clients made.
+func (cs *CraftingSystem) CraftRecipe(recipeID string, inventory *items.Inventory, station CraftingStation) error {
+	recipe, exists := cs.GetRecipe(recipeID)
+	if !exists {
+		return fmt.Errorf("recipe not found: %s", recipeID)
+	}
 	if !cs.CanCraft(recipe, inventory, station) {
 		return fmt.Errorf("cannot craft %s: missing materials, tools, or station", recipe.Name)
 	}
+	return cs.craft(recipe, inventory)
+}
 
+// craft takes a recipe's materials from the inventory and gives its results
+func (cs *CraftingSystem) craft(recipe *Recipe, inventory *items.Inventory) error {
 	// Remove input materials
 	for _, input := range recipe.Inputs {
 		if !inventory.RemoveItemType(input.ItemType, input.Quantity) {
@@ -594,7 +767,7 @@ func (cs *CraftingSystem) Craft(recipeID string, inventory *items.Inventory, sta
 
 	// Call crafting callback if set
 	if cs.OnItemCrafted != nil {
-		cs.OnItemCrafted(recipeID)
+		cs.OnItemCrafted(recipe.ID)
 	}
 
 	return nil
@@ -613,6 +786,39 @@ func (cs *CraftingSystem) GetAvailableRecipes(inventory *items.Inventory, statio
 	return available
 }
 
+// SearchRecipes returns the recipes the player knows whose name, description
+// or results contain a query, ignoring case, sorted by name
+func (cs *CraftingSystem) SearchRecipes(query string) []*Recipe {
+	query = strings.ToLower(strings.TrimSpace(query))
+	found := []*Recipe{}
+	for _, recipe := range cs.recipes {
+		if cs.IsUnlocked(recipe.ID) && recipe.mentions(query) {
+			found = append(found, recipe)
+		}
+	}
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].Name != found[j].Name {
+			return found[i].Name < found[j].Name
+		}
+		return found[i].ID < found[j].ID
+	})
+	return found
+}
+
+// mentions reports whether a recipe's name, description or results contain
+// a lowercase query
+func (r *Recipe) mentions(query string) bool {
+	if query == "" || strings.Contains(strings.ToLower(r.Name), query) || strings.Contains(strings.ToLower(r.Description), query) {
+		return true
+	}
+	for _, output := range r.Outputs {
+		if strings.Contains(strings.ToLower(items.ItemNameByID(output.ItemType)), query) {
+			return true
+		}
+	}
+	return false
+}
+
 // GetRecipeCount returns the number of loaded recipes
 func (cs *CraftingSystem) GetRecipeCount() int {
 	return len(cs.recipes)
@@ -0,0 +1,203 @@
+package crafting
+
+import (
+	"fmt"
+	"sort"
+
+	"tesselbox/pkg/items"
+)
+
+// GridRadius is how many rings of cells the crafting grid has around its
+// center cell; a radius of 1 gives seven cells
+const GridRadius = 1
+
+// HexCoord is a cell of the crafting grid in axial coordinates. Q runs along
+// a row and R down the rows, with each row shifted half a cell to the right
+// of the one above.
+type HexCoord struct {
+	Q int `yaml:"q"`
+	R int `yaml:"r"`
+}
+
+// InGrid reports whether a cell is on the crafting grid
+func (c HexCoord) InGrid() bool {
+	return (abs(c.Q)+abs(c.R)+abs(c.Q+c.R))/2 <= GridRadius
+}
+
+// GridCells returns the cells of the crafting grid, center first and then
+// row by row
+func GridCells() []HexCoord {
+	cells := []HexCoord{{}}
+	for r := -GridRadius; r <= GridRadius; r++ {
+		for q := -GridRadius; q <= GridRadius; q++ {
+			c := HexCoord{Q: q, R: r}
+			if c != (HexCoord{}) && c.InGrid() {
+				cells = append(cells, c)
+			}
+		}
+	}
+	return cells
+}
+
+// ShapeCell is an item a shaped recipe needs at a cell of its pattern
+type ShapeCell struct {
+	HexCoord `yaml:",inline"`
+	ItemType items.ItemType `yaml:"item_type"`
+}
+
+// CraftingGrid is a layout of item types on the crafting grid. It only holds
+// the layout: crafting takes the items from the inventory.
+type CraftingGrid map[HexCoord]items.ItemType
+
+// IsShaped reports whether a recipe must be laid out on the crafting grid
+func (r *Recipe) IsShaped() bool {
+	return len(r.Shape) > 0
+}
+
+// prepareShape checks a shaped recipe's pattern and sets its inputs to the
+// items in it, one per cell
+func (r *Recipe) prepareShape() error {
+	if !r.IsShaped() {
+		return nil
+	}
+	seen := make(map[HexCoord]bool, len(r.Shape))
+	counts := make(map[items.ItemType]int)
+	for _, cell := range r.Shape {
+		if cell.ItemType == items.NONE {
+			return fmt.Errorf("recipe %s has an empty cell in its shape", r.ID)
+		}
+		if seen[cell.HexCoord] {
+			return fmt.Errorf("recipe %s has two items at cell %d,%d", r.ID, cell.Q, cell.R)
+		}
+		seen[cell.HexCoord] = true
+		counts[cell.ItemType]++
+	}
+	if _, ok := r.placement(); !ok {
+		return fmt.Errorf("recipe %s does not fit on the crafting grid", r.ID)
+	}
+
+	r.Inputs = r.Inputs[:0]
+	for itemType, quantity := range counts {
+		r.Inputs = append(r.Inputs, RecipeInput{ItemType: itemType, Quantity: quantity})
+	}
+	sort.Slice(r.Inputs, func(i, j int) bool { return r.Inputs[i].ItemType < r.Inputs[j].ItemType })
+	return nil
+}
+
+// placement returns the recipe's pattern moved onto the crafting grid, as
+// close to its center as it fits
+func (r *Recipe) placement() (CraftingGrid, bool) {
+	pattern := normalizeShape(r.Shape)
+	for _, anchor := range GridCells() {
+		grid := make(CraftingGrid, len(pattern))
+		fits := true
+		for _, cell := range pattern {
+			c := HexCoord{Q: cell.Q + anchor.Q, R: cell.R + anchor.R}
+			if !c.InGrid() {
+				fits = false
+				break
+			}
+			grid[c] = cell.ItemType
+		}
+		if fits {
+			return grid, true
+		}
+	}
+	return nil, false
+}
+
+// normalizeShape moves a pattern so its first cell, by row and then along
+// the row, is at the origin, and sorts it in that order. Two patterns that
+// are the same but for where they sit on the grid normalize the same.
+func normalizeShape(shape []ShapeCell) []ShapeCell {
+	cells := append([]ShapeCell(nil), shape...)
+	sort.Slice(cells, func(i, j int) bool {
+		if cells[i].R != cells[j].R {
+			return cells[i].R < cells[j].R
+		}
+		return cells[i].Q < cells[j].Q
+	})
+	if len(cells) == 0 {
+		return cells
+	}
+	origin := cells[0].HexCoord
+	for i := range cells {
+		cells[i].Q -= origin.Q
+		cells[i].R -= origin.R
+	}
+	return cells
+}
+
+// matches reports whether a grid holds exactly the recipe's pattern,
+// wherever on the grid it sits
+func (r *Recipe) matches(grid CraftingGrid) bool {
+	if !r.IsShaped() {
+		return false
+	}
+	laid := make([]ShapeCell, 0, len(grid))
+	for c, itemType := range grid {
+		if itemType != items.NONE {
+			laid = append(laid, ShapeCell{HexCoord: c, ItemType: itemType})
+		}
+	}
+	if len(laid) != len(r.Shape) {
+		return false
+	}
+	want, got := normalizeShape(r.Shape), normalizeShape(laid)
+	for i := range want {
+		if want[i] != got[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchGrid returns the shaped recipe laid out on a grid, or nil if there is
+// none. Locked recipes match too, so players can discover them.
+func (cs *CraftingSystem) MatchGrid(grid CraftingGrid) *Recipe {
+	for _, id := range cs.sortedIDs() {
+		if recipe := cs.recipes[id]; recipe.matches(grid) {
+			return recipe
+		}
+	}
+	return nil
+}
+
+// FillGrid lays a shaped recipe out on the crafting grid, or returns nil if
+// the inventory lacks its materials
+func (cs *CraftingSystem) FillGrid(recipe *Recipe, inventory *items.Inventory) CraftingGrid {
+	if !recipe.IsShaped() {
+		return nil
+	}
+	for _, input := range recipe.Inputs {
+		if !inventory.HasItem(input.ItemType, input.Quantity) {
+			return nil
+		}
+	}
+	grid, _ := recipe.placement()
+	return grid
+}
+
+// CraftGrid crafts the shaped recipe laid out on a grid, taking its materials
+// from the inventory. Laying out a locked recipe discovers it.
+func (cs *CraftingSystem) CraftGrid(grid CraftingGrid, inventory *items.Inventory, station CraftingStation) (*Recipe, error) {
+	recipe := cs.MatchGrid(grid)
+	if recipe == nil {
+		return nil, fmt.Errorf("nothing can be made from that layout")
+	}
+	if !cs.IsUnlocked(recipe.ID) {
+		cs.Unlock(recipe.ID)
+	}
+	if !cs.CanCraft(recipe, inventory, station) {
+		return recipe, fmt.Errorf("cannot craft %s: missing materials, tools, or station", recipe.Name)
+	}
+	return recipe, cs.craft(recipe, inventory)
+}
+
+// abs returns the absolute value of an int
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
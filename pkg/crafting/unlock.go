@@ -0,0 +1,134 @@
+package crafting
+
+import (
+	"sort"
+
+	"tesselbox/pkg/items"
+)
+
+// UnlockTrigger lists what teaches a recipe; any one of them is enough.
+// Recipes without a trigger are known from the start.
+type UnlockTrigger struct {
+	Items  []items.ItemType `yaml:"items"`  // Holding any of these, such as after picking one up
+	Quests []string         `yaml:"quests"` // Completing any of these quests
+	Books  []string         `yaml:"books"`  // Finding a book with any of these IDs
+}
+
+// unlockIndex lists the IDs of locked recipes, in order, by the item, book or
+// quest that unlocks them
+type unlockIndex struct {
+	items  map[items.ItemType][]string
+	books  map[string][]string
+	quests map[string][]string
+}
+
+// indexTriggers builds the unlock index from the loaded recipes
+func (cs *CraftingSystem) indexTriggers() {
+	cs.triggers = unlockIndex{
+		items:  make(map[items.ItemType][]string),
+		books:  make(map[string][]string),
+		quests: make(map[string][]string),
+	}
+	for _, id := range cs.sortedIDs() {
+		trigger := cs.recipes[id].Unlock
+		if trigger == nil {
+			continue
+		}
+		for _, itemType := range trigger.Items {
+			cs.triggers.items[itemType] = append(cs.triggers.items[itemType], id)
+		}
+		for _, book := range trigger.Books {
+			cs.triggers.books[book] = append(cs.triggers.books[book], id)
+		}
+		for _, quest := range trigger.Quests {
+			cs.triggers.quests[quest] = append(cs.triggers.quests[quest], id)
+		}
+	}
+}
+
+// IsUnlocked reports whether the player knows a recipe
+func (cs *CraftingSystem) IsUnlocked(recipeID string) bool {
+	recipe, exists := cs.recipes[recipeID]
+	if !exists {
+		return false
+	}
+	return recipe.Unlock == nil || cs.unlocked[recipeID]
+}
+
+// Unlock teaches the player a recipe, reporting whether it was new to them
+func (cs *CraftingSystem) Unlock(recipeID string) bool {
+	if _, exists := cs.recipes[recipeID]; !exists || cs.IsUnlocked(recipeID) {
+		return false
+	}
+	cs.unlocked[recipeID] = true
+	if cs.OnRecipeUnlocked != nil {
+		cs.OnRecipeUnlocked(cs.recipes[recipeID])
+	}
+	return true
+}
+
+// UnlockedRecipes returns the IDs of the recipes the player was taught, for
+// saving. Recipes known from the start are left out.
+func (cs *CraftingSystem) UnlockedRecipes() []string {
+	ids := make([]string, 0, len(cs.unlocked))
+	for id := range cs.unlocked {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// SetUnlockedRecipes replaces what the player was taught, such as when
+// loading a save. IDs of recipes that no longer exist are kept so they are
+// not lost if the recipe comes back.
+func (cs *CraftingSystem) SetUnlockedRecipes(ids []string) {
+	cs.unlocked = make(map[string]bool, len(ids))
+	for _, id := range ids {
+		cs.unlocked[id] = true
+	}
+}
+
+// UnlockFromInventory teaches the recipes triggered by the items and books
+// in an inventory and returns the ones that were new
+func (cs *CraftingSystem) UnlockFromInventory(inventory *items.Inventory) []*Recipe {
+	var ids []string
+	for _, slot := range inventory.Slots {
+		if slot.Type == items.NONE {
+			continue
+		}
+		ids = append(ids, cs.triggers.items[slot.Type]...)
+		if bookID := slot.BookID(); bookID != "" {
+			ids = append(ids, cs.triggers.books[bookID]...)
+		}
+	}
+	sort.Strings(ids)
+	return cs.unlockAll(ids)
+}
+
+// UnlockForQuest teaches the recipes triggered by completing a quest and
+// returns the ones that were new
+func (cs *CraftingSystem) UnlockForQuest(questID string) []*Recipe {
+	return cs.unlockAll(cs.triggers.quests[questID])
+}
+
+// unlockAll teaches the recipes with the given IDs that the player does not
+// know yet, in the order given
+func (cs *CraftingSystem) unlockAll(ids []string) []*Recipe {
+	var learned []*Recipe
+	for _, id := range ids {
+		if cs.Unlock(id) {
+			learned = append(learned, cs.recipes[id])
+		}
+	}
+	return learned
+}
+
+// sortedIDs returns the IDs of all recipes in order
+func (cs *CraftingSystem) sortedIDs() []string {
+	ids := make([]string, 0, len(cs.recipes))
+	for id := range cs.recipes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
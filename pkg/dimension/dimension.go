@@ -8,7 +8,7 @@ import (
 	"time"
 
 	"tesselbox/pkg/blocks"
-	"tesselbox/pkg/enemies"
+	"tesselbox/pkg/mobs"
 	"tesselbox/pkg/world"
 )
 
@@ -22,6 +22,9 @@ const (
 	RandomlandMaxY = 450.0 // Bottom bedrock starts here
 	ReturnPortalX  = 250.0 // Center X
 	ReturnPortalY  = 250.0 // Center Y (in the cavern space)
+
+	// RandomlandAmbientLight is the constant light level of Randomland
+	RandomlandAmbientLight = 0.25
 )
 
 // DimensionType represents different dimension types
@@ -48,20 +51,28 @@ type RandomlandDimension struct {
 	Generated     bool
 	ReturnPortalX float64
 	ReturnPortalY float64
-	ZombieSpawner *enemies.ZombieSpawner
+	ZombieSpawner *mobs.Spawner
 	LastVisitTime time.Time
 }
 
+// RandomlandWorldName returns the name of the world storing a world's Randomland
+func RandomlandWorldName(worldName string) string {
+	return worldName + "__randomland_dim"
+}
+
 // NewRandomlandDimension creates a new Randomland dimension
 func NewRandomlandDimension(worldName string) *RandomlandDimension {
 	// Use a unique world name to avoid conflicts with player worlds
-	dimWorldName := worldName + "__randomland_dim"
-	spawner := enemies.NewZombieSpawner(nil) // No day/night cycle in randomland
+	dimWorldName := RandomlandWorldName(worldName)
+	spawner := mobs.NewSpawner(nil) // No day/night cycle in randomland
 	// Tune spawn rate for Randomland (2x faster spawning, higher cap)
 	spawner.SpawnCooldown = 1500 * time.Millisecond // 1.5s instead of 3s
-	spawner.MaxZombies = 25                         // Higher than overworld default of 15
+	spawner.MaxMobs = 25                            // Higher than overworld default of 15
+	dimWorld := world.NewWorld(dimWorldName)
+	spawner.Climbable = dimWorld.BoxClimbable
+	spawner.Navigator = dimWorld.Paths
 	return &RandomlandDimension{
-		World:         world.NewWorld(dimWorldName),
+		World:         dimWorld,
 		Type:          Randomland,
 		Name:          "Randomland",
 		Generated:     false,
@@ -322,9 +333,10 @@ func (r *RandomlandDimension) spawnZombies() {
 
 			if distance > 10000 { // At least 100 pixels away
 				// Create zombie
-				zombie := enemies.NewZombie(spawned, enemies.ZombieNormal, x, y)
-				r.ZombieSpawner.Zombies = append(r.ZombieSpawner.Zombies, zombie)
-				spawned++
+				if zombie, ok := r.ZombieSpawner.NewMob("zombie", x, y); ok {
+					r.ZombieSpawner.Mobs = append(r.ZombieSpawner.Mobs, zombie)
+					spawned++
+				}
 			}
 		}
 	}
@@ -338,8 +350,8 @@ func (r *RandomlandDimension) GetSpawnPosition() (float64, float64) {
 	return r.ReturnPortalX, r.ReturnPortalY - 50 // Spawn slightly above portal
 }
 
-// Update updates the dimension (zombies, etc.)
-func (r *RandomlandDimension) Update(playerX, playerY float64, deltaTime float64) {
+// Update updates the dimension (zombies, etc.), which hunt the target
+func (r *RandomlandDimension) Update(target mobs.Target, deltaTime float64) {
 	// Create collision function for zombies
 	collisionFunc := func(minX, minY, maxX, maxY float64) bool {
 		nearbyHexagons := r.World.GetNearbyHexagons((minX+maxX)/2, (minY+maxY)/2, 100)
@@ -362,8 +374,10 @@ func (r *RandomlandDimension) Update(playerX, playerY float64, deltaTime float64
 		return r.World.FindSpawnPosition(x, y)
 	}
 
-	// Update zombies (ambient light = 0.5 for randomland - always dim)
-	r.ZombieSpawner.Update(deltaTime, nil, 0.5, collisionFunc, spawnFunc)
+	// Update zombies; Randomland is always dim, dark enough for zombies to
+	// spawn but not to burn
+	r.ZombieSpawner.Update(deltaTime, target, RandomlandAmbientLight, collisionFunc, spawnFunc)
+	r.World.Paths.Update(deltaTime)
 }
 
 // IsNearReturnPortal checks if a position is near the return portal
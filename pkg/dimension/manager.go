@@ -10,7 +10,7 @@ import (
 	"time"
 
 	"tesselbox/pkg/blocks"
-	"tesselbox/pkg/enemies"
+	"tesselbox/pkg/mobs"
 	"tesselbox/pkg/player"
 	"tesselbox/pkg/world"
 )
@@ -163,8 +163,7 @@ func (m *Manager) CheckReturnPortalProximity(player *player.Player) bool {
 // Update updates the current dimension
 func (m *Manager) Update(player *player.Player, deltaTime float64) {
 	if m.CurrentDimension == Randomland && m.RandomlandDim != nil {
-		px, py := player.GetCenter()
-		m.RandomlandDim.Update(px, py, deltaTime)
+		m.RandomlandDim.Update(player, deltaTime)
 
 		// Safety check: ensure return portal exists (player could have destroyed it)
 		// Check every 5 seconds to avoid performance impact
@@ -175,19 +174,14 @@ func (m *Manager) Update(player *player.Player, deltaTime float64) {
 }
 
 // ZombieData represents a zombie for serialization
-type ZombieData struct {
-	ID        string  `json:"id"`
-	X         float64 `json:"x"`
-	Y         float64 `json:"y"`
-	Health    float64 `json:"health"`
-	MaxHealth float64 `json:"max_health"`
-	Type      int     `json:"type"`
-	IsAlive   bool    `json:"is_alive"`
-	State     int     `json:"state"`
-}
+type ZombieData = mobs.Data
+
+// StateVersion is the current dimension state file format version
+const StateVersion = "2.0"
 
 // DimensionState represents save data for dimensions
 type DimensionState struct {
+	Version             string       `json:"version"`
 	RandomlandGenerated bool         `json:"randomland_generated"`
 	ReturnPortalX       float64      `json:"return_portal_x"`
 	ReturnPortalY       float64      `json:"return_portal_y"`
@@ -211,6 +205,7 @@ func (m *Manager) Save() error {
 	}
 
 	state := DimensionState{
+		Version:             StateVersion,
 		RandomlandGenerated: m.RandomlandDim != nil && m.RandomlandDim.IsGenerated(),
 		LastOverworldX:      m.PlayerLastOverworldX,
 		LastOverworldY:      m.PlayerLastOverworldY,
@@ -222,19 +217,10 @@ func (m *Manager) Save() error {
 
 		// Save Randomland zombies
 		if m.RandomlandDim.ZombieSpawner != nil {
-			state.RandomlandZombies = make([]ZombieData, 0, len(m.RandomlandDim.ZombieSpawner.Zombies))
-			for _, zombie := range m.RandomlandDim.ZombieSpawner.Zombies {
-				if zombie.IsAlive {
-					state.RandomlandZombies = append(state.RandomlandZombies, ZombieData{
-						ID:        zombie.ID,
-						X:         zombie.X,
-						Y:         zombie.Y,
-						Health:    zombie.Health,
-						MaxHealth: zombie.MaxHealth,
-						Type:      int(zombie.Type),
-						IsAlive:   zombie.IsAlive,
-						State:     int(zombie.State),
-					})
+			state.RandomlandZombies = make([]ZombieData, 0, len(m.RandomlandDim.ZombieSpawner.Mobs))
+			for _, zombie := range m.RandomlandDim.ZombieSpawner.Mobs {
+				if zombie.IsAlive() {
+					state.RandomlandZombies = append(state.RandomlandZombies, zombie.Data())
 				}
 			}
 		}
@@ -270,6 +256,10 @@ func (m *Manager) Load() error {
 	if err := json.Unmarshal(data, &state); err != nil {
 		return fmt.Errorf("failed to unmarshal dimension state: %w", err)
 	}
+	// Files without a version predate versioning and share the current layout
+	if state.Version != "" && state.Version != StateVersion {
+		return fmt.Errorf("unsupported dimension state version %q", state.Version)
+	}
 
 	// Restore state
 	m.PlayerLastOverworldX = state.LastOverworldX
@@ -294,24 +284,15 @@ func (m *Manager) Load() error {
 
 		// Restore Randomland zombies
 		if len(state.RandomlandZombies) > 0 && m.RandomlandDim.ZombieSpawner != nil {
-			m.RandomlandDim.ZombieSpawner.Zombies = make([]*enemies.Zombie, 0, len(state.RandomlandZombies))
+			m.RandomlandDim.ZombieSpawner.Mobs = make([]*mobs.Mob, 0, len(state.RandomlandZombies))
 			for _, zombieData := range state.RandomlandZombies {
-				zombie := enemies.NewZombie(
-					len(m.RandomlandDim.ZombieSpawner.Zombies),
-					enemies.ZombieType(zombieData.Type),
-					zombieData.X,
-					zombieData.Y,
-				)
-				zombie.ID = zombieData.ID
-				zombie.Health = zombieData.Health
-				zombie.MaxHealth = zombieData.MaxHealth
-				zombie.IsAlive = zombieData.IsAlive
-				zombie.State = enemies.ZombieState(zombieData.State)
-				m.RandomlandDim.ZombieSpawner.Zombies = append(m.RandomlandDim.ZombieSpawner.Zombies, zombie)
+				if zombie, ok := mobs.FromData(zombieData); ok {
+					m.RandomlandDim.ZombieSpawner.Mobs = append(m.RandomlandDim.ZombieSpawner.Mobs, zombie)
+				}
 			}
 			// Update NextID to avoid ID conflicts
-			m.RandomlandDim.ZombieSpawner.NextID = len(m.RandomlandDim.ZombieSpawner.Zombies) + 1
-			fmt.Printf("Restored %d Randomland zombies\n", len(m.RandomlandDim.ZombieSpawner.Zombies))
+			m.RandomlandDim.ZombieSpawner.NextID = len(m.RandomlandDim.ZombieSpawner.Mobs) + 1
+			fmt.Printf("Restored %d Randomland zombies\n", len(m.RandomlandDim.ZombieSpawner.Mobs))
 		}
 	}
 
@@ -2,6 +2,7 @@ package dungeons
 
 import (
 	"fmt"
+	"math/rand"
 	"time"
 
 	"tesselbox/pkg/items"
@@ -41,6 +42,12 @@ type DungeonRoom struct {
 	Type     string `json:"type"` // "combat", "puzzle", "treasure", "boss"
 	Cleared  bool   `json:"cleared"`
 	MobCount int    `json:"mob_count"`
+
+	// Location of a room generated in the world
+	X           float64      `json:"x,omitempty"`
+	Y           float64      `json:"y,omitempty"`
+	StructureID string       `json:"structure_id,omitempty"`
+	SpawnPoints [][2]float64 `json:"spawn_points,omitempty"`
 }
 
 // DungeonInstance represents an active dungeon run
@@ -239,6 +246,7 @@ type DungeonReward struct {
 type DungeonManager struct {
 	definitions map[string]DungeonDefinition
 	instances   map[string]*DungeonInstance
+	layouts     map[string][]DungeonRoom // Rooms generated in the world, by dungeon ID
 
 	instanceCounter int
 }
@@ -248,6 +256,7 @@ func NewDungeonManager() *DungeonManager {
 	return &DungeonManager{
 		definitions: make(map[string]DungeonDefinition),
 		instances:   make(map[string]*DungeonInstance),
+		layouts:     make(map[string][]DungeonRoom),
 	}
 }
 
@@ -262,6 +271,85 @@ func (dm *DungeonManager) GetDungeon(dungeonID string) (DungeonDefinition, bool)
 	return def, exists
 }
 
+// AddGeneratedRoom records a room the world generator built for a dungeon.
+// Dungeons found in the world are registered with a default definition the
+// first time one of their rooms is added; rooms already added are ignored.
+func (dm *DungeonManager) AddGeneratedRoom(dungeonID, worldID string, room DungeonRoom) {
+	if _, exists := dm.definitions[dungeonID]; !exists {
+		dm.RegisterDungeon(DungeonDefinition{
+			ID:          dungeonID,
+			Name:        "Forgotten Crypt",
+			Description: "Rooms of old stone buried beneath " + worldID,
+			MinLevel:    1,
+			MaxPlayers:  4,
+			Rewards: map[DungeonTier]DungeonReward{
+				TierNormal: {
+					Money:      100,
+					XP:         250,
+					Items:      []items.Item{{Type: items.GOLD_INGOT, Quantity: 3, Durability: -1}},
+					BonusItems: []items.Item{{Type: items.DIAMOND, Quantity: 1, Durability: -1}},
+				},
+			},
+		})
+	}
+
+	for _, existing := range dm.layouts[dungeonID] {
+		if existing.StructureID == room.StructureID {
+			return
+		}
+	}
+	if room.ID == "" {
+		room.ID = fmt.Sprintf("room_%d", len(dm.layouts[dungeonID]))
+	}
+	dm.layouts[dungeonID] = append(dm.layouts[dungeonID], room)
+}
+
+// GetLayout returns the generated rooms of a dungeon in the order they were added
+func (dm *DungeonManager) GetLayout(dungeonID string) []DungeonRoom {
+	return dm.layouts[dungeonID]
+}
+
+// lootBooks are the books dungeon chests may hold, by the ID of what they
+// teach; crafting recipes list these IDs to be unlocked by them
+var lootBooks = []struct{ ID, Title string }{
+	{"charcoal_burning", "Notes on Charcoal Burning"},
+	{"masonry", "A Mason's Handbook"},
+}
+
+// ChestLoot returns the loot for a chest in a generated room of a type. The
+// same seed always gives the same loot.
+func ChestLoot(roomType string, seed int64) []items.Item {
+	rng := rand.New(rand.NewSource(seed))
+	loot := []items.Item{
+		{Type: items.TORCH, Quantity: 2 + rng.Intn(6), Durability: -1},
+		{Type: items.COAL, Quantity: 1 + rng.Intn(4), Durability: -1},
+	}
+
+	switch roomType {
+	case "boss":
+		loot = append(loot,
+			items.Item{Type: items.GOLD_INGOT, Quantity: 2 + rng.Intn(4), Durability: -1},
+			items.Item{Type: items.DIAMOND, Quantity: 1 + rng.Intn(2), Durability: -1})
+		if rng.Intn(3) == 0 {
+			loot = append(loot, items.Item{Type: items.DIAMOND_SWORD, Quantity: 1, Durability: -1})
+		}
+	case "treasure":
+		loot = append(loot, items.Item{Type: items.IRON_INGOT, Quantity: 2 + rng.Intn(5), Durability: -1})
+		if rng.Intn(2) == 0 {
+			loot = append(loot, items.Item{Type: items.GOLD_INGOT, Quantity: 1 + rng.Intn(3), Durability: -1})
+		}
+	case "puzzle":
+		loot = append(loot, items.Item{Type: items.STRING, Quantity: 1 + rng.Intn(3), Durability: -1})
+		if rng.Intn(2) == 0 {
+			book := lootBooks[rng.Intn(len(lootBooks))]
+			loot = append(loot, items.NewBook(book.ID, book.Title))
+		}
+	default:
+		loot = append(loot, items.Item{Type: items.IRON_INGOT, Quantity: 1 + rng.Intn(2), Durability: -1})
+	}
+	return loot
+}
+
 // StartDungeon starts a dungeon run
 func (dm *DungeonManager) StartDungeon(dungeonID, worldID, leaderID string, tier DungeonTier) (*DungeonInstance, error) {
 	def, exists := dm.GetDungeon(dungeonID)
@@ -273,6 +361,15 @@ func (dm *DungeonManager) StartDungeon(dungeonID, worldID, leaderID string, tier
 	instanceID := fmt.Sprintf("dungeon_%d_%d", dm.instanceCounter, time.Now().Unix())
 
 	instance := NewDungeonInstance(instanceID, dungeonID, worldID, leaderID, tier, 1)
+	if layout := dm.layouts[dungeonID]; len(layout) > 0 {
+		// Run through the rooms actually built in the world
+		instance.Rooms = make([]DungeonRoom, len(layout))
+		for i, room := range layout {
+			room.Cleared = false
+			room.MobCount = 2 + int(tier) + (i / 2)
+			instance.Rooms[i] = room
+		}
+	}
 	instance.TimeLimit = time.Duration(def.MaxPlayers) * 10 * time.Minute // Dynamic time limit
 
 	dm.instances[instanceID] = instance
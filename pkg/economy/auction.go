@@ -63,6 +63,7 @@ type Auction struct {
 // NewAuction creates a new auction
 func NewAuction(id, sellerID, worldID string, item items.Item, quantity int, startPrice, buyNowPrice, reservePrice float64, duration time.Duration) *Auction {
 	now := time.Now()
+	item.Meta = item.Meta.Clone() // The listing keeps the item as it was listed
 	return &Auction{
 		ID:           id,
 		SellerID:     sellerID,
@@ -77,6 +77,7 @@ func (t *TradeSession) AddItem(playerID string, item items.Item) error {
 		return fmt.Errorf("trade is not pending")
 	}
 	
+	item.Meta = item.Meta.Clone()
 	if playerID == t.InitiatorID {
 		t.InitiatorOffer.Items = append(t.InitiatorOffer.Items, item)
 	} else if playerID == t.PartnerID {
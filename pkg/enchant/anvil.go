@@ -0,0 +1,307 @@
+package enchant
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"tesselbox/pkg/items"
+)
+
+const (
+	// CombineXPPerLevel is the XP combining two items costs per enchantment
+	// level on the result
+	CombineXPPerLevel = 2
+	// RepairXPPerMaterial is the XP repairing costs per material used
+	RepairXPPerMaterial = 1
+	// RepairShare is the part of an item's durability each material restores
+	RepairShare = 0.25
+	// combineBonusShare is the extra part of an item's durability combining
+	// two damaged items restores
+	combineBonusShare = 0.1
+)
+
+// RepairMaterials maps item ID prefixes to the material that repairs items
+// with them, so "iron_" makes iron ingots repair iron pickaxes and swords
+var RepairMaterials = map[string]string{
+	"wooden_":  "planks",
+	"stone_":   "cobblestone",
+	"iron_":    "iron_ingot",
+	"gold_":    "gold_ingot",
+	"diamond_": "diamond",
+}
+
+// Cost is what an anvil operation takes from the player
+type Cost struct {
+	XP        int
+	Materials []items.Item // Plain items, without metadata
+}
+
+// Affordable reports whether an inventory and an amount of XP cover the cost.
+// Only items without metadata count as materials.
+func (c Cost) Affordable(inv *items.Inventory, xp int) bool {
+	if xp < c.XP {
+		return false
+	}
+	for _, m := range c.Materials {
+		if countPlain(inv, m.Type) < m.Quantity {
+			return false
+		}
+	}
+	return true
+}
+
+// Take removes the cost's materials from an inventory, leaving the XP to the
+// caller. It takes nothing unless the inventory has all of them.
+func (c Cost) Take(inv *items.Inventory) bool {
+	for _, m := range c.Materials {
+		if countPlain(inv, m.Type) < m.Quantity {
+			return false
+		}
+	}
+	for _, m := range c.Materials {
+		remaining := m.Quantity
+		for i := range inv.Slots {
+			slot := &inv.Slots[i]
+			if remaining == 0 {
+				break
+			}
+			if slot.Type != m.Type || !slot.Meta.IsEmpty() {
+				continue
+			}
+			take := min(slot.Quantity, remaining)
+			slot.Quantity -= take
+			remaining -= take
+			if slot.Quantity <= 0 {
+				*slot = items.Item{Type: items.NONE, Quantity: 0, Durability: -1}
+			}
+		}
+	}
+	return true
+}
+
+// String describes the cost, such as "6 XP, 2 Iron Ingot"
+func (c Cost) String() string {
+	parts := make([]string, 0, len(c.Materials)+1)
+	if c.XP > 0 {
+		parts = append(parts, fmt.Sprintf("%d XP", c.XP))
+	}
+	for _, m := range c.Materials {
+		parts = append(parts, fmt.Sprintf("%d %s", m.Quantity, items.ItemNameByID(m.Type)))
+	}
+	if len(parts) == 0 {
+		return "Free"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// countPlain counts the items of a type without metadata in an inventory
+func countPlain(inv *items.Inventory, itemType items.ItemType) int {
+	total := 0
+	for _, slot := range inv.Slots {
+		if slot.Type == itemType && slot.Meta.IsEmpty() {
+			total += slot.Quantity
+		}
+	}
+	return total
+}
+
+// Option is an enchantment an item can take next at the anvil
+type Option struct {
+	Enchantment *Enchantment
+	Level       int // The level it would have afterwards
+	Cost        Cost
+}
+
+// Options lists the enchantments an item of a kind with the given metadata
+// can take or raise by a level, in order
+func Options(meta *items.Metadata, target string) []Option {
+	var options []Option
+	for _, id := range IDs() {
+		level := 1
+		if meta != nil {
+			level = meta.Enchantments[id] + 1
+		}
+		if _, cost, err := Enchant(meta, target, id, level); err == nil {
+			e, _ := Get(id)
+			options = append(options, Option{Enchantment: e, Level: level, Cost: cost})
+		}
+	}
+	return options
+}
+
+// Enchant returns a copy of an item's metadata with an enchantment raised to
+// a level, and what that costs. The target is the kind of item it belongs to.
+func Enchant(meta *items.Metadata, target, id string, level int) (*items.Metadata, Cost, error) {
+	e, ok := Get(id)
+	if !ok {
+		return nil, Cost{}, fmt.Errorf("unknown enchantment %q", id)
+	}
+	if !e.AppliesTo(target) {
+		return nil, Cost{}, fmt.Errorf("%s cannot go on this item", e.Name)
+	}
+	if level < 1 || level > e.MaxLevel {
+		return nil, Cost{}, fmt.Errorf("%s only goes up to level %d", e.Name, e.MaxLevel)
+	}
+	if meta != nil {
+		if meta.Enchantments[id] >= level {
+			return nil, Cost{}, fmt.Errorf("item already has %s", e.LevelName(meta.Enchantments[id]))
+		}
+		for other := range meta.Enchantments {
+			if e.ConflictsWith(other) {
+				return nil, Cost{}, fmt.Errorf("%s conflicts with %s", e.Name, other)
+			}
+		}
+	}
+
+	cost := Cost{XP: e.XPCost * level}
+	for _, m := range e.Materials {
+		itemType, _ := items.ItemTypeByID(m.Item)
+		cost.Materials = append(cost.Materials, items.Item{Type: itemType, Quantity: m.Quantity * level, Durability: -1})
+	}
+
+	result := meta.Clone()
+	if result == nil {
+		result = &items.Metadata{}
+	}
+	if result.Enchantments == nil {
+		result.Enchantments = make(map[string]int)
+	}
+	result.Enchantments[id] = level
+	return result, cost, nil
+}
+
+// EnchantItem returns an item with an enchantment raised to a level, and what
+// that costs
+func EnchantItem(item items.Item, id string, level int) (items.Item, Cost, error) {
+	meta, cost, err := Enchant(item.Meta, TargetOf(item.Type), id, level)
+	if err != nil {
+		return item, Cost{}, err
+	}
+	item.Meta = meta
+	return item, cost, nil
+}
+
+// Combine merges a second item of the same type into the first, as the anvil
+// does. Matching enchantment levels go up by one, others keep the higher
+// level, and the durability of both is added together with a bonus.
+func Combine(target, sacrifice items.Item) (items.Item, Cost, error) {
+	if target.Type == items.NONE || target.Type != sacrifice.Type {
+		return target, Cost{}, fmt.Errorf("only items of the same type can be combined")
+	}
+	kind := TargetOf(target.Type)
+	if kind == "" {
+		return target, Cost{}, fmt.Errorf("%s cannot be combined", items.ItemNameByID(target.Type))
+	}
+
+	result := target
+	meta := target.Meta.Clone()
+	changed := false
+	if sacrifice.Meta != nil {
+		for _, id := range sacrifice.Meta.EnchantmentIDs() {
+			e, ok := Get(id)
+			if !ok || !e.AppliesTo(kind) {
+				continue
+			}
+			level := sacrifice.Meta.Enchantments[id]
+			current := 0
+			if meta != nil {
+				current = meta.Enchantments[id]
+			}
+			if level == current {
+				level = current + 1
+			}
+			if level > e.MaxLevel {
+				level = e.MaxLevel
+			}
+			if level <= current || conflicts(e, meta) {
+				continue
+			}
+			if meta == nil {
+				meta = &items.Metadata{}
+			}
+			if meta.Enchantments == nil {
+				meta.Enchantments = make(map[string]int)
+			}
+			meta.Enchantments[id] = level
+			changed = true
+		}
+	}
+	result.Meta = meta
+
+	if maxDurability := maxDurability(target.Type); maxDurability > 0 && target.Durability < maxDurability {
+		bonus := int(math.Ceil(float64(maxDurability) * combineBonusShare))
+		result.Durability = min(maxDurability, target.Durability+sacrifice.Durability+bonus)
+		changed = true
+	}
+	if !changed {
+		return target, Cost{}, fmt.Errorf("combining would not improve the item")
+	}
+
+	levels := 0
+	if meta != nil {
+		for _, level := range meta.Enchantments {
+			levels += level
+		}
+	}
+	return result, Cost{XP: max(1, levels*CombineXPPerLevel)}, nil
+}
+
+// conflicts reports whether an enchantment conflicts with any in metadata
+func conflicts(e *Enchantment, meta *items.Metadata) bool {
+	if meta == nil {
+		return false
+	}
+	for other := range meta.Enchantments {
+		if e.ConflictsWith(other) {
+			return true
+		}
+	}
+	return false
+}
+
+// RepairMaterial returns the material that repairs an item type, if any
+func RepairMaterial(itemType items.ItemType) (items.ItemType, bool) {
+	id := items.ItemID(itemType)
+	for prefix, material := range RepairMaterials {
+		if strings.HasPrefix(id, prefix) {
+			return items.ItemTypeByID(material)
+		}
+	}
+	return items.NONE, false
+}
+
+// Repair restores an item's durability with up to the given number of its
+// repair material, using as few as it needs. The cost holds the materials used.
+func Repair(target items.Item, available int) (items.Item, Cost, error) {
+	material, ok := RepairMaterial(target.Type)
+	maxDurability := maxDurability(target.Type)
+	switch {
+	case !ok || maxDurability <= 0:
+		return target, Cost{}, fmt.Errorf("%s cannot be repaired", items.ItemNameByID(target.Type))
+	case target.Durability >= maxDurability:
+		return target, Cost{}, fmt.Errorf("%s is not damaged", items.ItemNameByID(target.Type))
+	case available <= 0:
+		return target, Cost{}, fmt.Errorf("repairing needs %s", items.ItemNameByID(material))
+	}
+
+	perMaterial := int(math.Ceil(float64(maxDurability) * RepairShare))
+	needed := int(math.Ceil(float64(maxDurability-target.Durability) / float64(perMaterial)))
+	used := min(needed, available)
+
+	result := target
+	result.Durability = min(maxDurability, target.Durability+used*perMaterial)
+	return result, Cost{
+		XP:        used * RepairXPPerMaterial,
+		Materials: []items.Item{{Type: material, Quantity: used, Durability: -1}},
+	}, nil
+}
+
+// maxDurability returns the durability a new item of a type has, or 0 if it
+// has none
+func maxDurability(itemType items.ItemType) int {
+	if props := items.GetItemProperties(itemType); props != nil && props.Durability > 0 {
+		return props.Durability
+	}
+	return 0
+}
@@ -0,0 +1,322 @@
+// Package enchant implements enchantments: data-driven item modifiers that are
+// applied at the anvil and kept in an item's metadata
+package enchant
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+
+	"tesselbox/assets"
+	"tesselbox/pkg/items"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Kinds of item an enchantment can go on
+const (
+	TargetTool   = "tool"   // Pickaxes and other mining tools
+	TargetWeapon = "weapon" // Swords and bows
+	TargetWand   = "wand"   // Magic weapons
+	TargetArmor  = "armor"  // Armor items and equipped armor
+)
+
+// Stats that enchantment effects add to, per level
+const (
+	StatMiningSpeed = "mining_speed" // Fraction added to mining speed
+	StatDamage      = "damage"       // Fraction added to weapon damage
+	StatCritChance  = "crit_chance"  // Added to the chance of a critical hit
+	StatDefense     = "defense"      // Armor defense points
+)
+
+// Material is an item an enchantment takes at the anvil, per level
+type Material struct {
+	Item     string `yaml:"item"`
+	Quantity int    `yaml:"quantity"`
+}
+
+// Enchantment is the data definition of an enchantment, as found in
+// enchantments.yaml
+type Enchantment struct {
+	ID          string             `yaml:"-"`
+	Name        string             `yaml:"name"`
+	Description string             `yaml:"description"`
+	MaxLevel    int                `yaml:"max_level"`
+	Targets     []string           `yaml:"targets"`             // Kinds of item it can go on
+	Conflicts   []string           `yaml:"conflicts,omitempty"` // Enchantments it cannot share an item with
+	XPCost      int                `yaml:"xp_cost"`             // XP per level
+	Materials   []Material         `yaml:"materials,omitempty"` // Items per level
+	Effects     map[string]float64 `yaml:"effects"`             // Stat to bonus per level
+}
+
+// normalize fills in defaults for fields a definition left out
+func (e *Enchantment) normalize() {
+	if e.Name == "" {
+		e.Name = e.ID
+	}
+	if e.MaxLevel <= 0 {
+		e.MaxLevel = 1
+	}
+}
+
+// validate reports what is wrong with a definition, if anything
+func (e *Enchantment) validate() error {
+	if e.ID == "" {
+		return fmt.Errorf("enchantment has no ID")
+	}
+	if len(e.Targets) == 0 {
+		return fmt.Errorf("enchantment %s has no targets", e.ID)
+	}
+	for _, target := range e.Targets {
+		switch target {
+		case TargetTool, TargetWeapon, TargetWand, TargetArmor:
+		default:
+			return fmt.Errorf("enchantment %s has unknown target %q", e.ID, target)
+		}
+	}
+	if e.XPCost < 0 {
+		return fmt.Errorf("enchantment %s has a negative XP cost", e.ID)
+	}
+	for _, m := range e.Materials {
+		if _, ok := items.ItemTypeByID(m.Item); !ok || m.Quantity <= 0 {
+			return fmt.Errorf("enchantment %s has invalid material %q", e.ID, m.Item)
+		}
+	}
+	return nil
+}
+
+// AppliesTo reports whether the enchantment can go on a kind of item
+func (e *Enchantment) AppliesTo(target string) bool {
+	for _, t := range e.Targets {
+		if t == target {
+			return true
+		}
+	}
+	return false
+}
+
+// ConflictsWith reports whether two enchantments cannot share an item
+func (e *Enchantment) ConflictsWith(id string) bool {
+	if id == e.ID {
+		return false
+	}
+	for _, c := range e.Conflicts {
+		if c == id {
+			return true
+		}
+	}
+	if other, ok := Get(id); ok {
+		for _, c := range other.Conflicts {
+			if c == e.ID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// LevelName returns the enchantment's name with a level, such as "Sharpness III"
+func (e *Enchantment) LevelName(level int) string {
+	if e.MaxLevel == 1 {
+		return e.Name
+	}
+	return e.Name + " " + roman(level)
+}
+
+// roman writes small positive numbers as Roman numerals
+func roman(n int) string {
+	if n <= 0 || n >= 40 {
+		return fmt.Sprint(n)
+	}
+	var b strings.Builder
+	for _, step := range []struct {
+		value  int
+		symbol string
+	}{{10, "X"}, {9, "IX"}, {5, "V"}, {4, "IV"}, {1, "I"}} {
+		for n >= step.value {
+			b.WriteString(step.symbol)
+			n -= step.value
+		}
+	}
+	return b.String()
+}
+
+// TargetOf returns the kind of item an item type is for enchanting, or "" if
+// it cannot be enchanted
+func TargetOf(itemType items.ItemType) string {
+	props := items.GetItemProperties(itemType)
+	switch {
+	case props == nil:
+		return ""
+	case props.IsArmor:
+		return TargetArmor
+	case props.IsTool:
+		return TargetTool
+	case props.IsWeapon && props.WeaponType == "magic":
+		return TargetWand
+	case props.IsWeapon:
+		return TargetWeapon
+	}
+	return ""
+}
+
+// Bonus returns what the enchantments in an item's metadata add to a stat
+func Bonus(meta *items.Metadata, stat string) float64 {
+	if meta == nil {
+		return 0
+	}
+	total := 0.0
+	for id, level := range meta.Enchantments {
+		if e, ok := Get(id); ok {
+			total += e.Effects[stat] * float64(level)
+		}
+	}
+	return total
+}
+
+// Describe returns a line per enchantment in an item's metadata, in order,
+// for tooltips
+func Describe(meta *items.Metadata) []string {
+	var lines []string
+	for _, id := range meta.EnchantmentIDs() {
+		level := meta.Enchantments[id]
+		if e, ok := Get(id); ok {
+			lines = append(lines, e.LevelName(level))
+		} else {
+			lines = append(lines, fmt.Sprintf("%s %d", id, level))
+		}
+	}
+	return lines
+}
+
+// DefaultEnchantments are the built-in enchantments, overridden by
+// enchantments.yaml
+var DefaultEnchantments = map[string]*Enchantment{
+	"efficiency": {
+		Name: "Efficiency", Description: "Mines blocks faster",
+		MaxLevel: 5, Targets: []string{TargetTool}, XPCost: 3,
+		Effects: map[string]float64{StatMiningSpeed: 0.25},
+	},
+	"sharpness": {
+		Name: "Sharpness", Description: "Deals more damage",
+		MaxLevel: 5, Targets: []string{TargetWeapon}, XPCost: 3,
+		Effects: map[string]float64{StatDamage: 0.15},
+	},
+	"keen": {
+		Name: "Keen", Description: "Lands critical hits more often",
+		MaxLevel: 3, Targets: []string{TargetWeapon}, XPCost: 4,
+		Materials: []Material{{Item: "iron_ingot", Quantity: 1}},
+		Effects:   map[string]float64{StatCritChance: 0.05},
+	},
+	"arcane": {
+		Name: "Arcane", Description: "Spells hit harder",
+		MaxLevel: 5, Targets: []string{TargetWand}, XPCost: 2,
+		Materials: []Material{{Item: "gel", Quantity: 2}},
+		Effects:   map[string]float64{StatDamage: 0.2},
+	},
+	"protection": {
+		Name: "Protection", Description: "Adds armor defense",
+		MaxLevel: 4, Targets: []string{TargetArmor}, XPCost: 3,
+		Effects: map[string]float64{StatDefense: 2},
+	},
+}
+
+var (
+	enchantments      = make(map[string]*Enchantment)
+	enchantmentsMutex sync.RWMutex
+)
+
+// Register adds an enchantment or replaces the definition of an existing one,
+// returning the definition it replaced, if any. Items keep their levels of an
+// enchantment whose definition changes.
+func Register(id string, e *Enchantment) (*Enchantment, error) {
+	e.ID = id
+	e.normalize()
+	if err := e.validate(); err != nil {
+		return nil, err
+	}
+
+	enchantmentsMutex.Lock()
+	defer enchantmentsMutex.Unlock()
+	previous := enchantments[id]
+	enchantments[id] = e
+	return previous, nil
+}
+
+// Unregister removes an enchantment. Items that have it keep it in their
+// metadata, but it no longer has any effect.
+func Unregister(id string) {
+	enchantmentsMutex.Lock()
+	defer enchantmentsMutex.Unlock()
+	delete(enchantments, id)
+}
+
+// Get returns the definition of an enchantment
+func Get(id string) (*Enchantment, bool) {
+	enchantmentsMutex.RLock()
+	defer enchantmentsMutex.RUnlock()
+	e, ok := enchantments[id]
+	return e, ok
+}
+
+// IDs returns the IDs of every registered enchantment in order
+func IDs() []string {
+	enchantmentsMutex.RLock()
+	defer enchantmentsMutex.RUnlock()
+	ids := make([]string, 0, len(enchantments))
+	for id := range enchantments {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Parse parses enchantment definitions in the enchantments.yaml format, keyed by ID
+func Parse(data []byte) (map[string]*Enchantment, error) {
+	var defs map[string]*Enchantment
+	if err := yaml.Unmarshal(data, &defs); err != nil {
+		return nil, err
+	}
+	for id, def := range defs {
+		if def == nil {
+			return nil, fmt.Errorf("enchantment %s has no definition", id)
+		}
+	}
+	return defs, nil
+}
+
+// Load registers the built-in enchantments and then loads enchantments.yaml over them
+func Load() {
+	for id, def := range DefaultEnchantments {
+		copied := *def
+		if _, err := Register(id, &copied); err != nil {
+			log.Printf("Warning: Built-in enchantment %s is invalid: %v", id, err)
+		}
+	}
+	LoadFromAssets()
+}
+
+// LoadFromAssets loads enchantment definitions from embedded assets
+func LoadFromAssets() {
+	data, err := assets.GetConfigFile("enchantments.yaml")
+	if err != nil {
+		log.Printf("Warning: Failed to load enchantments.yaml from embedded assets: %v", err)
+		return
+	}
+	defs, err := Parse(data)
+	if err != nil {
+		log.Printf("Error loading enchantments configuration: %v", err)
+		return
+	}
+	for id, def := range defs {
+		if _, err := Register(id, def); err != nil {
+			log.Printf("Warning: Skipping enchantment %s: %v", id, err)
+		}
+	}
+}
+
+func init() {
+	Load()
+}
@@ -329,6 +329,20 @@ func (em *EntityManager) GetTemplate(id string) (*EntityTemplate, bool) {
 	return template, exists
 }
 
+// RegisterTemplate adds or replaces a template
+func (em *EntityManager) RegisterTemplate(template *EntityTemplate) error {
+	if err := ValidateEntityID(template.ID); err != nil {
+		return fmt.Errorf("invalid template: %v", err)
+	}
+	em.templates[template.ID] = template
+	return nil
+}
+
+// RemoveTemplate removes a template by ID
+func (em *EntityManager) RemoveTemplate(id string) {
+	delete(em.templates, id)
+}
+
 // ListTemplates returns all template IDs
 func (em *EntityManager) ListTemplates() []string {
 	templates := make([]string, 0, len(em.templates))
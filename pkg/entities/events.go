@@ -27,6 +27,7 @@ const (
 	EventBlockBroken EventType = "block_broken"
 	EventItemUsed    EventType = "item_used"
 	EventItemCrafted EventType = "item_crafted"
+	EventItemPickup  EventType = "item_pickup"
 
 	// Combat events
 	EventAttack EventType = "attack"
@@ -43,6 +44,9 @@ const (
 	EventWorldSaved    EventType = "world_saved"
 	EventChunkLoaded   EventType = "chunk_loaded"
 	EventChunkUnloaded EventType = "chunk_unloaded"
+
+	// Plugin events
+	EventPluginViolation EventType = "plugin_violation"
 )
 
 // Event represents a game event
@@ -58,34 +62,70 @@ type Event struct {
 // EventHandler represents an event handler function
 type EventHandler func(event Event)
 
-// EventBus manages event publishing and subscription
+// PreEventHandler runs before the action an event describes is applied, on
+// the publisher's goroutine. Setting Cancelled vetoes the action; changing
+// the fields of the event data, which is a pointer in the pre phase,
+// modifies it.
+type PreEventHandler func(event *Event)
+
+// SubscriptionID identifies one handler registered with SubscribeWithID
+type SubscriptionID uint64
+
+// subscription is a registered handler
+type subscription struct {
+	id      SubscriptionID
+	handler EventHandler
+}
+
+// preSubscription is a registered pre-phase handler
+type preSubscription struct {
+	id       SubscriptionID
+	priority int
+	handler  PreEventHandler
+}
+
+// EventBus manages event publishing and subscription. Actions that plugins
+// may veto are published in two phases: PublishPre runs the pre-phase
+// handlers in priority order before the action, and the ordinary
+// subscribers are notified once it has been applied.
 type EventBus struct {
-	subscribers map[EventType][]EventHandler
-	handlers    map[string]EventHandler
-	mutex       sync.RWMutex
-	eventQueue  []Event
-	maxQueue    int
-	enabled     bool
+	subscribers    map[EventType][]subscription
+	preSubscribers map[EventType][]preSubscription
+	handlers       map[string]EventHandler
+	mutex          sync.RWMutex
+	eventQueue     []Event
+	maxQueue       int
+	enabled        bool
+	nextID         SubscriptionID
 }
 
 // NewEventBus creates a new event bus
 func NewEventBus() *EventBus {
 	return &EventBus{
-		subscribers: make(map[EventType][]EventHandler),
-		handlers:    make(map[string]EventHandler),
-		eventQueue:  make([]Event, 0),
-		maxQueue:    1000,
-		enabled:     true,
+		subscribers:    make(map[EventType][]subscription),
+		preSubscribers: make(map[EventType][]preSubscription),
+		handlers:       make(map[string]EventHandler),
+		eventQueue:     make([]Event, 0),
+		maxQueue:       1000,
+		enabled:        true,
 	}
 }
 
 // Subscribe subscribes to an event type
 func (eb *EventBus) Subscribe(eventType EventType, handler EventHandler) {
+	eb.SubscribeWithID(eventType, handler)
+}
+
+// SubscribeWithID subscribes to an event type and returns an ID that removes
+// exactly this handler again, even when it is a closure
+func (eb *EventBus) SubscribeWithID(eventType EventType, handler EventHandler) SubscriptionID {
 	eb.mutex.Lock()
 	defer eb.mutex.Unlock()
 
-	eb.subscribers[eventType] = append(eb.subscribers[eventType], handler)
+	eb.nextID++
+	eb.subscribers[eventType] = append(eb.subscribers[eventType], subscription{id: eb.nextID, handler: handler})
 	log.Printf("Subscribed to event: %s", eventType)
+	return eb.nextID
 }
 
 // Unsubscribe removes an event handler
@@ -93,16 +133,98 @@ func (eb *EventBus) Unsubscribe(eventType EventType, handler EventHandler) {
 	eb.mutex.Lock()
 	defer eb.mutex.Unlock()
 
-	handlers := eb.subscribers[eventType]
-	for i, h := range handlers {
-		if reflect.ValueOf(h).Pointer() == reflect.ValueOf(handler).Pointer() {
-			eb.subscribers[eventType] = append(handlers[:i], handlers[i+1:]...)
+	subs := eb.subscribers[eventType]
+	for i, sub := range subs {
+		if reflect.ValueOf(sub.handler).Pointer() == reflect.ValueOf(handler).Pointer() {
+			eb.subscribers[eventType] = append(subs[:i:i], subs[i+1:]...)
 			break
 		}
 	}
 	log.Printf("Unsubscribed from event: %s", eventType)
 }
 
+// UnsubscribeID removes the handler registered under an ID, in either phase
+func (eb *EventBus) UnsubscribeID(eventType EventType, id SubscriptionID) {
+	eb.mutex.Lock()
+	defer eb.mutex.Unlock()
+
+	subs := eb.subscribers[eventType]
+	for i, sub := range subs {
+		if sub.id == id {
+			eb.subscribers[eventType] = append(subs[:i:i], subs[i+1:]...)
+			return
+		}
+	}
+	preSubs := eb.preSubscribers[eventType]
+	for i, sub := range preSubs {
+		if sub.id == id {
+			eb.preSubscribers[eventType] = append(preSubs[:i:i], preSubs[i+1:]...)
+			return
+		}
+	}
+}
+
+// SubscribePre registers a handler for the pre phase of an event type.
+// Handlers with a higher priority run first; equal priorities run in the
+// order they subscribed.
+func (eb *EventBus) SubscribePre(eventType EventType, priority int, handler PreEventHandler) SubscriptionID {
+	eb.mutex.Lock()
+	defer eb.mutex.Unlock()
+
+	eb.nextID++
+	subs := eb.preSubscribers[eventType]
+	i := len(subs)
+	for i > 0 && subs[i-1].priority < priority {
+		i--
+	}
+	subs = append(subs, preSubscription{})
+	copy(subs[i+1:], subs[i:])
+	subs[i] = preSubscription{id: eb.nextID, priority: priority, handler: handler}
+	eb.preSubscribers[eventType] = subs
+	return eb.nextID
+}
+
+// PublishPre runs the pre phase of an event and returns the event as the
+// handlers left it. data should be a pointer so handlers can modify it. The
+// caller applies the action unless the event comes back cancelled, using the
+// possibly modified data, and then publishes it to the ordinary subscribers.
+func (eb *EventBus) PublishPre(eventType EventType, source string, data interface{}) Event {
+	event := Event{
+		Type:      eventType,
+		Timestamp: time.Now(),
+		Source:    source,
+		Data:      data,
+		Priority:  0,
+		Cancelled: false,
+	}
+	if !eb.enabled {
+		return event
+	}
+
+	eb.mutex.RLock()
+	subs := eb.preSubscribers[eventType]
+	eb.mutex.RUnlock()
+
+	for _, sub := range subs {
+		eb.runPreHandler(sub.handler, &event)
+		if event.Cancelled {
+			break
+		}
+	}
+	return event
+}
+
+// runPreHandler calls a pre-phase handler; a handler that panics does not
+// cancel the event
+func (eb *EventBus) runPreHandler(handler PreEventHandler, event *Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Pre-event handler panic for %s: %v", event.Type, r)
+		}
+	}()
+	handler(event)
+}
+
 // Publish publishes an event to all subscribers
 func (eb *EventBus) Publish(eventType EventType, data interface{}) {
 	if !eb.enabled {
@@ -174,10 +296,10 @@ func (eb *EventBus) PublishWithPriority(eventType EventType, source string, data
 // processEvent processes a single event
 func (eb *EventBus) processEvent(event Event) {
 	eb.mutex.RLock()
-	handlers := eb.subscribers[event.Type]
+	subs := eb.subscribers[event.Type]
 	eb.mutex.RUnlock()
 
-	for _, handler := range handlers {
+	for _, sub := range subs {
 		if event.Cancelled {
 			break
 		}
@@ -190,7 +312,7 @@ func (eb *EventBus) processEvent(event Event) {
 				}
 			}()
 			h(event)
-		}(handler)
+		}(sub.handler)
 	}
 }
 
@@ -243,7 +365,8 @@ func (eb *EventBus) Clear() {
 	eb.mutex.Lock()
 	defer eb.mutex.Unlock()
 
-	eb.subscribers = make(map[EventType][]EventHandler)
+	eb.subscribers = make(map[EventType][]subscription)
+	eb.preSubscribers = make(map[EventType][]preSubscription)
 	eb.eventQueue = eb.eventQueue[:0]
 }
 
@@ -276,6 +399,10 @@ type BlockEvent struct {
 	} `json:"position"`
 	PlayerID string `json:"playerId,omitempty"`
 	ToolUsed string `json:"toolUsed,omitempty"`
+
+	// What a broken block drops; plugins may change it in the pre phase
+	Drop         string `json:"drop,omitempty"`
+	DropQuantity int    `json:"dropQuantity,omitempty"`
 }
 
 // ItemEvent represents item-related event data
@@ -297,6 +424,13 @@ type CombatEvent struct {
 	Killed     bool    `json:"killed"`
 }
 
+// DeathEvent represents death event data
+type DeathEvent struct {
+	EntityID string `json:"entityId"`
+	Cause    string `json:"cause"`
+	KillerID string `json:"killerId,omitempty"`
+}
+
 // SystemEvent represents system-related event data
 type SystemEvent struct {
 	SystemName string `json:"systemName"`
@@ -364,6 +498,15 @@ func CreateCombatEvent(attackerID, targetID string, damage float64, weaponType s
 	}
 }
 
+// CreateDeathEvent creates a death event
+func CreateDeathEvent(entityID, cause, killerID string) DeathEvent {
+	return DeathEvent{
+		EntityID: entityID,
+		Cause:    cause,
+		KillerID: killerID,
+	}
+}
+
 // CreateSystemEvent creates a system event
 func CreateSystemEvent(systemName, status, message string) SystemEvent {
 	return SystemEvent{
@@ -546,10 +689,11 @@ func (el *EventLogger) Start() {
 	eventTypes := []EventType{
 		EventEntityAdded, EventEntityRemoved, EventEntityUpdated,
 		EventComponentAdded, EventComponentRemoved, EventComponentUpdated,
-		EventBlockPlaced, EventBlockBroken, EventItemUsed, EventItemCrafted,
+		EventBlockPlaced, EventBlockBroken, EventItemUsed, EventItemCrafted, EventItemPickup,
 		EventAttack, EventDamage, EventDeath, EventHeal,
 		EventSystemStarted, EventSystemStopped,
 		EventWorldLoaded, EventWorldSaved, EventChunkLoaded, EventChunkUnloaded,
+		EventPluginViolation,
 	}
 
 	for _, eventType := range eventTypes {
@@ -570,6 +714,9 @@ func (el *EventLogger) SetFilter(filter *EventFilter) {
 // logEvent logs a single event
 func (el *EventLogger) logEvent(event Event) {
 	message := fmt.Sprintf("[%s] %s from %s", event.Timestamp.Format("15:04:05"), event.Type, event.Source)
+	if violation, ok := event.Data.(PluginViolation); ok {
+		message += ": " + violation.String()
+	}
 
 	if el.logToConsole {
 		log.Println(message)
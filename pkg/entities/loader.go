@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"tesselbox/assets"
+	"tesselbox/pkg/items"
 	"time"
 
 	"gopkg.in/yaml.v3"
@@ -659,9 +660,8 @@ func (dl *DataLoader) convertCraftingRecipe(recipeID string, config *CraftingRec
 
 	// Convert inputs from list to map
 	for _, input := range config.Inputs {
-		if itemType, ok := input["item_type"].(int); ok {
+		if itemType, ok := input["item_type"]; ok {
 			if quantity, ok := input["quantity"].(int); ok {
-				// Convert int item_type to string using existing item mappings
 				itemTypeStr := dl.getItemTypeString(itemType)
 				recipe.Inputs[itemTypeStr] = quantity
 			}
@@ -670,9 +670,8 @@ func (dl *DataLoader) convertCraftingRecipe(recipeID string, config *CraftingRec
 
 	// Convert outputs from list to map
 	for _, output := range config.Outputs {
-		if itemType, ok := output["item_type"].(int); ok {
+		if itemType, ok := output["item_type"]; ok {
 			if quantity, ok := output["quantity"].(int); ok {
-				// Convert int item_type to string using existing item mappings
 				itemTypeStr := dl.getItemTypeString(itemType)
 				recipe.Outputs[itemTypeStr] = quantity
 			}
@@ -726,31 +725,16 @@ func (dl *DataLoader) inferEffectiveBlocks(itemID string) []string {
 	return []string{"all"}
 }
 
-// getItemTypeString converts int item type to string using existing mappings
-func (dl *DataLoader) getItemTypeString(itemType int) string {
-	// Map item type IDs to strings based on existing items.go
-	itemTypeMap := map[int]string{
-		1:  "dirt_block",
-		2:  "grass_block",
-		3:  "stone_block",
-		4:  "sand_block",
-		5:  "log_block",
-		6:  "coal",
-		7:  "iron_ingot",
-		8:  "gold_ingot",
-		9:  "diamond",
-		10: "wooden_pickaxe",
-		11: "stone_pickaxe",
-		12: "iron_pickaxe",
-		13: "planks",
-		14: "stick",
-		15: "workbench",
-		16: "furnace",
-		17: "anvil",
-	}
-
-	if str, ok := itemTypeMap[itemType]; ok {
-		return str
+// getItemTypeString returns the string ID of a recipe item_type, which is
+// either a string ID or a legacy numeric item type
+func (dl *DataLoader) getItemTypeString(itemType interface{}) string {
+	switch v := itemType.(type) {
+	case string:
+		return v
+	case int:
+		if id := items.ItemID(items.ItemType(v)); id != "" {
+			return id
+		}
 	}
 	return "unknown"
 }
@@ -4,8 +4,13 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"sort"
 	"strings"
+	"sync"
 	"time"
+
+	"tesselbox/pkg/biomes"
+	"tesselbox/pkg/mobs"
 )
 
 // ============================================================================
@@ -20,6 +25,21 @@ type PluginAPI struct {
 	eventBus       *EventBus
 	pluginName     string
 	allowedActions map[string]bool
+	sandbox        *PluginSandbox
+	fs             *PluginFS
+
+	// Registrations made through this API, removed again by Release
+	resourceMutex sync.Mutex
+	subscriptions []pluginSubscription
+	templates     []string
+	biomes        []string // IDs of biomes overridden or added, for logging
+	mobs          []string // IDs of mob types overridden or added, for logging
+}
+
+// pluginSubscription records an event handler a plugin subscribed
+type pluginSubscription struct {
+	eventType EventType
+	id        SubscriptionID
 }
 
 // NewPluginAPI creates a new plugin API instance for a specific plugin
@@ -31,7 +51,9 @@ func NewPluginAPI(manager *PluginManager, pluginName string) *PluginAPI {
 		eventBus:       manager.eventBus,
 		pluginName:     pluginName,
 		allowedActions: make(map[string]bool),
+		sandbox:        manager.Sandbox(pluginName),
 	}
+	api.fs = NewPluginFS(api.sandbox)
 
 	// Initialize with basic safe permissions
 	api.initializeDefaultPermissions()
@@ -240,16 +262,47 @@ func (api *PluginAPI) SubscribeToEvent(eventType string, handler EventHandler) e
 			dataMap["handling_plugin"] = api.pluginName
 		}
 
-		handler(event)
+		api.sandbox.Run("handler for "+eventType, func() {
+			handler(event)
+		})
 	}
 
 	// Convert string to EventType
 	eventTypeEnum := EventType(eventType)
-	api.eventBus.Subscribe(eventTypeEnum, wrappedHandler)
+	id := api.eventBus.SubscribeWithID(eventTypeEnum, wrappedHandler)
+
+	api.resourceMutex.Lock()
+	api.subscriptions = append(api.subscriptions, pluginSubscription{eventType: eventTypeEnum, id: id})
+	api.resourceMutex.Unlock()
+
 	log.Printf("Plugin %s subscribed to event %s", api.pluginName, eventType)
 	return nil
 }
 
+// SubscribeToPreEvent registers a handler that runs before an action is
+// applied and may cancel or modify it. Higher priorities run first.
+func (api *PluginAPI) SubscribeToPreEvent(eventType string, priority int, handler PreEventHandler) error {
+	if !api.hasPermission("event.subscribe") {
+		return fmt.Errorf("plugin %s does not have permission to subscribe to events", api.pluginName)
+	}
+
+	wrappedHandler := func(event *Event) {
+		api.sandbox.Run("pre-handler for "+eventType, func() {
+			handler(event)
+		})
+	}
+
+	eventTypeEnum := EventType(eventType)
+	id := api.eventBus.SubscribePre(eventTypeEnum, priority, wrappedHandler)
+
+	api.resourceMutex.Lock()
+	api.subscriptions = append(api.subscriptions, pluginSubscription{eventType: eventTypeEnum, id: id})
+	api.resourceMutex.Unlock()
+
+	log.Printf("Plugin %s intercepts event %s", api.pluginName, eventType)
+	return nil
+}
+
 // ============================================================================
 // Template Management API
 // ============================================================================
@@ -267,12 +320,21 @@ func (api *PluginAPI) GetTemplate(templateID string) (*EntityTemplate, error) {
 	return template, nil
 }
 
-// RegisterTemplate registers a new entity template
+// RegisterTemplate registers a new entity template. Templates of type "mob"
+// also register a mob type under the template's ID, so the game can spawn it.
 func (api *PluginAPI) RegisterTemplate(template *EntityTemplate) error {
 	if !api.hasPermission("template.register") {
 		return fmt.Errorf("plugin %s does not have permission to register templates", api.pluginName)
 	}
 
+	var mobType *mobs.MobType
+	if template.Type == "mob" {
+		var err error
+		if mobType, err = mobTypeFromTemplate(template); err != nil {
+			return fmt.Errorf("plugin %s failed to register template: %v", api.pluginName, err)
+		}
+	}
+
 	// Add plugin metadata
 	if template.Metadata == nil {
 		template.Metadata = make(map[string]interface{})
@@ -280,7 +342,94 @@ func (api *PluginAPI) RegisterTemplate(template *EntityTemplate) error {
 	template.Metadata["plugin"] = api.pluginName
 	template.Metadata["registered_at"] = time.Now()
 
+	if err := api.entityManager.RegisterTemplate(template); err != nil {
+		return fmt.Errorf("plugin %s failed to register template: %v", api.pluginName, err)
+	}
+
+	api.resourceMutex.Lock()
+	defer api.resourceMutex.Unlock()
+	api.templates = append(api.templates, template.ID)
 	log.Printf("Plugin %s registered template %s", api.pluginName, template.ID)
+
+	if mobType != nil {
+		if err := mobs.OverrideType(api.pluginName, template.ID, mobType); err != nil {
+			return fmt.Errorf("plugin %s failed to register mob %s: %v", api.pluginName, template.ID, err)
+		}
+		api.mobs = append(api.mobs, template.ID)
+		log.Printf("Plugin %s registered mob %s", api.pluginName, template.ID)
+	}
+	return nil
+}
+
+// mobTypeFromTemplate builds a mob type from a "mob" template. Its "mob"
+// component is a definition in the mobs.yaml format; without one, health and
+// damage come from the "combat" component, hostility from "behavior" and the
+// color from "render".
+func mobTypeFromTemplate(template *EntityTemplate) (*mobs.MobType, error) {
+	mobType := &mobs.MobType{Behaviors: []string{"wander", "chase"}}
+	if comp, ok := template.Components["mob"]; ok {
+		if err := convertViaYAML(comp, mobType); err != nil {
+			return nil, fmt.Errorf("invalid mob component: %v", err)
+		}
+	} else {
+		var combat struct {
+			MaxHealth float64 `yaml:"maxHealth"`
+			Damage    float64 `yaml:"damage"`
+		}
+		var behavior struct {
+			Hostile bool `yaml:"hostile"`
+		}
+		var render struct {
+			Color []uint8 `yaml:"color"`
+		}
+		if err := convertViaYAML(template.Components["combat"], &combat); err != nil {
+			return nil, fmt.Errorf("invalid combat component: %v", err)
+		}
+		if err := convertViaYAML(template.Components["behavior"], &behavior); err != nil {
+			return nil, fmt.Errorf("invalid behavior component: %v", err)
+		}
+		if err := convertViaYAML(template.Components["render"], &render); err != nil {
+			return nil, fmt.Errorf("invalid render component: %v", err)
+		}
+		mobType.Health = combat.MaxHealth
+		mobType.Damage = combat.Damage
+		mobType.Hostile = behavior.Hostile
+		copy(mobType.Color[:], render.Color)
+	}
+	if mobType.Name == "" {
+		mobType.Name = template.Name
+	}
+	return mobType, nil
+}
+
+// ============================================================================
+// Biome API
+// ============================================================================
+
+// RegisterBiomes adds or overrides biomes from YAML in the biomes.yaml
+// format. Overrides stack on those of other plugins and are undone by Release.
+func (api *PluginAPI) RegisterBiomes(data []byte) error {
+	if !api.hasPermission("biome.register") {
+		return fmt.Errorf("plugin %s does not have permission to register biomes", api.pluginName)
+	}
+
+	defs, err := biomes.ParseBiomes(data)
+	if err != nil {
+		return fmt.Errorf("plugin %s failed to register biomes: %v", api.pluginName, err)
+	}
+	ids := make([]string, 0, len(defs))
+	for id := range defs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	api.resourceMutex.Lock()
+	defer api.resourceMutex.Unlock()
+	for _, id := range ids {
+		biomes.OverrideBiome(api.pluginName, id, defs[id])
+		api.biomes = append(api.biomes, id)
+		log.Printf("Plugin %s registered biome %s", api.pluginName, id)
+	}
 	return nil
 }
 
@@ -294,7 +443,7 @@ func (api *PluginAPI) RegisterSystem(system System) error {
 		return fmt.Errorf("plugin %s does not have permission to register systems", api.pluginName)
 	}
 
-	api.systemManager.RegisterSystem(system)
+	api.systemManager.RegisterSystem(&sandboxedSystem{System: system, sandbox: api.sandbox})
 	log.Printf("Plugin %s registered system %s", api.pluginName, system.GetName())
 	return nil
 }
@@ -310,6 +459,42 @@ func (api *PluginAPI) UnregisterSystem(systemName string) error {
 	return nil
 }
 
+// ============================================================================
+// Filesystem API
+// ============================================================================
+
+// ReadFile reads a file inside the allowed plugin paths
+func (api *PluginAPI) ReadFile(path string) ([]byte, error) {
+	if !api.hasPermission("file.read") {
+		return nil, fmt.Errorf("plugin %s does not have permission to read files", api.pluginName)
+	}
+	return api.fs.ReadFile(path)
+}
+
+// WriteFile writes a file inside the allowed plugin paths
+func (api *PluginAPI) WriteFile(path string, data []byte) error {
+	if !api.hasPermission("file.write") {
+		return fmt.Errorf("plugin %s does not have permission to write files", api.pluginName)
+	}
+	return api.fs.WriteFile(path, data)
+}
+
+// ListFiles lists a directory inside the allowed plugin paths
+func (api *PluginAPI) ListFiles(path string) ([]string, error) {
+	if !api.hasPermission("file.read") {
+		return nil, fmt.Errorf("plugin %s does not have permission to read files", api.pluginName)
+	}
+	return api.fs.ReadDir(path)
+}
+
+// FileExists checks whether a path inside the allowed plugin paths exists
+func (api *PluginAPI) FileExists(path string) (bool, error) {
+	if !api.hasPermission("file.read") {
+		return false, fmt.Errorf("plugin %s does not have permission to read files", api.pluginName)
+	}
+	return api.fs.Exists(path)
+}
+
 // ============================================================================
 // Utility API
 // ============================================================================
@@ -319,6 +504,45 @@ func (api *PluginAPI) Log(level string, message string) {
 	log.Printf("[%s] %s: %s", strings.ToUpper(level), api.pluginName, message)
 }
 
+// Release removes every event subscription, template, biome and mob type
+// registered through this API, so an unloaded plugin leaves nothing behind
+func (api *PluginAPI) Release() {
+	api.resourceMutex.Lock()
+	subscriptions := api.subscriptions
+	templates := api.templates
+	registeredBiomes := api.biomes
+	registeredMobs := api.mobs
+	api.subscriptions = nil
+	api.templates = nil
+	api.biomes = nil
+	api.mobs = nil
+	api.resourceMutex.Unlock()
+
+	for _, sub := range subscriptions {
+		api.eventBus.UnsubscribeID(sub.eventType, sub.id)
+	}
+	for _, templateID := range templates {
+		api.entityManager.RemoveTemplate(templateID)
+	}
+	// Other plugins' overrides of the same biomes stay in effect
+	if len(registeredBiomes) > 0 {
+		biomes.ReleaseBiomes(api.pluginName)
+	}
+	// Likewise for mob types; mobs already alive keep their definitions
+	if len(registeredMobs) > 0 {
+		mobs.ReleaseTypes(api.pluginName)
+	}
+	if len(subscriptions) > 0 || len(templates) > 0 || len(registeredBiomes) > 0 || len(registeredMobs) > 0 {
+		log.Printf("Plugin %s released %d event handlers, %d templates, %d biomes and %d mobs",
+			api.pluginName, len(subscriptions), len(templates), len(registeredBiomes), len(registeredMobs))
+	}
+}
+
+// Sandbox returns the sandbox enforcing the plugin's limits
+func (api *PluginAPI) Sandbox() *PluginSandbox {
+	return api.sandbox
+}
+
 // GetPluginInfo gets information about the plugin
 func (api *PluginAPI) GetPluginInfo() *PluginInfo {
 	info, _ := api.manager.GetPluginInfo(api.pluginName)
@@ -356,6 +580,10 @@ func (api *PluginAPI) HasPermission(permission string) bool {
 
 // hasPermission is the internal permission check
 func (api *PluginAPI) hasPermission(permission string) bool {
+	// "*" grants everything, as configured by defaultPermissions in plugins.yaml
+	if api.allowedActions["*"] {
+		return true
+	}
 	allowed, exists := api.allowedActions[permission]
 	if !exists {
 		// Default to deny - strict security model
@@ -26,6 +26,7 @@ type GlobalPluginConfig struct {
 	Enabled            bool           `yaml:"enabled"`
 	PluginDirectory    string         `yaml:"pluginDirectory"`
 	HotReload          bool           `yaml:"hotReload"`
+	Repository         string         `yaml:"repository"` // directory, file:// or http(s) URL of the plugin index
 	DefaultPermissions []string       `yaml:"defaultPermissions"`
 	Security           SecurityConfig `yaml:"security"`
 	Logging            LoggingConfig  `yaml:"logging"`
@@ -64,10 +65,11 @@ func NewPluginConfigManager(configPath string) *PluginConfigManager {
 		Enabled:            true,
 		PluginDirectory:    "plugins",
 		HotReload:          false,
+		Repository:         "repository",
 		DefaultPermissions: []string{"*"},
 		Security: SecurityConfig{
 			SandboxEnabled: false,
-			AllowedPaths:   []string{},
+			AllowedPaths:   []string{"plugins", "saves", "logs"},
 			BlockedPaths:   []string{"/etc", "/sys", "/proc"},
 			MaxMemory:      100 * 1024 * 1024, // 100MB
 			MaxCPU:         50,                // 50%
@@ -103,6 +105,10 @@ func (pcm *PluginConfigManager) LoadGlobalConfig() error {
 		return fmt.Errorf("failed to parse global config: %v", err)
 	}
 
+	if err := pcm.ValidateSecurityConfig(&pcm.globalConfig.Security); err != nil {
+		return fmt.Errorf("invalid security config: %v", err)
+	}
+
 	return nil
 }
 
@@ -205,6 +211,9 @@ func (pcm *PluginConfigManager) GetGlobalConfig() *GlobalPluginConfig {
 
 // UpdateGlobalConfig updates the global configuration
 func (pcm *PluginConfigManager) UpdateGlobalConfig(config *GlobalPluginConfig) error {
+	if err := pcm.ValidateSecurityConfig(&config.Security); err != nil {
+		return fmt.Errorf("invalid security config: %v", err)
+	}
 	pcm.globalConfig = config
 	return pcm.SaveGlobalConfig()
 }
@@ -269,8 +278,11 @@ func (pcm *PluginConfigManager) isValidPermission(permission string) bool {
 		"world.modify",
 		"template.get",
 		"template.register",
+		"biome.register",
 		"system.register",
 		"system.unregister",
+		"file.read",
+		"file.write",
 	}
 
 	for _, valid := range validPermissions {
@@ -299,24 +311,27 @@ func (pcm *PluginConfigManager) validateSettings(settings map[string]interface{}
 
 // isValidPath checks if a path is valid and allowed
 func (pcm *PluginConfigManager) isValidPath(path string) bool {
-	// Check against blocked paths
-	for _, blocked := range pcm.globalConfig.Security.BlockedPaths {
-		if strings.HasPrefix(path, blocked) {
-			return false
-		}
-	}
+	_, err := pcm.globalConfig.Security.CheckPath(path)
+	return err == nil
+}
 
-	// If allowed paths are specified, check against them
-	if len(pcm.globalConfig.Security.AllowedPaths) > 0 {
-		for _, allowed := range pcm.globalConfig.Security.AllowedPaths {
-			if strings.HasPrefix(path, allowed) {
-				return true
-			}
+// ValidateSecurityConfig checks the sandbox limits before they are enforced
+func (pcm *PluginConfigManager) ValidateSecurityConfig(config *SecurityConfig) error {
+	if config.MaxMemory < 0 {
+		return fmt.Errorf("maxMemory must not be negative")
+	}
+	if config.MaxCPU < 0 || config.MaxCPU > 100 {
+		return fmt.Errorf("maxCPU must be between 0 and 100")
+	}
+	if config.Timeout < 0 {
+		return fmt.Errorf("timeout must not be negative")
+	}
+	for _, path := range append(append([]string{}, config.AllowedPaths...), config.BlockedPaths...) {
+		if strings.TrimSpace(path) == "" {
+			return fmt.Errorf("sandbox paths must not be empty")
 		}
-		return false
 	}
-
-	return true
+	return nil
 }
 
 // ============================================================================
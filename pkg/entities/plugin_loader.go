@@ -6,9 +6,7 @@ import (
 	"io/fs"
 	"log"
 	"os"
-	"os/exec"
 	"path/filepath"
-	"plugin"
 	"strings"
 	"sync"
 	"time"
@@ -114,32 +112,28 @@ func (pd *PluginDiscovery) discoverInDirectory(dir string) ([]*PluginMetadata, e
 			return err
 		}
 
+		// A directory with a main script is one plugin (source plugins)
 		if d.IsDir() {
+			if path != dir && pd.isPluginDirectory(path) {
+				metadata, err := pd.analyzePlugin(path, "source")
+				if err != nil {
+					log.Printf("Failed to analyze plugin directory %s: %v", path, err)
+				} else {
+					plugins = append(plugins, metadata)
+				}
+				return filepath.SkipDir
+			}
 			return nil
 		}
 
-		// Check for .so files (compiled plugins)
-		if strings.HasSuffix(path, ".so") {
-			metadata, err := pd.analyzePlugin(path, "compiled")
+		// A lone script file is a single-file plugin
+		if IsScriptPluginFile(path) {
+			metadata, err := pd.analyzePlugin(path, "script")
 			if err != nil {
 				log.Printf("Failed to analyze plugin %s: %v", path, err)
 				return nil
 			}
 			plugins = append(plugins, metadata)
-			return nil
-		}
-
-		// Check for plugin directories (source plugins)
-		if d.IsDir() || strings.HasSuffix(path, ".go") {
-			// Check if this is a plugin directory
-			if pd.isPluginDirectory(path) {
-				metadata, err := pd.analyzePlugin(path, "source")
-				if err != nil {
-					log.Printf("Failed to analyze plugin directory %s: %v", path, err)
-					return nil
-				}
-				plugins = append(plugins, metadata)
-			}
 		}
 
 		return nil
@@ -150,25 +144,8 @@ func (pd *PluginDiscovery) discoverInDirectory(dir string) ([]*PluginMetadata, e
 
 // isPluginDirectory checks if a directory contains a plugin
 func (pd *PluginDiscovery) isPluginDirectory(path string) bool {
-	// Check for plugin.yaml or plugin.json
-	configPath := filepath.Join(path, "plugin.yaml")
-	if _, err := os.Stat(configPath); err == nil {
-		return true
-	}
-
-	configPath = filepath.Join(path, "plugin.json")
-	if _, err := os.Stat(configPath); err == nil {
-		return true
-	}
-
-	// Check for main.go with plugin package
-	mainPath := filepath.Join(path, "main.go")
-	if _, err := os.Stat(mainPath); err == nil {
-		// Could check file contents here for plugin package
-		return true
-	}
-
-	return false
+	_, err := os.Stat(filepath.Join(path, "main"+ScriptExtension))
+	return err == nil
 }
 
 // analyzePlugin analyzes a plugin and returns metadata
@@ -176,18 +153,24 @@ func (pd *PluginDiscovery) analyzePlugin(path string, pluginType string) (*Plugi
 	metadata := &PluginMetadata{
 		Path:       path,
 		Type:       pluginType,
+		Config:     *DefaultPluginConfig(),
 		Discovered: time.Now(),
 	}
 
-	// Try to read plugin configuration
-	configPath := strings.TrimSuffix(path, filepath.Ext(path)) + ".yaml"
+	// Plugin configuration sits next to a script (name.yaml) or inside a
+	// plugin directory (plugin.yaml)
+	base := strings.TrimSuffix(path, filepath.Ext(path))
+	if pluginType == "source" {
+		base = filepath.Join(path, "plugin")
+	}
+	configPath := base + ".yaml"
 	if _, err := os.Stat(configPath); err == nil {
 		if err := pd.loadPluginConfig(configPath, metadata); err != nil {
 			log.Printf("Failed to load plugin config %s: %v", configPath, err)
 		}
 	} else {
 		// Try JSON config
-		configPath = strings.TrimSuffix(path, filepath.Ext(path)) + ".json"
+		configPath = base + ".json"
 		if _, err := os.Stat(configPath); err == nil {
 			if err := pd.loadPluginConfigJSON(configPath, metadata); err != nil {
 				log.Printf("Failed to load plugin config %s: %v", configPath, err)
@@ -197,7 +180,7 @@ func (pd *PluginDiscovery) analyzePlugin(path string, pluginType string) (*Plugi
 
 	// Extract basic info from filename if no config found
 	if metadata.Name == "" {
-		metadata.Name = filepath.Base(strings.TrimSuffix(path, filepath.Ext(path)))
+		metadata.Name = ScriptPluginName(path)
 	}
 
 	return metadata, nil
@@ -253,7 +236,7 @@ func (pd *PluginDiscovery) loadPluginConfigJSON(path string, metadata *PluginMet
 type PluginMetadata struct {
 	PluginInfo
 	Path       string       `yaml:"path"`
-	Type       string       `yaml:"type"` // "compiled" or "source"
+	Type       string       `yaml:"type"` // "script" or "source"
 	Config     PluginConfig `yaml:"config"`
 	Discovered time.Time    `yaml:"discovered"`
 	Loaded     bool         `yaml:"loaded"`
@@ -341,51 +324,60 @@ func (epm *EnhancedPluginManager) LoadPluginFromMetadata(metadata *PluginMetadat
 		return fmt.Errorf("plugin %s is already loaded", metadata.Name)
 	}
 
-	// Store config
-	epm.configs[metadata.Name] = &metadata.Config
+	plugin, path, err := epm.preparePlugin(metadata)
+	if err != nil {
+		return err
+	}
+	return epm.activatePlugin(metadata, plugin, path)
+}
 
+// preparePlugin loads a plugin's code without initializing it, returning the
+// script it was loaded from
+func (epm *EnhancedPluginManager) preparePlugin(metadata *PluginMetadata) (Plugin, string, error) {
 	var plugin Plugin
 	var err error
+	path := metadata.Path
 
 	switch metadata.Type {
-	case "compiled":
-		plugin, err = epm.loadCompiledPlugin(metadata)
+	case "script":
+		plugin, err = epm.loadScriptPlugin(metadata, path)
 	case "source":
+		path = filepath.Join(metadata.Path, "main"+ScriptExtension)
 		plugin, err = epm.loadSourcePlugin(metadata)
 	default:
-		return fmt.Errorf("unsupported plugin type: %s", metadata.Type)
+		return nil, "", fmt.Errorf("unsupported plugin type: %s", metadata.Type)
 	}
 
 	if err != nil {
-		return fmt.Errorf("failed to load plugin %s: %v", metadata.Name, err)
+		return nil, "", fmt.Errorf("failed to load plugin %s: %v", metadata.Name, err)
 	}
+	return plugin, path, nil
+}
 
-	// Create plugin API
-	api := NewPluginAPI(epm.PluginManager, metadata.Name)
+// activatePlugin initializes a prepared plugin and registers it
+func (epm *EnhancedPluginManager) activatePlugin(metadata *PluginMetadata, plugin Plugin, path string) error {
+	// Store config
+	epm.configs[metadata.Name] = &metadata.Config
+
+	if enhancedPlugin, ok := plugin.(EnhancedPlugin); ok {
+		// Create plugin API
+		api := NewPluginAPI(epm.PluginManager, metadata.Name)
 
-	// Grant permissions
-	for _, permission := range metadata.Config.Permissions {
-		if permission == "*" {
-			// Grant all permissions
-			api.allowedActions["*"] = true
-		} else {
+		// Grant permissions
+		for _, permission := range metadata.Config.Permissions {
 			api.allowedActions[permission] = true
 		}
-	}
 
-	// Initialize plugin
-	if enhancedPlugin, ok := plugin.(EnhancedPlugin); ok {
 		if err := enhancedPlugin.OnLoad(api); err != nil {
 			return fmt.Errorf("plugin %s OnLoad failed: %v", metadata.Name, err)
 		}
-	} else {
-		if err := plugin.Initialize(epm.PluginManager); err != nil {
-			return fmt.Errorf("plugin %s Initialize failed: %v", metadata.Name, err)
-		}
+		epm.mutex.Lock()
+		epm.plugins[metadata.Name] = plugin
+		epm.mutex.Unlock()
+	} else if err := epm.installPlugin(metadata.Name, path, plugin); err != nil {
+		return err
 	}
 
-	// Store plugin
-	epm.plugins[metadata.Name] = plugin
 	metadata.Loaded = true
 	metadata.LoadTime = time.Now()
 
@@ -393,73 +385,25 @@ func (epm *EnhancedPluginManager) LoadPluginFromMetadata(metadata *PluginMetadat
 	return nil
 }
 
-// loadCompiledPlugin loads a compiled plugin (.so file)
-func (epm *EnhancedPluginManager) loadCompiledPlugin(metadata *PluginMetadata) (Plugin, error) {
-	pluginObj, err := plugin.Open(metadata.Path)
+// loadScriptPlugin loads a script plugin with the permissions from its config
+func (epm *EnhancedPluginManager) loadScriptPlugin(metadata *PluginMetadata, path string) (Plugin, error) {
+	scriptPlugin, err := LoadScriptPlugin(metadata.Name, path, epm.loadLimits())
 	if err != nil {
-		return nil, fmt.Errorf("failed to open plugin %s: %v", metadata.Path, err)
+		return nil, err
 	}
-
-	// Look for the plugin symbol
-	sym, err := pluginObj.Lookup("Plugin")
-	if err != nil {
-		return nil, fmt.Errorf("plugin %s does not export a Plugin symbol: %v", metadata.Name, err)
-	}
-
-	// Convert symbol to Plugin interface
-	pluginInstance, ok := sym.(Plugin)
-	if !ok {
-		return nil, fmt.Errorf("plugin %s does not implement the Plugin interface", metadata.Name)
+	if len(metadata.Config.Permissions) > 0 {
+		scriptPlugin.SetPermissions(metadata.Config.Permissions)
 	}
-
-	return pluginInstance, nil
+	return scriptPlugin, nil
 }
 
-// loadSourcePlugin loads a source plugin (for development)
+// loadSourcePlugin loads a plugin directory, whose entry point is its main script
 func (epm *EnhancedPluginManager) loadSourcePlugin(metadata *PluginMetadata) (Plugin, error) {
-	// Check if this is a Go plugin directory
-	mainGoPath := filepath.Join(metadata.Path, "main.go")
-	if _, err := os.Stat(mainGoPath); os.IsNotExist(err) {
-		return nil, fmt.Errorf("no main.go found in plugin directory %s", metadata.Path)
-	}
-
-	// Create a temporary build directory
-	tempDir, err := os.MkdirTemp("", "tesselbox-plugin-*")
-	if err != nil {
-		return nil, fmt.Errorf("failed to create temp directory: %v", err)
-	}
-	defer os.RemoveAll(tempDir)
-
-	// Build the plugin as a shared library (.so file)
-	soPath := filepath.Join(tempDir, "plugin.so")
-
-	// Use go build to create the plugin
-	cmd := exec.Command("go", "build", "-buildmode=plugin", "-o", soPath, mainGoPath)
-	cmd.Dir = metadata.Path
-
-	if output, err := cmd.CombinedOutput(); err != nil {
-		return nil, fmt.Errorf("failed to build plugin: %v\nOutput: %s", err, string(output))
+	mainPath := filepath.Join(metadata.Path, "main"+ScriptExtension)
+	if _, err := os.Stat(mainPath); os.IsNotExist(err) {
+		return nil, fmt.Errorf("no main%s found in plugin directory %s", ScriptExtension, metadata.Path)
 	}
-
-	// Load the compiled plugin
-	pluginObj, err := plugin.Open(soPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open compiled plugin: %v", err)
-	}
-
-	// Look for the plugin symbol
-	sym, err := pluginObj.Lookup("Plugin")
-	if err != nil {
-		return nil, fmt.Errorf("plugin does not export a Plugin symbol: %v", err)
-	}
-
-	// Convert symbol to Plugin interface
-	pluginInstance, ok := sym.(Plugin)
-	if !ok {
-		return nil, fmt.Errorf("plugin does not implement the Plugin interface")
-	}
-
-	return pluginInstance, nil
+	return epm.loadScriptPlugin(metadata, mainPath)
 }
 
 // UnloadPluginWithMetadata unloads a plugin and updates metadata
@@ -480,14 +424,14 @@ func (epm *EnhancedPluginManager) UnloadPluginWithMetadata(metadata *PluginMetad
 		if err := enhancedPlugin.OnUnload(api); err != nil {
 			log.Printf("Warning: plugin %s OnUnload failed: %v", metadata.Name, err)
 		}
-	} else {
-		if err := plugin.Shutdown(); err != nil {
-			log.Printf("Warning: plugin %s shutdown failed: %v", metadata.Name, err)
-		}
+		epm.mutex.Lock()
+		delete(epm.plugins, metadata.Name)
+		epm.mutex.Unlock()
+	} else if err := epm.UnloadPlugin(metadata.Name); err != nil {
+		return err
 	}
 
 	// Remove plugin
-	delete(epm.plugins, metadata.Name)
 	delete(epm.configs, metadata.Name)
 
 	metadata.Loaded = false
@@ -497,12 +441,20 @@ func (epm *EnhancedPluginManager) UnloadPluginWithMetadata(metadata *PluginMetad
 	return nil
 }
 
-// ReloadPluginWithMetadata reloads a plugin
+// ReloadPluginWithMetadata reloads a plugin. The new code is loaded before the
+// old plugin is unloaded, so a broken edit keeps the running version.
 func (epm *EnhancedPluginManager) ReloadPluginWithMetadata(metadata *PluginMetadata) error {
+	plugin, path, err := epm.preparePlugin(metadata)
+	if err != nil {
+		return fmt.Errorf("keeping loaded version: %v", err)
+	}
 	if err := epm.UnloadPluginWithMetadata(metadata); err != nil {
 		return err
 	}
-	return epm.LoadPluginFromMetadata(metadata)
+
+	epm.reloadMutex.Lock()
+	defer epm.reloadMutex.Unlock()
+	return epm.activatePlugin(metadata, plugin, path)
 }
 
 // EnableHotReload enables hot reloading of plugins
@@ -549,13 +501,13 @@ func (epm *EnhancedPluginManager) watchFiles() {
 
 // shouldHandleEvent checks if we should handle a file system event
 func (epm *EnhancedPluginManager) shouldHandleEvent(event fsnotify.Event) bool {
-	// Only handle write events for plugin files
-	if event.Op&fsnotify.Write != fsnotify.Write {
+	// Only handle writes, including editors that save by replacing the file
+	if event.Op&(fsnotify.Write|fsnotify.Create) == 0 {
 		return false
 	}
 
 	// Check if it's a plugin file
-	return strings.HasSuffix(event.Name, ".so") ||
+	return IsScriptPluginFile(event.Name) ||
 		strings.HasSuffix(event.Name, ".yaml") ||
 		strings.HasSuffix(event.Name, ".json")
 }
@@ -574,7 +526,7 @@ func (epm *EnhancedPluginManager) handleFileChange(event fsnotify.Event) {
 			strings.HasPrefix(event.Name, strings.TrimSuffix(metadata.Path, filepath.Ext(metadata.Path))) {
 
 			// Check if auto-reload is enabled for this plugin
-			if metadata.Config.AutoReload && metadata.Loaded {
+			if metadata.Config.AutoReload && epm.IsLoaded(metadata.Name) {
 				log.Printf("Auto-reloading plugin %s due to file change", metadata.Name)
 				if err := epm.ReloadPluginWithMetadata(metadata); err != nil {
 					log.Printf("Failed to auto-reload plugin %s: %v", metadata.Name, err)
@@ -608,6 +560,7 @@ func (epm *EnhancedPluginManager) EnablePlugin(name string) error {
 	if epm.pluginInfo[name] != nil {
 		epm.pluginInfo[name].Enabled = true
 	}
+	epm.Sandbox(name).SetDisabled(false)
 
 	return nil
 }
@@ -622,10 +575,11 @@ func (epm *EnhancedPluginManager) DisablePlugin(name string) error {
 		return fmt.Errorf("plugin not found: %s", name)
 	}
 
-	// Disable the plugin in info
+	// Disable the plugin in info; the sandbox stops its handlers and systems
 	if epm.pluginInfo[name] != nil {
 		epm.pluginInfo[name].Enabled = false
 	}
+	epm.Sandbox(name).SetDisabled(true)
 
 	return nil
 }
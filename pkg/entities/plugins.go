@@ -3,9 +3,13 @@ package entities
 import (
 	"fmt"
 	"log"
+	"os"
 	"path/filepath"
-	"plugin"
+	"sort"
+	"strings"
 	"sync"
+
+	"tesselbox/pkg/script"
 )
 
 // Plugin defines the interface for all plugins
@@ -40,12 +44,17 @@ type PluginInfo struct {
 type PluginManager struct {
 	plugins       map[string]Plugin
 	pluginInfo    map[string]*PluginInfo
-	loadedPlugins map[string]*plugin.Plugin
+	pluginFiles   map[string]string // Script path of each loaded script plugin
 	entityManager *EntityManager
 	systemManager *SystemManager
 	eventBus      *EventBus
 	mutex         sync.RWMutex
 	pluginPath    string
+
+	// Sandboxes enforcing the security settings, one per plugin
+	security     SecurityConfig
+	sandboxes    map[string]*PluginSandbox
+	sandboxMutex sync.Mutex
 }
 
 // NewPluginManager creates a new plugin manager
@@ -53,12 +62,70 @@ func NewPluginManager(entityManager *EntityManager, systemManager *SystemManager
 	return &PluginManager{
 		plugins:       make(map[string]Plugin),
 		pluginInfo:    make(map[string]*PluginInfo),
-		loadedPlugins: make(map[string]*plugin.Plugin),
+		pluginFiles:   make(map[string]string),
 		entityManager: entityManager,
 		systemManager: systemManager,
 		eventBus:      eventBus,
 		pluginPath:    "plugins",
+		sandboxes:     make(map[string]*PluginSandbox),
+	}
+}
+
+// SetSecurityConfig sets the sandbox limits enforced on plugins, normally the
+// security section of plugins.yaml as validated by PluginConfigManager. It
+// applies to loaded plugins immediately.
+func (pm *PluginManager) SetSecurityConfig(config SecurityConfig) {
+	pm.sandboxMutex.Lock()
+	defer pm.sandboxMutex.Unlock()
+
+	pm.security = config
+	for _, sandbox := range pm.sandboxes {
+		sandbox.SetConfig(config)
+	}
+}
+
+// loadLimits returns the interpreter limits for loading a script plugin
+func (pm *PluginManager) loadLimits() script.Limits {
+	pm.sandboxMutex.Lock()
+	defer pm.sandboxMutex.Unlock()
+	return LoadLimits(pm.security)
+}
+
+// Sandbox returns the sandbox of a plugin, creating it on first use
+func (pm *PluginManager) Sandbox(pluginName string) *PluginSandbox {
+	pm.sandboxMutex.Lock()
+	defer pm.sandboxMutex.Unlock()
+
+	sandbox, exists := pm.sandboxes[pluginName]
+	if !exists {
+		sandbox = NewPluginSandbox(pluginName, pm.security, pm.handleViolation)
+		pm.sandboxes[pluginName] = sandbox
 	}
+	return sandbox
+}
+
+// removeSandbox forgets a plugin's sandbox so a reloaded plugin starts with a
+// fresh budget
+func (pm *PluginManager) removeSandbox(pluginName string) {
+	pm.sandboxMutex.Lock()
+	defer pm.sandboxMutex.Unlock()
+	delete(pm.sandboxes, pluginName)
+}
+
+// handleViolation reports a plugin the sandbox disabled. The report goes out
+// as an EventPluginViolation, which EventLogger records.
+func (pm *PluginManager) handleViolation(violation PluginViolation) {
+	log.Printf("Disabled plugin %s: %s", violation.Plugin, violation.Detail)
+	pm.eventBus.PublishWithSource(EventPluginViolation, "plugin_sandbox", violation)
+
+	// The violation may come from a plugin being installed, which holds the lock
+	go func() {
+		pm.mutex.Lock()
+		defer pm.mutex.Unlock()
+		if info, exists := pm.pluginInfo[violation.Plugin]; exists {
+			info.Enabled = false
+		}
+	}()
 }
 
 // SetPluginPath sets the path to look for plugins
@@ -68,33 +135,44 @@ func (pm *PluginManager) SetPluginPath(path string) {
 	pm.pluginPath = path
 }
 
-// LoadPlugin loads a plugin by name
+// resolvePluginFile finds the script for a plugin name or path. A bare name
+// is looked up in the plugin directory.
+func (pm *PluginManager) resolvePluginFile(nameOrPath string) string {
+	if IsScriptPluginFile(nameOrPath) {
+		if _, err := os.Stat(nameOrPath); err == nil || filepath.IsAbs(nameOrPath) {
+			return nameOrPath
+		}
+		return filepath.Join(pm.pluginPath, filepath.Base(nameOrPath))
+	}
+	return filepath.Join(pm.pluginPath, nameOrPath+ScriptExtension)
+}
+
+// LoadPlugin loads a script plugin by name, or by the path of its script
 func (pm *PluginManager) LoadPlugin(pluginName string) error {
-	pm.mutex.Lock()
-	defer pm.mutex.Unlock()
+	pm.mutex.RLock()
+	path := pm.resolvePluginFile(pluginName)
+	pm.mutex.RUnlock()
 
-	// Check if plugin is already loaded
-	if _, exists := pm.plugins[pluginName]; exists {
-		return fmt.Errorf("plugin %s is already loaded", pluginName)
+	name := ScriptPluginName(path)
+	if pm.IsLoaded(name) {
+		return fmt.Errorf("plugin %s is already loaded", name)
 	}
 
-	// Load plugin from file
-	pluginPath := filepath.Join(pm.pluginPath, pluginName+".so")
-	plug, err := plugin.Open(pluginPath)
+	pluginInstance, err := LoadScriptPlugin(name, path, pm.loadLimits())
 	if err != nil {
-		return fmt.Errorf("failed to open plugin %s: %v", pluginName, err)
+		return fmt.Errorf("failed to load plugin %s: %v", name, err)
 	}
+	return pm.installPlugin(name, path, pluginInstance)
+}
 
-	// Look for the plugin symbol
-	sym, err := plug.Lookup("Plugin")
-	if err != nil {
-		return fmt.Errorf("plugin %s does not export a Plugin symbol: %v", pluginName, err)
-	}
+// installPlugin initializes a loaded plugin and registers what it provides
+func (pm *PluginManager) installPlugin(pluginName, path string, pluginInstance Plugin) error {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
 
-	// Convert symbol to Plugin interface
-	pluginInstance, ok := sym.(Plugin)
-	if !ok {
-		return fmt.Errorf("plugin %s does not implement the Plugin interface", pluginName)
+	// Check if plugin is already loaded
+	if _, exists := pm.plugins[pluginName]; exists {
+		return fmt.Errorf("plugin %s is already loaded", pluginName)
 	}
 
 	// Check dependencies
@@ -106,8 +184,10 @@ func (pm *PluginManager) LoadPlugin(pluginName string) error {
 	}
 
 	// Initialize plugin
-	err = pluginInstance.Initialize(pm)
+	sandbox := pm.Sandbox(pluginName)
+	err := pluginInstance.Initialize(pm)
 	if err != nil {
+		pm.removeSandbox(pluginName)
 		return fmt.Errorf("failed to initialize plugin %s: %v", pluginName, err)
 	}
 
@@ -120,24 +200,47 @@ func (pm *PluginManager) LoadPlugin(pluginName string) error {
 	// Register plugin systems
 	systems := pluginInstance.GetSystems()
 	for _, system := range systems {
-		pm.systemManager.RegisterSystem(system)
+		pm.systemManager.RegisterSystem(&sandboxedSystem{System: system, sandbox: sandbox})
 	}
 
 	// Register plugin templates
 	templates := pluginInstance.GetTemplates()
-	for templateID := range templates {
-		// This would need to be implemented in EntityManager
+	for templateID, template := range templates {
+		template.ID = templateID
+		if err := pm.entityManager.RegisterTemplate(template); err != nil {
+			log.Printf("Plugin %s template %s rejected: %v", pluginName, templateID, err)
+			continue
+		}
 		log.Printf("Registered template from plugin %s: %s", pluginName, templateID)
 	}
 
 	// Store plugin
 	pm.plugins[pluginName] = pluginInstance
-	pm.loadedPlugins[pluginName] = plug
+	if path != "" {
+		pm.pluginFiles[pluginName] = path
+	}
+	if scriptPlugin, ok := pluginInstance.(*ScriptPlugin); ok {
+		pm.pluginInfo[pluginName] = scriptPlugin.Info()
+	} else {
+		pm.pluginInfo[pluginName] = &PluginInfo{
+			Name:         pluginInstance.GetName(),
+			Version:      pluginInstance.GetVersion(),
+			Description:  pluginInstance.GetDescription(),
+			Author:       pluginInstance.GetAuthor(),
+			Dependencies: deps,
+			Enabled:      true,
+		}
+	}
 
 	log.Printf("Loaded plugin: %s v%s", pluginInstance.GetName(), pluginInstance.GetVersion())
 	return nil
 }
 
+// RegisterPlugin installs a plugin implemented in Go and built into the game
+func (pm *PluginManager) RegisterPlugin(pluginInstance Plugin) error {
+	return pm.installPlugin(pluginInstance.GetName(), "", pluginInstance)
+}
+
 // UnloadPlugin unloads a plugin by name
 func (pm *PluginManager) UnloadPlugin(pluginName string) error {
 	pm.mutex.Lock()
@@ -164,26 +267,53 @@ func (pm *PluginManager) UnloadPlugin(pluginName string) error {
 		log.Printf("Warning: plugin %s shutdown failed: %v", pluginName, err)
 	}
 
-	// Remove plugin systems
+	// Remove plugin systems and templates
 	systems := plugin.GetSystems()
 	for _, system := range systems {
 		pm.systemManager.UnregisterSystem(system.GetName())
 	}
+	for templateID := range plugin.GetTemplates() {
+		pm.entityManager.RemoveTemplate(templateID)
+	}
 
 	// Remove plugin
 	delete(pm.plugins, pluginName)
-	delete(pm.loadedPlugins, pluginName)
+	delete(pm.pluginInfo, pluginName)
+	delete(pm.pluginFiles, pluginName)
+	pm.removeSandbox(pluginName)
 
 	log.Printf("Unloaded plugin: %s", pluginName)
 	return nil
 }
 
-// ReloadPlugin reloads a plugin
+// ReloadPlugin reloads a plugin from its script. The new version is compiled
+// before the old one is unloaded, so a script with errors leaves the running
+// version in place.
 func (pm *PluginManager) ReloadPlugin(pluginName string) error {
+	pm.mutex.RLock()
+	path, isScript := pm.pluginFiles[pluginName]
+	if !isScript {
+		path = pm.resolvePluginFile(pluginName)
+	}
+	pm.mutex.RUnlock()
+
+	replacement, err := LoadScriptPlugin(pluginName, path, pm.loadLimits())
+	if err != nil {
+		return fmt.Errorf("failed to reload plugin %s, keeping the loaded version: %v", pluginName, err)
+	}
+
 	if err := pm.UnloadPlugin(pluginName); err != nil {
 		return err
 	}
-	return pm.LoadPlugin(pluginName)
+	return pm.installPlugin(pluginName, path, replacement)
+}
+
+// PluginFile returns the script a plugin was loaded from
+func (pm *PluginManager) PluginFile(pluginName string) (string, bool) {
+	pm.mutex.RLock()
+	defer pm.mutex.RUnlock()
+	path, exists := pm.pluginFiles[pluginName]
+	return path, exists
 }
 
 // GetPlugin returns a plugin by name
@@ -226,11 +356,49 @@ func (pm *PluginManager) IsLoaded(pluginName string) bool {
 	return exists
 }
 
-// LoadAllPlugins loads all plugins from the plugin directory
+// LoadAllPlugins loads every script plugin in the plugin directory. Plugins
+// whose dependencies load later in the listing are retried until no more
+// progress is made.
 func (pm *PluginManager) LoadAllPlugins() error {
-	// This would scan the plugin directory and load all .so files
-	// For now, we'll just log that this would be implemented
-	log.Printf("Plugin loading from directory %s would be implemented here", pm.pluginPath)
+	pm.mutex.RLock()
+	dir := pm.pluginPath
+	pm.mutex.RUnlock()
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read plugin directory %s: %w", dir, err)
+	}
+
+	var pending []string
+	for _, entry := range entries {
+		if !entry.IsDir() && IsScriptPluginFile(entry.Name()) {
+			pending = append(pending, filepath.Join(dir, entry.Name()))
+		}
+	}
+	sort.Strings(pending)
+
+	var failures []string
+	for len(pending) > 0 {
+		var retry []string
+		failures = failures[:0]
+		for _, path := range pending {
+			if err := pm.LoadPlugin(path); err != nil {
+				retry = append(retry, path)
+				failures = append(failures, fmt.Sprintf("%s: %v", ScriptPluginName(path), err))
+			}
+		}
+		if len(retry) == len(pending) {
+			break
+		}
+		pending = retry
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("failed to load %d plugins: %s", len(failures), strings.Join(failures, "; "))
+	}
 	return nil
 }
 
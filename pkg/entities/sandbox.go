@@ -0,0 +1,406 @@
+package entities
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"tesselbox/pkg/script"
+)
+
+// ============================================================================
+// Plugin Sandbox
+// ============================================================================
+
+// Sandbox limits reported in PluginViolation
+const (
+	ViolationTimeout    = "timeout"
+	ViolationCPU        = "cpu"
+	ViolationMemory     = "memory"
+	ViolationSteps      = "steps"
+	ViolationFilesystem = "filesystem"
+)
+
+// cpuWindow is the period over which a plugin's maxCPU share is measured
+const cpuWindow = time.Second
+
+// PluginViolation describes a plugin breaking one of its sandbox limits
+type PluginViolation struct {
+	Plugin string `json:"plugin"`
+	Limit  string `json:"limit"`
+	Detail string `json:"detail"`
+}
+
+func (v PluginViolation) String() string {
+	return fmt.Sprintf("plugin %s exceeded its %s limit: %s", v.Plugin, v.Limit, v.Detail)
+}
+
+// PluginSandbox enforces the security settings from plugins.yaml on one
+// plugin. Every plugin gets its own sandbox, so each has its own execution
+// budget: a handler call may take at most `timeout` seconds, and the plugin
+// may use at most `maxCPU` percent of each second. A plugin that exceeds a
+// limit is disabled; its handlers and systems are skipped from then on.
+type PluginSandbox struct {
+	pluginName  string
+	config      SecurityConfig
+	onViolation func(PluginViolation)
+
+	mutex       sync.Mutex
+	windowStart time.Time
+	windowUsed  time.Duration
+	disabled    bool
+}
+
+// NewPluginSandbox creates a sandbox for a plugin. onViolation is called once,
+// when the plugin is disabled for breaking a limit.
+func NewPluginSandbox(pluginName string, config SecurityConfig, onViolation func(PluginViolation)) *PluginSandbox {
+	return &PluginSandbox{
+		pluginName:  pluginName,
+		config:      config,
+		onViolation: onViolation,
+	}
+}
+
+// SetConfig replaces the security settings the sandbox enforces
+func (ps *PluginSandbox) SetConfig(config SecurityConfig) {
+	ps.mutex.Lock()
+	defer ps.mutex.Unlock()
+	ps.config = config
+}
+
+// Config returns the security settings the sandbox enforces
+func (ps *PluginSandbox) Config() SecurityConfig {
+	ps.mutex.Lock()
+	defer ps.mutex.Unlock()
+	return ps.config
+}
+
+// IsDisabled reports whether the plugin has been disabled
+func (ps *PluginSandbox) IsDisabled() bool {
+	ps.mutex.Lock()
+	defer ps.mutex.Unlock()
+	return ps.disabled
+}
+
+// SetDisabled disables or re-enables the plugin. Re-enabling starts a fresh
+// CPU budget.
+func (ps *PluginSandbox) SetDisabled(disabled bool) {
+	ps.mutex.Lock()
+	defer ps.mutex.Unlock()
+	ps.disabled = disabled
+	ps.windowStart = time.Time{}
+	ps.windowUsed = 0
+}
+
+// timeout returns the per-call time limit, or 0 when calls are unbounded
+func (ps *PluginSandbox) timeout() time.Duration {
+	if !ps.config.SandboxEnabled || ps.config.Timeout <= 0 {
+		return 0
+	}
+	return time.Duration(ps.config.Timeout) * time.Second
+}
+
+// cpuBudget returns the execution time allowed per window, or 0 when it is
+// unbounded
+func (ps *PluginSandbox) cpuBudget() time.Duration {
+	if !ps.config.SandboxEnabled || ps.config.MaxCPU <= 0 || ps.config.MaxCPU >= 100 {
+		return 0
+	}
+	return cpuWindow * time.Duration(ps.config.MaxCPU) / 100
+}
+
+// remaining returns how much of the current window's budget is left. The
+// caller holds ps.mutex.
+func (ps *PluginSandbox) remaining(now time.Time) time.Duration {
+	if now.Sub(ps.windowStart) >= cpuWindow {
+		ps.windowStart = now
+		ps.windowUsed = 0
+	}
+	return ps.cpuBudget() - ps.windowUsed
+}
+
+// ScriptLimits returns the interpreter limits for the next script call: the
+// handler timeout or what is left of the CPU budget, whichever is shorter,
+// and the memory limit
+func (ps *PluginSandbox) ScriptLimits() script.Limits {
+	ps.mutex.Lock()
+	defer ps.mutex.Unlock()
+
+	if !ps.config.SandboxEnabled {
+		return script.Limits{}
+	}
+	limits := script.Limits{
+		Timeout: ps.timeout(),
+		Memory:  ps.config.MaxMemory,
+	}
+	if ps.cpuBudget() > 0 {
+		left := ps.remaining(time.Now())
+		if left < time.Millisecond {
+			left = time.Millisecond
+		}
+		if limits.Timeout == 0 || left < limits.Timeout {
+			limits.Timeout = left
+		}
+	}
+	return limits
+}
+
+// Run calls fn within the plugin's limits. It is skipped once the plugin is
+// disabled. fn always runs to completion on the caller's goroutine: scripts
+// stop themselves when they overrun the timeout, since the interpreter
+// enforces it (see ScriptLimits), while Go code cannot be stopped, so a call
+// that returns late disables the plugin afterwards.
+func (ps *PluginSandbox) Run(label string, fn func()) error {
+	ps.mutex.Lock()
+	disabled := ps.disabled
+	timeout := ps.timeout()
+	ps.mutex.Unlock()
+
+	if disabled {
+		return fmt.Errorf("plugin %s is disabled", ps.pluginName)
+	}
+
+	start := time.Now()
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("Plugin %s %s panicked: %v", ps.pluginName, label, r)
+			}
+		}()
+		fn()
+	}()
+	elapsed := time.Since(start)
+
+	if timeout > 0 && elapsed > timeout {
+		detail := fmt.Sprintf("%s ran for %v, longer than %v", label, elapsed.Round(time.Millisecond), timeout)
+		ps.Violate(ViolationTimeout, detail)
+		return fmt.Errorf("plugin %s %s", ps.pluginName, detail)
+	}
+	return ps.Charge(label, elapsed)
+}
+
+// Charge adds execution time to the plugin's CPU budget and disables the
+// plugin when the budget for the current window is spent
+func (ps *PluginSandbox) Charge(label string, elapsed time.Duration) error {
+	ps.mutex.Lock()
+	budget := ps.cpuBudget()
+	exceeded := false
+	if budget > 0 && !ps.disabled {
+		ps.remaining(time.Now().Add(-elapsed))
+		ps.windowUsed += elapsed
+		exceeded = ps.windowUsed > budget
+	}
+	used := ps.windowUsed
+	ps.mutex.Unlock()
+
+	if exceeded {
+		detail := fmt.Sprintf("used %v of its %v per %v budget (last: %s)", used.Round(time.Millisecond), budget, cpuWindow, label)
+		ps.Violate(ViolationCPU, detail)
+		return fmt.Errorf("plugin %s %s", ps.pluginName, detail)
+	}
+	return nil
+}
+
+// Violate records a broken limit. With the sandbox enabled the plugin is
+// disabled and the violation reported; otherwise it is only logged.
+func (ps *PluginSandbox) Violate(limit, detail string) {
+	violation := PluginViolation{Plugin: ps.pluginName, Limit: limit, Detail: detail}
+
+	ps.mutex.Lock()
+	enforce := ps.config.SandboxEnabled && !ps.disabled
+	if enforce {
+		ps.disabled = true
+	}
+	ps.mutex.Unlock()
+
+	if !enforce {
+		log.Printf("Warning: %s", violation)
+		return
+	}
+	if ps.onViolation != nil {
+		ps.onViolation(violation)
+	}
+}
+
+// ViolateScriptLimit reports a script that ran out of its interpreter budget
+func (ps *PluginSandbox) ViolateScriptLimit(err *script.LimitError) {
+	ps.mutex.Lock()
+	timeout := ps.timeout()
+	ps.mutex.Unlock()
+
+	limit := ViolationSteps
+	switch err.Limit {
+	case script.LimitTimeout:
+		// The timeout was shortened to what was left of the CPU budget
+		limit = ViolationTimeout
+		if timeout == 0 || time.Duration(err.Max) < timeout {
+			limit = ViolationCPU
+		}
+	case script.LimitMemory:
+		limit = ViolationMemory
+	}
+	ps.Violate(limit, err.Error())
+}
+
+// ============================================================================
+// Plugin Filesystem
+// ============================================================================
+
+// PluginFS is the only file access plugins get. Paths are resolved relative to
+// the game directory and must lie inside one of the allowedPaths and outside
+// every blockedPath of the security settings.
+type PluginFS struct {
+	sandbox *PluginSandbox
+}
+
+// NewPluginFS creates a filesystem facade checked against a plugin's sandbox
+func NewPluginFS(sandbox *PluginSandbox) *PluginFS {
+	return &PluginFS{sandbox: sandbox}
+}
+
+// resolve checks a path against the security settings and returns its
+// absolute form. Denied paths are reported as violations.
+func (pfs *PluginFS) resolve(path string) (string, error) {
+	config := pfs.sandbox.Config()
+	resolved, err := config.CheckPath(path)
+	if err != nil {
+		pfs.sandbox.Violate(ViolationFilesystem, err.Error())
+		return "", err
+	}
+	return resolved, nil
+}
+
+// ReadFile reads a file
+func (pfs *PluginFS) ReadFile(path string) ([]byte, error) {
+	resolved, err := pfs.resolve(path)
+	if err != nil {
+		return nil, err
+	}
+	config := pfs.sandbox.Config()
+	if config.SandboxEnabled && config.MaxMemory > 0 {
+		if info, err := os.Stat(resolved); err == nil && info.Size() > config.MaxMemory {
+			return nil, fmt.Errorf("file %s is larger than the plugin memory limit", path)
+		}
+	}
+	return os.ReadFile(resolved)
+}
+
+// WriteFile writes a file, creating its directory if needed
+func (pfs *PluginFS) WriteFile(path string, data []byte) error {
+	resolved, err := pfs.resolve(path)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(resolved), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", path, err)
+	}
+	return os.WriteFile(resolved, data, 0644)
+}
+
+// ReadDir lists the names in a directory, sorted
+func (pfs *PluginFS) ReadDir(path string) ([]string, error) {
+	resolved, err := pfs.resolve(path)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(resolved)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Exists reports whether a path exists
+func (pfs *PluginFS) Exists(path string) (bool, error) {
+	resolved, err := pfs.resolve(path)
+	if err != nil {
+		return false, err
+	}
+	_, err = os.Stat(resolved)
+	return err == nil, nil
+}
+
+// CheckPath resolves a path and checks it against the allowed and blocked
+// paths. Both the path and the roots are made absolute and symlinks are
+// followed, so "plugins/../../etc" does not pass as a path under plugins.
+// Paths are denied unless they lie under an allowed path, so with no allowed
+// paths nothing is reachable.
+func (sc SecurityConfig) CheckPath(path string) (string, error) {
+	if path == "" {
+		return "", fmt.Errorf("empty path")
+	}
+	resolved, err := resolvePath(path)
+	if err != nil {
+		return "", fmt.Errorf("invalid path %s: %v", path, err)
+	}
+
+	for _, blocked := range sc.BlockedPaths {
+		if root, err := resolvePath(blocked); err == nil && pathWithin(resolved, root) {
+			return "", fmt.Errorf("path %s is blocked", path)
+		}
+	}
+
+	for _, allowed := range sc.AllowedPaths {
+		if root, err := resolvePath(allowed); err == nil && pathWithin(resolved, root) {
+			return resolved, nil
+		}
+	}
+	return "", fmt.Errorf("path %s is outside the allowed paths", path)
+}
+
+// resolvePath makes a path absolute and follows symlinks in the longest part
+// of it that exists
+func resolvePath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	existing, rest := abs, ""
+	for {
+		if real, err := filepath.EvalSymlinks(existing); err == nil {
+			return filepath.Join(real, rest), nil
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			return abs, nil
+		}
+		rest = filepath.Join(filepath.Base(existing), rest)
+		existing = parent
+	}
+}
+
+// pathWithin reports whether path is root or inside it
+func pathWithin(path, root string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
+
+// ============================================================================
+// Sandboxed Systems
+// ============================================================================
+
+// sandboxedSystem runs a plugin system inside the plugin's sandbox
+type sandboxedSystem struct {
+	System
+	sandbox *PluginSandbox
+}
+
+// Process runs the wrapped system unless the plugin has been disabled
+func (ss *sandboxedSystem) Process(deltaTime float64, entities []*Entity) {
+	ss.sandbox.Run("system "+ss.GetName(), func() {
+		ss.System.Process(deltaTime, entities)
+	})
+}
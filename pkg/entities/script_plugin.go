@@ -0,0 +1,568 @@
+package entities
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"tesselbox/pkg/script"
+)
+
+// ScriptExtension is the file extension of script plugins
+const ScriptExtension = ".lua"
+
+// ScriptPlugin is a plugin written in the embedded scripting language.
+//
+// The script's top level declares a global `plugin` table with its metadata
+// (version, description, author, dependencies, permissions) and defines
+// on_load and optionally on_unload. Game functions are reached through the
+// global `api` table from on_load and from event handlers:
+//
+//	api.log(level, message)
+//	api.create_entity(templateID)
+//	api.subscribe(eventType, function(event) ... end)
+//	api.intercept(eventType, function(event) ... end, priority)
+//	api.register_template{id = ..., type = ..., components = {...}}
+//	api.fs.read(path), api.fs.write(path, data), api.fs.list(path), api.fs.exists(path)
+//
+// Handlers registered with intercept run before the action happens. They
+// cancel it by returning false or setting event.cancelled, and modify it by
+// changing fields of event.data.
+//
+// Everything a script registers is released when it is shut down, so a
+// script plugin can be unloaded and reloaded at any time. Each call into the
+// script runs within the plugin sandbox's time and memory limits.
+type ScriptPlugin struct {
+	path        string
+	info        PluginInfo
+	permissions []string
+
+	mutex  sync.Mutex
+	interp *script.Interpreter
+	api    *PluginAPI
+	loaded bool
+}
+
+// ScriptPluginName returns the plugin name for a script file: its file name
+// without the extension
+func ScriptPluginName(path string) string {
+	return strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+}
+
+// IsScriptPluginFile reports whether a path is a script plugin
+func IsScriptPluginFile(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ScriptExtension)
+}
+
+// Budget of a script's top level, which only declares metadata and functions.
+// It applies even with the sandbox disabled, so a script that loops forever
+// while loading cannot hang the game.
+const (
+	maxLoadSteps = 1000000
+	maxLoadTime  = 5 * time.Second
+)
+
+// LoadLimits returns the interpreter limits for running a script's top level:
+// the sandbox's limits, capped at the load budget
+func LoadLimits(security SecurityConfig) script.Limits {
+	limits := NewPluginSandbox("", security, nil).ScriptLimits()
+	if limits.Steps == 0 || limits.Steps > maxLoadSteps {
+		limits.Steps = maxLoadSteps
+	}
+	if limits.Timeout == 0 || limits.Timeout > maxLoadTime {
+		limits.Timeout = maxLoadTime
+	}
+	return limits
+}
+
+// LoadScriptPlugin compiles a script plugin and runs its top level, which
+// declares metadata and functions, within limits (see LoadLimits). It does
+// not call on_load; that happens in Initialize once the plugin is registered.
+func LoadScriptPlugin(name, path string, limits script.Limits) (*ScriptPlugin, error) {
+	chunk, err := script.CompileFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	sp := &ScriptPlugin{
+		path: path,
+		info: PluginInfo{
+			Name:    name,
+			Version: "0.0.0",
+			Enabled: true,
+		},
+		interp: script.New(),
+	}
+	sp.bindAPI()
+
+	sp.interp.SetLimits(limits)
+	if _, err := sp.interp.Run(chunk); err != nil {
+		return nil, fmt.Errorf("failed to run script plugin %s: %w", name, err)
+	}
+	if err := sp.readInfo(); err != nil {
+		return nil, fmt.Errorf("invalid plugin table in %s: %w", path, err)
+	}
+	return sp, nil
+}
+
+// readInfo copies the script's `plugin` table into the plugin info
+func (sp *ScriptPlugin) readInfo() error {
+	value := sp.interp.Global("plugin")
+	if value == nil {
+		return nil
+	}
+	table, ok := value.(*script.Table)
+	if !ok {
+		return fmt.Errorf("plugin must be a table")
+	}
+
+	var declared struct {
+		Version      string   `yaml:"version"`
+		Description  string   `yaml:"description"`
+		Author       string   `yaml:"author"`
+		Website      string   `yaml:"website"`
+		License      string   `yaml:"license"`
+		Dependencies []string `yaml:"dependencies"`
+		Permissions  []string `yaml:"permissions"`
+		MinVersion   string   `yaml:"minVersion"`
+		MaxVersion   string   `yaml:"maxVersion"`
+	}
+	if err := convertViaYAML(script.ToGo(table), &declared); err != nil {
+		return err
+	}
+
+	if declared.Version != "" {
+		sp.info.Version = declared.Version
+	}
+	sp.info.Description = declared.Description
+	sp.info.Author = declared.Author
+	sp.info.Website = declared.Website
+	sp.info.License = declared.License
+	sp.info.Dependencies = declared.Dependencies
+	sp.info.MinVersion = declared.MinVersion
+	sp.info.MaxVersion = declared.MaxVersion
+	sp.permissions = declared.Permissions
+	return nil
+}
+
+// Path returns the script file the plugin was loaded from
+func (sp *ScriptPlugin) Path() string {
+	return sp.path
+}
+
+// Info returns the plugin's metadata
+func (sp *ScriptPlugin) Info() *PluginInfo {
+	info := sp.info
+	return &info
+}
+
+// Permissions returns the permissions the plugin will be granted
+func (sp *ScriptPlugin) Permissions() []string {
+	return sp.permissions
+}
+
+// SetPermissions replaces the permissions declared by the script, for
+// managers that take them from plugin configuration instead
+func (sp *ScriptPlugin) SetPermissions(permissions []string) {
+	sp.permissions = permissions
+}
+
+func (sp *ScriptPlugin) GetName() string           { return sp.info.Name }
+func (sp *ScriptPlugin) GetVersion() string        { return sp.info.Version }
+func (sp *ScriptPlugin) GetDescription() string    { return sp.info.Description }
+func (sp *ScriptPlugin) GetAuthor() string         { return sp.info.Author }
+func (sp *ScriptPlugin) GetDependencies() []string { return sp.info.Dependencies }
+
+// Script plugins register templates and handlers through the API instead
+func (sp *ScriptPlugin) GetComponents() []Component               { return nil }
+func (sp *ScriptPlugin) GetSystems() []System                     { return nil }
+func (sp *ScriptPlugin) GetTemplates() map[string]*EntityTemplate { return nil }
+
+// Initialize connects the script to the game and calls its on_load function
+func (sp *ScriptPlugin) Initialize(manager *PluginManager) error {
+	sp.mutex.Lock()
+	defer sp.mutex.Unlock()
+
+	if sp.loaded {
+		return fmt.Errorf("plugin %s is already initialized", sp.info.Name)
+	}
+
+	sp.api = NewPluginAPI(manager, sp.info.Name)
+	for _, permission := range sp.permissions {
+		sp.api.GrantPermission(permission)
+	}
+	sp.loaded = true
+
+	if err := sp.callHook("on_load"); err != nil {
+		sp.api.Release()
+		sp.api = nil
+		sp.loaded = false
+		return err
+	}
+	return nil
+}
+
+// Shutdown calls the script's on_unload function and releases everything it
+// registered
+func (sp *ScriptPlugin) Shutdown() error {
+	sp.mutex.Lock()
+	defer sp.mutex.Unlock()
+
+	if !sp.loaded {
+		return fmt.Errorf("plugin %s is not initialized", sp.info.Name)
+	}
+
+	err := sp.callHook("on_unload")
+	sp.api.Release()
+	sp.api = nil
+	sp.loaded = false
+	return err
+}
+
+// callHook calls a global script function if the script defines it. The
+// caller holds sp.mutex.
+func (sp *ScriptPlugin) callHook(name string) error {
+	fn := sp.interp.Global(name)
+	if fn == nil {
+		return nil
+	}
+	if _, err := sp.call(fn); err != nil {
+		return fmt.Errorf("plugin %s %s failed: %w", sp.info.Name, name, err)
+	}
+	return nil
+}
+
+// call runs a script function within the sandbox limits. A script that runs
+// out of budget is reported to the sandbox, which disables the plugin. The
+// caller holds sp.mutex.
+func (sp *ScriptPlugin) call(fn script.Value, args ...script.Value) ([]script.Value, error) {
+	sandbox := sp.api.Sandbox()
+	sp.interp.SetLimits(sandbox.ScriptLimits())
+	results, err := sp.interp.Call(fn, args...)
+
+	var limitErr *script.LimitError
+	if errors.As(err, &limitErr) {
+		sandbox.ViolateScriptLimit(limitErr)
+	}
+	return results, err
+}
+
+// bindAPI installs the `api` table and routes print to the plugin log
+func (sp *ScriptPlugin) bindAPI() {
+	api := script.NewTable()
+
+	api.SetString("log", script.NewBuiltin("api.log", func(args []script.Value) ([]script.Value, error) {
+		level := "info"
+		message := args
+		if len(args) > 1 {
+			level = script.ToString(args[0])
+			message = args[1:]
+		}
+		sp.log(level, script.JoinValues(message))
+		return nil, nil
+	}))
+
+	api.SetString("create_entity", script.NewBuiltin("api.create_entity", func(args []script.Value) ([]script.Value, error) {
+		pluginAPI, err := sp.requireAPI()
+		if err != nil {
+			return nil, err
+		}
+		templateID, ok := argAt(args, 0).(string)
+		if !ok {
+			return nil, fmt.Errorf("template ID must be a string")
+		}
+		entity, err := pluginAPI.CreateEntity(templateID)
+		if err != nil {
+			return nil, err
+		}
+		return []script.Value{entityToValue(entity)}, nil
+	}))
+
+	api.SetString("subscribe", script.NewBuiltin("api.subscribe", func(args []script.Value) ([]script.Value, error) {
+		pluginAPI, err := sp.requireAPI()
+		if err != nil {
+			return nil, err
+		}
+		eventType, ok := argAt(args, 0).(string)
+		if !ok {
+			return nil, fmt.Errorf("event type must be a string")
+		}
+		handler := argAt(args, 1)
+		switch handler.(type) {
+		case *script.Function, *script.Builtin:
+		default:
+			return nil, fmt.Errorf("event handler must be a function")
+		}
+		return nil, pluginAPI.SubscribeToEvent(eventType, func(event Event) {
+			sp.handleEvent(eventType, handler, event)
+		})
+	}))
+
+	api.SetString("intercept", script.NewBuiltin("api.intercept", func(args []script.Value) ([]script.Value, error) {
+		pluginAPI, err := sp.requireAPI()
+		if err != nil {
+			return nil, err
+		}
+		eventType, ok := argAt(args, 0).(string)
+		if !ok {
+			return nil, fmt.Errorf("event type must be a string")
+		}
+		handler := argAt(args, 1)
+		switch handler.(type) {
+		case *script.Function, *script.Builtin:
+		default:
+			return nil, fmt.Errorf("event handler must be a function")
+		}
+		priority := 0
+		if p, ok := argAt(args, 2).(float64); ok {
+			priority = int(p)
+		}
+		return nil, pluginAPI.SubscribeToPreEvent(eventType, priority, func(event *Event) {
+			sp.handlePreEvent(eventType, handler, event)
+		})
+	}))
+
+	api.SetString("register_template", script.NewBuiltin("api.register_template", func(args []script.Value) ([]script.Value, error) {
+		pluginAPI, err := sp.requireAPI()
+		if err != nil {
+			return nil, err
+		}
+		template, err := templateFromValue(argAt(args, 0))
+		if err != nil {
+			return nil, err
+		}
+		return nil, pluginAPI.RegisterTemplate(template)
+	}))
+
+	api.SetString("register_biomes", script.NewBuiltin("api.register_biomes", func(args []script.Value) ([]script.Value, error) {
+		pluginAPI, err := sp.requireAPI()
+		if err != nil {
+			return nil, err
+		}
+		data, ok := argAt(args, 0).(string)
+		if !ok {
+			return nil, fmt.Errorf("biomes must be a YAML string")
+		}
+		return nil, pluginAPI.RegisterBiomes([]byte(data))
+	}))
+
+	api.SetString("fs", sp.fsTable())
+
+	sp.interp.SetGlobal("api", api)
+	sp.interp.Register("print", func(args []script.Value) ([]script.Value, error) {
+		sp.log("info", script.JoinValues(args))
+		return nil, nil
+	})
+}
+
+// fsTable builds api.fs, the script side of the sandboxed filesystem
+func (sp *ScriptPlugin) fsTable() *script.Table {
+	fs := script.NewTable()
+
+	fs.SetString("read", script.NewBuiltin("api.fs.read", func(args []script.Value) ([]script.Value, error) {
+		pluginAPI, path, err := sp.fsArgs(args)
+		if err != nil {
+			return nil, err
+		}
+		data, err := pluginAPI.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return []script.Value{string(data)}, nil
+	}))
+
+	fs.SetString("write", script.NewBuiltin("api.fs.write", func(args []script.Value) ([]script.Value, error) {
+		pluginAPI, path, err := sp.fsArgs(args)
+		if err != nil {
+			return nil, err
+		}
+		data, ok := argAt(args, 1).(string)
+		if !ok {
+			return nil, fmt.Errorf("data must be a string")
+		}
+		return nil, pluginAPI.WriteFile(path, []byte(data))
+	}))
+
+	fs.SetString("list", script.NewBuiltin("api.fs.list", func(args []script.Value) ([]script.Value, error) {
+		pluginAPI, path, err := sp.fsArgs(args)
+		if err != nil {
+			return nil, err
+		}
+		names, err := pluginAPI.ListFiles(path)
+		if err != nil {
+			return nil, err
+		}
+		return []script.Value{script.ToValue(names)}, nil
+	}))
+
+	fs.SetString("exists", script.NewBuiltin("api.fs.exists", func(args []script.Value) ([]script.Value, error) {
+		pluginAPI, path, err := sp.fsArgs(args)
+		if err != nil {
+			return nil, err
+		}
+		exists, err := pluginAPI.FileExists(path)
+		if err != nil {
+			return nil, err
+		}
+		return []script.Value{exists}, nil
+	}))
+
+	return fs
+}
+
+// fsArgs returns the plugin API and the path argument of an api.fs call
+func (sp *ScriptPlugin) fsArgs(args []script.Value) (*PluginAPI, string, error) {
+	pluginAPI, err := sp.requireAPI()
+	if err != nil {
+		return nil, "", err
+	}
+	path, ok := argAt(args, 0).(string)
+	if !ok {
+		return nil, "", fmt.Errorf("path must be a string")
+	}
+	return pluginAPI, path, nil
+}
+
+// requireAPI returns the plugin API, which only exists between Initialize
+// and Shutdown
+func (sp *ScriptPlugin) requireAPI() (*PluginAPI, error) {
+	if sp.api == nil {
+		return nil, fmt.Errorf("the plugin API is only available from on_load and event handlers")
+	}
+	return sp.api, nil
+}
+
+// log writes a message through the plugin API, or directly while the
+// script's top level runs
+func (sp *ScriptPlugin) log(level, message string) {
+	if sp.api != nil {
+		sp.api.Log(level, message)
+		return
+	}
+	log.Printf("[%s] %s: %s", strings.ToUpper(level), sp.info.Name, message)
+}
+
+// handleEvent runs a script event handler. Handlers may arrive from several
+// goroutines, so calls into the interpreter are serialized.
+func (sp *ScriptPlugin) handleEvent(eventType string, handler script.Value, event Event) {
+	sp.mutex.Lock()
+	defer sp.mutex.Unlock()
+
+	if !sp.loaded {
+		return
+	}
+	if _, err := sp.call(handler, eventToValue(event)); err != nil {
+		sp.log("error", fmt.Sprintf("handler for %s failed: %v", eventType, err))
+	}
+}
+
+// handlePreEvent runs a script intercept handler and applies what it changed
+// to the event
+func (sp *ScriptPlugin) handlePreEvent(eventType string, handler script.Value, event *Event) {
+	sp.mutex.Lock()
+	defer sp.mutex.Unlock()
+
+	if !sp.loaded {
+		return
+	}
+	value := eventToValue(*event).(*script.Table)
+	results, err := sp.call(handler, value)
+	if err != nil {
+		sp.log("error", fmt.Sprintf("intercept handler for %s failed: %v", eventType, err))
+		return
+	}
+
+	if len(results) > 0 && results[0] == false {
+		event.Cancelled = true
+	}
+	if cancelled, ok := value.GetString("cancelled").(bool); ok && cancelled {
+		event.Cancelled = true
+	}
+	if err := updateEventData(event, value.GetString("data")); err != nil {
+		sp.log("error", fmt.Sprintf("intercept handler for %s returned invalid data: %v", eventType, err))
+	}
+}
+
+// argAt returns an argument or nil when it was not passed
+func argAt(args []script.Value, i int) script.Value {
+	if i < len(args) {
+		return args[i]
+	}
+	return nil
+}
+
+// eventToValue converts an event to the table passed to script handlers
+func eventToValue(event Event) script.Value {
+	table := script.NewTable()
+	table.SetString("type", string(event.Type))
+	table.SetString("source", event.Source)
+	table.SetString("priority", float64(event.Priority))
+	table.SetString("cancelled", event.Cancelled)
+	table.SetString("timestamp", float64(event.Timestamp.UnixNano())/1e9)
+	table.SetString("data", script.ToValue(event.Data))
+	return table
+}
+
+// updateEventData copies a script's edits of event.data back into the
+// event's data, which the pre phase passes as a pointer to a struct
+func updateEventData(event *Event, data script.Value) error {
+	if _, ok := data.(*script.Table); !ok || event.Data == nil {
+		return nil
+	}
+	if reflect.ValueOf(event.Data).Kind() != reflect.Ptr {
+		return nil
+	}
+	encoded, err := json.Marshal(script.ToGo(data))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(encoded, event.Data)
+}
+
+// entityToValue converts an entity to a script table
+func entityToValue(entity *Entity) script.Value {
+	table := script.NewTable()
+	table.SetString("id", entity.ID)
+	table.SetString("type", entity.Type)
+	table.SetString("name", entity.Name)
+	table.SetString("tags", script.ToValue(entity.Tags))
+	components := script.NewTable()
+	for componentType := range entity.Components {
+		components.Append(componentType)
+	}
+	table.SetString("components", components)
+	return table
+}
+
+// templateFromValue converts a script table to an entity template
+func templateFromValue(value script.Value) (*EntityTemplate, error) {
+	table, ok := value.(*script.Table)
+	if !ok {
+		return nil, fmt.Errorf("template must be a table")
+	}
+	var template EntityTemplate
+	if err := convertViaYAML(script.ToGo(table), &template); err != nil {
+		return nil, fmt.Errorf("invalid template: %v", err)
+	}
+	if template.ID == "" {
+		return nil, fmt.Errorf("template needs an id")
+	}
+	return &template, nil
+}
+
+// convertViaYAML decodes generic data into a struct using its yaml tags
+func convertViaYAML(data interface{}, out interface{}) error {
+	encoded, err := yaml.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return yaml.Unmarshal(encoded, out)
+}
@@ -5,6 +5,9 @@ package equipment
 import (
 	"fmt"
 	"image/color"
+
+	"tesselbox/pkg/enchant"
+	"tesselbox/pkg/items"
 )
 
 // Ensure color package is properly imported
@@ -82,6 +85,9 @@ type EquipmentItem struct {
 	SetName        string // Name of the armor set
 	SetPieces      int    // Number of pieces needed for bonus
 	SetBonusActive bool   // Whether set bonus is currently active
+
+	// Enchantments and other per-item data, as on inventory items
+	Meta *items.Metadata
 }
 
 // EquipmentSet manages all equipped items
@@ -136,12 +142,13 @@ func (es *EquipmentSet) GetItem(slot EquipmentSlot) *EquipmentItem {
 	return es.Slots[slot]
 }
 
-// GetTotalDefense calculates total defense from all equipped armor
+// GetTotalDefense calculates total defense from all equipped armor,
+// including what its enchantments add
 func (es *EquipmentSet) GetTotalDefense() float64 {
 	total := 0.0
 	for _, item := range es.Slots {
 		if item != nil && item.Slot != SlotWings {
-			total += item.BaseDefense
+			total += item.BaseDefense + enchant.Bonus(item.Meta, enchant.StatDefense)
 		}
 	}
 	return total
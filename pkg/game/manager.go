@@ -9,12 +9,12 @@ import (
 	"tesselbox/pkg/combat"
 	"tesselbox/pkg/crafting"
 	"tesselbox/pkg/debug"
-	"tesselbox/pkg/enemies"
 	"tesselbox/pkg/equipment"
 	"tesselbox/pkg/gametime"
 	"tesselbox/pkg/health"
 	"tesselbox/pkg/input"
 	"tesselbox/pkg/items"
+	"tesselbox/pkg/mobs"
 	"tesselbox/pkg/player"
 	"tesselbox/pkg/plugins"
 	"tesselbox/pkg/save"
@@ -37,7 +37,7 @@ type GameManager struct {
 
 	// Crafting
 	CraftingSystem *crafting.CraftingSystem
-	CraftingUI     *crafting.CraftingUI
+	CraftingUI     *ui.CraftingUI
 
 	// Plugins
 	PluginManager   *plugins.PluginManager
@@ -88,7 +88,7 @@ type GameManager struct {
 	DeathScreen       *ui.DeathScreen
 
 	// Enemy systems
-	ZombieSpawner *enemies.ZombieSpawner
+	ZombieSpawner *mobs.Spawner
 
 	// Game state
 	SelectedBlock string
@@ -197,7 +197,7 @@ func NewGameManager(worldName string, worldSeed int64, creativeMode bool) *GameM
 	if err := gm.CraftingSystem.LoadRecipesFromAssets(); err != nil {
 		log.Printf("Warning: Failed to load crafting recipes: %v", err)
 	}
-	gm.CraftingUI = crafting.NewCraftingUI(gm.CraftingSystem, gm.Inventory)
+	gm.CraftingUI = ui.NewCraftingUI(gm.CraftingSystem, gm.Inventory)
 
 	// Initialize input manager
 	gm.InputManager = input.NewInputManager()
@@ -263,7 +263,7 @@ func NewGameManager(worldName string, worldSeed int64, creativeMode bool) *GameM
 	gm.DeathScreen = ui.NewDeathScreen()
 
 	// Initialize enemy systems
-	gm.ZombieSpawner = enemies.NewZombieSpawner(gm.DayNightCycle)
+	gm.ZombieSpawner = mobs.NewSpawner(gm.DayNightCycle)
 
 	log.Printf("GameManager initialized successfully")
 	return gm
@@ -37,6 +37,7 @@ func DefaultInputConfig() *InputConfig {
 			"mine":       {Mouse: 0, IsMouse: true, Action: "mine"},
 			"place":      {Mouse: 1, IsMouse: true, Action: "place"},
 			"drop":       {Key: ebiten.KeyQ, Action: "drop"},
+			"throw":      {Key: ebiten.KeyG, Action: "throw"},
 			"inventory":  {Key: ebiten.KeyE, Action: "inventory"},
 			"crafting":   {Key: ebiten.KeyC, Action: "crafting"},
 			"hotbar_1":   {Key: ebiten.Key1, Action: "hotbar_1"},
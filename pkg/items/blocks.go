@@ -0,0 +1,35 @@
+package items
+
+import "tesselbox/pkg/blocks"
+
+// BlockDrop returns the item a mined block drops, or NONE
+func BlockDrop(blockType blocks.BlockType) ItemType {
+	switch blockType {
+	case blocks.DIRT:
+		return DIRT_BLOCK
+	case blocks.GRASS:
+		return GRASS_BLOCK
+	case blocks.STONE:
+		return STONE_BLOCK
+	case blocks.SAND:
+		return SAND_BLOCK
+	case blocks.LOG:
+		return LOG_BLOCK
+	case blocks.COAL_ORE:
+		return COAL
+	case blocks.IRON_ORE:
+		return IRON_INGOT // Could be iron ore, but using ingot for now
+	case blocks.GOLD_ORE:
+		return GOLD_INGOT
+	case blocks.DIAMOND_ORE:
+		return DIAMOND
+	default:
+		return NONE
+	}
+}
+
+// PlacesBlock reports whether an item places the given block
+func PlacesBlock(itemType ItemType, blockType blocks.BlockType) bool {
+	props := GetItemProperties(itemType)
+	return props != nil && props.IsPlaceable && props.BlockType == blocks.BlockID(blockType)
+}
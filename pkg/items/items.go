@@ -69,6 +69,18 @@ const (
 	DIAMOND_BOOTS
 	ANVIL
 	RANDOMLAND_PORTAL
+	// Ammunition
+	ARROW
+	// Knowledge
+	BOOK
+	// Food and drink
+	RAW_MEAT
+	COOKED_MEAT
+	BERRIES
+	MUSHROOM
+	ROASTED_MUSHROOM
+	BOTTLE
+	WATER_BOTTLE
 )
 
 // ItemProperties defines the properties of an item type
@@ -89,32 +101,62 @@ type ItemProperties struct {
 	WeaponRange  float64
 	WeaponSpeed  float64 // Attacks per second
 	WeaponType   string  // "melee", "ranged", "magic"
+	AmmoType     string  // Item ID a ranged weapon fires, e.g. "arrow"
+	ThrowDamage  float64 // Damage when thrown; 0 if it cannot be thrown
 	// Armor properties
 	IsArmor      bool
 	ArmorType    string // "helmet", "chestplate", "leggings", "boots"
 	ArmorDefense float64
+	// Fuel properties
+	FuelTime float64 // Seconds it burns in a furnace; 0 if it is not fuel
+	// Consumable properties
+	Nutrition  float64      // Hunger restored when eaten
+	Hydration  float64      // Thirst restored when drunk
+	Saturation float64      // Fullness used up before hunger starts to drop
+	Effects    []FoodEffect // Status effects applied when consumed
+	Leaves     string       // Item ID left behind once consumed, e.g. "bottle"
+}
+
+// FoodEffect is a status effect an item applies when it is consumed
+type FoodEffect struct {
+	Effect   string  `yaml:"effect"`   // Status effect name, e.g. "poison"
+	Duration float64 `yaml:"duration"` // Seconds it lasts
+	Strength float64 `yaml:"strength"`
+}
+
+// IsConsumable reports whether an item can be eaten or drunk
+func (p *ItemProperties) IsConsumable() bool {
+	return p.Nutrition > 0 || p.Hydration > 0 || len(p.Effects) > 0
 }
 
 // ItemJSON represents the YAML structure for item definitions
 type ItemJSON struct {
-	ID           string  `yaml:"id"`
-	Name         string  `yaml:"name"`
-	IconColor    []uint8 `yaml:"iconColor"`
-	Description  string  `yaml:"description"`
-	StackSize    int     `yaml:"stackSize"`
-	Durability   int     `yaml:"durability"`
-	IsTool       bool    `yaml:"isTool"`
-	ToolPower    float64 `yaml:"toolPower"`
-	IsPlaceable  bool    `yaml:"isPlaceable"`
-	BlockType    string  `yaml:"blockType"`
-	IsWeapon     bool    `yaml:"isWeapon"`
-	WeaponDamage float64 `yaml:"weaponDamage"`
-	WeaponRange  float64 `yaml:"weaponRange"`
-	WeaponSpeed  float64 `yaml:"weaponSpeed"`
-	WeaponType   string  `yaml:"weaponType"`
-	IsArmor      bool    `yaml:"isArmor"`
-	ArmorType    string  `yaml:"armorType"`
-	ArmorDefense float64 `yaml:"armorDefense"`
+	ID           string       `yaml:"id"`
+	Name         string       `yaml:"name"`
+	IconColor    []uint8      `yaml:"iconColor"`
+	Description  string       `yaml:"description"`
+	StackSize    int          `yaml:"stackSize"`
+	Durability   int          `yaml:"durability"`
+	IsTool       bool         `yaml:"isTool"`
+	ToolPower    float64      `yaml:"toolPower"`
+	IsPlaceable  bool         `yaml:"isPlaceable"`
+	BlockType    string       `yaml:"blockType"`
+	IsWeapon     bool         `yaml:"isWeapon"`
+	WeaponDamage float64      `yaml:"weaponDamage"`
+	WeaponRange  float64      `yaml:"weaponRange"`
+	WeaponSpeed  float64      `yaml:"weaponSpeed"`
+	WeaponType   string       `yaml:"weaponType"`
+	AmmoType     string       `yaml:"ammoType"`
+	ThrowDamage  float64      `yaml:"throwDamage"`
+	IsArmor      bool         `yaml:"isArmor"`
+	ArmorType    string       `yaml:"armorType"`
+	ArmorDefense float64      `yaml:"armorDefense"`
+	FuelTime     float64      `yaml:"fuelTime"`
+	Nutrition    float64      `yaml:"nutrition"`
+	Hydration    float64      `yaml:"hydration"`
+	Saturation   float64      `yaml:"saturation"`
+	Effects      []FoodEffect `yaml:"effects"`
+	Leaves       string       `yaml:"leaves"`
 }
 
 var ItemTypeMap = map[string]ItemType{
@@ -173,6 +215,15 @@ var ItemTypeMap = map[string]ItemType{
 	"diamond_boots":      DIAMOND_BOOTS,
 	"anvil":              ANVIL,
 	"randomland_portal":  RANDOMLAND_PORTAL,
+	"arrow":              ARROW,
+	"book":               BOOK,
+	"raw_meat":           RAW_MEAT,
+	"cooked_meat":        COOKED_MEAT,
+	"berries":            BERRIES,
+	"mushroom":           MUSHROOM,
+	"roasted_mushroom":   ROASTED_MUSHROOM,
+	"bottle":             BOTTLE,
+	"water_bottle":       WATER_BOTTLE,
 }
 
 var ItemDefinitions = map[ItemType]*ItemProperties{
@@ -238,6 +289,7 @@ var ItemDefinitions = map[ItemType]*ItemProperties{
 		IsTool:      false,
 		IsPlaceable: true,
 		BlockType:   "log",
+		FuelTime:    15,
 	},
 	COAL: {
 		ID:          COAL,
@@ -247,6 +299,7 @@ var ItemDefinitions = map[ItemType]*ItemProperties{
 		StackSize:   64,
 		Durability:  -1,
 		IsTool:      false,
+		FuelTime:    80,
 	},
 	IRON_INGOT: {
 		ID:          IRON_INGOT,
@@ -341,6 +394,7 @@ var ItemDefinitions = map[ItemType]*ItemProperties{
 		IsTool:      false,
 		IsPlaceable: true,
 		BlockType:   "snow",
+		ThrowDamage: 1.0,
 	},
 	TORCH: {
 		ID:          TORCH,
@@ -440,6 +494,7 @@ var ItemDefinitions = map[ItemType]*ItemProperties{
 		IsTool:      false,
 		IsPlaceable: true,
 		BlockType:   "plank",
+		FuelTime:    15,
 	},
 	STICK: {
 		ID:          STICK,
@@ -449,6 +504,7 @@ var ItemDefinitions = map[ItemType]*ItemProperties{
 		StackSize:   64,
 		Durability:  -1,
 		IsTool:      false,
+		FuelTime:    5,
 	},
 	WORKBENCH: {
 		ID:          WORKBENCH,
@@ -546,10 +602,12 @@ var ItemDefinitions = map[ItemType]*ItemProperties{
 		ID:          ROTTEN_FLESH,
 		Name:        "Rotten Flesh",
 		IconColor:   color.RGBA{139, 69, 19, 255},
-		Description: "Decaying flesh from zombies",
+		Description: "Decaying flesh from zombies; edible, if you must",
 		StackSize:   64,
 		Durability:  -1,
 		IsTool:      false,
+		Nutrition:   12,
+		Effects:     []FoodEffect{{Effect: "poison", Duration: 8, Strength: 1}},
 	},
 	WOODEN_SWORD: {
 		ID:           WOODEN_SWORD,
@@ -615,6 +673,7 @@ var ItemDefinitions = map[ItemType]*ItemProperties{
 		WeaponRange:  200.0,
 		WeaponSpeed:  1.0,
 		WeaponType:   "ranged",
+		AmmoType:     "arrow",
 	},
 	MAGIC_WAND: {
 		ID:           MAGIC_WAND,
@@ -761,13 +820,100 @@ var ItemDefinitions = map[ItemType]*ItemProperties{
 		ArmorType:    "boots",
 		ArmorDefense: 3.0,
 	},
+	ARROW: {
+		ID:          ARROW,
+		Name:        "Arrow",
+		IconColor:   color.RGBA{200, 200, 200, 255},
+		Description: "Ammunition for bows",
+		StackSize:   64,
+		Durability:  -1,
+		IsTool:      false,
+	},
+	BOOK: {
+		ID:          BOOK,
+		Name:        "Book",
+		IconColor:   color.RGBA{120, 60, 30, 255},
+		Description: "Notes that teach recipes to whoever finds them",
+		StackSize:   16,
+		Durability:  -1,
+		IsTool:      false,
+	},
+	RAW_MEAT: {
+		ID:          RAW_MEAT,
+		Name:        "Raw Meat",
+		IconColor:   color.RGBA{220, 90, 90, 255},
+		Description: "Fresh meat; better cooked in a furnace",
+		StackSize:   64,
+		Durability:  -1,
+		Nutrition:   10,
+		Effects:     []FoodEffect{{Effect: "poison", Duration: 4, Strength: 0.5}},
+	},
+	COOKED_MEAT: {
+		ID:          COOKED_MEAT,
+		Name:        "Cooked Meat",
+		IconColor:   color.RGBA{150, 80, 40, 255},
+		Description: "Hearty meat that keeps you full for a while",
+		StackSize:   64,
+		Durability:  -1,
+		Nutrition:   35,
+		Saturation:  20,
+	},
+	BERRIES: {
+		ID:          BERRIES,
+		Name:        "Berries",
+		IconColor:   color.RGBA{180, 30, 60, 255},
+		Description: "Juicy berries picked from bushes",
+		StackSize:   64,
+		Durability:  -1,
+		Nutrition:   8,
+		Hydration:   4,
+	},
+	MUSHROOM: {
+		ID:          MUSHROOM,
+		Name:        "Mushroom",
+		IconColor:   color.RGBA{200, 170, 140, 255},
+		Description: "A wild mushroom; roast it to bring out its goodness",
+		StackSize:   64,
+		Durability:  -1,
+		Nutrition:   6,
+	},
+	ROASTED_MUSHROOM: {
+		ID:          ROASTED_MUSHROOM,
+		Name:        "Roasted Mushroom",
+		IconColor:   color.RGBA{140, 100, 60, 255},
+		Description: "A roasted mushroom that helps wounds close",
+		StackSize:   64,
+		Durability:  -1,
+		Nutrition:   18,
+		Saturation:  6,
+		Effects:     []FoodEffect{{Effect: "regeneration", Duration: 10, Strength: 0.5}},
+	},
+	BOTTLE: {
+		ID:          BOTTLE,
+		Name:        "Bottle",
+		IconColor:   color.RGBA{210, 230, 255, 255},
+		Description: "An empty bottle; fill it at water",
+		StackSize:   16,
+		Durability:  -1,
+	},
+	WATER_BOTTLE: {
+		ID:          WATER_BOTTLE,
+		Name:        "Water Bottle",
+		IconColor:   color.RGBA{60, 120, 255, 255},
+		Description: "A bottle of water to drink on the go",
+		StackSize:   16,
+		Durability:  -1,
+		Hydration:   35,
+		Leaves:      "bottle",
+	},
 }
 
 // Item represents a stack of items
 type Item struct {
 	Type       ItemType
 	Quantity   int
-	Durability int // For tools
+	Durability int       // For tools
+	Meta       *Metadata `json:",omitempty"` // Custom name, enchantments and so on
 }
 
 // Inventory represents a player's inventory
@@ -802,19 +948,30 @@ func DefaultHotbarItems() []Item {
 	}
 }
 
-// AddItem adds an item to the inventory
+// AddItem adds plain items of a type, without metadata, to the inventory
 func (inv *Inventory) AddItem(itemType ItemType, quantity int) bool {
 	props := ItemDefinitions[itemType]
 	if props == nil {
 		return false
 	}
+	return inv.AddStack(Item{Type: itemType, Quantity: quantity, Durability: props.Durability})
+}
 
-	remaining := quantity
+// AddStack adds an item stack to the inventory, keeping its durability and
+// metadata. It only stacks with items it StacksWith, and reports whether all
+// of it fit; whatever fit stays in the inventory either way.
+func (inv *Inventory) AddStack(item Item) bool {
+	props := ItemDefinitions[item.Type]
+	if props == nil || item.Type == NONE {
+		return false
+	}
+
+	remaining := item.Quantity
 
 	// First, try to stack with existing items
 	if props.StackSize > 1 {
 		for i := range inv.Slots {
-			if inv.Slots[i].Type == itemType && inv.Slots[i].Quantity < props.StackSize {
+			if inv.Slots[i].StacksWith(item) && inv.Slots[i].Quantity < props.StackSize {
 				canAdd := props.StackSize - inv.Slots[i].Quantity
 				add := min(canAdd, remaining)
 				inv.Slots[i].Quantity += add
@@ -829,8 +986,9 @@ func (inv *Inventory) AddItem(itemType ItemType, quantity int) bool {
 	// Then, try to find empty slots
 	for i := range inv.Slots {
 		if inv.Slots[i].Type == NONE {
-			inv.Slots[i].Type = itemType
-			inv.Slots[i].Durability = props.Durability
+			inv.Slots[i].Type = item.Type
+			inv.Slots[i].Durability = item.Durability
+			inv.Slots[i].Meta = item.Meta.Clone()
 			inv.Slots[i].Quantity = min(remaining, props.StackSize)
 			remaining -= inv.Slots[i].Quantity
 			if remaining == 0 {
@@ -872,7 +1030,7 @@ func (inv *Inventory) SortInventory() {
 	inv.Slots = slots
 }
 
-// ConsolidateItems merges stackable items of the same type
+// ConsolidateItems merges stacks of the same type and metadata
 func (inv *Inventory) ConsolidateItems() {
 	for i := 0; i < len(inv.Slots); i++ {
 		if inv.Slots[i].Type == NONE {
@@ -880,9 +1038,9 @@ func (inv *Inventory) ConsolidateItems() {
 		}
 
 		for j := i + 1; j < len(inv.Slots); j++ {
-			if inv.Slots[j].Type == inv.Slots[i].Type {
+			if inv.Slots[j].StacksWith(inv.Slots[i]) {
 				props := ItemDefinitions[inv.Slots[i].Type]
-				if props != nil && props.StackSize > 1 {
+				if props != nil {
 					// Calculate how much can be transferred
 					canTransfer := min(props.StackSize-inv.Slots[i].Quantity, inv.Slots[j].Quantity)
 					inv.Slots[i].Quantity += canTransfer
@@ -963,19 +1121,21 @@ func (inv *Inventory) MoveItem(fromSlot, toSlot int, quantity int) bool {
 		toItem.Type = fromItem.Type
 		toItem.Quantity = quantity
 		toItem.Durability = fromItem.Durability
+		toItem.Meta = fromItem.Meta.Clone()
 
 		fromItem.Quantity -= quantity
 		if fromItem.Quantity == 0 {
 			fromItem.Type = NONE
 			fromItem.Durability = -1
+			fromItem.Meta = nil
 		}
 		return true
 	}
 
-	// If target slot has same item type, try to stack
-	if toItem.Type == fromItem.Type {
+	// If target slot has the same item, try to stack
+	if toItem.StacksWith(*fromItem) {
 		props := ItemDefinitions[fromItem.Type]
-		if props != nil && props.StackSize > 1 {
+		if props != nil {
 			canAdd := min(props.StackSize-toItem.Quantity, quantity)
 			toItem.Quantity += canAdd
 			fromItem.Quantity -= canAdd
@@ -983,6 +1143,7 @@ func (inv *Inventory) MoveItem(fromSlot, toSlot int, quantity int) bool {
 			if fromItem.Quantity == 0 {
 				fromItem.Type = NONE
 				fromItem.Durability = -1
+				fromItem.Meta = nil
 			}
 			return true
 		}
@@ -1008,6 +1169,7 @@ func (inv *Inventory) RemoveItem(quantity int) bool {
 	if slot.Quantity <= 0 {
 		slot.Type = NONE
 		slot.Durability = -1
+		slot.Meta = nil
 	}
 
 	return true
@@ -1035,6 +1197,7 @@ func (inv *Inventory) UseItem() bool {
 			item.Type = NONE
 			item.Quantity = 0
 			item.Durability = -1
+			item.Meta = nil
 		}
 		return true
 	}
@@ -1044,6 +1207,7 @@ func (inv *Inventory) UseItem() bool {
 		if item.Quantity <= 0 {
 			item.Type = NONE
 			item.Durability = -1
+			item.Meta = nil
 		}
 		return true
 	}
@@ -1051,6 +1215,33 @@ func (inv *Inventory) UseItem() bool {
 	return false
 }
 
+// WearSelectedItem takes one point of durability from the selected item,
+// breaking it when none is left. Unlike UseItem it never uses up a stack, so
+// it suits weapons; items without durability are unaffected.
+func (inv *Inventory) WearSelectedItem() {
+	inv.WearSlot(inv.Selected)
+}
+
+// WearSlot takes one point of durability from the item in a slot, like
+// WearSelectedItem
+func (inv *Inventory) WearSlot(index int) {
+	if index < 0 || index >= len(inv.Slots) {
+		return
+	}
+	item := &inv.Slots[index]
+	if item.Type == NONE || item.Durability <= 0 {
+		return
+	}
+
+	item.Durability--
+	if item.Durability <= 0 {
+		item.Type = NONE
+		item.Quantity = 0
+		item.Durability = -1
+		item.Meta = nil
+	}
+}
+
 // SelectSlot selects a slot by index
 func (inv *Inventory) SelectSlot(index int) bool {
 	if index < 0 || index >= len(inv.Slots) {
@@ -1096,6 +1287,7 @@ func (inv *Inventory) RemoveItemType(itemType ItemType, quantity int) bool {
 				inv.Slots[i].Type = NONE
 				inv.Slots[i].Quantity = 0
 				inv.Slots[i].Durability = -1
+				inv.Slots[i].Meta = nil
 			} else {
 				inv.Slots[i].Quantity -= remaining
 				remaining = 0
@@ -1172,9 +1364,17 @@ func LoadItemsFromAssets() {
 					WeaponRange:  i.WeaponRange,
 					WeaponSpeed:  i.WeaponSpeed,
 					WeaponType:   i.WeaponType,
+					AmmoType:     i.AmmoType,
+					ThrowDamage:  i.ThrowDamage,
 					IsArmor:      i.IsArmor,
 					ArmorType:    i.ArmorType,
 					ArmorDefense: i.ArmorDefense,
+					FuelTime:     i.FuelTime,
+					Nutrition:    i.Nutrition,
+					Hydration:    i.Hydration,
+					Saturation:   i.Saturation,
+					Effects:      i.Effects,
+					Leaves:       i.Leaves,
 				}
 				ItemDefinitions[it] = props
 			}
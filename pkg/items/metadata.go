@@ -0,0 +1,179 @@
+package items
+
+import (
+	"image/color"
+	"sort"
+)
+
+// Metadata is the per-stack data that sets an item apart from others of its
+// type, such as a custom name or enchantments. Items only stack when their
+// metadata is equal, and a nil Metadata is the same as an empty one.
+//
+// Metadata may be shared between copies of an Item, so treat it as read-only
+// and change it through Item.EditMeta.
+type Metadata struct {
+	Name         string         `json:"name,omitempty" yaml:"name,omitempty"`                 // Custom display name
+	Lore         []string       `json:"lore,omitempty" yaml:"lore,omitempty"`                 // Extra tooltip lines
+	Enchantments map[string]int `json:"enchantments,omitempty" yaml:"enchantments,omitempty"` // Enchantment ID to level
+	Dye          *color.RGBA    `json:"dye,omitempty" yaml:"dye,omitempty"`                   // Tint over the item's color
+
+	// Values and Tags hold anything else, such as data from plugins, keyed by
+	// a namespaced ID like "myplugin:charge"
+	Values map[string]float64 `json:"values,omitempty" yaml:"values,omitempty"`
+	Tags   map[string]string  `json:"tags,omitempty" yaml:"tags,omitempty"`
+}
+
+// IsEmpty reports whether the metadata holds nothing
+func (m *Metadata) IsEmpty() bool {
+	return m == nil || (m.Name == "" && len(m.Lore) == 0 && len(m.Enchantments) == 0 &&
+		m.Dye == nil && len(m.Values) == 0 && len(m.Tags) == 0)
+}
+
+// Clone returns a deep copy of the metadata, or nil if it is empty
+func (m *Metadata) Clone() *Metadata {
+	if m.IsEmpty() {
+		return nil
+	}
+	c := &Metadata{Name: m.Name}
+	if len(m.Lore) > 0 {
+		c.Lore = append([]string(nil), m.Lore...)
+	}
+	if len(m.Enchantments) > 0 {
+		c.Enchantments = make(map[string]int, len(m.Enchantments))
+		for id, level := range m.Enchantments {
+			c.Enchantments[id] = level
+		}
+	}
+	if m.Dye != nil {
+		dye := *m.Dye
+		c.Dye = &dye
+	}
+	if len(m.Values) > 0 {
+		c.Values = make(map[string]float64, len(m.Values))
+		for k, v := range m.Values {
+			c.Values[k] = v
+		}
+	}
+	if len(m.Tags) > 0 {
+		c.Tags = make(map[string]string, len(m.Tags))
+		for k, v := range m.Tags {
+			c.Tags[k] = v
+		}
+	}
+	return c
+}
+
+// Equal reports whether two metadata hold the same data
+func (m *Metadata) Equal(other *Metadata) bool {
+	if m.IsEmpty() || other.IsEmpty() {
+		return m.IsEmpty() && other.IsEmpty()
+	}
+	if m.Name != other.Name || len(m.Lore) != len(other.Lore) {
+		return false
+	}
+	for i := range m.Lore {
+		if m.Lore[i] != other.Lore[i] {
+			return false
+		}
+	}
+	if (m.Dye == nil) != (other.Dye == nil) || (m.Dye != nil && *m.Dye != *other.Dye) {
+		return false
+	}
+	return equalMaps(m.Enchantments, other.Enchantments) &&
+		equalMaps(m.Values, other.Values) &&
+		equalMaps(m.Tags, other.Tags)
+}
+
+// equalMaps reports whether two maps hold the same entries
+func equalMaps[V comparable](a, b map[string]V) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if w, ok := b[k]; !ok || w != v {
+			return false
+		}
+	}
+	return true
+}
+
+// EnchantmentIDs returns the IDs of the enchantments in order
+func (m *Metadata) EnchantmentIDs() []string {
+	if m == nil {
+		return nil
+	}
+	ids := make([]string, 0, len(m.Enchantments))
+	for id := range m.Enchantments {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// EnchantmentLevel returns the level of an enchantment, or 0 if the item does
+// not have it
+func (i Item) EnchantmentLevel(id string) int {
+	if i.Meta == nil {
+		return 0
+	}
+	return i.Meta.Enchantments[id]
+}
+
+// EditMeta gives the item its own copy of its metadata and returns it for
+// changing, so copies of the item that shared it are not affected
+func (i *Item) EditMeta() *Metadata {
+	i.Meta = i.Meta.Clone()
+	if i.Meta == nil {
+		i.Meta = &Metadata{}
+	}
+	return i.Meta
+}
+
+// StacksWith reports whether two items may share a slot: they must be the
+// same type, stackable and carry equal metadata
+func (i Item) StacksWith(other Item) bool {
+	if i.Type != other.Type || i.Type == NONE {
+		return false
+	}
+	props := ItemDefinitions[i.Type]
+	if props == nil || props.StackSize <= 1 {
+		return false
+	}
+	return i.Meta.Equal(other.Meta)
+}
+
+// BookTag is the metadata tag holding the ID of what a book teaches
+const BookTag = "tesselbox:book"
+
+// NewBook returns a titled book that teaches what is filed under an ID, such
+// as the recipes whose unlock triggers list it
+func NewBook(id, title string) Item {
+	return Item{Type: BOOK, Quantity: 1, Durability: -1, Meta: &Metadata{
+		Name: title,
+		Tags: map[string]string{BookTag: id},
+	}}
+}
+
+// BookID returns the ID of what a book teaches, or "" if the item is not one
+func (i Item) BookID() string {
+	if i.Type != BOOK || i.Meta == nil {
+		return ""
+	}
+	return i.Meta.Tags[BookTag]
+}
+
+// DisplayName returns the item's custom name, or its type's name if it has none
+func (i Item) DisplayName() string {
+	if i.Meta != nil && i.Meta.Name != "" {
+		return i.Meta.Name
+	}
+	return ItemNameByID(i.Type)
+}
+
+// DisplayColor returns the item's dye, or its type's icon color if it has none
+func (i Item) DisplayColor() color.RGBA {
+	if i.Meta != nil && i.Meta.Dye != nil {
+		return *i.Meta.Dye
+	}
+	return ItemColorByID(i.Type)
+}
@@ -0,0 +1,147 @@
+package items
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"tesselbox/pkg/palette"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	registryMutex sync.RWMutex
+	// itemIDs is the reverse of ItemTypeMap
+	itemIDs map[ItemType]string
+)
+
+// buildItemIDs rebuilds the reverse lookup; callers must hold registryMutex
+func buildItemIDs() {
+	itemIDs = make(map[ItemType]string, len(ItemTypeMap))
+	for id, itemType := range ItemTypeMap {
+		itemIDs[itemType] = id
+	}
+}
+
+// ItemID returns the stable string ID of an item type, or "" if it has none
+func ItemID(itemType ItemType) string {
+	registryMutex.RLock()
+	if itemIDs != nil && len(itemIDs) == len(ItemTypeMap) {
+		id := itemIDs[itemType]
+		registryMutex.RUnlock()
+		return id
+	}
+	registryMutex.RUnlock()
+
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+	buildItemIDs()
+	return itemIDs[itemType]
+}
+
+// ItemTypeByID returns the runtime item type for a stable string ID
+func ItemTypeByID(id string) (ItemType, bool) {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+	itemType, ok := ItemTypeMap[id]
+	return itemType, ok
+}
+
+// RegisterItemType returns the runtime item type for a string ID such as
+// "plugin:foo/bar", allocating the next free runtime ID if it is not known yet.
+// Runtime IDs are only valid for the current process; saves store string IDs.
+func RegisterItemType(id string) ItemType {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	if itemType, ok := ItemTypeMap[id]; ok {
+		return itemType
+	}
+
+	next := RANDOMLAND_PORTAL
+	for _, itemType := range ItemTypeMap {
+		if itemType > next {
+			next = itemType
+		}
+	}
+	next++
+
+	ItemTypeMap[id] = next
+	buildItemIDs()
+	return next
+}
+
+// NewItemPalette creates an item palette seeded with the built-in item types
+// in runtime order, so data written before palettes existed (which stored raw
+// item types) decodes unchanged
+func NewItemPalette() *palette.Palette {
+	var ids []string
+	for itemType := NONE; itemType <= RANDOMLAND_PORTAL; itemType++ {
+		id := ItemID(itemType)
+		if id == "" {
+			id = fmt.Sprintf("legacy:%d", itemType) // Keeps later indices aligned
+		}
+		ids = append(ids, id)
+	}
+	return palette.New(ids...)
+}
+
+// EncodeItemType returns the palette index for a runtime item type
+func EncodeItemType(itemPalette *palette.Palette, itemType ItemType) int {
+	id := ItemID(itemType)
+	if id == "" {
+		id = "none" // Unregistered runtime IDs cannot be restored
+	}
+	return itemPalette.IDFor(id)
+}
+
+// DecodeItemType returns the runtime item type for a palette index. String
+// IDs this build does not know (e.g. from a removed plugin) are registered as
+// placeholders so they survive being saved again.
+func DecodeItemType(itemPalette *palette.Palette, idx int) ItemType {
+	id, ok := itemPalette.Lookup(idx)
+	if !ok {
+		return NONE
+	}
+	if itemType, ok := ItemTypeByID(id); ok {
+		return itemType
+	}
+	return RegisterItemType(id)
+}
+
+// UnmarshalYAML accepts a string ID ("iron_pickaxe", "plugin:foo/bar") or,
+// for older config files, a raw numeric item type
+func (it *ItemType) UnmarshalYAML(value *yaml.Node) error {
+	var id string
+	if err := value.Decode(&id); err != nil {
+		return err
+	}
+
+	if itemType, ok := ItemTypeByID(id); ok {
+		*it = itemType
+		return nil
+	}
+
+	// Namespaced IDs may belong to a plugin that registers its items later
+	if strings.Contains(id, ":") {
+		*it = RegisterItemType(id)
+		return nil
+	}
+
+	var raw int
+	if err := value.Decode(&raw); err == nil && value.Tag == "!!int" {
+		*it = ItemType(raw)
+		return nil
+	}
+
+	return fmt.Errorf("unknown item id %q at line %d", id, value.Line)
+}
+
+// MarshalYAML writes the item type as its string ID
+func (it ItemType) MarshalYAML() (interface{}, error) {
+	if id := ItemID(it); id != "" {
+		return id, nil
+	}
+	return int(it), nil
+}
@@ -76,8 +76,10 @@ func (m *MailMessage) AttachMoney(amount float64) {
 	m.Attachments.Money = amount
 }
 
-// AttachItem attaches an item to the mail
+// AttachItem attaches an item to the mail, with its own copy of the item's
+// metadata
 func (m *MailMessage) AttachItem(item items.Item) {
+	item.Meta = item.Meta.Clone()
 	m.Attachments.Items = append(m.Attachments.Items, item)
 }
 
@@ -0,0 +1,133 @@
+package mobs
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Behavior is one piece of mob AI. A mob type lists behaviors by name and
+// runs them in order every update; they steer the mob with MoveTowards and
+// may change its state, attack or damage it.
+type Behavior interface {
+	Update(m *Mob, ctx *Context, deltaTime float64)
+}
+
+// BehaviorFunc adapts a function to the Behavior interface
+type BehaviorFunc func(m *Mob, ctx *Context, deltaTime float64)
+
+// Update calls the function
+func (f BehaviorFunc) Update(m *Mob, ctx *Context, deltaTime float64) {
+	f(m, ctx, deltaTime)
+}
+
+var (
+	behaviors = map[string]Behavior{
+		"burn_in_daylight": BehaviorFunc(burnInDaylight),
+		"chase":            BehaviorFunc(chase),
+		"wander":           BehaviorFunc(wander),
+	}
+	behaviorsMutex sync.RWMutex
+)
+
+// RegisterBehavior adds a behavior that mob types can list by name, replacing
+// any behavior already registered under it
+func RegisterBehavior(name string, b Behavior) {
+	behaviorsMutex.Lock()
+	defer behaviorsMutex.Unlock()
+	behaviors[name] = b
+}
+
+// GetBehavior returns a registered behavior
+func GetBehavior(name string) (Behavior, bool) {
+	behaviorsMutex.RLock()
+	defer behaviorsMutex.RUnlock()
+	b, ok := behaviors[name]
+	return b, ok
+}
+
+// burnInDaylight damages the mob while the ambient light is bright
+func burnInDaylight(m *Mob, ctx *Context, deltaTime float64) {
+	if ctx.AmbientLight > 0.4 {
+		m.IsBurning = true
+		m.State = MobBurning
+		m.TakeDamage(m.Def.BurnRate * deltaTime)
+		return
+	}
+	m.IsBurning = false
+	if m.State == MobBurning {
+		m.State = MobIdle
+	}
+}
+
+// chase makes hostile mobs follow the target once it comes into sight and
+// attack it when in range
+func chase(m *Mob, ctx *Context, deltaTime float64) {
+	if ctx.Target == nil || !m.Def.Hostile {
+		if m.State == MobChasing || m.State == MobAttacking {
+			m.State = MobIdle
+		}
+		return
+	}
+	tx, ty := ctx.Target.GetCenter()
+	dist := m.DistanceTo(tx, ty)
+
+	switch m.State {
+	case MobIdle, MobWandering:
+		if dist < m.Def.SightRange {
+			m.State = MobChasing
+			m.MoveTowards(tx, ty)
+		}
+
+	case MobChasing:
+		if dist > m.Def.LoseRange {
+			m.State = MobIdle
+		} else if dist < m.AttackRange {
+			m.State = MobAttacking
+		} else {
+			m.MoveTowards(tx, ty)
+		}
+
+	case MobAttacking:
+		if dist > m.AttackRange*1.2 {
+			m.State = MobChasing
+			m.MoveTowards(tx, ty)
+		} else if m.CanAttack() {
+			m.Attack(ctx)
+		}
+
+	case MobBurning:
+		// Panic but still move towards the target
+		m.MoveTowards(tx, ty)
+	}
+
+	if m.IsAttacking && time.Since(m.AttackTime) > 200*time.Millisecond {
+		m.IsAttacking = false
+	}
+}
+
+// wander makes idle mobs stroll to random nearby points
+func wander(m *Mob, ctx *Context, deltaTime float64) {
+	switch m.State {
+	case MobIdle:
+		// 1% chance per update to start wandering
+		if rand.Float64() < 0.01 {
+			cx, cy := m.GetCenter()
+			m.State = MobWandering
+			m.WanderX = cx + (rand.Float64()-0.5)*200
+			m.WanderY = cy + (rand.Float64()-0.5)*200
+			if m.Def.Movement == MovementWalk {
+				m.WanderY = cy // Walkers can only stroll along the ground
+			}
+		}
+
+	case MobWandering:
+		cx, _ := m.GetCenter()
+		if m.DistanceTo(m.WanderX, m.WanderY) < 20 || (m.Def.Movement == MovementWalk && math.Abs(m.WanderX-cx) < 20) {
+			m.State = MobIdle
+			return
+		}
+		m.MoveTowards(m.WanderX, m.WanderY)
+	}
+}
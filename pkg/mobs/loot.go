@@ -0,0 +1,45 @@
+package mobs
+
+import (
+	"math/rand"
+
+	"tesselbox/pkg/items"
+)
+
+// LootDrop is one entry of a mob type's loot table
+type LootDrop struct {
+	Item   string  `yaml:"item"`   // Item ID, e.g. "rotten_flesh"
+	Min    int     `yaml:"min"`    // Defaults to 1
+	Max    int     `yaml:"max"`    // Defaults to Min
+	Chance float64 `yaml:"chance"` // 0-1; 0 means always
+}
+
+// RollLoot returns the items the mob drops when killed
+func (m *Mob) RollLoot() []items.Item {
+	var drops []items.Item
+	for _, drop := range m.Def.Loot {
+		if drop.Chance > 0 && rand.Float64() >= drop.Chance {
+			continue
+		}
+		itemType, ok := items.ItemTypeByID(drop.Item)
+		if !ok {
+			continue
+		}
+
+		minQty, maxQty := drop.Min, drop.Max
+		if minQty < 1 {
+			minQty = 1
+		}
+		if maxQty < minQty {
+			maxQty = minQty
+		}
+		quantity := minQty + rand.Intn(maxQty-minQty+1)
+
+		durability := -1
+		if props := items.GetItemProperties(itemType); props != nil && props.Durability > 0 {
+			durability = props.Durability
+		}
+		drops = append(drops, items.Item{Type: itemType, Quantity: quantity, Durability: durability})
+	}
+	return drops
+}
@@ -0,0 +1,243 @@
+// Package mobs implements the hostile and passive creatures of the world:
+// data-defined mob types, shared physics and collision, pluggable AI
+// behaviors, loot tables and saving.
+package mobs
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// MobState represents AI states
+type MobState int
+
+const (
+	MobIdle MobState = iota
+	MobChasing
+	MobAttacking
+	MobBurning
+	MobDying
+	MobWandering
+)
+
+// Target is what hostile mobs chase and attack, usually a player
+type Target interface {
+	GetCenter() (float64, float64)
+	TakeDamage(amount float64)
+}
+
+// Context is what a mob can see of the world while it updates
+type Context struct {
+	Target       Target // Nil when there is nobody to chase
+	AmbientLight float64
+
+	// Collides reports whether a box overlaps solid terrain. Without it mobs
+	// move freely.
+	Collides func(minX, minY, maxX, maxY float64) bool
+	// Climbable reports whether a box touches something walking mobs can
+	// climb, such as a ladder
+	Climbable func(minX, minY, maxX, maxY float64) bool
+	// Navigator routes mobs around terrain. Without it they head straight
+	// for their goal.
+	Navigator Navigator
+
+	// OnAttack is called after a mob damages its target
+	OnAttack func(m *Mob, damage float64)
+}
+
+// Navigator plans routes through terrain, such as the world's pathfinder
+type Navigator interface {
+	// Waypoint returns the next point a mob should head for on its way to a
+	// goal, or false when it has no route there and should head straight for it
+	Waypoint(m *Mob, goalX, goalY float64) (float64, float64, bool)
+}
+
+// NavigatorFunc adapts a function to the Navigator interface
+type NavigatorFunc func(m *Mob, goalX, goalY float64) (float64, float64, bool)
+
+// Waypoint calls the function
+func (f NavigatorFunc) Waypoint(m *Mob, goalX, goalY float64) (float64, float64, bool) {
+	return f(m, goalX, goalY)
+}
+
+// Mob is a single living creature of some mob type
+type Mob struct {
+	ID     string
+	Type   string // Mob type ID, e.g. "zombie"
+	Def    *MobType
+	X, Y   float64 // Top-left of the bounding box
+	VX, VY float64
+	Width  float64
+	Height float64
+
+	// Combat stats
+	Health         float64
+	MaxHealth      float64
+	Damage         float64
+	Speed          float64
+	AttackRange    float64
+	LastAttack     time.Time
+	AttackCooldown time.Duration
+
+	// AI state
+	State     MobState
+	SpawnTime time.Time
+	IsBurning bool
+	WanderX   float64 // Where a wandering mob is heading
+	WanderY   float64
+
+	// Movement state, set by behaviors through MoveTowards
+	OnGround    bool
+	Jumping     bool
+	MovingLeft  bool
+	MovingRight bool
+	blocked     bool // A wall stopped it on the last update
+	goalX       float64
+	goalY       float64
+	hasGoal     bool
+
+	// Animation
+	IsAttacking bool
+	AttackTime  time.Time
+}
+
+// NewMob creates a mob of a type with its top-left corner at a position
+func NewMob(def *MobType, id string, x, y float64) *Mob {
+	return &Mob{
+		ID:             id,
+		Type:           def.ID,
+		Def:            def,
+		X:              x,
+		Y:              y,
+		Width:          def.Width,
+		Height:         def.Height,
+		Health:         def.Health,
+		MaxHealth:      def.Health,
+		Damage:         def.Damage,
+		Speed:          def.Speed,
+		AttackRange:    def.AttackRange,
+		AttackCooldown: time.Duration(def.AttackCooldown * float64(time.Second)),
+		State:          MobIdle,
+		SpawnTime:      time.Now(),
+	}
+}
+
+// Spawn creates a mob of a registered type standing on a point, or reports
+// false when the type is unknown
+func Spawn(typeID string, x, y float64) (*Mob, bool) {
+	def, ok := GetType(typeID)
+	if !ok {
+		return nil, false
+	}
+	id := fmt.Sprintf("%s_%d", typeID, rand.Int63())
+	return NewMob(def, id, x-def.Width/2, y-def.Height), true
+}
+
+// Update runs the mob's behaviors and then moves it
+func (m *Mob) Update(ctx *Context, deltaTime float64) {
+	if !m.IsAlive() {
+		return
+	}
+
+	// Clamp delta time to prevent physics issues
+	if deltaTime > 0.1 {
+		deltaTime = 0.1
+	}
+	if deltaTime < 0.001 {
+		deltaTime = 0.001
+	}
+
+	m.hasGoal = false
+	for _, name := range m.Def.Behaviors {
+		behavior, ok := GetBehavior(name)
+		if !ok {
+			continue
+		}
+		behavior.Update(m, ctx, deltaTime)
+		if !m.IsAlive() {
+			return
+		}
+	}
+
+	m.move(ctx, deltaTime)
+}
+
+// MoveTowards steers the mob towards a point for this update. Walking mobs
+// only use the height to decide whether to jump.
+func (m *Mob) MoveTowards(x, y float64) {
+	m.goalX, m.goalY = x, y
+	m.hasGoal = true
+}
+
+// DistanceTo returns the distance between the mob's center and a point
+func (m *Mob) DistanceTo(x, y float64) float64 {
+	cx, cy := m.GetCenter()
+	return math.Hypot(x-cx, y-cy)
+}
+
+// CanAttack reports whether the mob's attack has cooled down
+func (m *Mob) CanAttack() bool {
+	return time.Since(m.LastAttack) > m.AttackCooldown
+}
+
+// Attack damages the context's target
+func (m *Mob) Attack(ctx *Context) {
+	if ctx.Target == nil {
+		return
+	}
+	m.LastAttack = time.Now()
+	m.IsAttacking = true
+	m.AttackTime = time.Now()
+
+	ctx.Target.TakeDamage(m.Damage)
+	if ctx.OnAttack != nil {
+		ctx.OnAttack(m, m.Damage)
+	}
+}
+
+// TakeDamage applies damage to the mob
+func (m *Mob) TakeDamage(amount float64) {
+	m.Health -= amount
+	if m.Health <= 0 {
+		m.Die()
+	}
+}
+
+// Die kills the mob
+func (m *Mob) Die() {
+	m.State = MobDying
+	m.Health = 0
+}
+
+// IsAlive returns true if the mob has health remaining
+func (m *Mob) IsAlive() bool {
+	return m.Health > 0
+}
+
+// ApplyKnockback pushes the mob away from a point
+func (m *Mob) ApplyKnockback(fromX, fromY float64, force float64) {
+	cx, cy := m.GetCenter()
+	dx, dy := cx-fromX, cy-fromY
+	dist := math.Hypot(dx, dy)
+	if dist > 0 {
+		dx /= dist
+		dy /= dist
+	} else {
+		// Default knockback direction if at same position
+		dx, dy = 1, -0.5
+	}
+	m.VX += dx * force
+	m.VY += dy * force * 0.1
+}
+
+// GetBounds returns the mob's bounding box
+func (m *Mob) GetBounds() (minX, minY, maxX, maxY float64) {
+	return m.X, m.Y, m.X + m.Width, m.Y + m.Height
+}
+
+// GetCenter returns the mob's center position
+func (m *Mob) GetCenter() (float64, float64) {
+	return m.X + m.Width/2, m.Y + m.Height/2
+}
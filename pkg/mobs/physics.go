@@ -0,0 +1,217 @@
+package mobs
+
+import (
+	"math"
+)
+
+const (
+	// Walking mobs use the same physics as the player
+	Gravity      = 120.0 // Pixels per second squared
+	Friction     = 0.85
+	TerminalVelX = 300.0
+	TerminalVelY = 1200.0
+
+	// avoidDistance is how far ahead flying mobs look for obstacles
+	avoidDistance = 20.0
+	// climbReach is how close to a ladder a mob must be to climb it
+	climbReach = 4.0
+	// climbSpeed is the part of its speed a mob climbs ladders at
+	climbSpeed = 0.3
+)
+
+// Movement modes
+const (
+	MovementWalk = "walk" // Gravity, jumping and terrain collision like the player
+	MovementFly  = "fly"  // Moves straight towards its goal, steering around terrain
+)
+
+// move applies the mob's movement mode towards the goal its behaviors set,
+// following the context's navigator around terrain when there is one
+func (m *Mob) move(ctx *Context, deltaTime float64) {
+	if m.hasGoal && ctx.Navigator != nil {
+		if x, y, ok := ctx.Navigator.Waypoint(m, m.goalX, m.goalY); ok {
+			m.goalX, m.goalY = x, y
+		}
+	}
+	if m.Def.Movement == MovementFly {
+		m.fly(ctx, deltaTime)
+		return
+	}
+	m.walk(ctx, deltaTime)
+	m.X += m.VX * deltaTime
+	m.Y += m.VY * deltaTime
+	if ctx.Collides != nil {
+		m.collide(ctx)
+	}
+}
+
+// walk turns the goal into player-like controls and applies player physics
+func (m *Mob) walk(ctx *Context, deltaTime float64) {
+	m.MovingLeft, m.MovingRight = false, false
+	climbing := false
+	if ctx.Climbable != nil {
+		minX, minY, maxX, maxY := m.GetBounds()
+		climbing = ctx.Climbable(minX-climbReach, minY, maxX+climbReach, maxY)
+	}
+	if climbing {
+		m.VY = 0 // Hold on to the ladder
+	}
+
+	if m.hasGoal {
+		cx, cy := m.GetCenter()
+		dx, dy := m.goalX-cx, m.goalY-cy
+
+		// Climb up until level with the goal, so the mob can step off the
+		// top of the ladder, or down until close to it
+		climbUp, climbDown := climbing && dy < 0, climbing && dy > 5
+		if climbUp || climbDown {
+			m.VY = math.Copysign(m.Speed*climbSpeed, dy)
+		} else if math.Abs(dx) > 5 {
+			m.MovingRight = dx > 0
+			m.MovingLeft = dx < 0
+		}
+
+		if !climbUp && !climbDown && (dy < -30 || m.blocked) && m.OnGround && !m.Jumping {
+			// Jump if the goal is above or a wall is in the way
+			m.VY = -math.Sqrt(2 * Gravity * m.Def.JumpHeight)
+			m.Jumping = true
+			m.OnGround = false
+		}
+	}
+
+	// Apply acceleration from movement state
+	if m.MovingLeft {
+		m.VX -= m.Speed * deltaTime * 10
+	} else if m.MovingRight {
+		m.VX += m.Speed * deltaTime * 10
+	} else {
+		// Apply friction for smooth stopping
+		m.VX *= Friction
+	}
+	if m.VX > TerminalVelX {
+		m.VX = TerminalVelX
+	} else if m.VX < -TerminalVelX {
+		m.VX = -TerminalVelX
+	}
+
+	// Apply gravity, unless holding on to a ladder
+	if !climbing {
+		m.VY += Gravity * deltaTime
+		if m.VY > TerminalVelY {
+			m.VY = TerminalVelY
+		}
+	}
+
+	// Stop very small movements to prevent jitter
+	if !m.MovingLeft && !m.MovingRight && m.VX > -0.1 && m.VX < 0.1 {
+		m.VX = 0
+	}
+}
+
+// collide resolves the mob's box against terrain after it has moved: it lands
+// on the ground, stops at walls and bumps its head on ceilings
+func (m *Mob) collide(ctx *Context) {
+	collides := ctx.Collides
+	minX, minY, maxX, maxY := m.GetBounds()
+
+	// Check vertical collision (ground detection) - check from the feet downward
+	feetY := maxY
+	groundCheckDistance := 5.0
+
+	bottomLeftCollision := collides(minX, feetY, minX+m.Width/2, feetY+groundCheckDistance)
+	bottomRightCollision := collides(minX+m.Width/2, feetY, maxX, feetY+groundCheckDistance)
+	bottomCenterCollision := collides(minX+m.Width/2, feetY, minX+m.Width/2+1, feetY+groundCheckDistance)
+
+	if bottomLeftCollision || bottomRightCollision || bottomCenterCollision {
+		// We hit the ground - stop falling and snap to ground
+		if m.VY > 0 {
+			m.VY = 0
+			m.OnGround = true
+			m.Jumping = false
+
+			if collides(minX, feetY-1, maxX, feetY) {
+				// Sunk into the ground - rise back to its surface
+				for rise := 1.0; rise <= groundCheckDistance; rise += 1.0 {
+					if !collides(minX, feetY-rise-1, maxX, feetY-rise) {
+						m.Y = feetY - rise - m.Height
+						break
+					}
+				}
+			} else {
+				// Find exact ground position
+				for checkY := feetY; checkY <= feetY+groundCheckDistance; checkY += 1.0 {
+					if collides(minX, checkY, maxX, checkY+1) {
+						m.Y = checkY - m.Height
+						break
+					}
+				}
+			}
+		}
+	} else {
+		m.OnGround = false
+	}
+
+	// Check horizontal collision (walls)
+	m.blocked = false
+	if m.VX < 0 {
+		if collides(minX-1, minY+5, minX, maxY-5) {
+			m.X = minX + 1
+			m.VX = 0
+			m.blocked = true
+		}
+	} else if m.VX > 0 {
+		if collides(maxX, minY+5, maxX+1, maxY-5) {
+			m.X = maxX - m.Width - 1
+			m.VX = 0
+			m.blocked = true
+		}
+	}
+
+	// Check ceiling collision (head bump); ladders are climbed past, not bumped
+	if m.VY < 0 {
+		ceilingLeftCollision := collides(minX, minY-1, minX+m.Width/2, minY)
+		ceilingRightCollision := collides(minX+m.Width/2, minY-1, maxX, minY)
+		onLadder := ctx.Climbable != nil && ctx.Climbable(minX, minY-1, maxX, minY)
+		if (ceilingLeftCollision || ceilingRightCollision) && !onLadder {
+			m.VY = 0
+			m.Y = minY + 1
+		}
+	}
+}
+
+// fly moves straight towards the goal. When terrain is in the way it tries
+// turning left, then right, and otherwise waits.
+func (m *Mob) fly(ctx *Context, deltaTime float64) {
+	if !m.hasGoal {
+		return
+	}
+	cx, cy := m.GetCenter()
+	dx, dy := m.goalX-cx, m.goalY-cy
+	dist := math.Hypot(dx, dy)
+	if dist < 5 {
+		return // Already close enough
+	}
+	dx /= dist
+	dy /= dist
+
+	blocked := func(dirX, dirY float64) bool {
+		if ctx.Collides == nil {
+			return false
+		}
+		x, y := cx+dirX*avoidDistance, cy+dirY*avoidDistance
+		return ctx.Collides(x-1, y-1, x+1, y+1)
+	}
+	if blocked(dx, dy) {
+		switch {
+		case !blocked(-dy, dx):
+			dx, dy = -dy, dx
+		case !blocked(dy, -dx):
+			dx, dy = dy, -dx
+		default:
+			return
+		}
+	}
+
+	m.X += dx * m.Speed * deltaTime
+	m.Y += dy * m.Speed * deltaTime
+}
@@ -0,0 +1,61 @@
+package mobs
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// Data is a mob as stored in save files
+type Data struct {
+	ID        string  `json:"id"`
+	Mob       string  `json:"mob,omitempty"` // Mob type ID
+	X         float64 `json:"x"`
+	Y         float64 `json:"y"`
+	Health    float64 `json:"health"`
+	MaxHealth float64 `json:"max_health"`
+	Type      int     `json:"type"` // Zombie variant of saves from before mob types, see LegacyZombieTypes
+	IsAlive   bool    `json:"is_alive"`
+	State     int     `json:"state"`
+}
+
+// Data returns the mob's save data
+func (m *Mob) Data() Data {
+	return Data{
+		ID:        m.ID,
+		Mob:       m.Type,
+		X:         m.X,
+		Y:         m.Y,
+		Health:    m.Health,
+		MaxHealth: m.MaxHealth,
+		IsAlive:   m.IsAlive(),
+		State:     int(m.State),
+	}
+}
+
+// FromData recreates a saved mob, or reports false when its type is no
+// longer registered
+func FromData(d Data) (*Mob, bool) {
+	typeID := d.Mob
+	if typeID == "" {
+		if d.Type < 0 || d.Type >= len(LegacyZombieTypes) {
+			return nil, false
+		}
+		typeID = LegacyZombieTypes[d.Type]
+	}
+	def, ok := GetType(typeID)
+	if !ok {
+		return nil, false
+	}
+
+	id := d.ID
+	if id == "" {
+		id = fmt.Sprintf("%s_%d", typeID, rand.Int63())
+	}
+	m := NewMob(def, id, d.X, d.Y)
+	m.Health = d.Health
+	if d.MaxHealth > 0 {
+		m.MaxHealth = d.MaxHealth
+	}
+	m.State = MobState(d.State)
+	return m, true
+}
@@ -0,0 +1,177 @@
+package mobs
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"tesselbox/pkg/gametime"
+)
+
+// DamageCallback is called when a mob deals damage to the player
+type DamageCallback func(m *Mob, damage float64)
+
+// SpawnChance weights one mob type in a spawner's table
+type SpawnChance struct {
+	Mob    string
+	Weight float64
+}
+
+// DefaultSpawnTable is the night spawn table of the overworld zombie spawner
+var DefaultSpawnTable = []SpawnChance{
+	{Mob: "zombie", Weight: 0.6},
+	{Mob: "zombie_fast", Weight: 0.2},
+	{Mob: "zombie_strong", Weight: 0.15},
+	{Mob: "zombie_tank", Weight: 0.05},
+}
+
+// Spawner spawns hostile mobs around the player in the dark and despawns
+// them once they are dead or far away
+type Spawner struct {
+	Mobs           []*Mob
+	MaxMobs        int
+	SpawnRadius    float64
+	DespawnRadius  float64
+	LastSpawnTime  time.Time
+	SpawnCooldown  time.Duration
+	DayNightCycle  *gametime.DayNightCycle
+	NextID         int
+	Table          []SpawnChance
+	OnPlayerDamage DamageCallback // Callback for when player takes damage
+
+	// LightAt returns the local light level (0-1) at a position given the
+	// ambient light. Without it spawning falls back to the global ambient light.
+	LightAt func(x, y, ambientLight float64) float64
+
+	// Climbable and Navigator are passed on to the mobs, see Context
+	Climbable func(minX, minY, maxX, maxY float64) bool
+	Navigator Navigator
+}
+
+// NewSpawner creates a new spawner using DefaultSpawnTable
+func NewSpawner(dayNight *gametime.DayNightCycle) *Spawner {
+	return &Spawner{
+		Mobs:          make([]*Mob, 0),
+		MaxMobs:       15,
+		SpawnRadius:   800,
+		DespawnRadius: 1500,
+		SpawnCooldown: 3 * time.Second,
+		DayNightCycle: dayNight,
+		NextID:        1,
+		Table:         DefaultSpawnTable,
+	}
+}
+
+// Update updates all mobs and handles spawning/despawning
+func (s *Spawner) Update(deltaTime float64, target Target, ambientLight float64,
+	checkCollision func(float64, float64, float64, float64) bool, worldSpawnFunc func(float64, float64) (float64, float64)) {
+
+	tx, ty := target.GetCenter()
+
+	// Spawn new mobs at night, or in dark places when local light is known
+	isNight := ambientLight < 0.3
+	if (isNight || s.LightAt != nil) && len(s.Mobs) < s.MaxMobs {
+		if time.Since(s.LastSpawnTime) > s.SpawnCooldown {
+			if m := s.spawnNear(tx, ty, ambientLight, worldSpawnFunc); m != nil {
+				s.Mobs = append(s.Mobs, m)
+				s.LastSpawnTime = time.Now()
+			}
+		}
+	}
+
+	ctx := &Context{
+		Target:       target,
+		AmbientLight: ambientLight,
+		Collides:     checkCollision,
+		Climbable:    s.Climbable,
+		Navigator:    s.Navigator,
+	}
+	if s.OnPlayerDamage != nil {
+		ctx.OnAttack = s.OnPlayerDamage
+	}
+
+	// Update existing mobs
+	active := s.Mobs[:0]
+	for _, m := range s.Mobs {
+		m.Update(ctx, deltaTime)
+
+		// Despawn if too far or dead
+		if m.IsAlive() && m.DistanceTo(tx, ty) < s.DespawnRadius {
+			active = append(active, m)
+		}
+	}
+	for i := len(active); i < len(s.Mobs); i++ {
+		s.Mobs[i] = nil
+	}
+	s.Mobs = active
+}
+
+// NewMob creates a mob of a type at a position with the spawner's next ID
+func (s *Spawner) NewMob(typeID string, x, y float64) (*Mob, bool) {
+	def, ok := GetType(typeID)
+	if !ok {
+		return nil, false
+	}
+	m := NewMob(def, fmt.Sprintf("%s_%d", typeID, s.NextID), x, y)
+	s.NextID++
+	return m, true
+}
+
+// canSpawnAt checks if a location is dark enough for spawning
+func (s *Spawner) canSpawnAt(px, py float64, ambientLight float64) bool {
+	// Only spawn in dark areas (night time, caves, under cover)
+	if s.LightAt != nil {
+		return s.LightAt(px, py, ambientLight) < 0.3
+	}
+	return ambientLight < 0.3
+}
+
+// spawnNear spawns a mob at a valid position around a point using the world's
+// spawn function, so mobs land on terrain the same way the player does
+func (s *Spawner) spawnNear(px, py, ambientLight float64, worldSpawnFunc func(float64, float64) (float64, float64)) *Mob {
+	// Try multiple spawn positions
+	for attempts := 0; attempts < 10; attempts++ {
+		angle := rand.Float64() * 2 * math.Pi
+		dist := s.SpawnRadius*0.3 + rand.Float64()*s.SpawnRadius*0.7
+
+		tryX := px + math.Cos(angle)*dist
+		tryY := py + math.Sin(angle)*dist
+
+		// Use world spawn function to find valid ground position
+		spawnX, spawnY := worldSpawnFunc(tryX, tryY)
+		if spawnY >= 10000 {
+			continue // No ground found (the fallback max value)
+		}
+
+		// Drop the mob in from above the ground like the player
+		mobY := spawnY - 200
+		if !s.canSpawnAt(spawnX, mobY, ambientLight) {
+			continue
+		}
+		if m, ok := s.NewMob(s.pickType(), spawnX, mobY); ok {
+			return m
+		}
+	}
+
+	return nil // Could not find valid spawn
+}
+
+// pickType picks a mob type from the spawn table by weight
+func (s *Spawner) pickType() string {
+	total := 0.0
+	for _, entry := range s.Table {
+		total += entry.Weight
+	}
+	if total <= 0 {
+		return "zombie"
+	}
+	r := rand.Float64() * total
+	for _, entry := range s.Table {
+		r -= entry.Weight
+		if r < 0 {
+			return entry.Mob
+		}
+	}
+	return s.Table[len(s.Table)-1].Mob
+}
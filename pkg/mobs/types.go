@@ -0,0 +1,334 @@
+package mobs
+
+import (
+	"fmt"
+	"image/color"
+	"log"
+	"math"
+	"sort"
+	"sync"
+
+	"tesselbox/assets"
+	"tesselbox/pkg/overrides"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Mob shapes, used by renderers to pick how to draw a mob
+const (
+	ShapeHumanoid = "humanoid" // Body, head and legs like the player
+	ShapeBlob     = "blob"     // A single rounded body
+)
+
+// RGB is a color in YAML, such as [75, 118, 60]
+type RGB [3]uint8
+
+// RGBA returns the color as an opaque color.RGBA
+func (c RGB) RGBA() color.RGBA {
+	return color.RGBA{c[0], c[1], c[2], 255}
+}
+
+// MobType is the data definition of a kind of mob, as found in mobs.yaml
+type MobType struct {
+	ID     string  `yaml:"-"`
+	Name   string  `yaml:"name"`
+	Width  float64 `yaml:"width"`
+	Height float64 `yaml:"height"`
+
+	// Combat
+	Health         float64 `yaml:"health"`
+	Damage         float64 `yaml:"damage"`
+	Speed          float64 `yaml:"speed"` // Pixels per second
+	AttackRange    float64 `yaml:"attack_range"`
+	AttackCooldown float64 `yaml:"attack_cooldown"` // Seconds
+	Hostile        bool    `yaml:"hostile"`
+
+	// AI
+	SightRange float64  `yaml:"sight_range"` // Hostile mobs start chasing within this distance
+	LoseRange  float64  `yaml:"lose_range"`  // and give up beyond this one
+	BurnRate   float64  `yaml:"burn_rate"`   // Damage per second in daylight, for burn_in_daylight
+	Movement   string   `yaml:"movement"`    // "walk" or "fly"
+	Behaviors  []string `yaml:"behaviors"`   // Run in order every update
+	JumpHeight float64  `yaml:"jump_height"` // Pixels a walking mob can jump up
+	MaxFall    float64  `yaml:"max_fall"`    // Pixels a walking mob will drop when pathfinding
+
+	Loot []LootDrop `yaml:"loot,omitempty"`
+	XP   int        `yaml:"xp"` // Experience the player gets for killing it
+
+	// Appearance
+	Shape     string `yaml:"shape"`
+	Color     RGB    `yaml:"color"`
+	HeadColor RGB    `yaml:"head_color,omitempty"`
+	LegColor  RGB    `yaml:"leg_color,omitempty"`
+}
+
+// normalize fills in defaults for fields a definition left out
+func (t *MobType) normalize() {
+	if t.Name == "" {
+		t.Name = t.ID
+	}
+	if t.Width <= 0 {
+		t.Width = 40
+	}
+	if t.Height <= 0 {
+		t.Height = 40
+	}
+	if t.Health <= 0 {
+		t.Health = 10
+	}
+	if t.Speed <= 0 {
+		t.Speed = 120
+	}
+	if t.AttackRange <= 0 {
+		t.AttackRange = 50
+	}
+	if t.AttackCooldown <= 0 {
+		t.AttackCooldown = 1
+	}
+	if t.SightRange <= 0 {
+		t.SightRange = 300
+	}
+	if t.LoseRange < t.SightRange {
+		t.LoseRange = t.SightRange * 1.2
+	}
+	if t.JumpHeight <= 0 {
+		t.JumpHeight = 60
+	}
+	if t.MaxFall <= 0 {
+		t.MaxFall = 150
+	}
+	if t.XP <= 0 {
+		t.XP = int(math.Ceil(t.Health / 5))
+	}
+	if t.Movement == "" {
+		t.Movement = MovementWalk
+	}
+	if t.Shape == "" {
+		t.Shape = ShapeHumanoid
+	}
+	if t.HeadColor == (RGB{}) {
+		t.HeadColor = t.Color
+	}
+	if t.LegColor == (RGB{}) {
+		t.LegColor = t.Color
+	}
+}
+
+// validate reports what is wrong with a definition, if anything
+func (t *MobType) validate() error {
+	if t.ID == "" {
+		return fmt.Errorf("mob type has no ID")
+	}
+	if t.Movement != MovementWalk && t.Movement != MovementFly {
+		return fmt.Errorf("mob type %s has unknown movement %q", t.ID, t.Movement)
+	}
+	for _, name := range t.Behaviors {
+		if _, ok := GetBehavior(name); !ok {
+			return fmt.Errorf("mob type %s has unknown behavior %q", t.ID, name)
+		}
+	}
+	return nil
+}
+
+// LegacyZombieTypes maps the numeric zombie types of old saves to mob types
+var LegacyZombieTypes = []string{"zombie", "zombie_fast", "zombie_strong", "zombie_tank"}
+
+// DefaultMobTypes are the built-in mob types, overridden by mobs.yaml
+var DefaultMobTypes = map[string]*MobType{
+	"boar": {
+		Name: "Boar", Width: 45, Height: 30,
+		Health: 12, Speed: 150,
+		Behaviors: []string{"wander"},
+		Loot:      []LootDrop{{Item: "raw_meat", Min: 1, Max: 2, Chance: 1}},
+		Shape:     ShapeBlob,
+		Color:     RGB{130, 90, 60},
+	},
+	"zombie": {
+		Name: "Zombie", Width: 50, Height: 50,
+		Health: 20, Damage: 5, Speed: 300, AttackRange: 60, AttackCooldown: 0.8, Hostile: true,
+		SightRange: 500, LoseRange: 600, BurnRate: 10,
+		Behaviors: []string{"burn_in_daylight", "chase"},
+		Loot:      []LootDrop{{Item: "rotten_flesh", Min: 1, Max: 1, Chance: 1}},
+		Shape:     ShapeHumanoid,
+		Color:     RGB{75, 118, 60}, HeadColor: RGB{120, 200, 100}, LegColor: RGB{50, 80, 40},
+	},
+	"zombie_fast": {
+		Name: "Fast Zombie", Width: 50, Height: 50,
+		Health: 15, Damage: 3, Speed: 360, AttackRange: 60, AttackCooldown: 0.5, Hostile: true,
+		SightRange: 500, LoseRange: 600, BurnRate: 10,
+		Behaviors: []string{"burn_in_daylight", "chase"},
+		Loot:      []LootDrop{{Item: "rotten_flesh", Min: 1, Max: 1, Chance: 1}},
+		Shape:     ShapeHumanoid,
+		Color:     RGB{100, 160, 80}, HeadColor: RGB{150, 220, 120}, LegColor: RGB{70, 110, 50},
+	},
+	"zombie_strong": {
+		Name: "Strong Zombie", Width: 50, Height: 50,
+		Health: 30, Damage: 10, Speed: 240, AttackRange: 72, AttackCooldown: 1, Hostile: true,
+		SightRange: 500, LoseRange: 600, BurnRate: 10,
+		Behaviors: []string{"burn_in_daylight", "chase"},
+		Loot:      []LootDrop{{Item: "rotten_flesh", Min: 1, Max: 2, Chance: 1}},
+		Shape:     ShapeHumanoid,
+		Color:     RGB{60, 100, 50}, HeadColor: RGB{100, 160, 80}, LegColor: RGB{40, 70, 35},
+	},
+	"zombie_tank": {
+		Name: "Tank Zombie", Width: 50, Height: 50,
+		Health: 50, Damage: 6, Speed: 180, AttackRange: 60, AttackCooldown: 1.5, Hostile: true,
+		SightRange: 500, LoseRange: 600, BurnRate: 10,
+		Behaviors: []string{"burn_in_daylight", "chase"},
+		Loot:      []LootDrop{{Item: "rotten_flesh", Min: 1, Max: 3, Chance: 1}},
+		Shape:     ShapeHumanoid,
+		Color:     RGB{50, 80, 40}, HeadColor: RGB{80, 130, 60}, LegColor: RGB{30, 50, 25},
+	},
+	"slime": {
+		Name: "Slime", Width: 40, Height: 30,
+		Health: 20, Damage: 8, Speed: 120, AttackRange: 40, AttackCooldown: 1, Hostile: true,
+		SightRange: 100, LoseRange: 150, JumpHeight: 100,
+		Behaviors: []string{"wander", "chase"},
+		Loot:      []LootDrop{{Item: "gel", Min: 1, Max: 2, Chance: 1}},
+		Shape:     ShapeBlob,
+		Color:     RGB{0, 255, 0},
+	},
+	"spider": {
+		Name: "Spider", Width: 40, Height: 25,
+		Health: 15, Damage: 6, Speed: 240, AttackRange: 40, AttackCooldown: 1, Hostile: true,
+		SightRange: 100, LoseRange: 150,
+		Movement:  MovementFly,
+		Behaviors: []string{"wander", "chase"},
+		Loot:      []LootDrop{{Item: "string", Min: 1, Max: 1, Chance: 1}},
+		Shape:     ShapeBlob,
+		Color:     RGB{0, 0, 0},
+	},
+}
+
+var (
+	// mobTypes holds the definition in effect for every mob type: the
+	// topmost of its layers
+	mobTypes      = make(map[string]*MobType)
+	typeLayers    = overrides.New[string, *MobType]()
+	mobTypesMutex sync.RWMutex
+)
+
+// RegisterType sets the built-in definition of a mob type, adding the type
+// if it is new. Plugin overrides of the type stay on top of it. Mobs already
+// alive keep the definition they spawned with.
+func RegisterType(id string, t *MobType) error {
+	if err := prepareType(id, t); err != nil {
+		return err
+	}
+
+	mobTypesMutex.Lock()
+	defer mobTypesMutex.Unlock()
+	typeLayers.SetBuiltin(id, t)
+	applyTypeLayers(id)
+	return nil
+}
+
+// OverrideType stacks a plugin's definition of a mob type on top of any
+// others, adding the type if it is new. ReleaseTypes removes it again.
+func OverrideType(owner, id string, t *MobType) error {
+	if err := prepareType(id, t); err != nil {
+		return err
+	}
+
+	mobTypesMutex.Lock()
+	defer mobTypesMutex.Unlock()
+	typeLayers.Override(owner, id, t)
+	applyTypeLayers(id)
+	return nil
+}
+
+// ReleaseTypes removes every definition an owner stacked with OverrideType.
+// Each type falls back to the topmost definition left, and types with none
+// left are removed.
+func ReleaseTypes(owner string) {
+	mobTypesMutex.Lock()
+	defer mobTypesMutex.Unlock()
+	for _, id := range typeLayers.Release(owner) {
+		applyTypeLayers(id)
+	}
+}
+
+// prepareType fills in and checks a definition before it is registered
+func prepareType(id string, t *MobType) error {
+	t.ID = id
+	t.normalize()
+	return t.validate()
+}
+
+// applyTypeLayers puts a type's topmost definition in effect; callers must
+// hold mobTypesMutex
+func applyTypeLayers(id string) {
+	if t, ok := typeLayers.Top(id); ok {
+		mobTypes[id] = t
+	} else {
+		delete(mobTypes, id)
+	}
+}
+
+// GetType returns the definition of a mob type
+func GetType(id string) (*MobType, bool) {
+	mobTypesMutex.RLock()
+	defer mobTypesMutex.RUnlock()
+	t, ok := mobTypes[id]
+	return t, ok
+}
+
+// TypeIDs returns the IDs of every registered mob type in order
+func TypeIDs() []string {
+	mobTypesMutex.RLock()
+	defer mobTypesMutex.RUnlock()
+	ids := make([]string, 0, len(mobTypes))
+	for id := range mobTypes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// ParseTypes parses mob type definitions in the mobs.yaml format, keyed by ID
+func ParseTypes(data []byte) (map[string]*MobType, error) {
+	var defs map[string]*MobType
+	if err := yaml.Unmarshal(data, &defs); err != nil {
+		return nil, err
+	}
+	for id, def := range defs {
+		if def == nil {
+			return nil, fmt.Errorf("mob type %s has no definition", id)
+		}
+	}
+	return defs, nil
+}
+
+// LoadTypes registers the built-in mob types and then loads mobs.yaml over them
+func LoadTypes() {
+	for id, def := range DefaultMobTypes {
+		copied := *def
+		if err := RegisterType(id, &copied); err != nil {
+			log.Printf("Warning: Built-in mob type %s is invalid: %v", id, err)
+		}
+	}
+	LoadTypesFromAssets()
+}
+
+// LoadTypesFromAssets loads mob type definitions from embedded assets
+func LoadTypesFromAssets() {
+	data, err := assets.GetConfigFile("mobs.yaml")
+	if err != nil {
+		log.Printf("Warning: Failed to load mobs.yaml from embedded assets: %v", err)
+		return
+	}
+	defs, err := ParseTypes(data)
+	if err != nil {
+		log.Printf("Error loading mobs configuration: %v", err)
+		return
+	}
+	for id, def := range defs {
+		if err := RegisterType(id, def); err != nil {
+			log.Printf("Warning: Skipping mob type %s: %v", id, err)
+		}
+	}
+}
+
+func init() {
+	LoadTypes()
+}
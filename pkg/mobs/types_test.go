@@ -0,0 +1,13 @@
+package mobs
+
+import "testing"
+
+func TestOverrideTypeRejectsInvalid(t *testing.T) {
+	err := OverrideType("test", "bad_mob", &MobType{Behaviors: []string{"no_such_behavior"}})
+	if err == nil {
+		t.Fatal("OverrideType accepted an unknown behavior")
+	}
+	if _, ok := GetType("bad_mob"); ok {
+		t.Error("rejected type was registered")
+	}
+}
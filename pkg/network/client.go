@@ -0,0 +1,224 @@
+package network
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// clientQueueSize is the number of received messages buffered for the game loop
+const clientQueueSize = 1024
+
+// Client is a connection to a multiplayer server. Received messages are
+// delivered on Messages so the game loop can apply them between frames.
+type Client struct {
+	Welcome Welcome
+
+	conn     *Conn
+	messages chan *Envelope
+
+	// actions counts the inventory actions sent; the server acknowledges
+	// each one with an inventory
+	actions atomic.Uint64
+
+	mutex sync.Mutex
+	err   error
+}
+
+// Dial connects to a server and logs in. A missing port uses DefaultPort.
+func Dial(addr, playerName string) (*Client, error) {
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, strconv.Itoa(DefaultPort))
+	}
+	conn, err := net.DialTimeout("tcp", addr, HandshakeTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", addr, err)
+	}
+	return NewClient(conn, playerName)
+}
+
+// NewClient logs in over an existing connection, such as one end of net.Pipe
+func NewClient(netConn net.Conn, playerName string) (*Client, error) {
+	conn := NewConn(netConn)
+
+	hello := Hello{
+		Magic:           ProtocolMagic,
+		ProtocolVersion: ProtocolVersion,
+		PlayerName:      playerName,
+	}
+	if err := conn.Send(MsgHello, hello); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to send hello: %w", err)
+	}
+
+	conn.SetReadDeadline(time.Now().Add(HandshakeTimeout))
+	env, err := conn.Receive()
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to read handshake reply: %w", err)
+	}
+	conn.SetReadDeadline(time.Time{})
+
+	c := &Client{
+		conn:     conn,
+		messages: make(chan *Envelope, clientQueueSize),
+	}
+
+	switch env.Type {
+	case MsgWelcome:
+		if err := env.Decode(&c.Welcome); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	case MsgDisconnect:
+		var reason Disconnect
+		env.Decode(&reason)
+		conn.Close()
+		return nil, fmt.Errorf("server refused login: %s", reason.Reason)
+	default:
+		conn.Close()
+		return nil, fmt.Errorf("expected welcome, got %s", env.Type)
+	}
+
+	go c.readLoop()
+	return c, nil
+}
+
+// readLoop queues received messages until the connection closes
+func (c *Client) readLoop() {
+	defer close(c.messages)
+	for {
+		env, err := c.conn.Receive()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) && !errors.Is(err, io.ErrClosedPipe) {
+				c.setErr(err)
+			}
+			return
+		}
+		if env.Type == MsgDisconnect {
+			var reason Disconnect
+			env.Decode(&reason)
+			c.setErr(fmt.Errorf("disconnected by server: %s", reason.Reason))
+			c.conn.Close()
+			return
+		}
+		c.messages <- env
+	}
+}
+
+// setErr records why the connection ended
+func (c *Client) setErr(err error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.err == nil {
+		c.err = err
+	}
+}
+
+// Err returns why the connection ended, or nil while it is open
+func (c *Client) Err() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.err
+}
+
+// Messages returns received messages; it is closed when the connection ends
+func (c *Client) Messages() <-chan *Envelope {
+	return c.messages
+}
+
+// SendMove reports the local player's position
+func (c *Client) SendMove(x, y, vx, vy float64) error {
+	return c.conn.Send(MsgPlayerMove, PlayerMove{X: x, Y: y, VX: vx, VY: vy})
+}
+
+// sendAction sends a message the server answers with the player's inventory
+func (c *Client) sendAction(msgType MessageType, payload interface{}) error {
+	if err := c.conn.Send(msgType, payload); err != nil {
+		return err
+	}
+	c.actions.Add(1)
+	return nil
+}
+
+// Acknowledged reports whether an inventory or container with an Ack was sent
+// after the server handled every inventory action this client sent. Older
+// ones would undo changes the client already made locally.
+func (c *Client) Acknowledged(ack uint64) bool {
+	return ack == c.actions.Load()
+}
+
+// SendBlockChange asks the server to place a block, or remove one with "air"
+func (c *Client) SendBlockChange(x, y float64, blockID string) error {
+	return c.sendAction(MsgBlockChange, BlockChange{X: x, Y: y, Block: blockID})
+}
+
+// SendRespawn asks the server to respawn the player after dying
+func (c *Client) SendRespawn() error {
+	return c.conn.Send(MsgRespawn, struct{}{})
+}
+
+// SendFire asks the server to fire a weapon, or throw an item, at a point
+func (c *Client) SendFire(weapon string, throw bool, targetX, targetY float64) error {
+	return c.sendAction(MsgFire, Fire{Weapon: weapon, Throw: throw, TargetX: targetX, TargetY: targetY})
+}
+
+// SendCraft tells the server the player crafted a recipe at a station, with
+// a slot selected
+func (c *Client) SendCraft(recipeID, station string, selected int) error {
+	return c.sendAction(MsgCraft, Craft{Recipe: recipeID, Station: station, Selected: selected})
+}
+
+// SendUseItem asks the server to eat or drink the item in a slot, or use it
+// on the water at a point
+func (c *Client) SendUseItem(slot int, itemID string, targetX, targetY float64) error {
+	return c.sendAction(MsgUseItem, UseItem{Slot: slot, Item: itemID, TargetX: targetX, TargetY: targetY})
+}
+
+// SendAnvil asks the server to enchant an item, or combine or repair it with
+// the item in a second slot
+func (c *Client) SendAnvil(anvil Anvil) error {
+	return c.sendAction(MsgAnvil, anvil)
+}
+
+// SendPickUp asks the server to pick up a dropped item
+func (c *Client) SendPickUp(id uint64) error {
+	return c.sendAction(MsgPickUp, PickUp{ID: id})
+}
+
+// SendInventorySync sends the inventory after the player moved items around
+func (c *Client) SendInventorySync(sync InventorySync) error {
+	return c.sendAction(MsgInventorySync, sync)
+}
+
+// SendOpenContainer asks the server for what is in a chest or station
+func (c *Client) SendOpenContainer(kind string, x, y float64) error {
+	return c.conn.Send(MsgOpenContainer, Container{Kind: kind, X: x, Y: y})
+}
+
+// SendCloseContainer tells the server the player closed their container
+func (c *Client) SendCloseContainer() error {
+	return c.conn.Send(MsgCloseContainer, struct{}{})
+}
+
+// SendEquipment tells the server what armor the player wears
+func (c *Client) SendEquipment(msg Equipment) error {
+	return c.conn.Send(MsgEquipment, msg)
+}
+
+// SendChat sends a chat line
+func (c *Client) SendChat(content string) error {
+	return c.conn.Send(MsgChatSend, ChatSend{Content: content})
+}
+
+// Close says goodbye and closes the connection
+func (c *Client) Close() error {
+	c.conn.SetWriteDeadline(time.Now().Add(time.Second))
+	c.conn.Send(MsgDisconnect, Disconnect{Reason: "client closed"})
+	return c.conn.Close()
+}
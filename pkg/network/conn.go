@@ -0,0 +1,67 @@
+package network
+
+import (
+	"bufio"
+	"net"
+	"sync"
+	"time"
+)
+
+// Conn is a message-oriented wrapper around a stream connection. Reads must
+// come from a single goroutine; writes may come from any.
+type Conn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+
+	writeMutex sync.Mutex
+}
+
+// NewConn wraps a TCP connection or an in-process pipe from net.Pipe
+func NewConn(conn net.Conn) *Conn {
+	return &Conn{
+		conn:   conn,
+		reader: bufio.NewReader(conn),
+	}
+}
+
+// Send writes one message
+func (c *Conn) Send(msgType MessageType, payload interface{}) error {
+	frame, err := encodeMessage(msgType, payload)
+	if err != nil {
+		return err
+	}
+	return c.writeFrame(frame)
+}
+
+// writeFrame writes an already encoded message
+func (c *Conn) writeFrame(frame []byte) error {
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+	_, err := c.conn.Write(frame)
+	return err
+}
+
+// Receive reads the next message
+func (c *Conn) Receive() (*Envelope, error) {
+	return readMessage(c.reader)
+}
+
+// SetReadDeadline bounds the next Receive; a zero time removes the bound
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+// SetWriteDeadline bounds writes; a zero time removes the bound
+func (c *Conn) SetWriteDeadline(t time.Time) error {
+	return c.conn.SetWriteDeadline(t)
+}
+
+// RemoteAddr returns the peer's address
+func (c *Conn) RemoteAddr() string {
+	return c.conn.RemoteAddr().String()
+}
+
+// Close closes the underlying connection
+func (c *Conn) Close() error {
+	return c.conn.Close()
+}
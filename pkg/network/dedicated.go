@@ -0,0 +1,82 @@
+package network
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"tesselbox/pkg/server"
+)
+
+// RunDedicated parses server command line arguments, simulates the world and
+// accepts players until interrupted, then saves on shutdown. It is shared by
+// `tesselbox server` and the graphics-free tesselbox-server binary.
+func RunDedicated(args []string) error {
+	flags := flag.NewFlagSet("server", flag.ContinueOnError)
+	tickRate := flags.Int("tps", server.DefaultTickRate, "simulation ticks per second")
+	autoSave := flags.Duration("autosave", server.DefaultAutoSaveInterval, "interval between automatic saves (0 disables)")
+	listen := flags.String("listen", ":"+strconv.Itoa(DefaultPort), "TCP address to accept players on (empty for none)")
+	playerName := flags.String("player", "", "local player save to keep simulated without a client")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: tesselbox server [flags] <world name>")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	worldName := "default"
+	if flags.NArg() > 0 {
+		worldName = flags.Arg(0)
+	}
+	if *tickRate <= 0 {
+		return fmt.Errorf("tick rate must be positive, got %d", *tickRate)
+	}
+
+	sim, err := server.NewSimulation(worldName)
+	if err != nil {
+		return err
+	}
+	sim.TickRate = *tickRate
+	sim.AutoSaveInterval = *autoSave
+
+	if *playerName != "" {
+		if _, err := sim.AddPlayer(*playerName); err != nil {
+			return err
+		}
+	}
+
+	if *listen != "" {
+		srv := NewServer(sim)
+		defer srv.Close()
+		go func() {
+			if err := srv.ListenAndServe(*listen); err != nil {
+				log.Printf("Network server stopped: %v", err)
+			}
+		}()
+		log.Printf("Accepting players on %s (protocol version %d)", *listen, ProtocolVersion)
+	}
+
+	stop := make(chan struct{})
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	go func() {
+		sig := <-signals
+		log.Printf("Received %v, saving world and shutting down...", sig)
+		close(stop)
+	}()
+
+	log.Printf("Simulating world '%s' at %d ticks per second (seed %d)", worldName, sim.TickRate, sim.World.Seed)
+	started := time.Now()
+	if err := sim.Run(stop); err != nil {
+		return fmt.Errorf("failed to save world on shutdown: %w", err)
+	}
+	log.Printf("World '%s' saved after %d ticks (%s)", worldName, sim.TickCount, time.Since(started).Round(time.Second))
+	return nil
+}
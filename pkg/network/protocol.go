@@ -0,0 +1,394 @@
+// Package network implements the multiplayer protocol: length-prefixed JSON
+// messages over TCP, with a versioned handshake followed by chunk streaming,
+// entity sync, block changes, inventory actions and chat.
+package network
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"tesselbox/pkg/equipment"
+	"tesselbox/pkg/items"
+	"tesselbox/pkg/world"
+)
+
+const (
+	// ProtocolMagic identifies a TesselBox connection in the handshake
+	ProtocolMagic = "TESSELBOX"
+	// ProtocolVersion is bumped whenever a message changes incompatibly
+	ProtocolVersion = 3
+	// DefaultPort is the TCP port servers listen on by default
+	DefaultPort = 25590
+	// MaxFrameSize bounds a single message so a bad peer cannot exhaust memory
+	MaxFrameSize = 8 << 20
+)
+
+// MessageType identifies the payload of a message
+type MessageType string
+
+const (
+	MsgHello       MessageType = "hello"        // Client -> server, first message
+	MsgWelcome     MessageType = "welcome"      // Server -> client, login accepted
+	MsgDisconnect  MessageType = "disconnect"   // Either way, with a reason
+	MsgChunk       MessageType = "chunk"        // Server -> client, full chunk contents
+	MsgPlayerMove  MessageType = "player_move"  // Client -> server, own position
+	MsgEntities    MessageType = "entities"     // Server -> client, entity snapshot
+	MsgBlockChange MessageType = "block_change" // Client request, server broadcast
+	MsgChatSend    MessageType = "chat_send"    // Client -> server, chat line
+	MsgChat        MessageType = "chat"         // Server -> client, a chat.ChatMessage
+
+	MsgPlayerPosition MessageType = "player_position" // Server -> client, corrects the own position
+	MsgRespawn        MessageType = "respawn"         // Client -> server, after dying
+	MsgInventory      MessageType = "inventory"       // Server -> client, own inventory
+	MsgFire           MessageType = "fire"            // Client -> server, shoot or throw
+	MsgDamage         MessageType = "damage"          // Server -> client, a projectile hit them
+	MsgEquipment      MessageType = "equipment"       // Client -> server, the armor worn
+
+	MsgCraft          MessageType = "craft"           // Client -> server, crafted a recipe
+	MsgUseItem        MessageType = "use_item"        // Client -> server, eat, drink or fill a bottle
+	MsgAnvil          MessageType = "anvil"           // Client -> server, enchant, combine or repair
+	MsgPickUp         MessageType = "pick_up"         // Client -> server, pick up a dropped item
+	MsgInventorySync  MessageType = "inventory_sync"  // Client -> server, items moved or dropped
+	MsgOpenContainer  MessageType = "open_container"  // Client -> server, opened a chest or station
+	MsgCloseContainer MessageType = "close_container" // Client -> server, closed it again
+	MsgContainer      MessageType = "container"       // Server -> client, what is in the open one
+)
+
+// Envelope is the framed unit on the wire
+type Envelope struct {
+	Type    MessageType     `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// Decode unmarshals the envelope's payload
+func (e *Envelope) Decode(v interface{}) error {
+	if err := json.Unmarshal(e.Payload, v); err != nil {
+		return fmt.Errorf("failed to decode %s message: %w", e.Type, err)
+	}
+	return nil
+}
+
+// Hello opens the handshake
+type Hello struct {
+	Magic           string `json:"magic"`
+	ProtocolVersion int    `json:"protocol_version"`
+	PlayerName      string `json:"player_name"`
+}
+
+// Welcome accepts a login and describes the world the client joined
+type Welcome struct {
+	ProtocolVersion int                    `json:"protocol_version"`
+	PlayerName      string                 `json:"player_name"`
+	WorldName       string                 `json:"world_name"`
+	Seed            int64                  `json:"seed"`
+	Generation      world.GenerationParams `json:"generation"`
+	SpawnX          float64                `json:"spawn_x"`
+	SpawnY          float64                `json:"spawn_y"`
+	WorldTime       float64                `json:"world_time"`
+	TickRate        int                    `json:"tick_rate"`
+}
+
+// Disconnect tells the peer why the connection is closing
+type Disconnect struct {
+	Reason string `json:"reason"`
+}
+
+// ChunkMessage carries a chunk with its own block palette
+type ChunkMessage struct {
+	Blocks []string         `json:"blocks"`
+	Chunk  *world.ChunkData `json:"chunk"`
+}
+
+// PlayerMove reports the client's own position and velocity; as a
+// player_position message the server corrects them with its own
+type PlayerMove struct {
+	X  float64 `json:"x"`
+	Y  float64 `json:"y"`
+	VX float64 `json:"vx"`
+	VY float64 `json:"vy"`
+}
+
+// Fire shoots the weapon with the given item ID, or throws the item when
+// Throw is set, towards a point
+type Fire struct {
+	Weapon  string  `json:"weapon"`
+	Throw   bool    `json:"throw,omitempty"`
+	TargetX float64 `json:"target_x"`
+	TargetY float64 `json:"target_y"`
+}
+
+// Damage tells a player a projectile hit them
+type Damage struct {
+	Attacker string  `json:"attacker"` // Projectile target ID of the shooter
+	Weapon   string  `json:"weapon"`
+	Amount   float64 `json:"amount"`
+	Critical bool    `json:"critical,omitempty"`
+}
+
+// InventorySlot is one inventory slot, with the item as a string ID
+type InventorySlot struct {
+	Item       string          `json:"item"`
+	Quantity   int             `json:"quantity"`
+	Durability int             `json:"durability"`
+	Meta       *items.Metadata `json:"meta,omitempty"`
+}
+
+// NewInventorySlot converts an item stack to its wire form
+func NewInventorySlot(item items.Item) InventorySlot {
+	return InventorySlot{
+		Item:       items.ItemID(item.Type),
+		Quantity:   item.Quantity,
+		Durability: item.Durability,
+		Meta:       item.Meta,
+	}
+}
+
+// NewInventorySlots converts item stacks to their wire form
+func NewInventorySlots(stacks []items.Item) []InventorySlot {
+	slots := make([]InventorySlot, len(stacks))
+	for i, stack := range stacks {
+		slots[i] = NewInventorySlot(stack)
+	}
+	return slots
+}
+
+// Stack returns the item stack in a slot, or false if its item ID is not
+// registered here
+func (slot InventorySlot) Stack() (items.Item, bool) {
+	itemType, ok := items.ItemTypeByID(slot.Item)
+	if !ok {
+		return items.Item{}, false
+	}
+	if itemType == items.NONE || slot.Quantity <= 0 {
+		return items.Item{Type: items.NONE, Quantity: 0, Durability: -1}, true
+	}
+	return items.Item{Type: itemType, Quantity: slot.Quantity, Durability: slot.Durability, Meta: slot.Meta}, true
+}
+
+// stacks converts slots back to item stacks, failing on unknown item IDs
+func stacks(slots []InventorySlot) ([]items.Item, error) {
+	result := make([]items.Item, len(slots))
+	for i, slot := range slots {
+		stack, ok := slot.Stack()
+		if !ok {
+			return nil, fmt.Errorf("unknown item %q", slot.Item)
+		}
+		result[i] = stack
+	}
+	return result, nil
+}
+
+// ArmorPiece is a piece of armor a player wears. The server works out its
+// defense from the slot, material and type, and the enchantments in Meta.
+type ArmorPiece struct {
+	Slot      int             `json:"slot"`
+	Material  int             `json:"material"`
+	ArmorType int             `json:"armor_type"`
+	Meta      *items.Metadata `json:"meta,omitempty"`
+}
+
+// Equipment is the armor the client's player wears, sent when it changes
+type Equipment struct {
+	Armor []ArmorPiece `json:"armor"`
+}
+
+// NewEquipment lists the armor in an equipment set. Wings add no defense,
+// so they are left out.
+func NewEquipment(set *equipment.EquipmentSet) Equipment {
+	msg := Equipment{Armor: []ArmorPiece{}}
+	for slot, piece := range set.Slots {
+		if piece == nil || equipment.EquipmentSlot(slot) == equipment.SlotWings {
+			continue
+		}
+		msg.Armor = append(msg.Armor, ArmorPiece{
+			Slot:      slot,
+			Material:  int(piece.Material),
+			ArmorType: int(piece.ArmorType),
+			Meta:      piece.Meta,
+		})
+	}
+	return msg
+}
+
+// Pieces builds the armor with the stats of its slot, material and type
+func (e Equipment) Pieces() []*equipment.EquipmentItem {
+	var pieces []*equipment.EquipmentItem
+	for _, armor := range e.Armor {
+		slot := equipment.EquipmentSlot(armor.Slot)
+		if slot < 0 || slot >= equipment.SlotCount || slot == equipment.SlotWings {
+			continue
+		}
+		name, _, _ := equipment.MaterialProperties(equipment.ArmorMaterial(armor.Material))
+		piece := equipment.CreateArmor(name, slot, equipment.ArmorMaterial(armor.Material), equipment.ArmorType(armor.ArmorType))
+		piece.Meta = armor.Meta
+		pieces = append(pieces, piece)
+	}
+	return pieces
+}
+
+// Inventory is the inventory the server holds for the receiving player. Ack
+// counts the client's inventory actions the server has handled, so a client
+// can ignore inventories older than changes it already made locally.
+type Inventory struct {
+	Slots []InventorySlot `json:"slots"`
+	Ack   uint64          `json:"ack"`
+}
+
+// Craft crafts a recipe at a station, such as "workbench" or "none", with
+// the tool it needs in the selected slot
+type Craft struct {
+	Recipe   string `json:"recipe"`
+	Station  string `json:"station"`
+	Selected int    `json:"selected"`
+}
+
+// UseItem eats or drinks the item in a slot, or uses it on the water at a
+// point, such as to fill a bottle
+type UseItem struct {
+	Slot    int     `json:"slot"`
+	Item    string  `json:"item"`
+	TargetX float64 `json:"target_x"`
+	TargetY float64 `json:"target_y"`
+}
+
+// Anvil enchants the item in Slot, or combines or repairs it with the item
+// in Second when that is not -1
+type Anvil struct {
+	Slot        int    `json:"slot"`
+	Second      int    `json:"second"`
+	Enchantment string `json:"enchantment,omitempty"`
+	Level       int    `json:"level,omitempty"`
+}
+
+// PickUp picks up the dropped item with an ID
+type PickUp struct {
+	ID uint64 `json:"id"`
+}
+
+// Container is what is in a chest or station. Clients open one with only
+// the kind and position set; the server answers with its contents and sends
+// them again as they change until it is closed.
+type Container struct {
+	Kind      string          `json:"kind"` // "chest" or a station kind
+	X         float64         `json:"x"`
+	Y         float64         `json:"y"`
+	Slots     []InventorySlot `json:"slots,omitempty"`
+	Burn      float64         `json:"burn,omitempty"`
+	BurnTotal float64         `json:"burn_total,omitempty"`
+	Progress  float64         `json:"progress,omitempty"`
+	Ack       uint64          `json:"ack,omitempty"` // As in Inventory
+}
+
+// InventorySync is the client's inventory after the player moved items
+// around, along with the container they have open and the stacks they
+// dropped. The server rejects it, and resends its own, when it would create
+// or destroy items.
+type InventorySync struct {
+	Slots     []InventorySlot `json:"slots"`
+	Container *Container      `json:"container,omitempty"`
+	Dropped   []InventorySlot `json:"dropped,omitempty"`
+}
+
+// ItemDrop is an item stack lying in the world
+type ItemDrop struct {
+	ID   uint64        `json:"id"`
+	X    float64       `json:"x"`
+	Y    float64       `json:"y"`
+	Item InventorySlot `json:"item"`
+}
+
+// Entity kinds in an entity snapshot
+const (
+	EntityPlayer   = "player"
+	EntityZombie   = "zombie"
+	EntityCreature = "creature"
+)
+
+// EntityState is one entity in a snapshot
+type EntityState struct {
+	ID        string  `json:"id"`
+	Kind      string  `json:"kind"`
+	Mob       string  `json:"mob,omitempty"` // Mob type of zombies and creatures
+	X         float64 `json:"x"`
+	Y         float64 `json:"y"`
+	Health    float64 `json:"health"`
+	MaxHealth float64 `json:"max_health"`
+	Burning   bool    `json:"burning,omitempty"`
+}
+
+// Entities is a snapshot of every entity near the receiving player
+type Entities struct {
+	Tick      uint64        `json:"tick"`
+	WorldTime float64       `json:"world_time"`
+	Weather   string        `json:"weather"`
+	Entities  []EntityState `json:"entities"`
+	Items     []ItemDrop    `json:"items,omitempty"`
+}
+
+// BlockChange places a block, or removes one when Block is "air"
+type BlockChange struct {
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Block  string  `json:"block"`
+	Player string  `json:"player,omitempty"` // Set by the server when broadcasting
+
+	// Level and source state of liquid blocks, as in world.Hexagon
+	Level  int  `json:"level,omitempty"`
+	Source bool `json:"source,omitempty"`
+}
+
+// ChatSend is a chat line typed by a player
+type ChatSend struct {
+	Content string `json:"content"`
+}
+
+// encodeMessage builds a framed message: a 4-byte big-endian length followed
+// by the JSON envelope
+func encodeMessage(msgType MessageType, payload interface{}) ([]byte, error) {
+	env := Envelope{Type: msgType}
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal %s payload: %w", msgType, err)
+		}
+		env.Payload = data
+	}
+
+	body, err := json.Marshal(env)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s message: %w", msgType, err)
+	}
+	if len(body) > MaxFrameSize {
+		return nil, fmt.Errorf("%s message is %d bytes, limit is %d", msgType, len(body), MaxFrameSize)
+	}
+
+	frame := make([]byte, 4+len(body))
+	binary.BigEndian.PutUint32(frame, uint32(len(body)))
+	copy(frame[4:], body)
+	return frame, nil
+}
+
+// readMessage reads one framed message
+func readMessage(r io.Reader) (*Envelope, error) {
+	var header [4]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+	size := binary.BigEndian.Uint32(header[:])
+	if size > MaxFrameSize {
+		return nil, fmt.Errorf("message of %d bytes exceeds limit of %d", size, MaxFrameSize)
+	}
+
+	body := make([]byte, size)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, fmt.Errorf("failed to read message body: %w", err)
+	}
+
+	var env Envelope
+	if err := json.Unmarshal(body, &env); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal message: %w", err)
+	}
+	return &env, nil
+}
@@ -0,0 +1,916 @@
+package network
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"tesselbox/pkg/blocks"
+	"tesselbox/pkg/chat"
+	"tesselbox/pkg/combat"
+	"tesselbox/pkg/config"
+	"tesselbox/pkg/permissions"
+	"tesselbox/pkg/server"
+	"tesselbox/pkg/world"
+)
+
+const (
+	// HandshakeTimeout is how long a new connection has to send its hello
+	HandshakeTimeout = 10 * time.Second
+	// DefaultChunksPerTick limits chunk streaming per player per tick
+	DefaultChunksPerTick = 4
+	// DefaultEntityInterval is the number of ticks between entity snapshots
+	DefaultEntityInterval = 2
+	// EntityViewDistance is how far from a player entities are sent
+	EntityViewDistance = 1500.0
+	// sendQueueSize is the number of outgoing messages buffered per player
+	sendQueueSize = 512
+	// maxPlayerNameLength bounds player names
+	maxPlayerNameLength = 16
+)
+
+// Server accepts multiplayer clients into a headless simulation
+type Server struct {
+	Simulation *server.Simulation
+	Chat       *chat.ChatManager
+	Registry   *permissions.PlayerRegistry
+
+	ChunksPerTick  int
+	EntityInterval uint64
+
+	mutex     sync.Mutex
+	sessions  map[string]*session
+	listeners []net.Listener
+	closed    bool
+
+	// chatMutex serializes ChatManager, which is not safe for concurrent use
+	chatMutex sync.Mutex
+
+	// worldChanges are blocks the world changed by itself, such as flowing
+	// liquid, waiting to be relayed; only touched from the simulation's tick
+	worldChanges []BlockChange
+}
+
+// session is one logged-in client
+type session struct {
+	name string
+	conn *Conn
+	out  chan []byte
+	done chan struct{}
+
+	closeOnce sync.Once
+
+	// acked counts the inventory actions handled for the client
+	acked atomic.Uint64
+
+	// sentChunks is only touched from the simulation's tick, and container
+	// only with the simulation locked
+	sentChunks map[[2]int]bool
+	container  *containerView
+}
+
+// ContainerChest is the container kind of chests; stations use their own
+const ContainerChest = server.ContainerChest
+
+// containerView is the chest or station a client has open
+type containerView struct {
+	kind string
+	x, y float64
+	sent []byte // Last contents sent, to only send changes
+}
+
+// NewServer creates a server for a simulation and takes over its tick hook
+func NewServer(sim *server.Simulation) *Server {
+	registry := permissions.NewPlayerRegistry(config.GetWorldSaveDir(sim.WorldName))
+	if err := registry.Load(); err != nil {
+		log.Printf("No player registry loaded for world %s: %v", sim.WorldName, err)
+	}
+
+	s := &Server{
+		Simulation:     sim,
+		Chat:           chat.NewChatManager(),
+		Registry:       registry,
+		ChunksPerTick:  DefaultChunksPerTick,
+		EntityInterval: DefaultEntityInterval,
+		sessions:       make(map[string]*session),
+	}
+	s.Chat.OnMessage = s.broadcastChat
+	sim.OnTick = s.onTick
+	sim.OnPlayerHit = s.sendDamage
+	sim.World.OnBlockChange = func(x, y float64, blockType blocks.BlockType) {
+		change := BlockChange{X: x, Y: y, Block: blocks.BlockID(blockType)}
+		if hex := sim.World.GetHexagonAt(x, y); hex != nil && blocks.IsLiquidBlock(hex.BlockType) {
+			change.Level, change.Source = hex.LiquidLevel, hex.LiquidSource
+		}
+		s.worldChanges = append(s.worldChanges, change)
+	}
+	return s
+}
+
+// ListenAndServe listens on a TCP address and serves clients until Close
+func (s *Server) ListenAndServe(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	return s.Serve(listener)
+}
+
+// Serve accepts connections from a listener until Close
+func (s *Server) Serve(listener net.Listener) error {
+	s.mutex.Lock()
+	if s.closed {
+		s.mutex.Unlock()
+		listener.Close()
+		return net.ErrClosed
+	}
+	s.listeners = append(s.listeners, listener)
+	s.mutex.Unlock()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return fmt.Errorf("failed to accept connection: %w", err)
+		}
+		go s.ServeConn(conn)
+	}
+}
+
+// ServeConn runs one client connection until it disconnects. It accepts any
+// net.Conn, so tests can connect a client in-process with net.Pipe.
+func (s *Server) ServeConn(netConn net.Conn) {
+	conn := NewConn(netConn)
+	defer conn.Close()
+
+	sess, err := s.login(conn)
+	if err != nil {
+		log.Printf("Rejected connection from %s: %v", conn.RemoteAddr(), err)
+		conn.Send(MsgDisconnect, Disconnect{Reason: err.Error()})
+		return
+	}
+
+	go sess.writeLoop()
+	s.sendInventory(sess)
+	s.sendChat(func() { s.Chat.SendSystem(sess.name + " joined the game") })
+
+	err = s.readLoop(sess)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) && !errors.Is(err, io.ErrClosedPipe) {
+		log.Printf("Player %s disconnected: %v", sess.name, err)
+	}
+	s.logout(sess)
+}
+
+// login performs the handshake and adds the player to the simulation
+func (s *Server) login(conn *Conn) (*session, error) {
+	conn.SetReadDeadline(time.Now().Add(HandshakeTimeout))
+	env, err := conn.Receive()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read hello: %w", err)
+	}
+	conn.SetReadDeadline(time.Time{})
+
+	if env.Type != MsgHello {
+		return nil, fmt.Errorf("expected hello, got %s", env.Type)
+	}
+	var hello Hello
+	if err := env.Decode(&hello); err != nil {
+		return nil, err
+	}
+	if hello.Magic != ProtocolMagic {
+		return nil, fmt.Errorf("not a TesselBox client")
+	}
+	if hello.ProtocolVersion != ProtocolVersion {
+		return nil, fmt.Errorf("protocol version %d is not supported, server uses version %d",
+			hello.ProtocolVersion, ProtocolVersion)
+	}
+	if err := validatePlayerName(hello.PlayerName); err != nil {
+		return nil, err
+	}
+	name := hello.PlayerName
+
+	if err := s.registerLogin(name, conn.RemoteAddr()); err != nil {
+		return nil, err
+	}
+
+	sess := &session{
+		name:       name,
+		conn:       conn,
+		out:        make(chan []byte, sendQueueSize),
+		done:       make(chan struct{}),
+		sentChunks: make(map[[2]int]bool),
+	}
+
+	s.mutex.Lock()
+	if s.closed {
+		s.mutex.Unlock()
+		return nil, fmt.Errorf("server is shutting down")
+	}
+	if _, online := s.sessions[name]; online {
+		s.mutex.Unlock()
+		return nil, fmt.Errorf("player %s is already online", name)
+	}
+	s.sessions[name] = sess
+	s.mutex.Unlock()
+
+	state, err := s.Simulation.AddPlayer(name)
+	if err != nil {
+		s.removeSession(sess)
+		return nil, err
+	}
+
+	var welcome Welcome
+	sim := s.Simulation
+	sim.WithLock(func() {
+		welcome = Welcome{
+			ProtocolVersion: ProtocolVersion,
+			PlayerName:      name,
+			WorldName:       sim.WorldName,
+			Seed:            sim.World.Seed,
+			Generation:      sim.World.Generation,
+			SpawnX:          state.Player.X,
+			SpawnY:          state.Player.Y,
+			WorldTime:       sim.DayNight.GameTime,
+			TickRate:        sim.TickRate,
+		}
+	})
+	if err := conn.Send(MsgWelcome, welcome); err != nil {
+		s.removeSession(sess)
+		s.Simulation.RemovePlayer(name)
+		return nil, fmt.Errorf("failed to send welcome: %w", err)
+	}
+
+	log.Printf("Player %s logged in from %s", name, conn.RemoteAddr())
+	return sess, nil
+}
+
+// registerLogin records the login in the player registry and rejects bans
+func (s *Server) registerLogin(name, addr string) error {
+	entry, exists := s.Registry.GetByName(name)
+	if !exists {
+		var err error
+		entry, err = s.Registry.Register(name, name, "default")
+		if err != nil {
+			return fmt.Errorf("failed to register player %s: %w", name, err)
+		}
+	}
+	if entry.IsBanned() {
+		if entry.BanReason != "" {
+			return fmt.Errorf("banned: %s", entry.BanReason)
+		}
+		return fmt.Errorf("banned")
+	}
+
+	entry.RecordSession()
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		entry.RecordIP(host)
+	}
+	if err := s.Registry.Update(entry); err != nil {
+		log.Printf("Failed to update registry entry for %s: %v", name, err)
+	}
+	return nil
+}
+
+// validatePlayerName accepts 1-16 letters, digits and underscores
+func validatePlayerName(name string) error {
+	if name == "" || len(name) > maxPlayerNameLength {
+		return fmt.Errorf("player name must be 1-%d characters", maxPlayerNameLength)
+	}
+	for _, r := range name {
+		if !((r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_') {
+			return fmt.Errorf("player name may only contain letters, digits and underscores")
+		}
+	}
+	return nil
+}
+
+// readLoop handles messages from a logged-in client
+func (s *Server) readLoop(sess *session) error {
+	for {
+		env, err := sess.conn.Receive()
+		if err != nil {
+			return err
+		}
+
+		switch env.Type {
+		case MsgPlayerMove:
+			var move PlayerMove
+			if err := env.Decode(&move); err != nil {
+				return err
+			}
+			if err := s.Simulation.MovePlayer(sess.name, move.X, move.Y, move.VX, move.VY); err != nil {
+				log.Printf("Corrected position of %s: %v", sess.name, err)
+				s.sendPosition(sess)
+			}
+
+		case MsgRespawn:
+			if _, _, err := s.Simulation.RespawnPlayer(sess.name); err != nil {
+				return err
+			}
+			s.sendPosition(sess)
+
+		case MsgEquipment:
+			var msg Equipment
+			if err := env.Decode(&msg); err != nil {
+				return err
+			}
+			if err := s.Simulation.SetArmor(sess.name, msg.Pieces()); err != nil {
+				return err
+			}
+
+		case MsgBlockChange:
+			var change BlockChange
+			if err := env.Decode(&change); err != nil {
+				return err
+			}
+			s.handleBlockChange(sess, change)
+			s.acknowledge(sess)
+
+		case MsgFire:
+			var fire Fire
+			if err := env.Decode(&fire); err != nil {
+				return err
+			}
+			if err := s.Simulation.Fire(sess.name, fire.Weapon, fire.Throw, fire.TargetX, fire.TargetY); err != nil {
+				log.Printf("Rejected shot from %s: %v", sess.name, err)
+			}
+			s.acknowledge(sess)
+
+		case MsgCraft:
+			var craft Craft
+			if err := env.Decode(&craft); err != nil {
+				return err
+			}
+			if err := s.Simulation.Craft(sess.name, craft.Recipe, craft.Station, craft.Selected); err != nil {
+				log.Printf("Rejected craft from %s: %v", sess.name, err)
+			}
+			s.acknowledge(sess)
+
+		case MsgUseItem:
+			var use UseItem
+			if err := env.Decode(&use); err != nil {
+				return err
+			}
+			if err := s.Simulation.UseItem(sess.name, use.Slot, use.Item, use.TargetX, use.TargetY); err != nil {
+				log.Printf("Rejected item use from %s: %v", sess.name, err)
+			}
+			s.acknowledge(sess)
+
+		case MsgAnvil:
+			var anvil Anvil
+			if err := env.Decode(&anvil); err != nil {
+				return err
+			}
+			work := server.AnvilWork{Slot: anvil.Slot, Second: anvil.Second, Enchantment: anvil.Enchantment, Level: anvil.Level}
+			if err := s.Simulation.Anvil(sess.name, work); err != nil {
+				log.Printf("Rejected anvil use from %s: %v", sess.name, err)
+			}
+			s.acknowledge(sess)
+
+		case MsgPickUp:
+			var pickUp PickUp
+			if err := env.Decode(&pickUp); err != nil {
+				return err
+			}
+			if err := s.Simulation.PickUp(sess.name, pickUp.ID); err != nil {
+				log.Printf("Rejected pickup from %s: %v", sess.name, err)
+			}
+			s.acknowledge(sess)
+
+		case MsgInventorySync:
+			var moved InventorySync
+			if err := env.Decode(&moved); err != nil {
+				return err
+			}
+			if err := s.syncInventory(sess, moved); err != nil {
+				log.Printf("Rejected inventory from %s: %v", sess.name, err)
+			}
+			s.acknowledge(sess)
+
+		case MsgOpenContainer:
+			var open Container
+			if err := env.Decode(&open); err != nil {
+				return err
+			}
+			s.openContainer(sess, open)
+
+		case MsgCloseContainer:
+			s.Simulation.WithLock(func() { sess.container = nil })
+
+		case MsgChatSend:
+			var msg ChatSend
+			if err := env.Decode(&msg); err != nil {
+				return err
+			}
+			content := strings.TrimSpace(msg.Content)
+			if content == "" {
+				continue
+			}
+			s.sendChat(func() { s.Chat.SendGlobal(sess.name, sess.name, content) })
+
+		case MsgDisconnect:
+			return nil
+
+		default:
+			log.Printf("Ignoring unexpected %s message from %s", env.Type, sess.name)
+		}
+	}
+}
+
+// handleBlockChange applies a player's edit and broadcasts it
+func (s *Server) handleBlockChange(sess *session, change BlockChange) {
+	blockType, ok := blocks.BlockTypeByID(change.Block)
+	if !ok {
+		log.Printf("Player %s sent unknown block %q", sess.name, change.Block)
+		return
+	}
+
+	if err := s.Simulation.SetBlock(sess.name, change.X, change.Y, blockType); err != nil {
+		log.Printf("Rejected block change from %s: %v", sess.name, err)
+		// Undo the change the client already made locally
+		actual := BlockChange{X: change.X, Y: change.Y, Block: blocks.BlockID(s.Simulation.BlockAt(change.X, change.Y))}
+		s.send(sess, MsgBlockChange, actual)
+		return
+	}
+
+	change.Player = sess.name
+	frame, err := encodeMessage(MsgBlockChange, change)
+	if err != nil {
+		log.Printf("Failed to encode block change: %v", err)
+		return
+	}
+
+	chunkX, chunkY := s.Simulation.World.GetChunkCoords(change.X, change.Y)
+	key := [2]int{chunkX, chunkY}
+	for _, other := range s.sessionList() {
+		// Clients that have not received the chunk yet get the change with it
+		if other.hasChunk(s.Simulation, key) {
+			s.queue(other, frame)
+		}
+	}
+}
+
+// syncInventory applies the items a player moved between their inventory and
+// open container, and dropped
+func (s *Server) syncInventory(sess *session, moved InventorySync) error {
+	slots, err := stacks(moved.Slots)
+	if err != nil {
+		return err
+	}
+	dropped, err := stacks(moved.Dropped)
+	if err != nil {
+		return err
+	}
+	var container *server.Container
+	if open := moved.Container; open != nil {
+		contents, err := stacks(open.Slots)
+		if err != nil {
+			return err
+		}
+		container = &server.Container{Kind: open.Kind, X: open.X, Y: open.Y, Slots: contents}
+	}
+	return s.Simulation.SyncInventory(sess.name, slots, container, dropped)
+}
+
+// openContainer sends a player what is in the chest or station they opened,
+// and keeps sending it as it changes
+func (s *Server) openContainer(sess *session, open Container) {
+	if _, err := s.Simulation.OpenContainer(sess.name, open.Kind, open.X, open.Y); err != nil {
+		log.Printf("Rejected container from %s: %v", sess.name, err)
+		return
+	}
+	s.Simulation.WithLock(func() {
+		sess.container = &containerView{kind: open.Kind, x: open.X, y: open.Y}
+		s.pushContainer(sess)
+	})
+}
+
+// hasChunk reports whether a session has been sent a chunk
+func (sess *session) hasChunk(sim *server.Simulation, key [2]int) bool {
+	var sent bool
+	sim.WithLock(func() {
+		sent = sess.sentChunks[key]
+	})
+	return sent
+}
+
+// sendChat runs a ChatManager call; OnMessage relays the result
+func (s *Server) sendChat(fn func()) {
+	s.chatMutex.Lock()
+	defer s.chatMutex.Unlock()
+	fn()
+}
+
+// broadcastChat relays a chat message to every player
+func (s *Server) broadcastChat(msg *chat.ChatMessage) {
+	log.Printf("[chat] %s: %s", msg.SenderName, msg.Content)
+	frame, err := encodeMessage(MsgChat, msg)
+	if err != nil {
+		log.Printf("Failed to encode chat message: %v", err)
+		return
+	}
+	for _, sess := range s.sessionList() {
+		s.queue(sess, frame)
+	}
+}
+
+// onTick streams chunks and entity snapshots; it runs with the simulation locked
+func (s *Server) onTick(tick uint64, players []*server.PlayerState) {
+	sessions := s.sessionList()
+	s.relayWorldChanges(sessions)
+	if len(sessions) == 0 {
+		return
+	}
+
+	positions := make(map[string]*server.PlayerState, len(players))
+	for _, state := range players {
+		positions[state.Name] = state
+	}
+
+	for _, sess := range sessions {
+		state, ok := positions[sess.name]
+		if !ok {
+			continue
+		}
+		s.streamChunks(sess, state)
+	}
+
+	if s.EntityInterval == 0 || tick%s.EntityInterval != 0 {
+		return
+	}
+	all := s.entitySnapshot(players)
+	drops := s.itemDrops()
+	for _, sess := range sessions {
+		state, ok := positions[sess.name]
+		if !ok {
+			continue
+		}
+		s.sendEntities(sess, state, tick, all, drops)
+		s.pushContainer(sess)
+	}
+}
+
+// relayWorldChanges sends the world's own block changes to every session that
+// has the affected chunk; it runs with the simulation locked
+func (s *Server) relayWorldChanges(sessions []*session) {
+	changes := s.worldChanges
+	s.worldChanges = nil
+
+	w := s.Simulation.World
+	for _, change := range changes {
+		frame, err := encodeMessage(MsgBlockChange, change)
+		if err != nil {
+			log.Printf("Failed to encode block change: %v", err)
+			continue
+		}
+		chunkX, chunkY := w.GetChunkCoords(change.X, change.Y)
+		key := [2]int{chunkX, chunkY}
+		for _, sess := range sessions {
+			// Clients that have not received the chunk yet get the change with it
+			if sess.sentChunks[key] {
+				s.queue(sess, frame)
+			}
+		}
+	}
+}
+
+// streamChunks sends the nearest chunks a player has not received yet and
+// forgets chunks they have moved away from so they are resent on return
+func (s *Server) streamChunks(sess *session, state *server.PlayerState) {
+	w := s.Simulation.World
+	centerX, centerY := state.Player.GetCenter()
+	playerChunkX, playerChunkY := w.GetChunkCoords(centerX, centerY)
+
+	for key := range sess.sentChunks {
+		dx, dy := key[0]-playerChunkX, key[1]-playerChunkY
+		if dx*dx+dy*dy > world.ChunkUnloadDistance*world.ChunkUnloadDistance {
+			delete(sess.sentChunks, key)
+		}
+	}
+
+	var pending [][2]int
+	for dx := -world.RenderDistance; dx <= world.RenderDistance; dx++ {
+		for dy := -world.RenderDistance; dy <= world.RenderDistance; dy++ {
+			key := [2]int{playerChunkX + dx, playerChunkY + dy}
+			if !sess.sentChunks[key] {
+				pending = append(pending, key)
+			}
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		di := abs(pending[i][0]-playerChunkX) + abs(pending[i][1]-playerChunkY)
+		dj := abs(pending[j][0]-playerChunkX) + abs(pending[j][1]-playerChunkY)
+		return di < dj
+	})
+
+	budget := s.ChunksPerTick
+	if budget <= 0 {
+		budget = DefaultChunksPerTick
+	}
+	for i := 0; i < len(pending) && i < budget; i++ {
+		key := pending[i]
+		data, blockIDs := w.ExportChunk(key[0], key[1])
+		frame, err := encodeMessage(MsgChunk, ChunkMessage{Blocks: blockIDs, Chunk: data})
+		if err != nil {
+			log.Printf("Failed to encode chunk %d,%d: %v", key[0], key[1], err)
+			continue
+		}
+		if !s.queue(sess, frame) {
+			return
+		}
+		sess.sentChunks[key] = true
+	}
+}
+
+// entitySnapshot collects every player, zombie and creature
+func (s *Server) entitySnapshot(players []*server.PlayerState) []EntityState {
+	sim := s.Simulation
+	var entities []EntityState
+	for _, state := range players {
+		entities = append(entities, EntityState{
+			ID:        state.Name,
+			Kind:      EntityPlayer,
+			X:         state.Player.X,
+			Y:         state.Player.Y,
+			Health:    state.Player.Health,
+			MaxHealth: state.Player.MaxHealth,
+		})
+	}
+	for _, zombie := range sim.Zombies.Mobs {
+		if !zombie.IsAlive() {
+			continue
+		}
+		entities = append(entities, EntityState{
+			ID:        zombie.ID,
+			Kind:      EntityZombie,
+			Mob:       zombie.Type,
+			X:         zombie.X,
+			Y:         zombie.Y,
+			Health:    zombie.Health,
+			MaxHealth: zombie.MaxHealth,
+			Burning:   zombie.IsBurning,
+		})
+	}
+	for _, creature := range sim.World.Creatures {
+		entities = append(entities, EntityState{
+			ID:        creature.ID,
+			Kind:      EntityCreature,
+			Mob:       creature.Type,
+			X:         creature.X,
+			Y:         creature.Y,
+			Health:    creature.Health,
+			MaxHealth: creature.MaxHealth,
+		})
+	}
+	return entities
+}
+
+// itemDrops lists the items lying in the world by ID
+func (s *Server) itemDrops() []ItemDrop {
+	drops := make([]ItemDrop, 0, len(s.Simulation.Drops))
+	for _, drop := range s.Simulation.Drops {
+		drops = append(drops, ItemDrop{ID: drop.ID, X: drop.X, Y: drop.Y, Item: NewInventorySlot(drop.Item)})
+	}
+	sort.Slice(drops, func(i, j int) bool {
+		return drops[i].ID < drops[j].ID
+	})
+	return drops
+}
+
+// sendEntities sends the part of a snapshot within view of a player
+func (s *Server) sendEntities(sess *session, state *server.PlayerState, tick uint64, all []EntityState, drops []ItemDrop) {
+	msg := Entities{
+		Tick:      tick,
+		WorldTime: s.Simulation.DayNight.GameTime,
+		Weather:   s.Simulation.Weather.GetCurrentWeather(),
+	}
+	for _, entity := range all {
+		dx, dy := entity.X-state.Player.X, entity.Y-state.Player.Y
+		if dx*dx+dy*dy <= EntityViewDistance*EntityViewDistance {
+			msg.Entities = append(msg.Entities, entity)
+		}
+	}
+	for _, drop := range drops {
+		dx, dy := drop.X-state.Player.X, drop.Y-state.Player.Y
+		if dx*dx+dy*dy <= EntityViewDistance*EntityViewDistance {
+			msg.Items = append(msg.Items, drop)
+		}
+	}
+
+	frame, err := encodeMessage(MsgEntities, msg)
+	if err != nil {
+		log.Printf("Failed to encode entities for %s: %v", sess.name, err)
+		return
+	}
+	s.queue(sess, frame)
+}
+
+// queue hands a frame to a session's writer without blocking. A client that
+// falls too far behind is disconnected rather than stalling the simulation.
+func (s *Server) queue(sess *session, frame []byte) bool {
+	select {
+	case <-sess.done:
+		return false
+	default:
+	}
+
+	select {
+	case sess.out <- frame:
+		return true
+	default:
+		log.Printf("Disconnecting %s: send queue full", sess.name)
+		sess.close()
+		return false
+	}
+}
+
+// send encodes a message and queues it for one session
+func (s *Server) send(sess *session, msgType MessageType, payload interface{}) {
+	frame, err := encodeMessage(msgType, payload)
+	if err != nil {
+		log.Printf("Failed to encode %s message: %v", msgType, err)
+		return
+	}
+	s.queue(sess, frame)
+}
+
+// sendPosition corrects a client with the position the server holds
+func (s *Server) sendPosition(sess *session) {
+	x, y, vx, vy, ok := s.Simulation.PlayerPosition(sess.name)
+	if !ok {
+		return
+	}
+	s.send(sess, MsgPlayerPosition, PlayerMove{X: x, Y: y, VX: vx, VY: vy})
+}
+
+// acknowledge counts an inventory action from a client as handled, whether
+// it was accepted or not, and sends the inventory and container it left
+func (s *Server) acknowledge(sess *session) {
+	sess.acked.Add(1)
+	s.sendInventory(sess)
+	s.Simulation.WithLock(func() { s.pushContainer(sess) })
+}
+
+// sendInventory sends a player the inventory the server holds for them
+func (s *Server) sendInventory(sess *session) {
+	slots, ok := s.Simulation.Inventory(sess.name)
+	if !ok {
+		return
+	}
+	s.send(sess, MsgInventory, Inventory{Slots: NewInventorySlots(slots), Ack: sess.acked.Load()})
+}
+
+// pushContainer sends a player what is in their open container if it changed
+// since it was last sent, and forgets containers that are gone; it runs with
+// the simulation locked
+func (s *Server) pushContainer(sess *session) {
+	view := sess.container
+	if view == nil {
+		return
+	}
+	container := s.Simulation.ReadContainer(view.kind, view.x, view.y)
+	if container == nil {
+		sess.container = nil
+		return
+	}
+	frame, err := encodeMessage(MsgContainer, Container{
+		Kind:      container.Kind,
+		X:         container.X,
+		Y:         container.Y,
+		Slots:     NewInventorySlots(container.Slots),
+		Burn:      container.Burn,
+		BurnTotal: container.BurnTotal,
+		Progress:  container.Progress,
+		Ack:       sess.acked.Load(),
+	})
+	if err != nil {
+		log.Printf("Failed to encode container for %s: %v", sess.name, err)
+		return
+	}
+	if !bytes.Equal(frame, view.sent) && s.queue(sess, frame) {
+		view.sent = frame
+	}
+}
+
+// sendDamage tells a player a projectile hit them; it runs with the
+// simulation locked
+func (s *Server) sendDamage(victim *server.PlayerState, impact combat.ProjectileImpact) {
+	s.mutex.Lock()
+	sess, ok := s.sessions[victim.Name]
+	s.mutex.Unlock()
+	if !ok {
+		return
+	}
+	s.send(sess, MsgDamage, Damage{
+		Attacker: impact.Projectile.Owner,
+		Weapon:   impact.Projectile.Weapon,
+		Amount:   impact.Result.Damage,
+		Critical: impact.Result.IsCritical,
+	})
+}
+
+// writeLoop writes queued frames until the session closes
+func (sess *session) writeLoop() {
+	for {
+		select {
+		case frame := <-sess.out:
+			if err := sess.conn.writeFrame(frame); err != nil {
+				sess.close()
+				return
+			}
+		case <-sess.done:
+			return
+		}
+	}
+}
+
+// close stops the writer and unblocks the reader
+func (sess *session) close() {
+	sess.closeOnce.Do(func() {
+		close(sess.done)
+		sess.conn.Close()
+	})
+}
+
+// logout removes a player, saves them and tells everyone else
+func (s *Server) logout(sess *session) {
+	sess.close()
+	s.removeSession(sess)
+
+	if err := s.Simulation.RemovePlayer(sess.name); err != nil {
+		log.Printf("Failed to save player %s: %v", sess.name, err)
+	}
+	if err := s.Registry.Save(); err != nil {
+		log.Printf("Failed to save player registry: %v", err)
+	}
+
+	log.Printf("Player %s logged out", sess.name)
+	s.sendChat(func() { s.Chat.SendSystem(sess.name + " left the game") })
+}
+
+// removeSession drops a session from the online list
+func (s *Server) removeSession(sess *session) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.sessions[sess.name] == sess {
+		delete(s.sessions, sess.name)
+	}
+}
+
+// sessionList returns the online sessions
+func (s *Server) sessionList() []*session {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	sessions := make([]*session, 0, len(s.sessions))
+	for _, sess := range s.sessions {
+		sessions = append(sessions, sess)
+	}
+	return sessions
+}
+
+// OnlinePlayers returns the names of logged-in players
+func (s *Server) OnlinePlayers() []string {
+	var names []string
+	for _, sess := range s.sessionList() {
+		names = append(names, sess.name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Close stops accepting connections and disconnects every player
+func (s *Server) Close() error {
+	s.mutex.Lock()
+	s.closed = true
+	listeners := s.listeners
+	s.listeners = nil
+	s.mutex.Unlock()
+
+	for _, listener := range listeners {
+		listener.Close()
+	}
+	for _, sess := range s.sessionList() {
+		sess.conn.SetWriteDeadline(time.Now().Add(time.Second))
+		sess.conn.Send(MsgDisconnect, Disconnect{Reason: "server closed"})
+		sess.close()
+	}
+	return nil
+}
+
+// abs returns the absolute value of an int
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,293 @@
+package network
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"tesselbox/pkg/blocks"
+	"tesselbox/pkg/chat"
+	"tesselbox/pkg/config"
+	"tesselbox/pkg/items"
+	"tesselbox/pkg/server"
+	"tesselbox/pkg/world"
+)
+
+// receiveTimeout bounds how long a test waits for a message
+const receiveTimeout = 5 * time.Second
+
+// newTestServer starts a server on a fresh world that is deleted afterwards
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+	worldName := fmt.Sprintf("network_test_%d", time.Now().UnixNano())
+	t.Cleanup(func() {
+		os.RemoveAll(config.GetWorldSaveDir(worldName))
+		os.RemoveAll(world.NewWorldStorage(worldName).WorldDir)
+	})
+
+	sim, err := server.NewSimulation(worldName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := NewServer(sim)
+	t.Cleanup(func() { srv.Close() })
+	return srv
+}
+
+// connect logs a player in to a server over net.Pipe
+func connect(t *testing.T, srv *Server, name string) *Client {
+	t.Helper()
+	clientConn, serverConn := net.Pipe()
+	go srv.ServeConn(serverConn)
+
+	client, err := NewClient(clientConn, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+// receive waits for the next message of a type, skipping others
+func receive(t *testing.T, client *Client, msgType MessageType, v interface{}) {
+	t.Helper()
+	timeout := time.After(receiveTimeout)
+	for {
+		select {
+		case env, ok := <-client.Messages():
+			if !ok {
+				t.Fatalf("connection closed waiting for %s: %v", msgType, client.Err())
+			}
+			if env.Type != msgType {
+				continue
+			}
+			if err := env.Decode(v); err != nil {
+				t.Fatal(err)
+			}
+			return
+		case <-timeout:
+			t.Fatalf("no %s message", msgType)
+		}
+	}
+}
+
+// setInventory replaces a player's inventory on the server
+func setInventory(t *testing.T, srv *Server, name string, stacks ...items.Item) {
+	t.Helper()
+	for _, state := range srv.Simulation.Players() {
+		if state.Name != name {
+			continue
+		}
+		srv.Simulation.WithLock(func() {
+			for i := range state.Inventory.Slots {
+				state.Inventory.Slots[i] = items.Item{Type: items.NONE, Durability: -1}
+			}
+			copy(state.Inventory.Slots, stacks)
+		})
+		return
+	}
+	t.Fatalf("player %s is not in the world", name)
+}
+
+func TestHandshakeVersionMismatch(t *testing.T) {
+	srv := newTestServer(t)
+	clientConn, serverConn := net.Pipe()
+	go srv.ServeConn(serverConn)
+
+	conn := NewConn(clientConn)
+	defer conn.Close()
+	hello := Hello{Magic: ProtocolMagic, ProtocolVersion: ProtocolVersion - 1, PlayerName: "Old"}
+	if err := conn.Send(MsgHello, hello); err != nil {
+		t.Fatal(err)
+	}
+	env, err := conn.Receive()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if env.Type != MsgDisconnect {
+		t.Fatalf("got %s, want %s", env.Type, MsgDisconnect)
+	}
+	var reason Disconnect
+	if err := env.Decode(&reason); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(reason.Reason, "protocol version") {
+		t.Errorf("reason %q does not mention the protocol version", reason.Reason)
+	}
+}
+
+func TestLogin(t *testing.T) {
+	srv := newTestServer(t)
+	client := connect(t, srv, "Alice")
+
+	welcome := client.Welcome
+	if welcome.ProtocolVersion != ProtocolVersion || welcome.PlayerName != "Alice" {
+		t.Errorf("welcome = version %d player %q", welcome.ProtocolVersion, welcome.PlayerName)
+	}
+	if welcome.WorldName != srv.Simulation.WorldName || welcome.Seed != srv.Simulation.World.Seed {
+		t.Errorf("welcome = world %q seed %d", welcome.WorldName, welcome.Seed)
+	}
+
+	var inventory Inventory
+	receive(t, client, MsgInventory, &inventory)
+	if inventory.Ack != 0 {
+		t.Errorf("login inventory ack = %d, want 0", inventory.Ack)
+	}
+	if got := srv.OnlinePlayers(); len(got) != 1 || got[0] != "Alice" {
+		t.Errorf("online players = %v", got)
+	}
+
+	// The same name cannot log in twice
+	clientConn, serverConn := net.Pipe()
+	go srv.ServeConn(serverConn)
+	if _, err := NewClient(clientConn, "Alice"); err == nil {
+		t.Error("second login as Alice succeeded")
+	}
+}
+
+func TestChunkStreaming(t *testing.T) {
+	srv := newTestServer(t)
+	client := connect(t, srv, "Alice")
+
+	srv.Simulation.Tick(0.05)
+	var msg ChunkMessage
+	receive(t, client, MsgChunk, &msg)
+	if msg.Chunk == nil || len(msg.Blocks) == 0 {
+		t.Fatalf("chunk message without a chunk or palette: %+v", msg)
+	}
+	x, y := msg.Chunk.ChunkX, msg.Chunk.ChunkY
+	if x < -2 || x > 2 || y < -2 || y > 2 {
+		t.Errorf("first chunk streamed is (%d, %d), far from spawn", x, y)
+	}
+}
+
+func TestBlockChangeBroadcast(t *testing.T) {
+	srv := newTestServer(t)
+	alice := connect(t, srv, "Alice")
+	bob := connect(t, srv, "Bob")
+
+	// Both need the chunks around spawn before changes in them are sent
+	for range 20 {
+		srv.Simulation.Tick(0.05)
+	}
+
+	var target *world.Hexagon
+	for _, state := range srv.Simulation.Players() {
+		if state.Name != "Alice" {
+			continue
+		}
+		srv.Simulation.WithLock(func() {
+			centerX, centerY := state.Player.GetCenter()
+			nearest := math.Inf(1)
+			for _, hex := range srv.Simulation.World.GetNearbyHexagons(centerX, centerY, 200) {
+				distance := math.Hypot(hex.X-centerX, hex.Y-centerY)
+				if hex.BlockType != blocks.AIR && hex.BlockType != blocks.WATER && distance < nearest {
+					target, nearest = hex, distance
+				}
+			}
+		})
+	}
+	if target == nil {
+		t.Fatal("no block next to the spawn point")
+	}
+
+	if err := alice.SendBlockChange(target.X, target.Y, "air"); err != nil {
+		t.Fatal(err)
+	}
+	// Liquids flowing in the world are relayed too
+	var change BlockChange
+	for change.Player == "" {
+		receive(t, bob, MsgBlockChange, &change)
+	}
+	if change.Player != "Alice" || change.Block != "air" || change.X != target.X || change.Y != target.Y {
+		t.Errorf("Bob got %+v", change)
+	}
+
+	// Alice is acknowledged with her inventory, holding what she mined
+	var inventory Inventory
+	receive(t, alice, MsgInventory, &inventory)
+	for inventory.Ack == 0 {
+		receive(t, alice, MsgInventory, &inventory)
+	}
+	if !alice.Acknowledged(inventory.Ack) {
+		t.Errorf("ack %d does not cover the block change", inventory.Ack)
+	}
+}
+
+func TestChatRelay(t *testing.T) {
+	srv := newTestServer(t)
+	alice := connect(t, srv, "Alice")
+	bob := connect(t, srv, "Bob")
+
+	if err := alice.SendChat("hello there"); err != nil {
+		t.Fatal(err)
+	}
+	var msg chat.ChatMessage
+	for msg.SenderName != "Alice" {
+		receive(t, bob, MsgChat, &msg)
+	}
+	if msg.Content != "hello there" {
+		t.Errorf("Bob got %q", msg.Content)
+	}
+}
+
+func TestInventorySync(t *testing.T) {
+	srv := newTestServer(t)
+	client := connect(t, srv, "Alice")
+	var inventory Inventory
+	receive(t, client, MsgInventory, &inventory)
+
+	setInventory(t, srv, "Alice",
+		items.Item{Type: items.COAL, Quantity: 5, Durability: -1},
+		items.Item{Type: items.STICK, Quantity: 2, Durability: -1},
+	)
+	slots, _ := srv.Simulation.Inventory("Alice")
+
+	// Swapping two stacks is accepted
+	slots[0], slots[1] = slots[1], slots[0]
+	if err := client.SendInventorySync(InventorySync{Slots: NewInventorySlots(slots)}); err != nil {
+		t.Fatal(err)
+	}
+	receive(t, client, MsgInventory, &inventory)
+	if inventory.Ack != 1 {
+		t.Fatalf("ack = %d, want 1", inventory.Ack)
+	}
+	if inventory.Slots[0].Item != "stick" || inventory.Slots[1].Item != "coal" {
+		t.Errorf("swap not applied: %+v", inventory.Slots[:2])
+	}
+
+	// Making coal out of nothing is rejected, and the server's inventory resent
+	created := append([]items.Item(nil), slots...)
+	created[1].Quantity = 64
+	if err := client.SendInventorySync(InventorySync{Slots: NewInventorySlots(created)}); err != nil {
+		t.Fatal(err)
+	}
+	receive(t, client, MsgInventory, &inventory)
+	if inventory.Ack != 2 {
+		t.Fatalf("ack = %d, want 2", inventory.Ack)
+	}
+	if inventory.Slots[1].Item != "coal" || inventory.Slots[1].Quantity != 5 {
+		t.Errorf("coal after rejected sync = %+v, want 5", inventory.Slots[1])
+	}
+
+	// Dropping a stack takes it from the inventory into the world
+	dropped := append([]items.Item(nil), slots...)
+	dropped[0] = items.Item{Type: items.NONE, Durability: -1}
+	sync := InventorySync{Slots: NewInventorySlots(dropped), Dropped: NewInventorySlots(slots[:1])}
+	if err := client.SendInventorySync(sync); err != nil {
+		t.Fatal(err)
+	}
+	receive(t, client, MsgInventory, &inventory)
+	if inventory.Slots[0].Quantity != 0 {
+		t.Errorf("dropped slot still holds %+v", inventory.Slots[0])
+	}
+	var drops []ItemDrop
+	srv.Simulation.WithLock(func() { drops = srv.itemDrops() })
+	if len(drops) != 1 || drops[0].Item.Item != "stick" || drops[0].Item.Quantity != 2 {
+		t.Errorf("drops = %+v, want the 2 sticks", drops)
+	}
+}
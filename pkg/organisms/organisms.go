@@ -1,12 +1,14 @@
 package organisms
 
 import (
+	"image/color"
 	"log"
 	"math"
 	"tesselbox/pkg/hexagon"
 	"time"
 
 	"tesselbox/assets"
+	"tesselbox/pkg/items"
 
 	"gopkg.in/yaml.v3"
 )
@@ -372,6 +374,35 @@ func GetDrops(org *Organism) []string {
 	return []string{}
 }
 
+// DropItems returns the items an organism drops when destroyed, one of each
+// of its drops, leaving out drops that are not registered items
+func DropItems(org *Organism) []items.Item {
+	var drops []items.Item
+	for _, id := range GetDrops(org) {
+		itemType, ok := items.ItemTypeByID(id)
+		if !ok {
+			continue
+		}
+		drops = append(drops, items.Item{Type: itemType, Quantity: 1, Durability: -1})
+	}
+	return drops
+}
+
+// Color returns the color an organism's definition gives it, or gray if it
+// has none
+func (org *Organism) Color() color.RGBA {
+	if props, ok := OrganismDefinitions[org.TypeString]; ok {
+		if rgba, ok := props.Appearance["color"].([]interface{}); ok && len(rgba) >= 3 {
+			c := color.RGBA{uint8(toInt(rgba[0])), uint8(toInt(rgba[1])), uint8(toInt(rgba[2])), 255}
+			if len(rgba) >= 4 {
+				c.A = uint8(toInt(rgba[3]))
+			}
+			return c
+		}
+	}
+	return color.RGBA{128, 128, 128, 255}
+}
+
 // CanAttack checks if an organism can attack based on cooldown
 func (org *Organism) CanAttack() bool {
 	if !org.IsHostile {
@@ -0,0 +1,95 @@
+// Package overrides stacks the definitions plugins lay over built-in ones,
+// such as biomes and mob types, so each can be released independently
+package overrides
+
+// Registry holds the definitions stacked for each key. The built-in
+// definition has no owner and is always at the bottom; owners stack theirs
+// on top, so an owner overriding a key another owner overrode takes effect
+// until it is released, whatever order the two are released in.
+//
+// A Registry does no locking; callers guard it with the mutex that guards
+// whatever they derive from it.
+type Registry[K comparable, V any] struct {
+	layers map[K][]layer[V]
+}
+
+// layer is one definition of a key
+type layer[V any] struct {
+	owner string
+	value V
+}
+
+// New creates an empty registry
+func New[K comparable, V any]() *Registry[K, V] {
+	return &Registry[K, V]{layers: make(map[K][]layer[V])}
+}
+
+// SetBuiltin sets the built-in definition of a key, adding the key if it is
+// new. Overrides of the key stay on top of it.
+func (r *Registry[K, V]) SetBuiltin(key K, value V) {
+	layers := r.layers[key]
+	if len(layers) > 0 && layers[0].owner == "" {
+		layers[0].value = value
+		return
+	}
+	r.layers[key] = append([]layer[V]{{value: value}}, layers...)
+}
+
+// Override stacks an owner's definition of a key on top of any others,
+// adding the key if it is new. Owners must not be empty.
+func (r *Registry[K, V]) Override(owner string, key K, value V) {
+	r.layers[key] = append(r.layers[key], layer[V]{owner: owner, value: value})
+}
+
+// Release removes every definition an owner stacked with Override and
+// returns the keys that lost one. Keys with no definition left are removed.
+func (r *Registry[K, V]) Release(owner string) []K {
+	var changed []K
+	for key, layers := range r.layers {
+		kept := layers[:0]
+		for _, l := range layers {
+			if l.owner != owner {
+				kept = append(kept, l)
+			}
+		}
+		if len(kept) == len(layers) {
+			continue
+		}
+		if len(kept) == 0 {
+			delete(r.layers, key)
+		} else {
+			r.layers[key] = kept
+		}
+		changed = append(changed, key)
+	}
+	return changed
+}
+
+// Top returns the definition of a key in effect: the topmost one
+func (r *Registry[K, V]) Top(key K) (V, bool) {
+	layers := r.layers[key]
+	if len(layers) == 0 {
+		var zero V
+		return zero, false
+	}
+	return layers[len(layers)-1].value, true
+}
+
+// Builtin returns the built-in definition of a key, if it has one
+func (r *Registry[K, V]) Builtin(key K) (V, bool) {
+	layers := r.layers[key]
+	if len(layers) == 0 || layers[0].owner != "" {
+		var zero V
+		return zero, false
+	}
+	return layers[0].value, true
+}
+
+// Keys returns every key with a definition, in no particular order
+func (r *Registry[K, V]) Keys() []K {
+	keys := make([]K, 0, len(r.layers))
+	for key := range r.layers {
+		keys = append(keys, key)
+	}
+	return keys
+}
@@ -0,0 +1,65 @@
+package overrides
+
+import (
+	"slices"
+	"testing"
+)
+
+// wantTop checks the definition of a key in effect
+func wantTop(t *testing.T, r *Registry[string, string], key, want string) {
+	t.Helper()
+	got, ok := r.Top(key)
+	if want == "" {
+		if ok {
+			t.Fatalf("%s is still defined as %q", key, got)
+		}
+		return
+	}
+	if !ok || got != want {
+		t.Fatalf("%s in effect = %q, want %q", key, got, want)
+	}
+}
+
+func TestOverrideStack(t *testing.T) {
+	r := New[string, string]()
+	r.SetBuiltin("plains", "built-in")
+	r.Override("first", "plains", "first")
+	r.Override("second", "plains", "second")
+	wantTop(t, r, "plains", "second")
+
+	// Releasing the older override keeps the newer one in effect
+	if changed := r.Release("first"); !slices.Equal(changed, []string{"plains"}) {
+		t.Errorf("release changed %v, want [plains]", changed)
+	}
+	wantTop(t, r, "plains", "second")
+
+	// Reloading the built-in definition stays under the override
+	r.SetBuiltin("plains", "reloaded")
+	wantTop(t, r, "plains", "second")
+	if builtin, _ := r.Builtin("plains"); builtin != "reloaded" {
+		t.Errorf("built-in = %q, want reloaded", builtin)
+	}
+
+	r.Release("second")
+	wantTop(t, r, "plains", "reloaded")
+}
+
+func TestReleaseRemovesAddedKey(t *testing.T) {
+	r := New[string, string]()
+	r.SetBuiltin("plains", "built-in")
+	r.Override("plugin", "added", "plugin")
+	if _, ok := r.Builtin("added"); ok {
+		t.Error("added key has a built-in definition")
+	}
+
+	if changed := r.Release("plugin"); !slices.Equal(changed, []string{"added"}) {
+		t.Errorf("release changed %v, want [added]", changed)
+	}
+	wantTop(t, r, "added", "")
+	if keys := r.Keys(); !slices.Equal(keys, []string{"plains"}) {
+		t.Errorf("keys = %v, want [plains]", keys)
+	}
+	if changed := r.Release("plugin"); len(changed) != 0 {
+		t.Errorf("releasing again changed %v", changed)
+	}
+}
@@ -0,0 +1,102 @@
+package palette
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// Palette maps stable string IDs ("stone", "iron_pickaxe", "plugin:foo/bar")
+// to the compact numeric IDs written in save data. Entries are only ever
+// appended, so a numeric ID keeps its meaning for the lifetime of a save even
+// when the game's runtime constants are reordered or plugins are removed.
+type Palette struct {
+	mutex   sync.RWMutex
+	entries []string
+	index   map[string]int
+}
+
+// New creates a palette pre-populated with the given IDs in order
+func New(ids ...string) *Palette {
+	p := &Palette{index: make(map[string]int)}
+	for _, id := range ids {
+		p.add(id)
+	}
+	return p
+}
+
+// add appends an ID; callers must hold the write lock
+func (p *Palette) add(id string) int {
+	if idx, ok := p.index[id]; ok {
+		return idx
+	}
+	idx := len(p.entries)
+	p.entries = append(p.entries, id)
+	p.index[id] = idx
+	return idx
+}
+
+// IDFor returns the numeric ID of a string ID, appending it if needed
+func (p *Palette) IDFor(id string) int {
+	p.mutex.RLock()
+	idx, ok := p.index[id]
+	p.mutex.RUnlock()
+	if ok {
+		return idx
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.add(id)
+}
+
+// Lookup returns the string ID stored under a numeric ID
+func (p *Palette) Lookup(idx int) (string, bool) {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	if idx < 0 || idx >= len(p.entries) {
+		return "", false
+	}
+	return p.entries[idx], true
+}
+
+// Len returns the number of entries
+func (p *Palette) Len() int {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	return len(p.entries)
+}
+
+// Entries returns a copy of the string IDs ordered by numeric ID
+func (p *Palette) Entries() []string {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	entries := make([]string, len(p.entries))
+	copy(entries, p.entries)
+	return entries
+}
+
+// MarshalJSON writes the palette as an array of string IDs
+func (p *Palette) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.Entries())
+}
+
+// UnmarshalJSON reads a palette written by MarshalJSON
+func (p *Palette) UnmarshalJSON(data []byte) error {
+	var entries []string
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return fmt.Errorf("failed to unmarshal palette: %w", err)
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.entries = nil
+	p.index = make(map[string]int, len(entries))
+	for i, id := range entries {
+		if _, dup := p.index[id]; dup {
+			return fmt.Errorf("duplicate palette entry %q at %d", id, i)
+		}
+		p.add(id)
+	}
+	return nil
+}
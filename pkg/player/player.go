@@ -46,6 +46,9 @@ type Player struct {
 	Health    float64
 	MaxHealth float64
 
+	// Experience from killing mobs, spent at the anvil
+	XP int
+
 	// Time tracking for delta time
 	LastUpdateTime time.Time
 }
@@ -250,6 +253,22 @@ func (p *Player) Heal(amount float64) {
 	}
 }
 
+// AddXP gives the player experience
+func (p *Player) AddXP(amount int) {
+	if amount > 0 {
+		p.XP += amount
+	}
+}
+
+// SpendXP takes experience from the player if they have enough
+func (p *Player) SpendXP(amount int) bool {
+	if amount < 0 || p.XP < amount {
+		return false
+	}
+	p.XP -= amount
+	return true
+}
+
 // IsAlive returns true if player health is greater than 0
 func (p *Player) IsAlive() bool {
 	return p.Health > 0
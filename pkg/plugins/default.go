@@ -4,11 +4,10 @@ import (
 	"fmt"
 	"log"
 	"math/rand"
-	"time"
 
 	"tesselbox/pkg/audio"
 	"tesselbox/pkg/blocks"
-	"tesselbox/pkg/creatures"
+	"tesselbox/pkg/mobs"
 	"tesselbox/pkg/organisms"
 	"tesselbox/pkg/world"
 )
@@ -114,24 +113,24 @@ func (dp *DefaultPlugin) GetBlockProperties(blockType blocks.BlockType) (map[str
 }
 
 // GetCreatureTypes returns all available creature types
-func (dp *DefaultPlugin) GetCreatureTypes() []creatures.CreatureType {
-	return []creatures.CreatureType{
-		creatures.SLIME,
-		creatures.SPIDER,
-		creatures.ZOMBIE,
-	}
+func (dp *DefaultPlugin) GetCreatureTypes() []string {
+	return mobs.TypeIDs()
 }
 
 // GetCreatureDefinition returns a specific creature definition
-func (dp *DefaultPlugin) GetCreatureDefinition(creatureType creatures.CreatureType) (*CreatureDefinition, bool) {
-	// Create a basic creature definition
+func (dp *DefaultPlugin) GetCreatureDefinition(mobType string) (*CreatureDefinition, bool) {
+	// Describe the registered mob type
+	mob, ok := mobs.GetType(mobType)
+	if !ok {
+		return nil, false
+	}
 	def := &CreatureDefinition{
-		Type:   creatureType,
-		Name:   stringToCreatureName(creatureType),
-		Health: getDefaultCreatureHealth(creatureType),
-		Damage: getDefaultCreatureDamage(creatureType),
-		Speed:  getDefaultCreatureSpeed(creatureType),
-		Color:  getDefaultCreatureColor(creatureType),
+		Type:   mobType,
+		Name:   mob.Name,
+		Health: mob.Health,
+		Damage: mob.Damage,
+		Speed:  mob.Speed,
+		Color:  fmt.Sprintf("#%02X%02X%02X", mob.Color[0], mob.Color[1], mob.Color[2]),
 	}
 	return def, true
 }
@@ -216,14 +215,14 @@ func (dp *DefaultPlugin) OnBlockBroken(x, y, z int, blockType blocks.BlockType)
 }
 
 // OnCreatureSpawn handles creature spawn events
-func (dp *DefaultPlugin) OnCreatureSpawn(creature *creatures.Creature) error {
-	log.Printf("Creature spawned: %s", stringToCreatureName(creature.Type))
+func (dp *DefaultPlugin) OnCreatureSpawn(creature *mobs.Mob) error {
+	log.Printf("Creature spawned: %s", creature.Def.Name)
 	return nil
 }
 
 // OnCreatureDeath handles creature death events
-func (dp *DefaultPlugin) OnCreatureDeath(creature *creatures.Creature) error {
-	log.Printf("Creature died: %s", stringToCreatureName(creature.Type))
+func (dp *DefaultPlugin) OnCreatureDeath(creature *mobs.Mob) error {
+	log.Printf("Creature died: %s", creature.Def.Name)
 	return nil
 }
 
@@ -259,11 +258,7 @@ func (dp *DefaultPlugin) spawnRandomOrganism(world *world.World) {
 }
 
 func (dp *DefaultPlugin) spawnRandomCreature(world *world.World) {
-	creatureTypes := []creatures.CreatureType{
-		creatures.SLIME,
-		creatures.SPIDER,
-		creatures.ZOMBIE,
-	}
+	creatureTypes := []string{"slime", "spider", "zombie"}
 
 	creatureType := creatureTypes[rand.Intn(len(creatureTypes))]
 
@@ -271,21 +266,9 @@ func (dp *DefaultPlugin) spawnRandomCreature(world *world.World) {
 	x := rand.Intn(100)
 	y := 50
 
-	creature := &creatures.Creature{
-		ID:        fmt.Sprintf("creature_%d", rand.Intn(10000)),
-		Type:      creatureType,
-		X:         float64(x),
-		Y:         float64(y),
-		Health:    getDefaultCreatureHealth(creatureType),
-		MaxHealth: getDefaultCreatureHealth(creatureType),
-		Damage:    getDefaultCreatureDamage(creatureType),
-		Speed:     getDefaultCreatureSpeed(creatureType),
-		AIState:   creatures.IDLE,
-		IsHostile: isCreatureHostile(creatureType),
-		SpawnTime: time.Now(),
+	if creature, ok := mobs.Spawn(creatureType, float64(x), float64(y)); ok {
+		world.Creatures = append(world.Creatures, creature)
 	}
-
-	world.Creatures = append(world.Creatures, creature)
 }
 
 // Simple helper functions for demonstration
@@ -306,18 +289,6 @@ func stringToBlockName(blockType blocks.BlockType) string {
 	return "Unknown"
 }
 
-func stringToCreatureName(creatureType creatures.CreatureType) string {
-	names := map[creatures.CreatureType]string{
-		creatures.SLIME:  "Slime",
-		creatures.SPIDER: "Spider",
-		creatures.ZOMBIE: "Zombie",
-	}
-	if name, exists := names[creatureType]; exists {
-		return name
-	}
-	return "Unknown"
-}
-
 func stringToOrganismName(organismType organisms.OrganismType) string {
 	names := map[organisms.OrganismType]string{
 		organisms.TREE:          "Tree",
@@ -422,58 +393,6 @@ func isBlockBreakable(blockType blocks.BlockType) bool {
 	return true
 }
 
-func getDefaultCreatureHealth(creatureType creatures.CreatureType) float64 {
-	health := map[creatures.CreatureType]float64{
-		creatures.SLIME:  10,
-		creatures.SPIDER: 15,
-		creatures.ZOMBIE: 20,
-	}
-	if h, exists := health[creatureType]; exists {
-		return h
-	}
-	return 10
-}
-
-func getDefaultCreatureDamage(creatureType creatures.CreatureType) float64 {
-	damage := map[creatures.CreatureType]float64{
-		creatures.SLIME:  2,
-		creatures.SPIDER: 4,
-		creatures.ZOMBIE: 6,
-	}
-	if d, exists := damage[creatureType]; exists {
-		return d
-	}
-	return 2
-}
-
-func getDefaultCreatureSpeed(creatureType creatures.CreatureType) float64 {
-	speed := map[creatures.CreatureType]float64{
-		creatures.SLIME:  1.0,
-		creatures.SPIDER: 1.5,
-		creatures.ZOMBIE: 0.8,
-	}
-	if s, exists := speed[creatureType]; exists {
-		return s
-	}
-	return 1.0
-}
-
-func getDefaultCreatureColor(creatureType creatures.CreatureType) string {
-	colors := map[creatures.CreatureType]string{
-		creatures.SLIME:  "#00FF00",
-		creatures.SPIDER: "#8B4513",
-		creatures.ZOMBIE: "#808080",
-	}
-	if color, exists := colors[creatureType]; exists {
-		return color
-	}
-	return "#FFFFFF"
-}
-
-func isCreatureHostile(creatureType creatures.CreatureType) bool {
-	return creatureType == creatures.ZOMBIE || creatureType == creatures.SPIDER
-}
-
 func getDefaultOrganismHeight(organismType organisms.OrganismType) float64 {
 	height := map[organisms.OrganismType]float64{
 		organisms.TREE:          8.0,
@@ -3,280 +3,612 @@ package plugins
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io"
 	"log"
-	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"tesselbox/pkg/entities"
 )
 
+// GameVersion is the game version checked against a plugin's
+// MinGameVersion and MaxGameVersion
+const GameVersion = "2.1.0"
+
+// manifestFileName is the installed copy of a plugin's manifest, kept in the
+// plugin's data directory
+const manifestFileName = "plugin.json"
+
+// stagingDirName is the directory inside the plugins directory where
+// packages are downloaded and verified before they are installed
+const stagingDirName = ".install"
+
 // PluginInstaller handles downloading and installing plugins
 type PluginInstaller struct {
 	pluginManager    *entities.PluginManager
 	pluginsDirectory string
 	tempDirectory    string
-	downloads        map[string]*DownloadProgress
+	repository       *Repository
+	gameVersion      string
+
+	installMutex sync.Mutex // serializes installs and uninstalls
+
+	mutex     sync.Mutex // guards downloads, the progress in them and pending
+	downloads map[string]*DownloadProgress
+	pending   []func() // plugin loads and unloads waiting for RunPending
 }
 
 // DownloadProgress tracks the progress of a plugin download
 type DownloadProgress struct {
-	PluginID      string
-	TotalBytes     int64
+	PluginID        string
+	TotalBytes      int64
 	DownloadedBytes int64
-	StartTime      time.Time
-	Complete       bool
-	Error          error
-	Progress       float64
-	Speed          float64 // Bytes per second
+	StartTime       time.Time
+	Complete        bool
+	Error           error
+	Progress        float64
+	Speed           float64 // Bytes per second
 }
 
 // PluginManifest represents the manifest file for a plugin
 type PluginManifest struct {
-	ID            string   `json:"id"`
-	Name          string   `json:"name"`
-	Version       string   `json:"version"`
-	Description   string   `json:"description"`
-	Author        string   `json:"author"`
-	Dependencies  []string `json:"dependencies"`
-	MinGameVersion string  `json:"minGameVersion"`
-	MaxGameVersion string  `json:"maxGameVersion"`
-	Checksum      string   `json:"checksum"`
-	Files         []PluginFile `json:"files"`
+	ID             string       `json:"id"`
+	Name           string       `json:"name"`
+	Version        string       `json:"version"`
+	Description    string       `json:"description"`
+	Author         string       `json:"author"`
+	Dependencies   []string     `json:"dependencies"`
+	MinGameVersion string       `json:"minGameVersion"`
+	MaxGameVersion string       `json:"maxGameVersion"`
+	Checksum       string       `json:"checksum"`
+	Files          []PluginFile `json:"files"`
 }
 
 // PluginFile represents a file in the plugin package
 type PluginFile struct {
 	Path     string `json:"path"`
-	Type     string `json:"type"` // "binary", "config", "asset", "data"
+	Type     string `json:"type"` // "script", "config", "asset", "data"
 	Checksum string `json:"checksum"`
 	Size     int64  `json:"size"`
 }
 
 // NewPluginInstaller creates a new plugin installer
 func NewPluginInstaller(pluginManager *entities.PluginManager) *PluginInstaller {
-	pluginsDir := "plugins"
-	tempDir := filepath.Join(pluginsDir, "temp")
-	
-	// Ensure directories exist
-	os.MkdirAll(pluginsDir, 0755)
-	os.MkdirAll(tempDir, 0755)
-	
-	return &PluginInstaller{
-		pluginManager:    pluginManager,
-		pluginsDirectory: pluginsDir,
-		tempDirectory:    tempDir,
-		downloads:        make(map[string]*DownloadProgress),
-	}
-}
-
-// DownloadAndInstall downloads and installs a plugin from the marketplace
+	pi := &PluginInstaller{
+		pluginManager: pluginManager,
+		gameVersion:   GameVersion,
+		downloads:     make(map[string]*DownloadProgress),
+	}
+	pi.SetPluginsDirectory("plugins")
+	return pi
+}
+
+// SetPluginsDirectory sets where plugins are installed. It should be the
+// plugin manager's plugin path.
+func (pi *PluginInstaller) SetPluginsDirectory(dir string) {
+	pi.pluginsDirectory = dir
+	pi.tempDirectory = filepath.Join(dir, stagingDirName)
+}
+
+// SetRepository sets the repository plugins are installed from
+func (pi *PluginInstaller) SetRepository(repository *Repository) {
+	pi.repository = repository
+}
+
+// Repository returns the repository plugins are installed from, or nil
+func (pi *PluginInstaller) Repository() *Repository {
+	return pi.repository
+}
+
+// OpenRepository opens a repository and installs from it from now on
+func (pi *PluginInstaller) OpenRepository(location string) error {
+	repository, err := OpenRepository(location)
+	if err != nil {
+		return err
+	}
+	pi.repository = repository
+	return nil
+}
+
+// DownloadAndInstall installs a plugin from the repository in the
+// background, together with the dependencies it is missing. Problems found
+// before downloading, such as missing dependencies or an unsupported game
+// version, are returned directly; later ones are reported through the
+// download progress.
 func (pi *PluginInstaller) DownloadAndInstall(plugin *MarketplacePlugin) error {
 	log.Printf("Starting download and install for plugin: %s", plugin.ID)
-	
-	// Create download progress
+
+	plan, err := pi.plan(plugin.ID)
+	if err != nil {
+		return err
+	}
+
 	progress := &DownloadProgress{
-		PluginID:   plugin.ID,
-		StartTime:  time.Now(),
-		Complete:   false,
+		PluginID:  plugin.ID,
+		StartTime: time.Now(),
 	}
+	pi.mutex.Lock()
 	pi.downloads[plugin.ID] = progress
-	
-	// Start download in goroutine
-	go pi.performDownload(plugin, progress)
-	
+	pi.mutex.Unlock()
+
+	go pi.performInstall(plan, progress, pi.onGameLoop)
+
 	return nil
 }
 
-// performDownload handles the actual download process
-func (pi *PluginInstaller) performDownload(plugin *MarketplacePlugin, progress *DownloadProgress) {
-	// Download the plugin file
-	tempFile := filepath.Join(pi.tempDirectory, plugin.ID+".tmp")
-	
-	err := pi.downloadFile(plugin.DownloadURL, tempFile, progress)
+// Install installs a plugin and its missing dependencies from the repository
+// and waits until they are loaded. Unlike DownloadAndInstall it loads the
+// plugins itself, so it must be called from the goroutine that runs them.
+func (pi *PluginInstaller) Install(pluginID string) error {
+	plan, err := pi.plan(pluginID)
+	if err != nil {
+		return err
+	}
+	progress := &DownloadProgress{PluginID: pluginID, StartTime: time.Now()}
+	pi.performInstall(plan, progress, func(fn func() error) error { return fn() })
+	return progress.Error
+}
+
+// RunPending loads and unloads the plugins that background installs are
+// waiting on. Games call it every frame from their update loop, so plugins
+// are only ever run from the game's goroutine.
+func (pi *PluginInstaller) RunPending() {
+	pi.mutex.Lock()
+	pending := pi.pending
+	pi.pending = nil
+	pi.mutex.Unlock()
+
+	for _, fn := range pending {
+		fn()
+	}
+}
+
+// onGameLoop queues fn for RunPending and waits for its result
+func (pi *PluginInstaller) onGameLoop(fn func() error) error {
+	done := make(chan error, 1)
+	pi.mutex.Lock()
+	pi.pending = append(pi.pending, func() { done <- fn() })
+	pi.mutex.Unlock()
+	return <-done
+}
+
+// updateProgress changes a download's progress under the lock readers take
+func (pi *PluginInstaller) updateProgress(progress *DownloadProgress, update func(*DownloadProgress)) {
+	pi.mutex.Lock()
+	defer pi.mutex.Unlock()
+	update(progress)
+}
+
+// plan resolves the plugins to install for pluginID and checks that each of
+// them supports the running game version
+func (pi *PluginInstaller) plan(pluginID string) ([]*RepositoryEntry, error) {
+	if pi.repository == nil {
+		return nil, fmt.Errorf("no plugin repository is configured")
+	}
+	plan, err := pi.repository.Resolve(pluginID, pi.isDependencyInstalled)
 	if err != nil {
-		progress.Error = fmt.Errorf("download failed: %v", err)
-		progress.Complete = true
-		log.Printf("Failed to download plugin %s: %v", plugin.ID, err)
+		return nil, err
+	}
+	for _, entry := range plan {
+		if err := entry.CheckCompatibility(pi.gameVersion); err != nil {
+			return nil, err
+		}
+	}
+	return plan, nil
+}
+
+// performInstall downloads, verifies and installs a resolved plan as one
+// transaction: nothing in the plugins directory changes unless every file
+// passes its checksum, and if any plugin fails to load every change is
+// rolled back. Plugins are loaded and unloaded through run.
+func (pi *PluginInstaller) performInstall(plan []*RepositoryEntry, progress *DownloadProgress, run func(func() error) error) {
+	pi.installMutex.Lock()
+	defer pi.installMutex.Unlock()
+
+	fail := func(err error) {
+		pi.updateProgress(progress, func(p *DownloadProgress) {
+			p.Error = err
+			p.Complete = true
+		})
+		log.Printf("Failed to install plugin %s: %v", progress.PluginID, err)
+	}
+
+	if err := os.MkdirAll(pi.tempDirectory, 0755); err != nil {
+		fail(fmt.Errorf("failed to create staging directory: %v", err))
 		return
 	}
-	
-	// Verify checksum if available
-	if plugin.ID == "colored-blocks" { // Example checksum verification
-		expectedChecksum := "a1b2c3d4e5f6" // This would come from plugin metadata
-		if err := pi.verifyFileChecksum(tempFile, expectedChecksum); err != nil {
-			progress.Error = fmt.Errorf("checksum verification failed: %v", err)
-			progress.Complete = true
-			os.Remove(tempFile)
+	staging, err := os.MkdirTemp(pi.tempDirectory, progress.PluginID+"-")
+	if err != nil {
+		fail(fmt.Errorf("failed to create staging directory: %v", err))
+		return
+	}
+	defer os.RemoveAll(staging)
+
+	tx := &installTransaction{installer: pi, staging: staging, run: run}
+
+	var total int64
+	for _, entry := range plan {
+		total += pi.packageSize(entry)
+	}
+	pi.updateProgress(progress, func(p *DownloadProgress) { p.TotalBytes = total })
+	for _, entry := range plan {
+		if err := tx.stage(entry, progress); err != nil {
+			fail(fmt.Errorf("download of %s failed: %v", entry.ID, err))
 			return
 		}
 	}
-	
-	// Extract and install plugin
-	err = pi.installPluginFile(tempFile, plugin)
-	if err != nil {
-		progress.Error = fmt.Errorf("installation failed: %v", err)
-		progress.Complete = true
-		os.Remove(tempFile)
+
+	if err := tx.commit(); err != nil {
+		tx.rollback()
+		fail(fmt.Errorf("installation failed: %v", err))
 		return
 	}
-	
-	// Clean up temp file
-	os.Remove(tempFile)
-	
-	// Mark as complete
-	progress.Complete = true
-	progress.Progress = 100.0
-	
-	log.Printf("Successfully installed plugin: %s", plugin.ID)
+
+	pi.updateProgress(progress, func(p *DownloadProgress) {
+		p.Complete = true
+		p.Progress = 100.0
+	})
+
+	log.Printf("Successfully installed plugin: %s", progress.PluginID)
+}
+
+// packageSize returns the declared size of a plugin's files
+func (pi *PluginInstaller) packageSize(entry *RepositoryEntry) int64 {
+	var size int64
+	for _, file := range entry.PackageFiles() {
+		size += file.Size
+	}
+	return size
 }
 
-// downloadFile downloads a file with progress tracking
-func (pi *PluginInstaller) downloadFile(url, dest string, progress *DownloadProgress) error {
-	resp, err := http.Get(url)
+// targetPath returns where a plugin file is installed: the script next to
+// the other plugins, everything else in the plugin's own directory
+func (pi *PluginInstaller) targetPath(entry *RepositoryEntry, file PluginFile) (string, error) {
+	rel := filepath.Clean(filepath.FromSlash(file.Path))
+	if rel == "." || filepath.IsAbs(rel) || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid file path %q", file.Path)
+	}
+	if file.Type == "script" {
+		if !entities.IsScriptPluginFile(rel) {
+			return "", fmt.Errorf("script %s is not a %s file", file.Path, entities.ScriptExtension)
+		}
+		return filepath.Join(pi.pluginsDirectory, entry.ID+entities.ScriptExtension), nil
+	}
+	return filepath.Join(pi.pluginsDirectory, entry.ID, rel), nil
+}
+
+// installTransaction stages plugin packages and installs them so that they
+// can be rolled back together
+type installTransaction struct {
+	installer *PluginInstaller
+	staging   string
+	run       func(func() error) error // loads and unloads plugins
+
+	staged   []stagedPlugin
+	replaced []replacedFile
+	loaded   []string // plugins loaded by the transaction, in order
+	unloaded []string // previously loaded plugins it unloaded
+}
+
+// stagedPlugin is a verified package waiting to be installed
+type stagedPlugin struct {
+	entry *RepositoryEntry
+	files map[string]string // target path -> staged path
+}
+
+// replacedFile records a file the transaction put in place, and where the
+// file it replaced was moved
+type replacedFile struct {
+	target string
+	backup string // empty when there was no previous file
+}
+
+// stage downloads a plugin's files into the staging directory and verifies
+// their checksums
+func (tx *installTransaction) stage(entry *RepositoryEntry, progress *DownloadProgress) error {
+	pi := tx.installer
+	staged := stagedPlugin{entry: entry, files: make(map[string]string)}
+
+	scripts := 0
+	for i, file := range entry.PackageFiles() {
+		target, err := pi.targetPath(entry, file)
+		if err != nil {
+			return err
+		}
+		if file.Type == "script" {
+			scripts++
+		}
+		if file.Checksum == "" {
+			return fmt.Errorf("file %s has no checksum", file.Path)
+		}
+
+		dest := filepath.Join(tx.staging, fmt.Sprintf("%s-%d", entry.ID, i))
+		if err := pi.downloadFile(entry.filePath(file), dest, progress); err != nil {
+			return fmt.Errorf("%s: %v", file.Path, err)
+		}
+		if err := pi.verifyFileChecksum(dest, file.Checksum); err != nil {
+			return fmt.Errorf("checksum verification of %s failed: %v", file.Path, err)
+		}
+		staged.files[target] = dest
+	}
+	if scripts != 1 {
+		return fmt.Errorf("package must contain exactly one script, found %d", scripts)
+	}
+
+	manifest, err := json.MarshalIndent(entry.PluginManifest, "", "  ")
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to encode manifest: %v", err)
+	}
+	manifestPath := filepath.Join(tx.staging, entry.ID+"-"+manifestFileName)
+	if err := os.WriteFile(manifestPath, manifest, 0644); err != nil {
+		return fmt.Errorf("failed to write manifest: %v", err)
+	}
+	staged.files[filepath.Join(pi.pluginsDirectory, entry.ID, manifestFileName)] = manifestPath
+
+	tx.staged = append(tx.staged, staged)
+	return nil
+}
+
+// commit moves the staged files into the plugins directory and loads the
+// plugins, dependencies first. Plugins that were already loaded are
+// unloaded first so the new version replaces them.
+func (tx *installTransaction) commit() error {
+	pm := tx.installer.pluginManager
+
+	for i := len(tx.staged) - 1; i >= 0; i-- {
+		id := tx.staged[i].entry.ID
+		if pm != nil && pm.IsLoaded(id) {
+			if err := tx.run(func() error { return pm.UnloadPlugin(id) }); err != nil {
+				return fmt.Errorf("failed to unload the installed version of %s: %v", id, err)
+			}
+			tx.unloaded = append(tx.unloaded, id)
+		}
 	}
-	defer resp.Body.Close()
-	
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("HTTP error: %s", resp.Status)
+
+	for _, staged := range tx.staged {
+		for target, source := range staged.files {
+			if err := tx.replace(target, source); err != nil {
+				return err
+			}
+		}
+	}
+
+	if pm == nil {
+		return nil
+	}
+	for _, staged := range tx.staged {
+		id := staged.entry.ID
+		if err := tx.run(func() error { return pm.LoadPlugin(id) }); err != nil {
+			return fmt.Errorf("failed to load plugin: %v", err)
+		}
+		tx.loaded = append(tx.loaded, id)
 	}
-	
-	progress.TotalBytes = resp.ContentLength
-	
+	return nil
+}
+
+// replace moves a staged file to its target, keeping any file it replaces
+// so it can be restored
+func (tx *installTransaction) replace(target, source string) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return fmt.Errorf("failed to create plugin directory: %v", err)
+	}
+
+	record := replacedFile{target: target}
+	if _, err := os.Stat(target); err == nil {
+		record.backup = source + ".previous"
+		if err := os.Rename(target, record.backup); err != nil {
+			return fmt.Errorf("failed to back up %s: %v", target, err)
+		}
+	}
+	if err := os.Rename(source, target); err != nil {
+		if record.backup != "" {
+			os.Rename(record.backup, target)
+		}
+		return fmt.Errorf("failed to install %s: %v", target, err)
+	}
+	tx.replaced = append(tx.replaced, record)
+	return nil
+}
+
+// rollback undoes a failed commit: it unloads what was loaded, restores the
+// replaced files and reloads the plugins that were running before
+func (tx *installTransaction) rollback() {
+	pm := tx.installer.pluginManager
+
+	for i := len(tx.loaded) - 1; i >= 0; i-- {
+		id := tx.loaded[i]
+		if err := tx.run(func() error { return pm.UnloadPlugin(id) }); err != nil {
+			log.Printf("Warning: failed to unload plugin %s during rollback: %v", tx.loaded[i], err)
+		}
+	}
+
+	for i := len(tx.replaced) - 1; i >= 0; i-- {
+		record := tx.replaced[i]
+		os.Remove(record.target)
+		if record.backup != "" {
+			if err := os.Rename(record.backup, record.target); err != nil {
+				log.Printf("Warning: failed to restore %s: %v", record.target, err)
+			}
+		}
+	}
+	for _, staged := range tx.staged {
+		// Remove the plugin's directory if the transaction created it
+		os.Remove(filepath.Join(tx.installer.pluginsDirectory, staged.entry.ID))
+	}
+
+	for i := len(tx.unloaded) - 1; i >= 0; i-- {
+		id := tx.unloaded[i]
+		if err := tx.run(func() error { return pm.LoadPlugin(id) }); err != nil {
+			log.Printf("Warning: failed to reload plugin %s during rollback: %v", tx.unloaded[i], err)
+		}
+	}
+}
+
+// downloadFile copies a file from the repository with progress tracking
+func (pi *PluginInstaller) downloadFile(repoPath, dest string, progress *DownloadProgress) error {
+	source, size, err := pi.repository.open(repoPath)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	pi.updateProgress(progress, func(p *DownloadProgress) {
+		if p.TotalBytes == 0 && size > 0 {
+			p.TotalBytes = size
+		}
+	})
+
 	// Create destination file
 	file, err := os.Create(dest)
 	if err != nil {
 		return err
 	}
 	defer file.Close()
-	
+
 	// Create progress reader
 	reader := &progressReader{
-		reader:   resp.Body,
-		progress: progress,
+		reader:    source,
+		installer: pi,
+		progress:  progress,
 	}
-	
+
 	// Copy with progress tracking
 	_, err = io.Copy(file, reader)
 	if err != nil {
 		return err
 	}
-	
-	return nil
+
+	return file.Sync()
 }
 
 // progressReader wraps a reader to track download progress
 type progressReader struct {
-	reader   io.Reader
-	progress *DownloadProgress
+	reader    io.Reader
+	installer *PluginInstaller
+	progress  *DownloadProgress
 }
 
 func (pr *progressReader) Read(p []byte) (n int, err error) {
 	n, err = pr.reader.Read(p)
-	
-	pr.progress.DownloadedBytes += int64(n)
-	
-	if pr.progress.TotalBytes > 0 {
-		pr.progress.Progress = float64(pr.progress.DownloadedBytes) / float64(pr.progress.TotalBytes) * 100
-	}
-	
-	// Calculate download speed
-	elapsed := time.Since(pr.progress.StartTime).Seconds()
-	if elapsed > 0 {
-		pr.progress.Speed = float64(pr.progress.DownloadedBytes) / elapsed
-	}
-	
+
+	pr.installer.updateProgress(pr.progress, func(progress *DownloadProgress) {
+		progress.DownloadedBytes += int64(n)
+
+		if progress.TotalBytes > 0 {
+			progress.Progress = float64(progress.DownloadedBytes) / float64(progress.TotalBytes) * 100
+			if progress.Progress > 99 {
+				// The last percent is installation
+				progress.Progress = 99
+			}
+		}
+
+		// Calculate download speed
+		elapsed := time.Since(progress.StartTime).Seconds()
+		if elapsed > 0 {
+			progress.Speed = float64(progress.DownloadedBytes) / elapsed
+		}
+	})
+
 	return n, err
 }
 
 // verifyFileChecksum verifies the SHA256 checksum of a file
 func (pi *PluginInstaller) verifyFileChecksum(filePath, expectedChecksum string) error {
-	file, err := os.Open(filePath)
+	actualChecksum, err := FileChecksum(filePath)
 	if err != nil {
 		return err
 	}
-	defer file.Close()
-	
-	hasher := sha256.New()
-	if _, err := io.Copy(hasher, file); err != nil {
-		return err
-	}
-	
-	actualChecksum := hex.EncodeToString(hasher.Sum(nil))
-	
+
 	if !strings.EqualFold(actualChecksum, expectedChecksum) {
 		return fmt.Errorf("checksum mismatch: expected %s, got %s", expectedChecksum, actualChecksum)
 	}
-	
+
 	return nil
 }
 
-// installPluginFile installs a downloaded plugin file
-func (pi *PluginInstaller) installPluginFile(tempFile string, plugin *MarketplacePlugin) error {
-	// For now, we'll simulate installation by copying to plugins directory
-	pluginFile := filepath.Join(pi.pluginsDirectory, plugin.ID+".so")
-	
-	// Read source file
-	sourceData, err := os.ReadFile(tempFile)
-	if err != nil {
-		return fmt.Errorf("failed to read plugin file: %v", err)
-	}
-	
-	// Write to destination
-	err = os.WriteFile(pluginFile, sourceData, 0755)
+// FileChecksum returns the hex SHA256 checksum of a file, as used in
+// repository indexes
+func FileChecksum(filePath string) (string, error) {
+	file, err := os.Open(filePath)
 	if err != nil {
-		return fmt.Errorf("failed to write plugin file: %v", err)
+		return "", err
 	}
-	
-	// Load plugin using the plugin manager
-	err = pi.pluginManager.LoadPlugin(plugin.ID)
-	if err != nil {
-		// Remove the file if loading failed
-		os.Remove(pluginFile)
-		return fmt.Errorf("failed to load plugin: %v", err)
+	defer file.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, file); err != nil {
+		return "", err
 	}
-	
-	return nil
+	return hex.EncodeToString(hasher.Sum(nil)), nil
 }
 
-// UninstallPlugin removes a plugin
+// UninstallPlugin removes a plugin. It fails while an installation is in
+// progress rather than wait for it, since the installation may itself be
+// waiting for the game loop.
 func (pi *PluginInstaller) UninstallPlugin(pluginID string) error {
 	log.Printf("Uninstalling plugin: %s", pluginID)
-	
+
+	if !pi.installMutex.TryLock() {
+		return fmt.Errorf("an installation is in progress, try again once it finishes")
+	}
+	defer pi.installMutex.Unlock()
+
 	// Unload plugin first
-	err := pi.pluginManager.UnloadPlugin(pluginID)
-	if err != nil {
-		log.Printf("Warning: failed to unload plugin %s: %v", pluginID, err)
+	if pi.pluginManager.IsLoaded(pluginID) {
+		if err := pi.pluginManager.UnloadPlugin(pluginID); err != nil {
+			return fmt.Errorf("failed to unload plugin: %v", err)
+		}
 	}
-	
+
 	// Remove plugin file
-	pluginFile := filepath.Join(pi.pluginsDirectory, pluginID+".so")
+	pluginFile := filepath.Join(pi.pluginsDirectory, pluginID+entities.ScriptExtension)
 	if err := os.Remove(pluginFile); err != nil {
 		return fmt.Errorf("failed to remove plugin file: %v", err)
 	}
-	
+	if err := os.RemoveAll(filepath.Join(pi.pluginsDirectory, pluginID)); err != nil {
+		log.Printf("Warning: failed to remove data of plugin %s: %v", pluginID, err)
+	}
+
 	log.Printf("Successfully uninstalled plugin: %s", pluginID)
 	return nil
 }
 
-// GetDownloadProgress returns the download progress for a plugin
+// GetDownloadProgress returns a snapshot of the download progress for a
+// plugin, or nil if it is not being installed
 func (pi *PluginInstaller) GetDownloadProgress(pluginID string) *DownloadProgress {
-	return pi.downloads[pluginID]
+	pi.mutex.Lock()
+	defer pi.mutex.Unlock()
+	progress, exists := pi.downloads[pluginID]
+	if !exists {
+		return nil
+	}
+	snapshot := *progress
+	return &snapshot
 }
 
-// GetAllDownloadProgress returns all download progress
+// GetAllDownloadProgress returns snapshots of all download progress
 func (pi *PluginInstaller) GetAllDownloadProgress() map[string]*DownloadProgress {
-	return pi.downloads
+	pi.mutex.Lock()
+	defer pi.mutex.Unlock()
+
+	downloads := make(map[string]*DownloadProgress, len(pi.downloads))
+	for id, progress := range pi.downloads {
+		snapshot := *progress
+		downloads[id] = &snapshot
+	}
+	return downloads
 }
 
 // CleanupProgress removes completed download progress entries
 func (pi *PluginInstaller) CleanupProgress() {
+	pi.mutex.Lock()
+	defer pi.mutex.Unlock()
+
 	for id, progress := range pi.downloads {
 		if progress.Complete && time.Since(progress.StartTime) > 5*time.Minute {
 			delete(pi.downloads, id)
@@ -290,39 +622,46 @@ func (pi *PluginInstaller) GetInstalledPlugins() []string {
 	if err != nil {
 		return []string{}
 	}
-	
+
 	var plugins []string
 	for _, file := range files {
-		if !file.IsDir() && strings.HasSuffix(file.Name(), ".so") {
-			pluginName := strings.TrimSuffix(file.Name(), ".so")
-			plugins = append(plugins, pluginName)
+		if !file.IsDir() && entities.IsScriptPluginFile(file.Name()) {
+			plugins = append(plugins, entities.ScriptPluginName(file.Name()))
 		}
 	}
-	
+
 	return plugins
 }
 
+// IsInstalled reports whether a plugin is installed
+func (pi *PluginInstaller) IsInstalled(pluginID string) bool {
+	return pi.isDependencyInstalled(pluginID)
+}
+
+// InstalledManifest returns the manifest a plugin was installed with, or nil
+// for plugins not installed from a repository
+func (pi *PluginInstaller) InstalledManifest(pluginID string) *PluginManifest {
+	data, err := os.ReadFile(filepath.Join(pi.pluginsDirectory, pluginID, manifestFileName))
+	if err != nil {
+		return nil
+	}
+	var manifest PluginManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil
+	}
+	return &manifest
+}
+
 // ValidatePlugin checks if a plugin is compatible and safe to install
 func (pi *PluginInstaller) ValidatePlugin(plugin *MarketplacePlugin) error {
 	// Check if already installed
 	if plugin.Installed {
 		return fmt.Errorf("plugin is already installed")
 	}
-	
-	// Check dependencies
-	for _, dep := range plugin.Dependencies {
-		if !pi.isDependencyInstalled(dep) {
-			return fmt.Errorf("missing dependency: %s", dep)
-		}
-	}
-	
-	// Check game version compatibility (simplified)
-	gameVersion := "2.0.0" // This would come from actual game version
-	if plugin.Version < "1.0.0" {
-		return fmt.Errorf("plugin version %s is not compatible with game version %s", plugin.Version, gameVersion)
-	}
-	
-	return nil
+
+	// Check that the plugin, its dependencies and the game version fit
+	_, err := pi.plan(plugin.ID)
+	return err
 }
 
 // isDependencyInstalled checks if a dependency is installed
@@ -336,27 +675,18 @@ func (pi *PluginInstaller) isDependencyInstalled(dependency string) bool {
 	return false
 }
 
-// UpdatePlugin updates an installed plugin to the latest version
+// UpdatePlugin updates an installed plugin to the latest version. The
+// installed version keeps running until the new one is in place.
 func (pi *PluginInstaller) UpdatePlugin(plugin *MarketplacePlugin) error {
 	if !plugin.Installed {
 		return fmt.Errorf("plugin is not installed")
 	}
-	
+
 	log.Printf("Updating plugin: %s", plugin.ID)
-	
-	// Uninstall current version
-	err := pi.UninstallPlugin(plugin.ID)
-	if err != nil {
-		return fmt.Errorf("failed to uninstall current version: %v", err)
-	}
-	
-	// Install new version
-	err = pi.DownloadAndInstall(plugin)
-	if err != nil {
+
+	if err := pi.DownloadAndInstall(plugin); err != nil {
 		return fmt.Errorf("failed to install new version: %v", err)
 	}
-	
-	log.Printf("Successfully updated plugin: %s", plugin.ID)
 	return nil
 }
 
@@ -376,14 +706,14 @@ func (pi *PluginInstaller) DisablePlugin(pluginID string) error {
 
 // GetPluginInfo returns detailed information about an installed plugin
 func (pi *PluginInstaller) GetPluginInfo(pluginID string) (*PluginInfo, error) {
-	pluginFile := filepath.Join(pi.pluginsDirectory, pluginID+".so")
-	
+	pluginFile := filepath.Join(pi.pluginsDirectory, pluginID+entities.ScriptExtension)
+
 	// Get file info
 	fileInfo, err := os.Stat(pluginFile)
 	if err != nil {
 		return nil, fmt.Errorf("plugin not found: %v", err)
 	}
-	
+
 	// Create basic plugin info
 	info := &PluginInfo{
 		ID:          pluginID,
@@ -392,12 +722,26 @@ func (pi *PluginInstaller) GetPluginInfo(pluginID string) (*PluginInfo, error) {
 		Description: "Plugin description not available",
 		Author:      "Unknown",
 		Installed:   true,
-		Enabled:     true,
+		Enabled:     pi.pluginManager.IsLoaded(pluginID),
 		InstallDate: fileInfo.ModTime(),
 		FileSize:    fileInfo.Size(),
 		FilePath:    pluginFile,
 	}
-	
+
+	// Fill in what the repository said when it was installed
+	if manifest := pi.InstalledManifest(pluginID); manifest != nil {
+		if manifest.Name != "" {
+			info.Name = manifest.Name
+		}
+		info.Version = manifest.Version
+		info.Description = manifest.Description
+		info.Author = manifest.Author
+		info.Dependencies = manifest.Dependencies
+	}
+	if checksum, err := FileChecksum(pluginFile); err == nil {
+		info.Checksum = checksum
+	}
+
 	// Try to get more detailed info from plugin manager
 	if plugin, exists := pi.pluginManager.GetPlugin(pluginID); exists {
 		info.Name = plugin.GetName()
@@ -406,22 +750,22 @@ func (pi *PluginInstaller) GetPluginInfo(pluginID string) (*PluginInfo, error) {
 		info.Author = plugin.GetAuthor()
 		info.Dependencies = plugin.GetDependencies()
 	}
-	
+
 	return info, nil
 }
 
 // PluginInfo contains detailed information about a plugin
 type PluginInfo struct {
-	ID          string    `json:"id"`
-	Name        string    `json:"name"`
-	Version     string    `json:"version"`
-	Description string    `json:"description"`
-	Author      string    `json:"author"`
+	ID           string    `json:"id"`
+	Name         string    `json:"name"`
+	Version      string    `json:"version"`
+	Description  string    `json:"description"`
+	Author       string    `json:"author"`
 	Dependencies []string  `json:"dependencies"`
-	Installed   bool      `json:"installed"`
-	Enabled     bool      `json:"enabled"`
-	InstallDate time.Time `json:"installDate"`
-	FileSize    int64     `json:"fileSize"`
-	FilePath    string    `json:"filePath"`
-	Checksum    string    `json:"checksum"`
+	Installed    bool      `json:"installed"`
+	Enabled      bool      `json:"enabled"`
+	InstallDate  time.Time `json:"installDate"`
+	FileSize     int64     `json:"fileSize"`
+	FilePath     string    `json:"filePath"`
+	Checksum     string    `json:"checksum"`
 }
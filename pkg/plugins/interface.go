@@ -5,7 +5,7 @@ import (
 	"log"
 	"tesselbox/pkg/audio"
 	"tesselbox/pkg/blocks"
-	"tesselbox/pkg/creatures"
+	"tesselbox/pkg/mobs"
 	"tesselbox/pkg/organisms"
 	"tesselbox/pkg/world"
 )
@@ -27,8 +27,8 @@ type GamePlugin interface {
 	GetBlockTypes() []blocks.BlockType
 	GetBlockDefinition(blockType blocks.BlockType) (*BlockDefinition, bool)
 	GetBlockProperties(blockType blocks.BlockType) (map[string]interface{}, bool)
-	GetCreatureTypes() []creatures.CreatureType
-	GetCreatureDefinition(creatureType creatures.CreatureType) (*CreatureDefinition, bool)
+	GetCreatureTypes() []string
+	GetCreatureDefinition(mobType string) (*CreatureDefinition, bool)
 	GetOrganismTypes() []organisms.OrganismType
 	GetOrganismDefinition(organismType organisms.OrganismType) (*OrganismDefinition, bool)
 	GetAudioTypes() []audio.AudioType
@@ -42,8 +42,8 @@ type GamePlugin interface {
 	// Game hooks
 	OnBlockPlaced(x, y, z int, blockType blocks.BlockType) error
 	OnBlockBroken(x, y, z int, blockType blocks.BlockType) error
-	OnCreatureSpawn(creature *creatures.Creature) error
-	OnCreatureDeath(creature *creatures.Creature) error
+	OnCreatureSpawn(creature *mobs.Mob) error
+	OnCreatureDeath(creature *mobs.Mob) error
 	OnTick(world *world.World, deltaTime float64) error
 }
 
@@ -166,7 +166,7 @@ type BlockDefinition struct {
 }
 
 type CreatureDefinition struct {
-	Type   creatures.CreatureType
+	Type   string // Mob type ID
 	Name   string
 	Health float64
 	Damage float64
@@ -0,0 +1,343 @@
+package plugins
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// httpClient fetches from remote repositories. Its timeout covers whole
+// requests, bodies included, so a stalled server cannot hang an install.
+var httpClient = &http.Client{Timeout: 2 * time.Minute}
+
+// IndexFileName is the name of the index file at the root of a repository
+const IndexFileName = "index.json"
+
+// RepositoryIndex lists the plugins a repository provides. It is stored as
+// index.json at the root of the repository:
+//
+//	{
+//	  "name": "Local plugins",
+//	  "plugins": [
+//	    {
+//	      "id": "magic-system",
+//	      "name": "Magic System",
+//	      "version": "1.2.0",
+//	      "dependencies": ["mana"],
+//	      "minGameVersion": "2.0.0",
+//	      "category": "Gameplay",
+//	      "path": "magic-system",
+//	      "files": [
+//	        {"path": "magic-system.lua", "type": "script", "checksum": "<sha256>"},
+//	        {"path": "spells.json", "type": "data", "checksum": "<sha256>"}
+//	      ]
+//	    }
+//	  ]
+//	}
+//
+// File paths are relative to the entry's path, which is relative to the
+// index and defaults to the plugin ID. An entry without files is a single
+// script named <id>.lua whose checksum is the manifest checksum.
+type RepositoryIndex struct {
+	Name    string             `json:"name"`
+	Plugins []*RepositoryEntry `json:"plugins"`
+}
+
+// RepositoryEntry is a plugin listed in a repository index
+type RepositoryEntry struct {
+	PluginManifest
+	Category string   `json:"category"`
+	Tags     []string `json:"tags"`
+	Features []string `json:"features"`
+	Path     string   `json:"path"`
+}
+
+// Repository is a plugin repository served from a local directory, a
+// file:// URL or over HTTP
+type Repository struct {
+	location string
+	remote   bool
+	index    *RepositoryIndex
+}
+
+// OpenRepository opens a repository and reads its index. The location is a
+// directory, a path to an index file, a file:// URL or an http(s) URL.
+func OpenRepository(location string) (*Repository, error) {
+	repo := &Repository{}
+
+	switch {
+	case strings.HasPrefix(location, "http://"), strings.HasPrefix(location, "https://"):
+		repo.remote = true
+		repo.location = strings.TrimSuffix(strings.TrimSuffix(location, "/"+IndexFileName), "/")
+	case strings.HasPrefix(location, "file://"):
+		parsed, err := url.Parse(location)
+		if err != nil {
+			return nil, fmt.Errorf("invalid repository URL %s: %v", location, err)
+		}
+		repo.location = filepath.FromSlash(parsed.Path)
+	default:
+		repo.location = location
+	}
+	if !repo.remote && filepath.Base(repo.location) == IndexFileName {
+		repo.location = filepath.Dir(repo.location)
+	}
+
+	if err := repo.Reload(); err != nil {
+		return nil, err
+	}
+	return repo, nil
+}
+
+// Location returns the directory or base URL of the repository
+func (r *Repository) Location() string {
+	return r.location
+}
+
+// Reload reads the index again
+func (r *Repository) Reload() error {
+	reader, _, err := r.open(IndexFileName)
+	if err != nil {
+		return fmt.Errorf("failed to open repository index: %v", err)
+	}
+	defer reader.Close()
+
+	var index RepositoryIndex
+	if err := json.NewDecoder(reader).Decode(&index); err != nil {
+		return fmt.Errorf("failed to parse repository index: %v", err)
+	}
+
+	seen := make(map[string]bool)
+	for _, entry := range index.Plugins {
+		if entry.ID == "" {
+			return fmt.Errorf("repository index has a plugin without an id")
+		}
+		if strings.ContainsAny(entry.ID, `/\`) || strings.HasPrefix(entry.ID, ".") {
+			return fmt.Errorf("repository index has an invalid plugin id %q", entry.ID)
+		}
+		if seen[entry.ID] {
+			return fmt.Errorf("repository index lists plugin %s twice", entry.ID)
+		}
+		seen[entry.ID] = true
+	}
+
+	r.index = &index
+	return nil
+}
+
+// Name returns the repository's display name
+func (r *Repository) Name() string {
+	if r.index.Name != "" {
+		return r.index.Name
+	}
+	return r.location
+}
+
+// Plugins returns the plugins in the index
+func (r *Repository) Plugins() []*RepositoryEntry {
+	return r.index.Plugins
+}
+
+// Find returns the index entry for a plugin
+func (r *Repository) Find(pluginID string) (*RepositoryEntry, bool) {
+	for _, entry := range r.index.Plugins {
+		if entry.ID == pluginID {
+			return entry, true
+		}
+	}
+	return nil, false
+}
+
+// Resolve returns a plugin and the dependencies it needs, each after the
+// plugins it depends on. Dependencies for which installed returns true are
+// left out; the plugin itself is always included.
+func (r *Repository) Resolve(pluginID string, installed func(string) bool) ([]*RepositoryEntry, error) {
+	var order []*RepositoryEntry
+	state := make(map[string]int) // 1 while visiting, 2 once done
+
+	var visit func(id string, chain []string) error
+	visit = func(id string, chain []string) error {
+		switch state[id] {
+		case 1:
+			return fmt.Errorf("dependency cycle: %s", strings.Join(append(chain, id), " -> "))
+		case 2:
+			return nil
+		}
+		if len(chain) > 0 && installed(id) {
+			state[id] = 2
+			return nil
+		}
+
+		entry, exists := r.Find(id)
+		if !exists {
+			if len(chain) == 0 {
+				return fmt.Errorf("plugin %s is not in the repository", id)
+			}
+			return fmt.Errorf("missing dependency %s of %s", id, chain[len(chain)-1])
+		}
+
+		state[id] = 1
+		for _, dep := range entry.Dependencies {
+			if err := visit(dep, append(chain, id)); err != nil {
+				return err
+			}
+		}
+		state[id] = 2
+		order = append(order, entry)
+		return nil
+	}
+
+	if err := visit(pluginID, nil); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// open opens a file of the repository by its slash-separated path relative to
+// the repository root
+func (r *Repository) open(relPath string) (io.ReadCloser, int64, error) {
+	clean := path.Clean("/" + relPath)[1:]
+	if clean == "" || clean != strings.TrimPrefix(relPath, "./") {
+		return nil, 0, fmt.Errorf("invalid repository path %q", relPath)
+	}
+
+	if r.remote {
+		resp, err := httpClient.Get(r.location + "/" + clean)
+		if err != nil {
+			return nil, 0, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, 0, fmt.Errorf("HTTP error: %s", resp.Status)
+		}
+		return resp.Body, resp.ContentLength, nil
+	}
+
+	file, err := os.Open(filepath.Join(r.location, filepath.FromSlash(clean)))
+	if err != nil {
+		return nil, 0, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, 0, err
+	}
+	return file, info.Size(), nil
+}
+
+// PackageFiles returns the files that make up the plugin
+func (e *RepositoryEntry) PackageFiles() []PluginFile {
+	if len(e.Files) > 0 {
+		return e.Files
+	}
+	return []PluginFile{{
+		Path:     e.ID + ".lua",
+		Type:     "script",
+		Checksum: e.Checksum,
+	}}
+}
+
+// filePath returns the repository path of one of the plugin's files
+func (e *RepositoryEntry) filePath(file PluginFile) string {
+	dir := e.Path
+	if dir == "" {
+		dir = e.ID
+	}
+	return path.Join(dir, file.Path)
+}
+
+// CheckCompatibility reports whether the plugin supports a game version
+func (e *RepositoryEntry) CheckCompatibility(gameVersion string) error {
+	if e.MinGameVersion != "" && CompareVersions(gameVersion, e.MinGameVersion) < 0 {
+		return fmt.Errorf("plugin %s %s needs game version %s or newer (running %s)", e.ID, e.Version, e.MinGameVersion, gameVersion)
+	}
+	if e.MaxGameVersion != "" && CompareVersions(gameVersion, e.MaxGameVersion) > 0 {
+		return fmt.Errorf("plugin %s %s supports game versions up to %s (running %s)", e.ID, e.Version, e.MaxGameVersion, gameVersion)
+	}
+	return nil
+}
+
+// marketplacePlugin converts an index entry for the marketplace UI
+func (e *RepositoryEntry) marketplacePlugin() *MarketplacePlugin {
+	name := e.Name
+	if name == "" {
+		name = e.ID
+	}
+	compatibility := make(map[string]string)
+	if e.MinGameVersion != "" {
+		compatibility["min"] = e.MinGameVersion
+	}
+	if e.MaxGameVersion != "" {
+		compatibility["max"] = e.MaxGameVersion
+	}
+	return &MarketplacePlugin{
+		ID:            e.ID,
+		Name:          name,
+		Version:       e.Version,
+		Description:   e.Description,
+		Author:        e.Author,
+		Category:      e.Category,
+		Tags:          e.Tags,
+		Dependencies:  e.Dependencies,
+		Features:      e.Features,
+		Compatibility: compatibility,
+	}
+}
+
+// CompareVersions compares two dotted version numbers such as 1.2.0 and
+// returns -1, 0 or 1. A leading v and any pre-release or build suffix are
+// ignored, and missing parts count as zero.
+func CompareVersions(a, b string) int {
+	pa, pb := versionParts(a), versionParts(b)
+	for len(pa) < len(pb) {
+		pa = append(pa, 0)
+	}
+	for len(pb) < len(pa) {
+		pb = append(pb, 0)
+	}
+	for i := range pa {
+		if pa[i] < pb[i] {
+			return -1
+		}
+		if pa[i] > pb[i] {
+			return 1
+		}
+	}
+	return 0
+}
+
+// versionParts splits a version into its numeric parts
+func versionParts(version string) []int {
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if i := strings.IndexAny(version, "-+"); i >= 0 {
+		version = version[:i]
+	}
+	var parts []int
+	for _, field := range strings.Split(version, ".") {
+		n, _ := strconv.Atoi(field)
+		parts = append(parts, n)
+	}
+	return parts
+}
+
+// sortedCategories returns the categories used in the index
+func (r *Repository) sortedCategories() []string {
+	seen := make(map[string]bool)
+	var categories []string
+	for _, entry := range r.index.Plugins {
+		if entry.Category != "" && !seen[entry.Category] {
+			seen[entry.Category] = true
+			categories = append(categories, entry.Category)
+		}
+	}
+	sort.Strings(categories)
+	return categories
+}
@@ -17,6 +17,7 @@ import (
 // PluginUI represents the plugin manager interface
 type PluginUI struct {
 	pluginManager *entities.PluginManager
+	installer     *PluginInstaller
 
 	// UI state
 	currentView   PluginView
@@ -24,6 +25,8 @@ type PluginUI struct {
 	scrollOffset  int
 	searchQuery   string
 	searchActive  bool
+	detailsFrom   PluginView // list view the details view was opened from
+	statusMessage string
 
 	// Marketplace data
 	availablePlugins []*MarketplacePlugin
@@ -81,20 +84,21 @@ type MarketplacePlugin struct {
 	Compatibility map[string]string `json:"compatibility"`
 }
 
-// NewPluginUI creates a new plugin manager UI
-func NewPluginUI(pluginManager *entities.PluginManager) *PluginUI {
+// NewPluginUI creates a new plugin manager UI listing the installer's
+// repository
+func NewPluginUI(pluginManager *entities.PluginManager, installer *PluginInstaller) *PluginUI {
 	// Create a 1x1 white image for solid color drawing
 	whiteImage := ebiten.NewImage(1, 1)
 	whiteImage.Fill(color.RGBA{255, 255, 255, 255})
 
 	ui := &PluginUI{
 		pluginManager:     pluginManager,
+		installer:         installer,
 		currentView:       ViewMarketplace,
 		selectedIndex:     0,
 		scrollOffset:      0,
 		searchQuery:       "",
 		searchActive:      false,
-		categories:        []string{"All", "Blocks", "Items", "Creatures", "UI", "Tools", "Gameplay", "Visual"},
 		selectedCategory:  "All",
 		installingPlugins: make(map[string]bool),
 		installProgress:   make(map[string]float64),
@@ -114,114 +118,45 @@ func NewPluginUI(pluginManager *entities.PluginManager) *PluginUI {
 	return ui
 }
 
-// initializeMarketplace sets up the marketplace with sample plugins
+// initializeMarketplace lists the plugins in the installer's repository
 func (ui *PluginUI) initializeMarketplace() {
-	ui.availablePlugins = []*MarketplacePlugin{
-		{
-			ID:           "magic-system",
-			Name:         "Magic System",
-			Version:      "1.2.0",
-			Description:  "Adds magic spells, wands, and enchanting system to the game",
-			Author:       "TesselBox Team",
-			Category:     "Gameplay",
-			Tags:         []string{"magic", "spells", "enchanting"},
-			Downloads:    15420,
-			Rating:       4.8,
-			Dependencies: []string{},
-			DownloadURL:  "https://plugins.tesselbox.com/magic-system.so",
-			Installed:    false,
-			Enabled:      false,
-			LastUpdate:   time.Now().AddDate(0, -1, -15),
-			Features:     []string{"Spell casting", "Mana system", "Enchanting table", "Magic items"},
-		},
-		{
-			ID:           "colored-blocks",
-			Name:         "Colored Blocks Pack",
-			Version:      "2.1.0",
-			Description:  "Adds blocks with multiple color variations and patterns",
-			Author:       "Artist Collective",
-			Category:     "Blocks",
-			Tags:         []string{"blocks", "colors", "decoration"},
-			Downloads:    32100,
-			Rating:       4.9,
-			Dependencies: []string{},
-			DownloadURL:  "https://plugins.tesselbox.com/colored-blocks.so",
-			Installed:    false,
-			Enabled:      false,
-			LastUpdate:   time.Now().AddDate(0, -0, -5),
-			Features:     []string{"Multi-color blocks", "Pattern blocks", "Gradient blocks", "Custom textures"},
-		},
-		{
-			ID:           "advanced-crafting",
-			Name:         "Advanced Crafting",
-			Version:      "1.5.2",
-			Description:  "Enhanced crafting system with new recipes and workstations",
-			Author:       "Crafting Masters",
-			Category:     "Gameplay",
-			Tags:         []string{"crafting", "recipes", "workstations"},
-			Downloads:    28900,
-			Rating:       4.7,
-			Dependencies: []string{},
-			DownloadURL:  "https://plugins.tesselbox.com/advanced-crafting.so",
-			Installed:    false,
-			Enabled:      false,
-			LastUpdate:   time.Now().AddDate(0, -2, -10),
-			Features:     []string{"New recipes", "Advanced workstations", "Quality system", "Tool upgrades"},
-		},
-		{
-			ID:           "creature-mobs",
-			Name:         "Creature Mobs",
-			Version:      "1.0.0",
-			Description:  "Adds various creatures and mobs to the world",
-			Author:       "Wildlife Team",
-			Category:     "Creatures",
-			Tags:         []string{"creatures", "mobs", "animals"},
-			Downloads:    19800,
-			Rating:       4.6,
-			Dependencies: []string{},
-			DownloadURL:  "https://plugins.tesselbox.com/creature-mobs.so",
-			Installed:    false,
-			Enabled:      false,
-			LastUpdate:   time.Now().AddDate(0, -1, -20),
-			Features:     []string{"Friendly animals", "Hostile mobs", "Boss creatures", "AI behaviors"},
-		},
-		{
-			ID:           "ui-enhancement",
-			Name:         "UI Enhancement Pack",
-			Version:      "1.3.1",
-			Description:  "Improves the user interface with better layouts and visual effects",
-			Author:       "UI Designers",
-			Category:     "UI",
-			Tags:         []string{"ui", "interface", "visual"},
-			Downloads:    41200,
-			Rating:       4.9,
-			Dependencies: []string{},
-			DownloadURL:  "https://plugins.tesselbox.com/ui-enhancement.so",
-			Installed:    false,
-			Enabled:      false,
-			LastUpdate:   time.Now().AddDate(0, -0, -15),
-			Features:     []string{"Better inventory", "Enhanced tooltips", "Animations", "Themes"},
-		},
-		{
-			ID:           "tech-modpack",
-			Name:         "Technology Modpack",
-			Version:      "2.0.0",
-			Description:  "Adds technology, machines, and automation to the game",
-			Author:       "Tech Engineers",
-			Category:     "Tools",
-			Tags:         []string{"technology", "machines", "automation"},
-			Downloads:    35600,
-			Rating:       4.8,
-			Dependencies: []string{"advanced-crafting"},
-			DownloadURL:  "https://plugins.tesselbox.com/tech-modpack.so",
-			Installed:    false,
-			Enabled:      false,
-			LastUpdate:   time.Now().AddDate(0, -0, -8),
-			Features:     []string{"Machines", "Automation", "Power systems", "Tech items"},
-		},
+	ui.availablePlugins = nil
+	ui.categories = []string{"All"}
+
+	repository := ui.installer.Repository()
+	if repository == nil {
+		return
+	}
+	if err := repository.Reload(); err != nil {
+		log.Printf("Failed to reload plugin repository %s: %v", repository.Location(), err)
+	}
+
+	for _, entry := range repository.Plugins() {
+		plugin := entry.marketplacePlugin()
+		ui.refreshStatus(plugin)
+		ui.availablePlugins = append(ui.availablePlugins, plugin)
+	}
+	ui.categories = append(ui.categories, repository.sortedCategories()...)
+}
+
+// refreshStatus updates whether a plugin is installed and running
+func (ui *PluginUI) refreshStatus(plugin *MarketplacePlugin) {
+	plugin.Installed = ui.installer.IsInstalled(plugin.ID)
+	plugin.Enabled = plugin.Installed && ui.pluginManager.IsLoaded(plugin.ID)
+	if plugin.Installed {
+		plugin.LastUpdate = ui.installedAt(plugin.ID)
 	}
 }
 
+// installedAt returns when a plugin's script was installed
+func (ui *PluginUI) installedAt(pluginID string) time.Time {
+	info, err := ui.installer.GetPluginInfo(pluginID)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.InstallDate
+}
+
 // Update handles UI updates and input
 func (ui *PluginUI) Update() error {
 	// Update animations
@@ -329,6 +264,7 @@ func (ui *PluginUI) handleSelection() {
 
 		switch ui.currentView {
 		case ViewMarketplace, ViewSearch:
+			ui.detailsFrom = ui.currentView
 			ui.currentView = ViewDetails
 		case ViewInstalled:
 			// Toggle enable/disable
@@ -370,7 +306,10 @@ func (ui *PluginUI) getVisiblePlugins() []*MarketplacePlugin {
 	case ViewSearch:
 		return ui.searchPlugins(ui.searchQuery)
 	case ViewDetails:
-		return ui.getVisiblePlugins() // Return current selection
+		if ui.detailsFrom == ViewSearch {
+			return ui.searchPlugins(ui.searchQuery)
+		}
+		return ui.getPluginsByCategory(ui.selectedCategory)
 	default:
 		return ui.availablePlugins
 	}
@@ -420,40 +359,33 @@ func (ui *PluginUI) searchPlugins(query string) []*MarketplacePlugin {
 	return results
 }
 
-// installPlugin starts plugin installation
+// installPlugin starts installing a plugin from the repository
 func (ui *PluginUI) installPlugin(pluginID string) {
-	ui.installingPlugins[pluginID] = true
-	ui.installProgress[pluginID] = 0.0
-
-	// Simulate installation process
-	go func() {
-		for i := 0; i <= 100; i += 5 {
-			ui.installProgress[pluginID] = float64(i)
-			time.Sleep(100 * time.Millisecond)
+	for _, plugin := range ui.availablePlugins {
+		if plugin.ID != pluginID {
+			continue
 		}
-
-		// Mark as installed (thread safety issue - needs mutex)
-		for _, plugin := range ui.availablePlugins {
-			if plugin.ID == pluginID {
-				plugin.Installed = true
-				plugin.Enabled = true
-				break
-			}
+		if err := ui.installer.DownloadAndInstall(plugin); err != nil {
+			ui.statusMessage = fmt.Sprintf("Cannot install %s: %v", plugin.Name, err)
+			log.Printf("Cannot install plugin %s: %v", pluginID, err)
+			return
 		}
-
-		delete(ui.installingPlugins, pluginID)
-		delete(ui.installProgress, pluginID)
-
-		log.Printf("Plugin %s installed successfully", pluginID)
-	}()
+		ui.installingPlugins[pluginID] = true
+		ui.installProgress[pluginID] = 0.0
+		ui.statusMessage = ""
+		return
+	}
 }
 
 // uninstallPlugin uninstalls a plugin
 func (ui *PluginUI) uninstallPlugin(pluginID string) {
+	if err := ui.installer.UninstallPlugin(pluginID); err != nil {
+		ui.statusMessage = fmt.Sprintf("Cannot uninstall %s: %v", pluginID, err)
+		return
+	}
 	for _, plugin := range ui.availablePlugins {
 		if plugin.ID == pluginID {
-			plugin.Installed = false
-			plugin.Enabled = false
+			ui.refreshStatus(plugin)
 			break
 		}
 	}
@@ -525,9 +457,36 @@ func (ui *PluginUI) handleMouseClick(mx, my int) {
 	// and take appropriate actions
 }
 
-// updateInstallProgress updates installation progress
+// updateInstallProgress follows the installer's progress and refreshes the
+// list once an installation finishes
 func (ui *PluginUI) updateInstallProgress() {
-	// Progress is updated in the installation goroutines
+	finished := false
+	for pluginID := range ui.installingPlugins {
+		progress := ui.installer.GetDownloadProgress(pluginID)
+		if progress == nil {
+			delete(ui.installingPlugins, pluginID)
+			delete(ui.installProgress, pluginID)
+			continue
+		}
+		ui.installProgress[pluginID] = progress.Progress
+		if !progress.Complete {
+			continue
+		}
+
+		delete(ui.installingPlugins, pluginID)
+		delete(ui.installProgress, pluginID)
+		finished = true
+		if progress.Error != nil {
+			ui.statusMessage = fmt.Sprintf("Failed to install %s: %v", pluginID, progress.Error)
+		} else {
+			ui.statusMessage = fmt.Sprintf("Installed %s", pluginID)
+			log.Printf("Plugin %s installed successfully", pluginID)
+		}
+	}
+	if finished {
+		ui.installer.CleanupProgress()
+		ui.initializeMarketplace()
+	}
 }
 
 // Draw renders the plugin UI
@@ -739,6 +698,9 @@ func (ui *PluginUI) drawFooter(screen *ebiten.Image) {
 	// Draw help text
 	helpText := "↑↓ Navigate | Enter Select | Tab Switch Views | ESC Back | Search: Type"
 	ebitenutil.DebugPrintAt(screen, helpText, 10, 680)
+	if ui.statusMessage != "" {
+		ebitenutil.DebugPrintAt(screen, ui.statusMessage, 10, 660)
+	}
 }
 
 // wrapText wraps text to fit within a maximum width
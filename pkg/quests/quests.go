@@ -353,6 +353,13 @@ func (qm *QuestManager) GetActiveQuests(playerID string) []PlayerQuest {
 	return active
 }
 
+// GetQuestStatus gets the state of a player's quest, reporting false if the
+// player never accepted it
+func (qm *QuestManager) GetQuestStatus(playerID, questID string) (QuestStatus, bool) {
+	playerQuest, exists := qm.getPlayerQuests(playerID)[questID]
+	return playerQuest.Status, exists
+}
+
 // AbandonQuest abandons a quest
 func (qm *QuestManager) AbandonQuest(playerID, questID string) error {
 	playerData := qm.getPlayerQuests(playerID)
@@ -9,9 +9,9 @@ import (
 
 	"tesselbox/pkg/biomes"
 	"tesselbox/pkg/blocks"
-	"tesselbox/pkg/creatures"
 	"tesselbox/pkg/gametime"
 	"tesselbox/pkg/hexagon"
+	"tesselbox/pkg/mobs"
 	"tesselbox/pkg/organisms"
 	"tesselbox/pkg/player"
 	"tesselbox/pkg/world"
@@ -294,7 +294,14 @@ func (g *Game) Update() error {
 		g.World.SpawnCreatures(g.dayNightCycle, playerX, playerY)
 
 		// Update all creatures with AI
-		g.World.UpdateCreatures(playerX, playerY, deltaTime)
+		g.World.UpdateCreatures(&mobs.Context{
+			Target:       g.Player,
+			AmbientLight: g.dayNightCycle.AmbientLight,
+			Collides:     g.World.BoxCollides,
+			Climbable:    g.World.BoxClimbable,
+			Navigator:    g.World.Paths,
+		}, deltaTime)
+		g.World.Paths.Update(deltaTime)
 
 		// Update camera to follow player
 		g.CameraX = playerX - float64(g.ScreenWidth)/2
@@ -391,32 +398,15 @@ func (g *Game) Draw(screen *ebiten.Image) {
 				continue
 			}
 
-			// Choose color based on creature type
-			var creatureColor color.RGBA
-			switch creature.Type {
-			case creatures.SLIME:
-				creatureColor = color.RGBA{0, 255, 0, 255} // Green
-			case creatures.SPIDER:
-				creatureColor = color.RGBA{0, 0, 0, 255} // Black
-			case creatures.ZOMBIE:
-				creatureColor = color.RGBA{0, 100, 0, 255} // Dark green
-			default:
-				creatureColor = color.RGBA{128, 128, 128, 255} // Gray
-			}
-
-			// Draw creature as a square (zombies same size as player: 50x50)
-			size := 20.0
-			if creature.Type == creatures.ZOMBIE {
-				size = 50.0 // Same size as player
-			}
-			ebitenutil.DrawRect(screen, cX-size/2, cY-size/2, size, size, creatureColor)
+			// Draw creature as a box the size of its mob type
+			ebitenutil.DrawRect(screen, cX, cY, creature.Width, creature.Height, creature.Def.Color.RGBA())
 
 			// Draw health bar above creature if damaged
 			if creature.Health < creature.MaxHealth {
-				barWidth := size
+				barWidth := creature.Width
 				barHeight := 4.0
-				barX := cX - barWidth/2
-				barY := cY - size/2 - 8
+				barX := cX
+				barY := cY - 8
 
 				// Background (red)
 				ebitenutil.DrawRect(screen, barX, barY, barWidth, barHeight, color.RGBA{255, 0, 0, 255})
@@ -535,8 +525,8 @@ func (g *Game) drawHexagon(screen *ebiten.Image, hex *world.Hexagon) {
 	}
 
 	// Get humidity at current position from biome system
-	biomeType := biomes.GetBiomeAtPosition(hex.X, hex.Y, g.World.GetNoiseGenerator())
-	biomeProps := biomes.BiomeDefinitions[biomeType]
+	biomeType := g.World.BiomeAt(hex.X, hex.Y)
+	biomeProps := biomes.Definition(biomeType)
 	var humidity float64
 	if biomeProps != nil {
 		humidity = biomeProps.Humidity
@@ -0,0 +1,539 @@
+package save
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"tesselbox/pkg/chest"
+	"tesselbox/pkg/config"
+	"tesselbox/pkg/dimension"
+	"tesselbox/pkg/items"
+	"tesselbox/pkg/world"
+)
+
+// SaveVersion is the current player save format version
+const SaveVersion = "2.1"
+
+// FileKind identifies the kind of file a migration applies to
+type FileKind string
+
+const (
+	KindPlayerSave FileKind = "player_save"
+	KindChests     FileKind = "chests"
+	KindDimension  FileKind = "dimension_state"
+	KindChunks     FileKind = "chunks"
+)
+
+// legacyVersion is assumed for files written before they carried a version
+const legacyVersion = "1.0"
+
+// Migration upgrades one kind of file from one version to the next
+type Migration struct {
+	Kind        FileKind
+	From        string
+	To          string
+	Description string
+	// Apply transforms the file contents; the version field is set afterwards
+	Apply func(data []byte) ([]byte, error)
+}
+
+// MigrationRegistry holds the migration steps for every file kind
+type MigrationRegistry struct {
+	steps   map[FileKind]map[string]*Migration // Keyed by kind, then From version
+	current map[FileKind]string
+}
+
+// NewMigrationRegistry creates an empty migration registry
+func NewMigrationRegistry() *MigrationRegistry {
+	return &MigrationRegistry{
+		steps:   make(map[FileKind]map[string]*Migration),
+		current: make(map[FileKind]string),
+	}
+}
+
+// SetCurrentVersion sets the version migrations of a kind lead to
+func (mr *MigrationRegistry) SetCurrentVersion(kind FileKind, version string) {
+	mr.current[kind] = version
+}
+
+// CurrentVersion returns the version migrations of a kind lead to
+func (mr *MigrationRegistry) CurrentVersion(kind FileKind) string {
+	return mr.current[kind]
+}
+
+// Register adds a migration step. Only one step may start from each version.
+func (mr *MigrationRegistry) Register(migration *Migration) error {
+	if migration.From == migration.To {
+		return fmt.Errorf("migration %s %s does not change the version", migration.Kind, migration.From)
+	}
+	if mr.steps[migration.Kind] == nil {
+		mr.steps[migration.Kind] = make(map[string]*Migration)
+	}
+	if _, exists := mr.steps[migration.Kind][migration.From]; exists {
+		return fmt.Errorf("migration %s from %s is already registered", migration.Kind, migration.From)
+	}
+	mr.steps[migration.Kind][migration.From] = migration
+	return nil
+}
+
+// Plan returns the steps that upgrade a kind from the given version to the
+// current one, in order. An empty plan means the version is already current.
+func (mr *MigrationRegistry) Plan(kind FileKind, from string) ([]*Migration, error) {
+	current, ok := mr.current[kind]
+	if !ok {
+		return nil, fmt.Errorf("no current version registered for %s", kind)
+	}
+
+	var plan []*Migration
+	visited := make(map[string]bool)
+	for version := from; version != current; {
+		if visited[version] {
+			return nil, fmt.Errorf("migration cycle for %s at version %s", kind, version)
+		}
+		visited[version] = true
+
+		step, ok := mr.steps[kind][version]
+		if !ok {
+			return nil, fmt.Errorf("no migration path for %s from version %s to %s", kind, version, current)
+		}
+		plan = append(plan, step)
+		version = step.To
+	}
+	return plan, nil
+}
+
+// DefaultMigrations returns the registry of all built-in migrations
+func DefaultMigrations() *MigrationRegistry {
+	mr := NewMigrationRegistry()
+	mr.SetCurrentVersion(KindPlayerSave, SaveVersion)
+	mr.SetCurrentVersion(KindChests, chest.FileVersion)
+	mr.SetCurrentVersion(KindDimension, dimension.StateVersion)
+	mr.SetCurrentVersion(KindChunks, "2.0")
+
+	builtins := []*Migration{
+		{
+			Kind:        KindPlayerSave,
+			From:        "1.0",
+			To:          "2.0",
+			Description: "Enhanced format; survival, equipment, zombie and chest sections are optional",
+			Apply:       func(data []byte) ([]byte, error) { return data, nil },
+		},
+		{
+			Kind:        KindPlayerSave,
+			From:        "2.0",
+			To:          "2.1",
+			Description: "Record the item palette that raw item types were written with",
+			Apply:       migratePlayerSaveItemPalette,
+		},
+		{
+			Kind:        KindChests,
+			From:        "1.0",
+			To:          "2.0",
+			Description: "Wrap chests in a versioned file with an item palette",
+			Apply:       migrateChestsToPalette,
+		},
+		{
+			Kind:        KindDimension,
+			From:        "1.0",
+			To:          "2.0",
+			Description: "Add a version field to dimension state",
+			Apply:       func(data []byte) ([]byte, error) { return data, nil },
+		},
+		{
+			Kind:        KindChunks,
+			From:        "1.0",
+			To:          "2.0",
+			Description: "Move chunk_X_Y.json files into region files",
+			// Applied per world by Migrator.migrateChunks
+		},
+	}
+	for _, migration := range builtins {
+		if err := mr.Register(migration); err != nil {
+			panic(err) // Built-in migrations are static
+		}
+	}
+
+	return mr
+}
+
+// migratePlayerSaveItemPalette adds the palette matching the raw item types
+// that 2.0 saves stored
+func migratePlayerSaveItemPalette(data []byte) ([]byte, error) {
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal save data: %w", err)
+	}
+	if _, ok := doc["item_palette"]; !ok {
+		doc["item_palette"] = items.NewItemPalette()
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// migrateChestsToPalette converts a 1.0 chests array to the 2.0 file layout
+func migrateChestsToPalette(data []byte) ([]byte, error) {
+	var legacy []struct {
+		X     float64 `json:"x"`
+		Y     float64 `json:"y"`
+		Slots []struct {
+			Type       int
+			Quantity   int
+			Durability int
+		} `json:"slots"`
+	}
+	if err := json.Unmarshal(data, &legacy); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal chests: %w", err)
+	}
+
+	type slot struct {
+		Type       int `json:"type"`
+		Quantity   int `json:"quantity"`
+		Durability int `json:"durability"`
+	}
+	type chestRecord struct {
+		X     float64 `json:"x"`
+		Y     float64 `json:"y"`
+		Slots []slot  `json:"slots"`
+	}
+
+	// The seeded palette maps raw item types to themselves
+	chests := make([]chestRecord, 0, len(legacy))
+	for _, c := range legacy {
+		record := chestRecord{X: c.X, Y: c.Y, Slots: make([]slot, len(c.Slots))}
+		for i, s := range c.Slots {
+			record.Slots[i] = slot{Type: s.Type, Quantity: s.Quantity, Durability: s.Durability}
+		}
+		chests = append(chests, record)
+	}
+
+	return json.MarshalIndent(map[string]interface{}{
+		"item_palette": items.NewItemPalette(),
+		"chests":       chests,
+	}, "", "  ")
+}
+
+// MigrationResult describes the migration of a single file or chunk set
+type MigrationResult struct {
+	Kind        FileKind
+	Path        string
+	FromVersion string
+	ToVersion   string
+	Steps       []string // Descriptions of the applied (or planned) steps
+	BackupPath  string   // Empty in dry-run mode or when nothing changed
+	DryRun      bool
+}
+
+// Migrator applies registered migrations to files on disk. Every file is
+// backed up before it is rewritten; in dry-run mode nothing is written.
+type Migrator struct {
+	Registry  *MigrationRegistry
+	DryRun    bool
+	BackupDir string // Defaults to a timestamped directory beside each file
+}
+
+// NewMigrator creates a migrator using the built-in migrations
+func NewMigrator(dryRun bool) *Migrator {
+	return &Migrator{
+		Registry: DefaultMigrations(),
+		DryRun:   dryRun,
+	}
+}
+
+// detectVersion reads the version of a file's contents
+func detectVersion(kind FileKind, data []byte) (string, error) {
+	trimmed := bytes.TrimSpace(data)
+	if kind == KindChests && len(trimmed) > 0 && trimmed[0] == '[' {
+		return legacyVersion, nil // 1.0 chests were a bare array
+	}
+
+	var header struct {
+		Version interface{} `json:"version"`
+	}
+	if err := json.Unmarshal(trimmed, &header); err != nil {
+		return "", fmt.Errorf("failed to read file version: %w", err)
+	}
+
+	switch v := header.Version.(type) {
+	case nil:
+		return legacyVersion, nil
+	case string:
+		if v == "" {
+			return legacyVersion, nil
+		}
+		return v, nil
+	case float64:
+		return fmt.Sprintf("%.1f", v), nil
+	default:
+		return "", fmt.Errorf("invalid version field %v", v)
+	}
+}
+
+// setVersion stamps the version field of a JSON object
+func setVersion(data []byte, version string) ([]byte, error) {
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal migrated data: %w", err)
+	}
+	doc["version"] = version
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// MigrateFile upgrades a single file of the given kind to the current version
+func (m *Migrator) MigrateFile(kind FileKind, path string) (*MigrationResult, error) {
+	result, copyPath, err := m.MigrateCopy(kind, path)
+	if err != nil || copyPath == "" {
+		return result, err
+	}
+	if err := m.ReplaceWithCopy(result, copyPath); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// MigrateCopy upgrades a file of the given kind into a copy beside it and
+// leaves the file itself untouched, so the copy can be checked before
+// ReplaceWithCopy commits it. The copy path is empty when the file is already
+// current, and in dry-run mode.
+func (m *Migrator) MigrateCopy(kind FileKind, path string) (*MigrationResult, string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	result, migrated, err := m.migrateData(kind, path, data)
+	if err != nil || len(result.Steps) == 0 || m.DryRun {
+		return result, "", err
+	}
+
+	copyPath := path + ".migrated"
+	if err := os.WriteFile(copyPath, migrated, 0644); err != nil {
+		return nil, "", fmt.Errorf("failed to write migrated copy of %s: %w", path, err)
+	}
+	return result, copyPath, nil
+}
+
+// ReplaceWithCopy backs up the file a migration result describes and
+// atomically replaces it with the migrated copy
+func (m *Migrator) ReplaceWithCopy(result *MigrationResult, copyPath string) error {
+	backupPath, err := m.backupFile(result.Path)
+	if err != nil {
+		os.Remove(copyPath)
+		return err
+	}
+	result.BackupPath = backupPath
+
+	if err := os.Rename(copyPath, result.Path); err != nil {
+		os.Remove(copyPath)
+		return fmt.Errorf("failed to replace %s: %w", result.Path, err)
+	}
+	return nil
+}
+
+// migrateData runs the steps that upgrade a file's contents to the current
+// version. Every step runs even in dry-run mode so broken files are reported.
+func (m *Migrator) migrateData(kind FileKind, path string, data []byte) (*MigrationResult, []byte, error) {
+	from, err := detectVersion(kind, data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	plan, err := m.Registry.Plan(kind, from)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	result := &MigrationResult{
+		Kind:        kind,
+		Path:        path,
+		FromVersion: from,
+		ToVersion:   m.Registry.CurrentVersion(kind),
+		DryRun:      m.DryRun,
+	}
+
+	migrated := data
+	for _, step := range plan {
+		if step.Apply == nil {
+			return nil, nil, fmt.Errorf("%s: migration %s -> %s cannot be applied to a single file", path, step.From, step.To)
+		}
+		migrated, err = step.Apply(migrated)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: migration %s -> %s failed: %w", path, step.From, step.To, err)
+		}
+		migrated, err = setVersion(migrated, step.To)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", path, err)
+		}
+		result.Steps = append(result.Steps, fmt.Sprintf("%s -> %s: %s", step.From, step.To, step.Description))
+	}
+
+	if kind == KindPlayerSave && len(plan) > 0 {
+		var saveData SaveData
+		if err := json.Unmarshal(migrated, &saveData); err != nil {
+			return nil, nil, fmt.Errorf("%s: migrated save does not decode: %w", path, err)
+		}
+	}
+
+	return result, migrated, nil
+}
+
+// backupDirFor returns the directory a file is backed up to
+func (m *Migrator) backupDirFor(path string) string {
+	if m.BackupDir != "" {
+		return m.BackupDir
+	}
+	return filepath.Join(filepath.Dir(path), "backups", "migration_"+time.Now().Format("20060102_150405"))
+}
+
+// backupFile copies a file into the backup directory before it is migrated
+func (m *Migrator) backupFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s for backup: %w", path, err)
+	}
+
+	backupDir := m.backupDirFor(path)
+	if err := os.MkdirAll(backupDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	backupPath := filepath.Join(backupDir, filepath.Base(path))
+	if err := os.WriteFile(backupPath, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write backup of %s: %w", path, err)
+	}
+	return backupPath, nil
+}
+
+// migrateChunks moves a world's legacy chunk files into region files
+func (m *Migrator) migrateChunks(worldName string) (*MigrationResult, error) {
+	storage := world.NewWorldStorage(worldName)
+	pattern := filepath.Join(storage.WorldDir, "chunk_*.json")
+	legacyFiles, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list chunk files: %w", err)
+	}
+
+	current := m.Registry.CurrentVersion(KindChunks)
+	result := &MigrationResult{
+		Kind:        KindChunks,
+		Path:        storage.WorldDir,
+		FromVersion: current,
+		ToVersion:   current,
+		DryRun:      m.DryRun,
+	}
+	if len(legacyFiles) == 0 {
+		return result, nil
+	}
+
+	plan, err := m.Registry.Plan(KindChunks, legacyVersion)
+	if err != nil {
+		return nil, err
+	}
+	result.FromVersion = legacyVersion
+	for _, step := range plan {
+		result.Steps = append(result.Steps, fmt.Sprintf("%s -> %s: %s (%d files)", step.From, step.To, step.Description, len(legacyFiles)))
+	}
+
+	if m.DryRun {
+		return result, nil
+	}
+
+	backupDir := m.BackupDir
+	if backupDir == "" {
+		backupDir = m.backupDirFor(legacyFiles[0])
+	}
+	chunkBackups := &Migrator{Registry: m.Registry, BackupDir: filepath.Join(backupDir, "chunks", worldName)}
+	for _, file := range legacyFiles {
+		if _, err := chunkBackups.backupFile(file); err != nil {
+			return nil, err
+		}
+	}
+	result.BackupPath = chunkBackups.BackupDir
+
+	if _, err := storage.ConvertLegacyChunks(); err != nil {
+		return nil, fmt.Errorf("failed to convert chunk files: %w", err)
+	}
+
+	return result, nil
+}
+
+// MigrateWorld upgrades every file belonging to a world: player saves,
+// chests, dimension state and the chunk files of each dimension
+func (m *Migrator) MigrateWorld(worldName string) ([]*MigrationResult, error) {
+	saveDir := config.GetWorldSaveDir(worldName)
+
+	// Keep all backups of one run together
+	runner := *m
+	if runner.BackupDir == "" {
+		runner.BackupDir = filepath.Join(saveDir, "backups", "migration_"+time.Now().Format("20060102_150405"))
+	}
+
+	var results []*MigrationResult
+
+	playerSaves, err := filepath.Glob(filepath.Join(saveDir, "player_*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list player saves: %w", err)
+	}
+	files := make(map[string]FileKind)
+	for _, path := range playerSaves {
+		files[path] = KindPlayerSave
+	}
+	files[config.GetChestFile(worldName)] = KindChests
+	files[filepath.Join(saveDir, "dimensions", "dimension_state.json")] = KindDimension
+
+	for path, kind := range files {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			continue
+		}
+		result, err := runner.MigrateFile(kind, path)
+		if err != nil {
+			return results, err
+		}
+		results = append(results, result)
+	}
+
+	for _, name := range []string{worldName, dimension.RandomlandWorldName(worldName)} {
+		if !world.WorldExists(name) && !hasLegacyChunks(name) {
+			continue
+		}
+		result, err := runner.migrateChunks(name)
+		if err != nil {
+			return results, err
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// hasLegacyChunks reports whether a world directory holds chunk_X_Y.json files
+func hasLegacyChunks(worldName string) bool {
+	matches, _ := filepath.Glob(filepath.Join(config.GetWorldsDir(), worldName, "chunk_*.json"))
+	return len(matches) > 0
+}
+
+// FormatMigrationResults renders migration results for the console
+func FormatMigrationResults(results []*MigrationResult) string {
+	var b strings.Builder
+	for _, result := range results {
+		if len(result.Steps) == 0 {
+			fmt.Fprintf(&b, "%s: %s is up to date (%s)\n", result.Kind, result.Path, result.ToVersion)
+			continue
+		}
+		verb := "migrated"
+		if result.DryRun {
+			verb = "would migrate"
+		}
+		fmt.Fprintf(&b, "%s: %s %s from %s to %s\n", result.Kind, verb, result.Path, result.FromVersion, result.ToVersion)
+		for _, step := range result.Steps {
+			fmt.Fprintf(&b, "  %s\n", step)
+		}
+		if result.BackupPath != "" {
+			fmt.Fprintf(&b, "  backup: %s\n", result.BackupPath)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,282 @@
+package save
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"tesselbox/pkg/blocks"
+	"tesselbox/pkg/world"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// copyFixture copies a file from testdata/migrations into dir
+func copyFixture(t *testing.T, name, dir, as string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "migrations", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, as)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// checkGolden compares data with a golden file, rewriting it with -update
+func checkGolden(t *testing.T, name string, data []byte) {
+	t.Helper()
+	golden := filepath.Join("testdata", "migrations", name)
+	if *update {
+		if err := os.WriteFile(golden, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("%v (run go test -update to create it)", err)
+	}
+	if !bytes.Equal(data, want) {
+		t.Errorf("%s differs from the golden file:\ngot:\n%s\nwant:\n%s", name, data, want)
+	}
+}
+
+func TestMigrateFileGolden(t *testing.T) {
+	tests := []struct {
+		kind   FileKind
+		input  string
+		golden string
+		from   string
+		to     string
+		steps  int
+	}{
+		{KindPlayerSave, "player_save_1.0.json", "player_save_1.0.golden.json", "1.0", SaveVersion, 2},
+		{KindPlayerSave, "player_save_2.0.json", "player_save_2.0.golden.json", "2.0", SaveVersion, 1},
+		{KindChests, "chests_1.0.json", "chests_1.0.golden.json", "1.0", "2.0", 1},
+		{KindDimension, "dimension_state_1.0.json", "dimension_state_1.0.golden.json", "1.0", "2.0", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			dir := t.TempDir()
+			path := copyFixture(t, tt.input, dir, "file.json")
+			original, _ := os.ReadFile(path)
+
+			migrator := NewMigrator(false)
+			migrator.BackupDir = filepath.Join(dir, "backups")
+			result, err := migrator.MigrateFile(tt.kind, path)
+			if err != nil {
+				t.Fatalf("MigrateFile: %v", err)
+			}
+			if result.FromVersion != tt.from || result.ToVersion != tt.to {
+				t.Errorf("migrated %s -> %s, want %s -> %s", result.FromVersion, result.ToVersion, tt.from, tt.to)
+			}
+			if len(result.Steps) != tt.steps {
+				t.Errorf("applied %d steps, want %d: %v", len(result.Steps), tt.steps, result.Steps)
+			}
+
+			migrated, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkGolden(t, tt.golden, migrated)
+
+			backup, err := os.ReadFile(result.BackupPath)
+			if err != nil {
+				t.Fatalf("reading backup: %v", err)
+			}
+			if !bytes.Equal(backup, original) {
+				t.Error("backup does not hold the original file")
+			}
+
+			// Migrating again is a no-op
+			again, err := migrator.MigrateFile(tt.kind, path)
+			if err != nil {
+				t.Fatalf("second MigrateFile: %v", err)
+			}
+			if len(again.Steps) != 0 {
+				t.Errorf("second migration applied %v", again.Steps)
+			}
+		})
+	}
+}
+
+func TestMigrateChunksGolden(t *testing.T) {
+	if len(blocks.BlockDefinitions) == 0 {
+		blocks.LoadBlocks()
+	}
+	worldName := fmt.Sprintf("migrate_chunks_test_%d", time.Now().UnixNano())
+	storage := world.NewWorldStorage(worldName)
+	t.Cleanup(func() { os.RemoveAll(storage.WorldDir) })
+
+	fixtures, err := filepath.Glob(filepath.Join("testdata", "migrations", "chunks_1.0", "chunk_*.json"))
+	if err != nil || len(fixtures) == 0 {
+		t.Fatalf("no chunk fixtures: %v", err)
+	}
+	var chunks [][2]int
+	for _, fixture := range fixtures {
+		name := filepath.Base(fixture)
+		copyFixture(t, filepath.Join("chunks_1.0", name), storage.WorldDir, name)
+		var chunkX, chunkY int
+		fmt.Sscanf(name, "chunk_%d_%d.json", &chunkX, &chunkY)
+		chunks = append(chunks, [2]int{chunkX, chunkY})
+	}
+
+	migrator := NewMigrator(false)
+	migrator.BackupDir = t.TempDir()
+	result, err := migrator.migrateChunks(worldName)
+	if err != nil {
+		t.Fatalf("migrateChunks: %v", err)
+	}
+	if result.FromVersion != "1.0" || len(result.Steps) != 1 {
+		t.Errorf("migrated from %s with steps %v", result.FromVersion, result.Steps)
+	}
+	if legacy, _ := filepath.Glob(filepath.Join(storage.WorldDir, "chunk_*.json")); len(legacy) != 0 {
+		t.Errorf("legacy chunk files left behind: %v", legacy)
+	}
+	if backups, _ := filepath.Glob(filepath.Join(result.BackupPath, "chunk_*.json")); len(backups) != len(fixtures) {
+		t.Errorf("backed up %d chunk files, want %d", len(backups), len(fixtures))
+	}
+
+	// The regions decode to the same blocks the legacy files held
+	type goldenHex struct {
+		X            float64 `json:"x"`
+		Y            float64 `json:"y"`
+		Block        string  `json:"block"`
+		Health       float64 `json:"health"`
+		LiquidLevel  int     `json:"liquid_level,omitempty"`
+		LiquidSource bool    `json:"liquid_source,omitempty"`
+	}
+	decoded := make(map[string]map[string]goldenHex)
+	for _, coords := range chunks {
+		chunk, err := storage.LoadChunk(coords[0], coords[1])
+		if err != nil || chunk == nil {
+			t.Fatalf("chunk %d,%d not in its region: %v", coords[0], coords[1], err)
+		}
+		hexagons := make(map[string]goldenHex)
+		for key, hex := range chunk.Hexagons {
+			hexagons[fmt.Sprintf("%d,%d", key[0], key[1])] = goldenHex{
+				X:            hex.X,
+				Y:            hex.Y,
+				Block:        blocks.BlockID(hex.BlockType),
+				Health:       hex.Health,
+				LiquidLevel:  hex.LiquidLevel,
+				LiquidSource: hex.LiquidSource,
+			}
+		}
+		decoded[fmt.Sprintf("chunk_%d_%d", coords[0], coords[1])] = hexagons
+	}
+	data, err := json.MarshalIndent(decoded, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "chunks_1.0.golden.json", append(data, '\n'))
+
+	// Migrating again is a no-op
+	again, err := migrator.migrateChunks(worldName)
+	if err != nil {
+		t.Fatalf("second migrateChunks: %v", err)
+	}
+	if len(again.Steps) != 0 {
+		t.Errorf("second migration applied %v", again.Steps)
+	}
+}
+
+func TestMigrateDryRunLeavesFile(t *testing.T) {
+	dir := t.TempDir()
+	path := copyFixture(t, "player_save_1.0.json", dir, "player_alex.json")
+	original, _ := os.ReadFile(path)
+
+	result, copyPath, err := NewMigrator(true).MigrateCopy(KindPlayerSave, path)
+	if err != nil {
+		t.Fatalf("MigrateCopy: %v", err)
+	}
+	if len(result.Steps) == 0 || copyPath != "" || result.BackupPath != "" {
+		t.Errorf("dry run returned steps %v, copy %q, backup %q", result.Steps, copyPath, result.BackupPath)
+	}
+	if data, _ := os.ReadFile(path); !bytes.Equal(data, original) {
+		t.Error("dry run rewrote the file")
+	}
+}
+
+func TestMigrateErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		kind    FileKind
+		content string
+		wantErr string
+	}{
+		{"invalid JSON", KindPlayerSave, "{not json", "failed to read file version"},
+		{"unknown version", KindPlayerSave, `{"version": "0.5"}`, "no migration path"},
+		{"invalid version field", KindPlayerSave, `{"version": true}`, "invalid version field"},
+		{"chests not a list", KindChests, `{"version": "1.0", "chests": {}}`, "failed to unmarshal chests"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "file.json")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := NewMigrator(false).MigrateFile(tt.kind, path)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("MigrateFile error = %v, want %q", err, tt.wantErr)
+			}
+			if data, _ := os.ReadFile(path); string(data) != tt.content {
+				t.Error("failed migration rewrote the file")
+			}
+		})
+	}
+}
+
+func TestLoadGameMigratesValidSave(t *testing.T) {
+	dir := t.TempDir()
+	path := copyFixture(t, "player_save_1.0.json", dir, "player_alex.json")
+
+	sm := &SaveManager{SaveDir: dir, WorldName: "golden", PlayerName: "alex"}
+	data, err := sm.LoadGame()
+	if err != nil {
+		t.Fatalf("LoadGame: %v", err)
+	}
+	if data.Version != SaveVersion || data.ItemPalette == nil {
+		t.Errorf("loaded version %q with palette %v, want %s with a palette", data.Version, data.ItemPalette, SaveVersion)
+	}
+
+	onDisk, _ := os.ReadFile(path)
+	checkGolden(t, "player_save_1.0.golden.json", onDisk)
+	if _, err := os.Stat(path + ".migrated"); !os.IsNotExist(err) {
+		t.Error("migrated copy was left behind")
+	}
+}
+
+func TestLoadGameKeepsInvalidSave(t *testing.T) {
+	dir := t.TempDir()
+	original := []byte(`{"world_name": "golden", "player_x": 1, "player_y": 2}`)
+	path := filepath.Join(dir, "player_alex.json")
+	if err := os.WriteFile(path, original, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	sm := &SaveManager{SaveDir: dir, WorldName: "golden", PlayerName: "alex"}
+	if _, err := sm.LoadGame(); err == nil {
+		t.Fatal("LoadGame accepted a save without a player name")
+	}
+	if data, _ := os.ReadFile(path); !bytes.Equal(data, original) {
+		t.Errorf("invalid save was rewritten:\n%s", data)
+	}
+	if _, err := os.Stat(path + ".migrated"); !os.IsNotExist(err) {
+		t.Error("migrated copy was left behind")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "backups")); !os.IsNotExist(err) {
+		t.Error("a migration backup was made of a save that was not migrated")
+	}
+}
@@ -11,10 +11,11 @@ import (
 
 	"tesselbox/pkg/chest"
 	"tesselbox/pkg/config"
-	"tesselbox/pkg/enemies"
 	"tesselbox/pkg/equipment"
 	"tesselbox/pkg/health"
 	"tesselbox/pkg/items"
+	"tesselbox/pkg/mobs"
+	"tesselbox/pkg/palette"
 	"tesselbox/pkg/player"
 	"tesselbox/pkg/survival"
 	"tesselbox/pkg/world"
@@ -37,8 +38,13 @@ type SaveData struct {
 	PlayerVY        float64 `json:"player_vy"`
 	PlayerHealth    float64 `json:"player_health"`
 	PlayerMaxHealth float64 `json:"player_max_health"`
+	PlayerXP        int     `json:"player_xp,omitempty"`
 	SelectedSlot    int     `json:"selected_slot"`
 
+	// ItemPalette maps the item IDs stored in slots to stable string IDs.
+	// Saves written before palettes existed have none and store raw item types.
+	ItemPalette *palette.Palette `json:"item_palette,omitempty"`
+
 	// Inventory state
 	InventorySlots []InventorySlotData `json:"inventory_slots"`
 	HotbarSlots    []InventorySlotData `json:"hotbar_slots"`
@@ -76,9 +82,30 @@ type SaveData struct {
 
 // InventorySlotData represents a single inventory slot for serialization
 type InventorySlotData struct {
-	Type       items.ItemType `json:"type"`
-	Quantity   int            `json:"quantity"`
-	Durability int            `json:"durability"`
+	Type       int             `json:"type"` // Index into the save's item palette
+	Quantity   int             `json:"quantity"`
+	Durability int             `json:"durability"`
+	Meta       *items.Metadata `json:"meta,omitempty"`
+}
+
+// encodeSlot converts an item to its serialized form
+func encodeSlot(itemPalette *palette.Palette, item items.Item) InventorySlotData {
+	return InventorySlotData{
+		Type:       items.EncodeItemType(itemPalette, item.Type),
+		Quantity:   item.Quantity,
+		Durability: item.Durability,
+		Meta:       item.Meta.Clone(),
+	}
+}
+
+// decodeSlot converts a serialized slot back to an item
+func decodeSlot(itemPalette *palette.Palette, slot InventorySlotData) items.Item {
+	return items.Item{
+		Type:       items.DecodeItemType(itemPalette, slot.Type),
+		Quantity:   slot.Quantity,
+		Durability: slot.Durability,
+		Meta:       slot.Meta.Clone(),
+	}
 }
 
 // SurvivalStatsData stores survival mode statistics
@@ -89,6 +116,7 @@ type SurvivalStatsData struct {
 	MaxThirst      float64   `json:"max_thirst"`
 	Stamina        float64   `json:"stamina"`
 	MaxStamina     float64   `json:"max_stamina"`
+	Saturation     float64   `json:"saturation,omitempty"`
 	LastDamageTime time.Time `json:"last_damage_time"`
 	IsStarving     bool      `json:"is_starving"`
 	IsDehydrated   bool      `json:"is_dehydrated"`
@@ -96,14 +124,15 @@ type SurvivalStatsData struct {
 
 // EquipmentSlotData stores a single equipment item
 type EquipmentSlotData struct {
-	Name          string  `json:"name"`
-	Slot          int     `json:"slot"`
-	Material      int     `json:"material"`
-	ArmorType     int     `json:"armor_type"`
-	BaseDefense   float64 `json:"base_defense"`
-	Durability    int     `json:"durability"`
-	MaxDurability int     `json:"max_durability"`
-	GrantsFlight  bool    `json:"grants_flight"`
+	Name          string          `json:"name"`
+	Slot          int             `json:"slot"`
+	Material      int             `json:"material"`
+	ArmorType     int             `json:"armor_type"`
+	BaseDefense   float64         `json:"base_defense"`
+	Durability    int             `json:"durability"`
+	MaxDurability int             `json:"max_durability"`
+	GrantsFlight  bool            `json:"grants_flight"`
+	Meta          *items.Metadata `json:"meta,omitempty"`
 }
 
 // BodyPartHealthData stores health for each body part
@@ -114,17 +143,8 @@ type BodyPartHealthData struct {
 	IsVital   bool    `json:"is_vital"`
 }
 
-// ZombieData stores zombie state
-type ZombieData struct {
-	ID        string  `json:"id"`
-	X         float64 `json:"x,omitempty"`
-	Y         float64 `json:"y,omitempty"`
-	Health    float64 `json:"health"`
-	MaxHealth float64 `json:"max_health"`
-	Type      int     `json:"type"`
-	IsAlive   bool    `json:"is_alive"`
-	State     int     `json:"state"`
-}
+// ZombieData stores the state of a mob from the zombie spawner
+type ZombieData = mobs.Data
 
 // ChestData stores chest contents and position
 type ChestData struct {
@@ -160,7 +180,7 @@ func NewSaveManager(worldName, playerName string) *SaveManager {
 func (sm *SaveManager) SaveGame(gameState *GameState) error {
 	// Create save data
 	saveData := &SaveData{
-		Version:    "2.0", // Updated version for enhanced save format
+		Version:    SaveVersion,
 		SaveTime:   time.Now(),
 		WorldName:  sm.WorldName,
 		PlayerName: sm.PlayerName,
@@ -174,6 +194,7 @@ func (sm *SaveManager) SaveGame(gameState *GameState) error {
 		PlayerVY:        gameState.Player.VY,
 		PlayerHealth:    gameState.PlayerHealth,
 		PlayerMaxHealth: gameState.PlayerMaxHealth,
+		PlayerXP:        gameState.Player.XP,
 		SelectedSlot:    gameState.Player.SelectedSlot,
 
 		// Camera state
@@ -200,17 +221,15 @@ func (sm *SaveManager) SaveGame(gameState *GameState) error {
 		BlocksDestroyed: gameState.BlocksDestroyed,
 		ItemsCrafted:    gameState.ItemsCrafted,
 		PlayTime:        gameState.PlayTime,
+
+		ItemPalette: items.NewItemPalette(),
 	}
 
 	// Convert inventory to serializable format
 	if gameState.Inventory != nil {
 		saveData.InventorySlots = make([]InventorySlotData, len(gameState.Inventory.Slots))
 		for i, slot := range gameState.Inventory.Slots {
-			saveData.InventorySlots[i] = InventorySlotData{
-				Type:       slot.Type,
-				Quantity:   slot.Quantity,
-				Durability: slot.Durability,
-			}
+			saveData.InventorySlots[i] = encodeSlot(saveData.ItemPalette, slot)
 		}
 
 		// Save hotbar slots (first 10 slots of inventory)
@@ -221,11 +240,7 @@ func (sm *SaveManager) SaveGame(gameState *GameState) error {
 		saveData.HotbarSlots = make([]InventorySlotData, hotbarSize)
 		for i := 0; i < hotbarSize; i++ {
 			slot := gameState.Inventory.Slots[i]
-			saveData.HotbarSlots[i] = InventorySlotData{
-				Type:       slot.Type,
-				Quantity:   slot.Quantity,
-				Durability: slot.Durability,
-			}
+			saveData.HotbarSlots[i] = encodeSlot(saveData.ItemPalette, slot)
 		}
 	}
 
@@ -238,6 +253,7 @@ func (sm *SaveManager) SaveGame(gameState *GameState) error {
 			MaxThirst:      gameState.SurvivalManager.MaxThirst,
 			Stamina:        gameState.SurvivalManager.Stamina,
 			MaxStamina:     gameState.SurvivalManager.MaxStamina,
+			Saturation:     gameState.SurvivalManager.Saturation,
 			LastDamageTime: gameState.SurvivalManager.LastDamageTime,
 			IsStarving:     gameState.SurvivalManager.IsStarving,
 			IsDehydrated:   gameState.SurvivalManager.IsDehydrated,
@@ -258,6 +274,7 @@ func (sm *SaveManager) SaveGame(gameState *GameState) error {
 					Durability:    item.Durability,
 					MaxDurability: item.MaxDurability,
 					GrantsFlight:  item.GrantsFlight,
+					Meta:          item.Meta.Clone(),
 				})
 			}
 		}
@@ -278,20 +295,11 @@ func (sm *SaveManager) SaveGame(gameState *GameState) error {
 
 	// Save zombies
 	if gameState.ZombieSpawner != nil {
-		zombies := gameState.ZombieSpawner.Zombies
+		zombies := gameState.ZombieSpawner.Mobs
 		saveData.Zombies = make([]ZombieData, 0, len(zombies))
 		for _, zombie := range zombies {
-			if zombie.IsAlive {
-				saveData.Zombies = append(saveData.Zombies, ZombieData{
-					ID:        zombie.ID,
-					X:         zombie.X,
-					Y:         zombie.Y,
-					Health:    zombie.Health,
-					MaxHealth: zombie.MaxHealth,
-					Type:      int(zombie.Type),
-					IsAlive:   zombie.IsAlive,
-					State:     int(zombie.State),
-				})
+			if zombie.IsAlive() {
+				saveData.Zombies = append(saveData.Zombies, zombie.Data())
 			}
 		}
 	}
@@ -303,11 +311,7 @@ func (sm *SaveManager) SaveGame(gameState *GameState) error {
 		for _, chest := range chests {
 			slots := make([]InventorySlotData, len(chest.Slots))
 			for i, slot := range chest.Slots {
-				slots[i] = InventorySlotData{
-					Type:       slot.Type,
-					Quantity:   slot.Quantity,
-					Durability: slot.Durability,
-				}
+				slots[i] = encodeSlot(saveData.ItemPalette, slot)
 			}
 			saveData.Chests = append(saveData.Chests, ChestData{
 				X:     chest.X,
@@ -324,8 +328,7 @@ func (sm *SaveManager) SaveGame(gameState *GameState) error {
 	}
 
 	// Save world metadata
-	chunkCount := len(gameState.World.Chunks)
-	if err := worldStorage.SaveWorldMetadata(chunkCount); err != nil {
+	if err := worldStorage.SaveWorldMetadata(gameState.World); err != nil {
 		return fmt.Errorf("failed to save world metadata: %w", err)
 	}
 
@@ -385,10 +388,28 @@ func (sm *SaveManager) saveGameAtomic(saveData *SaveData) error {
 func (sm *SaveManager) LoadGame() (*SaveData, error) {
 	saveFilename := filepath.Join(sm.SaveDir, fmt.Sprintf("player_%s.json", sm.PlayerName))
 
-	// First, validate the save file
 	backupManager := NewBackupManager(sm.WorldName, sm.PlayerName, sm.SaveDir)
 	validator := NewSaveValidator(backupManager)
-	result := validator.ValidateSaveFile(saveFilename)
+
+	// Saves written by older versions are upgraded in a copy, and the save is
+	// only replaced once that copy validates. A save that cannot be migrated
+	// is validated as it is, which sends it to backup recovery.
+	migrator := NewMigrator(false)
+	var migration *MigrationResult
+	validateFilename := saveFilename
+	if _, err := os.Stat(saveFilename); err == nil {
+		result, copyPath, err := migrator.MigrateCopy(KindPlayerSave, saveFilename)
+		if err != nil {
+			fmt.Printf("Failed to migrate save file: %v\n", err)
+		} else if copyPath != "" {
+			migration = result
+			validateFilename = copyPath
+			defer os.Remove(copyPath) // Left behind only if the copy is rejected
+		}
+	}
+
+	// First, validate the save file
+	result := validator.ValidateSaveFile(validateFilename)
 
 	if !result.Valid {
 		// Save is corrupted - attempt recovery
@@ -404,6 +425,13 @@ func (sm *SaveManager) LoadGame() (*SaveData, error) {
 		return nil, fmt.Errorf("save file corrupted and no backup available: %v", result.Errors)
 	}
 
+	if migration != nil {
+		if err := migrator.ReplaceWithCopy(migration, validateFilename); err != nil {
+			return nil, fmt.Errorf("failed to migrate save file: %w", err)
+		}
+		fmt.Printf("Migrated save from version %s to %s (backup: %s)\n", migration.FromVersion, migration.ToVersion, migration.BackupPath)
+	}
+
 	// Validate passed, load the data
 	data, err := os.ReadFile(saveFilename)
 	if err != nil {
@@ -434,6 +462,7 @@ func (sm *SaveManager) ApplySaveData(saveData *SaveData, gameState *GameState) e
 	gameState.Player.Y = saveData.PlayerY
 	gameState.Player.VX = saveData.PlayerVX
 	gameState.Player.VY = saveData.PlayerVY
+	gameState.Player.XP = saveData.PlayerXP
 	gameState.Player.SelectedSlot = saveData.SelectedSlot
 
 	// Apply enhanced player state
@@ -444,6 +473,11 @@ func (sm *SaveManager) ApplySaveData(saveData *SaveData, gameState *GameState) e
 	gameState.CameraX = saveData.CameraX
 	gameState.CameraY = saveData.CameraY
 
+	// Restore the world seed so unsaved chunks regenerate with their original terrain
+	if saveData.Seed != 0 && gameState.World.Seed != saveData.Seed {
+		gameState.World.SetSeed(saveData.Seed)
+	}
+
 	// Apply world state
 	gameState.WorldTime = saveData.WorldTime
 	gameState.Weather = saveData.Weather
@@ -478,6 +512,12 @@ func (sm *SaveManager) ApplySaveData(saveData *SaveData, gameState *GameState) e
 	gameState.ItemsCrafted = saveData.ItemsCrafted
 	gameState.PlayTime = saveData.PlayTime
 
+	// Saves without a palette stored raw item types
+	itemPalette := saveData.ItemPalette
+	if itemPalette == nil {
+		itemPalette = items.NewItemPalette()
+	}
+
 	// Apply inventory state
 	if gameState.Inventory != nil && len(saveData.InventorySlots) > 0 {
 		// Ensure inventory has enough slots
@@ -494,11 +534,7 @@ func (sm *SaveManager) ApplySaveData(saveData *SaveData, gameState *GameState) e
 		// Restore inventory slots
 		for i, slotData := range saveData.InventorySlots {
 			if i < len(gameState.Inventory.Slots) {
-				gameState.Inventory.Slots[i] = items.Item{
-					Type:       slotData.Type,
-					Quantity:   slotData.Quantity,
-					Durability: slotData.Durability,
-				}
+				gameState.Inventory.Slots[i] = decodeSlot(itemPalette, slotData)
 			}
 		}
 
@@ -506,11 +542,7 @@ func (sm *SaveManager) ApplySaveData(saveData *SaveData, gameState *GameState) e
 		if len(saveData.HotbarSlots) > 0 {
 			for i, slotData := range saveData.HotbarSlots {
 				if i < len(gameState.Inventory.Slots) {
-					gameState.Inventory.Slots[i] = items.Item{
-						Type:       slotData.Type,
-						Quantity:   slotData.Quantity,
-						Durability: slotData.Durability,
-					}
+					gameState.Inventory.Slots[i] = decodeSlot(itemPalette, slotData)
 				}
 			}
 		}
@@ -524,6 +556,7 @@ func (sm *SaveManager) ApplySaveData(saveData *SaveData, gameState *GameState) e
 		gameState.SurvivalManager.MaxThirst = saveData.SurvivalStats.MaxThirst
 		gameState.SurvivalManager.Stamina = saveData.SurvivalStats.Stamina
 		gameState.SurvivalManager.MaxStamina = saveData.SurvivalStats.MaxStamina
+		gameState.SurvivalManager.Saturation = saveData.SurvivalStats.Saturation
 		gameState.SurvivalManager.LastDamageTime = saveData.SurvivalStats.LastDamageTime
 		gameState.SurvivalManager.IsStarving = saveData.SurvivalStats.IsStarving
 		gameState.SurvivalManager.IsDehydrated = saveData.SurvivalStats.IsDehydrated
@@ -542,6 +575,7 @@ func (sm *SaveManager) ApplySaveData(saveData *SaveData, gameState *GameState) e
 				Durability:    eqData.Durability,
 				MaxDurability: eqData.MaxDurability,
 				GrantsFlight:  eqData.GrantsFlight,
+				Meta:          eqData.Meta,
 			}
 			gameState.EquipmentSet.EquipItem(item, slot)
 		}
@@ -574,21 +608,14 @@ func (sm *SaveManager) ApplySaveData(saveData *SaveData, gameState *GameState) e
 	// Load zombies
 	if len(saveData.Zombies) > 0 && gameState.ZombieSpawner != nil {
 		// Clear existing zombies and recreate from save
-		gameState.ZombieSpawner.Zombies = make([]*enemies.Zombie, 0, len(saveData.Zombies))
+		gameState.ZombieSpawner.Mobs = make([]*mobs.Mob, 0, len(saveData.Zombies))
 		for _, zombieData := range saveData.Zombies {
-			zombie := enemies.NewZombie(
-				len(gameState.ZombieSpawner.Zombies),
-				enemies.ZombieType(zombieData.Type),
-				zombieData.X,
-				zombieData.Y,
-			)
-			zombie.Health = zombieData.Health
-			zombie.MaxHealth = zombieData.MaxHealth
-			zombie.State = enemies.ZombieState(zombieData.State)
-			gameState.ZombieSpawner.Zombies = append(gameState.ZombieSpawner.Zombies, zombie)
+			if zombie, ok := mobs.FromData(zombieData); ok {
+				gameState.ZombieSpawner.Mobs = append(gameState.ZombieSpawner.Mobs, zombie)
+			}
 		}
 		// Update NextID to avoid ID conflicts
-		gameState.ZombieSpawner.NextID = len(gameState.ZombieSpawner.Zombies) + 1
+		gameState.ZombieSpawner.NextID = len(gameState.ZombieSpawner.Mobs) + 1
 	}
 
 	// Load chests
@@ -596,11 +623,7 @@ func (sm *SaveManager) ApplySaveData(saveData *SaveData, gameState *GameState) e
 		for _, chestData := range saveData.Chests {
 			slots := make([]items.Item, len(chestData.Slots))
 			for i, slot := range chestData.Slots {
-				slots[i] = items.Item{
-					Type:       slot.Type,
-					Quantity:   slot.Quantity,
-					Durability: slot.Durability,
-				}
+				slots[i] = decodeSlot(itemPalette, slot)
 			}
 			gameState.ChestManager.SetChestContents(chestData.X, chestData.Y, slots)
 		}
@@ -733,7 +756,7 @@ type GameState struct {
 	HealthSystem    *health.LocationalHealthSystem
 
 	// Enemy systems
-	ZombieSpawner *enemies.ZombieSpawner
+	ZombieSpawner *mobs.Spawner
 
 	// Storage systems
 	ChestManager *chest.ChestManager
@@ -0,0 +1,155 @@
+package script
+
+// Expr is an expression node
+type Expr interface{ exprNode() }
+
+// Stmt is a statement node
+type Stmt interface{ stmtNode() }
+
+// Block is a sequence of statements with its own local scope
+type Block struct {
+	Stmts []Stmt
+}
+
+// Expressions
+
+type ConstExpr struct{ Value Value }
+
+type NameExpr struct {
+	Name string
+	Line int
+}
+
+type IndexExpr struct {
+	Object Expr
+	Key    Expr
+	Line   int
+}
+
+type CallExpr struct {
+	Fn   Expr
+	Args []Expr
+	Line int
+}
+
+type MethodCallExpr struct {
+	Object Expr
+	Method string
+	Args   []Expr
+	Line   int
+}
+
+type FunctionExpr struct {
+	Name   string
+	Params []string
+	Body   *Block
+	Line   int
+}
+
+type BinaryExpr struct {
+	Op    string
+	Left  Expr
+	Right Expr
+	Line  int
+}
+
+type UnaryExpr struct {
+	Op   string
+	X    Expr
+	Line int
+}
+
+// ParenExpr truncates a multi-value expression to its first value
+type ParenExpr struct{ X Expr }
+
+type TableField struct {
+	Key   Expr // nil for positional fields
+	Value Expr
+}
+
+type TableExpr struct {
+	Fields []TableField
+	Line   int
+}
+
+func (*ConstExpr) exprNode()      {}
+func (*NameExpr) exprNode()       {}
+func (*IndexExpr) exprNode()      {}
+func (*CallExpr) exprNode()       {}
+func (*MethodCallExpr) exprNode() {}
+func (*FunctionExpr) exprNode()   {}
+func (*BinaryExpr) exprNode()     {}
+func (*UnaryExpr) exprNode()      {}
+func (*ParenExpr) exprNode()      {}
+func (*TableExpr) exprNode()      {}
+
+// Statements
+
+type LocalStmt struct {
+	Names []string
+	Exprs []Expr
+}
+
+type AssignStmt struct {
+	Targets []Expr
+	Exprs   []Expr
+	Line    int
+}
+
+type CallStmt struct{ Call Expr }
+
+type DoStmt struct{ Body *Block }
+
+type WhileStmt struct {
+	Cond Expr
+	Body *Block
+}
+
+type RepeatStmt struct {
+	Body *Block
+	Cond Expr
+}
+
+type IfStmt struct {
+	Conds  []Expr
+	Blocks []*Block
+	Else   *Block
+}
+
+type NumericForStmt struct {
+	Var   string
+	Start Expr
+	Limit Expr
+	Step  Expr // nil means 1
+	Body  *Block
+	Line  int
+}
+
+type GenericForStmt struct {
+	Names []string
+	Exprs []Expr
+	Body  *Block
+	Line  int
+}
+
+type LocalFunctionStmt struct {
+	Name string
+	Func *FunctionExpr
+}
+
+type ReturnStmt struct{ Exprs []Expr }
+
+type BreakStmt struct{}
+
+func (*LocalStmt) stmtNode()         {}
+func (*AssignStmt) stmtNode()        {}
+func (*CallStmt) stmtNode()          {}
+func (*DoStmt) stmtNode()            {}
+func (*WhileStmt) stmtNode()         {}
+func (*RepeatStmt) stmtNode()        {}
+func (*IfStmt) stmtNode()            {}
+func (*NumericForStmt) stmtNode()    {}
+func (*GenericForStmt) stmtNode()    {}
+func (*LocalFunctionStmt) stmtNode() {}
+func (*ReturnStmt) stmtNode()        {}
+func (*BreakStmt) stmtNode()         {}
@@ -0,0 +1,127 @@
+package script
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+)
+
+// ToValue converts a Go value to a script value. Maps and slices become
+// tables; structs are converted through their JSON representation.
+func ToValue(v interface{}) Value {
+	switch x := v.(type) {
+	case nil:
+		return nil
+	case bool, float64, string, *Table, *Function, *Builtin:
+		return x
+	case float32:
+		return float64(x)
+	case int:
+		return float64(x)
+	case int8:
+		return float64(x)
+	case int16:
+		return float64(x)
+	case int32:
+		return float64(x)
+	case int64:
+		return float64(x)
+	case uint:
+		return float64(x)
+	case uint8:
+		return float64(x)
+	case uint16:
+		return float64(x)
+	case uint32:
+		return float64(x)
+	case uint64:
+		return float64(x)
+	case []interface{}:
+		table := NewTable()
+		for _, item := range x {
+			table.Append(ToValue(item))
+		}
+		return table
+	case []string:
+		table := NewTable()
+		for _, item := range x {
+			table.Append(item)
+		}
+		return table
+	case map[string]interface{}:
+		table := NewTable()
+		for _, key := range sortedKeys(x) {
+			table.Set(key, ToValue(x[key]))
+		}
+		return table
+	case map[interface{}]interface{}:
+		table := NewTable()
+		for key, item := range x {
+			if k := ToValue(key); k != nil {
+				table.Set(k, ToValue(item))
+			}
+		}
+		return table
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if rv.IsNil() {
+			return nil
+		}
+	case reflect.Func, reflect.Chan:
+		return nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	var generic interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return fmt.Sprint(v)
+	}
+	return ToValue(generic)
+}
+
+// ToGo converts a script value to plain Go data: tables holding a sequence
+// become []interface{}, other tables map[string]interface{}, and integral
+// numbers int. Functions convert to nil.
+func ToGo(v Value) interface{} {
+	switch x := v.(type) {
+	case float64:
+		if x == math.Trunc(x) && math.Abs(x) < 1<<53 {
+			return int(x)
+		}
+		return x
+	case *Table:
+		if len(x.order) == 0 && x.Len() > 0 {
+			list := make([]interface{}, x.Len())
+			for i := range list {
+				list[i] = ToGo(x.Get(float64(i + 1)))
+			}
+			return list
+		}
+		m := make(map[string]interface{}, x.Len()+len(x.order))
+		for _, key := range x.Keys() {
+			m[ToString(key)] = ToGo(x.Get(key))
+		}
+		return m
+	case *Function, *Builtin:
+		return nil
+	}
+	return v
+}
+
+// sortedKeys returns map keys in a stable order
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
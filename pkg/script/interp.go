@@ -0,0 +1,650 @@
+package script
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// MaxCallDepth bounds script recursion so a runaway script fails with an
+// error instead of exhausting the Go stack
+const MaxCallDepth = 200
+
+// Interpreter runs compiled chunks. It is not safe for concurrent use; callers
+// that share one between goroutines must serialize access.
+type Interpreter struct {
+	globals *Table
+	depth   int
+
+	// Budget of the outermost Call in progress, see Limits
+	limits    Limits
+	calls     int
+	steps     int64
+	allocated int64
+	deadline  time.Time
+}
+
+// New creates an interpreter with the standard library loaded
+func New() *Interpreter {
+	in := &Interpreter{globals: NewTable()}
+	openStdlib(in)
+	return in
+}
+
+// Globals returns the global table
+func (in *Interpreter) Globals() *Table {
+	return in.globals
+}
+
+// Global returns a global variable
+func (in *Interpreter) Global(name string) Value {
+	return in.globals.Get(name)
+}
+
+// SetGlobal sets a global variable
+func (in *Interpreter) SetGlobal(name string, value Value) {
+	in.globals.Set(name, value)
+}
+
+// Register exposes a Go function as a global
+func (in *Interpreter) Register(name string, fn GoFunction) {
+	in.SetGlobal(name, NewBuiltin(name, fn))
+}
+
+// Run executes a chunk's top-level code and returns what it returns
+func (in *Interpreter) Run(chunk *Chunk) ([]Value, error) {
+	main := &Function{
+		proto:  &FunctionExpr{Name: "main chunk", Body: chunk.Body},
+		source: chunk.Name,
+	}
+	return in.Call(main)
+}
+
+// Call calls a script or Go function value. Calls made from Go start a new
+// budget; calls made by scripts (through pcall) share their caller's.
+func (in *Interpreter) Call(fn Value, args ...Value) (results []Value, err error) {
+	if in.calls == 0 {
+		in.startBudget()
+	}
+	in.calls++
+	depth := in.depth
+	defer func() {
+		in.calls--
+		if r := recover(); r != nil {
+			in.depth = depth
+			switch e := r.(type) {
+			case *Error:
+				results, err = nil, e
+			case *LimitError:
+				// Only the outermost call stops a script that ran out of budget
+				if in.calls > 0 {
+					panic(e)
+				}
+				results, err = nil, e
+			default:
+				panic(r)
+			}
+		}
+	}()
+	return in.call(fn, args, &frame{}, 0), nil
+}
+
+// scope is one level of local variables
+type scope struct {
+	vars   map[string]*Value
+	parent *scope
+}
+
+func newScope(parent *scope) *scope {
+	return &scope{parent: parent}
+}
+
+// lookup finds a local variable's storage, or nil for a global
+func (s *scope) lookup(name string) *Value {
+	for sc := s; sc != nil; sc = sc.parent {
+		if slot, ok := sc.vars[name]; ok {
+			return slot
+		}
+	}
+	return nil
+}
+
+// define declares a new local in this scope
+func (s *scope) define(name string, value Value) {
+	if s.vars == nil {
+		s.vars = make(map[string]*Value, 4)
+	}
+	v := value
+	s.vars[name] = &v
+}
+
+// frame is the state of one function activation
+type frame struct {
+	source string
+	line   int // last line reached, for limit errors
+	ret    []Value
+}
+
+// flow is how a statement finished
+type flow int
+
+const (
+	flowNormal flow = iota
+	flowBreak
+	flowReturn
+)
+
+// throw aborts execution with a runtime error; Call recovers it
+func throw(fr *frame, line int, format string, args ...interface{}) {
+	panic(&Error{Source: fr.source, Line: line, Message: fmt.Sprintf(format, args...)})
+}
+
+// call invokes a function value with arguments
+func (in *Interpreter) call(fn Value, args []Value, caller *frame, line int) []Value {
+	switch f := fn.(type) {
+	case *Builtin:
+		results, err := f.Fn(args)
+		if err == nil {
+			in.allocStrings(f, results, caller)
+		}
+		if err != nil {
+			if scriptErr, ok := err.(*Error); ok {
+				if scriptErr.Source == "" && scriptErr.Line == 0 {
+					// Raised by error() or assert(): report where it was called
+					located := *scriptErr
+					located.Source, located.Line = caller.source, line
+					panic(&located)
+				}
+				panic(scriptErr)
+			}
+			throw(caller, line, "%s: %v", f.Name, err)
+		}
+		return results
+
+	case *Function:
+		if in.depth >= MaxCallDepth {
+			throw(caller, line, "stack overflow")
+		}
+		in.depth++
+		defer func() { in.depth-- }()
+
+		env := newScope(f.env)
+		for i, param := range f.proto.Params {
+			var arg Value
+			if i < len(args) {
+				arg = args[i]
+			}
+			env.define(param, arg)
+		}
+		fr := &frame{source: f.source}
+		if in.execBlock(f.proto.Body, env, fr) == flowReturn {
+			return fr.ret
+		}
+		return nil
+	}
+	throw(caller, line, "attempt to call a %s value", typeName(fn))
+	return nil
+}
+
+// execBlock runs a block in a new scope
+func (in *Interpreter) execBlock(block *Block, parent *scope, fr *frame) flow {
+	in.step(fr)
+	env := newScope(parent)
+	for _, stmt := range block.Stmts {
+		if fl := in.exec(stmt, env, fr); fl != flowNormal {
+			return fl
+		}
+	}
+	return flowNormal
+}
+
+// exec runs one statement
+func (in *Interpreter) exec(stmt Stmt, env *scope, fr *frame) flow {
+	in.step(fr)
+	switch s := stmt.(type) {
+	case *LocalStmt:
+		values := in.evalList(s.Exprs, env, fr)
+		for i, name := range s.Names {
+			var v Value
+			if i < len(values) {
+				v = values[i]
+			}
+			env.define(name, v)
+		}
+
+	case *AssignStmt:
+		fr.line = s.Line
+		values := in.evalList(s.Exprs, env, fr)
+		for i, target := range s.Targets {
+			var v Value
+			if i < len(values) {
+				v = values[i]
+			}
+			in.assign(target, v, env, fr)
+		}
+
+	case *CallStmt:
+		in.evalMulti(s.Call, env, fr)
+
+	case *DoStmt:
+		return in.execBlock(s.Body, env, fr)
+
+	case *WhileStmt:
+		for truthy(in.eval(s.Cond, env, fr)) {
+			fl := in.execBlock(s.Body, env, fr)
+			if fl == flowBreak {
+				break
+			}
+			if fl == flowReturn {
+				return fl
+			}
+		}
+
+	case *RepeatStmt:
+		for {
+			// The condition can see the body's locals
+			in.step(fr)
+			body := newScope(env)
+			fl := flowNormal
+			for _, inner := range s.Body.Stmts {
+				if fl = in.exec(inner, body, fr); fl != flowNormal {
+					break
+				}
+			}
+			if fl == flowBreak {
+				break
+			}
+			if fl == flowReturn {
+				return fl
+			}
+			if truthy(in.eval(s.Cond, body, fr)) {
+				break
+			}
+		}
+
+	case *IfStmt:
+		for i, cond := range s.Conds {
+			if truthy(in.eval(cond, env, fr)) {
+				return in.execBlock(s.Blocks[i], env, fr)
+			}
+		}
+		if s.Else != nil {
+			return in.execBlock(s.Else, env, fr)
+		}
+
+	case *NumericForStmt:
+		return in.execNumericFor(s, env, fr)
+
+	case *GenericForStmt:
+		return in.execGenericFor(s, env, fr)
+
+	case *LocalFunctionStmt:
+		// Declared before the closure is created so it can call itself
+		env.define(s.Name, nil)
+		*env.lookup(s.Name) = &Function{proto: s.Func, source: fr.source, env: env}
+
+	case *ReturnStmt:
+		fr.ret = in.evalList(s.Exprs, env, fr)
+		return flowReturn
+
+	case *BreakStmt:
+		return flowBreak
+	}
+	return flowNormal
+}
+
+func (in *Interpreter) execNumericFor(s *NumericForStmt, env *scope, fr *frame) flow {
+	start, ok1 := toNumber(in.eval(s.Start, env, fr))
+	limit, ok2 := toNumber(in.eval(s.Limit, env, fr))
+	step := 1.0
+	ok3 := true
+	if s.Step != nil {
+		step, ok3 = toNumber(in.eval(s.Step, env, fr))
+	}
+	if !ok1 || !ok2 || !ok3 {
+		throw(fr, s.Line, "'for' initial value, limit and step must be numbers")
+	}
+	if step == 0 {
+		throw(fr, s.Line, "'for' step is zero")
+	}
+
+	for i := start; (step > 0 && i <= limit) || (step < 0 && i >= limit); i += step {
+		loop := newScope(env)
+		loop.define(s.Var, i)
+		fl := in.execBlock(s.Body, loop, fr)
+		if fl == flowBreak {
+			break
+		}
+		if fl == flowReturn {
+			return fl
+		}
+	}
+	return flowNormal
+}
+
+func (in *Interpreter) execGenericFor(s *GenericForStmt, env *scope, fr *frame) flow {
+	values := in.evalList(s.Exprs, env, fr)
+	var iter, state, control Value
+	if len(values) > 0 {
+		iter = values[0]
+	}
+	if len(values) > 1 {
+		state = values[1]
+	}
+	if len(values) > 2 {
+		control = values[2]
+	}
+
+	for {
+		results := in.call(iter, []Value{state, control}, fr, s.Line)
+		if len(results) == 0 || results[0] == nil {
+			return flowNormal
+		}
+		control = results[0]
+
+		loop := newScope(env)
+		for i, name := range s.Names {
+			var v Value
+			if i < len(results) {
+				v = results[i]
+			}
+			loop.define(name, v)
+		}
+		fl := in.execBlock(s.Body, loop, fr)
+		if fl == flowBreak {
+			return flowNormal
+		}
+		if fl == flowReturn {
+			return fl
+		}
+	}
+}
+
+// assign stores a value into a variable or table field
+func (in *Interpreter) assign(target Expr, value Value, env *scope, fr *frame) {
+	switch t := target.(type) {
+	case *NameExpr:
+		if slot := env.lookup(t.Name); slot != nil {
+			*slot = value
+			return
+		}
+		in.globals.Set(t.Name, value)
+
+	case *IndexExpr:
+		obj := in.eval(t.Object, env, fr)
+		table, ok := obj.(*Table)
+		if !ok {
+			throw(fr, t.Line, "attempt to index a %s value%s", typeName(obj), describeExpr(t.Object, env))
+		}
+		key := in.eval(t.Key, env, fr)
+		if value != nil && table.Get(key) == nil {
+			in.alloc(tableEntrySize, fr)
+		}
+		if err := table.Set(key, value); err != nil {
+			throw(fr, t.Line, "%v", err)
+		}
+	}
+}
+
+// evalList evaluates an expression list, expanding the last expression's
+// results when it is a call
+func (in *Interpreter) evalList(exprs []Expr, env *scope, fr *frame) []Value {
+	if len(exprs) == 0 {
+		return nil
+	}
+	values := make([]Value, 0, len(exprs))
+	for _, expr := range exprs[:len(exprs)-1] {
+		values = append(values, in.eval(expr, env, fr))
+	}
+	return append(values, in.evalMulti(exprs[len(exprs)-1], env, fr)...)
+}
+
+// evalMulti evaluates an expression keeping every result of a call
+func (in *Interpreter) evalMulti(expr Expr, env *scope, fr *frame) []Value {
+	switch e := expr.(type) {
+	case *CallExpr:
+		fr.line = e.Line
+		fn := in.eval(e.Fn, env, fr)
+		args := in.evalList(e.Args, env, fr)
+		if fn == nil {
+			throw(fr, e.Line, "attempt to call a nil value%s", describeExpr(e.Fn, env))
+		}
+		return in.call(fn, args, fr, e.Line)
+
+	case *MethodCallExpr:
+		fr.line = e.Line
+		obj := in.eval(e.Object, env, fr)
+		method := in.index(obj, e.Method, fr, e.Line, e.Object, env)
+		if method == nil {
+			throw(fr, e.Line, "attempt to call a nil value (method '%s')", e.Method)
+		}
+		args := append([]Value{obj}, in.evalList(e.Args, env, fr)...)
+		return in.call(method, args, fr, e.Line)
+	}
+	return []Value{in.eval(expr, env, fr)}
+}
+
+// eval evaluates an expression to a single value
+func (in *Interpreter) eval(expr Expr, env *scope, fr *frame) Value {
+	switch e := expr.(type) {
+	case *ConstExpr:
+		return e.Value
+
+	case *NameExpr:
+		if slot := env.lookup(e.Name); slot != nil {
+			return *slot
+		}
+		return in.globals.Get(e.Name)
+
+	case *IndexExpr:
+		obj := in.eval(e.Object, env, fr)
+		key := in.eval(e.Key, env, fr)
+		return in.index(obj, key, fr, e.Line, e.Object, env)
+
+	case *CallExpr, *MethodCallExpr:
+		results := in.evalMulti(expr, env, fr)
+		if len(results) == 0 {
+			return nil
+		}
+		return results[0]
+
+	case *ParenExpr:
+		return in.eval(e.X, env, fr)
+
+	case *FunctionExpr:
+		return &Function{proto: e, source: fr.source, env: env}
+
+	case *TableExpr:
+		fr.line = e.Line
+		in.alloc(int64(len(e.Fields)+1)*tableEntrySize, fr)
+		table := NewTable()
+		for i, field := range e.Fields {
+			if field.Key != nil {
+				key := in.eval(field.Key, env, fr)
+				if err := table.Set(key, in.eval(field.Value, env, fr)); err != nil {
+					throw(fr, e.Line, "%v", err)
+				}
+				continue
+			}
+			// A trailing call expands to all of its results
+			if i == len(e.Fields)-1 {
+				for _, v := range in.evalMulti(field.Value, env, fr) {
+					table.Append(v)
+				}
+				continue
+			}
+			table.Set(float64(table.Len()+1), in.eval(field.Value, env, fr))
+		}
+		return table
+
+	case *UnaryExpr:
+		x := in.eval(e.X, env, fr)
+		switch e.Op {
+		case "not":
+			return !truthy(x)
+		case "-":
+			n, ok := toNumber(x)
+			if !ok {
+				throw(fr, e.Line, "attempt to perform arithmetic on a %s value%s", typeName(x), describeExpr(e.X, env))
+			}
+			return -n
+		case "#":
+			switch v := x.(type) {
+			case string:
+				return float64(len(v))
+			case *Table:
+				return float64(v.Len())
+			}
+			throw(fr, e.Line, "attempt to get length of a %s value%s", typeName(x), describeExpr(e.X, env))
+		}
+
+	case *BinaryExpr:
+		return in.evalBinary(e, env, fr)
+	}
+	return nil
+}
+
+// index reads obj[key]; strings index the string library so s:upper() works
+func (in *Interpreter) index(obj, key Value, fr *frame, line int, objExpr Expr, env *scope) Value {
+	switch o := obj.(type) {
+	case *Table:
+		return o.Get(key)
+	case string:
+		if lib, ok := in.globals.Get("string").(*Table); ok {
+			return lib.Get(key)
+		}
+		return nil
+	}
+	throw(fr, line, "attempt to index a %s value%s", typeName(obj), describeExpr(objExpr, env))
+	return nil
+}
+
+func (in *Interpreter) evalBinary(e *BinaryExpr, env *scope, fr *frame) Value {
+	// Logical operators short-circuit and return an operand
+	switch e.Op {
+	case "and":
+		left := in.eval(e.Left, env, fr)
+		if !truthy(left) {
+			return left
+		}
+		return in.eval(e.Right, env, fr)
+	case "or":
+		left := in.eval(e.Left, env, fr)
+		if truthy(left) {
+			return left
+		}
+		return in.eval(e.Right, env, fr)
+	}
+
+	left := in.eval(e.Left, env, fr)
+	right := in.eval(e.Right, env, fr)
+
+	switch e.Op {
+	case "==":
+		return valuesEqual(left, right)
+	case "~=":
+		return !valuesEqual(left, right)
+	case "<", ">", "<=", ">=":
+		return compare(e.Op, left, right, fr, e.Line)
+	case "..":
+		result := concatOperand(left, fr, e.Line) + concatOperand(right, fr, e.Line)
+		fr.line = e.Line
+		in.alloc(int64(len(result)), fr)
+		return result
+	}
+
+	a, ok := toNumber(left)
+	if !ok {
+		throw(fr, e.Line, "attempt to perform arithmetic on a %s value%s", typeName(left), describeExpr(e.Left, env))
+	}
+	b, ok := toNumber(right)
+	if !ok {
+		throw(fr, e.Line, "attempt to perform arithmetic on a %s value%s", typeName(right), describeExpr(e.Right, env))
+	}
+	switch e.Op {
+	case "+":
+		return a + b
+	case "-":
+		return a - b
+	case "*":
+		return a * b
+	case "/":
+		return a / b
+	case "%":
+		if b == 0 {
+			return math.NaN()
+		}
+		return a - math.Floor(a/b)*b
+	case "^":
+		return math.Pow(a, b)
+	}
+	throw(fr, e.Line, "unknown operator %s", e.Op)
+	return nil
+}
+
+// valuesEqual compares by value for primitives and by identity for tables and functions
+func valuesEqual(a, b Value) bool {
+	return a == b
+}
+
+func compare(op string, left, right Value, fr *frame, line int) bool {
+	switch l := left.(type) {
+	case float64:
+		if r, ok := right.(float64); ok {
+			switch op {
+			case "<":
+				return l < r
+			case ">":
+				return l > r
+			case "<=":
+				return l <= r
+			default:
+				return l >= r
+			}
+		}
+	case string:
+		if r, ok := right.(string); ok {
+			switch op {
+			case "<":
+				return l < r
+			case ">":
+				return l > r
+			case "<=":
+				return l <= r
+			default:
+				return l >= r
+			}
+		}
+	}
+	throw(fr, line, "attempt to compare %s with %s", typeName(left), typeName(right))
+	return false
+}
+
+func concatOperand(v Value, fr *frame, line int) string {
+	switch x := v.(type) {
+	case string:
+		return x
+	case float64:
+		return formatNumber(x)
+	}
+	throw(fr, line, "attempt to concatenate a %s value", typeName(v))
+	return ""
+}
+
+// describeExpr names a variable or field for error messages
+func describeExpr(expr Expr, env *scope) string {
+	switch e := expr.(type) {
+	case *NameExpr:
+		if env.lookup(e.Name) != nil {
+			return fmt.Sprintf(" (local '%s')", e.Name)
+		}
+		return fmt.Sprintf(" (global '%s')", e.Name)
+	case *IndexExpr:
+		if c, ok := e.Key.(*ConstExpr); ok {
+			if s, ok := c.Value.(string); ok {
+				return fmt.Sprintf(" (field '%s')", s)
+			}
+		}
+	}
+	return ""
+}
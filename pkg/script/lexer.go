@@ -0,0 +1,286 @@
+// Package script implements the embedded scripting language used by plugins:
+// a small, pure-Go interpreter for a Lua-like language. Scripts run inside
+// the game process without cgo or Go's plugin package, so they work on every
+// platform and can be unloaded and reloaded at runtime.
+package script
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// tokenKind classifies a lexical token
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokName
+	tokNumber
+	tokString
+	tokKeyword
+	tokSymbol
+)
+
+// token is one lexical token
+type token struct {
+	kind tokenKind
+	text string
+	num  float64
+	line int
+}
+
+// keywords are the reserved words of the language
+var keywords = map[string]bool{
+	"and": true, "break": true, "do": true, "else": true, "elseif": true,
+	"end": true, "false": true, "for": true, "function": true, "if": true,
+	"in": true, "local": true, "nil": true, "not": true, "or": true,
+	"repeat": true, "return": true, "then": true, "true": true, "until": true,
+	"while": true,
+}
+
+// symbols lists operators and punctuation, longest first
+var symbols = []string{
+	"...", "..", "==", "~=", "<=", ">=",
+	"+", "-", "*", "/", "%", "^", "#", "<", ">", "=",
+	"(", ")", "{", "}", "[", "]", ";", ":", ",", ".",
+}
+
+// lexer turns source text into tokens
+type lexer struct {
+	name string
+	src  string
+	pos  int
+	line int
+}
+
+// tokenize splits a whole script into tokens
+func tokenize(name, src string) ([]token, error) {
+	lx := &lexer{name: name, src: src, line: 1}
+	var tokens []token
+	for {
+		tok, err := lx.next()
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, tok)
+		if tok.kind == tokEOF {
+			return tokens, nil
+		}
+	}
+}
+
+// errorf builds a syntax error at the current line
+func (lx *lexer) errorf(format string, args ...interface{}) error {
+	return &Error{Source: lx.name, Line: lx.line, Message: fmt.Sprintf(format, args...)}
+}
+
+// next scans the next token
+func (lx *lexer) next() (token, error) {
+	if err := lx.skipSpace(); err != nil {
+		return token{}, err
+	}
+	if lx.pos >= len(lx.src) {
+		return token{kind: tokEOF, line: lx.line}, nil
+	}
+
+	c := lx.src[lx.pos]
+	switch {
+	case isLetter(c):
+		start := lx.pos
+		for lx.pos < len(lx.src) && (isLetter(lx.src[lx.pos]) || isDigit(lx.src[lx.pos])) {
+			lx.pos++
+		}
+		word := lx.src[start:lx.pos]
+		if keywords[word] {
+			return token{kind: tokKeyword, text: word, line: lx.line}, nil
+		}
+		return token{kind: tokName, text: word, line: lx.line}, nil
+
+	case isDigit(c) || (c == '.' && lx.pos+1 < len(lx.src) && isDigit(lx.src[lx.pos+1])):
+		return lx.number()
+
+	case c == '"' || c == '\'':
+		return lx.quotedString(c)
+
+	case c == '[' && (lx.peekLongBracket() >= 0):
+		line := lx.line
+		text, err := lx.longBracket()
+		if err != nil {
+			return token{}, err
+		}
+		return token{kind: tokString, text: text, line: line}, nil
+	}
+
+	for _, sym := range symbols {
+		if strings.HasPrefix(lx.src[lx.pos:], sym) {
+			lx.pos += len(sym)
+			return token{kind: tokSymbol, text: sym, line: lx.line}, nil
+		}
+	}
+	return token{}, lx.errorf("unexpected character %q", c)
+}
+
+// skipSpace skips whitespace and comments
+func (lx *lexer) skipSpace() error {
+	for lx.pos < len(lx.src) {
+		c := lx.src[lx.pos]
+		switch {
+		case c == '\n':
+			lx.line++
+			lx.pos++
+		case c == ' ' || c == '\t' || c == '\r':
+			lx.pos++
+		case strings.HasPrefix(lx.src[lx.pos:], "--"):
+			lx.pos += 2
+			if lx.pos < len(lx.src) && lx.src[lx.pos] == '[' && lx.peekLongBracket() >= 0 {
+				if _, err := lx.longBracket(); err != nil {
+					return err
+				}
+				continue
+			}
+			for lx.pos < len(lx.src) && lx.src[lx.pos] != '\n' {
+				lx.pos++
+			}
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+// number scans a decimal or hexadecimal number
+func (lx *lexer) number() (token, error) {
+	start := lx.pos
+	if strings.HasPrefix(lx.src[lx.pos:], "0x") || strings.HasPrefix(lx.src[lx.pos:], "0X") {
+		lx.pos += 2
+		for lx.pos < len(lx.src) && isHexDigit(lx.src[lx.pos]) {
+			lx.pos++
+		}
+		value, err := strconv.ParseUint(lx.src[start+2:lx.pos], 16, 64)
+		if err != nil {
+			return token{}, lx.errorf("malformed number %s", lx.src[start:lx.pos])
+		}
+		return token{kind: tokNumber, num: float64(value), text: lx.src[start:lx.pos], line: lx.line}, nil
+	}
+
+	for lx.pos < len(lx.src) && (isDigit(lx.src[lx.pos]) || lx.src[lx.pos] == '.') {
+		lx.pos++
+	}
+	if lx.pos < len(lx.src) && (lx.src[lx.pos] == 'e' || lx.src[lx.pos] == 'E') {
+		lx.pos++
+		if lx.pos < len(lx.src) && (lx.src[lx.pos] == '+' || lx.src[lx.pos] == '-') {
+			lx.pos++
+		}
+		for lx.pos < len(lx.src) && isDigit(lx.src[lx.pos]) {
+			lx.pos++
+		}
+	}
+	text := lx.src[start:lx.pos]
+	value, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return token{}, lx.errorf("malformed number %s", text)
+	}
+	return token{kind: tokNumber, num: value, text: text, line: lx.line}, nil
+}
+
+// quotedString scans a string delimited by ' or "
+func (lx *lexer) quotedString(quote byte) (token, error) {
+	line := lx.line
+	lx.pos++
+	var sb strings.Builder
+	for {
+		if lx.pos >= len(lx.src) || lx.src[lx.pos] == '\n' {
+			return token{}, lx.errorf("unfinished string")
+		}
+		c := lx.src[lx.pos]
+		lx.pos++
+		if c == quote {
+			break
+		}
+		if c != '\\' {
+			sb.WriteByte(c)
+			continue
+		}
+		if lx.pos >= len(lx.src) {
+			return token{}, lx.errorf("unfinished string")
+		}
+		esc := lx.src[lx.pos]
+		lx.pos++
+		switch esc {
+		case 'n':
+			sb.WriteByte('\n')
+		case 't':
+			sb.WriteByte('\t')
+		case 'r':
+			sb.WriteByte('\r')
+		case '\\', '"', '\'':
+			sb.WriteByte(esc)
+		case '\n':
+			sb.WriteByte('\n')
+			lx.line++
+		default:
+			if isDigit(esc) {
+				value := int(esc - '0')
+				for i := 0; i < 2 && lx.pos < len(lx.src) && isDigit(lx.src[lx.pos]); i++ {
+					value = value*10 + int(lx.src[lx.pos]-'0')
+					lx.pos++
+				}
+				if value > 255 {
+					return token{}, lx.errorf("escape sequence too large")
+				}
+				sb.WriteByte(byte(value))
+				continue
+			}
+			return token{}, lx.errorf("invalid escape sequence \\%c", esc)
+		}
+	}
+	return token{kind: tokString, text: sb.String(), line: line}, nil
+}
+
+// peekLongBracket returns the level of a long bracket such as [[ or [==[
+// starting at the current position, or -1 if there is none
+func (lx *lexer) peekLongBracket() int {
+	i := lx.pos + 1
+	level := 0
+	for i < len(lx.src) && lx.src[i] == '=' {
+		level++
+		i++
+	}
+	if i < len(lx.src) && lx.src[i] == '[' {
+		return level
+	}
+	return -1
+}
+
+// longBracket scans a long bracket string or comment body
+func (lx *lexer) longBracket() (string, error) {
+	level := lx.peekLongBracket()
+	lx.pos += level + 2
+	// A newline directly after the opening bracket is skipped
+	if lx.pos < len(lx.src) && lx.src[lx.pos] == '\n' {
+		lx.pos++
+		lx.line++
+	}
+	closing := "]" + strings.Repeat("=", level) + "]"
+	end := strings.Index(lx.src[lx.pos:], closing)
+	if end < 0 {
+		return "", lx.errorf("unfinished long string or comment")
+	}
+	text := lx.src[lx.pos : lx.pos+end]
+	lx.line += strings.Count(text, "\n")
+	lx.pos += end + len(closing)
+	return text, nil
+}
+
+func isLetter(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isHexDigit(c byte) bool {
+	return isDigit(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
@@ -0,0 +1,111 @@
+package script
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Limits bounds the work a single call into the interpreter may do, counting
+// everything it calls in turn. Zero fields are unlimited.
+type Limits struct {
+	Steps   int64         // statements executed and functions called
+	Timeout time.Duration // wall-clock time
+	Memory  int64         // approximate bytes allocated for strings and table entries
+}
+
+// Limit names used in LimitError
+const (
+	LimitSteps   = "steps"
+	LimitTimeout = "timeout"
+	LimitMemory  = "memory"
+)
+
+// deadlineCheckInterval is how many steps run between clock reads
+const deadlineCheckInterval = 256
+
+// tableEntrySize is the approximate cost of one table slot
+const tableEntrySize = 16
+
+// LimitError reports that a call exceeded one of the interpreter's limits.
+// Unlike runtime errors it cannot be caught by pcall.
+type LimitError struct {
+	Limit  string
+	Source string
+	Line   int
+	Used   int64
+	Max    int64
+}
+
+func (e *LimitError) Error() string {
+	where := e.Source
+	if e.Line > 0 {
+		where = fmt.Sprintf("%s:%d", e.Source, e.Line)
+	}
+	if e.Limit == LimitTimeout {
+		return fmt.Sprintf("%s: exceeded the time limit of %v", where, time.Duration(e.Max))
+	}
+	return fmt.Sprintf("%s: exceeded the %s limit (%d of %d)", where, e.Limit, e.Used, e.Max)
+}
+
+// SetLimits sets the limits applied to each Call from Go
+func (in *Interpreter) SetLimits(limits Limits) {
+	in.limits = limits
+}
+
+// Limits returns the configured limits
+func (in *Interpreter) Limits() Limits {
+	return in.limits
+}
+
+// Usage returns the steps and approximate bytes used by the last Call
+func (in *Interpreter) Usage() (steps, memory int64) {
+	return in.steps, in.allocated
+}
+
+// startBudget resets the counters for an outermost call
+func (in *Interpreter) startBudget() {
+	in.steps = 0
+	in.allocated = 0
+	in.deadline = time.Time{}
+	if in.limits.Timeout > 0 {
+		in.deadline = time.Now().Add(in.limits.Timeout)
+	}
+}
+
+// step counts one unit of work and aborts the call once the step or time
+// budget is spent
+func (in *Interpreter) step(fr *frame) {
+	in.steps++
+	if in.limits.Steps > 0 && in.steps > in.limits.Steps {
+		panic(&LimitError{Limit: LimitSteps, Source: fr.source, Line: fr.line, Used: in.steps, Max: in.limits.Steps})
+	}
+	if !in.deadline.IsZero() && in.steps%deadlineCheckInterval == 0 && time.Now().After(in.deadline) {
+		panic(&LimitError{Limit: LimitTimeout, Source: fr.source, Line: fr.line, Max: int64(in.limits.Timeout)})
+	}
+}
+
+// alloc charges bytes against the memory budget
+func (in *Interpreter) alloc(bytes int64, fr *frame) {
+	in.allocated += bytes
+	if in.limits.Memory > 0 && in.allocated > in.limits.Memory {
+		panic(&LimitError{Limit: LimitMemory, Source: fr.source, Line: fr.line, Used: in.allocated, Max: in.limits.Memory})
+	}
+}
+
+// allocStrings charges the strings returned by functions that build new
+// ones: the string library and table.concat
+func (in *Interpreter) allocStrings(fn *Builtin, results []Value, fr *frame) {
+	if !strings.HasPrefix(fn.Name, "string.") && fn.Name != "table.concat" {
+		return
+	}
+	var bytes int64
+	for _, v := range results {
+		if s, ok := v.(string); ok {
+			bytes += int64(len(s))
+		}
+	}
+	if bytes > 0 {
+		in.alloc(bytes, fr)
+	}
+}
@@ -0,0 +1,735 @@
+package script
+
+import (
+	"fmt"
+	"os"
+)
+
+// Chunk is a compiled script, ready to run in an Interpreter
+type Chunk struct {
+	Name string
+	Body *Block
+}
+
+// Compile parses script source. The name is used in error messages.
+func Compile(name, source string) (*Chunk, error) {
+	tokens, err := tokenize(name, source)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{name: name, tokens: tokens}
+	body, err := p.block()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().kind != tokEOF {
+		return nil, p.unexpected()
+	}
+	return &Chunk{Name: name, Body: body}, nil
+}
+
+// CompileFile reads and parses a script file
+func CompileFile(path string) (*Chunk, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read script %s: %w", path, err)
+	}
+	return Compile(path, string(data))
+}
+
+// parser is a recursive descent parser over a token list
+type parser struct {
+	name   string
+	tokens []token
+	pos    int
+}
+
+// Binary operator precedences; right associative operators bind their right
+// operand one level lower
+var binaryPriority = map[string][2]int{
+	"or":  {1, 1},
+	"and": {2, 2},
+	"<":   {3, 3},
+	">":   {3, 3},
+	"<=":  {3, 3},
+	">=":  {3, 3},
+	"~=":  {3, 3},
+	"==":  {3, 3},
+	"..":  {5, 4},
+	"+":   {6, 6},
+	"-":   {6, 6},
+	"*":   {7, 7},
+	"/":   {7, 7},
+	"%":   {7, 7},
+	"^":   {10, 9},
+}
+
+// unaryPriority binds tighter than every binary operator except ^
+const unaryPriority = 8
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) advance() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// check reports whether the next token is a keyword or symbol with this text
+func (p *parser) check(text string) bool {
+	tok := p.peek()
+	return (tok.kind == tokKeyword || tok.kind == tokSymbol) && tok.text == text
+}
+
+// accept consumes the next token if it matches
+func (p *parser) accept(text string) bool {
+	if p.check(text) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// expect consumes a required keyword or symbol
+func (p *parser) expect(text string) error {
+	if !p.accept(text) {
+		tok := p.peek()
+		return p.errorf(tok.line, "'%s' expected near %s", text, describe(tok))
+	}
+	return nil
+}
+
+// expectName consumes a required identifier
+func (p *parser) expectName() (string, error) {
+	tok := p.peek()
+	if tok.kind != tokName {
+		return "", p.errorf(tok.line, "name expected near %s", describe(tok))
+	}
+	p.pos++
+	return tok.text, nil
+}
+
+func (p *parser) errorf(line int, format string, args ...interface{}) error {
+	return &Error{Source: p.name, Line: line, Message: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) unexpected() error {
+	tok := p.peek()
+	return p.errorf(tok.line, "unexpected %s", describe(tok))
+}
+
+// describe names a token for error messages
+func describe(tok token) string {
+	switch tok.kind {
+	case tokEOF:
+		return "end of file"
+	case tokString:
+		return fmt.Sprintf("string %q", tok.text)
+	default:
+		return "'" + tok.text + "'"
+	}
+}
+
+// blockEnds reports whether the next token closes the current block
+func (p *parser) blockEnds() bool {
+	if p.peek().kind == tokEOF {
+		return true
+	}
+	return p.check("end") || p.check("else") || p.check("elseif") || p.check("until")
+}
+
+// block parses statements up to the end of a block
+func (p *parser) block() (*Block, error) {
+	block := &Block{}
+	for !p.blockEnds() {
+		if p.accept(";") {
+			continue
+		}
+		if p.check("return") {
+			stmt, err := p.returnStmt()
+			if err != nil {
+				return nil, err
+			}
+			block.Stmts = append(block.Stmts, stmt)
+			if !p.blockEnds() {
+				return nil, p.errorf(p.peek().line, "'end' expected after return near %s", describe(p.peek()))
+			}
+			break
+		}
+		stmt, err := p.statement()
+		if err != nil {
+			return nil, err
+		}
+		block.Stmts = append(block.Stmts, stmt)
+	}
+	return block, nil
+}
+
+// statement parses one statement other than return
+func (p *parser) statement() (Stmt, error) {
+	tok := p.peek()
+	if tok.kind == tokKeyword {
+		switch tok.text {
+		case "local":
+			p.advance()
+			if p.accept("function") {
+				name, err := p.expectName()
+				if err != nil {
+					return nil, err
+				}
+				fn, err := p.functionBody(name, tok.line, false)
+				if err != nil {
+					return nil, err
+				}
+				return &LocalFunctionStmt{Name: name, Func: fn}, nil
+			}
+			return p.localStmt()
+
+		case "function":
+			p.advance()
+			return p.functionStmt(tok.line)
+
+		case "if":
+			p.advance()
+			return p.ifStmt()
+
+		case "while":
+			p.advance()
+			cond, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("do"); err != nil {
+				return nil, err
+			}
+			body, err := p.blockUntil("end")
+			if err != nil {
+				return nil, err
+			}
+			return &WhileStmt{Cond: cond, Body: body}, nil
+
+		case "repeat":
+			p.advance()
+			body, err := p.blockUntil("until")
+			if err != nil {
+				return nil, err
+			}
+			cond, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			return &RepeatStmt{Body: body, Cond: cond}, nil
+
+		case "for":
+			p.advance()
+			return p.forStmt(tok.line)
+
+		case "do":
+			p.advance()
+			body, err := p.blockUntil("end")
+			if err != nil {
+				return nil, err
+			}
+			return &DoStmt{Body: body}, nil
+
+		case "break":
+			p.advance()
+			return &BreakStmt{}, nil
+		}
+	}
+	return p.exprStmt()
+}
+
+// blockUntil parses a block followed by a closing keyword
+func (p *parser) blockUntil(closing string) (*Block, error) {
+	body, err := p.block()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(closing); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+func (p *parser) returnStmt() (Stmt, error) {
+	p.advance()
+	stmt := &ReturnStmt{}
+	if p.blockEnds() || p.check(";") {
+		p.accept(";")
+		return stmt, nil
+	}
+	exprs, err := p.exprList()
+	if err != nil {
+		return nil, err
+	}
+	p.accept(";")
+	stmt.Exprs = exprs
+	return stmt, nil
+}
+
+func (p *parser) localStmt() (Stmt, error) {
+	stmt := &LocalStmt{}
+	for {
+		name, err := p.expectName()
+		if err != nil {
+			return nil, err
+		}
+		stmt.Names = append(stmt.Names, name)
+		if !p.accept(",") {
+			break
+		}
+	}
+	if p.accept("=") {
+		exprs, err := p.exprList()
+		if err != nil {
+			return nil, err
+		}
+		stmt.Exprs = exprs
+	}
+	return stmt, nil
+}
+
+// functionStmt parses `function a.b.c:m(...)`, which assigns a function value
+func (p *parser) functionStmt(line int) (Stmt, error) {
+	name, err := p.expectName()
+	if err != nil {
+		return nil, err
+	}
+	var target Expr = &NameExpr{Name: name, Line: line}
+	fullName := name
+	method := false
+	for p.check(".") || p.check(":") {
+		sep := p.advance().text
+		key, err := p.expectName()
+		if err != nil {
+			return nil, err
+		}
+		target = &IndexExpr{Object: target, Key: &ConstExpr{Value: key}, Line: line}
+		fullName += sep + key
+		if sep == ":" {
+			method = true
+			break
+		}
+	}
+	fn, err := p.functionBody(fullName, line, method)
+	if err != nil {
+		return nil, err
+	}
+	return &AssignStmt{Targets: []Expr{target}, Exprs: []Expr{fn}, Line: line}, nil
+}
+
+// functionBody parses a parameter list and body
+func (p *parser) functionBody(name string, line int, method bool) (*FunctionExpr, error) {
+	fn := &FunctionExpr{Name: name, Line: line}
+	if method {
+		fn.Params = append(fn.Params, "self")
+	}
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	if !p.check(")") {
+		for {
+			if p.check("...") {
+				return nil, p.errorf(p.peek().line, "variable arguments are not supported")
+			}
+			param, err := p.expectName()
+			if err != nil {
+				return nil, err
+			}
+			fn.Params = append(fn.Params, param)
+			if !p.accept(",") {
+				break
+			}
+		}
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	body, err := p.blockUntil("end")
+	if err != nil {
+		return nil, err
+	}
+	fn.Body = body
+	return fn, nil
+}
+
+func (p *parser) ifStmt() (Stmt, error) {
+	stmt := &IfStmt{}
+	for {
+		cond, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect("then"); err != nil {
+			return nil, err
+		}
+		body, err := p.block()
+		if err != nil {
+			return nil, err
+		}
+		stmt.Conds = append(stmt.Conds, cond)
+		stmt.Blocks = append(stmt.Blocks, body)
+
+		if p.accept("elseif") {
+			continue
+		}
+		if p.accept("else") {
+			elseBody, err := p.blockUntil("end")
+			if err != nil {
+				return nil, err
+			}
+			stmt.Else = elseBody
+			return stmt, nil
+		}
+		if err := p.expect("end"); err != nil {
+			return nil, err
+		}
+		return stmt, nil
+	}
+}
+
+func (p *parser) forStmt(line int) (Stmt, error) {
+	first, err := p.expectName()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.accept("=") {
+		stmt := &NumericForStmt{Var: first, Line: line}
+		if stmt.Start, err = p.expr(); err != nil {
+			return nil, err
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+		if stmt.Limit, err = p.expr(); err != nil {
+			return nil, err
+		}
+		if p.accept(",") {
+			if stmt.Step, err = p.expr(); err != nil {
+				return nil, err
+			}
+		}
+		if err := p.expect("do"); err != nil {
+			return nil, err
+		}
+		if stmt.Body, err = p.blockUntil("end"); err != nil {
+			return nil, err
+		}
+		return stmt, nil
+	}
+
+	stmt := &GenericForStmt{Names: []string{first}, Line: line}
+	for p.accept(",") {
+		name, err := p.expectName()
+		if err != nil {
+			return nil, err
+		}
+		stmt.Names = append(stmt.Names, name)
+	}
+	if err := p.expect("in"); err != nil {
+		return nil, err
+	}
+	if stmt.Exprs, err = p.exprList(); err != nil {
+		return nil, err
+	}
+	if err := p.expect("do"); err != nil {
+		return nil, err
+	}
+	if stmt.Body, err = p.blockUntil("end"); err != nil {
+		return nil, err
+	}
+	return stmt, nil
+}
+
+// exprStmt parses a function call or an assignment
+func (p *parser) exprStmt() (Stmt, error) {
+	line := p.peek().line
+	first, err := p.suffixedExpr()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.check("=") || p.check(",") {
+		targets := []Expr{first}
+		for p.accept(",") {
+			target, err := p.suffixedExpr()
+			if err != nil {
+				return nil, err
+			}
+			targets = append(targets, target)
+		}
+		for _, target := range targets {
+			switch target.(type) {
+			case *NameExpr, *IndexExpr:
+			default:
+				return nil, p.errorf(line, "cannot assign to this expression")
+			}
+		}
+		if err := p.expect("="); err != nil {
+			return nil, err
+		}
+		exprs, err := p.exprList()
+		if err != nil {
+			return nil, err
+		}
+		return &AssignStmt{Targets: targets, Exprs: exprs, Line: line}, nil
+	}
+
+	switch first.(type) {
+	case *CallExpr, *MethodCallExpr:
+		return &CallStmt{Call: first}, nil
+	}
+	return nil, p.errorf(line, "syntax error: expression is not a statement")
+}
+
+func (p *parser) exprList() ([]Expr, error) {
+	var exprs []Expr
+	for {
+		expr, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, expr)
+		if !p.accept(",") {
+			return exprs, nil
+		}
+	}
+}
+
+func (p *parser) expr() (Expr, error) {
+	return p.subExpr(0)
+}
+
+// subExpr parses a binary expression whose operators bind tighter than limit
+func (p *parser) subExpr(limit int) (Expr, error) {
+	var left Expr
+	tok := p.peek()
+	if (tok.kind == tokKeyword && tok.text == "not") || (tok.kind == tokSymbol && (tok.text == "-" || tok.text == "#")) {
+		p.advance()
+		operand, err := p.subExpr(unaryPriority)
+		if err != nil {
+			return nil, err
+		}
+		if c, ok := operand.(*ConstExpr); ok && tok.text == "-" {
+			if n, ok := c.Value.(float64); ok {
+				left = &ConstExpr{Value: -n}
+			}
+		}
+		if left == nil {
+			left = &UnaryExpr{Op: tok.text, X: operand, Line: tok.line}
+		}
+	} else {
+		var err error
+		if left, err = p.simpleExpr(); err != nil {
+			return nil, err
+		}
+	}
+
+	for {
+		tok := p.peek()
+		if tok.kind != tokSymbol && tok.kind != tokKeyword {
+			return left, nil
+		}
+		prio, ok := binaryPriority[tok.text]
+		if !ok || prio[0] <= limit {
+			return left, nil
+		}
+		p.advance()
+		right, err := p.subExpr(prio[1])
+		if err != nil {
+			return nil, err
+		}
+		left = &BinaryExpr{Op: tok.text, Left: left, Right: right, Line: tok.line}
+	}
+}
+
+// simpleExpr parses literals, function literals, tables and suffixed expressions
+func (p *parser) simpleExpr() (Expr, error) {
+	tok := p.peek()
+	switch tok.kind {
+	case tokNumber:
+		p.advance()
+		return &ConstExpr{Value: tok.num}, nil
+	case tokString:
+		p.advance()
+		return &ConstExpr{Value: tok.text}, nil
+	case tokKeyword:
+		switch tok.text {
+		case "nil":
+			p.advance()
+			return &ConstExpr{Value: nil}, nil
+		case "true":
+			p.advance()
+			return &ConstExpr{Value: true}, nil
+		case "false":
+			p.advance()
+			return &ConstExpr{Value: false}, nil
+		case "function":
+			p.advance()
+			return p.functionBody("anonymous", tok.line, false)
+		}
+	case tokSymbol:
+		switch tok.text {
+		case "{":
+			return p.tableConstructor()
+		case "...":
+			return nil, p.errorf(tok.line, "variable arguments are not supported")
+		}
+	}
+	return p.suffixedExpr()
+}
+
+// primaryExpr parses a name or a parenthesized expression
+func (p *parser) primaryExpr() (Expr, error) {
+	tok := p.peek()
+	if tok.kind == tokName {
+		p.advance()
+		return &NameExpr{Name: tok.text, Line: tok.line}, nil
+	}
+	if p.accept("(") {
+		inner, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return &ParenExpr{X: inner}, nil
+	}
+	return nil, p.unexpected()
+}
+
+// suffixedExpr parses field access, indexing and calls after a primary expression
+func (p *parser) suffixedExpr() (Expr, error) {
+	expr, err := p.primaryExpr()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok := p.peek()
+		switch {
+		case p.check("."):
+			p.advance()
+			name, err := p.expectName()
+			if err != nil {
+				return nil, err
+			}
+			expr = &IndexExpr{Object: expr, Key: &ConstExpr{Value: name}, Line: tok.line}
+
+		case p.check("["):
+			p.advance()
+			key, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			expr = &IndexExpr{Object: expr, Key: key, Line: tok.line}
+
+		case p.check(":"):
+			p.advance()
+			name, err := p.expectName()
+			if err != nil {
+				return nil, err
+			}
+			args, err := p.callArgs()
+			if err != nil {
+				return nil, err
+			}
+			expr = &MethodCallExpr{Object: expr, Method: name, Args: args, Line: tok.line}
+
+		case p.check("(") || p.check("{") || tok.kind == tokString:
+			args, err := p.callArgs()
+			if err != nil {
+				return nil, err
+			}
+			expr = &CallExpr{Fn: expr, Args: args, Line: tok.line}
+
+		default:
+			return expr, nil
+		}
+	}
+}
+
+// callArgs parses (args), a table constructor or a string literal argument
+func (p *parser) callArgs() ([]Expr, error) {
+	tok := p.peek()
+	if tok.kind == tokString {
+		p.advance()
+		return []Expr{&ConstExpr{Value: tok.text}}, nil
+	}
+	if p.check("{") {
+		table, err := p.tableConstructor()
+		if err != nil {
+			return nil, err
+		}
+		return []Expr{table}, nil
+	}
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	if p.accept(")") {
+		return nil, nil
+	}
+	args, err := p.exprList()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	return args, nil
+}
+
+// tableConstructor parses {a, b, key = value, [expr] = value}
+func (p *parser) tableConstructor() (Expr, error) {
+	line := p.peek().line
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	table := &TableExpr{Line: line}
+	for !p.check("}") {
+		var field TableField
+		switch {
+		case p.check("["):
+			p.advance()
+			key, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			if err := p.expect("="); err != nil {
+				return nil, err
+			}
+			field.Key = key
+
+		case p.peek().kind == tokName && p.tokens[p.pos+1].kind == tokSymbol && p.tokens[p.pos+1].text == "=":
+			field.Key = &ConstExpr{Value: p.advance().text}
+			p.advance()
+		}
+
+		value, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		field.Value = value
+		table.Fields = append(table.Fields, field)
+
+		if !p.accept(",") && !p.accept(";") {
+			break
+		}
+	}
+	if err := p.expect("}"); err != nil {
+		return nil, err
+	}
+	return table, nil
+}
@@ -0,0 +1,209 @@
+package script
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// run compiles and runs a script, returning its results printed as print
+// would
+func run(t *testing.T, in *Interpreter, source string) (string, error) {
+	t.Helper()
+	chunk, err := Compile("test", source)
+	if err != nil {
+		return "", err
+	}
+	results, err := in.Run(chunk)
+	return JoinValues(results), err
+}
+
+func TestEval(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{"arithmetic", "return 1 + 2 * 3, (1 + 2) * 3, 7 / 2, 7 % 3, 2 ^ 10, -3", "7\t9\t3.5\t1\t1024\t-3"},
+		{"right associative", "return 2 ^ 3 ^ 2, 'a' .. 'b' .. 'c'", "512\tabc"},
+		{"comparison", "return 1 < 2, 2 <= 1, 'a' < 'b', 1 == 1, 1 ~= 1", "true\tfalse\ttrue\ttrue\tfalse"},
+		{"logic", "return nil or 'x', false and 1, not nil, 1 and 2", "x\tfalse\ttrue\t2"},
+		{"concat numbers", "return 1 .. 2, 'n=' .. 1.5", "12\tn=1.5"},
+		{"length", "return #'hello', #{1, 2, 3}", "5\t3"},
+		{"locals and scope", "local x = 1 do local x = 2 end return x", "1"},
+		{"multiple assignment", "local a, b = 1, 2 a, b = b, a return a, b", "2\t1"},
+		{"if", "local x = 5 if x > 10 then return 'big' elseif x > 3 then return 'mid' else return 'small' end", "mid"},
+		{"while", "local i, s = 0, 0 while i < 5 do i = i + 1 s = s + i end return s", "15"},
+		{"repeat", "local i = 0 repeat i = i + 1 until i >= 3 return i", "3"},
+		{"numeric for", "local s = 0 for i = 10, 1, -3 do s = s + i end return s", "22"},
+		{"break", "local n = 0 for i = 1, 100 do if i > 4 then break end n = i end return n", "4"},
+		{"tables", "local t = {1, 2, x = 'y', [10] = 'ten'} t.z = 3 return t[2], t.x, t[10], t.z, #t", "2\ty\tten\t3\t2"},
+		{"ipairs", "local s = '' for i, v in ipairs({'a', 'b', 'c'}) do s = s .. i .. v end return s", "1a2b3c"},
+		{"pairs keeps insertion order", "local t = {} t.b = 1 t.a = 2 t.c = 3 local s = '' for k in pairs(t) do s = s .. k end return s", "bac"},
+		{"closures", "local function counter() local n = 0 return function() n = n + 1 return n end end local c = counter() c() return c()", "2"},
+		{"recursion", "local function fib(n) if n < 2 then return n end return fib(n - 1) + fib(n - 2) end return fib(15)", "610"},
+		{"methods", "local obj = {n = 4} function obj:double() return self.n * 2 end return obj:double()", "8"},
+		{"select", "return select('#', 'a', 'b', 'c'), select(2, 'a', 'b', 'c')", "3\tb\tc"},
+		{"string library", "return string.upper('ab'), ('x'):rep(3), string.sub('hello', 2, 4), string.format('%d-%s', 5, 'z')", "AB\txxx\tell\t5-z"},
+		{"math library", "return math.floor(2.7), math.max(1, 5, 3), math.abs(-2)", "2\t5\t2"},
+		{"table library", "local t = {3, 1, 2} table.sort(t) table.insert(t, 4) return table.concat(t, ',')", "1,2,3,4"},
+		{"conversions", "return tonumber('42') + 1, tostring(1.5), type({}), type(nil)", "43\t1.5\ttable\tnil"},
+		{"pcall catches errors", "local ok, err = pcall(function() error('boom') end) return ok, err", "false\tboom"},
+		{"pcall passes results", "return pcall(function(a) return a * 2 end, 21)", "true\t42"},
+		{"comments", "-- a comment\nreturn 1 --[[ block\ncomment ]] + 1", "2"},
+		{"long strings", "return [[line\nbreak]]", "line\nbreak"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := run(t, New(), tt.source)
+			if err != nil {
+				t.Fatalf("error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		wantErr string
+	}{
+		{"missing end", "if true then return 1", "test:1:"},
+		{"unexpected token", "return )", "test:1:"},
+		{"unfinished string", "return 'abc", "test:1:"},
+		{"bad assignment", "1 = 2", "test:1:"},
+		{"line number", "local x = 1\nlocal y = 2\nreturn = 3", "test:3:"},
+		{"unexpected end", "x = 1 end", "test:1:"},
+		{"varargs", "local function f(...) end", "variable arguments are not supported"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Compile("test", tt.source)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Compile error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRuntimeErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		wantErr string
+	}{
+		{"call nil", "local f f()", "test:1:"},
+		{"index nil", "local t return t.x", "test:1:"},
+		{"arithmetic on string", "return {} + 1", "test:1:"},
+		{"compare mismatched", "return 1 < 'a'", "test:1:"},
+		{"error value", "error('custom failure')", "custom failure"},
+		{"error line", "local x = 1\n\nerror('here')", "test:3:"},
+		{"assert", "assert(false, 'assertion text')", "assertion text"},
+		{"builtin argument", "return math.floor('x')", "bad argument #1"},
+		{"deep recursion", "local function f() return f() + 1 end return f()", "stack"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := run(t, New(), tt.source)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want one containing %q", err, tt.wantErr)
+			}
+			var scriptErr *Error
+			if !errors.As(err, &scriptErr) {
+				t.Errorf("error %T is not a script error", err)
+			}
+		})
+	}
+}
+
+func TestLimits(t *testing.T) {
+	tests := []struct {
+		name   string
+		limits Limits
+		source string
+		limit  string
+	}{
+		{"steps", Limits{Steps: 1000}, "while true do end", LimitSteps},
+		{"steps in pcall", Limits{Steps: 1000}, "pcall(function() while true do end end) return 'escaped'", LimitSteps},
+		{"timeout", Limits{Timeout: 20 * time.Millisecond}, "while true do end", LimitTimeout},
+		{"memory", Limits{Memory: 4096}, "local s = 'x' while true do s = s .. s end", LimitMemory},
+		{"table memory", Limits{Memory: 4096}, "local t = {} for i = 1, 100000 do t[i] = i end", LimitMemory},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := New()
+			in.SetLimits(tt.limits)
+			_, err := run(t, in, tt.source)
+			var limitErr *LimitError
+			if !errors.As(err, &limitErr) {
+				t.Fatalf("error = %v, want a %s limit error", err, tt.limit)
+			}
+			if limitErr.Limit != tt.limit {
+				t.Errorf("exceeded the %s limit, want %s", limitErr.Limit, tt.limit)
+			}
+		})
+	}
+}
+
+func TestLimitsResetPerCall(t *testing.T) {
+	in := New()
+	in.SetLimits(Limits{Steps: 500})
+	chunk, err := Compile("test", "function f() local n = 0 for i = 1, 100 do n = n + i end return n end")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := in.Run(chunk); err != nil {
+		t.Fatal(err)
+	}
+
+	// Each call gets a fresh budget, so many small calls all succeed
+	for i := 0; i < 20; i++ {
+		results, err := in.Call(in.Global("f"))
+		if err != nil {
+			t.Fatalf("call %d: %v", i, err)
+		}
+		if got := JoinValues(results); got != "5050" {
+			t.Fatalf("call %d returned %s, want 5050", i, got)
+		}
+	}
+	if steps, _ := in.Usage(); steps == 0 || steps > 500 {
+		t.Errorf("last call used %d steps, want between 1 and 500", steps)
+	}
+}
+
+func TestRegisteredFunctions(t *testing.T) {
+	in := New()
+	var got []Value
+	in.Register("record", func(args []Value) ([]Value, error) {
+		got = append(got, args...)
+		return []Value{float64(len(args))}, nil
+	})
+	in.Register("fail", func(args []Value) ([]Value, error) {
+		return nil, errors.New("go failure")
+	})
+
+	out, err := run(t, in, "return record(1, 'two', true)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "3" || JoinValues(got) != "1\ttwo\ttrue" {
+		t.Errorf("record returned %q with arguments %q", out, JoinValues(got))
+	}
+
+	out, err = run(t, in, "local ok, err = pcall(fail) return ok, err")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(out, "false\t") || !strings.Contains(out, "go failure") {
+		t.Errorf("pcall(fail) = %q, want false and the Go error", out)
+	}
+}
@@ -0,0 +1,553 @@
+package script
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+)
+
+// openStdlib installs the base functions and the string, math and table
+// libraries. There is deliberately no io, os or load: scripts only reach the
+// outside world through functions the host registers.
+func openStdlib(in *Interpreter) {
+	in.Register("type", func(args []Value) ([]Value, error) {
+		return []Value{typeName(arg(args, 0))}, nil
+	})
+	in.Register("tostring", func(args []Value) ([]Value, error) {
+		return []Value{ToString(arg(args, 0))}, nil
+	})
+	in.Register("tonumber", func(args []Value) ([]Value, error) {
+		if n, ok := toNumber(arg(args, 0)); ok {
+			return []Value{n}, nil
+		}
+		return []Value{nil}, nil
+	})
+	in.Register("print", func(args []Value) ([]Value, error) {
+		fmt.Println(JoinValues(args))
+		return nil, nil
+	})
+	in.Register("error", func(args []Value) ([]Value, error) {
+		value := arg(args, 0)
+		return nil, &Error{Message: ToString(value), Value: value}
+	})
+	in.Register("assert", func(args []Value) ([]Value, error) {
+		if truthy(arg(args, 0)) {
+			return args, nil
+		}
+		message := "assertion failed!"
+		if len(args) > 1 {
+			message = ToString(args[1])
+		}
+		return nil, &Error{Message: message, Value: message}
+	})
+	in.Register("pcall", func(args []Value) ([]Value, error) {
+		if len(args) == 0 {
+			return nil, fmt.Errorf("bad argument #1 (value expected)")
+		}
+		results, err := in.Call(args[0], args[1:]...)
+		if err != nil {
+			if scriptErr, ok := err.(*Error); ok && scriptErr.Value != nil {
+				return []Value{false, scriptErr.Value}, nil
+			}
+			return []Value{false, err.Error()}, nil
+		}
+		return append([]Value{true}, results...), nil
+	})
+	in.Register("select", func(args []Value) ([]Value, error) {
+		if s, ok := arg(args, 0).(string); ok && s == "#" {
+			return []Value{float64(len(args) - 1)}, nil
+		}
+		n, ok := toNumber(arg(args, 0))
+		if !ok || n < 1 {
+			return nil, fmt.Errorf("bad argument #1 (index out of range)")
+		}
+		if int(n) >= len(args) {
+			return nil, nil
+		}
+		return args[int(n):], nil
+	})
+	in.Register("pairs", func(args []Value) ([]Value, error) {
+		table, err := tableArg(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		keys := table.Keys()
+		i := 0
+		iter := NewBuiltin("pairs_iterator", func([]Value) ([]Value, error) {
+			for i < len(keys) {
+				key := keys[i]
+				i++
+				if value := table.Get(key); value != nil {
+					return []Value{key, value}, nil
+				}
+			}
+			return []Value{nil}, nil
+		})
+		return []Value{iter, table, nil}, nil
+	})
+	in.Register("ipairs", func(args []Value) ([]Value, error) {
+		table, err := tableArg(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		iter := NewBuiltin("ipairs_iterator", func(iterArgs []Value) ([]Value, error) {
+			i, _ := toNumber(arg(iterArgs, 1))
+			i++
+			value := table.Get(i)
+			if value == nil {
+				return []Value{nil}, nil
+			}
+			return []Value{i, value}, nil
+		})
+		return []Value{iter, table, float64(0)}, nil
+	})
+	in.Register("next", func(args []Value) ([]Value, error) {
+		table, err := tableArg(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		keys := table.Keys()
+		start := 0
+		if current := arg(args, 1); current != nil {
+			current, _ = normalizeKey(current)
+			start = len(keys)
+			for i, key := range keys {
+				if key == current {
+					start = i + 1
+					break
+				}
+			}
+		}
+		for _, key := range keys[start:] {
+			if value := table.Get(key); value != nil {
+				return []Value{key, value}, nil
+			}
+		}
+		return []Value{nil}, nil
+	})
+
+	in.SetGlobal("string", stringLibrary())
+	in.SetGlobal("math", mathLibrary())
+	in.SetGlobal("table", tableLibrary())
+}
+
+// library builds a table of Go functions
+func library(name string, fns map[string]GoFunction) *Table {
+	lib := NewTable()
+	for fnName, fn := range fns {
+		lib.Set(fnName, NewBuiltin(name+"."+fnName, fn))
+	}
+	return lib
+}
+
+func stringLibrary() *Table {
+	return library("string", map[string]GoFunction{
+		"len": func(args []Value) ([]Value, error) {
+			s, err := stringArg(args, 0)
+			return []Value{float64(len(s))}, err
+		},
+		"upper": func(args []Value) ([]Value, error) {
+			s, err := stringArg(args, 0)
+			return []Value{strings.ToUpper(s)}, err
+		},
+		"lower": func(args []Value) ([]Value, error) {
+			s, err := stringArg(args, 0)
+			return []Value{strings.ToLower(s)}, err
+		},
+		"rep": func(args []Value) ([]Value, error) {
+			s, err := stringArg(args, 0)
+			if err != nil {
+				return nil, err
+			}
+			n, _ := toNumber(arg(args, 1))
+			if n < 0 {
+				n = 0
+			}
+			if float64(len(s))*n > 1<<20 {
+				return nil, fmt.Errorf("resulting string too large")
+			}
+			return []Value{strings.Repeat(s, int(n))}, nil
+		},
+		"sub": func(args []Value) ([]Value, error) {
+			s, err := stringArg(args, 0)
+			if err != nil {
+				return nil, err
+			}
+			start, end := stringRange(len(s), arg(args, 1), arg(args, 2))
+			if start > end {
+				return []Value{""}, nil
+			}
+			return []Value{s[start-1 : end]}, nil
+		},
+		"find": func(args []Value) ([]Value, error) {
+			s, err := stringArg(args, 0)
+			if err != nil {
+				return nil, err
+			}
+			pattern, err := stringArg(args, 1)
+			if err != nil {
+				return nil, err
+			}
+			init := 1
+			if n, ok := toNumber(arg(args, 2)); ok {
+				init, _ = stringRange(len(s), n, nil)
+			}
+			if init > len(s)+1 {
+				return []Value{nil}, nil
+			}
+			// Patterns are matched literally
+			i := strings.Index(s[init-1:], pattern)
+			if i < 0 {
+				return []Value{nil}, nil
+			}
+			start := init + i
+			return []Value{float64(start), float64(start + len(pattern) - 1)}, nil
+		},
+		"format": func(args []Value) ([]Value, error) {
+			format, err := stringArg(args, 0)
+			if err != nil {
+				return nil, err
+			}
+			return []Value{formatString(format, args[1:])}, nil
+		},
+		"byte": func(args []Value) ([]Value, error) {
+			s, err := stringArg(args, 0)
+			if err != nil {
+				return nil, err
+			}
+			i := 1
+			if n, ok := toNumber(arg(args, 1)); ok {
+				i = int(n)
+			}
+			if i < 1 || i > len(s) {
+				return nil, nil
+			}
+			return []Value{float64(s[i-1])}, nil
+		},
+		"char": func(args []Value) ([]Value, error) {
+			var sb strings.Builder
+			for i := range args {
+				n, ok := toNumber(args[i])
+				if !ok || n < 0 || n > 255 {
+					return nil, fmt.Errorf("bad argument #%d (value out of range)", i+1)
+				}
+				sb.WriteByte(byte(n))
+			}
+			return []Value{sb.String()}, nil
+		},
+	})
+}
+
+// stringRange converts 1-based, possibly negative, inclusive indices to a
+// clamped range
+func stringRange(length int, startArg, endArg Value) (int, int) {
+	start, ok := toNumber(startArg)
+	if !ok {
+		start = 1
+	}
+	end, ok := toNumber(endArg)
+	if !ok {
+		end = -1
+	}
+	if start < 0 {
+		start = float64(length) + start + 1
+	}
+	if end < 0 {
+		end = float64(length) + end + 1
+	}
+	if start < 1 {
+		start = 1
+	}
+	if end > float64(length) {
+		end = float64(length)
+	}
+	return int(start), int(end)
+}
+
+// formatString implements string.format's %d, %i, %f, %g, %s, %q, %x and %%
+func formatString(format string, args []Value) string {
+	var sb strings.Builder
+	next := 0
+	for i := 0; i < len(format); i++ {
+		c := format[i]
+		if c != '%' || i+1 >= len(format) {
+			sb.WriteByte(c)
+			continue
+		}
+		// Copy flags, width and precision through to fmt
+		j := i + 1
+		for j < len(format) && strings.IndexByte("-+ #0123456789.", format[j]) >= 0 {
+			j++
+		}
+		if j >= len(format) {
+			sb.WriteString(format[i:])
+			break
+		}
+		spec := format[i:j]
+		verb := format[j]
+		i = j
+		if verb == '%' {
+			sb.WriteByte('%')
+			continue
+		}
+		value := arg(args, next)
+		next++
+		switch verb {
+		case 'd', 'i':
+			n, _ := toNumber(value)
+			sb.WriteString(fmt.Sprintf(spec+"d", int64(n)))
+		case 'x', 'X':
+			n, _ := toNumber(value)
+			sb.WriteString(fmt.Sprintf(spec+string(verb), int64(n)))
+		case 'f', 'g', 'e':
+			n, _ := toNumber(value)
+			sb.WriteString(fmt.Sprintf(spec+string(verb), n))
+		case 'q':
+			sb.WriteString(fmt.Sprintf("%q", ToString(value)))
+		default:
+			sb.WriteString(fmt.Sprintf(spec+"s", ToString(value)))
+		}
+	}
+	return sb.String()
+}
+
+func mathLibrary() *Table {
+	lib := library("math", map[string]GoFunction{
+		"floor": numberFn(math.Floor),
+		"ceil":  numberFn(math.Ceil),
+		"abs":   numberFn(math.Abs),
+		"sqrt":  numberFn(math.Sqrt),
+		"sin":   numberFn(math.Sin),
+		"cos":   numberFn(math.Cos),
+		"tan":   numberFn(math.Tan),
+		"exp":   numberFn(math.Exp),
+		"log":   numberFn(math.Log),
+		"atan": func(args []Value) ([]Value, error) {
+			y, err := numberArg(args, 0)
+			if err != nil {
+				return nil, err
+			}
+			x := 1.0
+			if n, ok := toNumber(arg(args, 1)); ok {
+				x = n
+			}
+			return []Value{math.Atan2(y, x)}, nil
+		},
+		"max": func(args []Value) ([]Value, error) {
+			best, err := numberArg(args, 0)
+			if err != nil {
+				return nil, err
+			}
+			for i := 1; i < len(args); i++ {
+				n, err := numberArg(args, i)
+				if err != nil {
+					return nil, err
+				}
+				best = math.Max(best, n)
+			}
+			return []Value{best}, nil
+		},
+		"min": func(args []Value) ([]Value, error) {
+			best, err := numberArg(args, 0)
+			if err != nil {
+				return nil, err
+			}
+			for i := 1; i < len(args); i++ {
+				n, err := numberArg(args, i)
+				if err != nil {
+					return nil, err
+				}
+				best = math.Min(best, n)
+			}
+			return []Value{best}, nil
+		},
+		"random": func(args []Value) ([]Value, error) {
+			switch len(args) {
+			case 0:
+				return []Value{rand.Float64()}, nil
+			case 1:
+				hi, err := numberArg(args, 0)
+				if err != nil || hi < 1 {
+					return nil, fmt.Errorf("bad argument #1 (interval is empty)")
+				}
+				return []Value{float64(1 + rand.Intn(int(hi)))}, nil
+			default:
+				lo, err := numberArg(args, 0)
+				if err != nil {
+					return nil, err
+				}
+				hi, err := numberArg(args, 1)
+				if err != nil || hi < lo {
+					return nil, fmt.Errorf("bad argument #2 (interval is empty)")
+				}
+				return []Value{lo + float64(rand.Intn(int(hi-lo)+1))}, nil
+			}
+		},
+	})
+	lib.Set("pi", math.Pi)
+	lib.Set("huge", math.Inf(1))
+	return lib
+}
+
+// numberFn adapts a float function
+func numberFn(fn func(float64) float64) GoFunction {
+	return func(args []Value) ([]Value, error) {
+		n, err := numberArg(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		return []Value{fn(n)}, nil
+	}
+}
+
+func tableLibrary() *Table {
+	return library("table", map[string]GoFunction{
+		"insert": func(args []Value) ([]Value, error) {
+			table, err := tableArg(args, 0)
+			if err != nil {
+				return nil, err
+			}
+			if len(args) < 3 {
+				table.Append(arg(args, 1))
+				return nil, nil
+			}
+			pos, err := numberArg(args, 1)
+			if err != nil {
+				return nil, err
+			}
+			n := table.Len()
+			if pos < 1 || int(pos) > n+1 {
+				return nil, fmt.Errorf("bad argument #2 (position out of bounds)")
+			}
+			for i := n; i >= int(pos); i-- {
+				table.Set(float64(i+1), table.Get(float64(i)))
+			}
+			table.Set(pos, args[2])
+			return nil, nil
+		},
+		"remove": func(args []Value) ([]Value, error) {
+			table, err := tableArg(args, 0)
+			if err != nil {
+				return nil, err
+			}
+			n := table.Len()
+			if n == 0 {
+				return []Value{nil}, nil
+			}
+			pos := n
+			if p, ok := toNumber(arg(args, 1)); ok {
+				pos = int(p)
+			}
+			if pos < 1 || pos > n {
+				return nil, fmt.Errorf("bad argument #2 (position out of bounds)")
+			}
+			removed := table.Get(float64(pos))
+			for i := pos; i < n; i++ {
+				table.Set(float64(i), table.Get(float64(i+1)))
+			}
+			table.Set(float64(n), nil)
+			return []Value{removed}, nil
+		},
+		"concat": func(args []Value) ([]Value, error) {
+			table, err := tableArg(args, 0)
+			if err != nil {
+				return nil, err
+			}
+			sep := ""
+			if s, ok := arg(args, 1).(string); ok {
+				sep = s
+			}
+			parts := make([]string, 0, table.Len())
+			for i := 1; i <= table.Len(); i++ {
+				v := table.Get(float64(i))
+				switch v.(type) {
+				case string, float64:
+					parts = append(parts, ToString(v))
+				default:
+					return nil, fmt.Errorf("invalid value (at index %d) in table for 'concat'", i)
+				}
+			}
+			return []Value{strings.Join(parts, sep)}, nil
+		},
+		"sort": func(args []Value) ([]Value, error) {
+			table, err := tableArg(args, 0)
+			if err != nil {
+				return nil, err
+			}
+			values := make([]Value, table.Len())
+			for i := range values {
+				values[i] = table.Get(float64(i + 1))
+			}
+			var sortErr error
+			sort.SliceStable(values, func(i, j int) bool {
+				if sortErr != nil {
+					return false
+				}
+				switch a := values[i].(type) {
+				case float64:
+					if b, ok := values[j].(float64); ok {
+						return a < b
+					}
+				case string:
+					if b, ok := values[j].(string); ok {
+						return a < b
+					}
+				}
+				sortErr = fmt.Errorf("attempt to compare %s with %s", typeName(values[i]), typeName(values[j]))
+				return false
+			})
+			if sortErr != nil {
+				return nil, sortErr
+			}
+			for i, v := range values {
+				table.Set(float64(i+1), v)
+			}
+			return nil, nil
+		},
+	})
+}
+
+// arg returns an argument or nil when it was not passed
+func arg(args []Value, i int) Value {
+	if i < len(args) {
+		return args[i]
+	}
+	return nil
+}
+
+func tableArg(args []Value, i int) (*Table, error) {
+	table, ok := arg(args, i).(*Table)
+	if !ok {
+		return nil, fmt.Errorf("bad argument #%d (table expected, got %s)", i+1, typeName(arg(args, i)))
+	}
+	return table, nil
+}
+
+func stringArg(args []Value, i int) (string, error) {
+	switch v := arg(args, i).(type) {
+	case string:
+		return v, nil
+	case float64:
+		return formatNumber(v), nil
+	}
+	return "", fmt.Errorf("bad argument #%d (string expected, got %s)", i+1, typeName(arg(args, i)))
+}
+
+func numberArg(args []Value, i int) (float64, error) {
+	n, ok := toNumber(arg(args, i))
+	if !ok {
+		return 0, fmt.Errorf("bad argument #%d (number expected, got %s)", i+1, typeName(arg(args, i)))
+	}
+	return n, nil
+}
+
+// JoinValues formats values separated by tabs, as print does
+func JoinValues(values []Value) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = ToString(v)
+	}
+	return strings.Join(parts, "\t")
+}
@@ -0,0 +1,300 @@
+package script
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Value is a script value: nil, bool, float64, string, *Table, *Function or
+// *Builtin
+type Value interface{}
+
+// GoFunction is the signature of functions implemented in Go
+type GoFunction func(args []Value) ([]Value, error)
+
+// Builtin is a Go function callable from scripts
+type Builtin struct {
+	Name string
+	Fn   GoFunction
+}
+
+// NewBuiltin wraps a Go function for use from scripts
+func NewBuiltin(name string, fn GoFunction) *Builtin {
+	return &Builtin{Name: name, Fn: fn}
+}
+
+// Function is a closure defined in a script
+type Function struct {
+	proto  *FunctionExpr
+	source string
+	env    *scope
+}
+
+// Name returns the function's name as written in the script
+func (f *Function) Name() string {
+	return f.proto.Name
+}
+
+// Error is a syntax or runtime error with the script position it occurred at
+type Error struct {
+	Source  string
+	Line    int
+	Message string
+	// Value is the value passed to error(), if the script raised it itself
+	Value Value
+}
+
+func (e *Error) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s", e.Source, e.Line, e.Message)
+	}
+	if e.Source != "" {
+		return fmt.Sprintf("%s: %s", e.Source, e.Message)
+	}
+	return e.Message
+}
+
+// Table is the language's only data structure: an associative array with a
+// fast path for the sequence 1..n
+type Table struct {
+	array []Value
+	hash  map[Value]Value
+	order []Value // insertion order of hash keys, for deterministic iteration
+}
+
+// NewTable creates an empty table
+func NewTable() *Table {
+	return &Table{}
+}
+
+// normalizeKey converts integral floats to a canonical form and rejects keys
+// that cannot be used
+func normalizeKey(key Value) (Value, error) {
+	switch k := key.(type) {
+	case nil:
+		return nil, fmt.Errorf("table index is nil")
+	case float64:
+		if math.IsNaN(k) {
+			return nil, fmt.Errorf("table index is NaN")
+		}
+		if k == 0 {
+			return float64(0), nil
+		}
+	case int:
+		return float64(k), nil
+	}
+	return key, nil
+}
+
+// arrayIndex returns the 0-based array slot for a key, if it is one
+func (t *Table) arrayIndex(key Value) (int, bool) {
+	f, ok := key.(float64)
+	if !ok || f != math.Trunc(f) || f < 1 || f > float64(len(t.array)) {
+		return 0, false
+	}
+	return int(f) - 1, true
+}
+
+// Get returns the value stored under key, or nil
+func (t *Table) Get(key Value) Value {
+	key, err := normalizeKey(key)
+	if err != nil {
+		return nil
+	}
+	if i, ok := t.arrayIndex(key); ok {
+		return t.array[i]
+	}
+	if t.hash == nil {
+		return nil
+	}
+	return t.hash[key]
+}
+
+// GetString returns the value stored under a string key
+func (t *Table) GetString(key string) Value {
+	return t.Get(key)
+}
+
+// Set stores a value; storing nil removes the key
+func (t *Table) Set(key, value Value) error {
+	key, err := normalizeKey(key)
+	if err != nil {
+		return err
+	}
+
+	if i, ok := t.arrayIndex(key); ok {
+		t.array[i] = value
+		if value == nil && i == len(t.array)-1 {
+			// Shrink so the length stays a border of the sequence
+			for len(t.array) > 0 && t.array[len(t.array)-1] == nil {
+				t.array = t.array[:len(t.array)-1]
+			}
+		}
+		return nil
+	}
+
+	if f, ok := key.(float64); ok && f == float64(len(t.array)+1) && value != nil {
+		t.array = append(t.array, value)
+		t.removeHash(key)
+		// Move any following integer keys from the hash into the array
+		for t.hash != nil {
+			next := float64(len(t.array) + 1)
+			v, exists := t.hash[next]
+			if !exists {
+				break
+			}
+			t.array = append(t.array, v)
+			t.removeHash(next)
+		}
+		return nil
+	}
+
+	if value == nil {
+		t.removeHash(key)
+		return nil
+	}
+	if t.hash == nil {
+		t.hash = make(map[Value]Value)
+	}
+	if _, exists := t.hash[key]; !exists {
+		t.order = append(t.order, key)
+	}
+	t.hash[key] = value
+	return nil
+}
+
+// SetString stores a value under a string key
+func (t *Table) SetString(key string, value Value) {
+	t.Set(key, value)
+}
+
+// removeHash deletes a key from the hash part
+func (t *Table) removeHash(key Value) {
+	if t.hash == nil {
+		return
+	}
+	if _, exists := t.hash[key]; !exists {
+		return
+	}
+	delete(t.hash, key)
+	for i, k := range t.order {
+		if k == key {
+			t.order = append(t.order[:i], t.order[i+1:]...)
+			break
+		}
+	}
+}
+
+// Len returns the length of the table's sequence part
+func (t *Table) Len() int {
+	return len(t.array)
+}
+
+// Append adds a value to the end of the sequence
+func (t *Table) Append(value Value) {
+	t.Set(float64(len(t.array)+1), value)
+}
+
+// Keys returns every key, sequence keys first, then the rest in insertion order
+func (t *Table) Keys() []Value {
+	keys := make([]Value, 0, len(t.array)+len(t.order))
+	for i, v := range t.array {
+		if v != nil {
+			keys = append(keys, float64(i+1))
+		}
+	}
+	keys = append(keys, t.order...)
+	return keys
+}
+
+// typeName returns the script type name of a value
+func typeName(v Value) string {
+	switch v.(type) {
+	case nil:
+		return "nil"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case *Table:
+		return "table"
+	case *Function, *Builtin:
+		return "function"
+	default:
+		return "userdata"
+	}
+}
+
+// truthy reports whether a value counts as true: everything but nil and false
+func truthy(v Value) bool {
+	switch b := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return b
+	}
+	return true
+}
+
+// formatNumber prints integral numbers without a fraction
+func formatNumber(f float64) string {
+	if f == math.Trunc(f) && math.Abs(f) < 1e15 {
+		return strconv.FormatInt(int64(f), 10)
+	}
+	if math.IsInf(f, 1) {
+		return "inf"
+	}
+	if math.IsInf(f, -1) {
+		return "-inf"
+	}
+	if math.IsNaN(f) {
+		return "nan"
+	}
+	return strconv.FormatFloat(f, 'g', 14, 64)
+}
+
+// ToString converts a value to its printed form
+func ToString(v Value) string {
+	switch x := v.(type) {
+	case nil:
+		return "nil"
+	case bool:
+		if x {
+			return "true"
+		}
+		return "false"
+	case float64:
+		return formatNumber(x)
+	case string:
+		return x
+	case *Table:
+		return fmt.Sprintf("table: %p", x)
+	case *Function:
+		return fmt.Sprintf("function: %s", x.Name())
+	case *Builtin:
+		return fmt.Sprintf("function: builtin %s", x.Name)
+	default:
+		return fmt.Sprint(x)
+	}
+}
+
+// toNumber converts numbers and numeric strings to a number
+func toNumber(v Value) (float64, bool) {
+	switch x := v.(type) {
+	case float64:
+		return x, true
+	case string:
+		s := strings.TrimSpace(x)
+		if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+			n, err := strconv.ParseUint(s[2:], 16, 64)
+			return float64(n), err == nil
+		}
+		n, err := strconv.ParseFloat(s, 64)
+		return n, err == nil
+	}
+	return 0, false
+}
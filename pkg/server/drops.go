@@ -0,0 +1,113 @@
+package server
+
+import (
+	"fmt"
+	"time"
+
+	"tesselbox/pkg/items"
+)
+
+const (
+	// DropLifetime is how long a dropped item lies in the world, as on clients
+	DropLifetime = 5 * time.Minute
+	// pickupRange is how far from where an item was dropped a player may
+	// pick it up. Clients run item physics themselves, so it allows for the
+	// item having fallen or slid away.
+	pickupRange = 600.0
+)
+
+// DroppedItem is an item stack lying in the world. Clients show it and ask
+// to pick it up by ID.
+type DroppedItem struct {
+	ID      uint64
+	Item    items.Item
+	X, Y    float64
+	Expires time.Time
+}
+
+// dropItem puts a stack in the world at a position; callers must hold the mutex
+func (s *Simulation) dropItem(item items.Item, x, y float64) {
+	if item.Type == items.NONE || item.Quantity <= 0 {
+		return
+	}
+	s.nextDropID++
+	item.Meta = item.Meta.Clone()
+	s.Drops[s.nextDropID] = &DroppedItem{
+		ID:      s.nextDropID,
+		Item:    item,
+		X:       x,
+		Y:       y,
+		Expires: time.Now().Add(DropLifetime),
+	}
+}
+
+// dropAtPlayer drops a stack just above a player; callers must hold the mutex
+func (s *Simulation) dropAtPlayer(state *PlayerState, item items.Item) {
+	centerX, centerY := state.Player.GetCenter()
+	s.dropItem(item, centerX, centerY-10)
+}
+
+// expireDrops removes dropped items that have lain too long; callers must
+// hold the mutex
+func (s *Simulation) expireDrops(now time.Time) {
+	for id, drop := range s.Drops {
+		if now.After(drop.Expires) {
+			delete(s.Drops, id)
+		}
+	}
+}
+
+// PickUp moves a dropped item into a player's inventory. Whatever does not
+// fit stays in the world.
+func (s *Simulation) PickUp(name string, id uint64) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	state, ok := s.players[name]
+	if !ok {
+		return fmt.Errorf("player %s is not in the world", name)
+	}
+	drop, ok := s.Drops[id]
+	if !ok {
+		return fmt.Errorf("no dropped item %d", id)
+	}
+	centerX, centerY := state.Player.GetCenter()
+	if dx, dy := drop.X-centerX, drop.Y-centerY; dx*dx+dy*dy > pickupRange*pickupRange {
+		return fmt.Errorf("dropped item %d is out of reach", id)
+	}
+
+	added := addStack(state.Inventory, drop.Item)
+	if added == 0 {
+		return fmt.Errorf("no room for %s", drop.Item.DisplayName())
+	}
+	drop.Item.Quantity -= added
+	if drop.Item.Quantity <= 0 {
+		delete(s.Drops, id)
+	}
+	return nil
+}
+
+// addStack adds as much of a stack to an inventory as fits and returns how
+// many items that was
+func addStack(inventory *items.Inventory, item items.Item) int {
+	before := countStack(inventory.Slots, item)
+	inventory.AddStack(item)
+	return countStack(inventory.Slots, item) - before
+}
+
+// countStack counts the items in slots that are the same as a stack
+func countStack(slots []items.Item, item items.Item) int {
+	count := 0
+	for _, slot := range slots {
+		if sameStack(slot, item) {
+			count += slot.Quantity
+		}
+	}
+	return count
+}
+
+// sameStack reports whether two items are the same thing: type, durability
+// and metadata, whatever their quantities
+func sameStack(a, b items.Item) bool {
+	return a.Type == b.Type && a.Type != items.NONE && a.Durability == b.Durability && a.Meta.Equal(b.Meta)
+}
@@ -0,0 +1,389 @@
+package server
+
+import (
+	"fmt"
+
+	"tesselbox/pkg/blocks"
+	"tesselbox/pkg/crafting"
+	"tesselbox/pkg/enchant"
+	"tesselbox/pkg/items"
+	"tesselbox/pkg/station"
+	"tesselbox/pkg/survival"
+)
+
+// ContainerChest is the container kind of chests; stations use their own kind
+const ContainerChest = "chest"
+
+// Container is a copy of what is in a chest or station a player has open
+type Container struct {
+	Kind  string
+	X, Y  float64
+	Slots []items.Item
+
+	// Stations only
+	Burn, BurnTotal, Progress float64
+}
+
+// AnvilWork is one use of an anvil: enchanting the item in Slot, or combining
+// or repairing it with the item in Second
+type AnvilWork struct {
+	Slot        int
+	Second      int    // -1 when enchanting
+	Enchantment string // ID of the enchantment to apply
+	Level       int
+}
+
+// Craft crafts a recipe for a player, at a station block within their reach.
+// Recipes are learned on clients, so the server knows all of them. Selected
+// is the client's selected slot, which holds any tool the recipe needs.
+func (s *Simulation) Craft(name, recipeID, stationName string, selected int) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	state, ok := s.players[name]
+	if !ok {
+		return fmt.Errorf("player %s is not in the world", name)
+	}
+	craftingStation, ok := crafting.ParseStation(stationName)
+	if !ok {
+		return fmt.Errorf("unknown crafting station %q", stationName)
+	}
+	if craftingStation != crafting.STATION_NONE && !s.nearBlock(state, stationName) {
+		return fmt.Errorf("no %s within reach", stationName)
+	}
+	if !state.Inventory.SelectSlot(selected) {
+		return fmt.Errorf("no inventory slot %d", selected)
+	}
+	return s.Recipes.CraftRecipe(recipeID, state.Inventory, craftingStation)
+}
+
+// UseItem eats or drinks the item in a slot, fills a bottle at water, or
+// drinks from the water with an empty hand, as clients do on right click.
+// The client has already decided the player wants to eat; its survival stats
+// are the ones the player sees.
+func (s *Simulation) UseItem(name string, slot int, itemID string, targetX, targetY float64) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	state, ok := s.players[name]
+	if !ok {
+		return fmt.Errorf("player %s is not in the world", name)
+	}
+	inventory := state.Inventory
+	if !inventory.SelectSlot(slot) {
+		return fmt.Errorf("no inventory slot %d", slot)
+	}
+	item := inventory.Slots[slot]
+	if id := items.ItemID(item.Type); id != itemID {
+		return fmt.Errorf("slot %d holds %s, not %s", slot, id, itemID)
+	}
+
+	hex := s.World.GetHexagonAt(targetX, targetY)
+	atWater := hex != nil && hex.BlockType == blocks.WATER && s.inReach(state, hex.X, hex.Y)
+	switch {
+	case item.Type == items.BOTTLE && atWater:
+		inventory.RemoveItem(1)
+		s.giveItem(state, items.WATER_BOTTLE)
+		return nil
+	case item.Type == items.NONE && atWater:
+		state.Survival.DrinkWater()
+		return nil
+	case item.Type == items.NONE:
+		return fmt.Errorf("nothing to use")
+	}
+
+	props := items.GetItemProperties(item.Type)
+	if props == nil || !props.IsConsumable() {
+		return fmt.Errorf("%s cannot be eaten or drunk", itemID)
+	}
+	state.Survival.Consume(item.Type)
+	inventory.UseItem()
+	if leftover, ok := items.ItemTypeByID(props.Leaves); ok {
+		s.giveItem(state, leftover)
+	}
+	return nil
+}
+
+// Anvil enchants, combines or repairs an item for a player at an anvil
+// within their reach. XP is earned and spent on clients, which run the kills
+// that award it, so only the materials are taken here.
+func (s *Simulation) Anvil(name string, work AnvilWork) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	state, ok := s.players[name]
+	if !ok {
+		return fmt.Errorf("player %s is not in the world", name)
+	}
+	if !s.nearBlock(state, "anvil") {
+		return fmt.Errorf("no anvil within reach")
+	}
+	slots := state.Inventory.Slots
+	if work.Slot < 0 || work.Slot >= len(slots) || work.Second >= len(slots) || work.Second == work.Slot {
+		return fmt.Errorf("bad anvil slots %d and %d", work.Slot, work.Second)
+	}
+
+	target := slots[work.Slot]
+	combine := work.Second >= 0 && slots[work.Second].Type == target.Type
+	var (
+		result items.Item
+		cost   enchant.Cost
+		err    error
+	)
+	switch {
+	case work.Second < 0:
+		result, cost, err = enchant.EnchantItem(target, work.Enchantment, work.Level)
+	case combine:
+		result, cost, err = enchant.Combine(target, slots[work.Second])
+	default:
+		second := slots[work.Second]
+		if material, ok := enchant.RepairMaterial(target.Type); !ok || material != second.Type {
+			return fmt.Errorf("%s does not repair %s", second.DisplayName(), target.DisplayName())
+		}
+		result, cost, err = enchant.Repair(target, second.Quantity)
+	}
+	if err != nil {
+		return err
+	}
+	if !cost.Take(state.Inventory) {
+		return fmt.Errorf("not enough materials: needs %s", cost.String())
+	}
+
+	if combine {
+		slots[work.Second] = items.Item{Type: items.NONE, Quantity: 0, Durability: -1}
+	}
+	slots[work.Slot] = result
+	return nil
+}
+
+// giveItem puts one item in a player's inventory, or drops it at them when
+// there is no room; callers must hold the mutex
+func (s *Simulation) giveItem(state *PlayerState, itemType items.ItemType) {
+	if state.Inventory.AddItem(itemType, 1) {
+		return
+	}
+	durability := -1
+	if props := items.GetItemProperties(itemType); props != nil {
+		durability = props.Durability
+	}
+	s.dropAtPlayer(state, items.Item{Type: itemType, Quantity: 1, Durability: durability})
+}
+
+// OpenContainer returns what is in the chest or station at a position, which
+// must be within the player's reach
+func (s *Simulation) OpenContainer(name, kind string, x, y float64) (*Container, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	state, ok := s.players[name]
+	if !ok {
+		return nil, fmt.Errorf("player %s is not in the world", name)
+	}
+	if !s.inReach(state, x, y) {
+		return nil, fmt.Errorf("%s at (%.0f, %.0f) is out of reach", kind, x, y)
+	}
+	container := s.ReadContainer(kind, x, y)
+	if container == nil {
+		return nil, fmt.Errorf("no %s at (%.0f, %.0f)", kind, x, y)
+	}
+	return container, nil
+}
+
+// ReadContainer returns a copy of what is in the chest or station at a
+// position, or nil if there is none. It does not lock the simulation, so it
+// may be called from OnTick or WithLock.
+func (s *Simulation) ReadContainer(kind string, x, y float64) *Container {
+	if kind == ContainerChest {
+		hex := s.World.GetHexagonAt(x, y)
+		if hex == nil || hex.BlockType != blocks.CHEST {
+			return nil
+		}
+		contents := s.Chests.GetChestContents(x, y)
+		for i := range contents {
+			contents[i].Meta = contents[i].Meta.Clone()
+		}
+		return &Container{Kind: kind, X: x, Y: y, Slots: contents}
+	}
+
+	st := s.World.StationAt(x, y)
+	if st == nil || st.Kind != kind {
+		return nil
+	}
+	container := &Container{
+		Kind:      kind,
+		X:         x,
+		Y:         y,
+		Slots:     make([]items.Item, len(st.Slots)),
+		Burn:      st.Burn,
+		BurnTotal: st.BurnTotal,
+		Progress:  st.Progress,
+	}
+	for i, item := range st.Slots {
+		item.Meta = item.Meta.Clone()
+		container.Slots[i] = item
+	}
+	return container
+}
+
+// SyncInventory takes a player's rearranged inventory, and the open chest or
+// station they moved items to and from, along with the stacks they dropped.
+// Outside creative mode the change must neither create nor destroy items, so
+// it is rejected unless the same items are in it as the server holds.
+func (s *Simulation) SyncInventory(name string, slots []items.Item, container *Container, dropped []items.Item) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	state, ok := s.players[name]
+	if !ok {
+		return fmt.Errorf("player %s is not in the world", name)
+	}
+	if len(slots) != len(state.Inventory.Slots) {
+		return fmt.Errorf("inventory has %d slots, not %d", len(state.Inventory.Slots), len(slots))
+	}
+	for _, item := range append(append([]items.Item(nil), slots...), dropped...) {
+		if err := checkStack(item); err != nil {
+			return err
+		}
+	}
+
+	before := append([]items.Item(nil), state.Inventory.Slots...)
+	after := append(append([]items.Item(nil), slots...), dropped...)
+
+	var st *station.Station
+	if container != nil {
+		if !s.inReach(state, container.X, container.Y) {
+			return fmt.Errorf("%s at (%.0f, %.0f) is out of reach", container.Kind, container.X, container.Y)
+		}
+		current := s.ReadContainer(container.Kind, container.X, container.Y)
+		if current == nil {
+			return fmt.Errorf("no %s at (%.0f, %.0f)", container.Kind, container.X, container.Y)
+		}
+		if len(container.Slots) != len(current.Slots) {
+			return fmt.Errorf("%s has %d slots, not %d", container.Kind, len(current.Slots), len(container.Slots))
+		}
+		for _, item := range container.Slots {
+			if err := checkStack(item); err != nil {
+				return err
+			}
+		}
+		if container.Kind != ContainerChest {
+			st = s.World.StationAt(container.X, container.Y)
+			if err := checkStationSlots(st, container.Slots); err != nil {
+				return err
+			}
+		}
+		before = append(before, current.Slots...)
+		after = append(after, container.Slots...)
+	}
+
+	if state.Survival.Mode != survival.ModeCreative && !conserved(before, after) {
+		return fmt.Errorf("inventory change would create or destroy items")
+	}
+
+	for i, item := range slots {
+		item.Meta = item.Meta.Clone()
+		state.Inventory.Slots[i] = item
+	}
+	if container != nil {
+		contents := make([]items.Item, len(container.Slots))
+		for i, item := range container.Slots {
+			item.Meta = item.Meta.Clone()
+			contents[i] = item
+		}
+		if st != nil {
+			st.SetContents(contents)
+		} else {
+			s.Chests.SetChestContents(container.X, container.Y, contents)
+		}
+	}
+	for _, item := range dropped {
+		s.dropAtPlayer(state, item)
+	}
+	return nil
+}
+
+// checkStack rejects stacks that cannot exist, such as ones over the stack size
+func checkStack(item items.Item) error {
+	if item.Type == items.NONE {
+		return nil
+	}
+	props := items.GetItemProperties(item.Type)
+	if props == nil {
+		return fmt.Errorf("unknown item %s", items.ItemID(item.Type))
+	}
+	if item.Quantity <= 0 || item.Quantity > max(props.StackSize, 1) {
+		return fmt.Errorf("stack of %d %s", item.Quantity, items.ItemID(item.Type))
+	}
+	return nil
+}
+
+// checkStationSlots rejects station contents the player could not have made:
+// only input and fuel the station accepts may be put in, and only taken out
+// of the output
+func checkStationSlots(st *station.Station, slots []items.Item) error {
+	for i, item := range slots {
+		current := st.Slots[i]
+		if item.Type == items.NONE || (sameStack(item, current) && item.Quantity <= current.Quantity) {
+			continue
+		}
+		if i == station.SlotOutput || !st.Accepts(i, item) {
+			return fmt.Errorf("%s cannot go in slot %d of the %s", items.ItemID(item.Type), i, st.Kind)
+		}
+	}
+	return nil
+}
+
+// conserved reports whether two lists of stacks hold the same items, however
+// they are split between slots
+func conserved(before, after []items.Item) bool {
+	type total struct {
+		item  items.Item
+		count int
+	}
+	var totals []total
+	add := func(item items.Item, sign int) {
+		if item.Type == items.NONE || item.Quantity <= 0 {
+			return
+		}
+		for i := range totals {
+			if sameStack(totals[i].item, item) {
+				totals[i].count += sign * item.Quantity
+				return
+			}
+		}
+		totals = append(totals, total{item: item, count: sign * item.Quantity})
+	}
+	for _, item := range before {
+		add(item, 1)
+	}
+	for _, item := range after {
+		add(item, -1)
+	}
+	for _, t := range totals {
+		if t.count != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// inReach reports whether a position is within a player's reach, with some
+// slack for movement the server has not seen yet; callers must hold the mutex
+func (s *Simulation) inReach(state *PlayerState, x, y float64) bool {
+	centerX, centerY := state.Player.GetCenter()
+	reach := state.Player.GetMiningRange() * 1.5
+	dx, dy := x-centerX, y-centerY
+	return dx*dx+dy*dy <= reach*reach
+}
+
+// nearBlock reports whether a block with an ID is within a player's reach;
+// callers must hold the mutex
+func (s *Simulation) nearBlock(state *PlayerState, blockID string) bool {
+	centerX, centerY := state.Player.GetCenter()
+	for _, hex := range s.World.GetNearbyHexagons(centerX, centerY, state.Player.GetMiningRange()*1.5) {
+		if blocks.BlockID(hex.BlockType) == blockID {
+			return true
+		}
+	}
+	return false
+}
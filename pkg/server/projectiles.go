@@ -0,0 +1,136 @@
+package server
+
+import (
+	"fmt"
+	"time"
+
+	"tesselbox/pkg/blocks"
+	"tesselbox/pkg/combat"
+	"tesselbox/pkg/items"
+	"tesselbox/pkg/mobs"
+)
+
+// Fire launches a shot from a player's weapon, or throws the item when throw
+// is set, towards a target point. The weapon must be in the server's copy of
+// their inventory, and the shot's ammo, durability or thrown item is used up
+// there; hits are applied by Tick.
+func (s *Simulation) Fire(name, weapon string, throw bool, targetX, targetY float64) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	state, ok := s.players[name]
+	if !ok {
+		return fmt.Errorf("player %s is not in the world", name)
+	}
+
+	slot := -1
+	for i, item := range state.Inventory.Slots {
+		if item.Quantity > 0 && items.ItemID(item.Type) == weapon {
+			slot = i
+			break
+		}
+	}
+	if slot < 0 {
+		return fmt.Errorf("no %s to fire", weapon)
+	}
+	item := state.Inventory.Slots[slot]
+	shot, ok := combat.ShotFor(item, throw)
+	if !ok {
+		return fmt.Errorf("%s cannot be fired", weapon)
+	}
+
+	now := time.Now()
+	if now.Sub(state.lastFire) < combat.FireCooldown(shot.Rate) {
+		return fmt.Errorf("%s is not ready to fire again", weapon)
+	}
+
+	switch {
+	case shot.Ammo != items.NONE:
+		if !state.Inventory.RemoveItemType(shot.Ammo, 1) {
+			return fmt.Errorf("out of %s", items.ItemID(shot.Ammo))
+		}
+	case shot.Kind == combat.ProjectileSpell:
+		state.Inventory.WearSlot(slot)
+	case shot.Kind == combat.ProjectileThrown:
+		state.Inventory.RemoveItemType(item.Type, 1)
+	}
+	state.lastFire = now
+
+	x, y := state.Player.GetCenter()
+	projectile := s.Projectiles.Fire(shot.Kind, combat.PlayerTargetID(name), x, y, targetX, targetY, shot.Damage)
+	projectile.Weapon = shot.Weapon
+	projectile.CritBonus = shot.CritBonus
+	if shot.Kind == combat.ProjectileThrown {
+		projectile.Item = shot.Weapon
+	}
+	return nil
+}
+
+// updateProjectiles moves projectiles and applies their hits to mobs and
+// players; callers must hold the mutex
+func (s *Simulation) updateProjectiles(deltaTime float64, players []*PlayerState) {
+	if len(s.Projectiles.Projectiles) == 0 {
+		return
+	}
+
+	byID := make(map[string]*PlayerState, len(players))
+	var targets []combat.ProjectileTarget
+	for _, state := range players {
+		id := combat.PlayerTargetID(state.Name)
+		byID[id] = state
+		targets = append(targets, combat.ProjectileTarget{
+			ID:     id,
+			Kind:   "player",
+			X:      state.Player.X,
+			Y:      state.Player.Y,
+			Width:  state.Player.Width,
+			Height: state.Player.Height,
+			Health: state.Player.Health,
+		})
+	}
+	mobsByID := make(map[string]*mobs.Mob)
+	for _, mob := range append(append([]*mobs.Mob{}, s.Zombies.Mobs...), s.World.Creatures...) {
+		if !mob.IsAlive() {
+			continue
+		}
+		mobsByID[mob.ID] = mob
+		targets = append(targets, combat.ProjectileTarget{
+			ID:     mob.ID,
+			Kind:   "mob",
+			X:      mob.X,
+			Y:      mob.Y,
+			Width:  mob.Width,
+			Height: mob.Height,
+			Health: mob.Health,
+		})
+	}
+
+	// Thrown items that land are used up; the server keeps no dropped items
+	impacts := s.Projectiles.Update(deltaTime, targets, s.isSolidAt)
+	for _, impact := range impacts {
+		if !impact.HitTarget {
+			continue
+		}
+		if victim, ok := byID[impact.Target.ID]; ok {
+			// Armor, and its enchantments, soak up part of the hit
+			impact.Result.Damage *= 1 - victim.Equipment.GetDamageReduction()
+			victim.Player.TakeDamage(impact.Result.Damage)
+			if s.OnPlayerHit != nil {
+				s.OnPlayerHit(victim, impact)
+			}
+		} else if mob, ok := mobsByID[impact.Target.ID]; ok {
+			mob.TakeDamage(impact.Result.Damage)
+		}
+	}
+}
+
+// isSolidAt reports whether a world position is inside a solid block;
+// callers must hold the mutex
+func (s *Simulation) isSolidAt(x, y float64) bool {
+	hex := s.World.GetHexagonAt(x, y)
+	if hex == nil {
+		return false
+	}
+	def := blocks.BlockDefinitions[blocks.BlockID(hex.BlockType)]
+	return def != nil && def.Solid
+}
@@ -0,0 +1,609 @@
+// Package server implements the headless world simulation used by dedicated
+// servers. Nothing in this package may import Ebiten or other graphics code.
+package server
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"tesselbox/pkg/blocks"
+	"tesselbox/pkg/chest"
+	"tesselbox/pkg/combat"
+	"tesselbox/pkg/config"
+	"tesselbox/pkg/crafting"
+	"tesselbox/pkg/dungeons"
+	"tesselbox/pkg/equipment"
+	"tesselbox/pkg/gametime"
+	"tesselbox/pkg/items"
+	"tesselbox/pkg/mobs"
+	"tesselbox/pkg/player"
+	"tesselbox/pkg/save"
+	"tesselbox/pkg/survival"
+	"tesselbox/pkg/village"
+	"tesselbox/pkg/weather"
+	"tesselbox/pkg/world"
+)
+
+const (
+	// DefaultTickRate is the number of simulation ticks per second
+	DefaultTickRate = 20
+	// DefaultAutoSaveInterval is how often a running server saves the world
+	DefaultAutoSaveInterval = 5 * time.Minute
+	// DayLengthSeconds matches the client's day/night cycle length
+	DayLengthSeconds = 600.0
+	// collisionRadius is the area searched for solid blocks around a player
+	collisionRadius = 300.0
+	// moveTolerance scales the fastest speeds of player physics when checking
+	// a client's move, to absorb network jitter and knockback
+	moveTolerance = 1.5
+	// moveSlack is the distance, in pixels, any move may cover on top of that
+	moveSlack = 2 * player.PlayerHeight
+	// maxMoveInterval caps the time a move is checked over; clients report
+	// every tick, so a longer gap earns no extra distance
+	maxMoveInterval = time.Second
+)
+
+// PlayerState is a player simulated by the server
+type PlayerState struct {
+	Name      string
+	Player    *player.Player
+	Inventory *items.Inventory
+	Survival  *survival.SurvivalManager
+	Equipment *equipment.EquipmentSet // Armor reported by their client
+
+	saveManager *save.SaveManager
+	lastMove    time.Time // When the last move from the client was accepted
+	lastFire    time.Time // When the player last fired or threw something
+}
+
+// Simulation ticks a world and its systems at a fixed rate without rendering
+type Simulation struct {
+	WorldName string
+	World     *world.World
+	DayNight  *gametime.DayNightCycle
+	Weather   *weather.WeatherSystem
+	Zombies   *mobs.Spawner
+	Chests    *chest.ChestManager
+	Villages  *village.VillageManager
+	Dungeons  *dungeons.DungeonManager
+
+	// Projectiles are fired by players through Fire
+	Projectiles *combat.ProjectileSystem
+	// Recipes checks crafts players make. Which recipes a player knows is
+	// up to their client, so all of them are unlocked.
+	Recipes *crafting.CraftingSystem
+	// Drops are the items lying in the world, by ID
+	Drops map[uint64]*DroppedItem
+
+	TickRate         int
+	AutoSaveInterval time.Duration
+	TickCount        uint64
+
+	// OnTick runs at the end of every tick with the simulation locked, so it
+	// may read the world directly but must not call locking methods
+	OnTick func(tick uint64, players []*PlayerState)
+	// OnPlayerHit runs with the simulation locked when a projectile hits a
+	// player, after the server has applied the damage
+	OnPlayerHit func(victim *PlayerState, impact combat.ProjectileImpact)
+
+	mutex      sync.Mutex
+	players    map[string]*PlayerState
+	nextDropID uint64
+}
+
+// NewSimulation loads a world, or creates it if it does not exist yet
+func NewSimulation(worldName string) (*Simulation, error) {
+	// Load block definitions before any world generation
+	if len(blocks.BlockDefinitions) == 0 {
+		blocks.LoadBlocks()
+	}
+
+	var gameWorld *world.World
+	if world.WorldExists(worldName) {
+		loaded, err := world.NewWorldFromStorage(worldName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load world %s: %w", worldName, err)
+		}
+		gameWorld = loaded
+	} else {
+		gameWorld = world.NewWorld(worldName)
+	}
+
+	dayNight := gametime.NewDayNightCycle(DayLengthSeconds)
+	sim := &Simulation{
+		WorldName:        worldName,
+		World:            gameWorld,
+		DayNight:         dayNight,
+		Weather:          weather.NewWeatherSystem(),
+		Zombies:          mobs.NewSpawner(dayNight),
+		Chests:           chest.NewChestManager(worldName),
+		Villages:         village.NewVillageManager(config.GetWorldSaveDir(worldName)),
+		Dungeons:         dungeons.NewDungeonManager(),
+		Projectiles:      combat.NewProjectileSystem(),
+		Recipes:          crafting.NewCraftingSystem(),
+		Drops:            make(map[uint64]*DroppedItem),
+		TickRate:         DefaultTickRate,
+		AutoSaveInterval: DefaultAutoSaveInterval,
+		players:          make(map[string]*PlayerState),
+	}
+
+	if err := sim.Recipes.LoadRecipesFromAssets(); err != nil {
+		return nil, fmt.Errorf("failed to load recipes: %w", err)
+	}
+	var recipeIDs []string
+	for _, recipe := range sim.Recipes.GetAllRecipes() {
+		recipeIDs = append(recipeIDs, recipe.ID)
+	}
+	sim.Recipes.SetUnlockedRecipes(recipeIDs)
+
+	if err := sim.Chests.LoadChests(); err != nil {
+		log.Printf("Warning: Failed to load chests for world %s: %v", worldName, err)
+	}
+	if err := sim.Villages.Load(); err != nil {
+		log.Printf("Warning: Failed to load villages for world %s: %v", worldName, err)
+	}
+	sim.Zombies.LightAt = gameWorld.LightLevelAt
+	sim.Zombies.Climbable = gameWorld.BoxClimbable
+	sim.Zombies.Navigator = gameWorld.Paths
+
+	return sim, nil
+}
+
+// AddPlayer loads a player's save, or spawns them fresh, and starts simulating them
+func (s *Simulation) AddPlayer(name string) (*PlayerState, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if state, ok := s.players[name]; ok {
+		return state, nil
+	}
+
+	spawnX, spawnY := s.World.FindSpawnPosition(0, 0)
+	p := player.NewPlayer(spawnX, spawnY)
+	inventory := items.NewInventory(32)
+	state := &PlayerState{
+		Name:        name,
+		Player:      p,
+		Inventory:   inventory,
+		Survival:    survival.NewSurvivalManager(survival.ModeSurvival, p, inventory),
+		Equipment:   equipment.NewEquipmentSet(),
+		saveManager: save.NewSaveManager(s.WorldName, name),
+		lastMove:    time.Now(),
+	}
+
+	saveData, err := state.saveManager.LoadGame()
+	if err == nil {
+		gameState := s.gameState(state)
+		if err := state.saveManager.ApplySaveData(saveData, gameState); err != nil {
+			return nil, fmt.Errorf("failed to apply save for player %s: %w", name, err)
+		}
+		if gameState.PlayerMaxHealth > 0 {
+			p.Health = gameState.PlayerHealth
+			p.MaxHealth = gameState.PlayerMaxHealth
+		}
+		if saveData.WorldTime > 0 && len(s.players) == 0 {
+			s.DayNight.SetTime(saveData.WorldTime)
+		}
+		if saveData.GameMode == "creative" {
+			state.Survival.SetGameMode(survival.ModeCreative)
+		}
+	} else {
+		log.Printf("No save for player %s in world %s, spawning at (%.0f, %.0f)", name, s.WorldName, spawnX, spawnY)
+	}
+
+	s.players[name] = state
+	return state, nil
+}
+
+// SetArmor replaces the armor a player wears, keeping their wings. Its
+// defense reduces the damage of hits on them.
+func (s *Simulation) SetArmor(name string, armor []*equipment.EquipmentItem) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	state, ok := s.players[name]
+	if !ok {
+		return fmt.Errorf("player %s is not in the world", name)
+	}
+	for slot := range equipment.SlotCount {
+		if slot != equipment.SlotWings {
+			state.Equipment.UnequipItem(slot)
+		}
+	}
+	for _, piece := range armor {
+		if piece.Slot != equipment.SlotWings {
+			state.Equipment.EquipItem(piece, piece.Slot)
+		}
+	}
+	return nil
+}
+
+// RemovePlayer saves a player and stops simulating them
+func (s *Simulation) RemovePlayer(name string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	state, ok := s.players[name]
+	if !ok {
+		return nil
+	}
+	delete(s.players, name)
+	return s.savePlayer(state)
+}
+
+// Players returns the simulated players sorted by name
+func (s *Simulation) Players() []*PlayerState {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.sortedPlayers()
+}
+
+// sortedPlayers returns players in a stable order; callers must hold the mutex
+func (s *Simulation) sortedPlayers() []*PlayerState {
+	players := make([]*PlayerState, 0, len(s.players))
+	for _, state := range s.players {
+		players = append(players, state)
+	}
+	sort.Slice(players, func(i, j int) bool {
+		return players[i].Name < players[j].Name
+	})
+	return players
+}
+
+// Tick advances the simulation by deltaTime seconds, in the same order the
+// client updates its systems
+func (s *Simulation) Tick(deltaTime float64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.TickCount++
+	s.DayNight.Update()
+
+	players := s.sortedPlayers()
+	anchors := make([][2]float64, 0, len(players))
+	for _, state := range players {
+		state.Survival.Update(deltaTime)
+
+		centerX, centerY := state.Player.GetCenter()
+		s.World.GetChunksInRange(centerX, centerY)
+		anchors = append(anchors, [2]float64{centerX, centerY})
+	}
+
+	// Zombies and creatures chase the first player; with nobody online they idle
+	if len(players) > 0 {
+		target := players[0].Player
+		collision := s.collisionFunc(target.X, target.Y)
+		spawn := func(x, y float64) (float64, float64) {
+			return s.World.FindSpawnPosition(x, y)
+		}
+		s.Zombies.Update(deltaTime, target, s.DayNight.AmbientLight, collision, spawn)
+
+		centerX, centerY := target.GetCenter()
+		s.World.SpawnCreatures(s.DayNight, centerX, centerY)
+		s.World.UpdateCreatures(&mobs.Context{
+			Target:       target,
+			AmbientLight: s.DayNight.AmbientLight,
+			Collides:     collision,
+			Climbable:    s.World.BoxClimbable,
+			Navigator:    s.World.Paths,
+		}, deltaTime)
+		s.World.RemoveDeadCreatures()
+	}
+	s.World.Paths.Update(deltaTime)
+	s.updateProjectiles(deltaTime, players)
+
+	s.World.UpdateBlocks(deltaTime)
+	s.dropBrokenFallingBlocks()
+	s.World.UpdateLiquids(deltaTime)
+	s.World.UpdateStations(deltaTime)
+	s.dropRemovedStations()
+	s.World.LinkStructures(s.World.TakeGeneratedStructures(), s.Villages, s.Dungeons, s.Chests)
+
+	s.expireDrops(time.Now())
+
+	// No screen on the server, so weather particles have nothing to fill
+	s.Weather.Update(deltaTime, 0, 0)
+
+	s.World.UnloadChunksFarFrom(anchors)
+
+	if s.OnTick != nil {
+		s.OnTick(s.TickCount, players)
+	}
+}
+
+// dropRemovedStations drops what was in stations whose blocks were removed;
+// callers must hold the mutex
+func (s *Simulation) dropRemovedStations() {
+	for _, st := range s.World.TakeRemovedStations() {
+		for _, item := range st.Contents() {
+			s.dropItem(item, st.X, st.Y-10)
+		}
+	}
+}
+
+// dropBrokenFallingBlocks drops falling blocks that had nowhere to land as
+// items; callers must hold the mutex
+func (s *Simulation) dropBrokenFallingBlocks() {
+	for _, fb := range s.World.TakeBrokenFallingBlocks() {
+		s.dropItem(items.Item{Type: items.BlockDrop(fb.BlockType), Quantity: 1, Durability: -1}, fb.X, fb.Y)
+	}
+}
+
+// WithLock runs fn with the simulation locked, for reading state between ticks
+func (s *Simulation) WithLock(fn func()) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	fn()
+}
+
+// MovePlayer sets a player's position and velocity as reported by their
+// client. A move faster than player physics allows is rejected, and the
+// player keeps the position the server holds.
+func (s *Simulation) MovePlayer(name string, x, y, vx, vy float64) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	state, ok := s.players[name]
+	if !ok {
+		return fmt.Errorf("player %s is not in the world", name)
+	}
+
+	maxVX := player.TerminalVelX * moveTolerance
+	maxVY := player.TerminalVelY * moveTolerance
+	if math.Abs(vx) > maxVX || math.Abs(vy) > maxVY {
+		return fmt.Errorf("velocity (%.0f, %.0f) is faster than a player can move", vx, vy)
+	}
+
+	now := time.Now()
+	elapsed := min(now.Sub(state.lastMove), maxMoveInterval).Seconds()
+	dx, dy := math.Abs(x-state.Player.X), math.Abs(y-state.Player.Y)
+	if dx > maxVX*elapsed+moveSlack || dy > maxVY*elapsed+moveSlack {
+		return fmt.Errorf("moved (%.0f, %.0f) in %.2fs, farther than a player can", dx, dy, elapsed)
+	}
+
+	state.lastMove = now
+	state.Player.SetPosition(x, y)
+	state.Player.SetVelocity(vx, vy)
+	return nil
+}
+
+// RespawnPlayer moves a player back to the world spawn with full health and
+// returns where they are now
+func (s *Simulation) RespawnPlayer(name string) (float64, float64, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	state, ok := s.players[name]
+	if !ok {
+		return 0, 0, fmt.Errorf("player %s is not in the world", name)
+	}
+	x, y := s.World.FindSpawnPosition(0, 0)
+	state.Player.SetPosition(x, y)
+	state.Player.SetVelocity(0, 0)
+	state.Player.Health = state.Player.MaxHealth
+	state.lastMove = time.Now()
+	return x, y, nil
+}
+
+// PlayerPosition returns the position and velocity the server holds for a player
+func (s *Simulation) PlayerPosition(name string) (x, y, vx, vy float64, ok bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	state, ok := s.players[name]
+	if !ok {
+		return 0, 0, 0, 0, false
+	}
+	return state.Player.X, state.Player.Y, state.Player.VX, state.Player.VY, true
+}
+
+// BlockAt returns the block at a position, or AIR when the cell is empty
+func (s *Simulation) BlockAt(x, y float64) blocks.BlockType {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	chunkX, chunkY := s.World.GetChunkCoords(x, y)
+	if hex := s.World.GetChunk(chunkX, chunkY).GetHexagonDirect(x, y); hex != nil {
+		return hex.BlockType
+	}
+	return blocks.AIR
+}
+
+// Inventory returns a copy of a player's inventory slots
+func (s *Simulation) Inventory(name string) ([]items.Item, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	state, ok := s.players[name]
+	if !ok {
+		return nil, false
+	}
+	slots := make([]items.Item, len(state.Inventory.Slots))
+	for i, slot := range state.Inventory.Slots {
+		slot.Meta = slot.Meta.Clone()
+		slots[i] = slot
+	}
+	return slots, true
+}
+
+// SetBlock places a block, or removes one when blockType is AIR, on behalf of
+// a player. The block must be within the player's reach. Outside creative
+// mode placing takes a matching item from the player's inventory, and
+// removing gives them the block's drop.
+func (s *Simulation) SetBlock(name string, x, y float64, blockType blocks.BlockType) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	state, ok := s.players[name]
+	if !ok {
+		return fmt.Errorf("player %s is not in the world", name)
+	}
+	if !s.inReach(state, x, y) {
+		return fmt.Errorf("block at (%.0f, %.0f) is out of reach", x, y)
+	}
+
+	creative := state.Survival.Mode == survival.ModeCreative
+	chunkX, chunkY := s.World.GetChunkCoords(x, y)
+	existing := s.World.GetChunk(chunkX, chunkY).GetHexagonDirect(x, y)
+
+	if blockType == blocks.AIR {
+		if existing == nil {
+			return fmt.Errorf("no block at (%.0f, %.0f)", x, y)
+		}
+		minedType := existing.BlockType
+		s.World.RemoveHexagonAt(x, y)
+		if drop := items.BlockDrop(minedType); !creative && drop != items.NONE {
+			if !state.Inventory.AddItem(drop, 1) {
+				s.dropItem(items.Item{Type: drop, Quantity: 1, Durability: -1}, x, y)
+			}
+		}
+		return nil
+	}
+
+	if existing != nil {
+		return fmt.Errorf("block at (%.0f, %.0f) is already taken", x, y)
+	}
+	if !creative && !takeBlockItem(state.Inventory, blockType) {
+		return fmt.Errorf("no %s to place", blocks.BlockID(blockType))
+	}
+	s.World.AddHexagonAt(x, y, blockType)
+	return nil
+}
+
+// takeBlockItem removes one item that places blockType from an inventory,
+// reporting whether there was one
+func takeBlockItem(inventory *items.Inventory, blockType blocks.BlockType) bool {
+	for _, slot := range inventory.Slots {
+		if slot.Quantity > 0 && items.PlacesBlock(slot.Type, blockType) {
+			return inventory.RemoveItemType(slot.Type, 1)
+		}
+	}
+	return false
+}
+
+// collisionFunc returns a bounding-box test against solid blocks near a position
+func (s *Simulation) collisionFunc(x, y float64) func(minX, minY, maxX, maxY float64) bool {
+	nearbyHexagons := s.World.GetNearbyHexagons(x, y, collisionRadius)
+	return func(minX, minY, maxX, maxY float64) bool {
+		for _, hex := range nearbyHexagons {
+			if hex == nil {
+				continue
+			}
+			def := blocks.BlockDefinitions[blocks.BlockID(hex.BlockType)]
+			if def == nil || !def.Solid {
+				continue
+			}
+			hexMinX := hex.X - hex.Size
+			hexMinY := hex.Y - hex.Size
+			hexMaxX := hex.X + hex.Size
+			hexMaxY := hex.Y + hex.Size
+			if !(maxX < hexMinX || minX > hexMaxX || maxY < hexMinY || minY > hexMaxY) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Run ticks the simulation at TickRate until stop is closed, autosaving
+// periodically and saving once more before it returns
+func (s *Simulation) Run(stop <-chan struct{}) error {
+	tickRate := s.TickRate
+	if tickRate <= 0 {
+		tickRate = DefaultTickRate
+	}
+	tickInterval := time.Second / time.Duration(tickRate)
+	ticker := time.NewTicker(tickInterval)
+	defer ticker.Stop()
+
+	var autoSave <-chan time.Time
+	if s.AutoSaveInterval > 0 {
+		autoSaveTicker := time.NewTicker(s.AutoSaveInterval)
+		defer autoSaveTicker.Stop()
+		autoSave = autoSaveTicker.C
+	}
+
+	lastTick := time.Now()
+	for {
+		select {
+		case <-stop:
+			return s.Save()
+		case now := <-ticker.C:
+			deltaTime := now.Sub(lastTick).Seconds()
+			lastTick = now
+			// Cap catch-up after a stall so systems never see a huge step
+			if maxDelta := 5 * tickInterval.Seconds(); deltaTime > maxDelta {
+				deltaTime = maxDelta
+			}
+			s.Tick(deltaTime)
+		case <-autoSave:
+			if err := s.Save(); err != nil {
+				log.Printf("Auto-save failed: %v", err)
+			}
+		}
+	}
+}
+
+// Save writes the world, chests, villages and every simulated player to disk
+func (s *Simulation) Save() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.World.Storage == nil {
+		s.World.Storage = world.NewWorldStorage(s.WorldName)
+	}
+	if err := s.World.SaveWorld(); err != nil {
+		return fmt.Errorf("failed to save world: %w", err)
+	}
+
+	if err := s.Chests.SaveChests(); err != nil {
+		return fmt.Errorf("failed to save chests: %w", err)
+	}
+	if err := s.Villages.Save(); err != nil {
+		return fmt.Errorf("failed to save villages: %w", err)
+	}
+
+	for _, state := range s.sortedPlayers() {
+		if err := s.savePlayer(state); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// savePlayer writes one player's save file; callers must hold the mutex
+func (s *Simulation) savePlayer(state *PlayerState) error {
+	if err := state.saveManager.SaveGame(s.gameState(state)); err != nil {
+		return fmt.Errorf("failed to save player %s: %w", state.Name, err)
+	}
+	return nil
+}
+
+// gameState builds the save system's view of a player in this simulation
+func (s *Simulation) gameState(state *PlayerState) *save.GameState {
+	gameMode := "survival"
+	if state.Survival.Mode == survival.ModeCreative {
+		gameMode = "creative"
+	}
+
+	return &save.GameState{
+		World:            s.World,
+		Player:           state.Player,
+		Inventory:        state.Inventory,
+		InGame:           true,
+		CreativeMode:     gameMode == "creative",
+		GameMode:         gameMode,
+		WorldTime:        s.DayNight.GameTime,
+		Weather:          s.Weather.GetCurrentWeather(),
+		CurrentDimension: "overworld",
+		PlayerHealth:     state.Player.Health,
+		PlayerMaxHealth:  state.Player.MaxHealth,
+		SurvivalManager:  state.Survival,
+		EquipmentSet:     state.Equipment,
+		// Zombies are shared by all players, so they are not stored per player
+	}
+}
@@ -57,6 +57,7 @@ func DefaultSettings() *Settings {
 			"crafting":   "C",
 			"menu":       "Escape",
 			"drop":       "Q",
+			"throw":      "G",
 		},
 		WindowWidth:  1280,
 		WindowHeight: 720,
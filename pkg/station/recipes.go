@@ -0,0 +1,117 @@
+package station
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"tesselbox/pkg/items"
+)
+
+// Recipe is a batch a station turns from its input into its output over time
+type Recipe struct {
+	ID             string
+	Kind           string // Kind of station that processes it, such as KindFurnace
+	Input          items.ItemType
+	InputQuantity  int
+	Output         items.ItemType
+	OutputQuantity int
+	Time           float64 // Seconds per batch
+}
+
+// normalize fills in defaults for fields a recipe left out
+func (r *Recipe) normalize() {
+	if r.InputQuantity <= 0 {
+		r.InputQuantity = 1
+	}
+	if r.OutputQuantity <= 0 {
+		r.OutputQuantity = 1
+	}
+	if r.Time <= 0 {
+		r.Time = DefaultTime
+	}
+}
+
+// validate reports what is wrong with a recipe, if anything
+func (r *Recipe) validate() error {
+	if r.ID == "" {
+		return fmt.Errorf("station recipe has no ID")
+	}
+	if r.Kind == "" {
+		return fmt.Errorf("station recipe %s has no station kind", r.ID)
+	}
+	if r.Input == items.NONE || r.Output == items.NONE {
+		return fmt.Errorf("station recipe %s needs an input and an output", r.ID)
+	}
+	return nil
+}
+
+var (
+	recipes      = make(map[string][]*Recipe) // Kind -> recipes in ID order
+	recipesMutex sync.RWMutex
+)
+
+// SetRecipes replaces the recipes of a kind of station. Recipes of other
+// kinds in the list are rejected.
+func SetRecipes(kind string, list []*Recipe) error {
+	checked := make([]*Recipe, 0, len(list))
+	for _, r := range list {
+		r.normalize()
+		if err := r.validate(); err != nil {
+			return err
+		}
+		if r.Kind != kind {
+			return fmt.Errorf("station recipe %s is for %s, not %s", r.ID, r.Kind, kind)
+		}
+		checked = append(checked, r)
+	}
+	sort.Slice(checked, func(i, j int) bool { return checked[i].ID < checked[j].ID })
+
+	recipesMutex.Lock()
+	defer recipesMutex.Unlock()
+	recipes[kind] = checked
+	return nil
+}
+
+// Recipes returns the recipes of a kind of station in ID order
+func Recipes(kind string) []*Recipe {
+	recipesMutex.RLock()
+	defer recipesMutex.RUnlock()
+	return append([]*Recipe(nil), recipes[kind]...)
+}
+
+// RecipeFor returns the recipe a kind of station uses for an input stack, or
+// nil if it has none or the stack is too small. Only plain items are processed.
+func RecipeFor(kind string, input items.Item) *Recipe {
+	if input.Type == items.NONE || !input.Meta.IsEmpty() {
+		return nil
+	}
+	recipesMutex.RLock()
+	defer recipesMutex.RUnlock()
+	for _, r := range recipes[kind] {
+		if r.Input == input.Type && input.Quantity >= r.InputQuantity {
+			return r
+		}
+	}
+	return nil
+}
+
+// Processes reports whether a kind of station has a recipe for an item type
+func Processes(kind string, itemType items.ItemType) bool {
+	recipesMutex.RLock()
+	defer recipesMutex.RUnlock()
+	for _, r := range recipes[kind] {
+		if r.Input == itemType {
+			return true
+		}
+	}
+	return false
+}
+
+// FuelTime returns how many seconds an item burns for, or 0 if it is not fuel
+func FuelTime(itemType items.ItemType) float64 {
+	if props := items.GetItemProperties(itemType); props != nil {
+		return props.FuelTime
+	}
+	return 0
+}
@@ -0,0 +1,312 @@
+// Package station implements processing stations, such as furnaces: block
+// entities placed in the world that turn their input into output over time
+// while they have fuel
+package station
+
+import (
+	"math"
+	"time"
+
+	"tesselbox/pkg/items"
+)
+
+const (
+	// KindFurnace smelts and cooks
+	KindFurnace = "furnace"
+
+	// DefaultTime is how long a recipe without a time of its own takes, in seconds
+	DefaultTime = 10.0
+)
+
+// Slots of a station
+const (
+	SlotInput = iota
+	SlotFuel
+	SlotOutput
+	SlotCount
+)
+
+// Station is the state of a placed processing station. It only lights new
+// fuel when it has something to process, and keeps the output slot for what
+// it makes.
+type Station struct {
+	Kind  string
+	X, Y  float64 // World position of its block
+	Slots [SlotCount]items.Item
+
+	Burn      float64 // Seconds the lit fuel keeps burning
+	BurnTotal float64 // Seconds the lit fuel burns for in all
+	Progress  float64 // Seconds spent on the current batch
+
+	away    float64 // Seconds its chunk spent unloaded, made up on the next Update
+	changed bool    // Slots were edited since the last Update
+}
+
+// New creates an empty station of a kind at a block position
+func New(kind string, x, y float64) *Station {
+	s := &Station{Kind: kind, X: x, Y: y}
+	for i := range s.Slots {
+		s.Slots[i] = items.Item{Type: items.NONE, Quantity: 0, Durability: -1}
+	}
+	return s
+}
+
+// Burning reports whether the station has lit fuel
+func (s *Station) Burning() bool {
+	return s.Burn > 0
+}
+
+// Recipe returns the recipe for the station's input, or nil if it has none or
+// the output slot cannot take the result
+func (s *Station) Recipe() *Recipe {
+	r := RecipeFor(s.Kind, s.Slots[SlotInput])
+	if r == nil {
+		return nil
+	}
+	out := s.Slots[SlotOutput]
+	if out.Type == items.NONE {
+		return r
+	}
+	props := items.GetItemProperties(r.Output)
+	if out.Type != r.Output || !out.Meta.IsEmpty() || props == nil || out.Quantity+r.OutputQuantity > props.StackSize {
+		return nil
+	}
+	return r
+}
+
+// ProgressFraction returns how far through its batch the station is, from 0 to 1
+func (s *Station) ProgressFraction() float64 {
+	if r := s.Recipe(); r != nil {
+		return math.Min(1, s.Progress/r.Time)
+	}
+	return 0
+}
+
+// BurnFraction returns how much of the lit fuel is left, from 0 to 1
+func (s *Station) BurnFraction() float64 {
+	if s.BurnTotal <= 0 {
+		return 0
+	}
+	return math.Min(1, s.Burn/s.BurnTotal)
+}
+
+// Update advances the station by a frame and by any time its chunk spent
+// unloaded, reporting whether its state changed
+func (s *Station) Update(deltaTime float64) bool {
+	seconds := deltaTime + s.away
+	s.away = 0
+	changed := s.Advance(seconds) || s.changed
+	s.changed = false
+	return changed
+}
+
+// Advance runs the station for a number of seconds, reporting whether its
+// state changed. Batches finish and fuel is lit as they would have over that
+// time, so a long gap is made up in one call.
+func (s *Station) Advance(seconds float64) bool {
+	changed := false
+	for seconds > 0 {
+		r := s.Recipe()
+		if !s.Burning() {
+			if r == nil || !s.light() {
+				break
+			}
+		}
+
+		step := math.Min(seconds, s.Burn)
+		if r != nil {
+			step = math.Min(step, math.Max(0, r.Time-s.Progress))
+			s.Progress += step
+		} else {
+			s.Progress = 0
+		}
+		s.Burn -= step
+		seconds -= step
+		changed = true
+
+		if r != nil && s.Progress >= r.Time {
+			s.finish(r)
+		}
+	}
+	if s.Burn <= 0 {
+		s.Burn, s.BurnTotal = 0, 0
+	}
+	if s.Progress > 0 && s.Recipe() == nil {
+		s.Progress = 0
+		changed = true
+	}
+	return changed
+}
+
+// light burns one fuel item, reporting whether there was any
+func (s *Station) light() bool {
+	fuel := &s.Slots[SlotFuel]
+	burnTime := FuelTime(fuel.Type)
+	if fuel.Type == items.NONE || fuel.Quantity <= 0 || burnTime <= 0 {
+		return false
+	}
+	s.take(SlotFuel, 1)
+	s.Burn, s.BurnTotal = burnTime, burnTime
+	return true
+}
+
+// finish turns a batch of input into output
+func (s *Station) finish(r *Recipe) {
+	s.Progress = 0
+	s.take(SlotInput, r.InputQuantity)
+	out := &s.Slots[SlotOutput]
+	if out.Type == items.NONE {
+		durability := -1
+		if props := items.GetItemProperties(r.Output); props != nil {
+			durability = props.Durability
+		}
+		*out = items.Item{Type: r.Output, Quantity: 0, Durability: durability}
+	}
+	out.Quantity += r.OutputQuantity
+}
+
+// take removes items from a slot, clearing it when it runs out
+func (s *Station) take(slot, quantity int) {
+	item := &s.Slots[slot]
+	item.Quantity -= quantity
+	if item.Quantity <= 0 {
+		*item = items.Item{Type: items.NONE, Quantity: 0, Durability: -1}
+	}
+}
+
+// Accepts reports whether an item may be put in a slot: input the station
+// has a recipe for, fuel, and nothing in the output
+func (s *Station) Accepts(slot int, item items.Item) bool {
+	switch slot {
+	case SlotInput:
+		return Processes(s.Kind, item.Type)
+	case SlotFuel:
+		return FuelTime(item.Type) > 0
+	}
+	return false
+}
+
+// Insert puts as much of an item stack as fits in a slot and returns how many
+// it took. It only stacks with items the stack StacksWith.
+func (s *Station) Insert(slot int, item items.Item) int {
+	if slot < 0 || slot >= SlotCount || item.Type == items.NONE || !s.Accepts(slot, item) {
+		return 0
+	}
+	current := &s.Slots[slot]
+	if current.Type == items.NONE {
+		*current = item
+		current.Meta = item.Meta.Clone()
+		s.changed = true
+		return item.Quantity
+	}
+	props := items.GetItemProperties(item.Type)
+	if !current.StacksWith(item) || props == nil {
+		return 0
+	}
+	added := min(item.Quantity, props.StackSize-current.Quantity)
+	if added <= 0 {
+		return 0
+	}
+	current.Quantity += added
+	s.changed = true
+	return added
+}
+
+// Take removes and returns everything in a slot
+func (s *Station) Take(slot int) items.Item {
+	if slot < 0 || slot >= SlotCount {
+		return items.Item{Type: items.NONE, Quantity: 0, Durability: -1}
+	}
+	item := s.Slots[slot]
+	s.Slots[slot] = items.Item{Type: items.NONE, Quantity: 0, Durability: -1}
+	if item.Type != items.NONE {
+		s.changed = true
+	}
+	return item
+}
+
+// SetContents replaces what is in the station's slots, such as with what a
+// player left in it
+func (s *Station) SetContents(slots []items.Item) {
+	copy(s.Slots[:], slots)
+	s.changed = true
+}
+
+// Contents returns the items in the station, such as to drop when it is broken
+func (s *Station) Contents() []items.Item {
+	var contents []items.Item
+	for _, item := range s.Slots {
+		if item.Type != items.NONE && item.Quantity > 0 {
+			contents = append(contents, item)
+		}
+	}
+	return contents
+}
+
+// Record is the saved form of a station. Items are recorded by ID, and the
+// time it was saved lets it make up the time its chunk spends unloaded.
+type Record struct {
+	Kind      string       `json:"kind"`
+	Slots     []ItemRecord `json:"slots"`
+	Burn      float64      `json:"burn,omitempty"`
+	BurnTotal float64      `json:"burn_total,omitempty"`
+	Progress  float64      `json:"progress,omitempty"`
+	SavedAt   time.Time    `json:"saved_at"`
+}
+
+// ItemRecord is a saved station slot
+type ItemRecord struct {
+	ID         string          `json:"id"`
+	Quantity   int             `json:"quantity,omitempty"`
+	Durability int             `json:"durability"`
+	Meta       *items.Metadata `json:"meta,omitempty"`
+}
+
+// Record returns the saved form of the station
+func (s *Station) Record() *Record {
+	r := &Record{
+		Kind:      s.Kind,
+		Slots:     make([]ItemRecord, len(s.Slots)),
+		Burn:      s.Burn,
+		BurnTotal: s.BurnTotal,
+		Progress:  s.Progress,
+		SavedAt:   time.Now(),
+	}
+	for i, item := range s.Slots {
+		r.Slots[i] = ItemRecord{
+			ID:         items.ItemID(item.Type),
+			Quantity:   item.Quantity,
+			Durability: item.Durability,
+			Meta:       item.Meta.Clone(),
+		}
+	}
+	return r
+}
+
+// FromRecord restores a saved station at a block position. Item IDs this
+// build does not know (e.g. from a removed plugin) are registered as
+// placeholders so they survive being saved again.
+func FromRecord(r *Record, x, y float64) *Station {
+	s := New(r.Kind, x, y)
+	for i, record := range r.Slots {
+		if i >= SlotCount {
+			break
+		}
+		if record.ID == "" || record.Quantity <= 0 {
+			continue
+		}
+		itemType, ok := items.ItemTypeByID(record.ID)
+		if !ok {
+			itemType = items.RegisterItemType(record.ID)
+		}
+		if itemType == items.NONE {
+			continue
+		}
+		s.Slots[i] = items.Item{Type: itemType, Quantity: record.Quantity, Durability: record.Durability, Meta: record.Meta}
+	}
+	s.Burn, s.BurnTotal, s.Progress = r.Burn, r.BurnTotal, r.Progress
+	if !r.SavedAt.IsZero() {
+		s.away = math.Max(0, time.Since(r.SavedAt).Seconds())
+	}
+	return s
+}
@@ -0,0 +1,23 @@
+package station
+
+import "testing"
+
+func TestFromRecordKeepsUnknownItems(t *testing.T) {
+	r := &Record{
+		Kind: KindFurnace,
+		Slots: []ItemRecord{
+			{ID: "plugin:test/strange_ore", Quantity: 3, Durability: -1},
+			{ID: "coal", Quantity: 2, Durability: -1},
+			{ID: "none", Durability: -1},
+		},
+	}
+
+	s := FromRecord(r, 10, 20)
+	saved := s.Record()
+	for i, want := range r.Slots {
+		got := saved.Slots[i]
+		if got.ID != want.ID || got.Quantity != want.Quantity {
+			t.Errorf("slot %d saved as %s x%d, want %s x%d", i, got.ID, got.Quantity, want.ID, want.Quantity)
+		}
+	}
+}
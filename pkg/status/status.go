@@ -14,8 +14,37 @@ const (
 	STRENGTH_BUFF
 	SPEED_BUFF
 	DEFENSE_BUFF
+	REGENERATION
 )
 
+// effectNames maps status effects to the names used in config files
+var effectNames = map[StatusEffectType]string{
+	POISON:        "poison",
+	BLEEDING:      "bleeding",
+	STRENGTH_BUFF: "strength",
+	SPEED_BUFF:    "speed",
+	DEFENSE_BUFF:  "defense",
+	REGENERATION:  "regeneration",
+}
+
+// String returns the config name of a status effect
+func (t StatusEffectType) String() string {
+	if name, ok := effectNames[t]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// ParseEffectType returns the status effect with a config name
+func ParseEffectType(name string) (StatusEffectType, bool) {
+	for t, n := range effectNames {
+		if n == name {
+			return t, true
+		}
+	}
+	return 0, false
+}
+
 // StatusEffect represents a status effect applied to an entity
 type StatusEffect struct {
 	Type      StatusEffectType
@@ -77,6 +106,11 @@ func (sm *StatusManager) RemoveEffect(effectType StatusEffectType) {
 	sm.Effects = newEffects
 }
 
+// Clear removes all effects
+func (sm *StatusManager) Clear() {
+	sm.Effects = nil
+}
+
 // Update removes expired effects
 func (sm *StatusManager) Update() {
 	var activeEffects []StatusEffect
@@ -130,6 +164,21 @@ func (sm *StatusManager) ApplyPeriodicEffects(health *float64, maxHealth float64
 	}
 }
 
+// HealthPerSecond returns how much health periodic effects restore each
+// second; it is negative while poison or bleeding outweighs regeneration
+func (sm *StatusManager) HealthPerSecond() float64 {
+	rate := 0.0
+	for _, effect := range sm.Effects {
+		switch effect.Type {
+		case POISON, BLEEDING:
+			rate -= effect.Strength
+		case REGENERATION:
+			rate += effect.Strength
+		}
+	}
+	return rate
+}
+
 // GetDamageMultiplier returns damage multiplier from buffs
 func (sm *StatusManager) GetDamageMultiplier() float64 {
 	multiplier := 1.0
@@ -7,8 +7,12 @@ import (
 
 	"tesselbox/pkg/items"
 	"tesselbox/pkg/player"
+	"tesselbox/pkg/status"
 )
 
+// WaterSipHydration is how much thirst a sip straight from water restores
+const WaterSipHydration = 10.0
+
 // GameMode represents the current game mode
 type GameMode int
 
@@ -31,6 +35,8 @@ type SurvivalManager struct {
 	MaxThirst         float64
 	Stamina           float64
 	MaxStamina        float64
+	Saturation        float64 // Fullness from food, used up before hunger
+	MaxSaturation     float64
 
 	// Health regeneration
 	HealthRegenRate   float64 // Health per second when conditions met
@@ -45,6 +51,7 @@ type SurvivalManager struct {
 	IsStarving        bool
 	IsDehydrated      bool
 	CanRegenerate     bool
+	Effects           *status.StatusManager // Effects from food and drink
 }
 
 // NewSurvivalManager creates a new survival manager
@@ -56,6 +63,7 @@ func NewSurvivalManager(mode GameMode, p *player.Player, inv *items.Inventory) *
 		MaxHunger:       100.0,
 		MaxThirst:       100.0,
 		MaxStamina:      100.0,
+		MaxSaturation:   50.0,
 		Hunger:          100.0,
 		Thirst:          100.0,
 		Stamina:         100.0,
@@ -65,6 +73,7 @@ func NewSurvivalManager(mode GameMode, p *player.Player, inv *items.Inventory) *
 		ThirstDecayRate: 0.03,  // Slightly faster thirst decay
 		LastDamageTime:  time.Now(),
 		CanRegenerate:   true,
+		Effects:         status.NewStatusManager(),
 	}
 
 	// Apply difficulty settings
@@ -106,26 +115,30 @@ func (sm *SurvivalManager) Update(deltaTime float64) {
 		return
 	}
 
-	// Decay hunger and thirst
-	sm.Hunger -= sm.HungerDecayRate * deltaTime
+	// Decay hunger and thirst, with saturation used up first
+	hungerLoss := sm.HungerDecayRate * deltaTime
+	if sm.Saturation > 0 {
+		used := min(sm.Saturation, hungerLoss)
+		sm.Saturation -= used
+		hungerLoss -= used
+	}
+	sm.Hunger -= hungerLoss
 	sm.Thirst -= sm.ThirstDecayRate * deltaTime
 
 	// Clamp values
 	if sm.Hunger < 0 {
 		sm.Hunger = 0
-		sm.IsStarving = true
 	} else if sm.Hunger > sm.MaxHunger {
 		sm.Hunger = sm.MaxHunger
-		sm.IsStarving = false
 	}
+	sm.IsStarving = sm.Hunger <= 0
 
 	if sm.Thirst < 0 {
 		sm.Thirst = 0
-		sm.IsDehydrated = true
 	} else if sm.Thirst > sm.MaxThirst {
 		sm.Thirst = sm.MaxThirst
-		sm.IsDehydrated = false
 	}
+	sm.IsDehydrated = sm.Thirst <= 0
 
 	// Regenerate stamina
 	if sm.Stamina < sm.MaxStamina {
@@ -138,6 +151,14 @@ func (sm *SurvivalManager) Update(deltaTime float64) {
 	// Health regeneration
 	sm.updateHealthRegeneration(deltaTime)
 
+	// Poison, regeneration and other effects from food
+	sm.Effects.Update()
+	if rate := sm.Effects.HealthPerSecond(); rate < 0 {
+		sm.Player.TakeDamage(-rate * deltaTime)
+	} else if rate > 0 {
+		sm.Player.Heal(rate * deltaTime)
+	}
+
 	// Starvation/dehydration damage
 	if sm.IsStarving {
 		sm.Player.TakeDamage(0.5 * deltaTime)
@@ -186,6 +207,9 @@ func (sm *SurvivalManager) EatFood(amount float64) {
 	if sm.Hunger > sm.MaxHunger {
 		sm.Hunger = sm.MaxHunger
 	}
+	if sm.Hunger > 0 {
+		sm.IsStarving = false
+	}
 }
 
 // Drink restores thirst
@@ -194,6 +218,53 @@ func (sm *SurvivalManager) Drink(amount float64) {
 	if sm.Thirst > sm.MaxThirst {
 		sm.Thirst = sm.MaxThirst
 	}
+	if sm.Thirst > 0 {
+		sm.IsDehydrated = false
+	}
+}
+
+// Consume eats or drinks an item, applying its nutrition, hydration,
+// saturation and effects. It returns false, leaving the item uneaten, if the
+// item is not consumable or the player has no room for it.
+func (sm *SurvivalManager) Consume(itemType items.ItemType) bool {
+	props := items.GetItemProperties(itemType)
+	if props == nil || !props.IsConsumable() || !sm.wants(props) {
+		return false
+	}
+
+	sm.EatFood(props.Nutrition)
+	sm.Drink(props.Hydration)
+	sm.Saturation = min(sm.Saturation+props.Saturation, sm.MaxSaturation)
+	for _, effect := range props.Effects {
+		effectType, ok := status.ParseEffectType(effect.Effect)
+		if !ok {
+			continue
+		}
+		duration := time.Duration(effect.Duration * float64(time.Second))
+		sm.Effects.ApplyEffect(effectType, duration, effect.Strength)
+	}
+	return true
+}
+
+// wants reports whether the player has room for a consumable: food needs
+// some hunger and drink some thirst. Items that only apply effects are
+// always taken.
+func (sm *SurvivalManager) wants(props *items.ItemProperties) bool {
+	if props.Nutrition == 0 && props.Hydration == 0 {
+		return true
+	}
+	return (props.Nutrition > 0 && sm.Hunger < sm.MaxHunger) ||
+		(props.Hydration > 0 && sm.Thirst < sm.MaxThirst)
+}
+
+// DrinkWater takes a sip straight from a water block, returning false if the
+// player is not thirsty
+func (sm *SurvivalManager) DrinkWater() bool {
+	if sm.Thirst >= sm.MaxThirst {
+		return false
+	}
+	sm.Drink(WaterSipHydration)
+	return true
 }
 
 // GetSurvivalStats returns current survival stats
@@ -205,6 +276,7 @@ func (sm *SurvivalManager) GetSurvivalStats() map[string]float64 {
 		"max_thirst":  sm.MaxThirst,
 		"stamina":     sm.Stamina,
 		"max_stamina": sm.MaxStamina,
+		"saturation":  sm.Saturation,
 		"health":      sm.Player.Health,
 		"max_health":  sm.Player.MaxHealth,
 	}
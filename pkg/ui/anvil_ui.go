@@ -0,0 +1,522 @@
+// Package ui implements the anvil interface for enchanting, combining and
+// repairing items
+package ui
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+
+	"tesselbox/pkg/enchant"
+	"tesselbox/pkg/equipment"
+	"tesselbox/pkg/items"
+	"tesselbox/pkg/player"
+)
+
+// anvilEquipmentSlots are the equipped pieces the anvil can enchant
+var anvilEquipmentSlots = []equipment.EquipmentSlot{
+	equipment.SlotHelmet, equipment.SlotChestplate, equipment.SlotLeggings,
+	equipment.SlotBoots, equipment.SlotGloves, equipment.SlotCloak,
+}
+
+// anvilAction is combining or repairing the target with the second item
+type anvilAction struct {
+	Name   string // "Combine" or "Repair"
+	Done   string // "Combined" or "Repaired"
+	Result items.Item
+	Cost   enchant.Cost
+	Err    error
+}
+
+// AnvilUI represents the anvil interface. The player picks an item to work
+// on, from their inventory or equipped armor, and either enchants it or
+// combines or repairs it with a second inventory item.
+type AnvilUI struct {
+	Open         bool
+	ScreenWidth  int
+	ScreenHeight int
+
+	// References
+	Inventory *items.Inventory
+	Equipment *equipment.EquipmentSet
+	Player    *player.Player
+
+	// OnOpenRecipes is called when the player switches to the anvil's
+	// crafting recipes
+	OnOpenRecipes func()
+	// OnWork is called after an inventory item is enchanted, with second
+	// -1, or combined or repaired with the item in second
+	OnWork func(slot, second int, enchantment string, level int)
+
+	// UI Layout
+	EquipmentX float64
+	EquipmentY float64
+	InventoryX float64
+	InventoryY float64
+	PanelX     float64
+	PanelY     float64
+
+	// Slot sizes
+	SlotSize    float64
+	SlotSpacing float64
+
+	// Selection; -1 when nothing is chosen
+	TargetSlot      int // Inventory slot being worked on
+	TargetEquipment int // Or equipment slot being worked on
+	SecondSlot      int // Inventory slot to combine or repair with
+
+	// Hover state
+	HoveredSlot      int
+	HoveredEquipment int
+	HoveredOption    int
+	HoveredAction    bool
+
+	options []enchant.Option
+	action  *anvilAction
+	Message string
+}
+
+// NewAnvilUI creates a new anvil UI
+func NewAnvilUI(screenWidth, screenHeight int, inv *items.Inventory, eq *equipment.EquipmentSet, p *player.Player) *AnvilUI {
+	ui := &AnvilUI{
+		ScreenWidth:  screenWidth,
+		ScreenHeight: screenHeight,
+		Inventory:    inv,
+		Equipment:    eq,
+		Player:       p,
+		SlotSize:     48,
+		SlotSpacing:  4,
+	}
+	ui.calculateLayout()
+	ui.clearSelection()
+	return ui
+}
+
+// calculateLayout positions UI elements
+func (ui *AnvilUI) calculateLayout() {
+	ui.EquipmentX = 60
+	ui.EquipmentY = 130
+	ui.InventoryX = 60
+	ui.InventoryY = ui.EquipmentY + ui.SlotSize + 60
+	ui.PanelX = ui.InventoryX + 9*(ui.SlotSize+ui.SlotSpacing) + 60
+	ui.PanelY = 100
+}
+
+// clearSelection forgets the chosen items
+func (ui *AnvilUI) clearSelection() {
+	ui.TargetSlot = -1
+	ui.TargetEquipment = -1
+	ui.SecondSlot = -1
+	ui.options = nil
+	ui.action = nil
+}
+
+// OpenAnvil opens the UI with nothing chosen
+func (ui *AnvilUI) OpenAnvil() {
+	ui.Open = true
+	ui.Message = ""
+	ui.clearSelection()
+}
+
+// Close closes the anvil UI
+func (ui *AnvilUI) Close() {
+	ui.Open = false
+	ui.clearSelection()
+}
+
+// Update handles input and updates UI state
+func (ui *AnvilUI) Update() error {
+	if !ui.Open {
+		return nil
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyTab) && ui.OnOpenRecipes != nil {
+		ui.OnOpenRecipes()
+		return nil
+	}
+
+	mx, my := ebiten.CursorPosition()
+	mouseX, mouseY := float64(mx), float64(my)
+	ui.refresh()
+	ui.updateHover(mouseX, mouseY)
+
+	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+		ui.handleClick()
+	}
+	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonRight) && ui.HoveredSlot >= 0 {
+		// Choose the second item, or put it back
+		if ui.SecondSlot == ui.HoveredSlot || ui.HoveredSlot == ui.TargetSlot {
+			ui.SecondSlot = -1
+		} else if ui.Inventory.Slots[ui.HoveredSlot].Type != items.NONE {
+			ui.SecondSlot = ui.HoveredSlot
+		}
+		ui.refresh()
+	}
+	return nil
+}
+
+// target returns the metadata and kind of the item being worked on
+func (ui *AnvilUI) target() (*items.Metadata, string, bool) {
+	if ui.TargetSlot >= 0 && ui.TargetSlot < len(ui.Inventory.Slots) {
+		item := ui.Inventory.Slots[ui.TargetSlot]
+		kind := enchant.TargetOf(item.Type)
+		return item.Meta, kind, item.Type != items.NONE
+	}
+	if ui.TargetEquipment >= 0 && ui.Equipment != nil {
+		if piece := ui.Equipment.GetItem(equipment.EquipmentSlot(ui.TargetEquipment)); piece != nil {
+			return piece.Meta, enchant.TargetArmor, true
+		}
+	}
+	return nil, "", false
+}
+
+// refresh works out what can be done with the chosen items, dropping choices
+// whose items have gone
+func (ui *AnvilUI) refresh() {
+	ui.options = nil
+	ui.action = nil
+
+	meta, kind, ok := ui.target()
+	if !ok {
+		ui.TargetSlot, ui.TargetEquipment = -1, -1
+		return
+	}
+	if kind != "" {
+		ui.options = enchant.Options(meta, kind)
+	}
+
+	if ui.SecondSlot < 0 || ui.SecondSlot >= len(ui.Inventory.Slots) || ui.TargetSlot < 0 {
+		return
+	}
+	target, second := ui.Inventory.Slots[ui.TargetSlot], ui.Inventory.Slots[ui.SecondSlot]
+	if second.Type == items.NONE {
+		ui.SecondSlot = -1
+		return
+	}
+	action := &anvilAction{Name: "Combine", Done: "Combined"}
+	if second.Type == target.Type {
+		action.Result, action.Cost, action.Err = enchant.Combine(target, second)
+	} else {
+		action.Name, action.Done = "Repair", "Repaired"
+		if material, ok := enchant.RepairMaterial(target.Type); ok && material == second.Type {
+			action.Result, action.Cost, action.Err = enchant.Repair(target, second.Quantity)
+		} else {
+			action.Err = fmt.Errorf("%s does not repair %s", second.DisplayName(), target.DisplayName())
+		}
+	}
+	ui.action = action
+}
+
+// updateHover updates the hovered slot, option or button
+func (ui *AnvilUI) updateHover(mx, my float64) {
+	ui.HoveredSlot, ui.HoveredEquipment, ui.HoveredOption = -1, -1, -1
+	ui.HoveredAction = false
+
+	inside := func(x, y, w, h float64) bool {
+		return mx >= x && mx <= x+w && my >= y && my <= y+h
+	}
+	for i, slot := range anvilEquipmentSlots {
+		x := ui.EquipmentX + float64(i)*(ui.SlotSize+ui.SlotSpacing)
+		if inside(x, ui.EquipmentY, ui.SlotSize, ui.SlotSize) {
+			ui.HoveredEquipment = int(slot)
+			return
+		}
+	}
+	for i := range ui.Inventory.Slots {
+		x, y := ui.inventorySlotPosition(i)
+		if inside(x, y, ui.SlotSize, ui.SlotSize) {
+			ui.HoveredSlot = i
+			return
+		}
+	}
+	for i := range ui.options {
+		x, y := ui.optionPosition(i)
+		if inside(x, y, 420, 24) {
+			ui.HoveredOption = i
+			return
+		}
+	}
+	if ui.action != nil {
+		x, y := ui.actionPosition()
+		ui.HoveredAction = inside(x, y, 420, 40)
+	}
+}
+
+// handleClick chooses the item to work on or applies an option
+func (ui *AnvilUI) handleClick() {
+	switch {
+	case ui.HoveredEquipment >= 0:
+		if ui.Equipment != nil && ui.Equipment.GetItem(equipment.EquipmentSlot(ui.HoveredEquipment)) != nil {
+			ui.clearSelection()
+			ui.TargetEquipment = ui.HoveredEquipment
+			ui.Message = ""
+		}
+
+	case ui.HoveredSlot >= 0:
+		item := ui.Inventory.Slots[ui.HoveredSlot]
+		if item.Type == items.NONE {
+			return
+		}
+		if enchant.TargetOf(item.Type) == "" {
+			ui.Message = item.DisplayName() + " cannot be worked on at an anvil"
+			return
+		}
+		second := ui.SecondSlot
+		ui.clearSelection()
+		ui.TargetSlot = ui.HoveredSlot
+		if second != ui.TargetSlot {
+			ui.SecondSlot = second
+		}
+		ui.Message = ""
+
+	case ui.HoveredOption >= 0 && ui.HoveredOption < len(ui.options):
+		ui.applyOption(ui.options[ui.HoveredOption])
+
+	case ui.HoveredAction:
+		ui.applyAction()
+	}
+	ui.refresh()
+}
+
+// pay takes a cost from the player, reporting whether they could afford it
+func (ui *AnvilUI) pay(cost enchant.Cost) bool {
+	if !cost.Affordable(ui.Inventory, ui.Player.XP) {
+		ui.Message = "Not enough: needs " + cost.String()
+		return false
+	}
+	if !cost.Take(ui.Inventory) || !ui.Player.SpendXP(cost.XP) {
+		ui.Message = "Not enough: needs " + cost.String()
+		return false
+	}
+	return true
+}
+
+// applyOption enchants the chosen item
+func (ui *AnvilUI) applyOption(option enchant.Option) {
+	id, level := option.Enchantment.ID, option.Level
+	name := option.Enchantment.LevelName(level)
+
+	if ui.TargetSlot >= 0 {
+		result, cost, err := enchant.EnchantItem(ui.Inventory.Slots[ui.TargetSlot], id, level)
+		if err != nil {
+			ui.Message = err.Error()
+			return
+		}
+		if !ui.pay(cost) {
+			return
+		}
+		ui.Inventory.Slots[ui.TargetSlot] = result
+		ui.Message = "Enchanted with " + name
+		if ui.OnWork != nil {
+			ui.OnWork(ui.TargetSlot, -1, id, level)
+		}
+		return
+	}
+
+	piece := ui.Equipment.GetItem(equipment.EquipmentSlot(ui.TargetEquipment))
+	if piece == nil {
+		return
+	}
+	meta, cost, err := enchant.Enchant(piece.Meta, enchant.TargetArmor, id, level)
+	if err != nil {
+		ui.Message = err.Error()
+		return
+	}
+	if !ui.pay(cost) {
+		return
+	}
+	piece.Meta = meta
+	ui.Message = "Enchanted with " + name
+}
+
+// applyAction combines or repairs the chosen item with the second one
+func (ui *AnvilUI) applyAction() {
+	action := ui.action
+	if action == nil {
+		return
+	}
+	if action.Err != nil {
+		ui.Message = action.Err.Error()
+		return
+	}
+	if !ui.pay(action.Cost) {
+		return
+	}
+	if action.Name == "Combine" {
+		ui.Inventory.Slots[ui.SecondSlot] = items.Item{Type: items.NONE, Quantity: 0, Durability: -1}
+	}
+	ui.Inventory.Slots[ui.TargetSlot] = action.Result
+	if ui.OnWork != nil {
+		ui.OnWork(ui.TargetSlot, ui.SecondSlot, "", 0)
+	}
+	ui.SecondSlot = -1
+	ui.Message = action.Done + " " + action.Result.DisplayName()
+}
+
+// inventorySlotPosition returns where an inventory slot is drawn
+func (ui *AnvilUI) inventorySlotPosition(index int) (float64, float64) {
+	col, row := index%9, index/9
+	return ui.InventoryX + float64(col)*(ui.SlotSize+ui.SlotSpacing),
+		ui.InventoryY + float64(row)*(ui.SlotSize+ui.SlotSpacing)
+}
+
+// optionPosition returns where an enchantment option is drawn
+func (ui *AnvilUI) optionPosition(index int) (float64, float64) {
+	return ui.PanelX, ui.PanelY + 200 + float64(index)*28
+}
+
+// actionPosition returns where the combine or repair button is drawn
+func (ui *AnvilUI) actionPosition() (float64, float64) {
+	_, y := ui.optionPosition(len(ui.options))
+	return ui.PanelX, y + 30
+}
+
+// Draw renders the anvil UI
+func (ui *AnvilUI) Draw(screen *ebiten.Image) {
+	if !ui.Open {
+		return
+	}
+
+	// Draw semi-transparent background
+	bgColor := color.RGBA{20, 20, 30, 230}
+	ebitenutil.DrawRect(screen, 0, 0, float64(ui.ScreenWidth), float64(ui.ScreenHeight), bgColor)
+
+	ebitenutil.DebugPrintAt(screen, "ANVIL", int(ui.InventoryX), 40)
+	ebitenutil.DebugPrintAt(screen, fmt.Sprintf("XP: %d", ui.Player.XP), int(ui.InventoryX), 60)
+	ebitenutil.DebugPrintAt(screen, "Left click: item to work on   Right click: item to combine or repair with   TAB: recipes   ESC: close", int(ui.InventoryX), 80)
+
+	// Draw equipped armor
+	ebitenutil.DebugPrintAt(screen, "EQUIPPED", int(ui.EquipmentX), int(ui.EquipmentY-20))
+	for i, slot := range anvilEquipmentSlots {
+		x := ui.EquipmentX + float64(i)*(ui.SlotSize+ui.SlotSpacing)
+		var piece *equipment.EquipmentItem
+		if ui.Equipment != nil {
+			piece = ui.Equipment.GetItem(slot)
+		}
+		ui.drawSlot(screen, x, ui.EquipmentY, ui.HoveredEquipment == int(slot), ui.TargetEquipment == int(slot), false)
+		if piece != nil {
+			ebitenutil.DrawRect(screen, x+4, ui.EquipmentY+4, ui.SlotSize-8, ui.SlotSize-8, piece.IconColor)
+			if piece.Meta != nil && len(piece.Meta.Enchantments) > 0 {
+				ebitenutil.DrawRect(screen, x+4, ui.EquipmentY+4, 8, 8, color.RGBA{200, 100, 255, 255})
+			}
+		}
+	}
+
+	// Draw inventory
+	ebitenutil.DebugPrintAt(screen, "INVENTORY", int(ui.InventoryX), int(ui.InventoryY-20))
+	for i := range ui.Inventory.Slots {
+		x, y := ui.inventorySlotPosition(i)
+		ui.drawSlot(screen, x, y, ui.HoveredSlot == i, ui.TargetSlot == i, ui.SecondSlot == i)
+		if item := ui.Inventory.Slots[i]; item.Type != items.NONE {
+			ui.drawItem(screen, item, x+4, y+4)
+		}
+	}
+
+	ui.drawPanel(screen)
+
+	if ui.Message != "" {
+		ebitenutil.DebugPrintAt(screen, ui.Message, int(ui.InventoryX), ui.ScreenHeight-40)
+	}
+}
+
+// drawPanel draws the chosen item, its enchantment options and the combine
+// or repair button
+func (ui *AnvilUI) drawPanel(screen *ebiten.Image) {
+	x, y := int(ui.PanelX), int(ui.PanelY)
+	meta, _, ok := ui.target()
+	if !ok {
+		ebitenutil.DebugPrintAt(screen, "Choose an item to work on", x, y)
+		return
+	}
+
+	name, durability, maxDurability := "", -1, 0
+	if ui.TargetSlot >= 0 {
+		item := ui.Inventory.Slots[ui.TargetSlot]
+		name, durability = item.DisplayName(), item.Durability
+		if props := items.GetItemProperties(item.Type); props != nil {
+			maxDurability = props.Durability
+		}
+	} else if piece := ui.Equipment.GetItem(equipment.EquipmentSlot(ui.TargetEquipment)); piece != nil {
+		name, durability, maxDurability = piece.Name, piece.Durability, piece.MaxDurability
+	}
+	ebitenutil.DebugPrintAt(screen, name, x, y)
+	if maxDurability > 0 {
+		ebitenutil.DebugPrintAt(screen, fmt.Sprintf("Durability: %d/%d", durability, maxDurability), x, y+20)
+	}
+	for i, line := range enchant.Describe(meta) {
+		ebitenutil.DebugPrintAt(screen, line, x+10, y+45+i*18)
+	}
+
+	ebitenutil.DebugPrintAt(screen, "ENCHANT", x, y+175)
+	if len(ui.options) == 0 {
+		ebitenutil.DebugPrintAt(screen, "No enchantments left for this item", x, y+200)
+	}
+	for i, option := range ui.options {
+		ox, oy := ui.optionPosition(i)
+		affordable := option.Cost.Affordable(ui.Inventory, ui.Player.XP)
+		rowColor := color.RGBA{50, 50, 60, 255}
+		if !affordable {
+			rowColor = color.RGBA{40, 30, 30, 255}
+		} else if i == ui.HoveredOption {
+			rowColor = color.RGBA{80, 80, 110, 255}
+		}
+		ebitenutil.DrawRect(screen, ox, oy, 420, 24, rowColor)
+		label := fmt.Sprintf("%s - %s", option.Enchantment.LevelName(option.Level), option.Cost)
+		ebitenutil.DebugPrintAt(screen, label, int(ox)+6, int(oy)+4)
+	}
+
+	if ui.action != nil {
+		ax, ay := ui.actionPosition()
+		buttonColor := color.RGBA{100, 160, 100, 255}
+		label := fmt.Sprintf("%s - %s", ui.action.Name, ui.action.Cost)
+		if ui.action.Err != nil {
+			buttonColor = color.RGBA{90, 90, 90, 255}
+			label = ui.action.Err.Error()
+		} else if !ui.action.Cost.Affordable(ui.Inventory, ui.Player.XP) {
+			buttonColor = color.RGBA{120, 80, 80, 255}
+		} else if ui.HoveredAction {
+			buttonColor = color.RGBA{130, 200, 130, 255}
+		}
+		ebitenutil.DrawRect(screen, ax, ay, 420, 40, buttonColor)
+		ebitenutil.DebugPrintAt(screen, label, int(ax)+10, int(ay)+12)
+	}
+}
+
+// drawSlot draws an empty slot, highlighted when hovered or chosen
+func (ui *AnvilUI) drawSlot(screen *ebiten.Image, x, y float64, hovered, target, second bool) {
+	bgColor := color.RGBA{50, 50, 60, 255}
+	switch {
+	case target:
+		bgColor = color.RGBA{100, 200, 100, 255}
+	case second:
+		bgColor = color.RGBA{200, 160, 80, 255}
+	case hovered:
+		bgColor = color.RGBA{70, 70, 90, 255}
+	}
+	ebitenutil.DrawRect(screen, x, y, ui.SlotSize, ui.SlotSize, bgColor)
+
+	borderColor := color.RGBA{80, 80, 100, 255}
+	ebitenutil.DrawRect(screen, x, y, ui.SlotSize, 2, borderColor)
+	ebitenutil.DrawRect(screen, x, y+ui.SlotSize-2, ui.SlotSize, 2, borderColor)
+	ebitenutil.DrawRect(screen, x, y, 2, ui.SlotSize, borderColor)
+	ebitenutil.DrawRect(screen, x+ui.SlotSize-2, y, 2, ui.SlotSize, borderColor)
+}
+
+// drawItem draws an item at the specified position, marking enchanted ones
+func (ui *AnvilUI) drawItem(screen *ebiten.Image, item items.Item, x, y float64) {
+	ebitenutil.DrawRect(screen, x, y, ui.SlotSize-8, ui.SlotSize-8, item.DisplayColor())
+	if item.Meta != nil && len(item.Meta.Enchantments) > 0 {
+		ebitenutil.DrawRect(screen, x, y, 8, 8, color.RGBA{200, 100, 255, 255})
+	}
+	if item.Quantity > 1 {
+		ebitenutil.DebugPrintAt(screen, fmt.Sprintf("%d", item.Quantity), int(x)+25, int(y)+25)
+	}
+}
+
+// IsOpen returns whether the UI is open
+func (ui *AnvilUI) IsOpen() bool {
+	return ui.Open
+}
@@ -214,12 +214,14 @@ func (ui *BackpackUI) handleClick(mx, my float64) {
 			item := &ui.Inventory.Slots[ui.HoveredSlot]
 			if item.Type != items.NONE {
 				// Start dragging
-				ui.DraggedItem = item
+				dragged := *item
+				ui.DraggedItem = &dragged
 				ui.DraggedQuantity = item.Quantity
 				// Clear the slot
 				item.Type = items.NONE
 				item.Quantity = 0
 				item.Durability = -1
+				item.Meta = nil
 			}
 		}
 	case SlotTypeArmor:
@@ -245,7 +247,8 @@ func (ui *BackpackUI) handleRightClick(mx, my float64) {
 		item := &ui.Inventory.Slots[ui.HoveredSlot]
 		if item.Quantity > 1 {
 			half := item.Quantity / 2
-			ui.DraggedItem = item
+			dragged := *item
+			ui.DraggedItem = &dragged
 			ui.DraggedQuantity = half
 			ui.DraggedItem.Quantity = half
 			item.Quantity -= half
@@ -212,7 +212,8 @@ func (ui *ChestUI) handleClick(mx, my float64) {
 		if ui.HoveredSlot >= 0 && ui.HoveredSlot < len(ui.PlayerInventory.Slots) {
 			item := &ui.PlayerInventory.Slots[ui.HoveredSlot]
 			if item.Type != items.NONE {
-				ui.DraggedItem = item
+				dragged := *item
+				ui.DraggedItem = &dragged
 				ui.DraggedQuantity = item.Quantity
 				ui.SelectedPlayerSlot = ui.HoveredSlot
 
@@ -220,6 +221,7 @@ func (ui *ChestUI) handleClick(mx, my float64) {
 				item.Type = items.NONE
 				item.Quantity = 0
 				item.Durability = -1
+				item.Meta = nil
 			}
 		}
 	}
@@ -238,7 +240,7 @@ func (ui *ChestUI) handleRightClick(mx, my float64) {
 		if ui.HoveredSlot < len(contents) && contents[ui.HoveredSlot].Type != items.NONE {
 			item := contents[ui.HoveredSlot]
 			// Add to player inventory
-			if ui.PlayerInventory.AddItem(item.Type, item.Quantity) {
+			if ui.PlayerInventory.AddStack(item) {
 				// Remove from chest
 				ui.ChestManager.RemoveItemFromChest(ui.CurrentChestX, ui.CurrentChestY, ui.HoveredSlot, item.Quantity)
 			}
@@ -250,7 +252,7 @@ func (ui *ChestUI) handleRightClick(mx, my float64) {
 			item := ui.PlayerInventory.Slots[ui.HoveredSlot]
 			if item.Type != items.NONE {
 				// Add to chest
-				if ui.ChestManager.AddItemToChest(ui.CurrentChestX, ui.CurrentChestY, item.Type, item.Quantity) {
+				if ui.ChestManager.AddStackToChest(ui.CurrentChestX, ui.CurrentChestY, item) {
 					// Remove from player
 					ui.PlayerInventory.Slots[ui.HoveredSlot] = items.Item{Type: items.NONE, Quantity: 0, Durability: -1}
 				}
@@ -0,0 +1,760 @@
+package ui
+
+import (
+	"fmt"
+	"math"
+	"unicode"
+	"unicode/utf8"
+
+	"image/color"
+	"tesselbox/pkg/crafting"
+	"tesselbox/pkg/items"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// crafting.Recipe book layout
+const (
+	bookListX       = 50
+	bookListY       = 130
+	bookListWidth   = 400
+	bookRowHeight   = 36
+	bookVisibleRows = 14
+	maxSearchLength = 32
+
+	detailsX = 500
+	detailsY = 100
+
+	craftButtonX      = 500
+	craftButtonY      = 580
+	craftButtonWidth  = 320
+	craftButtonHeight = 50
+
+	gridPanelX   = 940
+	gridCenterX  = 1080
+	gridCenterY  = 190
+	gridCellSize = 30 // Distance from a cell's center to its corners
+
+	paletteY      = 310
+	paletteSize   = 32
+	palettePerRow = 8
+	paletteMax    = 16
+
+	gridButtonY      = 420
+	gridButtonWidth  = 130
+	gridButtonHeight = 36
+	clearButtonX     = gridPanelX + gridButtonWidth + 20
+)
+
+// CraftingUI represents the crafting interface
+type CraftingUI struct {
+	craftingSystem *crafting.CraftingSystem
+	inventory      *items.Inventory
+
+	// UI state
+	Open           bool
+	SelectedRecipe int
+	CreativeMode   bool
+
+	// crafting.Recipe display
+	visibleRecipes []*crafting.Recipe
+
+	// Creative mode items
+	allItems     []items.ItemType
+	scrollOffset int
+
+	// crafting.Recipe book
+	showBook   bool   // In creative mode, show the recipe book rather than the item grid
+	search     string // Recipes are listed when their name, description or results contain it
+	listScroll int
+	selectedID string
+	status     string // Result of the last craft or fill
+
+	// Crafting grid, painted with the brush from the inventory's item types
+	grid    crafting.CraftingGrid
+	brush   items.ItemType
+	palette []items.ItemType
+
+	// Quantity selector
+	craftQuantity int
+
+	// Current crafting station
+	currentStation crafting.CraftingStation
+
+	// Animation
+	animationProgress float64
+}
+
+// NewCraftingUI creates a new crafting UI
+func NewCraftingUI(craftingSystem *crafting.CraftingSystem, inventory *items.Inventory) *CraftingUI {
+	return &CraftingUI{
+		craftingSystem:    craftingSystem,
+		inventory:         inventory,
+		Open:              false,
+		SelectedRecipe:    -1,
+		CreativeMode:      true, // Enable creative mode
+		scrollOffset:      0,
+		craftQuantity:     1,
+		currentStation:    crafting.STATION_NONE,
+		animationProgress: 0.0,
+	}
+}
+
+// Toggle opens or closes the crafting UI
+func (ui *CraftingUI) Toggle() {
+	ui.Open = !ui.Open
+	if ui.Open {
+		if ui.CreativeMode {
+			ui.allItems = []items.ItemType{}
+			for itemType := range items.ItemDefinitions {
+				ui.allItems = append(ui.allItems, itemType)
+			}
+		}
+		ui.status = ""
+		ui.refresh()
+	}
+}
+
+// SetStation sets the current crafting station and refreshes available recipes
+func (ui *CraftingUI) SetStation(station crafting.CraftingStation) {
+	ui.currentStation = station
+	if ui.Open {
+		ui.refresh()
+	}
+}
+
+// GetCurrentStation returns the current crafting station
+func (ui *CraftingUI) GetCurrentStation() crafting.CraftingStation {
+	return ui.currentStation
+}
+
+// refresh lists the known recipes matching the search, keeping the selected
+// recipe selected while it is listed
+func (ui *CraftingUI) refresh() {
+	ui.visibleRecipes = ui.craftingSystem.SearchRecipes(ui.search)
+	ui.SelectedRecipe = -1
+	for i, recipe := range ui.visibleRecipes {
+		if recipe.ID == ui.selectedID {
+			ui.SelectedRecipe = i
+			break
+		}
+	}
+	if ui.SelectedRecipe < 0 && len(ui.visibleRecipes) > 0 {
+		ui.selectRecipe(0)
+	}
+	ui.listScroll = max(0, min(ui.listScroll, len(ui.visibleRecipes)-bookVisibleRows))
+	ui.palette = inventoryTypes(ui.inventory)
+}
+
+// selectRecipe selects a listed recipe and scrolls the list to it
+func (ui *CraftingUI) selectRecipe(i int) {
+	if i < 0 || i >= len(ui.visibleRecipes) {
+		return
+	}
+	if ui.visibleRecipes[i].ID != ui.selectedID {
+		ui.craftQuantity = 1
+	}
+	ui.SelectedRecipe = i
+	ui.selectedID = ui.visibleRecipes[i].ID
+	if i < ui.listScroll {
+		ui.listScroll = i
+	} else if i >= ui.listScroll+bookVisibleRows {
+		ui.listScroll = i - bookVisibleRows + 1
+	}
+}
+
+// selected returns the selected recipe, or nil if there is none
+func (ui *CraftingUI) selected() *crafting.Recipe {
+	if ui.SelectedRecipe >= 0 && ui.SelectedRecipe < len(ui.visibleRecipes) {
+		return ui.visibleRecipes[ui.SelectedRecipe]
+	}
+	return nil
+}
+
+// gridEmpty reports whether nothing is laid out on the crafting grid
+func (ui *CraftingUI) gridEmpty() bool {
+	for _, itemType := range ui.grid {
+		if itemType != items.NONE {
+			return false
+		}
+	}
+	return true
+}
+
+// craftTarget returns what crafting makes: the recipe laid out on the grid
+// if anything is, or else the selected recipe
+func (ui *CraftingUI) craftTarget() *crafting.Recipe {
+	if !ui.gridEmpty() {
+		return ui.craftingSystem.MatchGrid(ui.grid)
+	}
+	return ui.selected()
+}
+
+// Update handles input updates for the crafting UI
+func (ui *CraftingUI) Update() error {
+	if !ui.Open {
+		return nil
+	}
+
+	// Keyboard navigation
+	if ebiten.IsKeyPressed(ebiten.KeyEscape) {
+		ui.Toggle()
+		return nil
+	}
+
+	// Creative players can switch between the item grid and the recipe book
+	if ui.CreativeMode && inpututil.IsKeyJustPressed(ebiten.KeyTab) {
+		ui.showBook = !ui.showBook
+		ui.refresh()
+	}
+
+	if ui.CreativeMode && !ui.showBook {
+		// Handle scroll
+		_, scrollY := ebiten.Wheel()
+		if scrollY > 0 {
+			ui.scrollOffset = max(0, ui.scrollOffset-1)
+		} else if scrollY < 0 {
+			ui.scrollOffset++
+		}
+
+		// Handle mouse click to select item
+		if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+			mx, my := ebiten.CursorPosition()
+			x := 50
+			y := 100
+			itemSize := 50
+			perRow := 10
+
+			if mx >= x && my >= y {
+				col := (mx - x) / itemSize
+				row := (my - y) / itemSize
+				localIndex := row*perRow + col
+				globalIndex := ui.scrollOffset*perRow + localIndex
+
+				if globalIndex >= 0 && globalIndex < len(ui.allItems) {
+					itemType := ui.allItems[globalIndex]
+					// Add to selected inventory slot (assume slot 0 for now, or find empty slot)
+					for i := 0; i < len(ui.inventory.Slots); i++ {
+						if ui.inventory.Slots[i].Type == items.NONE {
+							ui.inventory.Slots[i] = items.Item{Type: itemType, Quantity: 1, Durability: -1}
+							break
+						}
+					}
+				}
+			}
+		}
+	} else {
+		ui.updateRecipeBook()
+	}
+
+	return nil
+}
+
+// updateRecipeBook handles searching, browsing and crafting in the recipe book
+func (ui *CraftingUI) updateRecipeBook() {
+	// Typing searches the book
+	for _, r := range ebiten.AppendInputChars(nil) {
+		if unicode.IsPrint(r) && len(ui.search) < maxSearchLength {
+			ui.search += string(r)
+		}
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyBackspace) && len(ui.search) > 0 {
+		_, size := utf8.DecodeLastRuneInString(ui.search)
+		ui.search = ui.search[:len(ui.search)-size]
+	}
+	ui.refresh()
+
+	if count := len(ui.visibleRecipes); count > 0 {
+		if inpututil.IsKeyJustPressed(ebiten.KeyUp) {
+			ui.selectRecipe((ui.SelectedRecipe - 1 + count) % count)
+		}
+		if inpututil.IsKeyJustPressed(ebiten.KeyDown) {
+			ui.selectRecipe((ui.SelectedRecipe + 1) % count)
+		}
+	}
+
+	// Quantity selection
+	if inpututil.IsKeyJustPressed(ebiten.KeyLeft) {
+		ui.craftQuantity = max(1, ui.craftQuantity-1)
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyRight) {
+		ui.craftQuantity = min(64, ui.craftQuantity+1)
+	}
+
+	// Craft on Enter
+	if inpututil.IsKeyJustPressed(ebiten.KeyEnter) {
+		ui.craft()
+	}
+
+	// Scroll the list
+	_, scrollY := ebiten.Wheel()
+	if scrollY > 0 {
+		ui.listScroll = max(0, ui.listScroll-1)
+	} else if scrollY < 0 {
+		ui.listScroll = max(0, min(ui.listScroll+1, len(ui.visibleRecipes)-bookVisibleRows))
+	}
+
+	mx, my := ebiten.CursorPosition()
+	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+		ui.handleClick(mx, my)
+	}
+	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonRight) {
+		// Right click clears a grid cell
+		if c, ok := cellAt(mx, my); ok {
+			delete(ui.grid, c)
+		}
+	}
+}
+
+// craft crafts the craft target as many times as the quantity asks
+func (ui *CraftingUI) craft() {
+	recipe := ui.craftTarget()
+	if recipe == nil {
+		if !ui.gridEmpty() {
+			ui.status = "Nothing can be made from that layout"
+		}
+		return
+	}
+
+	made := 0
+	var err error
+	for i := 0; i < ui.craftQuantity; i++ {
+		switch {
+		case recipe.IsShaped() && ui.gridEmpty():
+			err = fmt.Errorf("fill the grid to craft %s", recipe.Name)
+		case recipe.IsShaped():
+			_, err = ui.craftingSystem.CraftGrid(ui.grid, ui.inventory, ui.currentStation)
+		default:
+			err = ui.craftingSystem.Craft(recipe.ID, ui.inventory, ui.currentStation)
+		}
+		if err != nil {
+			break // Stop if crafting fails (e.g., inventory full)
+		}
+		made++
+	}
+
+	if made > 0 {
+		ui.status = fmt.Sprintf("Crafted %s x%d", recipe.Name, made)
+	} else if err != nil {
+		ui.status = err.Error()
+	}
+	ui.refresh()
+}
+
+// fill lays the selected recipe out on the crafting grid from the inventory
+func (ui *CraftingUI) fill() {
+	recipe := ui.selected()
+	switch {
+	case recipe == nil:
+		return
+	case !recipe.IsShaped():
+		ui.status = recipe.Name + " has no shape; craft it straight away"
+	default:
+		grid := ui.craftingSystem.FillGrid(recipe, ui.inventory)
+		if grid == nil {
+			ui.status = "Missing materials for " + recipe.Name
+			return
+		}
+		ui.grid = grid
+		ui.status = "Laid out " + recipe.Name
+	}
+}
+
+// handleClick handles mouse clicks on the crafting UI
+func (ui *CraftingUI) handleClick(mx, my int) {
+	// crafting.Recipe list area
+	for row := 0; row < bookVisibleRows; row++ {
+		i := ui.listScroll + row
+		if i >= len(ui.visibleRecipes) {
+			break
+		}
+		recipeY := bookListY + row*bookRowHeight
+		if inRect(mx, my, bookListX, recipeY, bookListWidth, bookRowHeight-4) {
+			ui.selectRecipe(i)
+			return
+		}
+	}
+
+	// Grid cells take the brush
+	if c, ok := cellAt(mx, my); ok {
+		if ui.brush == items.NONE {
+			delete(ui.grid, c)
+			return
+		}
+		if ui.grid == nil {
+			ui.grid = make(crafting.CraftingGrid)
+		}
+		ui.grid[c] = ui.brush
+		return
+	}
+
+	// Palette picks the brush; picking it again puts it down
+	for i, itemType := range ui.palette {
+		x, y := paletteSlot(i)
+		if inRect(mx, my, x, y, paletteSize, paletteSize) {
+			if ui.brush == itemType {
+				ui.brush = items.NONE
+			} else {
+				ui.brush = itemType
+			}
+			return
+		}
+	}
+
+	switch {
+	case inRect(mx, my, gridPanelX, gridButtonY, gridButtonWidth, gridButtonHeight):
+		ui.fill()
+	case inRect(mx, my, clearButtonX, gridButtonY, gridButtonWidth, gridButtonHeight):
+		ui.grid = nil
+		ui.status = ""
+	case inRect(mx, my, craftButtonX, craftButtonY, craftButtonWidth, craftButtonHeight):
+		ui.craft()
+	}
+}
+
+// Draw renders the crafting UI
+func (ui *CraftingUI) Draw(screen *ebiten.Image) {
+	if !ui.Open {
+		return
+	}
+
+	if ui.CreativeMode && !ui.showBook {
+		// Creative mode: draw item grid
+		ui.drawCreativeGrid(screen)
+	} else {
+		// Normal crafting mode
+		ui.drawCraftingUI(screen)
+	}
+}
+
+// drawCreativeGrid draws the creative item selection grid
+func (ui *CraftingUI) drawCreativeGrid(screen *ebiten.Image) {
+	// Draw semi-transparent background
+	bgColor := color.RGBA{30, 30, 40, 230}
+	ebitenutil.DrawRect(screen, 0, 0, 1280, 720, bgColor)
+
+	// Draw title
+	ui.drawText(screen, "CREATIVE INVENTORY", 50, 40)
+	ui.drawText(screen, "Press ESC to close, mouse wheel to scroll, click to select item, TAB for the recipe book", 50, 70)
+
+	// Draw grid of items
+	x := 50
+	y := 100
+	itemSize := 50
+	perRow := 10
+	visibleRows := 10
+
+	for i := ui.scrollOffset * perRow; i < len(ui.allItems) && (i-ui.scrollOffset*perRow) < perRow*visibleRows; i++ {
+		localIndex := i - ui.scrollOffset*perRow
+		row := localIndex / perRow
+		col := localIndex % perRow
+		ix := x + col*itemSize
+		iy := y + row*itemSize
+
+		itemType := ui.allItems[i]
+		itemColor := items.ItemColorByID(itemType)
+		ebitenutil.DrawRect(screen, float64(ix), float64(iy), float64(itemSize-2), float64(itemSize-2), itemColor)
+	}
+}
+
+// drawCraftingUI draws the recipe book and the crafting grid
+func (ui *CraftingUI) drawCraftingUI(screen *ebiten.Image) {
+	// Draw semi-transparent background
+	bgColor := color.RGBA{30, 30, 40, 230}
+	ebitenutil.DrawRect(screen, 0, 0, 1280, 720, bgColor)
+
+	// Draw title
+	ui.drawText(screen, "RECIPE BOOK - "+stationLabel(ui.currentStation), 50, 40)
+	help := "Type to search, ESC to close"
+	if ui.CreativeMode {
+		help += ", TAB for creative items"
+	}
+	ui.drawText(screen, help, 50, 70)
+
+	// Search box
+	ebitenutil.DrawRect(screen, bookListX, 96, bookListWidth, 26, color.RGBA{20, 20, 28, 255})
+	ui.drawText(screen, "Search: "+ui.search+"_", bookListX+8, 101)
+
+	// Draw recipe list
+	ui.drawRecipeList(screen)
+
+	// Draw selected recipe details
+	if ui.selected() != nil {
+		ui.drawRecipeDetails(screen)
+	}
+	ui.drawCraftButton(screen)
+	ui.drawGrid(screen)
+
+	if ui.status != "" {
+		ui.drawText(screen, ui.status, craftButtonX, craftButtonY+craftButtonHeight+16)
+	}
+
+	// Instructions
+	ui.drawText(screen, "Up/down to browse, left/right for quantity, ENTER to craft", bookListX, 690)
+}
+
+// drawRecipeList draws the known recipes that match the search
+func (ui *CraftingUI) drawRecipeList(screen *ebiten.Image) {
+	for row := 0; row < bookVisibleRows; row++ {
+		i := ui.listScroll + row
+		if i >= len(ui.visibleRecipes) {
+			break
+		}
+		recipe := ui.visibleRecipes[i]
+		y := bookListY + row*bookRowHeight
+
+		// crafting.Recipe background
+		bgColor := color.RGBA{50, 50, 60, 255}
+		if i == ui.SelectedRecipe {
+			bgColor = color.RGBA{80, 80, 100, 255}
+		}
+		ebitenutil.DrawRect(screen, bookListX, float64(y), bookListWidth, bookRowHeight-4, bgColor)
+
+		// First result and recipe name
+		if len(recipe.Outputs) > 0 {
+			ebitenutil.DrawRect(screen, bookListX+6, float64(y+6), 20, 20, items.ItemColorByID(recipe.Outputs[0].ItemType))
+		}
+		ui.drawText(screen, recipe.Name, bookListX+36, y+9)
+
+		// Marker for recipes that can be crafted here now
+		marker := color.RGBA{90, 90, 90, 255}
+		if ui.craftingSystem.CanCraft(recipe, ui.inventory, ui.currentStation) {
+			marker = color.RGBA{100, 200, 100, 255}
+		}
+		ebitenutil.DrawRect(screen, bookListX+bookListWidth-18, float64(y+10), 10, 10, marker)
+	}
+
+	// No recipes available message
+	if len(ui.visibleRecipes) == 0 {
+		ui.drawText(screen, "No known recipes match!", bookListX, bookListY)
+	} else {
+		ui.drawText(screen, fmt.Sprintf("%d recipes known", len(ui.visibleRecipes)), bookListX, bookListY+bookVisibleRows*bookRowHeight+4)
+	}
+}
+
+// drawRecipeDetails draws the selected recipe and what is missing to craft it
+func (ui *CraftingUI) drawRecipeDetails(screen *ebiten.Image) {
+	recipe := ui.selected()
+	y := detailsY
+
+	ui.drawText(screen, "Recipe: "+recipe.Name, detailsX, y)
+	y += 24
+	ui.drawText(screen, recipe.Description, detailsX, y)
+	y += 30
+
+	where := "Made at: " + stationLabel(recipe.RequiredStation)
+	if recipe.RequiredStation.IsTimed() {
+		where = "Smelted over time in a furnace"
+	}
+	ui.drawText(screen, where, detailsX, y)
+	y += 24
+	if recipe.RequiredTool != items.NONE {
+		ui.drawText(screen, "Hold: "+items.ItemNameByID(recipe.RequiredTool), detailsX, y)
+		y += 24
+	}
+	if recipe.IsShaped() {
+		ui.drawText(screen, "Shaped: lay it out on the crafting grid", detailsX, y)
+		y += 24
+	}
+
+	// Inputs section, with what is missing marked red
+	y += 10
+	ui.drawText(screen, "Required Materials:", detailsX, y)
+	y += 30
+	missing := make(map[items.ItemType]int)
+	for _, m := range ui.craftingSystem.GetMissingMaterials(recipe, ui.inventory, ui.currentStation) {
+		missing[m.ItemType] = m.Quantity
+	}
+	for _, input := range recipe.Inputs {
+		itemName := items.ItemNameByID(input.ItemType)
+		have := input.Quantity - missing[input.ItemType]
+		if missing[input.ItemType] > 0 {
+			ebitenutil.DrawRect(screen, detailsX-4, float64(y-4), 28, 28, color.RGBA{200, 60, 60, 255})
+		}
+		ebitenutil.DrawRect(screen, detailsX, float64(y), 20, 20, items.ItemColorByID(input.ItemType))
+
+		line := fmt.Sprintf("%s %d/%d", itemName, have, input.Quantity)
+		if missing[input.ItemType] > 0 {
+			line += fmt.Sprintf(" (missing %d)", missing[input.ItemType])
+		}
+		ui.drawText(screen, line, detailsX+30, y+2)
+		y += 30
+	}
+
+	// Outputs section
+	y += 20
+	ui.drawText(screen, "Results:", detailsX, y)
+	y += 30
+	for _, output := range recipe.Outputs {
+		ebitenutil.DrawRect(screen, detailsX, float64(y), 20, 20, items.ItemColorByID(output.ItemType))
+		ui.drawText(screen, fmt.Sprintf("%s x%d", items.ItemNameByID(output.ItemType), output.Quantity), detailsX+30, y+2)
+		y += 30
+	}
+}
+
+// drawCraftButton draws the quantity and the button that crafts the craft target
+func (ui *CraftingUI) drawCraftButton(screen *ebiten.Image) {
+	target := ui.craftTarget()
+	if target == nil && ui.gridEmpty() {
+		return
+	}
+
+	ui.drawText(screen, fmt.Sprintf("Quantity: %d", ui.craftQuantity), craftButtonX, craftButtonY-30)
+
+	label := "CRAFT"
+	enabled := false
+	switch {
+	case target == nil:
+		label = "NO MATCH"
+	case target.RequiredStation.IsTimed():
+		label = "USE A FURNACE"
+	case target.IsShaped() && ui.gridEmpty():
+		label = "FILL THE GRID"
+	case !ui.craftingSystem.IsUnlocked(target.ID):
+		// Laying out a locked recipe discovers it when crafted
+		label = "TRY IT"
+		enabled = true
+	case !ui.craftingSystem.CanCraft(target, ui.inventory, ui.currentStation):
+		label = "MISSING ITEMS"
+	default:
+		enabled = true
+	}
+	if target != nil && !ui.gridEmpty() && ui.craftingSystem.IsUnlocked(target.ID) {
+		label += ": " + target.Name
+	}
+	drawButton(screen, craftButtonX, craftButtonY, craftButtonWidth, craftButtonHeight, label, enabled)
+}
+
+// drawGrid draws the crafting grid, the materials to paint it with and its buttons
+func (ui *CraftingUI) drawGrid(screen *ebiten.Image) {
+	ui.drawText(screen, "CRAFTING GRID", gridPanelX, detailsY)
+
+	for _, c := range crafting.GridCells() {
+		x, y := cellCenter(c)
+		vector.DrawFilledCircle(screen, float32(x), float32(y), gridCellSize*0.85, color.RGBA{55, 55, 70, 255}, true)
+		if itemType := ui.grid[c]; itemType != items.NONE {
+			ebitenutil.DrawRect(screen, x-12, y-12, 24, 24, items.ItemColorByID(itemType))
+		}
+	}
+
+	if !ui.gridEmpty() {
+		makes := "Nothing matches this layout"
+		if match := ui.craftingSystem.MatchGrid(ui.grid); match != nil && ui.craftingSystem.IsUnlocked(match.ID) {
+			makes = "Makes: " + match.Name
+		} else if match != nil {
+			makes = "Makes: something new"
+		}
+		ui.drawText(screen, makes, gridPanelX, gridCenterY+70)
+	}
+
+	// Materials to paint the grid with
+	ui.drawText(screen, "Click a material, then cells:", gridPanelX, paletteY-22)
+	for i, itemType := range ui.palette {
+		x, y := paletteSlot(i)
+		if itemType == ui.brush {
+			ebitenutil.DrawRect(screen, float64(x-3), float64(y-3), paletteSize+6, paletteSize+6, color.RGBA{255, 255, 255, 255})
+		}
+		ebitenutil.DrawRect(screen, float64(x), float64(y), paletteSize, paletteSize, items.ItemColorByID(itemType))
+	}
+	if ui.brush != items.NONE {
+		ui.drawText(screen, "Painting: "+items.ItemNameByID(ui.brush), gridPanelX, gridButtonY-24)
+	}
+
+	fillable := false
+	if recipe := ui.selected(); recipe != nil && recipe.IsShaped() {
+		fillable = ui.craftingSystem.FillGrid(recipe, ui.inventory) != nil
+	}
+	drawButton(screen, gridPanelX, gridButtonY, gridButtonWidth, gridButtonHeight, "FILL", fillable)
+	drawButton(screen, clearButtonX, gridButtonY, gridButtonWidth, gridButtonHeight, "CLEAR", !ui.gridEmpty())
+}
+
+// drawButton draws a button, greyed out when it would do nothing
+func drawButton(screen *ebiten.Image, x, y, width, height int, label string, enabled bool) {
+	buttonColor := color.RGBA{100, 200, 100, 255}
+	if !enabled {
+		buttonColor = color.RGBA{150, 150, 150, 255}
+	}
+	ebitenutil.DrawRect(screen, float64(x), float64(y), float64(width), float64(height), buttonColor)
+	ebitenutil.DrawRect(screen, float64(x), float64(y), float64(width), 3, color.RGBA{255, 255, 255, 255})
+
+	// Center text in button
+	textX := x + (width-len(label)*6)/2
+	textY := y + height/2 - 8
+	for dx := 0; dx < 2; dx++ {
+		for dy := 0; dy < 2; dy++ {
+			ebitenutil.DebugPrintAt(screen, label, textX+dx, textY+dy)
+		}
+	}
+}
+
+// drawText draws text on the screen with larger, more readable text
+func (ui *CraftingUI) drawText(screen *ebiten.Image, text string, x, y int) {
+	// Draw text multiple times with slight offsets for thicker, more readable text
+	for dx := 0; dx < 2; dx++ {
+		for dy := 0; dy < 2; dy++ {
+			ebitenutil.DebugPrintAt(screen, text, x+dx, y+dy)
+		}
+	}
+}
+
+// stationLabel names where recipes for a station are made
+func stationLabel(station crafting.CraftingStation) string {
+	switch station {
+	case crafting.STATION_WORKBENCH:
+		return "Workbench"
+	case crafting.STATION_FURNACE:
+		return "Furnace"
+	case crafting.STATION_ANVIL:
+		return "Anvil"
+	}
+	return "Anywhere"
+}
+
+// cellCenter returns the screen position of the center of a grid cell
+func cellCenter(c crafting.HexCoord) (float64, float64) {
+	x := gridCenterX + gridCellSize*math.Sqrt(3)*(float64(c.Q)+float64(c.R)/2)
+	y := gridCenterY + gridCellSize*1.5*float64(c.R)
+	return x, y
+}
+
+// cellAt returns the grid cell at a screen position
+func cellAt(mx, my int) (crafting.HexCoord, bool) {
+	for _, c := range crafting.GridCells() {
+		x, y := cellCenter(c)
+		if math.Hypot(float64(mx)-x, float64(my)-y) <= gridCellSize*0.85 {
+			return c, true
+		}
+	}
+	return crafting.HexCoord{}, false
+}
+
+// paletteSlot returns the screen position of a material in the palette
+func paletteSlot(i int) (int, int) {
+	return gridPanelX + (i%palettePerRow)*(paletteSize+4), paletteY + (i/palettePerRow)*(paletteSize+4)
+}
+
+// inRect reports whether a point is inside a rectangle
+func inRect(mx, my, x, y, width, height int) bool {
+	return mx >= x && mx <= x+width && my >= y && my <= y+height
+}
+
+// inventoryTypes returns the item types in an inventory in slot order, as
+// many as the palette shows
+func inventoryTypes(inventory *items.Inventory) []items.ItemType {
+	var types []items.ItemType
+	seen := make(map[items.ItemType]bool)
+	for _, slot := range inventory.Slots {
+		if slot.Type == items.NONE || seen[slot.Type] {
+			continue
+		}
+		seen[slot.Type] = true
+		types = append(types, slot.Type)
+		if len(types) == paletteMax {
+			break
+		}
+	}
+	return types
+}
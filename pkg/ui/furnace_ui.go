@@ -0,0 +1,427 @@
+// Package ui implements the furnace interface
+package ui
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+
+	"tesselbox/pkg/items"
+	"tesselbox/pkg/station"
+)
+
+// furnaceSlotNames label the furnace's slots
+var furnaceSlotNames = [station.SlotCount]string{"Input", "Fuel", "Output"}
+
+// FurnaceUI represents the interface of a placed furnace. Like the chest UI,
+// items are dragged between the furnace and the player inventory, or moved
+// across with a right click.
+type FurnaceUI struct {
+	Open         bool
+	ScreenWidth  int
+	ScreenHeight int
+
+	// References
+	PlayerInventory *items.Inventory
+	Station         *station.Station // The open furnace
+
+	// UI Layout
+	StationX   float64
+	StationY   float64
+	PlayerInvX float64
+	PlayerInvY float64
+
+	// Slot sizes
+	SlotSize    float64
+	SlotSpacing float64
+
+	// Drag and drop; the item came from a station slot or a player slot
+	DraggedItem       *items.Item
+	DragFromStation   int
+	DragFromPlayer    int
+	DragX, DragY      float64
+	HoveredStation    int
+	HoveredPlayerSlot int
+
+	// OnDrop is called with a dragged stack that has nowhere to go back to
+	// when the UI closes, to drop it into the world
+	OnDrop func(item items.Item)
+}
+
+// NewFurnaceUI creates a new furnace UI
+func NewFurnaceUI(screenWidth, screenHeight int, inv *items.Inventory) *FurnaceUI {
+	ui := &FurnaceUI{
+		ScreenWidth:     screenWidth,
+		ScreenHeight:    screenHeight,
+		PlayerInventory: inv,
+		SlotSize:        48,
+		SlotSpacing:     4,
+	}
+
+	ui.calculateLayout()
+	ui.clearDrag()
+	return ui
+}
+
+// calculateLayout positions UI elements
+func (ui *FurnaceUI) calculateLayout() {
+	centerX := float64(ui.ScreenWidth) / 2
+
+	// Furnace slots at top
+	ui.StationX = centerX - 140
+	ui.StationY = 100
+
+	// Player inventory below (9x3 main + 9 hotbar)
+	ui.PlayerInvX = centerX - (9*ui.SlotSize+8*ui.SlotSpacing)/2
+	ui.PlayerInvY = ui.StationY + 2*ui.SlotSize + 100
+}
+
+// stationSlotPosition returns where a furnace slot is drawn: input above
+// fuel, with the output to their right
+func (ui *FurnaceUI) stationSlotPosition(slot int) (float64, float64) {
+	switch slot {
+	case station.SlotInput:
+		return ui.StationX, ui.StationY
+	case station.SlotFuel:
+		return ui.StationX, ui.StationY + ui.SlotSize + 40
+	default:
+		return ui.StationX + 200, ui.StationY + (ui.SlotSize+40)/2
+	}
+}
+
+// playerSlotPosition returns where a player inventory slot is drawn, with
+// the hotbar below the main inventory
+func (ui *FurnaceUI) playerSlotPosition(index int) (float64, float64) {
+	if index < 9 {
+		return ui.PlayerInvX + float64(index)*(ui.SlotSize+ui.SlotSpacing),
+			ui.PlayerInvY + 3*ui.SlotSize + 3*ui.SlotSpacing + 20
+	}
+	col, row := (index-9)%9, (index-9)/9
+	return ui.PlayerInvX + float64(col)*(ui.SlotSize+ui.SlotSpacing),
+		ui.PlayerInvY + float64(row)*(ui.SlotSize+ui.SlotSpacing)
+}
+
+// OpenFurnace opens the UI for a placed furnace
+func (ui *FurnaceUI) OpenFurnace(s *station.Station) {
+	ui.Station = s
+	ui.Open = true
+	ui.clearDrag()
+}
+
+// Close closes the furnace UI, putting back anything being dragged or,
+// failing that, dropping it
+func (ui *FurnaceUI) Close() {
+	ui.returnDragged()
+	if ui.DraggedItem != nil && ui.OnDrop != nil {
+		ui.OnDrop(*ui.DraggedItem)
+	}
+	ui.Open = false
+	ui.Station = nil
+	ui.clearDrag()
+}
+
+// clearDrag forgets the dragged item
+func (ui *FurnaceUI) clearDrag() {
+	ui.DraggedItem = nil
+	ui.DragFromStation = -1
+	ui.DragFromPlayer = -1
+}
+
+// Update handles input and updates UI state
+func (ui *FurnaceUI) Update() error {
+	if !ui.Open || ui.Station == nil {
+		return nil
+	}
+
+	// Get mouse position
+	mx, my := ebiten.CursorPosition()
+	mouseX, mouseY := float64(mx), float64(my)
+
+	// Update hover state
+	ui.updateHover(mouseX, mouseY)
+
+	// Handle clicks
+	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) && ui.DraggedItem == nil {
+		ui.handleClick()
+	}
+
+	// Handle right clicks (quick transfer)
+	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonRight) && ui.DraggedItem == nil {
+		ui.handleRightClick()
+	}
+
+	// Update dragged item position
+	if ui.DraggedItem != nil {
+		ui.DragX = mouseX - ui.SlotSize/2
+		ui.DragY = mouseY - ui.SlotSize/2
+	}
+
+	// Drop dragged item on mouse release
+	if ui.DraggedItem != nil && inpututil.IsMouseButtonJustReleased(ebiten.MouseButtonLeft) {
+		ui.handleDrop()
+	}
+
+	return nil
+}
+
+// updateHover updates the hovered slot
+func (ui *FurnaceUI) updateHover(mx, my float64) {
+	ui.HoveredStation = -1
+	ui.HoveredPlayerSlot = -1
+
+	inside := func(x, y float64) bool {
+		return mx >= x && mx <= x+ui.SlotSize && my >= y && my <= y+ui.SlotSize
+	}
+	for slot := 0; slot < station.SlotCount; slot++ {
+		if inside(ui.stationSlotPosition(slot)) {
+			ui.HoveredStation = slot
+			return
+		}
+	}
+	for i := range ui.PlayerInventory.Slots {
+		if inside(ui.playerSlotPosition(i)) {
+			ui.HoveredPlayerSlot = i
+			return
+		}
+	}
+}
+
+// handleClick picks up the hovered stack
+func (ui *FurnaceUI) handleClick() {
+	switch {
+	case ui.HoveredStation >= 0:
+		item := ui.Station.Take(ui.HoveredStation)
+		if item.Type != items.NONE {
+			ui.DraggedItem = &item
+			ui.DragFromStation = ui.HoveredStation
+		}
+
+	case ui.HoveredPlayerSlot >= 0:
+		item := &ui.PlayerInventory.Slots[ui.HoveredPlayerSlot]
+		if item.Type != items.NONE {
+			dragged := *item
+			ui.DraggedItem = &dragged
+			ui.DragFromPlayer = ui.HoveredPlayerSlot
+
+			// Clear the slot
+			*item = items.Item{Type: items.NONE, Quantity: 0, Durability: -1}
+		}
+	}
+}
+
+// handleRightClick moves the hovered stack across: out of the furnace into
+// the inventory, or from the inventory into the input slot if the furnace
+// processes it and otherwise the fuel slot
+func (ui *FurnaceUI) handleRightClick() {
+	switch {
+	case ui.HoveredStation >= 0:
+		item := ui.Station.Slots[ui.HoveredStation]
+		if item.Type == items.NONE {
+			return
+		}
+		moved := min(item.Quantity, inventorySpace(ui.PlayerInventory, item))
+		if moved <= 0 {
+			return
+		}
+		item = ui.Station.Take(ui.HoveredStation)
+		rest := item
+		item.Quantity = moved
+		rest.Quantity -= moved
+		ui.PlayerInventory.AddStack(item)
+		if rest.Quantity > 0 {
+			ui.Station.Slots[ui.HoveredStation] = rest
+		}
+
+	case ui.HoveredPlayerSlot >= 0:
+		item := &ui.PlayerInventory.Slots[ui.HoveredPlayerSlot]
+		if item.Type == items.NONE {
+			return
+		}
+		added := ui.Station.Insert(station.SlotInput, *item)
+		if added == 0 {
+			added = ui.Station.Insert(station.SlotFuel, *item)
+		}
+		item.Quantity -= added
+		if item.Quantity <= 0 {
+			*item = items.Item{Type: items.NONE, Quantity: 0, Durability: -1}
+		}
+	}
+}
+
+// handleDrop puts the dragged stack in the hovered slot, or back where it
+// came from if it does not fit there
+func (ui *FurnaceUI) handleDrop() {
+	dragged := ui.DraggedItem
+	switch {
+	case ui.HoveredStation >= 0:
+		dragged.Quantity -= ui.Station.Insert(ui.HoveredStation, *dragged)
+
+	case ui.HoveredPlayerSlot >= 0:
+		slot := &ui.PlayerInventory.Slots[ui.HoveredPlayerSlot]
+		if slot.Type == items.NONE {
+			*slot = *dragged
+			dragged.Quantity = 0
+		} else if slot.StacksWith(*dragged) {
+			if props := items.GetItemProperties(slot.Type); props != nil {
+				added := min(dragged.Quantity, props.StackSize-slot.Quantity)
+				slot.Quantity += added
+				dragged.Quantity -= added
+			}
+		}
+	}
+
+	if dragged.Quantity <= 0 {
+		ui.clearDrag()
+		return
+	}
+	ui.returnDragged()
+}
+
+// returnDragged puts the dragged stack back in the slot it came from if that
+// is still free, and otherwise in the player inventory. If neither has room
+// the stack stays dragged.
+func (ui *FurnaceUI) returnDragged() {
+	dragged := ui.DraggedItem
+	if dragged == nil {
+		return
+	}
+	if ui.DragFromPlayer >= 0 && ui.PlayerInventory.Slots[ui.DragFromPlayer].Type == items.NONE {
+		ui.PlayerInventory.Slots[ui.DragFromPlayer] = *dragged
+		ui.clearDrag()
+		return
+	}
+	if ui.DragFromStation >= 0 && ui.Station != nil && ui.Station.Slots[ui.DragFromStation].Type == items.NONE {
+		ui.Station.Slots[ui.DragFromStation] = *dragged
+		ui.clearDrag()
+		return
+	}
+	if inventorySpace(ui.PlayerInventory, *dragged) >= dragged.Quantity {
+		ui.PlayerInventory.AddStack(*dragged)
+		ui.clearDrag()
+	}
+}
+
+// inventorySpace returns how many of an item an inventory has room for
+func inventorySpace(inv *items.Inventory, item items.Item) int {
+	props := items.GetItemProperties(item.Type)
+	if props == nil {
+		return 0
+	}
+	space := 0
+	for _, slot := range inv.Slots {
+		switch {
+		case slot.Type == items.NONE:
+			space += props.StackSize
+		case slot.StacksWith(item):
+			space += max(0, props.StackSize-slot.Quantity)
+		}
+	}
+	return space
+}
+
+// Draw renders the furnace UI
+func (ui *FurnaceUI) Draw(screen *ebiten.Image) {
+	if !ui.Open || ui.Station == nil {
+		return
+	}
+
+	// Draw semi-transparent background
+	bgColor := color.RGBA{20, 20, 30, 230}
+	ebitenutil.DrawRect(screen, 0, 0, float64(ui.ScreenWidth), float64(ui.ScreenHeight), bgColor)
+
+	// Draw furnace label and status
+	ebitenutil.DebugPrintAt(screen, "FURNACE", int(ui.StationX), int(ui.StationY-50))
+	ebitenutil.DebugPrintAt(screen, ui.status(), int(ui.StationX), int(ui.StationY-30))
+
+	// Draw furnace slots
+	for slot := 0; slot < station.SlotCount; slot++ {
+		x, y := ui.stationSlotPosition(slot)
+		ui.drawSlot(screen, x, y, ui.HoveredStation == slot)
+		if item := ui.Station.Slots[slot]; item.Type != items.NONE {
+			ui.drawItem(screen, &item, x+4, y+4)
+		}
+		ebitenutil.DebugPrintAt(screen, furnaceSlotNames[slot], int(x+ui.SlotSize+8), int(y+16))
+	}
+
+	// Draw the flame between input and fuel, shrinking as the fuel burns
+	flameX, flameY := ui.StationX+ui.SlotSize/2-6, ui.StationY+ui.SlotSize+6
+	ebitenutil.DrawRect(screen, flameX, flameY, 12, 28, color.RGBA{50, 40, 40, 255})
+	if burn := ui.Station.BurnFraction(); burn > 0 {
+		height := 28 * burn
+		ebitenutil.DrawRect(screen, flameX, flameY+28-height, 12, height, color.RGBA{255, 140, 30, 255})
+	}
+
+	// Draw the progress arrow towards the output
+	outX, outY := ui.stationSlotPosition(station.SlotOutput)
+	arrowX, arrowY := ui.StationX+ui.SlotSize+70, outY+ui.SlotSize/2-5
+	arrowWidth := outX - arrowX - 10
+	ebitenutil.DrawRect(screen, arrowX, arrowY, arrowWidth, 10, color.RGBA{60, 60, 70, 255})
+	if progress := ui.Station.ProgressFraction(); progress > 0 {
+		ebitenutil.DrawRect(screen, arrowX, arrowY, arrowWidth*progress, 10, color.RGBA{220, 220, 220, 255})
+	}
+
+	// Draw player inventory label
+	ebitenutil.DebugPrintAt(screen, "INVENTORY", int(ui.PlayerInvX)+160, int(ui.PlayerInvY-30))
+
+	// Draw player inventory
+	for i := range ui.PlayerInventory.Slots {
+		x, y := ui.playerSlotPosition(i)
+		ui.drawSlot(screen, x, y, ui.HoveredPlayerSlot == i)
+		if item := &ui.PlayerInventory.Slots[i]; item.Type != items.NONE {
+			ui.drawItem(screen, item, x+4, y+4)
+		}
+	}
+
+	// Draw dragged item
+	if ui.DraggedItem != nil {
+		ui.drawItem(screen, ui.DraggedItem, ui.DragX, ui.DragY)
+	}
+}
+
+// status describes what the furnace is doing
+func (ui *FurnaceUI) status() string {
+	s := ui.Station
+	recipe := s.Recipe()
+	switch {
+	case recipe != nil && (s.Burning() || s.Slots[station.SlotFuel].Type != items.NONE):
+		return fmt.Sprintf("Making %s (%.0fs each)", items.ItemNameByID(recipe.Output), recipe.Time)
+	case recipe != nil:
+		return "Needs fuel"
+	case s.Slots[station.SlotInput].Type != items.NONE:
+		return "Output is full"
+	case s.Burning():
+		return "Burning"
+	}
+	return "Put something to smelt in the input and fuel below it"
+}
+
+// drawSlot draws an empty slot, highlighted when hovered
+func (ui *FurnaceUI) drawSlot(screen *ebiten.Image, x, y float64, hovered bool) {
+	bgColor := color.RGBA{50, 50, 60, 255}
+	if hovered {
+		bgColor = color.RGBA{70, 70, 90, 255}
+	}
+	ebitenutil.DrawRect(screen, x, y, ui.SlotSize, ui.SlotSize, bgColor)
+
+	borderColor := color.RGBA{80, 80, 100, 255}
+	ebitenutil.DrawRect(screen, x, y, ui.SlotSize, 2, borderColor)
+	ebitenutil.DrawRect(screen, x, y+ui.SlotSize-2, ui.SlotSize, 2, borderColor)
+	ebitenutil.DrawRect(screen, x, y, 2, ui.SlotSize, borderColor)
+	ebitenutil.DrawRect(screen, x+ui.SlotSize-2, y, 2, ui.SlotSize, borderColor)
+}
+
+// drawItem draws an item at the specified position
+func (ui *FurnaceUI) drawItem(screen *ebiten.Image, item *items.Item, x, y float64) {
+	ebitenutil.DrawRect(screen, x, y, ui.SlotSize-8, ui.SlotSize-8, item.DisplayColor())
+	if item.Quantity > 1 {
+		ebitenutil.DebugPrintAt(screen, fmt.Sprintf("%d", item.Quantity), int(x)+25, int(y)+25)
+	}
+}
+
+// IsOpen returns whether the UI is open
+func (ui *FurnaceUI) IsOpen() bool {
+	return ui.Open
+}
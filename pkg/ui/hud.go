@@ -86,6 +86,7 @@ func (h *HUD) Draw(screen *ebiten.Image) {
 		h.drawHungerBar(screen)
 		h.drawThirstBar(screen)
 		h.drawStaminaBar(screen)
+		h.drawEffects(screen)
 	}
 
 	// Draw day/night indicator
@@ -161,6 +162,12 @@ func (h *HUD) drawHungerBar(screen *ebiten.Image) {
 
 	ebitenutil.DrawRect(screen, h.HungerBarX, h.HungerBarY, fillWidth, h.BarHeight, fillColor)
 
+	// Saturation as a gold strip along the top
+	if h.SurvivalManager.MaxSaturation > 0 && h.SurvivalManager.Saturation > 0 {
+		satWidth := h.BarWidth * h.SurvivalManager.Saturation / h.SurvivalManager.MaxSaturation
+		ebitenutil.DrawRect(screen, h.HungerBarX, h.HungerBarY, satWidth, 3, color.RGBA{255, 200, 50, 255})
+	}
+
 	// Icon
 	h.drawIcon(screen, h.HungerBarX-18, h.HungerBarY, color.RGBA{139, 90, 43, 255}, "HU")
 
@@ -239,6 +246,18 @@ func (h *HUD) drawStaminaBar(screen *ebiten.Image) {
 	ebitenutil.DebugPrintAt(screen, text, int(h.StaminaBarX+h.BarWidth+5), int(h.StaminaBarY))
 }
 
+// drawEffects lists the effects from food and drink above the health bar,
+// with the seconds each has left
+func (h *HUD) drawEffects(screen *ebiten.Image) {
+	if h.SurvivalManager.Effects == nil {
+		return
+	}
+	for i, effect := range h.SurvivalManager.Effects.Effects {
+		text := fmt.Sprintf("%s %.0fs", effect.Type, effect.GetRemainingTime().Seconds())
+		ebitenutil.DebugPrintAt(screen, text, int(h.HealthBarX), int(h.HealthBarY)-28-14*i)
+	}
+}
+
 // drawDayNightIndicator draws the sun/moon indicator
 func (h *HUD) drawDayNightIndicator(screen *ebiten.Image) {
 	if h.DayNightCycle == nil {
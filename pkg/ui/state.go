@@ -14,6 +14,8 @@ const (
 	StatePluginUI
 	StateSkinEditor
 	StateDeathScreen
+	StateAnvil
+	StateFurnace
 )
 
 // StateManager manages game state transitions
@@ -83,6 +85,10 @@ func (s GameState) String() string {
 		return "SkinEditor"
 	case StateDeathScreen:
 		return "DeathScreen"
+	case StateAnvil:
+		return "Anvil"
+	case StateFurnace:
+		return "Furnace"
 	default:
 		return "Unknown"
 	}
@@ -133,6 +133,9 @@ type Village struct {
 	// State
 	Attacked    bool `json:"attacked"`
 	UnderAttack bool `json:"under_attack"`
+
+	// StructureID links a village to the one the world generator built
+	StructureID string `json:"structure_id,omitempty"`
 }
 
 // Building represents a village structure
@@ -142,6 +145,8 @@ type Building struct {
 	X        float64 `json:"x"`
 	Y        float64 `json:"y"`
 	Occupied bool    `json:"occupied"`
+
+	StructureID string `json:"structure_id,omitempty"`
 }
 
 // NewVillage creates a new village
@@ -387,3 +392,28 @@ func (vm *VillageManager) GenerateDefaultVillage(name, worldID string, centerX,
 
 	return village
 }
+
+// AddGeneratedBuilding records a building the world generator built. The
+// first building of a generated village creates the village with default
+// NPCs; buildings already recorded are ignored.
+func (vm *VillageManager) AddGeneratedBuilding(structureID, worldID string, centerX, centerY float64, building Building) *Village {
+	var village *Village
+	for _, v := range vm.GetVillagesByWorld(worldID) {
+		if v.StructureID == structureID {
+			village = v
+			break
+		}
+	}
+	if village == nil {
+		village = vm.GenerateDefaultVillage("Village", worldID, centerX, centerY)
+		village.StructureID = structureID
+	}
+
+	for _, existing := range village.Buildings {
+		if existing.ID == building.ID {
+			return village
+		}
+	}
+	village.Buildings = append(village.Buildings, building)
+	return village
+}
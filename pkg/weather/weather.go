@@ -1,12 +1,10 @@
 package weather
 
 import (
-	"image/color"
 	"math/rand"
 	"time"
 
-	"github.com/hajimehoshi/ebiten/v2"
-	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"tesselbox/pkg/biomes"
 )
 
 // WeatherType represents different types of weather
@@ -50,6 +48,13 @@ type WeatherSystem struct {
 	// Particle effects
 	rainParticles []*WeatherParticle
 	snowParticles []*WeatherParticle
+
+	// Wind gusts: noise sampled along elapsed time
+	windNoise *biomes.SimplexNoise
+	windTime  float64
+
+	// Biome whose weather weights scale the chances above
+	biome biomes.BiomeType
 }
 
 // WeatherParticle represents a weather effect particle
@@ -77,6 +82,7 @@ func NewWeatherSystem() *WeatherSystem {
 		RainToClearProb:  0.03,  // 3% chance per minute to stop raining
 		ClearToSnowProb:  0.005, // 0.5% chance per minute for snow (cold areas)
 		SnowToClearProb:  0.02,  // 2% chance per minute to stop snowing
+		windNoise:        biomes.NewSimplexNoise(float64(time.Now().UnixNano())),
 	}
 
 	// Pre-allocate particle pools
@@ -96,6 +102,7 @@ func NewWeatherSystem() *WeatherSystem {
 // Update updates the weather system
 func (ws *WeatherSystem) Update(deltaTime float64, screenWidth, screenHeight int) {
 	now := time.Now()
+	ws.windTime += deltaTime
 
 	// Handle weather transitions
 	if ws.IsTransitioning {
@@ -116,6 +123,12 @@ func (ws *WeatherSystem) Update(deltaTime float64, screenWidth, screenHeight int
 	ws.updateParticles(deltaTime, screenWidth, screenHeight)
 }
 
+// SetBiome sets the biome the weather is over, whose weather weights make
+// rain, storms and snow more or less likely to start
+func (ws *WeatherSystem) SetBiome(biome biomes.BiomeType) {
+	ws.biome = biome
+}
+
 // transitionToNewWeather transitions to a new weather state
 func (ws *WeatherSystem) transitionToNewWeather() {
 	currentType := ws.CurrentWeather.Type
@@ -123,15 +136,22 @@ func (ws *WeatherSystem) transitionToNewWeather() {
 	var newType WeatherType
 	var duration time.Duration
 
-	// Determine next weather based on current weather and probabilities
+	// Determine next weather based on current weather and probabilities,
+	// weighted by the biome the weather is over
+	clearToRain, rainToStorm, clearToSnow := ws.ClearToRainProb, ws.RainToStormProb, ws.ClearToSnowProb
+	if props := biomes.Definition(ws.biome); props != nil && props.Weather != nil {
+		clearToRain *= props.Weather.Rain
+		rainToStorm *= props.Weather.Storm
+		clearToSnow *= props.Weather.Snow
+	}
 	randVal := rand.Float64()
 
 	switch currentType {
 	case Clear:
-		if randVal < ws.ClearToRainProb {
+		if randVal < clearToRain {
 			newType = Rain
 			duration = time.Duration(300+rand.Intn(600)) * time.Second // 5-15 minutes
-		} else if randVal < ws.ClearToRainProb+ws.ClearToSnowProb {
+		} else if randVal < clearToRain+clearToSnow {
 			newType = Snow
 			duration = time.Duration(180+rand.Intn(420)) * time.Second // 3-10 minutes
 		} else {
@@ -139,10 +159,10 @@ func (ws *WeatherSystem) transitionToNewWeather() {
 			duration = time.Duration(60+rand.Intn(240)) * time.Second // 1-5 minutes
 		}
 	case Rain:
-		if randVal < ws.RainToStormProb {
+		if randVal < rainToStorm {
 			newType = Storm
 			duration = time.Duration(120+rand.Intn(300)) * time.Second // 2-7 minutes
-		} else if randVal < ws.RainToStormProb+ws.RainToClearProb {
+		} else if randVal < rainToStorm+ws.RainToClearProb {
 			newType = Clear
 			duration = time.Duration(60+rand.Intn(180)) * time.Second // 1-4 minutes
 		} else {
@@ -268,7 +288,7 @@ func (ws *WeatherSystem) updateRainParticles(deltaTime float64, screenWidth, scr
 		if particle != nil {
 			particle.X = rand.Float64()*float64(screenWidth+100) - 50
 			particle.Y = -10
-			particle.VX = weather.WindSpeed * 0.3
+			particle.VX = weather.WindSpeed * 0.3 * ws.Gust()
 			particle.VY = 300 + rand.Float64()*100 // Fast falling
 			particle.Life = weather.ParticleLifetime
 			particle.MaxLife = weather.ParticleLifetime
@@ -301,8 +321,8 @@ func (ws *WeatherSystem) updateSnowParticles(deltaTime float64, screenWidth, scr
 		if particle != nil {
 			particle.X = rand.Float64()*float64(screenWidth+100) - 50
 			particle.Y = -10
-			particle.VX = weather.WindSpeed * 0.1 * (rand.Float64()*2 - 1) // Gentle horizontal drift
-			particle.VY = 30 + rand.Float64()*20                           // Slow falling
+			particle.VX = weather.WindSpeed * 0.1 * (ws.Gust() + rand.Float64() - 0.5) // Gentle horizontal drift
+			particle.VY = 30 + rand.Float64()*20                                       // Slow falling
 			particle.Life = weather.ParticleLifetime
 			particle.MaxLife = weather.ParticleLifetime
 			particle.Active = true
@@ -324,6 +344,12 @@ func (ws *WeatherSystem) updateSnowParticles(deltaTime float64, screenWidth, scr
 	}
 }
 
+// Gust returns the current wind strength relative to the weather's wind
+// speed, wandering smoothly between 0.5 and 1.5
+func (ws *WeatherSystem) Gust() float64 {
+	return 1 + 0.5*ws.windNoise.FBM2D(ws.windTime*0.15, 0, 3, 2, 0.5)
+}
+
 // getInactiveRainParticle returns an inactive rain particle
 func (ws *WeatherSystem) getInactiveRainParticle() *WeatherParticle {
 	for _, p := range ws.rainParticles {
@@ -431,55 +457,21 @@ func (ws *WeatherSystem) GetCurrentWeather() string {
 	}
 }
 
-// Draw renders weather particles
-func (ws *WeatherSystem) Draw(screen *ebiten.Image, cameraX, cameraY float64) {
-	weather := ws.CurrentWeather
+// ActiveWeather returns the weather whose effects are showing, which is the
+// incoming weather during a transition
+func (ws *WeatherSystem) ActiveWeather() *WeatherState {
 	if ws.IsTransitioning {
-		weather = ws.NextWeather
-	}
-
-	switch weather.Type {
-	case Rain, Storm:
-		ws.drawRainParticles(screen, cameraX, cameraY, weather.Intensity)
-	case Snow:
-		ws.drawSnowParticles(screen, cameraX, cameraY, weather.Intensity)
+		return ws.NextWeather
 	}
+	return ws.CurrentWeather
 }
 
-// drawRainParticles draws rain particles
-func (ws *WeatherSystem) drawRainParticles(screen *ebiten.Image, cameraX, cameraY, intensity float64) {
-	for _, particle := range ws.rainParticles {
-		if particle.Active {
-			screenX := particle.X - cameraX
-			screenY := particle.Y - cameraY
-
-			// Draw rain drop as a line
-			length := 8.0 * intensity
-			alpha := uint8(200 * intensity * (particle.Life / particle.MaxLife))
-
-			// Draw as a vertical line
-			ebitenutil.DrawLine(screen, screenX, screenY, screenX, screenY+length, color.RGBA{200, 220, 255, alpha})
-		}
-	}
+// RainParticles returns the rain particle pool
+func (ws *WeatherSystem) RainParticles() []*WeatherParticle {
+	return ws.rainParticles
 }
 
-// drawSnowParticles draws snow particles
-func (ws *WeatherSystem) drawSnowParticles(screen *ebiten.Image, cameraX, cameraY, intensity float64) {
-	for _, particle := range ws.snowParticles {
-		if particle.Active {
-			screenX := particle.X - cameraX
-			screenY := particle.Y - cameraY
-
-			// Draw snowflake as small circles
-			size := 2.0 + rand.Float64()*2.0 // Random size
-			alpha := uint8(180 * intensity * (particle.Life / particle.MaxLife))
-
-			// Draw multiple small dots for snowflake effect
-			for i := 0; i < 3; i++ {
-				offsetX := (rand.Float64() - 0.5) * 4
-				offsetY := (rand.Float64() - 0.5) * 4
-				ebitenutil.DrawRect(screen, screenX+offsetX-size/2, screenY+offsetY-size/2, size, size, color.RGBA{255, 255, 255, alpha})
-			}
-		}
-	}
+// SnowParticles returns the snow particle pool
+func (ws *WeatherSystem) SnowParticles() []*WeatherParticle {
+	return ws.snowParticles
 }
@@ -5,6 +5,8 @@ import (
 	"time"
 
 	"tesselbox/pkg/blocks"
+	"tesselbox/pkg/palette"
+	"tesselbox/pkg/station"
 )
 
 const (
@@ -30,6 +32,12 @@ type Chunk struct {
 	Modified     bool
 	LastAccessed time.Time
 	LastSaved    time.Time
+
+	// Stations are the block entities of station blocks, such as furnaces,
+	// keyed by the position of their block
+	Stations map[[2]int]*station.Station
+
+	light []uint8 // Sky light in the high nibble, block light in the low nibble
 }
 
 // NewChunk creates a new chunk
@@ -38,6 +46,7 @@ func NewChunk(chunkX, chunkY int) *Chunk {
 		ChunkX:       chunkX,
 		ChunkY:       chunkY,
 		Hexagons:     make(map[[2]int]*Hexagon),
+		Stations:     make(map[[2]int]*station.Station),
 		Modified:     false,
 		LastAccessed: time.Now(),
 		LastSaved:    time.Time{},
@@ -51,6 +60,20 @@ func (c *Chunk) GetWorldPosition() (float64, float64) {
 	return worldX, worldY
 }
 
+// cellPosition returns the world position of the cell at local column and row
+func (c *Chunk) cellPosition(col, row int) (float64, float64) {
+	worldX, worldY := c.GetWorldPosition()
+
+	// Calculate hexagon position with interlocking pattern
+	var x float64
+	if row%2 == 0 {
+		x = worldX + float64(col)*HexWidth + HexWidth/2
+	} else {
+		x = worldX + float64(col)*HexWidth + HexWidth
+	}
+	return x, worldY + float64(row)*HexVSpacing + HexSize
+}
+
 // GetHexagon returns the hexagon at the given world coordinates
 func (c *Chunk) GetHexagon(x, y float64) *Hexagon {
 	// Use PixelToHexCenter to get accurate hexagon coordinates
@@ -166,28 +189,45 @@ type ChunkData struct {
 	ChunkX   int                           `json:"chunk_x"`
 	ChunkY   int                           `json:"chunk_y"`
 	Hexagons map[string]*SerializedHexagon `json:"hexagons"`
+	Stations map[string]*station.Record    `json:"stations,omitempty"` // Keyed by block position
 }
 
 // SerializedHexagon represents a hexagon that can be serialized to JSON
 type SerializedHexagon struct {
-	X         float64          `json:"x"`
-	Y         float64          `json:"y"`
-	Size      float64          `json:"size"`
-	BlockType blocks.BlockType `json:"block_type"`
-	Health    float64          `json:"health"`
+	X         float64 `json:"x"`
+	Y         float64 `json:"y"`
+	Size      float64 `json:"size"`
+	BlockType int     `json:"block_type"` // Index into the world's block palette
+	Health    float64 `json:"health"`
+
+	// Liquid state; absent on liquids saved before the liquid simulation,
+	// which load as full sources
+	LiquidLevel  int  `json:"liquid_level,omitempty"`
+	LiquidSource bool `json:"liquid_source,omitempty"`
 }
 
-// ToChunkData converts a chunk to serializable format
-func (c *Chunk) ToChunkData() *ChunkData {
+// ToChunkData converts a chunk to serializable format, recording block types
+// as indices into the world's block palette
+func (c *Chunk) ToChunkData(blockPalette *palette.Palette) *ChunkData {
 	hexagons := make(map[string]*SerializedHexagon)
 	for key, hex := range c.Hexagons {
 		keyStr := fmt.Sprintf("%d,%d", key[0], key[1])
 		hexagons[keyStr] = &SerializedHexagon{
-			X:         hex.X,
-			Y:         hex.Y,
-			Size:      hex.Size,
-			BlockType: hex.BlockType,
-			Health:    hex.Health,
+			X:            hex.X,
+			Y:            hex.Y,
+			Size:         hex.Size,
+			BlockType:    encodeBlock(blockPalette, hex.BlockType),
+			Health:       hex.Health,
+			LiquidLevel:  hex.LiquidLevel,
+			LiquidSource: hex.LiquidSource,
+		}
+	}
+
+	var stations map[string]*station.Record
+	if len(c.Stations) > 0 {
+		stations = make(map[string]*station.Record, len(c.Stations))
+		for key, s := range c.Stations {
+			stations[fmt.Sprintf("%d,%d", key[0], key[1])] = s.Record()
 		}
 	}
 
@@ -195,11 +235,12 @@ func (c *Chunk) ToChunkData() *ChunkData {
 		ChunkX:   c.ChunkX,
 		ChunkY:   c.ChunkY,
 		Hexagons: hexagons,
+		Stations: stations,
 	}
 }
 
 // FromChunkData loads chunk data from serializable format
-func (c *Chunk) FromChunkData(data *ChunkData) {
+func (c *Chunk) FromChunkData(data *ChunkData, blockPalette *palette.Palette) {
 	c.ChunkX = data.ChunkX
 	c.ChunkY = data.ChunkY
 	c.Hexagons = make(map[[2]int]*Hexagon)
@@ -212,14 +253,38 @@ func (c *Chunk) FromChunkData(data *ChunkData) {
 			X:         serHex.X,
 			Y:         serHex.Y,
 			Size:      serHex.Size,
-			BlockType: serHex.BlockType,
+			BlockType: decodeBlock(blockPalette, serHex.BlockType),
 			Health:    serHex.Health,
 			ChunkX:    c.ChunkX,
 			ChunkY:    c.ChunkY,
 		}
+		if blocks.IsLiquidBlock(hex.BlockType) {
+			hex.LiquidLevel = serHex.LiquidLevel
+			hex.LiquidSource = serHex.LiquidSource
+			if hex.LiquidLevel <= 0 || hex.LiquidLevel > LiquidMaxLevel {
+				hex.LiquidLevel = LiquidMaxLevel
+				hex.LiquidSource = true
+			}
+		}
 		c.Hexagons[key] = hex
 	}
 
+	// Stations whose block is gone are dropped
+	c.Stations = make(map[[2]int]*station.Station)
+	if len(data.Stations) > 0 {
+		blocksAt := make(map[[2]int]*Hexagon, len(c.Hexagons))
+		for _, hex := range c.Hexagons {
+			blocksAt[stationKey(hex.X, hex.Y)] = hex
+		}
+		for keyStr, record := range data.Stations {
+			var key [2]int
+			fmt.Sscanf(keyStr, "%d,%d", &key[0], &key[1])
+			if hex, ok := blocksAt[key]; ok && record != nil {
+				c.Stations[key] = station.FromRecord(record, hex.X, hex.Y)
+			}
+		}
+	}
+
 	c.Modified = false
 	c.LastSaved = time.Now()
 }
@@ -0,0 +1,121 @@
+package world
+
+import (
+	"math"
+)
+
+// GeneratorVersion identifies the terrain generator revision. Worlds record the
+// version they were created with so unsaved chunks regenerate the same way.
+//
+//	1  initial generator
+//	2  oceans filled with water up to SeaLevel
+//	3  villages, dungeons and ruins
+//	4  caves, ore veins, underground lakes and a bedrock floor
+//	5  gradient noise in place of sine noise for terrain, biomes and caves
+//	6  every organism in a biome's table can grow, not only the first
+//	7  biomes registered by plugins take part in biome placement
+const GeneratorVersion = 7
+
+// GenerationParams holds the tunable parameters of terrain generation.
+// They are persisted in world metadata alongside the seed.
+type GenerationParams struct {
+	// Terrain noise amplitudes, from continental to detail scale
+	ContinentalAmplitude float64 `json:"continental_amplitude"`
+	RegionalAmplitude    float64 `json:"regional_amplitude"`
+	LocalAmplitude       float64 `json:"local_amplitude"`
+	DetailAmplitude      float64 `json:"detail_amplitude"`
+	RiverAmplitude       float64 `json:"river_amplitude"`
+
+	// OreMultiplier scales every biome's ore frequency
+	OreMultiplier float64 `json:"ore_multiplier"`
+	// OrganismMultiplier scales every biome's organism spawn chance
+	OrganismMultiplier float64 `json:"organism_multiplier"`
+
+	// SeaLevel is the world Y below which ocean biomes are filled with water
+	SeaLevel float64 `json:"sea_level"`
+	// BedrockLevel is the world Y of the bedrock floor at the bottom of the world
+	BedrockLevel float64 `json:"bedrock_level,omitempty"`
+}
+
+// DefaultGenerationParams returns the parameters used for new worlds
+func DefaultGenerationParams() GenerationParams {
+	return GenerationParams{
+		ContinentalAmplitude: 200,
+		RegionalAmplitude:    100,
+		LocalAmplitude:       40,
+		DetailAmplitude:      8,
+		RiverAmplitude:       30,
+		OreMultiplier:        1.0,
+		OrganismMultiplier:   1.0,
+		SeaLevel:             500,
+		BedrockLevel:         3600,
+	}
+}
+
+// withDefaults fills zero fields (e.g. from older metadata) with default values
+func (p GenerationParams) withDefaults() GenerationParams {
+	defaults := DefaultGenerationParams()
+	if p.ContinentalAmplitude == 0 {
+		p.ContinentalAmplitude = defaults.ContinentalAmplitude
+	}
+	if p.RegionalAmplitude == 0 {
+		p.RegionalAmplitude = defaults.RegionalAmplitude
+	}
+	if p.LocalAmplitude == 0 {
+		p.LocalAmplitude = defaults.LocalAmplitude
+	}
+	if p.DetailAmplitude == 0 {
+		p.DetailAmplitude = defaults.DetailAmplitude
+	}
+	if p.RiverAmplitude == 0 {
+		p.RiverAmplitude = defaults.RiverAmplitude
+	}
+	if p.OreMultiplier == 0 {
+		p.OreMultiplier = defaults.OreMultiplier
+	}
+	if p.OrganismMultiplier == 0 {
+		p.OrganismMultiplier = defaults.OrganismMultiplier
+	}
+	if p.SeaLevel == 0 {
+		p.SeaLevel = defaults.SeaLevel
+	}
+	if p.BedrockLevel == 0 {
+		p.BedrockLevel = defaults.BedrockLevel
+	}
+	return p
+}
+
+// Salts separating the independent random streams used during generation
+const (
+	saltOre      uint64 = 0x6f7265 // "ore"
+	saltOrganism uint64 = 0x6f7267 // "org"
+
+	saltVillage   uint64 = 0x76696c // "vil"
+	saltDungeon   uint64 = 0x64756e // "dun"
+	saltRuin      uint64 = 0x72756e // "run"
+	saltRuinDecay uint64 = 0x646563 // "dec"
+
+	saltCaveTunnel uint64 = 0x74756e // "tun"
+	saltCavern     uint64 = 0x636176 // "cav"
+	saltLake       uint64 = 0x6c616b // "lak"
+	saltOreVein    uint64 = 0x76656e // "ven"
+	saltBedrock    uint64 = 0x626564 // "bed"
+)
+
+// positionRandom returns a deterministic value in [0, 1) for a world position.
+// Unlike reseeding math/rand, it has no shared state, so generation order and
+// other users of the global generator cannot change the result.
+func positionRandom(seed int64, x, y float64, salt uint64) float64 {
+	h := mix64(uint64(seed) ^ salt)
+	h = mix64(h ^ uint64(int64(math.Floor(x))))
+	h = mix64(h ^ uint64(int64(math.Floor(y))))
+	return float64(h>>11) / (1 << 53)
+}
+
+// mix64 is the SplitMix64 finalizer
+func mix64(h uint64) uint64 {
+	h += 0x9e3779b97f4a7c15
+	h = (h ^ (h >> 30)) * 0xbf58476d1ce4e5b9
+	h = (h ^ (h >> 27)) * 0x94d049bb133111eb
+	return h ^ (h >> 31)
+}
@@ -0,0 +1,103 @@
+package world
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"tesselbox/pkg/blocks"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// generationSeed is the seed every generation test world uses
+const generationSeed = 20240917
+
+// generationChunks cover sky, surface and underground chunks on both sides
+// of the origin
+var generationChunks = [][2]int{{0, -1}, {0, 0}, {5, 0}, {-7, 0}, {2, 1}, {-3, 2}}
+
+// generatedChunk generates a chunk of a fresh world with the given seed and
+// generator version and returns it as saved to disk
+func generatedChunk(t *testing.T, seed int64, version, chunkX, chunkY int) []byte {
+	t.Helper()
+	if len(blocks.BlockDefinitions) == 0 {
+		blocks.LoadBlocks()
+	}
+
+	w := newWorld("generation_test", seed, DefaultGenerationParams())
+	w.Storage = nil
+	w.GeneratorVersion = version
+	w.noiseGenerator = w.newNoise()
+
+	chunk := NewChunk(chunkX, chunkY)
+	w.generateChunk(chunk)
+	data, err := json.Marshal(chunk.ToChunkData(NewBlockPalette()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestGenerateChunkRepeatable(t *testing.T) {
+	for _, key := range generationChunks {
+		first := generatedChunk(t, generationSeed, GeneratorVersion, key[0], key[1])
+		second := generatedChunk(t, generationSeed, GeneratorVersion, key[0], key[1])
+		if string(first) != string(second) {
+			t.Errorf("chunk %v generated differently the second time", key)
+		}
+	}
+}
+
+// TestGenerateChunkGolden checks every generator version still generates the
+// chunks it did when it was released. Worlds regenerate unsaved chunks with
+// the version they were created with, so a failure here means a change that
+// needs a new GeneratorVersion, not new hashes.
+func TestGenerateChunkGolden(t *testing.T) {
+	golden := filepath.Join("testdata", "generation.golden")
+
+	var lines []string
+	for version := 1; version <= GeneratorVersion; version++ {
+		for _, key := range generationChunks {
+			sum := sha256.Sum256(generatedChunk(t, generationSeed, version, key[0], key[1]))
+			lines = append(lines, fmt.Sprintf("v%d %d,%d %x", version, key[0], key[1], sum))
+		}
+	}
+
+	if *update {
+		if err := os.WriteFile(golden, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	file, err := os.Open(golden)
+	if err != nil {
+		t.Fatalf("%v (run go test -update to create it)", err)
+	}
+	defer file.Close()
+	want := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if fields := strings.Fields(scanner.Text()); len(fields) == 3 {
+			want[fields[0]+" "+fields[1]] = fields[2]
+		}
+	}
+
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		chunk, sum := fields[0]+" "+fields[1], fields[2]
+		switch expected, ok := want[chunk]; {
+		case !ok:
+			t.Errorf("%s: no golden hash (run go test -update after bumping GeneratorVersion)", chunk)
+		case expected != sum:
+			t.Errorf("%s: generated chunk changed", chunk)
+		}
+	}
+}
@@ -34,6 +34,10 @@ type Hexagon struct {
 	Corners     [][2]float64
 	ChunkX      int
 	ChunkY      int
+
+	// Liquid state, only meaningful for liquid blocks
+	LiquidLevel  int  // 1 to LiquidMaxLevel
+	LiquidSource bool // Source cells never drain
 }
 
 // NewHexagon creates a new hexagon at the specified position
@@ -71,6 +75,12 @@ func NewHexagon(x, y, size float64, blockType blocks.BlockType) *Hexagon {
 		Corners:     make([][2]float64, 6),
 	}
 
+	if blocks.IsLiquidBlock(blockType) {
+		// Placed and generated liquid starts as a full source
+		h.LiquidLevel = LiquidMaxLevel
+		h.LiquidSource = true
+	}
+
 	h.calculateCorners()
 	return h
 }
@@ -167,6 +177,9 @@ func getBlockKey(blockType blocks.BlockType) string {
 	case blocks.CACTUS:
 		return "cactus"
 	default:
+		if id := blocks.BlockID(blockType); id != "" {
+			return id
+		}
 		return "dirt"
 	}
 }
@@ -194,6 +207,45 @@ func (h *Hexagon) GetTopSurfaceY(x float64) float64 {
 	return h.Y
 }
 
+// CellAt returns the global column and row of the grid cell whose centre is
+// nearest to a world position, using the same row layout as generateChunk
+func CellAt(x, y float64) (col, row int) {
+	row = int(math.Round((y - HexSize) / HexVSpacing))
+	col = int(math.Round((x - cellOffset(row)) / HexWidth))
+	return col, row
+}
+
+// CellCenter returns the world position of a grid cell's centre
+func CellCenter(col, row int) (float64, float64) {
+	return float64(col)*HexWidth + cellOffset(row), float64(row)*HexVSpacing + HexSize
+}
+
+// cellOffset is the horizontal offset of a row; odd rows sit half a hexagon right
+func cellOffset(row int) float64 {
+	if row&1 != 0 {
+		return HexWidth
+	}
+	return HexWidth / 2
+}
+
+// CellNeighbors returns the six cells touching a cell, ordered left, right,
+// up-left, up-right, down-left, down-right
+func CellNeighbors(col, row int) [6][2]int {
+	// Rows interlock, so the diagonal neighbours shift with row parity
+	left := col - 1
+	if row&1 != 0 {
+		left = col
+	}
+	return [6][2]int{
+		{col - 1, row},
+		{col + 1, row},
+		{left, row - 1},
+		{left + 1, row - 1},
+		{left, row + 1},
+		{left + 1, row + 1},
+	}
+}
+
 // PixelToHexCenter converts pixel coordinates to hexagon center coordinates
 func PixelToHexCenter(wx, wy float64) (centerX, centerY, col, row float64) {
 	hexSize := HexSize
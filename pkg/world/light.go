@@ -0,0 +1,340 @@
+package world
+
+import (
+	"tesselbox/pkg/blocks"
+)
+
+// MaxLightLevel is the brightest light value, that of open sky
+const MaxLightLevel = 15
+
+// lightChannel selects one of the two independent light values of a cell
+type lightChannel int
+
+const (
+	// skyLight comes from open sky: it falls straight down without fading
+	// until it meets a block, then spreads like block light
+	skyLight lightChannel = iota
+	// blockLight is emitted by blocks with a LightLevel, such as torches and lava
+	blockLight
+)
+
+// lightNode is a queued cell in a light flood fill
+type lightNode struct {
+	col, row int
+	level    int
+}
+
+// floorDiv divides rounding towards negative infinity
+func floorDiv(a, b int) int {
+	q := a / b
+	if (a%b != 0) && ((a < 0) != (b < 0)) {
+		q--
+	}
+	return q
+}
+
+// lightSlot returns the chunk storing a cell's light and the cell's index in
+// it. Light is stored by grid cell, so a cell belongs to the chunk whose rows
+// and columns contain it. It returns nil when that chunk is not loaded.
+func (w *World) lightSlot(col, row int) (*Chunk, int) {
+	chunkX := floorDiv(col, ChunkSize)
+	chunkY := floorDiv(row, ChunkSize)
+	chunk, ok := w.Chunks[[2]int{chunkX, chunkY}]
+	if !ok || chunk.light == nil {
+		return nil, 0
+	}
+	return chunk, (row-chunkY*ChunkSize)*ChunkSize + (col - chunkX*ChunkSize)
+}
+
+// getLight returns a cell's light on one channel, zero if its chunk is not loaded
+func (w *World) getLight(channel lightChannel, col, row int) int {
+	chunk, idx := w.lightSlot(col, row)
+	if chunk == nil {
+		return 0
+	}
+	if channel == skyLight {
+		return int(chunk.light[idx] >> 4)
+	}
+	return int(chunk.light[idx] & 0x0f)
+}
+
+// setLight stores a cell's light on one channel
+func (w *World) setLight(channel lightChannel, col, row, level int) {
+	chunk, idx := w.lightSlot(col, row)
+	if chunk == nil {
+		return
+	}
+	if channel == skyLight {
+		chunk.light[idx] = chunk.light[idx]&0x0f | uint8(level)<<4
+	} else {
+		chunk.light[idx] = chunk.light[idx]&0xf0 | uint8(level)
+	}
+}
+
+// lightOpacity returns how much light a cell absorbs on top of the one level
+// lost per step: none for air, one for glass, water and other transparent
+// blocks, and everything for opaque blocks and unloaded cells
+func (w *World) lightOpacity(col, row int) int {
+	cell := w.hexagonAtCell(col, row)
+	if !cell.loaded {
+		return MaxLightLevel
+	}
+	if cell.empty() {
+		return 0
+	}
+	def := blocks.BlockDefinitions[getBlockKey(cell.hex.BlockType)]
+	if def == nil || !def.Transparent {
+		return MaxLightLevel
+	}
+	return 1
+}
+
+// lightEmission returns the block light a cell's block gives off
+func (w *World) lightEmission(col, row int) int {
+	cell := w.hexagonAtCell(col, row)
+	if cell.hex == nil {
+		return 0
+	}
+	def := blocks.BlockDefinitions[getBlockKey(cell.hex.BlockType)]
+	if def == nil {
+		return 0
+	}
+	return clampLight(def.LightLevel)
+}
+
+// clampLight limits a light value to 0..MaxLightLevel
+func clampLight(level int) int {
+	if level < 0 {
+		return 0
+	}
+	if level > MaxLightLevel {
+		return MaxLightLevel
+	}
+	return level
+}
+
+// skyFromAbove returns the sky light entering a cell from the cell directly
+// above it. Above the loaded area the sky is assumed open.
+func (w *World) skyFromAbove(col, row int) int {
+	if chunk, _ := w.lightSlot(col, row-1); chunk == nil {
+		return MaxLightLevel
+	}
+	if w.getLight(skyLight, col, row-1) == MaxLightLevel {
+		return MaxLightLevel
+	}
+	return 0
+}
+
+// propagateLight floods light outward from the queued cells, raising every
+// neighbour that would be brighter lit from them
+func (w *World) propagateLight(channel lightChannel, queue []lightNode) {
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+
+		level := w.getLight(channel, node.col, node.row)
+		if level <= 0 {
+			continue
+		}
+		for _, n := range CellNeighbors(node.col, node.row) {
+			opacity := w.lightOpacity(n[0], n[1])
+			if opacity >= MaxLightLevel {
+				continue
+			}
+			next := level - 1 - opacity
+			if channel == skyLight && level == MaxLightLevel && n == [2]int{node.col, node.row + 1} {
+				next = level - opacity // Open sky falls straight down undimmed
+			}
+			if next > w.getLight(channel, n[0], n[1]) {
+				w.setLight(channel, n[0], n[1], next)
+				queue = append(queue, lightNode{col: n[0], row: n[1]})
+			}
+		}
+	}
+}
+
+// unpropagateLight darkens every cell that was lit through the removed
+// cells and returns the brighter cells bordering the darkened area, which
+// must be propagated again to refill it
+func (w *World) unpropagateLight(channel lightChannel, removed []lightNode) []lightNode {
+	var refill []lightNode
+	for len(removed) > 0 {
+		node := removed[0]
+		removed = removed[1:]
+
+		for _, n := range CellNeighbors(node.col, node.row) {
+			level := w.getLight(channel, n[0], n[1])
+			if level == 0 {
+				continue
+			}
+			below := n == [2]int{node.col, node.row + 1}
+			dependent := level < node.level ||
+				(channel == skyLight && below && node.level == MaxLightLevel && level == MaxLightLevel)
+			if !dependent {
+				refill = append(refill, lightNode{col: n[0], row: n[1]})
+				continue
+			}
+
+			w.setLight(channel, n[0], n[1], 0)
+			removed = append(removed, lightNode{col: n[0], row: n[1], level: level})
+			if channel == blockLight {
+				if emission := w.lightEmission(n[0], n[1]); emission > 0 {
+					w.setLight(channel, n[0], n[1], emission)
+					refill = append(refill, lightNode{col: n[0], row: n[1]})
+				}
+			}
+		}
+	}
+	return refill
+}
+
+// relightCell updates light after the block in a cell changed: light that
+// reached other cells through it is removed, then the cell's own emission,
+// sky from above and its neighbours' light flood back in
+func (w *World) relightCell(col, row int) {
+	if chunk, _ := w.lightSlot(col, row); chunk == nil {
+		return
+	}
+
+	for _, channel := range []lightChannel{skyLight, blockLight} {
+		var refill []lightNode
+		if old := w.getLight(channel, col, row); old > 0 {
+			w.setLight(channel, col, row, 0)
+			refill = w.unpropagateLight(channel, []lightNode{{col: col, row: row, level: old}})
+		}
+
+		own := 0
+		opacity := w.lightOpacity(col, row)
+		if channel == blockLight {
+			own = w.lightEmission(col, row)
+		} else if opacity < MaxLightLevel {
+			own = w.skyFromAbove(col, row) - opacity
+		}
+		if own > 0 {
+			w.setLight(channel, col, row, own)
+			refill = append(refill, lightNode{col: col, row: row})
+		}
+
+		for _, n := range CellNeighbors(col, row) {
+			if w.getLight(channel, n[0], n[1]) > 0 {
+				refill = append(refill, lightNode{col: n[0], row: n[1]})
+			}
+		}
+		w.propagateLight(channel, refill)
+	}
+}
+
+// relightAt updates light around a block placed or removed at a world position
+func (w *World) relightAt(x, y float64) {
+	col, row := CellAt(x, y)
+	w.relightCell(col, row)
+}
+
+// lightChunk computes the light of a chunk that was just loaded: open sky
+// falling from above, emissive blocks, and light spilling in from loaded
+// neighbours. Light is not saved, since it depends on neighbouring chunks.
+func (w *World) lightChunk(chunk *Chunk) {
+	chunk.light = make([]uint8, ChunkSize*ChunkSize)
+	minCol := chunk.ChunkX * ChunkSize
+	minRow := chunk.ChunkY * ChunkSize
+
+	// Sky falls down each column until something dims it
+	var sky []lightNode
+	for col := minCol; col < minCol+ChunkSize; col++ {
+		if w.skyFromAbove(col, minRow) < MaxLightLevel {
+			continue
+		}
+		for row := minRow; row < minRow+ChunkSize; row++ {
+			opacity := w.lightOpacity(col, row)
+			if opacity >= MaxLightLevel {
+				break
+			}
+			w.setLight(skyLight, col, row, MaxLightLevel-opacity)
+			sky = append(sky, lightNode{col: col, row: row})
+			if opacity > 0 {
+				break // Dimmed light spreads from here by flood fill
+			}
+		}
+	}
+
+	var block []lightNode
+	for _, hex := range chunk.Hexagons {
+		def := blocks.BlockDefinitions[getBlockKey(hex.BlockType)]
+		if def == nil || def.LightLevel <= 0 {
+			continue
+		}
+		col, row := CellAt(hex.X, hex.Y)
+		w.setLight(blockLight, col, row, clampLight(def.LightLevel))
+		block = append(block, lightNode{col: col, row: row})
+	}
+
+	// Light already in the neighbouring chunks spills across the border
+	for i := -1; i <= ChunkSize; i++ {
+		for _, n := range [][2]int{
+			{minCol + i, minRow - 1}, {minCol + i, minRow + ChunkSize},
+			{minCol - 1, minRow + i}, {minCol + ChunkSize, minRow + i},
+		} {
+			if w.getLight(skyLight, n[0], n[1]) > 0 {
+				sky = append(sky, lightNode{col: n[0], row: n[1]})
+			}
+			if w.getLight(blockLight, n[0], n[1]) > 0 {
+				block = append(block, lightNode{col: n[0], row: n[1]})
+			}
+		}
+	}
+
+	w.propagateLight(skyLight, sky)
+	w.propagateLight(blockLight, block)
+
+	// The chunk below assumed open sky while this one was missing
+	belowRow := minRow + ChunkSize
+	for col := minCol; col < minCol+ChunkSize; col++ {
+		if w.getLight(skyLight, col, belowRow) == MaxLightLevel && w.skyFromAbove(col, belowRow) < MaxLightLevel {
+			w.relightCell(col, belowRow)
+		}
+	}
+}
+
+// GetLightAt returns the sky and block light of the cell at a world position
+func (w *World) GetLightAt(x, y float64) (sky, block int) {
+	col, row := CellAt(x, y)
+	return w.getLight(skyLight, col, row), w.getLight(blockLight, col, row)
+}
+
+// LightLevelAt returns the brightness at a world position from 0 to 1, with
+// sky light scaled by the day/night ambient light. Positions in chunks that
+// are not loaded fall back to the ambient light.
+func (w *World) LightLevelAt(x, y, ambientLight float64) float64 {
+	col, row := CellAt(x, y)
+	if chunk, _ := w.lightSlot(col, row); chunk == nil {
+		return ambientLight
+	}
+	return w.cellBrightness(col, row, ambientLight)
+}
+
+// BlockBrightness returns how brightly a block is lit from 0 to 1. Opaque
+// blocks hold no light themselves, so the brightest of the block's cell and
+// the cells around it is used, lighting the faces that border open space.
+func (w *World) BlockBrightness(hex *Hexagon, ambientLight float64) float64 {
+	col, row := CellAt(hex.X, hex.Y)
+	if chunk, _ := w.lightSlot(col, row); chunk == nil {
+		return ambientLight
+	}
+	brightness := w.cellBrightness(col, row, ambientLight)
+	for _, n := range CellNeighbors(col, row) {
+		if b := w.cellBrightness(n[0], n[1], ambientLight); b > brightness {
+			brightness = b
+		}
+	}
+	return brightness
+}
+
+// cellBrightness combines a cell's sky and block light into a 0 to 1 value
+func (w *World) cellBrightness(col, row int, ambientLight float64) float64 {
+	sky := float64(w.getLight(skyLight, col, row)) * ambientLight
+	block := float64(w.getLight(blockLight, col, row))
+	if block > sky {
+		return block / MaxLightLevel
+	}
+	return sky / MaxLightLevel
+}
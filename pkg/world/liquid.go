@@ -0,0 +1,362 @@
+package world
+
+import (
+	"sort"
+
+	"tesselbox/pkg/blocks"
+)
+
+const (
+	// LiquidMaxLevel is the level of a full liquid cell; flowing liquid loses
+	// level as it spreads sideways away from its source
+	LiquidMaxLevel = 8
+
+	// liquidBaseInterval is the seconds between liquid ticks at FlowSpeed 1
+	liquidBaseInterval = 0.5
+	// maxLiquidUpdatesPerTick bounds the work one liquid tick may do
+	maxLiquidUpdatesPerTick = 1024
+)
+
+// liquidChange is the new state of one cell decided during a liquid tick
+type liquidChange struct {
+	blockType blocks.BlockType
+	level     int
+	source    bool
+}
+
+// activateLiquidsAround marks a cell and its neighbours for a liquid update,
+// so liquid reacts when a block next to it is placed or broken
+func (w *World) activateLiquidsAround(x, y float64) {
+	col, row := CellAt(x, y)
+	w.liquidActive[[2]int{col, row}] = true
+	for _, n := range CellNeighbors(col, row) {
+		w.liquidActive[n] = true
+	}
+}
+
+// activateChunkLiquids queues every liquid in a newly loaded chunk, and the
+// cells just outside it, so liquid resumes flowing across the chunk border
+func (w *World) activateChunkLiquids(chunk *Chunk) {
+	for _, hex := range chunk.Hexagons {
+		if blocks.IsLiquidBlock(hex.BlockType) {
+			col, row := CellAt(hex.X, hex.Y)
+			w.liquidActive[[2]int{col, row}] = true
+		}
+	}
+
+	minCol := chunk.ChunkX * ChunkSize
+	minRow := chunk.ChunkY * ChunkSize
+	for i := -1; i <= ChunkSize; i++ {
+		w.liquidActive[[2]int{minCol + i, minRow - 1}] = true
+		w.liquidActive[[2]int{minCol + i, minRow + ChunkSize}] = true
+		w.liquidActive[[2]int{minCol - 1, minRow + i}] = true
+		w.liquidActive[[2]int{minCol + ChunkSize, minRow + i}] = true
+	}
+}
+
+// UpdateLiquids advances the liquid simulation. Each liquid ticks at its own
+// rate, derived from its FlowSpeed, and only cells queued as active are
+// examined; cells that did not change settle and leave the queue.
+func (w *World) UpdateLiquids(deltaTime float64) {
+	liquidTypes := make([]blocks.LiquidType, 0, len(blocks.LiquidDefinitions))
+	for liquidType := range blocks.LiquidDefinitions {
+		liquidTypes = append(liquidTypes, liquidType)
+	}
+	sort.Slice(liquidTypes, func(i, j int) bool { return liquidTypes[i] < liquidTypes[j] })
+
+	for _, liquidType := range liquidTypes {
+		props := blocks.LiquidDefinitions[liquidType]
+		if props.FlowSpeed <= 0 {
+			continue
+		}
+		interval := liquidBaseInterval / props.FlowSpeed
+
+		w.liquidTimers[liquidType] += deltaTime
+		if w.liquidTimers[liquidType] < interval {
+			continue
+		}
+		w.liquidTimers[liquidType] = 0
+		w.tickLiquid(liquidType)
+	}
+}
+
+// tickLiquid runs one update of every active cell holding the given liquid.
+// All cells decide their next state from the current one before any change
+// is applied, so the result does not depend on iteration order.
+func (w *World) tickLiquid(liquidType blocks.LiquidType) {
+	blockType := liquidType.Block()
+
+	cells := make([][2]int, 0, len(w.liquidActive))
+	for cell := range w.liquidActive {
+		cells = append(cells, cell)
+	}
+	sort.Slice(cells, func(i, j int) bool {
+		if cells[i][1] != cells[j][1] {
+			return cells[i][1] < cells[j][1]
+		}
+		return cells[i][0] < cells[j][0]
+	})
+
+	changes := make(map[[2]int]liquidChange)
+	updates := 0
+	for _, cell := range cells {
+		current := w.hexagonAtCell(cell[0], cell[1])
+		if !current.loaded || current.hex == nil || !blocks.IsLiquidBlock(current.hex.BlockType) {
+			delete(w.liquidActive, cell) // Nothing here for any liquid to do
+			continue
+		}
+		if current.hex.BlockType != blockType {
+			continue // Another liquid's cell; it is handled on that liquid's tick
+		}
+		if updates >= maxLiquidUpdatesPerTick {
+			break // Remaining cells stay active for the next tick
+		}
+		updates++
+
+		delete(w.liquidActive, cell)
+		w.updateLiquidCell(cell[0], cell[1], current.hex, liquidType, changes)
+	}
+
+	keys := make([][2]int, 0, len(changes))
+	for key := range changes {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][1] != keys[j][1] {
+			return keys[i][1] < keys[j][1]
+		}
+		return keys[i][0] < keys[j][0]
+	})
+	for _, key := range keys {
+		w.applyLiquidChange(key[0], key[1], changes[key])
+	}
+}
+
+// updateLiquidCell decides the next state of one liquid cell and of the empty
+// cells it flows into, recording the results in changes
+func (w *World) updateLiquidCell(col, row int, hex *Hexagon, liquidType blocks.LiquidType, changes map[[2]int]liquidChange) {
+	props := blocks.GetLiquidProperties(liquidType)
+	blockType := hex.BlockType
+	neighbors := CellNeighbors(col, row)
+	var cells [6]gridCell
+	for i, n := range neighbors {
+		cells[i] = w.hexagonAtCell(n[0], n[1])
+	}
+
+	// Lava touching water hardens: sources into obsidian, flows into cobblestone
+	if liquidType == blocks.LAVA_LIQUID {
+		for _, c := range cells {
+			if c.holds(blocks.WATER) {
+				hardened := blocks.COBBLESTONE
+				if hex.LiquidSource {
+					hardened = blocks.OBSIDIAN
+				}
+				changes[[2]int{col, row}] = liquidChange{blockType: hardened}
+				return
+			}
+		}
+	} else {
+		for i, c := range cells {
+			if c.holds(blocks.LAVA) {
+				w.liquidActive[neighbors[i]] = true
+			}
+		}
+	}
+
+	pours := pouring(cells, blockType)
+	level := hex.LiquidLevel
+	source := hex.LiquidSource
+	if !source {
+		level = w.expectedLiquidLevel(col, neighbors, cells, blockType, props)
+		if level <= 0 {
+			changes[[2]int{col, row}] = liquidChange{blockType: blocks.AIR}
+			return
+		}
+
+		// Settling: flowing water resting between two sources becomes one
+		if liquidType == blocks.WATER_LIQUID && !pours &&
+			cells[0].holds(blockType) && cells[0].hex.LiquidSource &&
+			cells[1].holds(blockType) && cells[1].hex.LiquidSource {
+			level = LiquidMaxLevel
+			source = true
+		}
+
+		if level != hex.LiquidLevel || source != hex.LiquidSource {
+			changes[[2]int{col, row}] = liquidChange{blockType: blockType, level: level, source: source}
+		}
+	}
+
+	// Flow down first, into the cell directly below in the zigzag column,
+	// or the other lower cell only when that one is blocked, so falling
+	// liquid forms a column instead of a cone
+	for _, i := range downOrder(neighbors, col, row) {
+		if cells[i].empty() {
+			flowInto(changes, neighbors[i], blockType, LiquidMaxLevel)
+			return
+		}
+		if cells[i].holds(blockType) {
+			return // Already pouring into liquid below
+		}
+	}
+	if pours {
+		return
+	}
+
+	// Resting on something solid, so spread sideways with a lower level
+	spread := level - liquidLevelDrop(props)
+	if spread <= 0 {
+		return
+	}
+	for i := 0; i < 2; i++ {
+		if cells[i].empty() {
+			flowInto(changes, neighbors[i], blockType, spread)
+		} else if cells[i].holds(blockType) && !cells[i].hex.LiquidSource && cells[i].hex.LiquidLevel < spread {
+			w.liquidActive[neighbors[i]] = true
+		}
+	}
+}
+
+// downOrder returns the indices of a cell's two lower neighbours, the one
+// directly below first
+func downOrder(neighbors [6][2]int, col, row int) [2]int {
+	if neighbors[5] == [2]int{col, row + 1} {
+		return [2]int{5, 4}
+	}
+	return [2]int{4, 5}
+}
+
+// expectedLiquidLevel returns the level a flowing cell is fed to: full when
+// liquid pours in from above, otherwise the strongest sideways feeder minus
+// the liquid's level drop. Zero or less means nothing feeds the cell any more.
+func (w *World) expectedLiquidLevel(col int, neighbors [6][2]int, cells [6]gridCell, blockType blocks.BlockType, props *blocks.LiquidProperties) int {
+	for i := 2; i < 4; i++ {
+		if cells[i].holds(blockType) && w.pouringInto(neighbors[i], col, blockType) {
+			return LiquidMaxLevel
+		}
+	}
+
+	best := 0
+	for i := 0; i < 2; i++ {
+		c := cells[i]
+		if !c.holds(blockType) || c.hex.LiquidLevel <= best {
+			continue
+		}
+		// Flowing liquid that can still fall does not spread sideways
+		if !c.hex.LiquidSource {
+			var around [6]gridCell
+			for j, n := range CellNeighbors(neighbors[i][0], neighbors[i][1]) {
+				if j >= 4 {
+					around[j] = w.hexagonAtCell(n[0], n[1])
+				}
+			}
+			if pouring(around, blockType) {
+				continue
+			}
+		}
+		best = c.hex.LiquidLevel
+	}
+	return best - liquidLevelDrop(props)
+}
+
+// pouringInto reports whether liquid in the cell above pours into the cell
+// in column col: it does when that cell is directly below it, or when the
+// cell directly below it is blocked by something other than liquid
+func (w *World) pouringInto(above [2]int, col int, blockType blocks.BlockType) bool {
+	below := [2]int{above[0], above[1] + 1}
+	if below[0] == col {
+		return true
+	}
+	c := w.hexagonAtCell(below[0], below[1])
+	return c.loaded && !c.empty() && !c.holds(blockType)
+}
+
+// pouring reports whether a cell's liquid falls into one of the cells below
+// it, either because it is empty or because it already holds the same liquid
+func pouring(cells [6]gridCell, blockType blocks.BlockType) bool {
+	for _, c := range cells[4:] {
+		if c.empty() || c.holds(blockType) {
+			return true
+		}
+	}
+	return false
+}
+
+// liquidLevelDrop is how much level a liquid loses per cell it spreads
+// sideways, so it reaches about SpreadRate cells from a source
+func liquidLevelDrop(props *blocks.LiquidProperties) int {
+	if props == nil || props.SpreadRate <= 0 {
+		return LiquidMaxLevel
+	}
+	drop := LiquidMaxLevel / props.SpreadRate
+	if drop < 1 {
+		drop = 1
+	}
+	return drop
+}
+
+// flowInto records liquid entering an empty cell, keeping the highest level
+// when several cells flow into it during the same tick
+func flowInto(changes map[[2]int]liquidChange, cell [2]int, blockType blocks.BlockType, level int) {
+	if existing, ok := changes[cell]; ok && existing.blockType == blockType && existing.level >= level {
+		return
+	}
+	changes[cell] = liquidChange{blockType: blockType, level: level}
+}
+
+// applyLiquidChange writes a decided change to the world and wakes the cells
+// around it for the next tick
+func (w *World) applyLiquidChange(col, row int, change liquidChange) {
+	current := w.hexagonAtCell(col, row)
+	if !current.loaded {
+		return
+	}
+	x, y := CellCenter(col, row)
+
+	if current.hex != nil && current.hex.BlockType == change.blockType {
+		// Same liquid, only its level changed
+		current.hex.LiquidLevel = change.level
+		current.hex.LiquidSource = change.source
+		if chunk, ok := w.Chunks[[2]int{current.hex.ChunkX, current.hex.ChunkY}]; ok {
+			chunk.Modified = true
+		}
+		w.activateLiquidsAround(x, y)
+		if w.OnBlockChange != nil {
+			w.OnBlockChange(x, y, change.blockType)
+		}
+		return
+	}
+
+	if current.hex != nil {
+		if !blocks.IsLiquidBlock(current.hex.BlockType) && current.hex.BlockType != blocks.AIR {
+			return // A block was placed here since the change was decided
+		}
+		w.removeHexagon(current.hex)
+	}
+	if change.blockType != blocks.AIR {
+		w.AddHexagonAt(x, y, change.blockType)
+		if blocks.IsLiquidBlock(change.blockType) {
+			if placed := w.hexagonAtCell(col, row).hex; placed != nil {
+				placed.LiquidLevel = change.level
+				placed.LiquidSource = change.source
+			}
+		}
+	}
+	w.activateLiquidsAround(x, y)
+
+	if w.OnBlockChange != nil {
+		w.OnBlockChange(x, y, change.blockType)
+	}
+}
+
+// removeHexagon removes a hexagon from the chunk that holds it. Unlike
+// RemoveHexagonAt it does not assume the hexagon lies inside that chunk's
+// bounds, which generated hexagons on a chunk's right edge do not.
+func (w *World) removeHexagon(hex *Hexagon) {
+	w.removeHexagonFromSpatialHash(hex)
+	w.removeStation(hex)
+	if chunk, ok := w.Chunks[[2]int{hex.ChunkX, hex.ChunkY}]; ok {
+		chunk.RemoveHexagonDirect(hex.X, hex.Y)
+	}
+	w.relightAt(hex.X, hex.Y)
+	w.notifyBlockChange(hex.X, hex.Y)
+}
@@ -0,0 +1,39 @@
+package world
+
+import (
+	"testing"
+
+	"tesselbox/pkg/blocks"
+)
+
+func TestLiquidLevelChangeReported(t *testing.T) {
+	if len(blocks.BlockDefinitions) == 0 {
+		blocks.LoadBlocks()
+	}
+	w := newWorld("liquid_test", generationSeed, DefaultGenerationParams())
+	w.Storage = nil
+	chunk := NewChunk(0, 0)
+	w.Chunks[[2]int{0, 0}] = chunk
+
+	col, row := ChunkSize/2, ChunkSize/2
+	x, y := CellCenter(col, row)
+	w.AddHexagonAt(x, y, blocks.WATER)
+
+	type report struct {
+		blockType blocks.BlockType
+		level     int
+	}
+	var reports []report
+	w.OnBlockChange = func(x, y float64, blockType blocks.BlockType) {
+		reports = append(reports, report{blockType, w.GetHexagonAt(x, y).LiquidLevel})
+	}
+
+	// Only the level changes, which servers must still relay
+	w.applyLiquidChange(col, row, liquidChange{blockType: blocks.WATER, level: 3})
+	if len(reports) != 1 || reports[0] != (report{blocks.WATER, 3}) {
+		t.Fatalf("reports = %v, want water at level 3", reports)
+	}
+	if hex := w.GetHexagonAt(x, y); hex.LiquidSource {
+		t.Error("water is still a source")
+	}
+}
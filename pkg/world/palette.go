@@ -0,0 +1,145 @@
+package world
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"tesselbox/pkg/blocks"
+	"tesselbox/pkg/palette"
+)
+
+const (
+	// paletteFileName is the block palette stored next to the region directory
+	paletteFileName = "palette.json"
+	// paletteVersion is the current palette file format version
+	paletteVersion = 1
+)
+
+// paletteFile is the on-disk form of a world's block palette
+type paletteFile struct {
+	Version int              `json:"version"`
+	Blocks  *palette.Palette `json:"blocks"`
+}
+
+// NewBlockPalette creates a block palette seeded with the built-in block
+// types in runtime order. Chunks written before palettes existed stored the
+// raw block type, so this ordering decodes them unchanged.
+func NewBlockPalette() *palette.Palette {
+	var ids []string
+	for blockType := blocks.AIR; blockType <= blocks.RANDOMLAND_PORTAL; blockType++ {
+		id := blocks.BlockID(blockType)
+		if id == "" {
+			id = fmt.Sprintf("legacy:%d", blockType) // Keeps later indices aligned
+		}
+		ids = append(ids, id)
+	}
+	return palette.New(ids...)
+}
+
+// encodeBlock returns the palette index for a runtime block type
+func encodeBlock(p *palette.Palette, blockType blocks.BlockType) int {
+	id := blocks.BlockID(blockType)
+	if id == "" {
+		id = "air" // Unregistered runtime IDs cannot be restored
+	}
+	return p.IDFor(id)
+}
+
+// decodeBlock returns the runtime block type for a palette index. String IDs
+// this build does not know (e.g. from a removed plugin) are registered as
+// placeholders so they survive being saved again.
+func decodeBlock(p *palette.Palette, idx int) blocks.BlockType {
+	id, ok := p.Lookup(idx)
+	if !ok {
+		return blocks.AIR
+	}
+	if blockType, ok := blocks.BlockTypeByID(id); ok {
+		return blockType
+	}
+	return blocks.RegisterBlockType(id)
+}
+
+// palettePath returns the path of the world's block palette file
+func (ws *WorldStorage) palettePath() string {
+	return filepath.Join(ws.WorldDir, paletteFileName)
+}
+
+// blockPalette returns the world's block palette, reloading it if another
+// process changed the file. Callers must hold the storage mutex.
+func (ws *WorldStorage) blockPalette() (*palette.Palette, error) {
+	shared := ws.shared
+
+	info, err := os.Stat(ws.palettePath())
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to stat palette file: %w", err)
+		}
+		// No palette on disk yet (new world, or one saved before palettes)
+		if shared.palette == nil || !shared.paletteModTime.IsZero() {
+			shared.palette = NewBlockPalette()
+			shared.paletteModTime = time.Time{}
+			shared.paletteSaved = 0
+		}
+		return shared.palette, nil
+	}
+
+	if shared.palette != nil && info.ModTime().Equal(shared.paletteModTime) {
+		return shared.palette, nil
+	}
+
+	data, err := os.ReadFile(ws.palettePath())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read palette file: %w", err)
+	}
+
+	file := paletteFile{Blocks: palette.New()}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal palette file: %w", err)
+	}
+	if file.Version > paletteVersion {
+		return nil, fmt.Errorf("palette version %d is newer than supported version %d", file.Version, paletteVersion)
+	}
+
+	shared.palette = file.Blocks
+	shared.paletteModTime = info.ModTime()
+	shared.paletteSaved = file.Blocks.Len()
+	return shared.palette, nil
+}
+
+// savePalette writes the block palette if entries were added since it was
+// last written. It must run before any region referencing the new entries is
+// saved. Callers must hold the storage mutex.
+func (ws *WorldStorage) savePalette(p *palette.Palette) error {
+	shared := ws.shared
+	if p.Len() == shared.paletteSaved && !shared.paletteModTime.IsZero() {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(paletteFile{Version: paletteVersion, Blocks: p}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal palette: %w", err)
+	}
+
+	if err := os.MkdirAll(ws.WorldDir, 0755); err != nil {
+		return fmt.Errorf("failed to create world directory: %w", err)
+	}
+
+	// Use atomic write to prevent corruption
+	tempFile := ws.palettePath() + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0644); err != nil {
+		return fmt.Errorf("failed to write temp palette file: %w", err)
+	}
+	if err := os.Rename(tempFile, ws.palettePath()); err != nil {
+		os.Remove(tempFile)
+		return fmt.Errorf("failed to rename palette file: %w", err)
+	}
+
+	if info, err := os.Stat(ws.palettePath()); err == nil {
+		shared.paletteModTime = info.ModTime()
+	}
+	shared.paletteSaved = p.Len()
+	return nil
+}
@@ -0,0 +1,603 @@
+package world
+
+import (
+	"container/heap"
+	"math"
+
+	"tesselbox/pkg/blocks"
+	"tesselbox/pkg/mobs"
+)
+
+const (
+	// DefaultPathNodeBudget is how many cells searches may expand per update,
+	// shared by every pending search
+	DefaultPathNodeBudget = 2000
+	// maxPathSearchNodes bounds a single search; goals it cannot reach within
+	// this many cells are treated as unreachable
+	maxPathSearchNodes = 4000
+	// maxPendingSearches bounds the searches waiting for budget; further
+	// requests are refused until the queue drains
+	maxPendingSearches = 64
+	// pathCacheTTL is the seconds an unused path or route stays cached
+	pathCacheTTL = 10.0
+	// maxGoalDrift is how many cells a goal may move before a route is replanned
+	maxGoalDrift = 2
+	// maxGoalSnap is how many rows a walker's goal is moved down to find
+	// ground under it
+	maxGoalSnap = 4
+)
+
+// PathProfile describes how an entity gets around, in grid cells
+type PathProfile struct {
+	Height     int  // Rows of open cells the entity needs
+	JumpHeight int  // Rows it can jump up
+	MaxFall    int  // Rows it is willing to drop
+	Fly        bool // Ignores gravity and moves to any open neighbouring cell
+}
+
+// MobPathProfile returns the path profile of a mob from its size and type
+func MobPathProfile(m *mobs.Mob) PathProfile {
+	profile := PathProfile{
+		Height:     int(math.Ceil(m.Height / HexVSpacing)),
+		JumpHeight: int(m.Def.JumpHeight / HexVSpacing),
+		MaxFall:    int(m.Def.MaxFall / HexVSpacing),
+		Fly:        m.Def.Movement == mobs.MovementFly,
+	}
+	if profile.Height < 1 {
+		profile.Height = 1
+	}
+	return profile
+}
+
+// cellKind is what a grid cell means to pathfinding
+type cellKind int
+
+const (
+	cellSolid  cellKind = iota // Blocks movement; unloaded cells count as solid
+	cellOpen                   // Air and other non-solid blocks
+	cellWater                  // Passable but slow
+	cellHazard                 // Lava and other harmful liquids, never entered
+	cellLadder                 // Solid, but can be climbed from the cells beside it
+)
+
+// passable reports whether an entity can be inside a cell of this kind
+func (k cellKind) passable() bool {
+	return k == cellOpen || k == cellWater
+}
+
+// supports reports whether an entity can stand on a cell of this kind
+func (k cellKind) supports() bool {
+	return k == cellSolid || k == cellLadder
+}
+
+// pathKey identifies a search by where it starts and ends and who it is for
+type pathKey struct {
+	start, goal [2]int
+	profile     PathProfile
+}
+
+// cachedPath is the result of a finished search. Cells is nil when the goal
+// could not be reached.
+type cachedPath struct {
+	key      pathKey
+	cells    [][2]int
+	chunks   map[[2]int]bool // Chunks the search looked into
+	lastUsed float64
+	stale    bool // Dropped from the cache by a block change
+}
+
+// route is the path an entity is following and how far along it is
+type route struct {
+	path     *cachedPath
+	next     int // Index of the cell it is heading for
+	lastUsed float64
+}
+
+// pathNode is a cell in a search's open set
+type pathNode struct {
+	cell [2]int
+	f    float64
+}
+
+// pathHeap orders open cells by estimated total cost
+type pathHeap []pathNode
+
+func (h pathHeap) Len() int            { return len(h) }
+func (h pathHeap) Less(i, j int) bool  { return h[i].f < h[j].f }
+func (h pathHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *pathHeap) Push(x interface{}) { *h = append(*h, x.(pathNode)) }
+func (h *pathHeap) Pop() interface{} {
+	old := *h
+	n := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return n
+}
+
+// pathSearch is an A* search that can be paused when the budget runs out and
+// resumed on a later update
+type pathSearch struct {
+	key      pathKey
+	open     pathHeap
+	cost     map[[2]int]float64
+	from     map[[2]int][2]int
+	closed   map[[2]int]bool
+	chunks   map[[2]int]bool
+	expanded int
+}
+
+// newPathSearch starts a search
+func newPathSearch(key pathKey) *pathSearch {
+	s := &pathSearch{key: key}
+	s.reset()
+	return s
+}
+
+// reset throws away a search's progress, for when the terrain it looked at changed
+func (s *pathSearch) reset() {
+	s.open = pathHeap{{cell: s.key.start, f: float64(hexDistance(s.key.start, s.key.goal))}}
+	s.cost = map[[2]int]float64{s.key.start: 0}
+	s.from = make(map[[2]int][2]int)
+	s.closed = make(map[[2]int]bool)
+	s.chunks = make(map[[2]int]bool)
+	s.expanded = 0
+}
+
+// pathMove is one step from a cell and what it costs
+type pathMove struct {
+	cell [2]int
+	cost float64
+}
+
+// Pathfinder plans routes through the grid with A*. Walkers follow the ground,
+// jump, drop and climb ladders; flyers move through any open cell. Finished
+// searches are cached and indexed by the chunks they looked into, so a block
+// change only drops the paths it could affect. Searches run in Update within
+// a node budget, so many mobs asking at once spread the work over updates.
+type Pathfinder struct {
+	// NodeBudget is how many cells searches may expand per update
+	NodeBudget int
+
+	world     *World
+	time      float64
+	lastEvict float64
+	paths     map[pathKey]*cachedPath
+	byChunk   map[[2]int]map[*cachedPath]bool
+	searches  map[pathKey]*pathSearch
+	pending   []*pathSearch
+	routes    map[string]*route
+}
+
+// NewPathfinder creates a pathfinder over a world
+func NewPathfinder(w *World) *Pathfinder {
+	return &Pathfinder{
+		NodeBudget: DefaultPathNodeBudget,
+		world:      w,
+		paths:      make(map[pathKey]*cachedPath),
+		byChunk:    make(map[[2]int]map[*cachedPath]bool),
+		searches:   make(map[pathKey]*pathSearch),
+		routes:     make(map[string]*route),
+	}
+}
+
+// Update runs pending searches until the node budget is spent and forgets
+// paths and routes nobody has used for a while
+func (p *Pathfinder) Update(deltaTime float64) {
+	p.time += deltaTime
+
+	budget := p.NodeBudget
+	for budget > 0 && len(p.pending) > 0 {
+		s := p.pending[0]
+		used, done := p.step(s, budget)
+		budget -= used
+		if !done {
+			break
+		}
+		p.pending[0] = nil
+		p.pending = p.pending[1:]
+		delete(p.searches, s.key)
+	}
+
+	if p.time-p.lastEvict >= 1 {
+		p.evict()
+		p.lastEvict = p.time
+	}
+}
+
+// Waypoint returns the next point a mob should head for on its way to a
+// goal. It implements mobs.Navigator.
+func (p *Pathfinder) Waypoint(m *mobs.Mob, goalX, goalY float64) (float64, float64, bool) {
+	profile := MobPathProfile(m)
+	x, y := m.GetCenter()
+	if profile.Fly {
+		return p.Route(m.ID, x, y, goalX, goalY, profile)
+	}
+
+	// Walkers are in the cell just above their feet, and head for where
+	// their center is when standing in the next cell
+	_, _, _, maxY := m.GetBounds()
+	x, y, ok := p.Route(m.ID, x, maxY-5, goalX, goalY, profile)
+	return x, y + HexSize/2 - m.Height/2, ok
+}
+
+// Route returns the next point an entity should head for on its way from one
+// position to another, or false when it has no route yet or none exists and
+// should head straight for the goal. The ID ties calls together, so that an
+// entity keeps following its route while the goal moves a little.
+func (p *Pathfinder) Route(id string, fromX, fromY, toX, toY float64, profile PathProfile) (float64, float64, bool) {
+	start := cellKey(CellAt(fromX, fromY))
+	goal := cellKey(CellAt(toX, toY))
+	if !profile.Fly {
+		goal = p.groundUnder(goal, profile)
+	}
+
+	r := p.routes[id]
+	if r != nil && (r.path.stale || r.path.key.profile != profile || !r.follow(fromX, start, profile)) {
+		r = nil
+	}
+	if r == nil || hexDistance(r.path.key.goal, goal) > maxGoalDrift {
+		// Plan a new route, following the old one until it is ready
+		if path, ok := p.lookup(pathKey{start: start, goal: goal, profile: profile}); ok && path.cells != nil {
+			r = &route{path: path, next: 1}
+		} else if ok {
+			r = nil
+		}
+	}
+	if r == nil {
+		delete(p.routes, id)
+		return 0, 0, false
+	}
+	p.routes[id] = r
+	r.lastUsed = p.time
+	r.path.lastUsed = p.time
+
+	if r.next >= len(r.path.cells) {
+		return 0, 0, false // Close enough to go straight for the goal
+	}
+	x, y := CellCenter(r.path.cells[r.next][0], r.path.cells[r.next][1])
+	return x, y, true
+}
+
+// follow advances a route past the cell the entity is in, reporting false
+// when the entity has strayed from it. Cells going up and down alternate
+// between rows offset by half a hexagon, so an entity in the same row as a
+// cell and most of the way there has reached it.
+func (r *route) follow(x float64, cell [2]int, profile PathProfile) bool {
+	cells := r.path.cells
+	last := r.next + 3
+	if last >= len(cells) {
+		last = len(cells) - 1
+	}
+	for i := last; i >= r.next-1 && i >= 0; i-- {
+		cellX, _ := CellCenter(cells[i][0], cells[i][1])
+		if cells[i] == cell || (cells[i][1] == cell[1] && math.Abs(x-cellX) < HexWidth*0.75) {
+			r.next = i + 1
+			return true
+		}
+	}
+	// Jumps and drops leave the route between cells
+	stray := 3
+	if profile.MaxFall+1 > stray {
+		stray = profile.MaxFall + 1
+	}
+	if profile.JumpHeight+1 > stray {
+		stray = profile.JumpHeight + 1
+	}
+	next := r.next
+	if next >= len(cells) {
+		next = len(cells) - 1
+	}
+	return hexDistance(cell, cells[next]) <= stray
+}
+
+// lookup returns a cached path, or queues a search for it and reports false
+func (p *Pathfinder) lookup(key pathKey) (*cachedPath, bool) {
+	if path, ok := p.paths[key]; ok {
+		return path, true
+	}
+	if _, ok := p.searches[key]; !ok && len(p.pending) < maxPendingSearches {
+		s := newPathSearch(key)
+		p.searches[key] = s
+		p.pending = append(p.pending, s)
+	}
+	return nil, false
+}
+
+// step expands up to budget cells of a search, reporting how many it used and
+// whether the search finished
+func (p *Pathfinder) step(s *pathSearch, budget int) (int, bool) {
+	used := 0
+	for used < budget {
+		if s.open.Len() == 0 || s.expanded >= maxPathSearchNodes {
+			p.finish(s, nil)
+			return used, true
+		}
+		node := heap.Pop(&s.open).(pathNode)
+		if s.closed[node.cell] {
+			continue
+		}
+		s.closed[node.cell] = true
+		s.expanded++
+		used++
+
+		if hexDistance(node.cell, s.key.goal) <= 1 {
+			p.finish(s, s.reconstruct(node.cell))
+			return used, true
+		}
+
+		for _, move := range p.moves(node.cell, s.key.profile, s.chunks) {
+			cost := s.cost[node.cell] + move.cost
+			if old, ok := s.cost[move.cell]; ok && old <= cost {
+				continue
+			}
+			s.cost[move.cell] = cost
+			s.from[move.cell] = node.cell
+			heap.Push(&s.open, pathNode{cell: move.cell, f: cost + float64(hexDistance(move.cell, s.key.goal))})
+		}
+	}
+	return used, false
+}
+
+// reconstruct walks back from the last cell of a search to its start
+func (s *pathSearch) reconstruct(cell [2]int) [][2]int {
+	cells := [][2]int{cell}
+	for cell != s.key.start {
+		cell = s.from[cell]
+		cells = append(cells, cell)
+	}
+	for i, j := 0, len(cells)-1; i < j; i, j = i+1, j-1 {
+		cells[i], cells[j] = cells[j], cells[i]
+	}
+	return cells
+}
+
+// finish caches the result of a search under every chunk it looked into
+func (p *Pathfinder) finish(s *pathSearch, cells [][2]int) {
+	path := &cachedPath{key: s.key, cells: cells, chunks: s.chunks, lastUsed: p.time}
+	p.paths[s.key] = path
+	for chunk := range s.chunks {
+		if p.byChunk[chunk] == nil {
+			p.byChunk[chunk] = make(map[*cachedPath]bool)
+		}
+		p.byChunk[chunk][path] = true
+	}
+}
+
+// drop removes a path from the cache
+func (p *Pathfinder) drop(path *cachedPath) {
+	path.stale = true
+	if p.paths[path.key] == path {
+		delete(p.paths, path.key)
+	}
+	for chunk := range path.chunks {
+		delete(p.byChunk[chunk], path)
+		if len(p.byChunk[chunk]) == 0 {
+			delete(p.byChunk, chunk)
+		}
+	}
+}
+
+// invalidateCell drops every path and restarts every search that looked
+// into the chunks of a changed cell or its neighbours, whose standing spots
+// the change may have made or broken
+func (p *Pathfinder) invalidateCell(col, row int) {
+	chunks := map[[2]int]bool{cellChunk(col, row): true}
+	for _, n := range CellNeighbors(col, row) {
+		chunks[cellChunk(n[0], n[1])] = true
+	}
+	for chunk := range chunks {
+		p.invalidateChunk(chunk)
+	}
+}
+
+// invalidateChunk drops every path and restarts every search that looked
+// into a chunk, for when its blocks change or it is loaded or unloaded
+func (p *Pathfinder) invalidateChunk(chunk [2]int) {
+	for path := range p.byChunk[chunk] {
+		p.drop(path)
+	}
+	for _, s := range p.pending {
+		if s.chunks[chunk] {
+			s.reset()
+		}
+	}
+}
+
+// evict forgets paths and routes that have not been used for pathCacheTTL
+func (p *Pathfinder) evict() {
+	for _, path := range p.paths {
+		if p.time-path.lastUsed > pathCacheTTL {
+			p.drop(path)
+		}
+	}
+	for id, r := range p.routes {
+		if p.time-r.lastUsed > pathCacheTTL {
+			delete(p.routes, id)
+		}
+	}
+}
+
+// moves returns the cells an entity can get to in one step from a cell,
+// noting the chunks it looked into
+func (p *Pathfinder) moves(cell [2]int, profile PathProfile, seen map[[2]int]bool) []pathMove {
+	kind := func(c [2]int) cellKind {
+		return p.kindAt(c, seen)
+	}
+	fits := func(c [2]int) bool {
+		for i := 0; i < profile.Height; i++ {
+			if !kind(c).passable() {
+				return false
+			}
+			c = cellAbove(c)
+		}
+		return true
+	}
+	climbable := func(c [2]int) bool {
+		n := CellNeighbors(c[0], c[1])
+		return kind(n[0]) == cellLadder || kind(n[1]) == cellLadder
+	}
+	standable := func(c [2]int) bool {
+		if !fits(c) {
+			return false
+		}
+		n := CellNeighbors(c[0], c[1])
+		return kind(n[4]).supports() || kind(n[5]).supports() || climbable(c)
+	}
+	cost := func(c [2]int, base float64) float64 {
+		if kind(c) == cellWater {
+			return base * 3
+		}
+		return base
+	}
+
+	moves := make([]pathMove, 0, 8)
+	neighbors := CellNeighbors(cell[0], cell[1])
+
+	if profile.Fly {
+		for _, n := range neighbors {
+			if fits(n) {
+				moves = append(moves, pathMove{cell: n, cost: cost(n, 1)})
+			}
+		}
+		return moves
+	}
+
+	// Walk left and right, dropping down when there is no ground
+	for _, n := range neighbors[:2] {
+		if !fits(n) {
+			continue
+		}
+		if standable(n) {
+			moves = append(moves, pathMove{cell: n, cost: cost(n, 1)})
+			continue
+		}
+		landing := n
+		for fall := 1; fall <= profile.MaxFall; fall++ {
+			landing = cellBelow(landing)
+			if !fits(landing) {
+				break
+			}
+			if standable(landing) {
+				moves = append(moves, pathMove{cell: landing, cost: cost(landing, 1+0.5*float64(fall))})
+				break
+			}
+		}
+	}
+
+	// Jump straight up and land on the apex or beside it
+	apex := cell
+	for rise := 1; rise <= profile.JumpHeight; rise++ {
+		apex = cellAbove(apex)
+		if !fits(apex) {
+			break
+		}
+		an := CellNeighbors(apex[0], apex[1])
+		for _, landing := range [][2]int{apex, an[0], an[1]} {
+			if standable(landing) {
+				moves = append(moves, pathMove{cell: landing, cost: cost(landing, 1+float64(rise))})
+			}
+		}
+	}
+
+	// Climb up and down beside ladders
+	if climbable(cell) {
+		if up := cellAbove(cell); standable(up) {
+			moves = append(moves, pathMove{cell: up, cost: 1.5})
+		}
+		if down := cellBelow(cell); standable(down) {
+			moves = append(moves, pathMove{cell: down, cost: 1.5})
+		}
+	}
+	return moves
+}
+
+// kindAt classifies a cell, noting its chunk as looked into
+func (p *Pathfinder) kindAt(cell [2]int, seen map[[2]int]bool) cellKind {
+	seen[cellChunk(cell[0], cell[1])] = true
+	c := p.world.hexagonAtCell(cell[0], cell[1])
+	if !c.loaded {
+		return cellSolid
+	}
+	if c.empty() {
+		return cellOpen
+	}
+	switch blockType := c.hex.BlockType; {
+	case blockType == blocks.LADDER:
+		return cellLadder
+	case blockType == blocks.WATER:
+		return cellWater
+	case blocks.IsLiquidBlock(blockType):
+		return cellHazard
+	}
+	if def := blocks.BlockDefinitions[getBlockKey(c.hex.BlockType)]; def != nil && def.Solid {
+		return cellSolid
+	}
+	return cellOpen
+}
+
+// groundUnder moves a walker's goal down onto the first cell it could stand
+// in, so that a target in mid-air or a tall one is reached from below
+func (p *Pathfinder) groundUnder(goal [2]int, profile PathProfile) [2]int {
+	seen := make(map[[2]int]bool)
+	cell := goal
+	for i := 0; i <= maxGoalSnap; i++ {
+		if !p.kindAt(cell, seen).passable() {
+			return goal
+		}
+		n := CellNeighbors(cell[0], cell[1])
+		if p.kindAt(n[4], seen).supports() || p.kindAt(n[5], seen).supports() {
+			return cell
+		}
+		cell = cellBelow(cell)
+	}
+	return goal
+}
+
+// cellAbove returns the cell on top of a cell. Rows interlock, so going up
+// alternates between the up-right and up-left neighbour and stays in a
+// column around the same x.
+func cellAbove(cell [2]int) [2]int {
+	n := CellNeighbors(cell[0], cell[1])
+	if cell[1]&1 == 0 {
+		return n[3]
+	}
+	return n[2]
+}
+
+// cellBelow returns the cell under a cell, undoing cellAbove
+func cellBelow(cell [2]int) [2]int {
+	n := CellNeighbors(cell[0], cell[1])
+	if cell[1]&1 == 0 {
+		return n[5]
+	}
+	return n[4]
+}
+
+// cellKey packs a column and row into a map key
+func cellKey(col, row int) [2]int {
+	return [2]int{col, row}
+}
+
+// cellChunk returns the chunk a grid cell belongs to
+func cellChunk(col, row int) [2]int {
+	return [2]int{floorDiv(col, ChunkSize), floorDiv(row, ChunkSize)}
+}
+
+// hexDistance returns the number of steps between two cells. Odd rows sit
+// half a hexagon right, so cells convert to cube coordinates by shifting the
+// column back by half the row.
+func hexDistance(a, b [2]int) int {
+	ax := a[0] - (a[1]-(a[1]&1))/2
+	bx := b[0] - (b[1]-(b[1]&1))/2
+	dx := ax - bx
+	dz := a[1] - b[1]
+	dy := -dx - dz
+	return (abs(dx) + abs(dy) + abs(dz)) / 2
+}
+
+// abs returns the absolute value of an integer
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
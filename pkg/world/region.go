@@ -0,0 +1,345 @@
+package world
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// RegionSize is the number of chunks per region file dimension
+	RegionSize = 16
+	// RegionChunkCount is the number of chunk slots in a region file
+	RegionChunkCount = RegionSize * RegionSize
+
+	// regionMagic identifies a TesselBox region file
+	regionMagic = "TBRG"
+	// regionVersion is the current region file format version
+	regionVersion = 1
+
+	// regionHeaderSize is magic(4) + version(2) + region size(2)
+	regionHeaderSize = 8
+	// regionEntrySize is offset(4) + length(4) + checksum(4) + timestamp(8)
+	regionEntrySize = 20
+	// regionTableSize is the size of the offset table following the header
+	regionTableSize = RegionChunkCount * regionEntrySize
+
+	// Compression schemes for chunk payloads
+	compressionNone byte = 0
+	compressionZlib byte = 1
+)
+
+// regionEntry is one slot of a region file offset table
+type regionEntry struct {
+	Offset    uint32 // Byte offset of the chunk payload from the start of the file
+	Length    uint32 // Length of the payload including the compression byte
+	Checksum  uint32 // CRC32 (IEEE) of the payload
+	Timestamp int64  // Unix time the chunk was last written
+}
+
+// RegionFile packs a RegionSize x RegionSize grid of chunks into a single file.
+//
+// Layout:
+//
+//	header    magic "TBRG", uint16 version, uint16 region size
+//	table     RegionChunkCount entries of {offset, length, crc32, timestamp}
+//	payloads  one per stored chunk: compression byte followed by the data
+//
+// All integers are little endian. A zero offset marks an empty slot.
+type RegionFile struct {
+	Path    string
+	RegionX int
+	RegionY int
+
+	entries [RegionChunkCount]regionEntry
+	// payloads holds chunk payloads that have been read or written in memory
+	payloads map[int][]byte
+}
+
+// GetRegionCoords returns the region containing the given chunk and the chunk's local slot
+func GetRegionCoords(chunkX, chunkY int) (regionX, regionY, localX, localY int) {
+	regionX = int(math.Floor(float64(chunkX) / RegionSize))
+	regionY = int(math.Floor(float64(chunkY) / RegionSize))
+	localX = chunkX - regionX*RegionSize
+	localY = chunkY - regionY*RegionSize
+	return regionX, regionY, localX, localY
+}
+
+// RegionFileName returns the file name used for a region
+func RegionFileName(regionX, regionY int) string {
+	return fmt.Sprintf("r.%d.%d.tbr", regionX, regionY)
+}
+
+// OpenRegionFile opens a region file, reading only its header and offset table.
+// A missing file yields an empty region that will be created on Save.
+func OpenRegionFile(path string, regionX, regionY int) (*RegionFile, error) {
+	region := &RegionFile{
+		Path:     path,
+		RegionX:  regionX,
+		RegionY:  regionY,
+		payloads: make(map[int][]byte),
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return region, nil
+		}
+		return nil, fmt.Errorf("failed to open region file: %w", err)
+	}
+	defer file.Close()
+
+	header := make([]byte, regionHeaderSize+regionTableSize)
+	if _, err := io.ReadFull(file, header); err != nil {
+		return nil, fmt.Errorf("failed to read region header: %w", err)
+	}
+
+	if string(header[0:4]) != regionMagic {
+		return nil, fmt.Errorf("invalid region file %s: bad magic", filepath.Base(path))
+	}
+	if version := binary.LittleEndian.Uint16(header[4:6]); version != regionVersion {
+		return nil, fmt.Errorf("unsupported region file version %d", version)
+	}
+	if size := binary.LittleEndian.Uint16(header[6:8]); size != RegionSize {
+		return nil, fmt.Errorf("region file has size %d, expected %d", size, RegionSize)
+	}
+
+	for i := 0; i < RegionChunkCount; i++ {
+		off := regionHeaderSize + i*regionEntrySize
+		region.entries[i] = regionEntry{
+			Offset:    binary.LittleEndian.Uint32(header[off : off+4]),
+			Length:    binary.LittleEndian.Uint32(header[off+4 : off+8]),
+			Checksum:  binary.LittleEndian.Uint32(header[off+8 : off+12]),
+			Timestamp: int64(binary.LittleEndian.Uint64(header[off+12 : off+20])),
+		}
+	}
+
+	return region, nil
+}
+
+// slotIndex returns the table index for a local chunk position
+func slotIndex(localX, localY int) int {
+	return localY*RegionSize + localX
+}
+
+// HasChunk reports whether the region stores the chunk at the local position
+func (r *RegionFile) HasChunk(localX, localY int) bool {
+	idx := slotIndex(localX, localY)
+	if _, ok := r.payloads[idx]; ok {
+		return true
+	}
+	return r.entries[idx].Offset != 0
+}
+
+// ChunkCount returns the number of chunks stored in the region
+func (r *RegionFile) ChunkCount() int {
+	count := 0
+	for i := 0; i < RegionChunkCount; i++ {
+		if _, ok := r.payloads[i]; ok || r.entries[i].Offset != 0 {
+			count++
+		}
+	}
+	return count
+}
+
+// readPayload returns the raw payload for a slot, verifying its checksum
+func (r *RegionFile) readPayload(idx int) ([]byte, error) {
+	if payload, ok := r.payloads[idx]; ok {
+		return payload, nil
+	}
+	if r.entries[idx].Offset == 0 {
+		return nil, nil
+	}
+
+	file, err := os.Open(r.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open region file: %w", err)
+	}
+	defer file.Close()
+
+	return r.readPayloadFrom(file, idx)
+}
+
+// readPayloadFrom reads and caches a slot's payload from an open region file
+func (r *RegionFile) readPayloadFrom(file io.ReaderAt, idx int) ([]byte, error) {
+	entry := r.entries[idx]
+	payload := make([]byte, entry.Length)
+	if _, err := file.ReadAt(payload, int64(entry.Offset)); err != nil {
+		return nil, fmt.Errorf("failed to read chunk payload: %w", err)
+	}
+
+	if crc32.ChecksumIEEE(payload) != entry.Checksum {
+		return nil, fmt.Errorf("chunk payload checksum mismatch in slot %d", idx)
+	}
+
+	r.payloads[idx] = payload
+	return payload, nil
+}
+
+// loadAllPayloads reads every stored payload not yet held in memory
+func (r *RegionFile) loadAllPayloads() error {
+	var file *os.File
+	for i := 0; i < RegionChunkCount; i++ {
+		if _, ok := r.payloads[i]; ok || r.entries[i].Offset == 0 {
+			continue
+		}
+		if file == nil {
+			f, err := os.Open(r.Path)
+			if err != nil {
+				return fmt.Errorf("failed to open region file: %w", err)
+			}
+			defer f.Close()
+			file = f
+		}
+		if _, err := r.readPayloadFrom(file, i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadChunk decodes the chunk stored at the local position, or returns nil if the slot is empty
+func (r *RegionFile) ReadChunk(localX, localY int) (*ChunkData, error) {
+	payload, err := r.readPayload(slotIndex(localX, localY))
+	if err != nil || payload == nil {
+		return nil, err
+	}
+
+	data, err := decompressPayload(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var chunkData ChunkData
+	if err := json.Unmarshal(data, &chunkData); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal chunk data: %w", err)
+	}
+
+	return &chunkData, nil
+}
+
+// WriteChunk stores chunk data at the local position. Changes are kept in
+// memory until Save is called.
+func (r *RegionFile) WriteChunk(localX, localY int, chunkData *ChunkData) error {
+	data, err := json.Marshal(chunkData)
+	if err != nil {
+		return fmt.Errorf("failed to marshal chunk data: %w", err)
+	}
+
+	payload, err := compressPayload(data)
+	if err != nil {
+		return err
+	}
+
+	idx := slotIndex(localX, localY)
+	r.payloads[idx] = payload
+	r.entries[idx].Timestamp = time.Now().Unix()
+	return nil
+}
+
+// Save rewrites the region file atomically with all stored chunks
+func (r *RegionFile) Save() error {
+	// Pull every existing payload into memory before the file is replaced
+	if err := r.loadAllPayloads(); err != nil {
+		return err
+	}
+
+	var body bytes.Buffer
+	offset := uint32(regionHeaderSize + regionTableSize)
+	var entries [RegionChunkCount]regionEntry
+
+	for i := 0; i < RegionChunkCount; i++ {
+		payload, ok := r.payloads[i]
+		if !ok {
+			continue
+		}
+		entries[i] = regionEntry{
+			Offset:    offset,
+			Length:    uint32(len(payload)),
+			Checksum:  crc32.ChecksumIEEE(payload),
+			Timestamp: r.entries[i].Timestamp,
+		}
+		body.Write(payload)
+		offset += uint32(len(payload))
+	}
+
+	header := make([]byte, regionHeaderSize+regionTableSize)
+	copy(header[0:4], regionMagic)
+	binary.LittleEndian.PutUint16(header[4:6], regionVersion)
+	binary.LittleEndian.PutUint16(header[6:8], RegionSize)
+	for i, entry := range entries {
+		off := regionHeaderSize + i*regionEntrySize
+		binary.LittleEndian.PutUint32(header[off:off+4], entry.Offset)
+		binary.LittleEndian.PutUint32(header[off+4:off+8], entry.Length)
+		binary.LittleEndian.PutUint32(header[off+8:off+12], entry.Checksum)
+		binary.LittleEndian.PutUint64(header[off+12:off+20], uint64(entry.Timestamp))
+	}
+
+	if err := os.MkdirAll(filepath.Dir(r.Path), 0755); err != nil {
+		return fmt.Errorf("failed to create region directory: %w", err)
+	}
+
+	// Use atomic write to prevent corruption
+	tempFile := r.Path + ".tmp"
+	if err := os.WriteFile(tempFile, append(header, body.Bytes()...), 0644); err != nil {
+		return fmt.Errorf("failed to write temp region file: %w", err)
+	}
+	if err := os.Rename(tempFile, r.Path); err != nil {
+		os.Remove(tempFile)
+		return fmt.Errorf("failed to rename region file: %w", err)
+	}
+
+	r.entries = entries
+	return nil
+}
+
+// compressPayload zlib-compresses data and prefixes the compression byte
+func compressPayload(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte(compressionZlib)
+
+	writer := zlib.NewWriter(&buf)
+	if _, err := writer.Write(data); err != nil {
+		writer.Close()
+		return nil, fmt.Errorf("failed to compress chunk data: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress chunk data: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// decompressPayload reverses compressPayload
+func decompressPayload(payload []byte) ([]byte, error) {
+	if len(payload) == 0 {
+		return nil, fmt.Errorf("empty chunk payload")
+	}
+
+	switch payload[0] {
+	case compressionNone:
+		return payload[1:], nil
+	case compressionZlib:
+		reader, err := zlib.NewReader(bytes.NewReader(payload[1:]))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress chunk data: %w", err)
+		}
+		defer reader.Close()
+
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress chunk data: %w", err)
+		}
+		return data, nil
+	default:
+		return nil, fmt.Errorf("unknown chunk compression scheme %d", payload[0])
+	}
+}
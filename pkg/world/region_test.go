@@ -0,0 +1,177 @@
+package world
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// testChunkData returns chunk data holding a single block
+func testChunkData(chunkX, chunkY, blockType int) *ChunkData {
+	return &ChunkData{
+		ChunkX: chunkX,
+		ChunkY: chunkY,
+		Hexagons: map[string]*SerializedHexagon{
+			"0,0": {X: 15, Y: 20, Size: 20, BlockType: blockType, Health: 100},
+		},
+	}
+}
+
+func TestRegionRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), RegionFileName(-1, 2))
+	region, err := OpenRegionFile(path, -1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, last := testChunkData(-16, 32, 3), testChunkData(-1, 47, 5)
+	if err := region.WriteChunk(0, 0, first); err != nil {
+		t.Fatal(err)
+	}
+	if err := region.WriteChunk(RegionSize-1, RegionSize-1, last); err != nil {
+		t.Fatal(err)
+	}
+	if err := region.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := OpenRegionFile(path, -1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := reopened.ChunkCount(); got != 2 {
+		t.Errorf("chunk count = %d, want 2", got)
+	}
+	for _, tc := range []struct {
+		localX, localY int
+		want           *ChunkData
+	}{
+		{0, 0, first},
+		{RegionSize - 1, RegionSize - 1, last},
+		{1, 0, nil},
+	} {
+		got, err := reopened.ReadChunk(tc.localX, tc.localY)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("slot %d,%d = %+v, want %+v", tc.localX, tc.localY, got, tc.want)
+		}
+		if reopened.HasChunk(tc.localX, tc.localY) != (tc.want != nil) {
+			t.Errorf("HasChunk(%d, %d) = %v", tc.localX, tc.localY, tc.want == nil)
+		}
+	}
+}
+
+func TestRegionChecksumMismatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), RegionFileName(0, 0))
+	region, err := OpenRegionFile(path, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := region.WriteChunk(3, 4, testChunkData(3, 4, 2)); err != nil {
+		t.Fatal(err)
+	}
+	if err := region.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Flip a bit in the last byte of the only payload
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[len(data)-1] ^= 0x01
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	corrupted, err := OpenRegionFile(path, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := corrupted.ReadChunk(3, 4); err == nil {
+		t.Error("reading a corrupted chunk succeeded")
+	}
+	// Rewriting the region must not carry the corrupted payload over
+	if err := corrupted.WriteChunk(0, 0, testChunkData(0, 0, 1)); err != nil {
+		t.Fatal(err)
+	}
+	if err := corrupted.Save(); err == nil {
+		t.Error("saving a region with a corrupted chunk succeeded")
+	}
+}
+
+func TestConvertLegacyChunks(t *testing.T) {
+	ws := NewWorldStorage(fmt.Sprintf("region_test_%d", time.Now().UnixNano()))
+	t.Cleanup(func() { os.RemoveAll(ws.WorldDir) })
+
+	legacy := []*ChunkData{
+		testChunkData(0, 0, 1),
+		testChunkData(1, 0, 2),
+		testChunkData(-1, 20, 3),
+	}
+	for _, chunkData := range legacy {
+		data, err := json.Marshal(chunkData)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(ws.legacyChunkPath(chunkData.ChunkX, chunkData.ChunkY), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The region already holds a newer copy of chunk 1,0
+	region, err := OpenRegionFile(ws.regionPath(0, 0), 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newer := testChunkData(1, 0, 4)
+	if err := region.WriteChunk(1, 0, newer); err != nil {
+		t.Fatal(err)
+	}
+	if err := region.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	converted, err := ws.ConvertLegacyChunks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if converted != 2 {
+		t.Errorf("converted %d chunks, want 2", converted)
+	}
+	for _, chunkData := range legacy {
+		if _, err := os.Stat(ws.legacyChunkPath(chunkData.ChunkX, chunkData.ChunkY)); !os.IsNotExist(err) {
+			t.Errorf("legacy file for chunk %d,%d was not removed", chunkData.ChunkX, chunkData.ChunkY)
+		}
+	}
+
+	want := map[[2]int]*ChunkData{
+		{0, 0}:   legacy[0],
+		{1, 0}:   newer,
+		{-1, 20}: legacy[2],
+	}
+	for coords, wantData := range want {
+		regionX, regionY, localX, localY := GetRegionCoords(coords[0], coords[1])
+		region, err := OpenRegionFile(ws.regionPath(regionX, regionY), regionX, regionY)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := region.ReadChunk(localX, localY)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, wantData) {
+			t.Errorf("chunk %d,%d = %+v, want %+v", coords[0], coords[1], got, wantData)
+		}
+	}
+
+	// Nothing is left to convert
+	if converted, err := ws.ConvertLegacyChunks(); err != nil || converted != 0 {
+		t.Errorf("second conversion = %d, %v, want 0", converted, err)
+	}
+}
@@ -0,0 +1,43 @@
+package world
+
+import (
+	"tesselbox/pkg/palette"
+)
+
+// NewRemoteWorld creates a client-side copy of a server's world. It has no
+// storage: chunks are generated locally from the server's seed as
+// placeholders and replaced by ImportChunk when the server sends them.
+func NewRemoteWorld(worldName string, seed int64, params GenerationParams) *World {
+	world := newWorld(worldName, seed, params.withDefaults())
+	world.Storage = nil
+	return world
+}
+
+// ExportChunk returns a chunk with a palette of only the block IDs it uses,
+// so it can be sent to a client that does not share the world's palette
+func (w *World) ExportChunk(chunkX, chunkY int) (*ChunkData, []string) {
+	chunk := w.GetChunk(chunkX, chunkY)
+	chunkPalette := palette.New()
+	data := chunk.ToChunkData(chunkPalette)
+	return data, chunkPalette.Entries()
+}
+
+// ImportChunk replaces a chunk with data produced by ExportChunk
+func (w *World) ImportChunk(data *ChunkData, blockIDs []string) {
+	key := [2]int{data.ChunkX, data.ChunkY}
+	if old, exists := w.Chunks[key]; exists {
+		for _, hex := range old.Hexagons {
+			w.removeHexagonFromSpatialHash(hex)
+		}
+	}
+
+	chunk := NewChunk(data.ChunkX, data.ChunkY)
+	chunk.FromChunkData(data, palette.New(blockIDs...))
+	w.Chunks[key] = chunk
+
+	for _, hex := range chunk.Hexagons {
+		w.addHexagonToSpatialHash(hex)
+	}
+	w.lightChunk(chunk)
+	w.Paths.invalidateChunk(key)
+}
@@ -0,0 +1,82 @@
+package world
+
+import (
+	"math"
+
+	"tesselbox/pkg/blocks"
+	"tesselbox/pkg/station"
+)
+
+// stationKinds maps blocks that are processing stations to their kind
+var stationKinds = map[blocks.BlockType]string{
+	blocks.FURNACE: station.KindFurnace,
+}
+
+// stationKey returns the key of the station of a block at a position
+func stationKey(x, y float64) [2]int {
+	return [2]int{int(math.Round(x)), int(math.Round(y))}
+}
+
+// StationAt returns the station of the block at a world position, creating
+// it for station blocks that have none yet, or nil if the block is not a
+// station
+func (w *World) StationAt(x, y float64) *station.Station {
+	hex := w.GetHexagonAt(x, y)
+	if hex == nil {
+		return nil
+	}
+	kind, ok := stationKinds[hex.BlockType]
+	if !ok {
+		return nil
+	}
+	chunk, ok := w.Chunks[[2]int{hex.ChunkX, hex.ChunkY}]
+	if !ok {
+		return nil
+	}
+
+	key := stationKey(hex.X, hex.Y)
+	s, ok := chunk.Stations[key]
+	if !ok || s.Kind != kind {
+		s = station.New(kind, hex.X, hex.Y)
+		chunk.Stations[key] = s
+		chunk.Modified = true
+	}
+	return s
+}
+
+// UpdateStations runs the stations in loaded chunks. Stations in chunks that
+// were unloaded make up the time on their first update after loading.
+func (w *World) UpdateStations(deltaTime float64) {
+	for _, chunk := range w.Chunks {
+		for _, s := range chunk.Stations {
+			if s.Update(deltaTime) {
+				chunk.Modified = true
+			}
+		}
+	}
+}
+
+// removeStation removes the station of a removed hexagon, if it had one, and
+// keeps it for TakeRemovedStations
+func (w *World) removeStation(hex *Hexagon) {
+	chunk, ok := w.Chunks[[2]int{hex.ChunkX, hex.ChunkY}]
+	if !ok {
+		return
+	}
+	key := stationKey(hex.X, hex.Y)
+	s, ok := chunk.Stations[key]
+	if !ok {
+		return
+	}
+	delete(chunk.Stations, key)
+	chunk.Modified = true
+	w.removedStations = append(w.removedStations, s)
+}
+
+// TakeRemovedStations returns the stations of blocks removed since the last
+// call, so games can drop what was in them
+func (w *World) TakeRemovedStations() []*station.Station {
+	stations := w.removedStations
+	w.removedStations = nil
+	return stations
+}
@@ -5,14 +5,47 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
 	"time"
 
 	"tesselbox/pkg/config"
+	"tesselbox/pkg/palette"
 )
 
 // WorldStorage handles persistent storage of world data
 type WorldStorage struct {
 	WorldDir string
+
+	shared *sharedWorldState
+}
+
+// sharedWorldState is shared by every WorldStorage opened on the same world
+// directory so that region rewrites are serialized and all of them agree on
+// the block palette
+type sharedWorldState struct {
+	mutex sync.Mutex // Serializes region file and palette reads and rewrites
+
+	palette        *palette.Palette
+	paletteModTime time.Time // Modification time of the palette file when loaded or saved
+	paletteSaved   int       // Number of palette entries on disk
+}
+
+var (
+	sharedWorlds      = make(map[string]*sharedWorldState)
+	sharedWorldsMutex sync.Mutex
+)
+
+// sharedStateFor returns the shared state for a world directory
+func sharedStateFor(worldDir string) *sharedWorldState {
+	sharedWorldsMutex.Lock()
+	defer sharedWorldsMutex.Unlock()
+
+	state, ok := sharedWorlds[worldDir]
+	if !ok {
+		state = &sharedWorldState{}
+		sharedWorlds[worldDir] = state
+	}
+	return state
 }
 
 // NewWorldStorage creates a new world storage instance
@@ -26,10 +59,31 @@ func NewWorldStorage(worldName string) *WorldStorage {
 
 	return &WorldStorage{
 		WorldDir: worldDir,
+		shared:   sharedStateFor(worldDir),
+	}
+}
+
+// regionPath returns the path of the region file with the given coordinates
+func (ws *WorldStorage) regionPath(regionX, regionY int) string {
+	return filepath.Join(ws.WorldDir, "region", RegionFileName(regionX, regionY))
+}
+
+// legacyChunkPath returns the path of a pre-region one-file-per-chunk JSON file
+func (ws *WorldStorage) legacyChunkPath(chunkX, chunkY int) string {
+	return filepath.Join(ws.WorldDir, fmt.Sprintf("chunk_%d_%d.json", chunkX, chunkY))
+}
+
+// openRegion opens the region file containing the given chunk
+func (ws *WorldStorage) openRegion(chunkX, chunkY int) (*RegionFile, int, int, error) {
+	regionX, regionY, localX, localY := GetRegionCoords(chunkX, chunkY)
+	region, err := OpenRegionFile(ws.regionPath(regionX, regionY), regionX, regionY)
+	if err != nil {
+		return nil, 0, 0, err
 	}
+	return region, localX, localY, nil
 }
 
-// SaveChunk saves a single chunk to disk
+// SaveChunk saves a single chunk to its region file
 func (ws *WorldStorage) SaveChunk(chunk *Chunk) error {
 	if chunk == nil {
 		return fmt.Errorf("cannot save nil chunk") // Fixed: Add nil check
@@ -39,44 +93,81 @@ func (ws *WorldStorage) SaveChunk(chunk *Chunk) error {
 		return nil // Skip saving unchanged chunks
 	}
 
-	// Create a copy of chunk data to avoid race conditions
-	chunkData := chunk.ToChunkData()
+	ws.shared.mutex.Lock()
+	defer ws.shared.mutex.Unlock()
 
-	data, err := json.MarshalIndent(chunkData, "", "  ")
+	blockPalette, err := ws.blockPalette()
 	if err != nil {
-		return fmt.Errorf("failed to marshal chunk data: %w", err)
+		return err
 	}
 
-	filename := filepath.Join(ws.WorldDir, fmt.Sprintf("chunk_%d_%d.json", chunk.ChunkX, chunk.ChunkY))
-
-	// Use atomic write to prevent corruption
-	tempFile := filename + ".tmp"
-	err = os.WriteFile(tempFile, data, 0644)
+	region, localX, localY, err := ws.openRegion(chunk.ChunkX, chunk.ChunkY)
 	if err != nil {
-		return fmt.Errorf("failed to write temp chunk file: %w", err)
+		return err
 	}
 
-	// Atomic rename
-	err = os.Rename(tempFile, filename)
-	if err != nil {
-		os.Remove(tempFile) // Clean up temp file
-		return fmt.Errorf("failed to rename chunk file: %w", err)
+	// Create a copy of chunk data to avoid race conditions
+	if err := region.WriteChunk(localX, localY, chunk.ToChunkData(blockPalette)); err != nil {
+		return err
+	}
+
+	// The palette must hold every ID the region refers to before it is written
+	if err := ws.savePalette(blockPalette); err != nil {
+		return err
+	}
+
+	if err := region.Save(); err != nil {
+		return err
 	}
 
+	// The region now holds the authoritative copy
+	os.Remove(ws.legacyChunkPath(chunk.ChunkX, chunk.ChunkY))
+
 	chunk.Modified = false
 	chunk.LastSaved = time.Now()
 
 	return nil
 }
 
-// LoadChunk loads a chunk from disk
+// LoadChunk loads a chunk from disk, falling back to legacy JSON chunk files
 func (ws *WorldStorage) LoadChunk(chunkX, chunkY int) (*Chunk, error) {
-	filename := filepath.Join(ws.WorldDir, fmt.Sprintf("chunk_%d_%d.json", chunkX, chunkY))
+	ws.shared.mutex.Lock()
+	defer ws.shared.mutex.Unlock()
+
+	region, localX, localY, err := ws.openRegion(chunkX, chunkY)
+	if err != nil {
+		return nil, err
+	}
 
-	data, err := os.ReadFile(filename)
+	chunkData, err := region.ReadChunk(localX, localY)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read chunk %d,%d from region: %w", chunkX, chunkY, err)
+	}
+
+	if chunkData == nil {
+		chunkData, err = ws.loadLegacyChunk(chunkX, chunkY)
+		if err != nil || chunkData == nil {
+			return nil, err // Chunk doesn't exist, return nil
+		}
+	}
+
+	blockPalette, err := ws.blockPalette()
+	if err != nil {
+		return nil, err
+	}
+
+	chunk := NewChunk(chunkX, chunkY)
+	chunk.FromChunkData(chunkData, blockPalette)
+
+	return chunk, nil
+}
+
+// loadLegacyChunk reads a chunk_X_Y.json file written before region storage
+func (ws *WorldStorage) loadLegacyChunk(chunkX, chunkY int) (*ChunkData, error) {
+	data, err := os.ReadFile(ws.legacyChunkPath(chunkX, chunkY))
 	if err != nil {
 		if os.IsNotExist(err) {
-			return nil, nil // Chunk doesn't exist, return nil
+			return nil, nil
 		}
 		return nil, fmt.Errorf("failed to read chunk file: %w", err)
 	}
@@ -87,23 +178,75 @@ func (ws *WorldStorage) LoadChunk(chunkX, chunkY int) (*Chunk, error) {
 		return nil, fmt.Errorf("failed to unmarshal chunk data: %w", err)
 	}
 
-	chunk := NewChunk(chunkX, chunkY)
-	chunk.FromChunkData(&chunkData)
-
-	return chunk, nil
+	return &chunkData, nil
 }
 
-// SaveWorld saves all modified chunks in the world
+// SaveWorld saves all modified chunks in the world, rewriting each touched region once
 func (ws *WorldStorage) SaveWorld(world *World) error {
+	chunks := make([]*Chunk, 0, len(world.Chunks))
+	for _, chunk := range world.Chunks {
+		chunks = append(chunks, chunk)
+	}
+	return ws.SaveChunks(chunks)
+}
+
+// SaveChunks saves the modified chunks among those given, rewriting each
+// touched region once
+func (ws *WorldStorage) SaveChunks(chunks []*Chunk) error {
 	var saveErrors []error
 
-	for key, chunk := range world.Chunks {
-		if chunk.Modified {
-			err := ws.SaveChunk(chunk)
-			if err != nil {
-				saveErrors = append(saveErrors, fmt.Errorf("chunk %d,%d: %w", key[0], key[1], err))
+	// Group modified chunks by region
+	byRegion := make(map[[2]int][]*Chunk)
+	for _, chunk := range chunks {
+		if chunk != nil && chunk.Modified {
+			regionX, regionY, _, _ := GetRegionCoords(chunk.ChunkX, chunk.ChunkY)
+			key := [2]int{regionX, regionY}
+			byRegion[key] = append(byRegion[key], chunk)
+		}
+	}
+
+	if len(byRegion) == 0 {
+		return nil
+	}
+
+	ws.shared.mutex.Lock()
+	defer ws.shared.mutex.Unlock()
+
+	blockPalette, err := ws.blockPalette()
+	if err != nil {
+		return err
+	}
+
+	for key, chunks := range byRegion {
+		region, err := OpenRegionFile(ws.regionPath(key[0], key[1]), key[0], key[1])
+		if err != nil {
+			saveErrors = append(saveErrors, fmt.Errorf("region %d,%d: %w", key[0], key[1], err))
+			continue
+		}
+
+		for _, chunk := range chunks {
+			_, _, localX, localY := GetRegionCoords(chunk.ChunkX, chunk.ChunkY)
+			if err := region.WriteChunk(localX, localY, chunk.ToChunkData(blockPalette)); err != nil {
+				saveErrors = append(saveErrors, fmt.Errorf("chunk %d,%d: %w", chunk.ChunkX, chunk.ChunkY, err))
 			}
 		}
+
+		// The palette must hold every ID the region refers to before it is written
+		if err := ws.savePalette(blockPalette); err != nil {
+			return err
+		}
+
+		if err := region.Save(); err != nil {
+			saveErrors = append(saveErrors, fmt.Errorf("region %d,%d: %w", key[0], key[1], err))
+			continue
+		}
+
+		now := time.Now()
+		for _, chunk := range chunks {
+			os.Remove(ws.legacyChunkPath(chunk.ChunkX, chunk.ChunkY))
+			chunk.Modified = false
+			chunk.LastSaved = now
+		}
 	}
 
 	if len(saveErrors) > 0 {
@@ -113,6 +256,87 @@ func (ws *WorldStorage) SaveWorld(world *World) error {
 	return nil
 }
 
+// ConvertLegacyChunks upgrades a world saved as one chunk_X_Y.json file per
+// chunk to region files in place. Legacy files are removed only after the
+// region holding them has been written. It returns the number of chunks converted.
+func (ws *WorldStorage) ConvertLegacyChunks() (int, error) {
+	entries, err := os.ReadDir(ws.WorldDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to read world directory: %w", err)
+	}
+
+	// Group legacy chunk files by region
+	byRegion := make(map[[2]int][][2]int)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		var chunkX, chunkY int
+		if _, err := fmt.Sscanf(entry.Name(), "chunk_%d_%d.json", &chunkX, &chunkY); err != nil {
+			continue
+		}
+		if entry.Name() != fmt.Sprintf("chunk_%d_%d.json", chunkX, chunkY) {
+			continue // Skips temp files and other look-alikes
+		}
+		regionX, regionY, _, _ := GetRegionCoords(chunkX, chunkY)
+		key := [2]int{regionX, regionY}
+		byRegion[key] = append(byRegion[key], [2]int{chunkX, chunkY})
+	}
+
+	ws.shared.mutex.Lock()
+	defer ws.shared.mutex.Unlock()
+
+	// Legacy chunks store raw block types, which the seeded palette maps
+	// unchanged; pin that mapping on disk before any region refers to it
+	blockPalette, err := ws.blockPalette()
+	if err != nil {
+		return 0, err
+	}
+	if err := ws.savePalette(blockPalette); err != nil {
+		return 0, err
+	}
+
+	converted := 0
+	for key, chunks := range byRegion {
+		region, err := OpenRegionFile(ws.regionPath(key[0], key[1]), key[0], key[1])
+		if err != nil {
+			return converted, fmt.Errorf("region %d,%d: %w", key[0], key[1], err)
+		}
+
+		written := 0
+		for _, coords := range chunks {
+			_, _, localX, localY := GetRegionCoords(coords[0], coords[1])
+			if region.HasChunk(localX, localY) {
+				continue // Region copy is newer than the legacy file
+			}
+
+			chunkData, err := ws.loadLegacyChunk(coords[0], coords[1])
+			if err != nil {
+				return converted, fmt.Errorf("chunk %d,%d: %w", coords[0], coords[1], err)
+			}
+			if err := region.WriteChunk(localX, localY, chunkData); err != nil {
+				return converted, fmt.Errorf("chunk %d,%d: %w", coords[0], coords[1], err)
+			}
+			written++
+		}
+
+		if err := region.Save(); err != nil {
+			return converted, fmt.Errorf("region %d,%d: %w", key[0], key[1], err)
+		}
+		converted += written
+
+		// Skipped legacy files are stale copies of chunks the region holds
+		for _, coords := range chunks {
+			os.Remove(ws.legacyChunkPath(coords[0], coords[1]))
+		}
+	}
+
+	return converted, nil
+}
+
 // LoadWorld loads chunks around a specific position
 func (ws *WorldStorage) LoadWorld(world *World, centerX, centerY float64, radius int) error {
 	centerChunkX, centerChunkY := world.GetChunkCoords(centerX, centerY)
@@ -148,10 +372,12 @@ func (ws *WorldStorage) GetWorldMetadata() (*WorldMetadata, error) {
 		if os.IsNotExist(err) {
 			// Return default metadata if file doesn't exist
 			return &WorldMetadata{
-				CreatedAt:  time.Now(),
-				LastSaved:  time.Now(),
-				ChunkCount: 0,
-				Version:    "1.0",
+				CreatedAt:        time.Now(),
+				LastSaved:        time.Now(),
+				ChunkCount:       0,
+				Version:          "1.0",
+				GeneratorVersion: GeneratorVersion,
+				Generation:       DefaultGenerationParams(),
 			}, nil
 		}
 		return nil, fmt.Errorf("failed to read metadata file: %w", err)
@@ -166,13 +392,17 @@ func (ws *WorldStorage) GetWorldMetadata() (*WorldMetadata, error) {
 	return &metadata, nil
 }
 
-// SaveWorldMetadata saves world metadata
-func (ws *WorldStorage) SaveWorldMetadata(chunkCount int) error {
+// SaveWorldMetadata saves world metadata, including the seed and generation
+// settings needed to regenerate unsaved chunks identically
+func (ws *WorldStorage) SaveWorldMetadata(world *World) error {
 	metadata := WorldMetadata{
-		CreatedAt:  time.Now(),
-		LastSaved:  time.Now(),
-		ChunkCount: chunkCount,
-		Version:    "1.0",
+		CreatedAt:        world.CreatedAt,
+		LastSaved:        time.Now(),
+		ChunkCount:       len(world.Chunks),
+		Version:          "1.0",
+		Seed:             world.Seed,
+		GeneratorVersion: world.GeneratorVersion,
+		Generation:       world.Generation,
 	}
 
 	data, err := json.MarshalIndent(metadata, "", "  ")
@@ -195,6 +425,11 @@ type WorldMetadata struct {
 	LastSaved  time.Time `json:"last_saved"`
 	ChunkCount int       `json:"chunk_count"`
 	Version    string    `json:"version"`
+
+	// Generation settings; zero values mean the world predates them
+	Seed             int64            `json:"seed,omitempty"`
+	GeneratorVersion int              `json:"generator_version,omitempty"`
+	Generation       GenerationParams `json:"generation"`
 }
 
 // ListSavedWorlds returns a list of all saved world names
@@ -0,0 +1,656 @@
+package world
+
+import (
+	"fmt"
+	"sort"
+
+	"tesselbox/pkg/biomes"
+	"tesselbox/pkg/blocks"
+	"tesselbox/pkg/chest"
+	"tesselbox/pkg/dungeons"
+	"tesselbox/pkg/village"
+)
+
+// StructureKind says what a placed structure piece is part of
+type StructureKind int
+
+const (
+	StructureDungeonRoom StructureKind = iota
+	StructureVillageBuilding
+	StructureRuin
+)
+
+// StructureTemplate is a block layout stamped into terrain. Rows run top to
+// bottom with one character per grid cell, looked up in structurePalette.
+// Because rows interlock, a template looks slightly sheared on screen.
+type StructureTemplate struct {
+	ID     string
+	Kind   StructureKind
+	Type   string   // Dungeon room or village building type, such as "boss" or "inn"
+	Rows   []string // The bottom row is the floor and rests on the terrain surface
+	Biomes []biomes.BiomeType
+}
+
+// structurePalette maps template characters to blocks. A space leaves the
+// terrain as generated and '.' clears it to air.
+var structurePalette = map[byte]blocks.BlockType{
+	'.': blocks.AIR,
+	'#': blocks.COBBLESTONE,
+	'm': blocks.MOSSY_COBBLESTONE,
+	'b': blocks.STONE_BRICKS,
+	'z': blocks.CHISELED_STONE,
+	'y': blocks.SANDSTONE,
+	'r': blocks.BRICK,
+	'p': blocks.PLANK,
+	'l': blocks.LOG,
+	'g': blocks.GLASS,
+	't': blocks.TORCH,
+	'c': blocks.CHEST,
+	's': blocks.SPAWNER,
+	'k': blocks.BOOKSHELF,
+	'h': blocks.HAY_BALE,
+	'w': blocks.WOOL,
+	'x': blocks.CRAFTING_TABLE,
+	'f': blocks.FURNACE,
+	'a': blocks.ANVIL,
+}
+
+// landBiomes are the biomes surface structures may be built in
+var landBiomes = []biomes.BiomeType{
+	biomes.PLAINS, biomes.FOREST, biomes.DESERT, biomes.BADLANDS, biomes.MOUNTAINS,
+	biomes.SWAMP, biomes.TAIGA, biomes.TUNDRA, biomes.JUNGLE, biomes.SAVANNA,
+	biomes.ICE_FIELDS, biomes.VOLCANIC, biomes.MANGROVE,
+}
+
+// villageBiomes are the biomes gentle enough for villages
+var villageBiomes = []biomes.BiomeType{
+	biomes.PLAINS, biomes.FOREST, biomes.SAVANNA, biomes.TAIGA, biomes.DESERT,
+}
+
+// StructureTemplates holds every template available to generation, by ID
+var StructureTemplates = make(map[string]*StructureTemplate)
+
+// RegisterStructureTemplate adds a template, or replaces one with the same ID.
+// Templates must be registered before chunks that could hold them generate.
+func RegisterStructureTemplate(template *StructureTemplate) {
+	StructureTemplates[template.ID] = template
+}
+
+func init() {
+	for _, template := range defaultStructureTemplates {
+		RegisterStructureTemplate(template)
+	}
+}
+
+var defaultStructureTemplates = []*StructureTemplate{
+	{
+		ID: "village_house", Kind: StructureVillageBuilding, Type: "house", Biomes: villageBiomes,
+		Rows: []string{
+			"  ppppp  ",
+			" ppppppp ",
+			"l.g...g.l",
+			"l...t...l",
+			".........",
+			".c.....h.",
+			"rrrrrrrrr",
+		},
+	},
+	{
+		ID: "village_shop", Kind: StructureVillageBuilding, Type: "shop", Biomes: villageBiomes,
+		Rows: []string{
+			"  rrrrrrr  ",
+			" rrrrrrrrr ",
+			"r.........r",
+			"r.t.....t.r",
+			"...........",
+			"..a.f.x.c..",
+			"###########",
+		},
+	},
+	{
+		ID: "village_inn", Kind: StructureVillageBuilding, Type: "inn", Biomes: villageBiomes,
+		Rows: []string{
+			"   ppppppp   ",
+			"  ppppppppp  ",
+			" ppppppppppp ",
+			"l..g..t..g..l",
+			"l...........l",
+			".............",
+			".w.w.x.c.w.w.",
+			"rrrrrrrrrrrrr",
+		},
+	},
+	{
+		ID: "village_church", Kind: StructureVillageBuilding, Type: "church", Biomes: villageBiomes,
+		Rows: []string{
+			"    z    ",
+			"   bbb   ",
+			"  bb.bb  ",
+			" bb.t.bb ",
+			"bb.....bb",
+			"b..g.g..b",
+			"b.......b",
+			"....k....",
+			".k.....k.",
+			"#########",
+		},
+	},
+	{
+		ID: "dungeon_combat", Kind: StructureDungeonRoom, Type: "combat", Biomes: landBiomes,
+		Rows: []string{
+			"bbmbbbbbmbb",
+			"b.........b",
+			"m..t...t..m",
+			"b.........b",
+			"...........",
+			".....s.....",
+			"bbbmbbbbmbb",
+		},
+	},
+	{
+		ID: "dungeon_puzzle", Kind: StructureDungeonRoom, Type: "puzzle", Biomes: landBiomes,
+		Rows: []string{
+			"bbbbbmbbbbb",
+			"b.k.kck.k.b",
+			"b.........b",
+			"m....t....m",
+			"...........",
+			"..z.....z..",
+			"bbbbbbbbbbb",
+		},
+	},
+	{
+		ID: "dungeon_treasure", Kind: StructureDungeonRoom, Type: "treasure", Biomes: landBiomes,
+		Rows: []string{
+			"bbbbbbbbbbb",
+			"bm.......mb",
+			"b...t.t...b",
+			"b.........b",
+			"...........",
+			"...c.s.c...",
+			"bbbbbbbbbbb",
+		},
+	},
+	{
+		ID: "dungeon_boss", Kind: StructureDungeonRoom, Type: "boss", Biomes: landBiomes,
+		Rows: []string{
+			"zbbbbbbbbbbbz",
+			"b...........b",
+			"b..t.....t..b",
+			"m...........m",
+			"b...........b",
+			".............",
+			"..s..ccc..s..",
+			"zbbbbbbbbbbbz",
+		},
+	},
+	{
+		ID: "ruined_tower", Kind: StructureRuin, Type: "tower",
+		Biomes: []biomes.BiomeType{biomes.PLAINS, biomes.FOREST, biomes.TAIGA, biomes.SWAMP, biomes.MOUNTAINS, biomes.JUNGLE},
+		Rows: []string{
+			" mmm ",
+			"m...m",
+			"#...#",
+			"m...m",
+			"#...#",
+			"..c.#",
+			"#####",
+		},
+	},
+	{
+		ID: "ruined_wall", Kind: StructureRuin, Type: "wall",
+		Biomes: []biomes.BiomeType{biomes.PLAINS, biomes.FOREST, biomes.SAVANNA, biomes.TUNDRA, biomes.BADLANDS},
+		Rows: []string{
+			"#m  m  ##",
+			"##m.#m###",
+			"#########",
+		},
+	},
+	{
+		ID: "desert_ruin", Kind: StructureRuin, Type: "temple",
+		Biomes: []biomes.BiomeType{biomes.DESERT, biomes.BADLANDS, biomes.SAVANNA},
+		Rows: []string{
+			"y y   y",
+			"yyy yyy",
+			"y.....y",
+			"...c...",
+			"yyyyyyy",
+		},
+	},
+}
+
+// width returns the number of columns the template spans
+func (t *StructureTemplate) width() int {
+	width := 0
+	for _, line := range t.Rows {
+		if len(line) > width {
+			width = len(line)
+		}
+	}
+	return width
+}
+
+// allows reports whether the template may be placed in a biome
+func (t *StructureTemplate) allows(biome biomes.BiomeType) bool {
+	if len(t.Biomes) == 0 {
+		return true
+	}
+	for _, allowed := range t.Biomes {
+		if allowed == biome {
+			return true
+		}
+	}
+	return false
+}
+
+// templatesOf returns the templates of a kind and type, sorted by ID so the
+// choice between them is deterministic
+func templatesOf(kind StructureKind, structureType string) []*StructureTemplate {
+	var found []*StructureTemplate
+	for _, template := range StructureTemplates {
+		if template.Kind == kind && (structureType == "" || template.Type == structureType) {
+			found = append(found, template)
+		}
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].ID < found[j].ID })
+	return found
+}
+
+// Structure is one placed structure piece: a dungeon room, a village
+// building or a ruin. It is reported through TakeGeneratedStructures when the
+// chunk holding its top-left cell is loaded, so games can link it to their
+// dungeons and villages.
+type Structure struct {
+	ID       string // Stable for a world seed; derived from template and position
+	GroupID  string // Dungeon or village the piece belongs to; empty for ruins
+	Kind     StructureKind
+	Type     string
+	Template string
+
+	X, Y     float64      // World position of the top-left cell
+	Chests   [][2]float64 // World positions of chest blocks
+	Spawners [][2]float64 // World positions of spawner blocks
+}
+
+// structurePiece is a template positioned in the grid
+type structurePiece struct {
+	template   *StructureTemplate
+	col, row   int     // Grid cell of the top-left corner
+	decay      float64 // Chance each block is left out, for ruins
+	foundation []int   // Per column, rows of support below the floor
+	structure  *Structure
+}
+
+// bounds returns the grid cells the piece covers, foundation included
+func (p *structurePiece) bounds() (minCol, minRow, maxCol, maxRow int) {
+	maxRow = p.row + len(p.template.Rows) - 1
+	for _, depth := range p.foundation {
+		if p.row+len(p.template.Rows)-1+depth > maxRow {
+			maxRow = p.row + len(p.template.Rows) - 1 + depth
+		}
+	}
+	return p.col, p.row, p.col + p.template.width() - 1, maxRow
+}
+
+// structureSet is one kind of structure spread over the world. The world is
+// divided into square regions of regionChunks chunks, and each region holds
+// at most one structure of the set, planned from the seed and the region
+// alone so chunks can generate in any order.
+type structureSet struct {
+	salt         uint64
+	regionChunks int
+	chance       float64
+	plan         func(w *World, rx, ry int, rng *structureRand) []*structurePiece
+}
+
+var structureSets = []structureSet{
+	{salt: saltVillage, regionChunks: 8, chance: 0.4, plan: (*World).planVillage},
+	{salt: saltDungeon, regionChunks: 4, chance: 0.5, plan: (*World).planDungeon},
+	{salt: saltRuin, regionChunks: 3, chance: 0.35, plan: (*World).planRuin},
+}
+
+// structureKey identifies the plan of one region of one structure set
+type structureKey struct {
+	set, rx, ry int
+}
+
+// structureRand is a small deterministic generator for planning one region
+type structureRand struct {
+	state uint64
+}
+
+// newStructureRand seeds a generator for a region of a structure set
+func newStructureRand(seed int64, salt uint64, rx, ry int) *structureRand {
+	h := mix64(uint64(seed) ^ salt)
+	h = mix64(h ^ uint64(int64(rx)))
+	h = mix64(h ^ uint64(int64(ry)))
+	return &structureRand{state: h}
+}
+
+// Float64 returns the next value in [0, 1)
+func (r *structureRand) Float64() float64 {
+	r.state = mix64(r.state)
+	return float64(r.state>>11) / (1 << 53)
+}
+
+// Intn returns the next value in [0, n)
+func (r *structureRand) Intn(n int) int {
+	return int(r.Float64() * float64(n))
+}
+
+// surfaceRow returns the first solid row from the top of a grid column and
+// the biome there, from the terrain function alone so neighbouring chunks
+// need not be generated
+func (w *World) surfaceRow(col int) (int, biomes.BiomeType, bool) {
+	for row := -20; row < 60; row++ {
+		x, y := CellCenter(col, row)
+		biome, depth := w.terrainAt(x, y)
+		if depth >= -10 {
+			return row, biome, true
+		}
+	}
+	return 0, 0, false
+}
+
+// structurePlan returns the pieces planned for one region of a set
+func (w *World) structurePlan(set, rx, ry int) []*structurePiece {
+	key := structureKey{set: set, rx: rx, ry: ry}
+	if pieces, ok := w.structurePlans[key]; ok {
+		return pieces
+	}
+
+	var pieces []*structurePiece
+	rng := newStructureRand(w.Seed, structureSets[set].salt, rx, ry)
+	if rng.Float64() < structureSets[set].chance {
+		pieces = structureSets[set].plan(w, rx, ry, rng)
+	}
+	w.structurePlans[key] = pieces
+	return pieces
+}
+
+// structuresNear returns the planned pieces that overlap a chunk
+func (w *World) structuresNear(chunk *Chunk) []*structurePiece {
+	minCol, minRow := chunk.ChunkX*ChunkSize, chunk.ChunkY*ChunkSize
+	maxCol, maxRow := minCol+ChunkSize-1, minRow+ChunkSize-1
+
+	var near []*structurePiece
+	for set := range structureSets {
+		size := structureSets[set].regionChunks
+		rx, ry := floorDiv(chunk.ChunkX, size), floorDiv(chunk.ChunkY, size)
+		for dy := -1; dy <= 1; dy++ {
+			for dx := -1; dx <= 1; dx++ {
+				for _, piece := range w.structurePlan(set, rx+dx, ry+dy) {
+					pMinCol, pMinRow, pMaxCol, pMaxRow := piece.bounds()
+					if pMaxCol >= minCol && pMinCol <= maxCol && pMaxRow >= minRow && pMinRow <= maxRow {
+						near = append(near, piece)
+					}
+				}
+			}
+		}
+	}
+	return near
+}
+
+// planSurfacePiece positions a template with its floor on the terrain
+// surface at a column, and works out the foundation needed where the ground
+// falls away beneath it. It returns nil if the biome there does not allow it.
+func (w *World) planSurfacePiece(template *StructureTemplate, col int) *structurePiece {
+	width := template.width()
+	surface, biome, ok := w.surfaceRow(col + width/2)
+	if !ok || !template.allows(biome) {
+		return nil
+	}
+
+	piece := &structurePiece{
+		template:   template,
+		col:        col,
+		row:        surface - len(template.Rows) + 1,
+		foundation: make([]int, width),
+	}
+	for i := range piece.foundation {
+		if ground, _, ok := w.surfaceRow(col + i); ok && ground > surface {
+			piece.foundation[i] = min(ground-surface, 6)
+		}
+	}
+	return piece
+}
+
+// planVillage lines up a few buildings along the surface
+func (w *World) planVillage(rx, ry int, rng *structureRand) []*structurePiece {
+	cells := 8 * ChunkSize
+	anchor := rx*cells + rng.Intn(cells/2)
+	surface, _, ok := w.surfaceRow(anchor)
+	if !ok || floorDiv(surface, cells) != ry {
+		return nil // The surface passes through another region here
+	}
+
+	groupID := fmt.Sprintf("village_%d_%d", rx, ry)
+	types := []string{"house", "shop", "inn", "church"}
+	count := 3 + rng.Intn(3)
+
+	var pieces []*structurePiece
+	col := anchor
+	for i := 0; i < count; i++ {
+		structureType := "house"
+		if i > 0 {
+			structureType = types[rng.Intn(len(types))]
+		}
+		candidates := templatesOf(StructureVillageBuilding, structureType)
+		if len(candidates) == 0 {
+			continue
+		}
+		template := candidates[rng.Intn(len(candidates))]
+
+		if piece := w.planSurfacePiece(template, col); piece != nil {
+			piece.structure = newStructure(piece, groupID)
+			pieces = append(pieces, piece)
+		}
+		col += template.width() + 2 + rng.Intn(3)
+	}
+	return pieces
+}
+
+// planDungeon chains rooms side by side under the surface, ending in a boss room
+func (w *World) planDungeon(rx, ry int, rng *structureRand) []*structurePiece {
+	cells := 4 * ChunkSize
+	anchor := rx*cells + rng.Intn(cells/2)
+	surface, biome, ok := w.surfaceRow(anchor)
+	if !ok {
+		return nil
+	}
+	top := surface + 3 + rng.Intn(3)
+	if floorDiv(top, cells) != ry {
+		return nil
+	}
+
+	groupID := fmt.Sprintf("dungeon_%d_%d", rx, ry)
+	count := 3 + rng.Intn(3)
+
+	var pieces []*structurePiece
+	col := anchor
+	for i := 0; i < count; i++ {
+		structureType := "combat"
+		switch {
+		case i == count-1:
+			structureType = "boss"
+		case i%2 == 1:
+			structureType = "puzzle"
+		case i > 0:
+			structureType = "treasure"
+		}
+		candidates := templatesOf(StructureDungeonRoom, structureType)
+		if len(candidates) == 0 {
+			continue
+		}
+		template := candidates[rng.Intn(len(candidates))]
+		if !template.allows(biome) {
+			return nil
+		}
+
+		// Rooms share a floor level so their doorways line up
+		floor := top + 6
+		piece := &structurePiece{template: template, col: col, row: floor - len(template.Rows) + 1}
+		piece.structure = newStructure(piece, groupID)
+		pieces = append(pieces, piece)
+		col += template.width()
+	}
+	return pieces
+}
+
+// planRuin places a single weathered structure, half sunk into the ground
+func (w *World) planRuin(rx, ry int, rng *structureRand) []*structurePiece {
+	cells := 3 * ChunkSize
+	candidates := templatesOf(StructureRuin, "")
+	if len(candidates) == 0 {
+		return nil
+	}
+	template := candidates[rng.Intn(len(candidates))]
+
+	piece := w.planSurfacePiece(template, rx*cells+rng.Intn(cells-template.width()))
+	if piece == nil || floorDiv(piece.row, cells) != ry {
+		return nil
+	}
+	piece.row++
+	piece.decay = 0.25
+	piece.structure = newStructure(piece, "")
+	return []*structurePiece{piece}
+}
+
+// newStructure describes a planned piece for reporting
+func newStructure(piece *structurePiece, groupID string) *Structure {
+	template := piece.template
+	x, y := CellCenter(piece.col, piece.row)
+	s := &Structure{
+		ID:       fmt.Sprintf("%s_%d_%d", template.ID, piece.col, piece.row),
+		GroupID:  groupID,
+		Kind:     template.Kind,
+		Type:     template.Type,
+		Template: template.ID,
+		X:        x,
+		Y:        y,
+	}
+	for r, line := range template.Rows {
+		for c := 0; c < len(line); c++ {
+			cx, cy := CellCenter(piece.col+c, piece.row+r)
+			switch structurePalette[line[c]] {
+			case blocks.CHEST:
+				s.Chests = append(s.Chests, [2]float64{cx, cy})
+			case blocks.SPAWNER:
+				s.Spawners = append(s.Spawners, [2]float64{cx, cy})
+			}
+		}
+	}
+	return s
+}
+
+// placeStructures stamps every planned structure overlapping a freshly
+// generated chunk into it
+func (w *World) placeStructures(chunk *Chunk) {
+	minCol, minRow := chunk.ChunkX*ChunkSize, chunk.ChunkY*ChunkSize
+	set := func(col, row int, blockType blocks.BlockType) {
+		localCol, localRow := col-minCol, row-minRow
+		if localCol < 0 || localCol >= ChunkSize || localRow < 0 || localRow >= ChunkSize {
+			return
+		}
+		x, y := chunk.cellPosition(localCol, localRow)
+		if blockType == blocks.AIR {
+			chunk.RemoveHexagonDirect(x, y)
+			return
+		}
+		chunk.AddHexagon(x, y, NewHexagon(x, y, HexSize, blockType))
+	}
+
+	for _, piece := range w.structuresNear(chunk) {
+		rows := piece.template.Rows
+		for r, line := range rows {
+			for c := 0; c < len(line); c++ {
+				blockType, ok := structurePalette[line[c]]
+				if !ok {
+					continue // Keep the terrain
+				}
+				col, row := piece.col+c, piece.row+r
+				if piece.decay > 0 && blockType != blocks.AIR &&
+					positionRandom(w.Seed, float64(col), float64(row), saltRuinDecay) < piece.decay {
+					continue
+				}
+				set(col, row, blockType)
+			}
+		}
+
+		// Prop the floor up where the ground falls away
+		floor := rows[len(rows)-1]
+		for c, depth := range piece.foundation {
+			blockType, ok := structurePalette[floor[c]]
+			if !ok || blockType == blocks.AIR {
+				continue
+			}
+			for k := 1; k <= depth; k++ {
+				set(piece.col+c, piece.row+len(rows)-1+k, blockType)
+			}
+		}
+	}
+}
+
+// reportStructures queues the pieces whose top-left cell lies in a chunk
+func (w *World) reportStructures(chunk *Chunk) {
+	for _, piece := range w.structuresNear(chunk) {
+		if floorDiv(piece.col, ChunkSize) == chunk.ChunkX && floorDiv(piece.row, ChunkSize) == chunk.ChunkY {
+			w.generatedStructures = append(w.generatedStructures, piece.structure)
+		}
+	}
+}
+
+// TakeGeneratedStructures returns the structures in chunks loaded since the
+// last call. The same structure is reported again each time its chunk loads,
+// so linking it must be idempotent.
+func (w *World) TakeGeneratedStructures() []*Structure {
+	structures := w.generatedStructures
+	w.generatedStructures = nil
+	return structures
+}
+
+// LinkStructures registers structures the world generated with the village
+// and dungeon managers and fills their chests with loot. Structures are
+// reported each time their chunk loads, so chests are only stocked the first
+// time and only while the chest block is still standing.
+func (w *World) LinkStructures(structures []*Structure, villages *village.VillageManager,
+	dungeonManager *dungeons.DungeonManager, chests *chest.ChestManager) {
+	for _, s := range structures {
+		switch s.Kind {
+		case StructureVillageBuilding:
+			if villages != nil {
+				villages.AddGeneratedBuilding(s.GroupID, w.WorldName, s.X, s.Y, village.Building{
+					ID:          s.ID,
+					Type:        s.Type,
+					X:           s.X,
+					Y:           s.Y,
+					StructureID: s.ID,
+				})
+			}
+		case StructureDungeonRoom:
+			if dungeonManager != nil {
+				dungeonManager.AddGeneratedRoom(s.GroupID, w.WorldName, dungeons.DungeonRoom{
+					Type:        s.Type,
+					X:           s.X,
+					Y:           s.Y,
+					StructureID: s.ID,
+					SpawnPoints: s.Spawners,
+				})
+			}
+		}
+
+		if chests == nil {
+			continue
+		}
+		for i, pos := range s.Chests {
+			if chests.ChestExists(pos[0], pos[1]) {
+				continue
+			}
+			if hex := w.GetHexagonAt(pos[0], pos[1]); hex == nil || hex.BlockType != blocks.CHEST {
+				continue
+			}
+			seed := w.Seed ^ int64(pos[0])*73856093 ^ int64(pos[1])*19349663 ^ int64(i)
+			chests.SetChestContents(pos[0], pos[1], dungeons.ChestLoot(s.Type, seed))
+		}
+	}
+}
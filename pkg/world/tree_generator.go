@@ -104,25 +104,37 @@ func (tg *TreeGenerator) generateTree(chunk *Chunk, biome biomes.BiomeType) {
 	tg.placeLeaves(chunk, localX, groundY, structure)
 }
 
-// selectTreeType chooses a tree type based on biome
+// treeTypeNames maps the tree names of biome tree tables to tree types
+var treeTypeNames = map[string]TreeType{
+	"oak":    Oak,
+	"birch":  Birch,
+	"spruce": Spruce,
+	"jungle": Jungle,
+	"acacia": Acacia,
+}
+
+// selectTreeType chooses a tree type from the biome's weighted tree table
 func (tg *TreeGenerator) selectTreeType(biome biomes.BiomeType) TreeType {
-	switch biome {
-	case biomes.FOREST:
-		if tg.rng.Float64() < 0.7 {
-			return Oak
-		}
-		return Birch
-	case biomes.TAIGA:
-		return Spruce
-	case biomes.JUNGLE:
-		return Jungle
-	case biomes.SAVANNA:
-		return Acacia
-	case biomes.DESERT:
-		return Oak // Small oak in desert oases
-	default:
+	props := biomes.Definition(biome)
+	if props == nil || len(props.Trees) == 0 {
 		return Oak
 	}
+	if len(props.Trees) == 1 {
+		return treeTypeNames[props.Trees[0].Tree]
+	}
+
+	total := 0.0
+	for _, t := range props.Trees {
+		total += t.Weight
+	}
+	pick := tg.rng.Float64() * total
+	for _, t := range props.Trees {
+		pick -= t.Weight
+		if pick < 0 {
+			return treeTypeNames[t.Tree]
+		}
+	}
+	return Oak
 }
 
 // getTreeStructure returns the dimensions for a tree type
@@ -0,0 +1,199 @@
+package world
+
+import (
+	"math"
+
+	"tesselbox/pkg/biomes"
+	"tesselbox/pkg/blocks"
+)
+
+const (
+	// caveRoofDepth is the least depth below the surface caves may carve,
+	// leaving a roof so they do not open into the sky or drain the sea
+	caveRoofDepth = 60.0
+	// lavaLakeDepth is the depth below which every underground lake is lava
+	lavaLakeDepth = 2400.0
+	// Underground lakes are decided per region of lakeRegionCols by
+	// lakeRegionRows grid cells
+	lakeRegionCols = 12
+	lakeRegionRows = 6
+)
+
+// valueNoise returns smoothly interpolated lattice noise in [-1, 1]. Like
+// positionRandom it depends only on the seed, salt and position.
+func valueNoise(seed int64, x, y float64, salt uint64) float64 {
+	x0, y0 := math.Floor(x), math.Floor(y)
+	fx, fy := x-x0, y-y0
+	fx = fx * fx * (3 - 2*fx)
+	fy = fy * fy * (3 - 2*fy)
+
+	v00 := positionRandom(seed, x0, y0, salt)
+	v10 := positionRandom(seed, x0+1, y0, salt)
+	v01 := positionRandom(seed, x0, y0+1, salt)
+	v11 := positionRandom(seed, x0+1, y0+1, salt)
+
+	top := v00 + (v10-v00)*fx
+	bottom := v01 + (v11-v01)*fx
+	return (top+(bottom-top)*fy)*2 - 1
+}
+
+// fractalNoise sums octaves of value noise, each at twice the frequency and
+// half the weight of the last, normalised back to [-1, 1]
+func fractalNoise(seed int64, x, y float64, salt uint64, octaves int) float64 {
+	sum, weight, total := 0.0, 1.0, 0.0
+	for i := 0; i < octaves; i++ {
+		sum += valueNoise(seed, x, y, salt+uint64(i)) * weight
+		total += weight
+		x, y = x*2, y*2
+		weight /= 2
+	}
+	return sum / total
+}
+
+// caveNoise samples fractal noise for carving caves. Worlds from before
+// gradient noise keep the value noise their caves were carved with.
+func (w *World) caveNoise(x, y float64, salt uint64, octaves int) float64 {
+	if w.GeneratorVersion < 5 {
+		return fractalNoise(w.Seed, x, y, salt, octaves)
+	}
+	offset := float64(salt % 4096) // Separate fields for tunnels and caverns
+	return w.noiseGenerator.FBM2D(x+offset, y-offset, octaves, 2, 0.5)
+}
+
+// bedrockTop returns the world Y where bedrock starts in a grid column. The
+// floor is ragged by up to two rows so it does not read as a ruled line.
+func (w *World) bedrockTop(col int) float64 {
+	rows := math.Floor(positionRandom(w.Seed, float64(col), 0, saltBedrock) * 3)
+	return w.Generation.BedrockLevel - rows*HexVSpacing
+}
+
+// isCave reports whether rock at a position is carved out by a cave. Winding
+// tunnels follow the zero line of one noise field and open caverns the peaks
+// of another; the biome's CaveDensity widens or narrows both.
+func (w *World) isCave(x, y, depth float64, props *biomes.BiomeProperties) bool {
+	if props == nil || props.CaveDensity <= 0 || depth < caveRoofDepth {
+		return false
+	}
+	if y >= w.Generation.BedrockLevel-3*HexVSpacing {
+		return false
+	}
+
+	// Half-width of tunnels and the level caverns open above, in noise units
+	tunnelWidth, cavernLevel := 0.09, 0.6
+	if w.GeneratorVersion >= 5 {
+		tunnelWidth, cavernLevel = 0.06, 0.68
+	}
+
+	tunnel := w.caveNoise(x/900, y/500, saltCaveTunnel, 2)
+	if math.Abs(tunnel) < tunnelWidth*props.CaveDensity {
+		return true
+	}
+
+	cavern := w.caveNoise(x/520, y/300, saltCavern, 2)
+	return cavern > cavernLevel-0.15*props.CaveDensity
+}
+
+// lakeLiquid returns the liquid a carved cave cell is flooded with, or AIR
+// when it stays open. Lakes fill the bottom of a region's caves from a
+// per-region level down, but only where the column below holds the liquid up
+// within the region, so lakes do not hang over open caves.
+func (w *World) lakeLiquid(col, row int, depth float64, props *biomes.BiomeProperties) blocks.BlockType {
+	if props.LakeChance <= 0 {
+		return blocks.AIR
+	}
+	rx, ry := floorDiv(col, lakeRegionCols), floorDiv(row, lakeRegionRows)
+	if positionRandom(w.Seed, float64(rx), float64(ry), saltLake) >= props.LakeChance {
+		return blocks.AIR
+	}
+	level := ry*lakeRegionRows + 2 + int(positionRandom(w.Seed, float64(rx), float64(ry), saltLake+1)*3)
+	if row < level {
+		return blocks.AIR
+	}
+
+	bottom := (ry + 1) * lakeRegionRows
+	for r := row + 1; ; r++ {
+		if r >= bottom {
+			return blocks.AIR // Would drain into the region below
+		}
+		x, y := CellCenter(col, r)
+		_, below := w.terrainAt(x, y)
+		if !w.isCave(x, y, below, props) {
+			break
+		}
+	}
+
+	if depth > lavaLakeDepth {
+		return blocks.LAVA
+	}
+	if liquid, ok := blocks.BlockTypeMap[props.LakeLiquid]; ok && blocks.IsLiquidBlock(liquid) {
+		return liquid
+	}
+	return blocks.WATER
+}
+
+// oreVeinAt returns the ore of the first vein of a biome covering a cell, or
+// false when the cell is plain stone. Each band is split into patches a few
+// veins wide; a patch may hold one roughly round vein, which can reach into
+// neighbouring patches.
+func (w *World) oreVeinAt(col, row int, depth float64, props *biomes.BiomeProperties) (blocks.BlockType, bool) {
+	veins := props.OreVeins
+	if veins == nil {
+		veins = biomes.DefaultOreVeins
+	}
+	frequency := props.OreFrequency * w.Generation.OreMultiplier
+	x, y := CellCenter(col, row)
+
+	for i, vein := range veins {
+		if depth < vein.MinDepth || depth > vein.MaxDepth || vein.Size <= 0 {
+			continue
+		}
+		blockType, ok := blocks.BlockTypeMap[vein.Block]
+		if !ok {
+			continue
+		}
+
+		salt := saltOreVein + uint64(i)<<32
+		patch := vein.Size * 4
+		px, py := floorDiv(col, patch), floorDiv(row, patch)
+		for dy := -1; dy <= 1; dy++ {
+			for dx := -1; dx <= 1; dx++ {
+				cx, cy := float64(px+dx), float64(py+dy)
+				if positionRandom(w.Seed, cx, cy, salt) >= vein.Chance*frequency {
+					continue
+				}
+				centerCol := (px+dx)*patch + int(positionRandom(w.Seed, cx, cy, salt+1)*float64(patch))
+				centerRow := (py+dy)*patch + int(positionRandom(w.Seed, cx, cy, salt+2)*float64(patch))
+				radius := float64(vein.Size) * (0.6 + 0.8*positionRandom(w.Seed, cx, cy, salt+3)) * HexWidth
+
+				vx, vy := CellCenter(centerCol, centerRow)
+				dist := math.Hypot(x-vx, y-vy)
+				// Ragged edges rather than perfect discs
+				dist *= 0.75 + 0.5*positionRandom(w.Seed, float64(col), float64(row), salt+4)
+				if dist <= radius {
+					return blockType, true
+				}
+			}
+		}
+	}
+	return 0, false
+}
+
+// undergroundBlock returns the block below the subsurface layer: bedrock at
+// the world floor, cave air or lake liquid, ore veins, or stone
+func (w *World) undergroundBlock(col, row int, x, y, depth float64, biomeType biomes.BiomeType) blocks.BlockType {
+	if y >= w.bedrockTop(col) {
+		return blocks.BEDROCK
+	}
+
+	props := biomes.Definition(biomeType)
+	if props == nil {
+		return blocks.STONE
+	}
+	if w.isCave(x, y, depth, props) {
+		return w.lakeLiquid(col, row, depth, props)
+	}
+	if ore, ok := w.oreVeinAt(col, row, depth, props); ok {
+		return ore
+	}
+	return blocks.STONE
+}
@@ -0,0 +1,270 @@
+package world
+
+import (
+	"sort"
+
+	"tesselbox/pkg/blocks"
+)
+
+const (
+	// gravityTickDelay is the seconds an unsupported gravity block hangs
+	// before it starts to fall
+	gravityTickDelay = 0.05
+	// fallingBlockGravity is the acceleration of falling blocks in pixels/s²
+	fallingBlockGravity = 1200.0
+	// fallingBlockMaxSpeed is the terminal speed of falling blocks in pixels/s
+	fallingBlockMaxSpeed = 900.0
+	// maxScheduledTicksPerUpdate bounds the scheduled ticks run per update;
+	// the rest run on later updates
+	maxScheduledTicksPerUpdate = 512
+)
+
+// BlockBehavior is how a block type reacts to world updates. Hooks should
+// change blocks through the world so that neighbours are notified in turn;
+// reacting to a change by scheduling a tick rather than changing blocks
+// straight away keeps chains of updates spread over several updates.
+type BlockBehavior struct {
+	// NeighborChanged is called when the block itself or one of its six
+	// neighbours is placed or removed
+	NeighborChanged func(w *World, col, row int)
+	// ScheduledTick is called when a tick scheduled with ScheduleTick is due
+	ScheduledTick func(w *World, col, row int)
+}
+
+// blockBehaviors holds behaviours registered for specific block types
+var blockBehaviors = make(map[blocks.BlockType]BlockBehavior)
+
+// RegisterBlockBehavior sets the behaviour of a block type, replacing the
+// built-in liquid or gravity behaviour it would otherwise have
+func RegisterBlockBehavior(blockType blocks.BlockType, behavior BlockBehavior) {
+	blockBehaviors[blockType] = behavior
+}
+
+// behaviorFor returns the behaviour of a block type. Without a registered
+// behaviour, liquids wake the liquid simulation and blocks with Gravity fall
+// when nothing holds them up.
+func behaviorFor(blockType blocks.BlockType) (BlockBehavior, bool) {
+	if behavior, ok := blockBehaviors[blockType]; ok {
+		return behavior, true
+	}
+	if blocks.IsLiquidBlock(blockType) {
+		return BlockBehavior{NeighborChanged: (*World).activateLiquid}, true
+	}
+	if def := blocks.BlockDefinitions[getBlockKey(blockType)]; def != nil && def.Gravity {
+		return BlockBehavior{
+			NeighborChanged: (*World).scheduleGravityTick,
+			ScheduledTick:   (*World).tickGravity,
+		}, true
+	}
+	return BlockBehavior{}, false
+}
+
+// activateLiquid queues a liquid cell for the next liquid tick
+func (w *World) activateLiquid(col, row int) {
+	w.liquidActive[[2]int{col, row}] = true
+}
+
+// scheduleGravityTick checks a gravity block for support shortly
+func (w *World) scheduleGravityTick(col, row int) {
+	w.ScheduleTick(col, row, gravityTickDelay)
+}
+
+// FallingBlock is a gravity block dropping through open space. It belongs to
+// no chunk until it lands and is placed back into the world.
+type FallingBlock struct {
+	BlockType blocks.BlockType
+	X, Y      float64
+	VY        float64
+
+	col, row int // Cell the block is falling out of
+}
+
+// ScheduleTick asks for the block in a cell to get a scheduled tick after
+// delay seconds. A cell holds at most one pending tick; the earlier one wins.
+func (w *World) ScheduleTick(col, row int, delay float64) {
+	cell := [2]int{col, row}
+	due := w.blockTime + delay
+	if existing, ok := w.scheduledTicks[cell]; ok && existing <= due {
+		return
+	}
+	w.scheduledTicks[cell] = due
+}
+
+// notifyBlockChange tells the block at a changed position, and the blocks
+// around it, that their surroundings changed
+func (w *World) notifyBlockChange(x, y float64) {
+	col, row := CellAt(x, y)
+	w.notifyCell(col, row)
+	for _, n := range CellNeighbors(col, row) {
+		w.notifyCell(n[0], n[1])
+	}
+	w.Paths.invalidateCell(col, row)
+}
+
+// notifyCell calls the NeighborChanged hook of the block in a cell
+func (w *World) notifyCell(col, row int) {
+	cell := w.hexagonAtCell(col, row)
+	if cell.hex == nil {
+		return
+	}
+	if behavior, ok := behaviorFor(cell.hex.BlockType); ok && behavior.NeighborChanged != nil {
+		behavior.NeighborChanged(w, col, row)
+	}
+}
+
+// UpdateBlocks runs the scheduled block ticks that are due and moves falling
+// blocks. Ticks run in order of due time, then top to bottom, so results do
+// not depend on map iteration order.
+func (w *World) UpdateBlocks(deltaTime float64) {
+	w.blockTime += deltaTime
+
+	due := make([][2]int, 0)
+	for cell, at := range w.scheduledTicks {
+		if at <= w.blockTime {
+			due = append(due, cell)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		a, b := w.scheduledTicks[due[i]], w.scheduledTicks[due[j]]
+		if a != b {
+			return a < b
+		}
+		if due[i][1] != due[j][1] {
+			return due[i][1] < due[j][1]
+		}
+		return due[i][0] < due[j][0]
+	})
+	if len(due) > maxScheduledTicksPerUpdate {
+		due = due[:maxScheduledTicksPerUpdate]
+	}
+
+	for _, cell := range due {
+		delete(w.scheduledTicks, cell)
+		current := w.hexagonAtCell(cell[0], cell[1])
+		if current.hex == nil {
+			continue
+		}
+		if behavior, ok := behaviorFor(current.hex.BlockType); ok && behavior.ScheduledTick != nil {
+			behavior.ScheduledTick(w, cell[0], cell[1])
+		}
+	}
+
+	w.updateFallingBlocks(deltaTime)
+}
+
+// canFallInto reports whether a falling block may pass through a cell
+func (w *World) canFallInto(col, row int) bool {
+	cell := w.hexagonAtCell(col, row)
+	return cell.empty() || (cell.hex != nil && blocks.IsLiquidBlock(cell.hex.BlockType))
+}
+
+// tickGravity turns an unsupported gravity block into a falling block. Blocks
+// fall down the zigzag column of cells (col, row+1), the same path falling
+// liquid takes.
+func (w *World) tickGravity(col, row int) {
+	cell := w.hexagonAtCell(col, row)
+	if cell.hex == nil || !w.canFallInto(col, row+1) {
+		return
+	}
+
+	blockType := cell.hex.BlockType
+	x, y := cell.hex.X, cell.hex.Y
+	w.removeHexagon(cell.hex)
+	if w.OnBlockChange != nil {
+		w.OnBlockChange(x, y, blocks.AIR)
+	}
+
+	w.FallingBlocks = append(w.FallingBlocks, &FallingBlock{
+		BlockType: blockType,
+		X:         x,
+		Y:         y,
+		col:       col,
+		row:       row,
+	})
+}
+
+// updateFallingBlocks moves falling blocks and places the ones that landed
+func (w *World) updateFallingBlocks(deltaTime float64) {
+	falling := w.FallingBlocks[:0]
+	for _, fb := range w.FallingBlocks {
+		fb.VY += fallingBlockGravity * deltaTime
+		if fb.VY > fallingBlockMaxSpeed {
+			fb.VY = fallingBlockMaxSpeed
+		}
+		fb.Y += fb.VY * deltaTime
+
+		landed := false
+		for {
+			_, fromY := CellCenter(fb.col, fb.row)
+			if !w.canFallInto(fb.col, fb.row+1) {
+				if fb.Y >= fromY {
+					landed = true
+				}
+				break
+			}
+
+			toX, toY := CellCenter(fb.col, fb.row+1)
+			if fb.Y < toY {
+				// Slide across to the next cell's column while passing it
+				fromX, _ := CellCenter(fb.col, fb.row)
+				fb.X = fromX + (toX-fromX)*(fb.Y-fromY)/(toY-fromY)
+				break
+			}
+			fb.row++
+		}
+
+		if landed {
+			w.landFallingBlock(fb)
+			continue
+		}
+		falling = append(falling, fb)
+	}
+	w.FallingBlocks = falling
+}
+
+// landFallingBlock places a falling block in the cell it stopped in. If
+// something was built there in the meantime the block breaks instead, and
+// TakeBrokenFallingBlocks hands it to the game to drop as an item.
+func (w *World) landFallingBlock(fb *FallingBlock) {
+	if !w.canFallInto(fb.col, fb.row) {
+		w.brokenFallingBlocks = append(w.brokenFallingBlocks, fb)
+		return
+	}
+
+	if cell := w.hexagonAtCell(fb.col, fb.row); cell.hex != nil {
+		w.removeHexagon(cell.hex) // Displaced liquid
+	}
+	x, y := CellCenter(fb.col, fb.row)
+	w.AddHexagonAt(x, y, fb.BlockType)
+	if w.OnBlockChange != nil {
+		w.OnBlockChange(x, y, fb.BlockType)
+	}
+}
+
+// landFallingBlocksIn drops the falling blocks over the given chunks
+// straight to where they would land, so chunks about to be unloaded are
+// saved with them rather than losing them in mid-air
+func (w *World) landFallingBlocksIn(chunks map[[2]int]bool) {
+	falling := w.FallingBlocks[:0]
+	for _, fb := range w.FallingBlocks {
+		chunkX, chunkY := w.GetChunkCoords(CellCenter(fb.col, fb.row))
+		if !chunks[[2]int{chunkX, chunkY}] {
+			falling = append(falling, fb)
+			continue
+		}
+		for w.canFallInto(fb.col, fb.row+1) {
+			fb.row++
+		}
+		fb.X, fb.Y = CellCenter(fb.col, fb.row)
+		w.landFallingBlock(fb)
+	}
+	w.FallingBlocks = falling
+}
+
+// TakeBrokenFallingBlocks returns the falling blocks that broke on landing
+// since the last call, so games can drop them as items
+func (w *World) TakeBrokenFallingBlocks() []*FallingBlock {
+	broken := w.brokenFallingBlocks
+	w.brokenFallingBlocks = nil
+	return broken
+}
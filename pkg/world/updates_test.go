@@ -0,0 +1,75 @@
+package world
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"tesselbox/pkg/blocks"
+)
+
+// newFallingTestWorld returns a world with one empty chunk loaded
+func newFallingTestWorld(storage *WorldStorage) *World {
+	if len(blocks.BlockDefinitions) == 0 {
+		blocks.LoadBlocks()
+	}
+	w := newWorld("updates_test", generationSeed, DefaultGenerationParams())
+	w.Storage = storage
+	w.Chunks[[2]int{0, 0}] = NewChunk(0, 0)
+	return w
+}
+
+func TestFallingBlockBreaksOnTakenCell(t *testing.T) {
+	w := newFallingTestWorld(nil)
+	col, row := ChunkSize/2, ChunkSize/2
+	x, y := CellCenter(col, row)
+	w.AddHexagonAt(x, y, blocks.STONE)
+
+	// Something was built where the sand was about to land
+	fb := &FallingBlock{BlockType: blocks.SAND, X: x, Y: y, col: col, row: row}
+	w.landFallingBlock(fb)
+	if hex := w.GetHexagonAt(x, y); hex == nil || hex.BlockType != blocks.STONE {
+		t.Errorf("block at the landing cell = %v, want the stone", hex)
+	}
+	if broken := w.TakeBrokenFallingBlocks(); len(broken) != 1 || broken[0] != fb {
+		t.Fatalf("broken falling blocks = %v, want the sand", broken)
+	}
+	if broken := w.TakeBrokenFallingBlocks(); len(broken) != 0 {
+		t.Errorf("broken falling blocks taken twice: %v", broken)
+	}
+}
+
+func TestFallingBlocksLandBeforeUnload(t *testing.T) {
+	storage := NewWorldStorage(fmt.Sprintf("updates_test_%d", time.Now().UnixNano()))
+	t.Cleanup(func() { os.RemoveAll(storage.WorldDir) })
+	w := newFallingTestWorld(storage)
+
+	// Sand in mid-air, far above the floor it falls to
+	col, floorRow := 4, ChunkSize-2
+	floorX, floorY := CellCenter(col, floorRow)
+	w.AddHexagonAt(floorX, floorY, blocks.STONE)
+	w.FallingBlocks = append(w.FallingBlocks, &FallingBlock{BlockType: blocks.SAND, col: col, row: 1})
+
+	w.UnloadAllChunks()
+	if len(w.FallingBlocks) != 0 {
+		t.Fatalf("%d blocks still falling after unloading", len(w.FallingBlocks))
+	}
+
+	chunk, err := storage.LoadChunk(0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sand := 0
+	for _, hex := range chunk.Hexagons {
+		if hex.BlockType == blocks.SAND {
+			sand++
+			if _, row := CellAt(hex.X, hex.Y); row != floorRow-1 {
+				t.Errorf("sand saved in row %d, want %d on the floor", row, floorRow-1)
+			}
+		}
+	}
+	if sand != 1 {
+		t.Errorf("saved chunk holds %d sand blocks, want 1", sand)
+	}
+}
@@ -4,15 +4,18 @@ import (
 	"fmt"
 	"math"
 	"math/rand"
+	"os"
+	"path/filepath"
 	"sync"
 	"time"
 
 	"tesselbox/pkg/biomes"
 	"tesselbox/pkg/blocks"
-	"tesselbox/pkg/creatures"
 	"tesselbox/pkg/gametime"
 	"tesselbox/pkg/hexagon"
+	"tesselbox/pkg/mobs"
 	"tesselbox/pkg/organisms"
+	"tesselbox/pkg/station"
 )
 
 const (
@@ -32,10 +35,15 @@ type World struct {
 	Chunks    map[[2]int]*Chunk
 	Seed      int64
 	Organisms []*organisms.Organism
-	Creatures []*creatures.Creature
+	Creatures []*mobs.Mob
 	Storage   *WorldStorage
 	WorldName string
 
+	// Generation settings persisted in world metadata
+	CreatedAt        time.Time
+	GeneratorVersion int
+	Generation       GenerationParams
+
 	// Spatial hash for optimized collision detection
 	spatialHash map[[2]int][]*Hexagon // Cell coordinates -> list of hexagons
 
@@ -45,26 +53,65 @@ type World struct {
 	// Loading state to prevent deadlocks
 	loadingChunks map[[2]int]bool // Fixed: Track chunks being loaded
 	loadingMutex  sync.Mutex      // Fixed: Protect loading state
+
+	// Liquid simulation: grid cells that may change on the next liquid tick,
+	// and the time accumulated towards each liquid's next tick
+	liquidActive map[[2]int]bool
+	liquidTimers map[blocks.LiquidType]float64
+
+	// Block updates: seconds of simulated time, the time each cell's pending
+	// scheduled tick is due, gravity blocks currently in the air, and those
+	// that broke on landing since the last TakeBrokenFallingBlocks
+	blockTime           float64
+	scheduledTicks      map[[2]int]float64
+	FallingBlocks       []*FallingBlock
+	brokenFallingBlocks []*FallingBlock
+
+	// Structures: planned pieces by structure set and region, and pieces in
+	// chunks loaded since the last TakeGeneratedStructures
+	structurePlans      map[structureKey][]*structurePiece
+	generatedStructures []*Structure
+
+	// Paths plans mob routes through the world's terrain
+	Paths *Pathfinder
+
+	// OnBlockChange is called when the world changes a block by itself, such
+	// as flowing liquid, so servers can relay changes no player made. It is
+	// also called when only the level of a liquid changes.
+	OnBlockChange func(x, y float64, blockType blocks.BlockType)
+
+	// Stations of removed blocks since the last TakeRemovedStations
+	removedStations []*station.Station
 }
 
 // NewWorld creates a new world
 func NewWorld(worldName string) *World {
-	seed := time.Now().UnixNano()
-	rand.Seed(seed)
+	return newWorld(worldName, time.Now().UnixNano(), DefaultGenerationParams())
+}
 
+// newWorld creates an empty world with the given seed and generation parameters
+func newWorld(worldName string, seed int64, params GenerationParams) *World {
 	world := &World{
-		Chunks:        make(map[[2]int]*Chunk),
-		Seed:          seed,
-		Organisms:     []*organisms.Organism{},
-		Creatures:     []*creatures.Creature{},
-		Storage:       NewWorldStorage(worldName),
-		WorldName:     worldName,
-		spatialHash:   make(map[[2]int][]*Hexagon),
-		loadingChunks: make(map[[2]int]bool),
+		Chunks:           make(map[[2]int]*Chunk),
+		Seed:             seed,
+		Organisms:        []*organisms.Organism{},
+		Creatures:        []*mobs.Mob{},
+		Storage:          NewWorldStorage(worldName),
+		WorldName:        worldName,
+		CreatedAt:        time.Now(),
+		GeneratorVersion: GeneratorVersion,
+		Generation:       params,
+		spatialHash:      make(map[[2]int][]*Hexagon),
+		loadingChunks:    make(map[[2]int]bool),
+		liquidActive:     make(map[[2]int]bool),
+		liquidTimers:     make(map[blocks.LiquidType]float64),
+		scheduledTicks:   make(map[[2]int]float64),
+		structurePlans:   make(map[structureKey][]*structurePiece),
 	}
 
 	// Initialize noise generator for terrain generation
-	world.noiseGenerator = biomes.NewSimplexNoise(float64(seed))
+	world.noiseGenerator = world.newNoise()
+	world.Paths = NewPathfinder(world)
 
 	return world
 }
@@ -72,12 +119,27 @@ func NewWorld(worldName string) *World {
 // SetSeed sets the world seed and regenerates the noise generator
 func (w *World) SetSeed(seed int64) {
 	w.Seed = seed
-	rand.Seed(seed)
-	w.noiseGenerator = biomes.NewSimplexNoise(float64(seed))
+	w.noiseGenerator = w.newNoise()
 
-	// Clear existing chunks to force regeneration with new seed
+	// Clear existing chunks and the organisms they spawned to force regeneration with new seed
 	w.Chunks = make(map[[2]int]*Chunk)
+	w.Organisms = []*organisms.Organism{}
 	w.spatialHash = make(map[[2]int][]*Hexagon)
+	w.liquidActive = make(map[[2]int]bool)
+	w.scheduledTicks = make(map[[2]int]float64)
+	w.FallingBlocks = nil
+	w.brokenFallingBlocks = nil
+	w.structurePlans = make(map[structureKey][]*structurePiece)
+	w.generatedStructures = nil
+}
+
+// newNoise creates the noise generator for the world's seed. Worlds from
+// before gradient noise keep the sine noise their terrain was shaped by.
+func (w *World) newNoise() *biomes.SimplexNoise {
+	if w.GeneratorVersion < 5 {
+		return biomes.NewSineNoise(float64(w.Seed))
+	}
+	return biomes.NewSimplexNoise(float64(w.Seed))
 }
 
 // GetSeed returns the current world seed
@@ -96,27 +158,44 @@ func (w *World) ValidateSeed(seed int64) bool {
 	return seed != 0 // Avoid zero seed for better randomness
 }
 
-// NewWorldFromStorage creates a world and loads it from storage
+// NewWorldFromStorage creates a world and loads its seed and generation
+// settings from storage. A world without saved metadata is created fresh.
 func NewWorldFromStorage(worldName string) (*World, error) {
-	world := &World{
-		Chunks:    make(map[[2]int]*Chunk),
-		Organisms: []*organisms.Organism{},
-		Storage:   NewWorldStorage(worldName),
-		WorldName: worldName,
-	}
+	storage := NewWorldStorage(worldName)
 
-	// Load metadata to get seed
-	metadata, err := world.Storage.GetWorldMetadata()
+	metadata, err := storage.GetWorldMetadata()
 	if err != nil {
 		return nil, fmt.Errorf("failed to load world metadata: %w", err)
 	}
 
-	world.Seed = metadata.CreatedAt.UnixNano()
-	rand.Seed(world.Seed)
+	seed := metadata.Seed
+	if seed == 0 {
+		// Metadata written before seeds were persisted
+		seed = metadata.CreatedAt.UnixNano()
+	}
+
+	world := newWorld(worldName, seed, metadata.Generation.withDefaults())
+	world.Storage = storage
+	world.CreatedAt = metadata.CreatedAt
+	world.GeneratorVersion = metadata.GeneratorVersion
+	if world.GeneratorVersion == 0 {
+		world.GeneratorVersion = 1
+	}
+	if world.GeneratorVersion > GeneratorVersion {
+		return nil, fmt.Errorf("world %s was generated by a newer generator (version %d, supported %d)",
+			worldName, world.GeneratorVersion, GeneratorVersion)
+	}
+	world.noiseGenerator = world.newNoise()
 
 	return world, nil
 }
 
+// WorldExists reports whether a world has saved metadata on disk
+func WorldExists(worldName string) bool {
+	_, err := os.Stat(filepath.Join(NewWorldStorage(worldName).WorldDir, "metadata.json"))
+	return err == nil
+}
+
 // GetChunkCoords returns the chunk coordinates for a given world position
 func (w *World) GetChunkCoords(x, y float64) (int, int) {
 	chunkX := int(math.Floor(x / GetChunkWidth()))
@@ -197,8 +276,13 @@ func (w *World) GetChunk(chunkX, chunkY int) *Chunk {
 	w.loadingChunks[key] = true
 	w.loadingMutex.Unlock()
 
-	// Try to load from storage first
-	loadedChunk, err := w.Storage.LoadChunk(chunkX, chunkY)
+	// Try to load from storage first; remote worlds have none and generate
+	// placeholders until the server sends the real chunk
+	var loadedChunk *Chunk
+	var err error
+	if w.Storage != nil {
+		loadedChunk, err = w.Storage.LoadChunk(chunkX, chunkY)
+	}
 	if err != nil {
 		// If loading fails, generate new chunk
 		chunk = NewChunk(chunkX, chunkY)
@@ -217,6 +301,12 @@ func (w *World) GetChunk(chunkX, chunkY int) *Chunk {
 	for _, hex := range chunk.Hexagons {
 		w.addHexagonToSpatialHash(hex)
 	}
+	w.lightChunk(chunk)
+	w.activateChunkLiquids(chunk)
+	w.Paths.invalidateChunk(key)
+	if w.GeneratorVersion >= 3 {
+		w.reportStructures(chunk)
+	}
 
 	// Mark loading as complete
 	w.loadingMutex.Lock()
@@ -227,151 +317,175 @@ func (w *World) GetChunk(chunkX, chunkY int) *Chunk {
 	return chunk
 }
 
-// generateChunk generates terrain for a chunk with biome integration
-func (w *World) generateChunk(chunk *Chunk) {
-	worldX, worldY := chunk.GetWorldPosition()
+// terrainSampleY returns the y at which terrain noise is sampled for a
+// position. Sine noise barely changes with y, so older worlds sampled it at
+// the position itself; gradient noise does, so biomes and the surface are
+// sampled per column to keep the surface a single height field.
+func (w *World) terrainSampleY(y float64) float64 {
+	if w.GeneratorVersion >= 5 {
+		return 0
+	}
+	return y
+}
 
-	// Use cached noise generator for better performance
+// BiomeAt returns the biome at a world position
+func (w *World) BiomeAt(x, y float64) biomes.BiomeType {
+	return w.biomeAt(x, w.terrainSampleY(y))
+}
+
+// biomeAt returns the biome at a terrain sample position. Worlds from before
+// generator version 7 place only the built-in biomes, so a plugin's biomes
+// cannot move the biomes their unsaved chunks regenerate with.
+func (w *World) biomeAt(x, sampleY float64) biomes.BiomeType {
+	if w.GeneratorVersion < 7 {
+		return biomes.GetBuiltinBiomeAtPosition(x, sampleY, w.noiseGenerator)
+	}
+	return biomes.GetBiomeAtPosition(x, sampleY, w.noiseGenerator)
+}
+
+// terrainAt returns the biome at a world position and how far below the
+// terrain surface the position lies; negative depths are above the surface
+func (w *World) terrainAt(x, y float64) (biomes.BiomeType, float64) {
 	noise := w.noiseGenerator
+	params := w.Generation
 
-	for row := 0; row < ChunkSize; row++ {
-		for col := 0; col < ChunkSize; col++ {
-			var x, y float64
+	// Get biome at this position
+	sampleY := w.terrainSampleY(y)
+	biomeType := w.biomeAt(x, sampleY)
 
-			// Calculate hexagon position with interlocking pattern
-			if row%2 == 0 {
-				x = worldX + float64(col)*HexWidth + HexWidth/2
-			} else {
-				x = worldX + float64(col)*HexWidth + HexWidth
-			}
-			y = worldY + float64(row)*HexVSpacing + HexSize
-
-			// Get biome at this position
-			biomeType := biomes.GetBiomeAtPosition(x, y, noise)
-			biomeProps := biomes.BiomeDefinitions[biomeType]
-
-			// Base terrain height varies by biome
-			var baseHeight float64
-			switch biomeType {
-			case biomes.OCEAN:
-				baseHeight = 550.0 // Lower for oceans
-			case biomes.CORAL_REEF:
-				baseHeight = 560.0 // Slightly lower for coral reefs
-			case biomes.MANGROVE:
-				baseHeight = 545.0 // Slightly above ocean
-			case biomes.DESERT:
-				baseHeight = 380.0 // Lower for deserts
-			case biomes.SAVANNA:
-				baseHeight = 390.0 // Slightly higher than plains
-			case biomes.MOUNTAINS:
-				baseHeight = 320.0 // Higher for mountains
-			case biomes.VOLCANIC:
-				baseHeight = 300.0 // Highest for volcanic regions
-			case biomes.SWAMP:
-				baseHeight = 420.0
-			case biomes.TAIGA:
-				baseHeight = 380.0 // Cooler climate
-			case biomes.TUNDRA:
-				baseHeight = 360.0 // Cold, flat terrain
-			case biomes.JUNGLE:
-				baseHeight = 400.0 // Tropical elevation
-			case biomes.ICE_FIELDS:
-				baseHeight = 340.0 // Ice cap elevation
-			default:
-				baseHeight = 400.0
-			}
+	// Base terrain height varies by biome
+	baseHeight, heightScale := 400.0, 0.0
+	if props := biomes.Definition(biomeType); props != nil {
+		baseHeight, heightScale = props.BaseHeight, props.HeightScale
+	}
 
-			// Enhanced multi-layer terrain noise for more realistic terrain
-			// Continental scale features (mountains, valleys)
-			continentalNoise := noise.Noise2D(x*0.001, y*0.001) * 200
+	// Enhanced multi-layer terrain noise for more realistic terrain
+	// Continental scale features (mountains, valleys)
+	continentalNoise := noise.Noise2D(x*0.001, sampleY*0.001) * params.ContinentalAmplitude
 
-			// Regional scale features (hills, ridges)
-			regionalNoise := noise.Noise2D(x*0.003, y*0.003) * 100
+	// Regional scale features (hills, ridges)
+	regionalNoise := noise.Noise2D(x*0.003, sampleY*0.003) * params.RegionalAmplitude
 
-			// Local scale features (small hills, dunes)
-			localNoise := noise.Noise2D(x*0.01, y*0.01) * 40
+	// Local scale features (small hills, dunes)
+	localNoise := noise.Noise2D(x*0.01, sampleY*0.01) * params.LocalAmplitude
 
-			// Detail scale features (small variations)
-			detailNoise := noise.Noise2D(x*0.05, y*0.05) * 8
+	// Detail scale features (small variations)
+	detailNoise := noise.Noise2D(x*0.05, sampleY*0.05) * params.DetailAmplitude
 
-			// River and valley cutting
-			riverNoise := noise.Noise2D(x*0.015, y*0.015) * 30
-			if riverNoise < -0.3 {
-				riverNoise *= 2.0 // Deepen valleys
-			}
+	// River and valley cutting
+	riverNoise := noise.Noise2D(x*0.015, sampleY*0.015) * params.RiverAmplitude
+	if riverNoise < -0.3 {
+		riverNoise *= 2.0 // Deepen valleys
+	}
 
-			// Combine all noise layers with biome-specific weighting
-			terrainNoise := continentalNoise + regionalNoise + localNoise + detailNoise + riverNoise
+	// Combine all noise layers with biome-specific weighting
+	terrainNoise := continentalNoise + regionalNoise + localNoise + detailNoise + riverNoise
+	if heightScale > 0 {
+		terrainNoise *= heightScale
+	}
 
-			// Combine all noise layers
-			surfaceY := baseHeight + terrainNoise
+	// Combine all noise layers
+	surfaceY := baseHeight + terrainNoise
 
-			// Determine block type based on depth and biome
-			depth := y - surfaceY
+	return biomeType, y - surfaceY
+}
+
+// surfaceDepth returns the depth a biome's surface block reaches
+func surfaceDepth(props *biomes.BiomeProperties) float64 {
+	if props == nil || props.SurfaceDepth <= 0 {
+		return biomes.DefaultSurfaceDepth
+	}
+	return props.SurfaceDepth
+}
+
+// subsurfaceDepth returns the depth a biome's under block reaches
+func subsurfaceDepth(props *biomes.BiomeProperties) float64 {
+	if props == nil || props.SubsurfaceDepth <= 0 {
+		return biomes.DefaultSubsurfaceDepth
+	}
+	return props.SubsurfaceDepth
+}
+
+// layerBlock returns a biome's surface or under block, falling back to grass
+// over dirt for biomes without one or with an unknown block name
+func layerBlock(props *biomes.BiomeProperties, surface bool) blocks.BlockType {
+	name, fallback := "", blocks.DIRT
+	if surface {
+		fallback = blocks.GRASS
+	}
+	if props != nil {
+		name = props.UnderBlock
+		if surface {
+			name = props.SurfaceBlock
+		}
+	}
+	if blockType, ok := blocks.BlockTypeMap[name]; ok {
+		return blockType
+	}
+	return fallback
+}
+
+// surfaceOrganism returns the organism a surface cell grows, or "" for none.
+// A biome's organisms take consecutive slices of spawnChance in order. Worlds
+// from before generator version 6 only ever grew the first.
+func (w *World) surfaceOrganism(props *biomes.BiomeProperties, spawnChance float64) string {
+	if props == nil {
+		return ""
+	}
+	table := props.Organisms
+	if w.GeneratorVersion < 6 && len(table) > 1 {
+		table = table[:1]
+	}
+	limit := 0.0
+	for _, entry := range table {
+		limit += entry.Chance
+		if spawnChance < limit {
+			return entry.Organism
+		}
+	}
+	return ""
+}
+
+// generateChunk generates terrain for a chunk with biome integration
+func (w *World) generateChunk(chunk *Chunk) {
+	// Use cached noise generator for better performance
+	noise := w.noiseGenerator
+	params := w.Generation
+
+	for row := 0; row < ChunkSize; row++ {
+		for col := 0; col < ChunkSize; col++ {
+			x, y := chunk.cellPosition(col, row)
+
+			// Get biome at this position and depth below its surface
+			biomeType, depth := w.terrainAt(x, y)
+			biomeProps := biomes.Definition(biomeType)
 
 			var blockType blocks.BlockType
 
 			if depth < -10 {
-				// Above surface - air (unless it's an ocean)
-				if biomeType == biomes.OCEAN && depth < 0 && depth > -60 {
-					blockType = blocks.AIR // Ocean areas are air for now
+				// Above surface - air, or still water up to sea level in oceans
+				if w.GeneratorVersion >= 2 && biomeProps != nil && biomeProps.Flooded && y >= params.SeaLevel {
+					blockType = blocks.WATER
 				} else {
 					blockType = blocks.AIR
 				}
-			} else if depth < 5 {
+			} else if depth < surfaceDepth(biomeProps) {
 				// Surface layer - determine by biome
-				switch biomeType {
-				case biomes.DESERT:
-					blockType = blocks.SAND
-				case biomes.OCEAN, biomes.CORAL_REEF:
-					blockType = blocks.SAND
-				case biomes.MANGROVE:
-					blockType = blocks.GRASS
-				case biomes.SWAMP:
-					blockType = blocks.GRASS
-				case biomes.MOUNTAINS, biomes.VOLCANIC:
-					blockType = blocks.STONE
-				case biomes.TAIGA:
-					blockType = blocks.GRASS
-				case biomes.TUNDRA:
-					blockType = blocks.SNOW
-				case biomes.JUNGLE:
-					blockType = blocks.GRASS
-				case biomes.SAVANNA:
-					blockType = blocks.GRASS
-				case biomes.ICE_FIELDS:
-					blockType = blocks.ICE
-				default:
-					blockType = blocks.GRASS
-				}
-			} else if depth < 15 {
+				blockType = layerBlock(biomeProps, true)
+			} else if depth < subsurfaceDepth(biomeProps) {
 				// Subsurface layer
-				switch biomeType {
-				case biomes.DESERT, biomes.OCEAN, biomes.CORAL_REEF:
-					blockType = blocks.SAND
-				case biomes.SWAMP, biomes.MANGROVE, biomes.JUNGLE, biomes.SAVANNA:
-					blockType = blocks.DIRT
-				case biomes.TAIGA:
-					blockType = blocks.DIRT
-				case biomes.TUNDRA:
-					blockType = blocks.SNOW
-				case biomes.ICE_FIELDS:
-					blockType = blocks.ICE
-				case biomes.MOUNTAINS, biomes.VOLCANIC:
-					blockType = blocks.STONE
-				default:
-					blockType = blocks.DIRT
-				}
+				blockType = layerBlock(biomeProps, false)
+			} else if w.GeneratorVersion >= 4 {
+				// Caves, lakes and ore veins down to the bedrock floor
+				blockType = w.undergroundBlock(chunk.ChunkX*ChunkSize+col, chunk.ChunkY*ChunkSize+row, x, y, depth, biomeType)
 			} else if depth < 200 {
 				// Stone layers with ore generation
 				// Use biome ore frequency modifier
-				oreFrequency := biomeProps.OreFrequency
+				oreFrequency := biomeProps.OreFrequency * params.OreMultiplier
 
 				// Enhanced ore generation with more variety
-				// Use position-based seed for consistent ore generation
-				posSeed := w.Seed + int64(x)*1000 + int64(y)
-				rand.Seed(posSeed)
-				oreChance := rand.Float64()
+				// Use position-based randomness for consistent ore generation
+				oreChance := positionRandom(w.Seed, x, y, saltOre)
 
 				// Add vein detection for more realistic ore deposits
 				veinNoise := noise.Noise2D(x*0.1, y*0.1)
@@ -418,110 +532,11 @@ func (w *World) generateChunk(chunk *Chunk) {
 
 			// Enhanced organism spawning for all biomes
 			if (blockType == blocks.GRASS || blockType == blocks.SAND || blockType == blocks.SNOW || blockType == blocks.ICE) && depth >= -2 && depth <= 2 {
-				// Use position-based seed for consistent organism spawning
-				posSeed := w.Seed + int64(x)*10000 + int64(y)*10000
-				rand.Seed(posSeed)
-				spawnChance := rand.Float64()
-
-				var orgType string
-				var spawnProbability float64
-
-				switch biomeType {
-				case biomes.FOREST:
-					if spawnChance < 0.15 {
-						orgType = "tree"
-						spawnProbability = 0.15
-					} else if spawnChance < 0.25 {
-						orgType = "bush"
-						spawnProbability = 0.10
-					} else if spawnChance < 0.35 {
-						orgType = "flower"
-						spawnProbability = 0.10
-					}
-				case biomes.JUNGLE:
-					if spawnChance < 0.25 {
-						orgType = "tree"
-						spawnProbability = 0.25
-					} else if spawnChance < 0.35 {
-						orgType = "bush"
-						spawnProbability = 0.10
-					}
-				case biomes.TAIGA:
-					if spawnChance < 0.12 {
-						orgType = "tree"
-						spawnProbability = 0.12
-					} else if spawnChance < 0.18 {
-						orgType = "bush"
-						spawnProbability = 0.06
-					}
-				case biomes.DESERT:
-					if spawnChance < 0.02 {
-						orgType = "cactus"
-						spawnProbability = 0.02
-					} else if spawnChance < 0.05 {
-						orgType = "dead_bush"
-						spawnProbability = 0.03
-					}
-				case biomes.SAVANNA:
-					if spawnChance < 0.08 {
-						orgType = "tree"
-						spawnProbability = 0.08
-					} else if spawnChance < 0.12 {
-						orgType = "bush"
-						spawnProbability = 0.04
-					}
-				case biomes.TUNDRA:
-					if spawnChance < 0.01 {
-						orgType = "ice_shrub"
-						spawnProbability = 0.01
-					}
-				case biomes.ICE_FIELDS:
-					if spawnChance < 0.005 {
-						orgType = "ice_spike"
-						spawnProbability = 0.005
-					}
-				case biomes.SWAMP:
-					if spawnChance < 0.08 {
-						orgType = "bush"
-						spawnProbability = 0.08
-					} else if spawnChance < 0.12 {
-						orgType = "flower"
-						spawnProbability = 0.04
-					}
-				case biomes.MANGROVE:
-					if spawnChance < 0.10 {
-						orgType = "mangrove_tree"
-						spawnProbability = 0.10
-					}
-				case biomes.CORAL_REEF:
-					if spawnChance < 0.05 {
-						orgType = "coral"
-						spawnProbability = 0.05
-					}
-				case biomes.MOUNTAINS:
-					if spawnChance < 0.03 {
-						orgType = "bush"
-						spawnProbability = 0.03
-					}
-				case biomes.VOLCANIC:
-					if spawnChance < 0.02 {
-						orgType = "lava_rock"
-						spawnProbability = 0.02
-					}
-				default: // PLAINS
-					if spawnChance < 0.05 {
-						orgType = "tree"
-						spawnProbability = 0.05
-					} else if spawnChance < 0.10 {
-						orgType = "bush"
-						spawnProbability = 0.05
-					} else if spawnChance < 0.15 {
-						orgType = "flower"
-						spawnProbability = 0.05
-					}
-				}
+				// Use position-based randomness for consistent organism spawning
+				spawnChance := positionRandom(w.Seed, x, y, saltOrganism) / params.OrganismMultiplier
 
-				if spawnChance < spawnProbability {
+				orgType := w.surfaceOrganism(biomeProps, spawnChance)
+				if orgType != "" {
 					// Convert pixel coordinates to hex coordinates
 					q, r := hexagon.PixelToHex(x, y, HexSize)
 					hexagonCoords := hexagon.HexRound(q, r)
@@ -543,6 +558,10 @@ func (w *World) generateChunk(chunk *Chunk) {
 		}
 	}
 
+	if w.GeneratorVersion >= 3 {
+		w.placeStructures(chunk)
+	}
+
 	chunk.Modified = false
 }
 
@@ -583,6 +602,38 @@ func (w *World) GetNearbyHexagons(x, y, radius float64) []*Hexagon {
 	return hexagons
 }
 
+// BoxCollides reports whether a box overlaps a solid hexagon, treating each
+// hexagon as its bounding square like player collision does
+func (w *World) BoxCollides(minX, minY, maxX, maxY float64) bool {
+	return w.boxOverlaps(minX, minY, maxX, maxY, func(hex *Hexagon) bool {
+		def := blocks.BlockDefinitions[getBlockKey(hex.BlockType)]
+		return def != nil && def.Solid
+	})
+}
+
+// BoxClimbable reports whether a box overlaps a ladder
+func (w *World) BoxClimbable(minX, minY, maxX, maxY float64) bool {
+	return w.boxOverlaps(minX, minY, maxX, maxY, func(hex *Hexagon) bool {
+		return hex.BlockType == blocks.LADDER
+	})
+}
+
+// boxOverlaps reports whether a box overlaps the bounding square of a
+// hexagon that matches
+func (w *World) boxOverlaps(minX, minY, maxX, maxY float64, match func(hex *Hexagon) bool) bool {
+	centerX, centerY := (minX+maxX)/2, (minY+maxY)/2
+	radius := math.Hypot(maxX-minX, maxY-minY)/2 + HexSize*2
+	for _, hex := range w.GetNearbyHexagons(centerX, centerY, radius) {
+		if !match(hex) {
+			continue
+		}
+		if !(maxX < hex.X-hex.Size || minX > hex.X+hex.Size || maxY < hex.Y-hex.Size || minY > hex.Y+hex.Size) {
+			return true
+		}
+	}
+	return false
+}
+
 // GetHexagonAt returns the hexagon at the given world position with tolerance
 func (w *World) GetHexagonAt(x, y float64) *Hexagon {
 	chunkX, chunkY := w.GetChunkCoords(x, y)
@@ -615,6 +666,40 @@ func (w *World) GetHexagonAt(x, y float64) *Hexagon {
 	return closestHex
 }
 
+// gridCell is the contents of one grid cell
+type gridCell struct {
+	hex    *Hexagon
+	loaded bool // False when the cell's chunk is not in memory
+}
+
+// empty reports whether the cell holds no block
+func (c gridCell) empty() bool {
+	return c.loaded && (c.hex == nil || c.hex.BlockType == blocks.AIR)
+}
+
+// holds reports whether the cell contains the given block type
+func (c gridCell) holds(blockType blocks.BlockType) bool {
+	return c.hex != nil && c.hex.BlockType == blockType
+}
+
+// hexagonAtCell returns the hexagon centred on a grid cell. Cells in chunks
+// that are not loaded report loaded=false, so neither liquid nor light
+// spreads into them.
+func (w *World) hexagonAtCell(col, row int) gridCell {
+	x, y := CellCenter(col, row)
+	chunkX, chunkY := w.GetChunkCoords(x, y)
+	if _, ok := w.Chunks[[2]int{chunkX, chunkY}]; !ok {
+		return gridCell{}
+	}
+
+	for _, hex := range w.spatialHash[w.getSpatialHashKey(x, y)] {
+		if hex != nil && hex.X > x-1 && hex.X < x+1 && hex.Y > y-1 && hex.Y < y+1 {
+			return gridCell{hex: hex, loaded: true}
+		}
+	}
+	return gridCell{loaded: true}
+}
+
 // AddHexagonAt adds a hexagon at the given world position
 func (w *World) AddHexagonAt(x, y float64, blockType blocks.BlockType) {
 	// Use the coordinates directly - don't convert to center
@@ -626,6 +711,8 @@ func (w *World) AddHexagonAt(x, y float64, blockType blocks.BlockType) {
 
 	// Add to spatial hash
 	w.addHexagonToSpatialHash(hexagon)
+	w.relightAt(x, y)
+	w.notifyBlockChange(x, y)
 }
 
 // RemoveHexagonAt removes the hexagon at the given world position
@@ -641,39 +728,53 @@ func (w *World) RemoveHexagonAt(x, y float64) bool {
 
 	// Remove from spatial hash first
 	w.removeHexagonFromSpatialHash(hexagon)
+	w.removeStation(hexagon)
 
 	// Then remove from chunk using direct coordinates
-	return chunk.RemoveHexagonDirect(x, y)
+	removed := chunk.RemoveHexagonDirect(x, y)
+	w.relightAt(x, y)
+	w.notifyBlockChange(x, y)
+	return removed
 }
 
 // UnloadDistantChunks unloads chunks that are far from the player
 func (w *World) UnloadDistantChunks(playerX, playerY float64) {
-	playerChunkX, playerChunkY := w.GetChunkCoords(playerX, playerY)
+	w.UnloadChunksFarFrom([][2]float64{{playerX, playerY}})
+}
+
+// UnloadChunksFarFrom unloads chunks that are far from every given position,
+// so a server keeps the areas around all of its players loaded
+func (w *World) UnloadChunksFarFrom(positions [][2]float64) {
+	anchors := make([][2]int, 0, len(positions))
+	for _, pos := range positions {
+		chunkX, chunkY := w.GetChunkCoords(pos[0], pos[1])
+		anchors = append(anchors, [2]int{chunkX, chunkY})
+	}
 	toDelete := [][2]int{}
 
 	for key, chunk := range w.Chunks {
-		dx := chunk.ChunkX - playerChunkX
-		dy := chunk.ChunkY - playerChunkY
-		distance := math.Sqrt(float64(dx*dx + dy*dy))
+		nearAnchor := false
+		for _, anchor := range anchors {
+			dx := chunk.ChunkX - anchor[0]
+			dy := chunk.ChunkY - anchor[1]
+			distance := math.Sqrt(float64(dx*dx + dy*dy))
+			if distance <= ChunkUnloadDistance {
+				nearAnchor = true
+				break
+			}
+		}
 
-		if distance > ChunkUnloadDistance {
+		if !nearAnchor {
 			toDelete = append(toDelete, key)
 		}
 	}
 
-	// Save and unload distant chunks
+	// Save modified chunks before unloading, rewriting each region once
+	w.saveBeforeUnload(toDelete)
+
 	for _, key := range toDelete {
 		chunk := w.Chunks[key]
 
-		// Save modified chunks before unloading
-		if chunk.Modified {
-			err := w.Storage.SaveChunk(chunk)
-			if err != nil {
-				// Log error but continue - don't prevent unloading due to save failure
-				fmt.Printf("Warning: Failed to save chunk %d,%d before unloading: %v\n", key[0], key[1], err)
-			}
-		}
-
 		// Remove all hexagons from this chunk from the spatial hash
 		for _, hex := range chunk.Hexagons {
 			w.removeHexagonFromSpatialHash(hex)
@@ -681,21 +782,41 @@ func (w *World) UnloadDistantChunks(playerX, playerY float64) {
 
 		// Remove chunk from memory
 		delete(w.Chunks, key)
+		w.Paths.invalidateChunk(key)
+	}
+}
+
+// saveBeforeUnload lands the blocks falling over chunks about to be
+// unloaded, then saves the modified ones
+func (w *World) saveBeforeUnload(keys [][2]int) {
+	unloading := make(map[[2]int]bool, len(keys))
+	for _, key := range keys {
+		unloading[key] = true
+	}
+	w.landFallingBlocksIn(unloading)
+
+	if w.Storage == nil {
+		return
+	}
+	chunks := make([]*Chunk, 0, len(keys))
+	for _, key := range keys {
+		chunks = append(chunks, w.Chunks[key])
+	}
+	if err := w.Storage.SaveChunks(chunks); err != nil {
+		// Log error but continue - don't prevent unloading due to save failure
+		fmt.Printf("Warning: Failed to save chunks before unloading: %v\n", err)
 	}
 }
 
 // UnloadAllChunks unloads all chunks from memory (used when leaving a dimension)
 func (w *World) UnloadAllChunks() {
-	// Save and unload all chunks
-	for key, chunk := range w.Chunks {
-		// Save modified chunks before unloading
-		if chunk.Modified {
-			err := w.Storage.SaveChunk(chunk)
-			if err != nil {
-				fmt.Printf("Warning: Failed to save chunk %d,%d before unloading: %v\n", key[0], key[1], err)
-			}
-		}
+	keys := make([][2]int, 0, len(w.Chunks))
+	for key := range w.Chunks {
+		keys = append(keys, key)
+	}
+	w.saveBeforeUnload(keys)
 
+	for key, chunk := range w.Chunks {
 		// Remove all hexagons from this chunk from the spatial hash
 		for _, hex := range chunk.Hexagons {
 			w.removeHexagonFromSpatialHash(hex)
@@ -703,6 +824,7 @@ func (w *World) UnloadAllChunks() {
 
 		// Remove chunk from memory
 		delete(w.Chunks, key)
+		w.Paths.invalidateChunk(key)
 	}
 }
 
@@ -835,6 +957,17 @@ func (w *World) GetOrganismAt(x, y, tolerance float64) *organisms.Organism {
 	return nil
 }
 
+// RemoveOrganism removes an organism from the world, such as once it has
+// been harvested
+func (w *World) RemoveOrganism(org *organisms.Organism) {
+	for i, o := range w.Organisms {
+		if o == org {
+			w.Organisms = append(w.Organisms[:i], w.Organisms[i+1:]...)
+			return
+		}
+	}
+}
+
 // SaveWorld saves the current world state to storage
 func (w *World) SaveWorld() error {
 	if w.Storage == nil {
@@ -847,7 +980,7 @@ func (w *World) SaveWorld() error {
 	}
 
 	// Save metadata
-	return w.Storage.SaveWorldMetadata(len(w.Chunks))
+	return w.Storage.SaveWorldMetadata(w)
 }
 
 // LoadWorldArea loads chunks around a specific position from storage
@@ -907,50 +1040,66 @@ func (w *World) SpawnCreatures(dayNightCycle *gametime.DayNightCycle, playerX, p
 			continue // Can't spawn in solid blocks
 		}
 
-		// Determine creature type based on biome and time
-		var creatureType creatures.CreatureType
-		if isNightTime {
-			// Night spawns
-			switch rand.Intn(3) {
-			case 0:
-				creatureType = creatures.SLIME
-			case 1:
-				creatureType = creatures.SPIDER
-			case 2:
-				creatureType = creatures.ZOMBIE
-			}
-		} else {
-			// Day spawns - only passive or less aggressive creatures
-			// For now, only spawn at night
+		// Determine creature type from the biome's spawn table for the time
+		mobType, ok := pickCreature(w.BiomeAt(spawnX, spawnY), isNightTime)
+		if !ok {
 			continue
 		}
 
 		// Create and add creature
-		// Convert pixel coordinates to hex coordinates
-		q, r := hexagon.PixelToHex(hex.X, hex.Y, HexSize)
-		hexagonCoords := hexagon.HexRound(q, r)
-		creatureHex, _ := hexagon.AxialToHex(hexagonCoords.Q, hexagonCoords.R)
-		creature := creatures.NewCreature(creatureType, spawnX, spawnY, creatureHex)
-		w.Creatures = append(w.Creatures, creature)
+		if creature, ok := mobs.Spawn(mobType, spawnX, spawnY); ok {
+			w.Creatures = append(w.Creatures, creature)
+		}
 	}
 }
 
-// UpdateCreatures updates all creatures in the world
-func (w *World) UpdateCreatures(playerX, playerY float64, deltaTime float64) {
-	// Create isBlocked function for creatures
-	isBlocked := func(x, y float64) bool {
-		hex := w.GetHexagonAt(x, y)
-		return hex != nil && hex.BlockType != blocks.AIR
+// pickCreature picks a mob type by weight from a biome's day or night spawn
+// table, or reports false when the table has no registered mob types
+func pickCreature(biomeType biomes.BiomeType, night bool) (string, bool) {
+	var table []biomes.CreatureChance
+	if props := biomes.Definition(biomeType); props != nil {
+		table = props.Creatures.Day
+		if night {
+			table = props.Creatures.Night
+		}
+	}
+	if night && table == nil {
+		table = biomes.DefaultNightCreatures
+	}
+
+	total := 0.0
+	for _, entry := range table {
+		if _, ok := mobs.GetType(entry.Creature); ok {
+			total += entry.Weight
+		}
+	}
+	if total <= 0 {
+		return "", false
 	}
+	pick := rand.Float64() * total
+	for _, entry := range table {
+		if _, ok := mobs.GetType(entry.Creature); !ok {
+			continue
+		}
+		pick -= entry.Weight
+		if pick < 0 {
+			return entry.Creature, true
+		}
+	}
+	return "", false
+}
 
+// UpdateCreatures updates all creatures in the world with what they can see
+// of it: who to chase, the light and terrain collision
+func (w *World) UpdateCreatures(ctx *mobs.Context, deltaTime float64) {
 	for _, creature := range w.Creatures {
-		creature.Update(playerX, playerY, deltaTime, isBlocked)
+		creature.Update(ctx, deltaTime)
 	}
 }
 
 // RemoveDeadCreatures removes creatures that have died
 func (w *World) RemoveDeadCreatures() {
-	var aliveCreatures []*creatures.Creature
+	var aliveCreatures []*mobs.Mob
 	for _, creature := range w.Creatures {
 		if creature.IsAlive() {
 			aliveCreatures = append(aliveCreatures, creature)
@@ -1026,13 +1175,10 @@ func (w *World) checkPositionForSpawn(x, y float64) (float64, float64) {
 }
 
 // GetCreaturesInArea returns creatures within a certain radius of a point
-func (w *World) GetCreaturesInArea(centerX, centerY, radius float64) []*creatures.Creature {
-	var nearbyCreatures []*creatures.Creature
+func (w *World) GetCreaturesInArea(centerX, centerY, radius float64) []*mobs.Mob {
+	var nearbyCreatures []*mobs.Mob
 	for _, creature := range w.Creatures {
-		dx := creature.X - centerX
-		dy := creature.Y - centerY
-		distance := math.Sqrt(dx*dx + dy*dy)
-		if distance <= radius {
+		if creature.DistanceTo(centerX, centerY) <= radius {
 			nearbyCreatures = append(nearbyCreatures, creature)
 		}
 	}